@@ -0,0 +1,57 @@
+package account
+
+import (
+	"context"
+	"errors"
+
+	"belajar-golang-fiber/middleware/adminauth"
+	"belajar-golang-fiber/middleware/rbac"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireAdmin answers 401 without a session and 403 unless the signed
+// in user holds the admin role.
+func RequireAdmin(users *user.Service) fiber.Handler {
+	return authorize(users, false)
+}
+
+// RequireSelfOrAdmin is RequireAdmin that also lets through the user
+// named by the :id parameter.
+func RequireSelfOrAdmin(users *user.Service) fiber.Handler {
+	return authorize(users, true)
+}
+
+func authorize(users *user.Service, self bool) fiber.Handler {
+	admin := rbac.Require(adminauth.RoleAdmin)
+	return func(ctx *fiber.Ctx) error {
+		userID := session.UserID(ctx)
+		if userID == "" {
+			return fiber.ErrUnauthorized
+		}
+		if self && ctx.Params("id") == userID {
+			return ctx.Next()
+		}
+		roles, err := userRoles(ctx.UserContext(), users, userID)
+		if err != nil {
+			return err
+		}
+		ctx.Locals(rbac.ConfigDefault.ContextKey, roles)
+		return admin(ctx)
+	}
+}
+
+// userRoles returns the roles of the user with the given id, none once
+// the user is gone.
+func userRoles(ctx context.Context, users *user.Service, id string) ([]string, error) {
+	found, err := users.Users.Get(ctx, id)
+	if errors.Is(err, user.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return found.Roles, nil
+}
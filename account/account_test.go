@@ -0,0 +1,251 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/captcha"
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/middleware/realip"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterHandler(t *testing.T) {
+	app := fiber.New()
+	handler := &Handler{Users: user.NewService(user.NewMemoryRepository(), "ID")}
+	handler.Register(app.Group("/api/v1"))
+
+	body := strings.NewReader(`{"username":"salman","password":"rahasia","phone":"+62 812 3456 7890"}`)
+	request := httptest.NewRequest("POST", "/api/v1/register", body)
+	request.Header.Set("Content-Type", "application/json")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+
+	bytes, _ := io.ReadAll(response.Body)
+	assert.Contains(t, string(bytes), `"phone":"+6281234567890"`)
+	assert.NotContains(t, string(bytes), "password")
+
+	body = strings.NewReader(`username=budi&password=rahasia&phone=123`)
+	request = httptest.NewRequest("POST", "/api/v1/register", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 422, response.StatusCode)
+}
+
+func TestLoginHandler(t *testing.T) {
+	service := user.NewService(user.NewMemoryRepository(), "ID")
+	_, err := service.Register(context.Background(), user.RegisterInput{Username: "salman", Password: "rahasia"})
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app.Use(sessions.Middleware())
+	handler := &Handler{Users: service, Sessions: sessions}
+	handler.Register(app.Group("/api/v1"))
+
+	body := strings.NewReader(`<LoginRequest><username>salman</username><password>rahasia</password></LoginRequest>`)
+	request := httptest.NewRequest("POST", "/api/v1/login", body)
+	request.Header.Set("Content-Type", "application/xml")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var login dto.LoginResponse
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&login))
+	assert.Equal(t, "salman", login.User.Username)
+	assert.NotEmpty(t, login.Token)
+
+	request = httptest.NewRequest("POST", "/api/v1/logout", nil)
+	request.Header.Set("Authorization", "Bearer "+login.Token)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 204, response.StatusCode)
+
+	request = httptest.NewRequest("POST", "/api/v1/logout", nil)
+	request.Header.Set("Authorization", "Bearer "+login.Token)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	body = strings.NewReader(`username=salman&password=salah`)
+	request = httptest.NewRequest("POST", "/api/v1/login", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+}
+
+func TestLockedAndPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	service := user.NewService(user.NewMemoryRepository(), "ID")
+	created, err := service.Register(ctx, user.RegisterInput{Username: "salman", Password: "rahasia"})
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app.Use(sessions.Middleware())
+	app.Use(RequirePasswordChange(service.Users, func(c *fiber.Ctx) bool {
+		return c.Path() == "/api/v1/logout"
+	}))
+	handler := &Handler{Users: service, Sessions: sessions}
+	handler.Register(app.Group("/api/v1"))
+	app.Get("/api/v1/orders", func(c *fiber.Ctx) error {
+		return c.SendString("orders")
+	})
+
+	login := func() (int, string) {
+		request := httptest.NewRequest("POST", "/api/v1/login", strings.NewReader(`{"username":"salman","password":"rahasia"}`))
+		request.Header.Set("Content-Type", "application/json")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		var body dto.LoginResponse
+		json.NewDecoder(response.Body).Decode(&body)
+		return response.StatusCode, body.Token
+	}
+
+	service.Lock(ctx, created.ID)
+	status, _ := login()
+	assert.Equal(t, 423, status)
+	service.Unlock(ctx, created.ID)
+
+	service.RequirePasswordReset(ctx, created.ID)
+	status, token := login()
+	assert.Equal(t, 200, status)
+	orders := func() int {
+		request := httptest.NewRequest("GET", "/api/v1/orders", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+	assert.Equal(t, 403, orders())
+
+	password := "rahasia2"
+	service.Update(ctx, created.ID, user.UpdateInput{Password: &password})
+	assert.Equal(t, 200, orders())
+}
+
+func TestRefreshAndLogoutAll(t *testing.T) {
+	ctx := context.Background()
+	service := user.NewService(user.NewMemoryRepository(), "ID")
+	created, err := service.Register(ctx, user.RegisterInput{Username: "salman", Password: "rahasia"})
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore(), Refresh: session.NewMemoryRefreshStore()})
+	app.Use(sessions.Middleware())
+	handler := &Handler{Users: service, Sessions: sessions}
+	handler.Register(app.Group("/api/v1"))
+
+	send := func(target, token, body string) (int, dto.LoginResponse) {
+		request := httptest.NewRequest("POST", target, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		var login dto.LoginResponse
+		json.NewDecoder(response.Body).Decode(&login)
+		return response.StatusCode, login
+	}
+	_, laptop := send("/api/v1/login", "", `{"username":"salman","password":"rahasia"}`)
+	assert.NotEmpty(t, laptop.RefreshToken)
+	assert.NotNil(t, laptop.RefreshExpiresAt)
+
+	status, rotated := send("/api/v1/refresh", "", `{"refresh_token":"`+laptop.RefreshToken+`"}`)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "salman", rotated.User.Username)
+	assert.NotEqual(t, laptop.Token, rotated.Token)
+	status, _ = send("/api/v1/refresh", "", `{"refresh_token":"`+laptop.RefreshToken+`"}`)
+	assert.Equal(t, 401, status)
+	status, _ = send("/api/v1/refresh", "", `{"refresh_token":"`+rotated.RefreshToken+`"}`)
+	assert.Equal(t, 401, status, "reuse revoked the family")
+
+	_, laptop = send("/api/v1/login", "", `{"username":"salman","password":"rahasia"}`)
+	_, phone := send("/api/v1/login", "", `{"username":"salman","password":"rahasia"}`)
+	status, _ = send("/api/v1/logout-all", laptop.Token, "")
+	assert.Equal(t, 204, status)
+	status, _ = send("/api/v1/logout", phone.Token, "")
+	assert.Equal(t, 401, status)
+	status, _ = send("/api/v1/refresh", "", `{"refresh_token":"`+phone.RefreshToken+`"}`)
+	assert.Equal(t, 401, status)
+
+	_, laptop = send("/api/v1/login", "", `{"username":"salman","password":"rahasia"}`)
+	service.Lock(ctx, created.ID)
+	status, _ = send("/api/v1/refresh", "", `{"refresh_token":"`+laptop.RefreshToken+`"}`)
+	assert.Equal(t, 401, status, "locked users get no new session")
+}
+
+func TestLoginRemember(t *testing.T) {
+	service := user.NewService(user.NewMemoryRepository(), "ID")
+	_, err := service.Register(context.Background(), user.RegisterInput{Username: "salman", Password: "rahasia"})
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore(), Remember: session.NewMemoryRefreshStore()})
+	app.Use(sessions.Middleware())
+	handler := &Handler{Users: service, Sessions: sessions}
+	handler.Register(app.Group("/api/v1"))
+
+	remembered := func(body string) bool {
+		request := httptest.NewRequest("POST", "/api/v1/login", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+		for _, cookie := range response.Cookies() {
+			if cookie.Name == "remember_me" {
+				return true
+			}
+		}
+		return false
+	}
+	assert.False(t, remembered(`{"username":"salman","password":"rahasia"}`))
+	assert.True(t, remembered(`{"username":"salman","password":"rahasia","remember":true}`))
+}
+
+func TestLoginCaptcha(t *testing.T) {
+	service := user.NewService(user.NewMemoryRepository(), "ID")
+	_, err := service.Register(context.Background(), user.RegisterInput{Username: "salman", Password: "rahasia"})
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	// Clients are told apart by the address their proxy forwards.
+	app.Use(realip.New(realip.Config{TrustedProxies: []string{"0.0.0.0"}}))
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	handler := &Handler{
+		Users:    service,
+		Sessions: sessions,
+		Throttle: captcha.NewThrottle(2, time.Minute),
+		Captcha:  captcha.Stub{Token: "solved"},
+	}
+	handler.Register(app.Group("/api/v1"))
+
+	login := func(ip, body string) int {
+		request := httptest.NewRequest("POST", "/api/v1/login", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Forwarded-For", ip)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+	assert.Equal(t, 401, login("203.0.113.7", `{"username":"salman","password":"salah"}`))
+	assert.Equal(t, 401, login("203.0.113.7", `{"username":"Salman","password":"salah"}`))
+	assert.Equal(t, 428, login("203.0.113.7", `{"username":"salman","password":"rahasia"}`), "the right password needs a CAPTCHA too")
+	assert.Equal(t, 428, login("203.0.113.7", `{"username":"salman","password":"rahasia","captcha":"guess"}`))
+	assert.Equal(t, 200, login("203.0.113.7", `{"username":"salman","password":"rahasia","captcha":"solved"}`))
+	assert.Equal(t, 428, login("203.0.113.7", `{"username":"budi","password":"rahasia"}`), "the address failed too often as well")
+	assert.Equal(t, 401, login("198.51.100.9", `{"username":"budi","password":"rahasia"}`), "other clients behind the proxy are not")
+}
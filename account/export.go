@@ -0,0 +1,99 @@
+package account
+
+import (
+	"bufio"
+	"context"
+
+	"belajar-golang-fiber/logger"
+	"belajar-golang-fiber/mapping"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const (
+	mimeNDJSON = "application/x-ndjson"
+
+	// exportBatch is how many users are read from the repository at a
+	// time while streaming an export.
+	exportBatch = 500
+)
+
+// export streams every user as NDJSON (default, or Accept:
+// application/x-ndjson) or, with ?format=json, as one JSON array. Users
+// are read and written a batch at a time, so memory use does not grow
+// with the number of users.
+func (r *UserResource) export(ctx *fiber.Ctx) error {
+	format := ctx.Query("format")
+	if format == "" {
+		format = "ndjson"
+		if ctx.Accepts(mimeNDJSON, fiber.MIMEApplicationJSON) == fiber.MIMEApplicationJSON {
+			format = "json"
+		}
+	}
+
+	var contentType string
+	switch format {
+	case "ndjson":
+		contentType = mimeNDJSON
+	case "json":
+		contentType = fiber.MIMEApplicationJSONCharsetUTF8
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "format must be ndjson or json")
+	}
+
+	// The stream writer runs after the handler has returned, so take
+	// what it needs from ctx now, detached from the request's deadline.
+	requestCtx := context.WithoutCancel(ctx.UserContext())
+	encode := ctx.App().Config().JSONEncoder
+	repository := r.Service.Users
+
+	ctx.Attachment("users." + format)
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := streamUsers(requestCtx, repository, w, format == "json", encode); err != nil {
+			logger.FromContext(requestCtx).Warn("account: user export aborted", "error", err)
+		}
+	})
+	return nil
+}
+
+func streamUsers(ctx context.Context, repository user.Repository, w *bufio.Writer, array bool, encode utils.JSONMarshal) error {
+	if array {
+		w.WriteByte('[')
+	}
+	first := true
+	for offset := 0; ; offset += exportBatch {
+		users, total, err := repository.List(ctx, user.ListOptions{Offset: offset, Limit: exportBatch})
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			line, err := encode(mapping.UserResponse(u))
+			if err != nil {
+				return err
+			}
+			if array && !first {
+				w.WriteByte(',')
+			}
+			first = false
+			w.Write(line)
+			if !array {
+				w.WriteByte('\n')
+			}
+		}
+		// Flush sends each batch as its own chunk; it fails once the
+		// client has gone away.
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if len(users) == 0 || offset+len(users) >= total {
+			break
+		}
+	}
+	if array {
+		w.WriteByte(']')
+	}
+	return w.Flush()
+}
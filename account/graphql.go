@@ -0,0 +1,376 @@
+package account
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/graphql"
+	"belajar-golang-fiber/mapping"
+	"belajar-golang-fiber/middleware/adminauth"
+	"belajar-golang-fiber/order"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+)
+
+// GraphQLSchema exposes users and orders over GraphQL on top of the same
+// services as the REST resources, with the same access: users read and
+// update their own account, admins any; orders are only visible to, and
+// placed by, the signed in user.
+func GraphQLSchema(users *user.Service, orders *order.Service) *graphql.Schema {
+	orderItem := &graphql.Object{Name: "OrderItem", Fields: graphql.Fields{
+		"sku":       {Type: graphql.NonNullOf(graphql.String)},
+		"name":      {Type: graphql.NonNullOf(graphql.String)},
+		"quantity":  {Type: graphql.NonNullOf(graphql.Int)},
+		"unitPrice": {Type: graphql.NonNullOf(graphql.Int)},
+	}}
+	orderType := &graphql.Object{Name: "Order", Fields: graphql.Fields{
+		"id":        {Type: graphql.NonNullOf(graphql.ID)},
+		"number":    {Type: graphql.String},
+		"userId":    {Type: graphql.NonNullOf(graphql.ID)},
+		"status":    {Type: graphql.NonNullOf(graphql.String)},
+		"currency":  {Type: graphql.NonNullOf(graphql.String)},
+		"items":     {Type: graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(orderItem)))},
+		"total":     {Type: graphql.NonNullOf(graphql.Int)},
+		"createdAt": {Type: graphql.NonNullOf(graphql.DateTime)},
+		"updatedAt": {Type: graphql.NonNullOf(graphql.DateTime)},
+	}}
+	orderPage := page("OrderPage", orderType)
+	pageArgs := graphql.Args{
+		"page":    {Type: graphql.Int, Default: 1},
+		"perPage": {Type: graphql.Int, Default: defaultPerPage},
+	}
+
+	listOrders := func(p graphql.Params) (any, error) {
+		userID, err := signedIn(p)
+		if err != nil {
+			return nil, err
+		}
+		pageNumber, perPage, err := graphQLPagination(p.Args)
+		if err != nil {
+			return nil, err
+		}
+		found, total, err := orders.Orders.ListByUser(p.Context, userID, order.ListOptions{
+			Offset: (pageNumber - 1) * perPage,
+			Limit:  perPage,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return dto.OrderListResponse{Data: mapping.OrderResponses(found), Page: pageNumber, PerPage: perPage, Total: total}, nil
+	}
+
+	userType := &graphql.Object{Name: "User", Fields: graphql.Fields{
+		"id":        {Type: graphql.NonNullOf(graphql.ID)},
+		"username":  {Type: graphql.NonNullOf(graphql.String)},
+		"name":      {Type: graphql.NonNullOf(graphql.String)},
+		"email":     {Type: graphql.String},
+		"phone":     {Type: graphql.String},
+		"createdAt": {Type: graphql.NonNullOf(graphql.DateTime)},
+		"updatedAt": {Type: graphql.NonNullOf(graphql.DateTime)},
+		"orders": {
+			Type:        graphql.NonNullOf(orderPage),
+			Args:        pageArgs,
+			Description: "Only readable on the signed in user.",
+			Resolve: func(p graphql.Params) (any, error) {
+				if userID, _ := signedIn(p); userID != p.Source.(dto.UserResponse).ID {
+					return nil, errForbidden
+				}
+				return listOrders(p)
+			},
+		},
+	}}
+	userPage := page("UserPage", userType)
+
+	query := &graphql.Object{Name: "Query", Fields: graphql.Fields{
+		"user": {
+			Type: userType,
+			Args: graphql.Args{"id": {Type: graphql.NonNullOf(graphql.ID)}},
+			Resolve: func(p graphql.Params) (any, error) {
+				if err := authorizeUser(p, users, p.Args["id"].(string)); err != nil {
+					return nil, err
+				}
+				found, err := users.Users.Get(p.Context, p.Args["id"].(string))
+				if errors.Is(err, user.ErrNotFound) {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+				return mapping.UserResponse(found), nil
+			},
+		},
+		"users": {
+			Type: graphql.NonNullOf(userPage),
+			Args: pageArgs,
+			Resolve: func(p graphql.Params) (any, error) {
+				if err := authorizeUser(p, users, ""); err != nil {
+					return nil, err
+				}
+				pageNumber, perPage, err := graphQLPagination(p.Args)
+				if err != nil {
+					return nil, err
+				}
+				found, total, err := users.Users.List(p.Context, user.ListOptions{
+					Offset: (pageNumber - 1) * perPage,
+					Limit:  perPage,
+				})
+				if err != nil {
+					return nil, err
+				}
+				return dto.UserListResponse{Data: mapping.UserResponses(found), Page: pageNumber, PerPage: perPage, Total: total}, nil
+			},
+		},
+		"me": {
+			Type: userType,
+			Resolve: func(p graphql.Params) (any, error) {
+				userID, err := signedIn(p)
+				if err != nil {
+					return nil, nil
+				}
+				found, err := users.Users.Get(p.Context, userID)
+				if err != nil {
+					return nil, err
+				}
+				return mapping.UserResponse(found), nil
+			},
+		},
+		"order": {
+			Type: orderType,
+			Args: graphql.Args{"id": {Type: graphql.NonNullOf(graphql.ID)}},
+			Resolve: func(p graphql.Params) (any, error) {
+				userID, err := signedIn(p)
+				if err != nil {
+					return nil, err
+				}
+				// Orders of other users are null as well, so ids cannot
+				// be probed.
+				found, err := orders.Orders.Get(p.Context, p.Args["id"].(string))
+				if errors.Is(err, order.ErrNotFound) || (err == nil && found.UserID != userID) {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+				return mapping.OrderResponse(found), nil
+			},
+		},
+		"orders": {
+			Type:    graphql.NonNullOf(orderPage),
+			Args:    pageArgs,
+			Resolve: listOrders,
+		},
+	}}
+
+	registerInput := &graphql.InputObject{Name: "RegisterInput", Fields: graphql.Args{
+		"username": {Type: graphql.NonNullOf(graphql.String)},
+		"password": {Type: graphql.NonNullOf(graphql.String)},
+		"name":     {Type: graphql.String},
+		"email":    {Type: graphql.String},
+		"phone":    {Type: graphql.String},
+	}}
+	// Passwords are changed at PUT /api/v1/me/password only.
+	updateUserInput := &graphql.InputObject{Name: "UpdateUserInput", Fields: graphql.Args{
+		"username": {Type: graphql.String},
+		"name":     {Type: graphql.String},
+		"email":    {Type: graphql.String},
+		"phone":    {Type: graphql.String},
+	}}
+	orderItemInput := &graphql.InputObject{Name: "OrderItemInput", Fields: graphql.Args{
+		"sku":       {Type: graphql.NonNullOf(graphql.String)},
+		"name":      {Type: graphql.String},
+		"quantity":  {Type: graphql.NonNullOf(graphql.Int)},
+		"unitPrice": {Type: graphql.NonNullOf(graphql.Int)},
+	}}
+	placeOrderInput := &graphql.InputObject{Name: "PlaceOrderInput", Fields: graphql.Args{
+		"currency": {Type: graphql.NonNullOf(graphql.String)},
+		"items":    {Type: graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(orderItemInput)))},
+	}}
+
+	mutation := &graphql.Object{Name: "Mutation", Fields: graphql.Fields{
+		"registerUser": {
+			Type: graphql.NonNullOf(userType),
+			Args: graphql.Args{"input": {Type: graphql.NonNullOf(registerInput)}},
+			Resolve: func(p graphql.Params) (any, error) {
+				if err := authorizeUser(p, users, ""); err != nil {
+					return nil, err
+				}
+				input := p.Args["input"].(map[string]any)
+				created, err := users.Register(p.Context, user.RegisterInput{
+					Username: stringArg(input, "username"),
+					Password: stringArg(input, "password"),
+					Name:     stringArg(input, "name"),
+					Email:    stringArg(input, "email"),
+					Phone:    stringArg(input, "phone"),
+				})
+				if err != nil {
+					return nil, graphQLUserError(err)
+				}
+				return mapping.UserResponse(created), nil
+			},
+		},
+		"updateUser": {
+			Type: graphql.NonNullOf(userType),
+			Args: graphql.Args{
+				"id":    {Type: graphql.NonNullOf(graphql.ID)},
+				"input": {Type: graphql.NonNullOf(updateUserInput)},
+			},
+			Resolve: func(p graphql.Params) (any, error) {
+				if err := authorizeUser(p, users, p.Args["id"].(string)); err != nil {
+					return nil, err
+				}
+				input := p.Args["input"].(map[string]any)
+				updated, err := users.Update(p.Context, p.Args["id"].(string), user.UpdateInput{
+					Username: optionalArg(input, "username"),
+					Name:     optionalArg(input, "name"),
+					Email:    optionalArg(input, "email"),
+					Phone:    optionalArg(input, "phone"),
+				})
+				if err != nil {
+					return nil, graphQLUserError(err)
+				}
+				return mapping.UserResponse(updated), nil
+			},
+		},
+		"deleteUser": {
+			Type: graphql.NonNullOf(graphql.Boolean),
+			Args: graphql.Args{"id": {Type: graphql.NonNullOf(graphql.ID)}},
+			Resolve: func(p graphql.Params) (any, error) {
+				if err := authorizeUser(p, users, ""); err != nil {
+					return nil, err
+				}
+				if err := users.Delete(p.Context, p.Args["id"].(string)); err != nil {
+					return nil, graphQLUserError(err)
+				}
+				return true, nil
+			},
+		},
+		"placeOrder": {
+			Type: graphql.NonNullOf(orderType),
+			Args: graphql.Args{"input": {Type: graphql.NonNullOf(placeOrderInput)}},
+			Resolve: func(p graphql.Params) (any, error) {
+				userID, err := signedIn(p)
+				if err != nil {
+					return nil, err
+				}
+				input := p.Args["input"].(map[string]any)
+				place := order.PlaceInput{Currency: stringArg(input, "currency")}
+				place.Tenant, _ = graphql.FiberCtx(p.Context).Locals("tenant").(string)
+				for _, raw := range input["items"].([]any) {
+					item := raw.(map[string]any)
+					place.Items = append(place.Items, order.Item{
+						SKU:       stringArg(item, "sku"),
+						Name:      stringArg(item, "name"),
+						Quantity:  item["quantity"].(int),
+						UnitPrice: int64(item["unitPrice"].(int)),
+					})
+				}
+				placed, err := orders.Place(p.Context, userID, place)
+				var invalid *order.ValidationError
+				if errors.As(err, &invalid) {
+					return nil, validationError(invalid.Error(), invalid.Field)
+				}
+				if err != nil {
+					return nil, err
+				}
+				return mapping.OrderResponse(placed), nil
+			},
+		},
+	}}
+
+	return &graphql.Schema{Query: query, Mutation: mutation}
+}
+
+// page is a page of items, shaped like the REST list responses.
+func page(name string, item *graphql.Object) *graphql.Object {
+	return &graphql.Object{Name: name, Fields: graphql.Fields{
+		"data":    {Type: graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(item)))},
+		"page":    {Type: graphql.NonNullOf(graphql.Int)},
+		"perPage": {Type: graphql.NonNullOf(graphql.Int)},
+		"total":   {Type: graphql.NonNullOf(graphql.Int)},
+	}}
+}
+
+// graphQLPagination checks the page and perPage arguments like pagination
+// checks the query string.
+func graphQLPagination(args map[string]any) (int, int, error) {
+	pageNumber, _ := args["page"].(int)
+	perPage, _ := args["perPage"].(int)
+	if pageNumber < 1 {
+		return 0, 0, validationError("page must be a positive integer", "page")
+	}
+	if perPage < 1 || perPage > maxPerPage {
+		return 0, 0, validationError("perPage must be between 1 and "+strconv.Itoa(maxPerPage), "perPage")
+	}
+	return pageNumber, perPage, nil
+}
+
+// signedIn returns the session user or an UNAUTHENTICATED error.
+func signedIn(p graphql.Params) (string, error) {
+	if ctx := graphql.FiberCtx(p.Context); ctx != nil {
+		if userID := session.UserID(ctx); userID != "" {
+			return userID, nil
+		}
+	}
+	return "", &graphql.Error{Message: "sign in required", Extensions: map[string]any{"code": "UNAUTHENTICATED"}}
+}
+
+var errForbidden = &graphql.Error{Message: "forbidden", Extensions: map[string]any{"code": "FORBIDDEN"}}
+
+// authorizeUser is RequireSelfOrAdmin for resolvers: it fails unless the
+// signed in user is the user with the given id or an admin. An empty id
+// admits admins only.
+func authorizeUser(p graphql.Params, users *user.Service, id string) error {
+	userID, err := signedIn(p)
+	if err != nil {
+		return err
+	}
+	if id != "" && id == userID {
+		return nil
+	}
+	roles, err := userRoles(p.Context, users, userID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(roles, adminauth.RoleAdmin) {
+		return errForbidden
+	}
+	return nil
+}
+
+// graphQLUserError is userError for GraphQL: the errors of package user
+// with a code instead of a status.
+func graphQLUserError(err error) error {
+	var invalid *user.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return validationError(invalid.Error(), invalid.Field)
+	case errors.Is(err, user.ErrUsernameTaken):
+		return &graphql.Error{Message: err.Error(), Extensions: map[string]any{"code": "CONFLICT"}}
+	case errors.Is(err, user.ErrNotFound):
+		return &graphql.Error{Message: err.Error(), Extensions: map[string]any{"code": "NOT_FOUND"}}
+	case errors.Is(err, user.ErrLocked):
+		return &graphql.Error{Message: err.Error(), Extensions: map[string]any{"code": "LOCKED"}}
+	}
+	return err
+}
+
+func validationError(message, field string) error {
+	return &graphql.Error{Message: message, Extensions: map[string]any{"code": "VALIDATION", "field": field}}
+}
+
+func stringArg(args map[string]any, name string) string {
+	value, _ := args[name].(string)
+	return value
+}
+
+// optionalArg is nil for arguments left out, so updates only change the
+// fields given, like PATCH.
+func optionalArg(args map[string]any, name string) *string {
+	value, ok := args[name].(string)
+	if !ok {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,126 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/graphql"
+	"belajar-golang-fiber/order"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphQLSchema(t *testing.T) {
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	users := user.NewService(user.NewMemoryRepository(), "ID")
+	users.Users.Create(context.Background(), &user.User{ID: "admin", Username: "admin", Roles: []string{"admin"}})
+	app := fiber.New()
+	app.Use(sessions.Middleware())
+	app.Post("/login/:id", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, ctx.Params("id"))
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	handler := &graphql.Handler{Schema: GraphQLSchema(users, order.NewService(order.NewMemoryRepository(), nil))}
+	handler.Register(app.Group("/graphql"))
+
+	send := func(token, query string, variables map[string]any) (map[string]any, []any) {
+		body, _ := json.Marshal(graphql.Request{Query: query, Variables: variables})
+		request := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+		request.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		var result struct {
+			Data   map[string]any `json:"data"`
+			Errors []any          `json:"errors"`
+		}
+		assert.Nil(t, json.NewDecoder(response.Body).Decode(&result))
+		return result.Data, result.Errors
+	}
+
+	code := func(errs []any) any {
+		if len(errs) == 0 {
+			return nil
+		}
+		return errs[0].(map[string]any)["extensions"].(map[string]any)["code"]
+	}
+	signIn := func(id string) string {
+		response, err := app.Test(httptest.NewRequest("POST", "/login/"+id, nil))
+		assert.Nil(t, err)
+		return response.Cookies()[0].Value
+	}
+	admin := signIn("admin")
+
+	data, errs := send(admin, `mutation($input: RegisterInput!) { registerUser(input: $input) { id username } }`,
+		map[string]any{"input": map[string]any{"username": "salman", "password": "rahasia", "name": "Salman"}})
+	assert.Empty(t, errs)
+	id := data["registerUser"].(map[string]any)["id"].(string)
+
+	_, errs = send(admin, `mutation { registerUser(input: {username: "salman", password: "rahasia"}) { id } }`, nil)
+	assert.Equal(t, "CONFLICT", code(errs))
+
+	data, errs = send(admin, `{ users(perPage: 5) { total data { username } } }`, nil)
+	assert.Empty(t, errs)
+	assert.Equal(t, float64(2), data["users"].(map[string]any)["total"])
+	_, errs = send("", `{ users { total } }`, nil)
+	assert.Equal(t, "UNAUTHENTICATED", code(errs))
+	assert.Equal(t, "UNAUTHENTICATED", code(errs))
+	_, errs = send("", `mutation { placeOrder(input: {currency: "idr", items: [{sku: "BK-1", quantity: 2, unitPrice: 150000}]}) { id } }`, nil)
+	assert.Equal(t, "UNAUTHENTICATED", errs[0].(map[string]any)["extensions"].(map[string]any)["code"])
+
+	token := signIn(id)
+	for _, query := range []string{
+		`{ users { total } }`,
+		`{ user(id: "admin") { username } }`,
+		`mutation { registerUser(input: {username: "budi", password: "rahasia"}) { id } }`,
+		`mutation { updateUser(id: "admin", input: {name: "Salman"}) { id } }`,
+		`mutation { deleteUser(id: "admin") }`,
+	} {
+		_, errs = send(token, query, nil)
+		assert.Equal(t, "FORBIDDEN", code(errs), query)
+	}
+	data, errs = send(token, `mutation($id: ID!) { updateUser(id: $id, input: {name: "Salman Seif"}) { name } }`, map[string]any{"id": id})
+	assert.Empty(t, errs)
+	assert.Equal(t, "Salman Seif", data["updateUser"].(map[string]any)["name"])
+	_, errs = send(token, `mutation($id: ID!) { updateUser(id: $id, input: {password: "diganti"}) { name } }`, map[string]any{"id": id})
+	assert.NotEmpty(t, errs, "passwords are changed at /me/password only")
+	data, errs = send(token, `mutation { placeOrder(input: {currency: "idr", items: [{sku: "BK-1", quantity: 2, unitPrice: 150000}]}) { currency total items { sku } } }`, nil)
+	assert.Empty(t, errs)
+	assert.Equal(t, map[string]any{"currency": "IDR", "total": float64(300000), "items": []any{map[string]any{"sku": "BK-1"}}}, data["placeOrder"])
+
+	data, errs = send(token, `{ me { username orders { total data { total } } } }`, nil)
+	assert.Empty(t, errs)
+	assert.Equal(t, float64(1), data["me"].(map[string]any)["orders"].(map[string]any)["total"])
+
+	data, errs = send(admin, `query($id: ID!) { user(id: $id) { username orders { total } } }`, map[string]any{"id": id})
+	assert.Equal(t, "FORBIDDEN", code(errs), "orders only on the signed in user")
+	assert.Nil(t, data["user"])
+	_, errs = send("", `query($id: ID!) { user(id: $id) { username } }`, map[string]any{"id": id})
+	assert.Equal(t, "UNAUTHENTICATED", code(errs))
+
+	// Deleting goes through the service and its BeforeDelete checks.
+	held := map[string]bool{id: true}
+	users.BeforeDelete(func(ctx context.Context, u *user.User) error {
+		if held[u.ID] {
+			return user.ErrLocked
+		}
+		return nil
+	})
+	_, errs = send(admin, `mutation($id: ID!) { deleteUser(id: $id) }`, map[string]any{"id": id})
+	assert.Equal(t, "LOCKED", code(errs))
+	delete(held, id)
+	data, errs = send(admin, `mutation($id: ID!) { deleteUser(id: $id) }`, map[string]any{"id": id})
+	assert.Empty(t, errs)
+	assert.Equal(t, true, data["deleteUser"])
+}
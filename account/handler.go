@@ -0,0 +1,229 @@
+// Package account serves the sign-up and sign-in endpoints.
+package account
+
+import (
+	"errors"
+	"strings"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/binding"
+	"belajar-golang-fiber/captcha"
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/mapping"
+	"belajar-golang-fiber/middleware/realip"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/txn"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler exposes account registration, login and logout.
+type Handler struct {
+	Users    *user.Service
+	Sessions *session.Manager
+	Audit    *audit.Logger
+	// Throttle, when set, has logins from a client address or for an
+	// account that failed too often solve a challenge of Captcha first.
+	Throttle *captcha.Throttle
+	Captcha  captcha.Verifier
+}
+
+// Register mounts the routes on router, e.g. app.Group("/api/v1").
+func (h *Handler) Register(router fiber.Router) {
+	router.Post("/register", h.register)
+	router.Post("/login", h.login)
+	router.Post("/logout", session.Require(), h.logout)
+	router.Post("/logout-all", session.Require(), h.logoutAll)
+	router.Post("/refresh", h.refresh)
+}
+
+func (h *Handler) register(ctx *fiber.Ctx) error {
+	return binding.With(ctx, func(request *dto.RegisterRequest) error {
+		// The user, its audit event and the jobs of AfterRegister hooks
+		// are written together.
+		var created *user.User
+		err := txn.InRequest(ctx, func(tx *txn.Tx) error {
+			var err error
+			created, err = h.Users.Register(tx, mapping.RegisterInput(*request))
+			if err != nil {
+				return err
+			}
+			h.Audit.Log(ctx, audit.ActionRegister, audit.OutcomeSuccess, "user:"+created.ID, map[string]string{"username": created.Username})
+			return nil
+		})
+		if err != nil {
+			return userError(ctx, err)
+		}
+		return ctx.Status(fiber.StatusCreated).JSON(mapping.UserResponse(created))
+	})
+}
+
+func (h *Handler) login(ctx *fiber.Ctx) error {
+	return binding.With(ctx, func(request *dto.LoginRequest) error {
+		return h.signIn(ctx, request)
+	})
+}
+
+// signIn checks the credentials of request, which is pooled and must not
+// be kept.
+func (h *Handler) signIn(ctx *fiber.Ctx, request *dto.LoginRequest) error {
+	keys := []string{"ip:" + realip.IP(ctx), "user:" + strings.ToLower(strings.TrimSpace(request.Username))}
+	if err := h.challenge(ctx, keys, request.Captcha); err != nil {
+		if errors.Is(err, captcha.ErrRequired) {
+			h.Audit.Log(ctx, audit.ActionLoginFailed, audit.OutcomeFailure, "", map[string]string{"username": request.Username, "reason": "captcha"})
+			return ctx.Status(fiber.StatusPreconditionRequired).JSON(fiber.Map{
+				"error":            err.Error(),
+				"captcha_required": true,
+			})
+		}
+		return err
+	}
+
+	found, err := h.Users.Authenticate(ctx.UserContext(), mapping.LoginInput(*request))
+	if errors.Is(err, user.ErrInvalidCredentials) {
+		if h.Throttle != nil {
+			h.Throttle.Fail(keys...)
+		}
+		h.Audit.Log(ctx, audit.ActionLoginFailed, audit.OutcomeFailure, "", map[string]string{"username": request.Username})
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, user.ErrAccountLocked) {
+		h.Audit.Log(ctx, audit.ActionLoginFailed, audit.OutcomeFailure, "", map[string]string{"username": request.Username, "reason": "locked"})
+		return ctx.Status(fiber.StatusLocked).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if h.Throttle != nil {
+		h.Throttle.Reset(keys[1])
+	}
+
+	grant, err := h.Sessions.Grant(ctx, found.ID)
+	if err != nil {
+		return err
+	}
+	if request.Remember {
+		if err := h.Sessions.Remember(ctx, grant.Session); err != nil {
+			return err
+		}
+	}
+	h.Audit.Log(ctx, audit.ActionLogin, audit.OutcomeSuccess, "user:"+found.ID, map[string]string{"session": grant.Session.ID})
+	return ctx.JSON(loginResponse(found, grant))
+}
+
+// challenge fails with captcha.ErrRequired when one of keys failed too
+// often and token solves no challenge. Solving one lets the attempt
+// through, failures still being counted.
+func (h *Handler) challenge(ctx *fiber.Ctx, keys []string, token string) error {
+	if h.Throttle == nil || !h.Throttle.Required(keys...) {
+		return nil
+	}
+	if h.Captcha == nil {
+		return captcha.ErrRequired
+	}
+	ok, err := h.Captcha.Verify(ctx.UserContext(), token, realip.IP(ctx))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return captcha.ErrRequired
+	}
+	return nil
+}
+
+// refresh swaps a refresh token for a new session and refresh token. A
+// token sent twice signs its family out: one of the senders stole it.
+func (h *Handler) refresh(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[dto.RefreshRequest](ctx)
+	if err != nil {
+		return err
+	}
+	unauthorized := func() error {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "invalid refresh token",
+		})
+	}
+
+	grant, err := h.Sessions.Refresh(ctx, request.RefreshToken)
+	var reused *session.ReuseError
+	switch {
+	case errors.As(err, &reused):
+		h.Audit.Log(ctx, audit.ActionRefreshReused, audit.OutcomeFailure, "user:"+reused.UserID, map[string]string{"family": reused.Family})
+		return unauthorized()
+	case errors.Is(err, session.ErrNotFound):
+		return unauthorized()
+	case err != nil:
+		return err
+	}
+
+	// Locked and deleted users keep no session.
+	found, err := h.Users.Users.Get(ctx.UserContext(), grant.Session.UserID)
+	if err != nil && !errors.Is(err, user.ErrNotFound) {
+		return err
+	}
+	if found == nil || found.Locked() {
+		if err := h.Sessions.End(ctx.UserContext(), grant.Session); err != nil {
+			return err
+		}
+		return unauthorized()
+	}
+	return ctx.JSON(loginResponse(found, grant))
+}
+
+func loginResponse(found *user.User, grant *session.Grant) dto.LoginResponse {
+	response := dto.LoginResponse{
+		User:      mapping.UserResponse(found),
+		Token:     grant.Token,
+		ExpiresAt: grant.Session.ExpiresAt,
+	}
+	if grant.Refresh != nil {
+		response.RefreshToken = grant.RefreshToken
+		response.RefreshExpiresAt = &grant.Refresh.ExpiresAt
+	}
+	return response
+}
+
+func (h *Handler) logout(ctx *fiber.Ctx) error {
+	h.Audit.Log(ctx, audit.ActionLogout, audit.OutcomeSuccess, "", nil)
+	if err := h.Sessions.Revoke(ctx); err != nil {
+		return err
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// logoutAll signs the user out of every device, refresh tokens included.
+func (h *Handler) logoutAll(ctx *fiber.Ctx) error {
+	h.Audit.Log(ctx, audit.ActionLogoutAll, audit.OutcomeSuccess, "user:"+session.UserID(ctx), nil)
+	if err := h.Sessions.RevokeAll(ctx); err != nil {
+		return err
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// RequirePasswordChange keeps signed in users an admin required to reset
+// their password to changing it: their other requests, but those next
+// returns true for, answer 403.
+func RequirePasswordChange(users user.Repository, next func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		userID := session.UserID(ctx)
+		if userID == "" || (next != nil && next(ctx)) {
+			return ctx.Next()
+		}
+		found, err := users.Get(ctx.UserContext(), userID)
+		if err != nil && !errors.Is(err, user.ErrNotFound) {
+			return err
+		}
+		if found == nil || !found.PasswordResetRequired {
+			return ctx.Next()
+		}
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "the password must be changed",
+		})
+	}
+}
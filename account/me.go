@@ -0,0 +1,113 @@
+package account
+
+import (
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/binding"
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/mapping"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MeHandler lets signed in users manage their own account: their
+// profile, password and sessions.
+type MeHandler struct {
+	Users    *user.Service
+	Sessions *session.Manager
+	Audit    *audit.Logger
+}
+
+// Register mounts the routes on router, e.g. app.Group("/api/v1/me").
+// Every route requires a session.
+func (h *MeHandler) Register(router fiber.Router) {
+	router.Use(session.Require())
+	router.Get("/", h.get)
+	router.Patch("/", h.update)
+	router.Put("/password", h.password)
+	router.Get("/sessions", h.sessions)
+	router.Delete("/sessions/:id", h.revoke)
+}
+
+func (h *MeHandler) get(ctx *fiber.Ctx) error {
+	found, err := h.Users.Users.Get(ctx.UserContext(), session.UserID(ctx))
+	if err != nil {
+		return userError(ctx, err)
+	}
+	ctx.Set(fiber.HeaderETag, userETag(found))
+	return ctx.JSON(mapping.UserResponse(found))
+}
+
+func (h *MeHandler) update(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[dto.ProfileRequest](ctx)
+	if err != nil {
+		return err
+	}
+	updated, err := h.Users.Update(ctx.UserContext(), session.UserID(ctx), mapping.ProfileInput(*request))
+	if err != nil {
+		return userError(ctx, err)
+	}
+	ctx.Set(fiber.HeaderETag, userETag(updated))
+	return ctx.JSON(mapping.UserResponse(updated))
+}
+
+// password changes the password and signs the user out of their other
+// sessions, refresh tokens and remember-me tokens, which whoever knew
+// the old one may hold.
+func (h *MeHandler) password(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[dto.ChangePasswordRequest](ctx)
+	if err != nil {
+		return err
+	}
+	userID := session.UserID(ctx)
+	if _, err := h.Users.ChangePassword(ctx.UserContext(), userID, request.CurrentPassword, request.NewPassword); err != nil {
+		return userError(ctx, err)
+	}
+
+	if err := h.Sessions.RevokeOthers(ctx.UserContext(), session.Current(ctx)); err != nil {
+		return err
+	}
+	h.Audit.Log(ctx, audit.ActionPasswordChange, audit.OutcomeSuccess, "user:"+userID, nil)
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *MeHandler) sessions(ctx *fiber.Ctx) error {
+	sessions, err := h.Sessions.Store().ListByUser(ctx.UserContext(), session.UserID(ctx))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(mapping.SessionResponses(sessions, session.Current(ctx).ID))
+}
+
+// revoke ends one of the user's sessions; revoking the current one signs
+// out.
+func (h *MeHandler) revoke(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if id == session.Current(ctx).ID {
+		if err := h.Sessions.Revoke(ctx); err != nil {
+			return err
+		}
+	} else {
+		sessions, err := h.Sessions.Store().ListByUser(ctx.UserContext(), session.UserID(ctx))
+		if err != nil {
+			return err
+		}
+		var owned *session.Session
+		for _, s := range sessions {
+			if s.ID == id {
+				owned = s
+			}
+		}
+		if owned == nil {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": session.ErrNotFound.Error(),
+			})
+		}
+		if err := h.Sessions.End(ctx.UserContext(), owned); err != nil {
+			return err
+		}
+	}
+	h.Audit.Log(ctx, audit.ActionSessionRevoke, audit.OutcomeSuccess, "user:"+session.UserID(ctx), map[string]string{"session": id})
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
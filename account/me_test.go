@@ -0,0 +1,98 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeHandler(t *testing.T) {
+	service := user.NewService(user.NewMemoryRepository(), "ID")
+	_, err := service.Register(context.Background(), user.RegisterInput{Username: "salman", Password: "rahasia"})
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore(), Refresh: session.NewMemoryRefreshStore()})
+	app.Use(sessions.Middleware())
+	(&Handler{Users: service, Sessions: sessions}).Register(app.Group("/api/v1"))
+	(&MeHandler{Users: service, Sessions: sessions}).Register(app.Group("/api/v1/me"))
+
+	send := func(method, target, token, body string) (int, string) {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		bytes, _ := io.ReadAll(response.Body)
+		return response.StatusCode, string(bytes)
+	}
+	var refresh map[string]string
+	login := func(password string) string {
+		status, body := send("POST", "/api/v1/login", "", `{"username":"salman","password":"`+password+`"}`)
+		assert.Equal(t, 200, status)
+		var login dto.LoginResponse
+		json.Unmarshal([]byte(body), &login)
+		refresh[login.Token] = login.RefreshToken
+		return login.Token
+	}
+	refresh = map[string]string{}
+	laptop, phone := login("rahasia"), login("rahasia")
+
+	status, _ := send("GET", "/api/v1/me", "", "")
+	assert.Equal(t, 401, status)
+	status, body := send("PATCH", "/api/v1/me", laptop, `{"name":"Salman Seif","username":"admin"}`)
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"name":"Salman Seif"`)
+	assert.Contains(t, body, `"username":"salman"`, "the username is not part of the profile")
+
+	var listed []dto.SessionResponse
+	_, body = send("GET", "/api/v1/me/sessions", laptop, "")
+	assert.Nil(t, json.Unmarshal([]byte(body), &listed))
+	assert.Len(t, listed, 2)
+	var other string
+	for _, s := range listed {
+		if !s.Current {
+			other = s.ID
+		}
+	}
+
+	status, body = send("PUT", "/api/v1/me/password", laptop, `{"current_password":"salah!","new_password":"rahasia2"}`)
+	assert.Equal(t, 422, status)
+	assert.Contains(t, body, `"field":"current_password"`)
+	status, _ = send("PUT", "/api/v1/me/password", laptop, `{"current_password":"rahasia","new_password":"rahasia2"}`)
+	assert.Equal(t, 204, status)
+	status, _ = send("GET", "/api/v1/me", phone, "")
+	assert.Equal(t, 401, status, "other sessions are signed out")
+	status, _ = send("GET", "/api/v1/me", laptop, "")
+	assert.Equal(t, 200, status)
+	status, _ = send("POST", "/api/v1/refresh", "", `{"refresh_token":"`+refresh[phone]+`"}`)
+	assert.Equal(t, 401, status, "so are their refresh tokens")
+
+	status, _ = send("DELETE", "/api/v1/me/sessions/"+other, laptop, "")
+	assert.Equal(t, 404, status, "the session is gone already")
+
+	tablet := login("rahasia2")
+	_, body = send("GET", "/api/v1/me/sessions", tablet, "")
+	json.Unmarshal([]byte(body), &listed)
+	for _, s := range listed {
+		if !s.Current {
+			other = s.ID
+		}
+	}
+	status, _ = send("DELETE", "/api/v1/me/sessions/"+other, tablet, "")
+	assert.Equal(t, 204, status)
+	status, _ = send("GET", "/api/v1/me", laptop, "")
+	assert.Equal(t, 401, status)
+}
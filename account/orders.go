@@ -0,0 +1,138 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/binding"
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/export"
+	"belajar-golang-fiber/mapping"
+	"belajar-golang-fiber/middleware/negotiate"
+	"belajar-golang-fiber/order"
+	"belajar-golang-fiber/rpc/appv1"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// OrderResource is the /users/:userId/orders REST resource. Users can
+// only see and place their own orders.
+type OrderResource struct {
+	Service *order.Service
+}
+
+// Register mounts the routes on router, which must carry the :userId
+// parameter, e.g. app.Group("/api/v1/users/:userId/orders").
+func (r *OrderResource) Register(router fiber.Router) {
+	router.Use(session.Require(), ownerOnly)
+	router.Get("/", r.list)
+	router.Post("/", r.create)
+	router.Get("/:orderId", r.get)
+}
+
+// ownerOnly rejects requests for another user's orders.
+func ownerOnly(ctx *fiber.Ctx) error {
+	if ctx.Params("userId") != session.UserID(ctx) {
+		return fiber.ErrForbidden
+	}
+	return ctx.Next()
+}
+
+// orderColumns are the fields of ?format=csv and ?format=xlsx downloads.
+var orderColumns = []export.Column[*order.Order]{
+	{Header: "id", Value: func(o *order.Order) string { return o.ID }},
+	{Header: "number", Value: func(o *order.Order) string { return o.Number }},
+	{Header: "status", Value: func(o *order.Order) string { return o.Status }},
+	{Header: "currency", Value: func(o *order.Order) string { return o.Currency }},
+	{Header: "total", Value: func(o *order.Order) string { return strconv.FormatInt(o.Total, 10) }},
+	{Header: "created_at", Value: func(o *order.Order) string { return o.CreatedAt.Format(time.RFC3339) }},
+}
+
+func (r *OrderResource) list(ctx *fiber.Ctx) error {
+	if format := export.Requested(ctx); format != "" {
+		orders := r.Service.Orders
+		userID := session.UserID(ctx)
+		return export.Stream(ctx, format, export.Config[*order.Order]{
+			Filename: "orders",
+			Columns:  orderColumns,
+			Fetch: func(ctx context.Context, offset, limit int) ([]*order.Order, int, error) {
+				return orders.ListByUser(ctx, userID, order.ListOptions{Offset: offset, Limit: limit})
+			},
+		})
+	}
+
+	page, perPage, err := pagination(ctx)
+	if err != nil {
+		return err
+	}
+
+	orders, total, err := r.Service.Orders.ListByUser(ctx.UserContext(), ctx.Params("userId"), order.ListOptions{
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	})
+	if err != nil {
+		return err
+	}
+	return negotiate.SendProto(ctx, dto.OrderListResponse{
+		Data:    mapping.OrderResponses(orders),
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	}, func() proto.Message {
+		return &appv1.ListOrdersResponse{Orders: mapping.OrderMessages(orders), Total: int32(total)}
+	})
+}
+
+func (r *OrderResource) create(ctx *fiber.Ctx) error {
+	return binding.WithProto(ctx, mapping.CreateOrderRequestMessage, func(request *dto.CreateOrderRequest) error {
+		return r.place(ctx, mapping.PlaceOrderInput(*request))
+	})
+}
+
+// place places input, answering 422 for invalid ones.
+func (r *OrderResource) place(ctx *fiber.Ctx, input order.PlaceInput) error {
+	input.Tenant, _ = ctx.Locals("tenant").(string)
+	placed, err := r.Service.Place(ctx.UserContext(), session.UserID(ctx), input)
+	var invalid *order.ValidationError
+	if errors.As(err, &invalid) {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": invalid.Error(),
+			"field": invalid.Field,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	ctx.Location(strings.TrimSuffix(ctx.Path(), "/") + "/" + placed.ID)
+	return sendOrder(ctx.Status(fiber.StatusCreated), placed)
+}
+
+// get answers 404 for orders of other users as well, so order ids cannot
+// be probed.
+func (r *OrderResource) get(ctx *fiber.Ctx) error {
+	found, err := r.Service.Orders.Get(ctx.UserContext(), ctx.Params("orderId"))
+	if err == nil && found.UserID != ctx.Params("userId") {
+		err = order.ErrNotFound
+	}
+	if errors.Is(err, order.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return sendOrder(ctx, found)
+}
+
+// sendOrder answers with o as JSON, MessagePack or Protocol Buffers.
+func sendOrder(ctx *fiber.Ctx, o *order.Order) error {
+	return negotiate.SendProto(ctx, mapping.OrderResponse(o), func() proto.Message {
+		return mapping.OrderMessage(o)
+	})
+}
@@ -0,0 +1,125 @@
+package account
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/order"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderResource(t *testing.T) {
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app := fiber.New()
+	app.Use(sessions.Middleware())
+	app.Post("/login/:id", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, ctx.Params("id"))
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	resource := &OrderResource{Service: order.NewService(order.NewMemoryRepository(), nil)}
+	resource.Register(app.Group("/api/v1/users/:userId/orders"))
+
+	login := func(id string) string {
+		response, err := app.Test(httptest.NewRequest("POST", "/login/"+id, nil))
+		assert.Nil(t, err)
+		return response.Cookies()[0].Value
+	}
+	send := func(token, method, target, body string) *httpResponse {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return &httpResponse{status: response.StatusCode, decoder: json.NewDecoder(response.Body)}
+	}
+	salman, budi := login("1"), login("2")
+
+	response := send("", "GET", "/api/v1/users/1/orders", "")
+	assert.Equal(t, 401, response.status)
+	response = send(budi, "GET", "/api/v1/users/1/orders", "")
+	assert.Equal(t, 403, response.status)
+
+	var created dto.OrderResponse
+	for i := 0; i < 3; i++ {
+		response = send(salman, "POST", "/api/v1/users/1/orders", `{"currency":"idr","items":[{"sku":"BK-1","quantity":2,"unit_price":150000}]}`)
+		assert.Equal(t, 201, response.status)
+		assert.Nil(t, response.decoder.Decode(&created))
+	}
+	assert.Equal(t, int64(300000), created.Total)
+	assert.Equal(t, "IDR", created.Currency)
+
+	response = send(salman, "POST", "/api/v1/users/1/orders", `{"currency":"IDR","items":[]}`)
+	assert.Equal(t, 422, response.status)
+
+	response = send(salman, "GET", "/api/v1/users/1/orders/"+created.ID, "")
+	assert.Equal(t, 200, response.status)
+
+	send(budi, "POST", "/api/v1/users/2/orders", `{"currency":"USD","items":[{"sku":"X","quantity":1,"unit_price":1}]}`)
+	response = send(budi, "GET", "/api/v1/users/2/orders/"+created.ID, "")
+	assert.Equal(t, 404, response.status)
+
+	response = send(salman, "GET", "/api/v1/users/1/orders?page=2&per_page=2", "")
+	assert.Equal(t, 200, response.status)
+	var page dto.OrderListResponse
+	assert.Nil(t, response.decoder.Decode(&page))
+	assert.Equal(t, 3, page.Total)
+	assert.Len(t, page.Data, 1)
+}
+
+type httpResponse struct {
+	status  int
+	decoder *json.Decoder
+}
+
+func TestOrderResourceCSV(t *testing.T) {
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	service := order.NewService(order.NewMemoryRepository(), nil)
+	_, err := service.Place(context.Background(), "1", order.PlaceInput{Currency: "IDR", Items: []order.Item{{SKU: "BK-1", Quantity: 3, UnitPrice: 5000}}})
+	assert.Nil(t, err)
+	_, err = service.Place(context.Background(), "2", order.PlaceInput{Currency: "USD", Items: []order.Item{{SKU: "BK-2", Quantity: 1, UnitPrice: 5}}})
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	app.Use(sessions.Middleware())
+	app.Post("/login", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, "1")
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	resource := &OrderResource{Service: service}
+	resource.Register(app.Group("/api/v1/users/:userId/orders"))
+
+	response, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+	assert.Nil(t, err)
+	token, _ := io.ReadAll(response.Body)
+
+	request := httptest.NewRequest("GET", "/api/v1/users/1/orders?format=csv", nil)
+	request.Header.Set("Authorization", "Bearer "+string(token))
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `attachment; filename="orders.csv"`, response.Header.Get("Content-Disposition"))
+
+	records, err := csv.NewReader(response.Body).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, []string{"id", "number", "status", "currency", "total", "created_at"}, records[0])
+	assert.Equal(t, "IDR", records[1][3])
+	assert.Equal(t, "15000", records[1][4])
+}
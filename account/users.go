@@ -0,0 +1,214 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/binding"
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/export"
+	"belajar-golang-fiber/mapping"
+	"belajar-golang-fiber/middleware/negotiate"
+	"belajar-golang-fiber/rpc/appv1"
+	"belajar-golang-fiber/txn"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// UserResource is the /users REST resource. Users can read and update
+// their own account; everything else is for admins.
+type UserResource struct {
+	Service *user.Service
+}
+
+// Register mounts the routes on router, e.g. app.Group("/api/v1/users").
+func (r *UserResource) Register(router fiber.Router) {
+	admin := RequireAdmin(r.Service)
+	self := RequireSelfOrAdmin(r.Service)
+	router.Get("/", admin, r.list)
+	router.Post("/", admin, r.create)
+	router.Get("/export", admin, r.export)
+	router.Get("/:id", self, r.get)
+	router.Put("/:id", self, r.replace)
+	router.Patch("/:id", self, r.patch)
+	router.Delete("/:id", admin, r.delete)
+}
+
+// userColumns are the fields of ?format=csv and ?format=xlsx downloads.
+var userColumns = []export.Column[*user.User]{
+	{Header: "id", Value: func(u *user.User) string { return u.ID }},
+	{Header: "username", Value: func(u *user.User) string { return u.Username }},
+	{Header: "name", Value: func(u *user.User) string { return u.Name }},
+	{Header: "email", Value: func(u *user.User) string { return u.Email }},
+	{Header: "phone", Value: func(u *user.User) string { return u.Phone }},
+	{Header: "created_at", Value: func(u *user.User) string { return u.CreatedAt.Format(time.RFC3339) }},
+}
+
+// list answers ?page=1&per_page=20, or every user as a file with
+// ?format=csv or ?format=xlsx.
+func (r *UserResource) list(ctx *fiber.Ctx) error {
+	if format := export.Requested(ctx); format != "" {
+		users := r.Service.Users
+		return export.Stream(ctx, format, export.Config[*user.User]{
+			Filename: "users",
+			Columns:  userColumns,
+			Fetch: func(ctx context.Context, offset, limit int) ([]*user.User, int, error) {
+				return users.List(ctx, user.ListOptions{Offset: offset, Limit: limit})
+			},
+		})
+	}
+
+	page, perPage, err := pagination(ctx)
+	if err != nil {
+		return err
+	}
+
+	users, total, err := r.Service.Users.List(ctx.UserContext(), user.ListOptions{
+		Offset: (page - 1) * perPage,
+		Limit:  perPage,
+	})
+	if err != nil {
+		return err
+	}
+	return negotiate.SendProto(ctx, dto.UserListResponse{
+		Data:    mapping.UserResponses(users),
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+	}, func() proto.Message {
+		return &appv1.ListUsersResponse{Users: mapping.UserMessages(users), Total: int32(total)}
+	})
+}
+
+func (r *UserResource) create(ctx *fiber.Ctx) error {
+	request, err := binding.BindProto(ctx, mapping.RegisterRequestMessage)
+	if err != nil {
+		return err
+	}
+
+	var created *user.User
+	err = txn.WithTx(ctx.UserContext(), func(tx *txn.Tx) error {
+		created, err = r.Service.Register(tx, mapping.RegisterInput(*request))
+		return err
+	})
+	if err != nil {
+		return userError(ctx, err)
+	}
+	ctx.Location(strings.TrimSuffix(ctx.Path(), "/") + "/" + created.ID)
+	ctx.Set(fiber.HeaderETag, userETag(created))
+	return sendUser(ctx.Status(fiber.StatusCreated), created)
+}
+
+func (r *UserResource) get(ctx *fiber.Ctx) error {
+	found, err := r.Service.Users.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return userError(ctx, err)
+	}
+	ctx.Set(fiber.HeaderETag, userETag(found))
+	return sendUser(ctx, found)
+}
+
+func (r *UserResource) replace(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[dto.ReplaceUserRequest](ctx)
+	if err != nil {
+		return err
+	}
+	return r.update(ctx, mapping.ReplaceUserInput(*request))
+}
+
+func (r *UserResource) patch(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[dto.PatchUserRequest](ctx)
+	if err != nil {
+		return err
+	}
+	return r.update(ctx, mapping.PatchUserInput(*request))
+}
+
+// update applies input. With an If-Match header carrying the ETag of a
+// GET, it fails with 412 when the user changed since.
+func (r *UserResource) update(ctx *fiber.Ctx, input user.UpdateInput) error {
+	if match := ctx.Get(fiber.HeaderIfMatch); match != "" && match != "*" {
+		version, err := strconv.Atoi(strings.Trim(match, `"`))
+		if err != nil || version < 1 || !strings.HasPrefix(match, `"`) {
+			return userError(ctx, user.ErrVersionConflict)
+		}
+		input.Version = version
+	}
+	updated, err := r.Service.Update(ctx.UserContext(), ctx.Params("id"), input)
+	if err != nil {
+		return userError(ctx, err)
+	}
+	ctx.Set(fiber.HeaderETag, userETag(updated))
+	return sendUser(ctx, updated)
+}
+
+// sendUser answers with u as JSON, MessagePack or Protocol Buffers.
+func sendUser(ctx *fiber.Ctx, u *user.User) error {
+	return negotiate.SendProto(ctx, mapping.UserResponse(u), func() proto.Message {
+		return mapping.UserMessage(u)
+	})
+}
+
+// userETag is the strong ETag of a user's version.
+func userETag(u *user.User) string {
+	return `"` + strconv.Itoa(u.Version) + `"`
+}
+
+func (r *UserResource) delete(ctx *fiber.Ctx) error {
+	if err := r.Service.Delete(ctx.UserContext(), ctx.Params("id")); err != nil {
+		return userError(ctx, err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// pagination reads ?page=1&per_page=20.
+func pagination(ctx *fiber.Ctx) (int, int, error) {
+	page, err := strconv.Atoi(ctx.Query("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "page must be a positive integer")
+	}
+	perPage, err := strconv.Atoi(ctx.Query("per_page", strconv.Itoa(defaultPerPage)))
+	if err != nil || perPage < 1 || perPage > maxPerPage {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "per_page must be between 1 and "+strconv.Itoa(maxPerPage))
+	}
+	return page, perPage, nil
+}
+
+// userError answers the errors of package user with their status code.
+func userError(ctx *fiber.Ctx, err error) error {
+	var invalid *user.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": invalid.Error(),
+			"field": invalid.Field,
+		})
+	case errors.Is(err, user.ErrUsernameTaken):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, user.ErrNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, user.ErrVersionConflict):
+		return ctx.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, user.ErrLocked):
+		return ctx.Status(fiber.StatusLocked).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return err
+}
@@ -0,0 +1,324 @@
+package account
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/rpc/appv1"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+// userApp serves service at /api/v1/users next to an admin, user
+// "admin". signIn returns a session token for the user with the given id.
+func userApp(t *testing.T, service *user.Service) (app *fiber.App, signIn func(id string) string) {
+	service.Users.Create(context.Background(), &user.User{
+		ID:        "admin",
+		Username:  "admin",
+		Roles:     []string{"admin"},
+		CreatedAt: time.Unix(0, 0),
+	})
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app = fiber.New()
+	app.Use(sessions.Middleware())
+	app.Post("/login/:id", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, ctx.Params("id"))
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	resource := &UserResource{Service: service}
+	resource.Register(app.Group("/api/v1/users"))
+
+	return app, func(id string) string {
+		response, err := app.Test(httptest.NewRequest("POST", "/login/"+id, nil))
+		assert.Nil(t, err)
+		token, _ := io.ReadAll(response.Body)
+		return string(token)
+	}
+}
+
+func TestUserResource(t *testing.T) {
+	service := user.NewService(user.NewMemoryRepository(), "ID")
+	app, signIn := userApp(t, service)
+	token := signIn("admin")
+
+	send := func(method, target, body string) (int, map[string]interface{}, string) {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		request.Header.Set("Authorization", "Bearer "+token)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		decoded := map[string]interface{}{}
+		json.NewDecoder(response.Body).Decode(&decoded)
+		return response.StatusCode, decoded, response.Header.Get("Location")
+	}
+
+	status, created, location := send("POST", "/api/v1/users", `{"username":"salman","password":"rahasia","name":"Salman"}`)
+	assert.Equal(t, 201, status)
+	id := created["id"].(string)
+	assert.Equal(t, "/api/v1/users/"+id, location)
+
+	status, _, _ = send("POST", "/api/v1/users", `{"username":"budi","password":"rahasia"}`)
+	assert.Equal(t, 201, status)
+	status, _, _ = send("POST", "/api/v1/users", `{"username":"salman","password":"rahasia"}`)
+	assert.Equal(t, 409, status)
+	status, body, _ := send("POST", "/api/v1/users", `{"username":"rina","password":"rahasia","email":"not-an-email"}`)
+	assert.Equal(t, 422, status)
+	assert.Equal(t, "email", body["field"])
+
+	status, body, _ = send("PATCH", "/api/v1/users/"+id, `{"phone":"0812-3456-7890"}`)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "+6281234567890", body["phone"])
+	assert.Equal(t, "Salman", body["name"])
+
+	status, body, _ = send("PUT", "/api/v1/users/"+id, `{"username":"salman","name":"Salman Seif"}`)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "Salman Seif", body["name"])
+	assert.Nil(t, body["phone"])
+
+	status, _, _ = send("PATCH", "/api/v1/users/"+id, `{"username":"budi"}`)
+	assert.Equal(t, 409, status)
+
+	request := httptest.NewRequest("GET", "/api/v1/users?page=3&per_page=1", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	var page dto.UserListResponse
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&page))
+	assert.Equal(t, 3, page.Total, "admin, salman and budi")
+	assert.Len(t, page.Data, 1)
+	assert.Equal(t, "budi", page.Data[0].Username)
+
+	status, _, _ = send("GET", "/api/v1/users?per_page=1000", "")
+	assert.Equal(t, 400, status)
+
+	status, _, _ = send("DELETE", "/api/v1/users/"+id, "")
+	assert.Equal(t, 204, status)
+	status, _, _ = send("GET", "/api/v1/users/"+id, "")
+	assert.Equal(t, 404, status)
+	status, _, _ = send("DELETE", "/api/v1/users/"+id, "")
+	assert.Equal(t, 404, status)
+}
+
+func TestUserAccess(t *testing.T) {
+	service := user.NewService(user.NewMemoryRepository(), "ID")
+	app, signIn := userApp(t, service)
+	salman, err := service.Register(context.Background(), user.RegisterInput{Username: "salman", Password: "rahasia"})
+	assert.Nil(t, err)
+	budi, err := service.Register(context.Background(), user.RegisterInput{Username: "budi", Password: "rahasia"})
+	assert.Nil(t, err)
+	token := signIn(salman.ID)
+
+	send := func(token, method, target, body string) int {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+
+	for _, route := range [][2]string{
+		{"GET", "/api/v1/users"},
+		{"GET", "/api/v1/users/export"},
+		{"POST", "/api/v1/users"},
+		{"GET", "/api/v1/users/" + salman.ID},
+		{"PATCH", "/api/v1/users/" + salman.ID},
+		{"DELETE", "/api/v1/users/" + salman.ID},
+	} {
+		assert.Equal(t, 401, send("", route[0], route[1], `{}`), route)
+	}
+	for _, route := range [][2]string{
+		{"GET", "/api/v1/users"},
+		{"GET", "/api/v1/users?format=csv"},
+		{"GET", "/api/v1/users/export"},
+		{"POST", "/api/v1/users"},
+		{"GET", "/api/v1/users/" + budi.ID},
+		{"PUT", "/api/v1/users/" + budi.ID},
+		{"PATCH", "/api/v1/users/" + budi.ID},
+		{"DELETE", "/api/v1/users/" + budi.ID},
+		{"DELETE", "/api/v1/users/" + salman.ID},
+	} {
+		assert.Equal(t, 403, send(token, route[0], route[1], `{}`), route)
+	}
+
+	assert.Equal(t, 200, send(token, "GET", "/api/v1/users/"+salman.ID, ""))
+	assert.Equal(t, 200, send(token, "PATCH", "/api/v1/users/"+salman.ID, `{"name":"Salman","password":"diganti"}`))
+	_, err = service.Authenticate(context.Background(), user.LoginInput{Username: "salman", Password: "rahasia"})
+	assert.Nil(t, err, "passwords are changed at /me/password only")
+	assert.Equal(t, 200, send(signIn("admin"), "PATCH", "/api/v1/users/"+budi.ID, `{"name":"Budi"}`))
+}
+
+func TestUserConcurrentEdits(t *testing.T) {
+	app, signIn := userApp(t, user.NewService(user.NewMemoryRepository(), "ID"))
+	token := signIn("admin")
+
+	send := func(method, target, body, ifMatch string) (int, string) {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		if ifMatch != "" {
+			request.Header.Set("If-Match", ifMatch)
+		}
+		request.Header.Set("Authorization", "Bearer "+token)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode, response.Header.Get("ETag")
+	}
+
+	request := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(`{"username":"salman","password":"rahasia"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+token)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	var created dto.UserResponse
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&created))
+	target := "/api/v1/users/" + created.ID
+
+	_, etag := send("GET", target, "", "")
+	assert.Equal(t, `"1"`, etag)
+
+	// Two clients edit the version they read; the second one loses.
+	status, etag := send("PATCH", target, `{"name":"Salman"}`, `"1"`)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, `"2"`, etag)
+	status, _ = send("PUT", target, `{"username":"salman","name":"Seif"}`, `"1"`)
+	assert.Equal(t, 412, status)
+	status, _ = send("PATCH", target, `{"name":"Seif"}`, `W/"2"`)
+	assert.Equal(t, 412, status)
+
+	status, _ = send("PATCH", target, `{"name":"Seif"}`, `"2"`)
+	assert.Equal(t, 200, status)
+	status, etag = send("PATCH", target, `{"name":"Salman Seif"}`, "*")
+	assert.Equal(t, 200, status)
+	assert.Equal(t, `"4"`, etag)
+}
+
+func TestUserExport(t *testing.T) {
+	users := user.NewMemoryRepository()
+	service := user.NewService(users, "ID")
+	// With the admin of userApp, listed first, that is exportBatch+3.
+	for i := 0; i < exportBatch+2; i++ {
+		users.Create(context.Background(), &user.User{
+			ID:        strconv.Itoa(i),
+			Username:  "user" + strconv.Itoa(i),
+			CreatedAt: time.Unix(int64(i)+1, 0),
+		})
+	}
+
+	app, signIn := userApp(t, service)
+	token := signIn("admin")
+	get := func(target string) (*http.Response, error) {
+		request := httptest.NewRequest("GET", target, nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		return app.Test(request)
+	}
+
+	response, err := get("/api/v1/users/export")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, mimeNDJSON, response.Header.Get("Content-Type"))
+	assert.Contains(t, response.Header.Get("Content-Disposition"), "users.ndjson")
+
+	scanner := bufio.NewScanner(response.Body)
+	lines := 0
+	for scanner.Scan() {
+		var decoded dto.UserResponse
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &decoded))
+		if lines == 0 {
+			assert.Equal(t, "admin", decoded.ID)
+		} else {
+			assert.Equal(t, strconv.Itoa(lines-1), decoded.ID)
+		}
+		lines++
+	}
+	assert.Equal(t, exportBatch+3, lines)
+
+	response, err = get("/api/v1/users/export?format=json")
+	assert.Nil(t, err)
+	var list []dto.UserResponse
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&list))
+	assert.Len(t, list, exportBatch+3)
+
+	response, err = get("/api/v1/users/export?format=xml")
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestUserProtobuf(t *testing.T) {
+	app, signIn := userApp(t, user.NewService(user.NewMemoryRepository(), "ID"))
+	token := signIn("admin")
+
+	send := func(method, target string, message proto.Message, reply proto.Message) *http.Response {
+		var body io.Reader
+		if message != nil {
+			data, err := proto.Marshal(message)
+			assert.Nil(t, err)
+			body = bytes.NewReader(data)
+		}
+		request := httptest.NewRequest(method, target, body)
+		request.Header.Set("Content-Type", "application/x-protobuf")
+		request.Header.Set("Accept", "application/x-protobuf")
+		request.Header.Set("Authorization", "Bearer "+token)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		data, _ := io.ReadAll(response.Body)
+		if response.Header.Get("Content-Type") == "application/x-protobuf" {
+			assert.Nil(t, proto.Unmarshal(data, reply))
+		}
+		return response
+	}
+
+	var created appv1.User
+	response := send("POST", "/api/v1/users", &appv1.RegisterUserRequest{
+		Username: "salman",
+		Password: "rahasia",
+		Email:    "salman@example.com",
+	}, &created)
+	assert.Equal(t, 201, response.StatusCode)
+	assert.Equal(t, "salman", created.GetUsername())
+	assert.Equal(t, "/api/v1/users/"+created.GetId(), response.Header.Get("Location"))
+
+	var found appv1.User
+	response = send("GET", "/api/v1/users/"+created.GetId(), nil, &found)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "salman@example.com", found.GetEmail())
+
+	var page appv1.ListUsersResponse
+	response = send("GET", "/api/v1/users", nil, &page)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, int32(2), page.GetTotal())
+	assert.Len(t, page.GetUsers(), 2)
+
+	response = send("POST", "/api/v1/users", &appv1.RegisterUserRequest{Username: "salman", Password: "rahasia"}, nil)
+	assert.Equal(t, 409, response.StatusCode, "errors stay JSON")
+	assert.Equal(t, fiber.MIMEApplicationJSON, response.Header.Get("Content-Type"))
+
+	request := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader("\xff\xff"))
+	request.Header.Set("Content-Type", "application/x-protobuf")
+	request.Header.Set("Authorization", "Bearer "+token)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+}
@@ -0,0 +1,85 @@
+package address
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"belajar-golang-fiber/middleware/realip"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingProvider struct {
+	calls  int
+	tokens []string
+}
+
+func (p *countingProvider) Autocomplete(ctx context.Context, query Query) ([]Suggestion, error) {
+	p.calls++
+	p.tokens = append(p.tokens, query.SessionToken)
+	return []Suggestion{{PlaceID: "p1", Description: query.Input + " Street"}}, nil
+}
+
+func TestAutocompleteCachesAndIssuesSession(t *testing.T) {
+	provider := &countingProvider{}
+	app := fiber.New()
+	app.Use(realip.New(realip.Config{TrustedProxies: []string{"0.0.0.0"}}))
+	NewHandler(Config{Provider: provider, RateLimit: 3}).Register(app.Group("/api/address"))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/api/address/autocomplete?input=Jalan", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	token := response.Header.Get(HeaderSessionToken)
+	assert.NotEmpty(t, token)
+
+	request := httptest.NewRequest("GET", "/api/address/autocomplete?input=jalan", nil)
+	request.Header.Set(HeaderSessionToken, token)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	var body struct {
+		Session string `json:"session"`
+		Cached  bool   `json:"cached"`
+	}
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Equal(t, token, body.Session)
+	assert.True(t, body.Cached)
+	assert.Equal(t, 1, provider.calls)
+	assert.Equal(t, []string{token}, provider.tokens)
+
+	// Too short to be worth a provider call, but still counted.
+	response, err = app.Test(httptest.NewRequest("GET", "/api/address/autocomplete?input=Ja", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, 1, provider.calls)
+
+	response, err = app.Test(httptest.NewRequest("GET", "/api/address/autocomplete?input=Jalan", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, response.StatusCode)
+
+	// Clients behind the same proxy are limited one by one.
+	request = httptest.NewRequest("GET", "/api/address/autocomplete?input=Jalan", nil)
+	request.Header.Set("X-Forwarded-For", "203.0.113.7")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
+
+func TestGooglePlaces(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.URL.Query().Get("key"))
+		assert.Equal(t, "tok", r.URL.Query().Get("sessiontoken"))
+		assert.Equal(t, "country:id", r.URL.Query().Get("components"))
+		w.Write([]byte(`{"status":"OK","predictions":[{"place_id":"abc","description":"Jalan Sudirman, Jakarta","structured_formatting":{"main_text":"Jalan Sudirman","secondary_text":"Jakarta"}}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewGooglePlaces("secret")
+	provider.BaseURL = server.URL
+	suggestions, err := provider.Autocomplete(context.Background(), Query{Input: "Jalan Sud", Country: "id", SessionToken: "tok"})
+	assert.Nil(t, err)
+	assert.Equal(t, []Suggestion{{PlaceID: "abc", Description: "Jalan Sudirman, Jakarta", MainText: "Jalan Sudirman", Secondary: "Jakarta"}}, suggestions)
+}
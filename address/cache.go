@@ -0,0 +1,60 @@
+package address
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	suggestions []Suggestion
+	expires     time.Time
+}
+
+// cache keeps provider answers for identical lookups across sessions.
+type cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[string]cacheEntry
+}
+
+func newCache(ttl time.Duration, max int) *cache {
+	return &cache{ttl: ttl, max: max, entries: map[string]cacheEntry{}}
+}
+
+func (c *cache) get(key string) ([]Suggestion, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.suggestions, true
+}
+
+func (c *cache) set(key string, suggestions []Suggestion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.max {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) >= c.max {
+		// Still full of live entries: drop an arbitrary one.
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = cacheEntry{suggestions: suggestions, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,112 @@
+package address
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"belajar-golang-fiber/middleware/realip"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// HeaderSessionToken carries the autocomplete session token. Clients send
+// back the token from the first response until the address is picked.
+const HeaderSessionToken = "X-Session-Token"
+
+// Config defines the config for Handler.
+type Config struct {
+	Provider Provider
+
+	// MinLength is the shortest input forwarded to the provider.
+	//
+	// Optional. Default: 3
+	MinLength int
+
+	// CacheTTL is how long identical lookups are answered from memory.
+	//
+	// Optional. Default: 24 * time.Hour
+	CacheTTL time.Duration
+
+	// CacheSize caps the number of cached lookups.
+	//
+	// Optional. Default: 10000
+	CacheSize int
+
+	// RateLimit is the number of lookups allowed per client, by the
+	// address realip found for it, per minute.
+	//
+	// Optional. Default: 60
+	RateLimit int
+}
+
+// Handler proxies address autocomplete to a Provider.
+type Handler struct {
+	config Config
+	cache  *cache
+}
+
+func NewHandler(config Config) *Handler {
+	if config.MinLength <= 0 {
+		config.MinLength = 3
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = 24 * time.Hour
+	}
+	if config.CacheSize <= 0 {
+		config.CacheSize = 10000
+	}
+	if config.RateLimit <= 0 {
+		config.RateLimit = 60
+	}
+	return &Handler{config: config, cache: newCache(config.CacheTTL, config.CacheSize)}
+}
+
+// Register mounts the routes on router, e.g. app.Group("/api/address").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/autocomplete", limiter.New(limiter.Config{
+		Max:          h.config.RateLimit,
+		Expiration:   time.Minute,
+		KeyGenerator: realip.IP,
+	}), h.autocomplete)
+}
+
+func (h *Handler) autocomplete(ctx *fiber.Ctx) error {
+	token := ctx.Get(HeaderSessionToken, ctx.Query("session"))
+	if token == "" {
+		token = utils.UUIDv4()
+	}
+	ctx.Set(HeaderSessionToken, token)
+
+	query := Query{
+		Input:        strings.TrimSpace(ctx.Query("input")),
+		Country:      strings.ToLower(ctx.Query("country")),
+		Language:     ctx.Query("language"),
+		SessionToken: token,
+	}
+	if utf8.RuneCountInString(query.Input) < h.config.MinLength {
+		return ctx.JSON(fiber.Map{
+			"session":     token,
+			"suggestions": []Suggestion{},
+		})
+	}
+
+	key := strings.ToLower(query.Input) + "|" + query.Country + "|" + query.Language
+	suggestions, cached := h.cache.get(key)
+	if !cached {
+		var err error
+		suggestions, err = h.config.Provider.Autocomplete(ctx.UserContext(), query)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadGateway, err.Error())
+		}
+		h.cache.set(key, suggestions)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"session":     token,
+		"cached":      cached,
+		"suggestions": suggestions,
+	})
+}
@@ -0,0 +1,108 @@
+package address
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"belajar-golang-fiber/httpclient"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Suggestion is one autocomplete prediction.
+type Suggestion struct {
+	PlaceID     string `json:"place_id"`
+	Description string `json:"description"`
+	MainText    string `json:"main_text,omitempty"`
+	Secondary   string `json:"secondary_text,omitempty"`
+}
+
+// Query is an autocomplete lookup. SessionToken groups the keystrokes of
+// one address entry so the provider bills them as a single session.
+type Query struct {
+	Input        string
+	Country      string
+	Language     string
+	SessionToken string
+}
+
+// Provider looks up address predictions.
+type Provider interface {
+	Autocomplete(ctx context.Context, query Query) ([]Suggestion, error)
+}
+
+// GooglePlaces calls the Places Autocomplete web service.
+type GooglePlaces struct {
+	Key     string
+	BaseURL string
+	Client  *httpclient.Client
+}
+
+const googlePlacesURL = "https://maps.googleapis.com/maps/api/place/autocomplete/json"
+
+func NewGooglePlaces(key string) *GooglePlaces {
+	return &GooglePlaces{
+		Key:     key,
+		BaseURL: googlePlacesURL,
+		Client:  httpclient.New(httpclient.Config{Name: "address", Timeout: 5 * time.Second}),
+	}
+}
+
+type googleResponse struct {
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message"`
+	Predictions  []struct {
+		PlaceID     string `json:"place_id"`
+		Description string `json:"description"`
+		Structured  struct {
+			MainText      string `json:"main_text"`
+			SecondaryText string `json:"secondary_text"`
+		} `json:"structured_formatting"`
+	} `json:"predictions"`
+}
+
+func (g *GooglePlaces) Autocomplete(ctx context.Context, query Query) ([]Suggestion, error) {
+	params := url.Values{}
+	params.Set("input", query.Input)
+	params.Set("key", g.Key)
+	params.Set("types", "address")
+	if query.SessionToken != "" {
+		params.Set("sessiontoken", query.SessionToken)
+	}
+	if query.Country != "" {
+		params.Set("components", "country:"+query.Country)
+	}
+	if query.Language != "" {
+		params.Set("language", query.Language)
+	}
+
+	response, err := g.Client.Get(ctx, g.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status != fiber.StatusOK {
+		return nil, fmt.Errorf("address: provider returned %d", response.Status)
+	}
+
+	var body googleResponse
+	if err := json.Unmarshal(response.Body, &body); err != nil {
+		return nil, err
+	}
+	if body.Status != "OK" && body.Status != "ZERO_RESULTS" {
+		return nil, fmt.Errorf("address: provider status %s: %s", body.Status, body.ErrorMessage)
+	}
+
+	suggestions := make([]Suggestion, 0, len(body.Predictions))
+	for _, prediction := range body.Predictions {
+		suggestions = append(suggestions, Suggestion{
+			PlaceID:     prediction.PlaceID,
+			Description: prediction.Description,
+			MainText:    prediction.Structured.MainText,
+			Secondary:   prediction.Structured.SecondaryText,
+		})
+	}
+	return suggestions, nil
+}
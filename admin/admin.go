@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/domains"
+	"belajar-golang-fiber/legalhold"
+	"belajar-golang-fiber/middleware/adminauth"
+	"belajar-golang-fiber/middleware/ipfilter"
+	"belajar-golang-fiber/middleware/rbac"
+	"belajar-golang-fiber/moderation"
+	"belajar-golang-fiber/reports"
+	"belajar-golang-fiber/sequence"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/tenant"
+	"belajar-golang-fiber/terms"
+	"belajar-golang-fiber/user"
+	"belajar-golang-fiber/webhooks"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/monitor"
+)
+
+// Config holds what the admin area needs from the main application.
+type Config struct {
+	Auth  adminauth.Config
+	Users user.Repository
+	// Sessions, when set, has the sessions of users locked or required
+	// to reset their password at /users revoked.
+	Sessions  *session.Manager
+	Sequences *sequence.Service
+	// Audit records every change made through the admin area and is
+	// listed at /audit.
+	Audit *audit.Logger
+	// Webhooks, when set, has its subscriptions managed at /webhooks.
+	Webhooks *webhooks.Dispatcher
+	// Moderation, when set, has its review queue at /moderation.
+	Moderation *moderation.Moderator
+	// Reports, when set, has its triage queue at /reports.
+	Reports *reports.Service
+	// LegalHolds, when set, has its holds managed at /legal-holds.
+	LegalHolds *legalhold.Service
+	// Terms, when set, has its versions published at /terms.
+	Terms *terms.Service
+	// Domains, when set, has tenants' custom domains managed at /domains.
+	Domains *domains.Service
+	// IPFilter, when set, has the rules of its Store managed at
+	// /ip-rules. A rule denying the admin's own network locks them out.
+	IPFilter *ipfilter.Filter
+	// Tenants, when set, has its tenants managed at /tenants, and
+	// TenantUsage reports their quotas at /tenants/:id/usage.
+	Tenants     tenant.Store
+	TenantUsage func(ctx context.Context, tenant *tenant.Tenant) (tenant.Usage, error)
+	// Reload, when set, is called by POST /config/reload to re-read the
+	// configuration. Its error is answered 422 Unprocessable Entity.
+	Reload func(ctx context.Context) error
+	// RequestMethods must be those of the app mounting the admin area, as
+	// Fiber merges the routes of mounted apps method by method. Nil means
+	// Fiber's defaults.
+	RequestMethods []string
+}
+
+// New builds the admin sub-application, meant to be mounted with
+// app.Mount("/admin", admin.New(cfg)). Every route requires the admin role
+// and errors are answered as JSON.
+func New(cfg Config) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler:   ErrorHandler,
+		RequestMethods: cfg.RequestMethods,
+	})
+
+	app.Use(adminauth.New(cfg.Auth), rbac.Require(adminauth.RoleAdmin))
+	if cfg.Audit != nil {
+		app.Use(cfg.Audit.Changes())
+		app.Get("/audit", audit.ListHandler(cfg.Audit.Store))
+	}
+
+	app.Get("/monitor", monitor.New(monitor.Config{
+		Title: "Fiber Monitor (pid " + strconv.Itoa(os.Getpid()) + ")",
+	}))
+
+	accounts := &UserHandler{
+		Users:    user.NewService(cfg.Users, ""),
+		Sessions: cfg.Sessions,
+		Audit:    cfg.Audit,
+	}
+	accounts.Register(app.Group("/users"))
+
+	if cfg.Sequences != nil {
+		sequences := &sequence.Handler{Service: cfg.Sequences}
+		sequences.Register(app.Group("/sequences"))
+	}
+
+	if cfg.Webhooks != nil {
+		subscriptions := &webhooks.Handler{Dispatcher: cfg.Webhooks}
+		subscriptions.Register(app.Group("/webhooks"))
+	}
+
+	if cfg.Moderation != nil {
+		review := &moderation.Handler{Moderator: cfg.Moderation}
+		review.Register(app.Group("/moderation"))
+	}
+
+	if cfg.Reports != nil {
+		triage := &reports.AdminHandler{Service: cfg.Reports}
+		triage.Register(app.Group("/reports"))
+	}
+
+	if cfg.LegalHolds != nil {
+		holds := &legalhold.Handler{Service: cfg.LegalHolds}
+		holds.Register(app.Group("/legal-holds"))
+	}
+
+	if cfg.Terms != nil {
+		versions := &terms.AdminHandler{Service: cfg.Terms}
+		versions.Register(app.Group("/terms"))
+	}
+
+	if cfg.Domains != nil {
+		custom := &domains.Handler{Service: cfg.Domains}
+		custom.Register(app.Group("/domains"))
+	}
+
+	if cfg.IPFilter != nil {
+		rules := &ipfilter.Handler{Filter: cfg.IPFilter}
+		rules.Register(app.Group("/ip-rules"))
+	}
+
+	if cfg.Tenants != nil {
+		tenants := &tenant.Handler{Store: cfg.Tenants, Usage: cfg.TenantUsage}
+		tenants.Register(app.Group("/tenants"))
+	}
+
+	if cfg.Reload != nil {
+		app.Post("/config/reload", func(ctx *fiber.Ctx) error {
+			if err := cfg.Reload(ctx.UserContext()); err != nil {
+				return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+			}
+			return ctx.SendStatus(fiber.StatusNoContent)
+		})
+	}
+
+	return app
+}
+
+// ErrorHandler answers {"error": message} with the error's status code.
+func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	code := fiber.StatusInternalServerError
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		code = fiberErr.Code
+	}
+	return ctx.Status(code).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,186 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/middleware/adminauth"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountedAdminApp(t *testing.T) {
+	users := user.NewMemoryRepository()
+	users.Create(context.Background(), &user.User{ID: "1", Username: "salman", CreatedAt: time.Now()})
+
+	app := fiber.New()
+	app.Mount("/admin", New(Config{
+		Auth:  adminauth.Config{Token: "rahasia"},
+		Users: users,
+	}))
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("Hello, World!")
+	})
+
+	request := httptest.NewRequest("GET", "/admin/users", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	bytes, _ := io.ReadAll(response.Body)
+	assert.Equal(t, `{"error":"Unauthorized"}`, string(bytes))
+
+	request = httptest.NewRequest("GET", "/admin/users", nil)
+	request.Header.Set(adminauth.HeaderAdminToken, "rahasia")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	bytes, _ = io.ReadAll(response.Body)
+	assert.Contains(t, string(bytes), `"username":"salman"`)
+
+	request = httptest.NewRequest("GET", "/", nil)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
+
+func TestMountedWithExtraMethods(t *testing.T) {
+	methods := append(append([]string{}, fiber.DefaultMethods...), "PROPFIND")
+	app := fiber.New(fiber.Config{RequestMethods: methods})
+	app.Mount("/admin", New(Config{
+		Auth:           adminauth.Config{Token: "rahasia"},
+		Users:          user.NewMemoryRepository(),
+		RequestMethods: methods,
+	}))
+
+	request := httptest.NewRequest("GET", "/admin/users", nil)
+	request.Header.Set(adminauth.HeaderAdminToken, "rahasia")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
+
+func TestSoftDeletedUsers(t *testing.T) {
+	ctx := context.Background()
+	users := user.NewMemoryRepository()
+	users.Create(ctx, &user.User{ID: "1", Username: "salman", CreatedAt: time.Now()})
+	users.Delete(ctx, "1")
+
+	app := fiber.New()
+	app.Mount("/admin", New(Config{
+		Auth:  adminauth.Config{Token: "rahasia"},
+		Users: users,
+	}))
+	send := func(method, target string) (int, string) {
+		request := httptest.NewRequest(method, target, nil)
+		request.Header.Set(adminauth.HeaderAdminToken, "rahasia")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		bytes, _ := io.ReadAll(response.Body)
+		return response.StatusCode, string(bytes)
+	}
+
+	_, body := send("GET", "/admin/users")
+	assert.Equal(t, "[]", body)
+	_, body = send("GET", "/admin/users?include_deleted=true")
+	assert.Contains(t, body, `"deleted_at":`)
+
+	status, body := send("POST", "/admin/users/1/restore")
+	assert.Equal(t, 200, status)
+	assert.NotContains(t, body, `"deleted_at":`)
+	status, _ = send("POST", "/admin/users/1/restore")
+	assert.Equal(t, 404, status)
+
+	_, body = send("GET", "/admin/users")
+	assert.Contains(t, body, `"username":"salman"`)
+}
+
+func TestUserManagement(t *testing.T) {
+	ctx := context.Background()
+	users := user.NewMemoryRepository()
+	users.Create(ctx, &user.User{ID: "1", Username: "salman", CreatedAt: time.Now()})
+	sessions := session.NewMemoryStore()
+	sessions.Create(ctx, &session.Session{ID: "s1", UserID: "1", TokenHash: "h", ExpiresAt: time.Now().Add(time.Hour)})
+	events := audit.NewMemoryStore()
+
+	app := New(Config{
+		Auth:     adminauth.Config{Token: "rahasia"},
+		Users:    users,
+		Sessions: session.NewManager(session.Config{Store: sessions}),
+		Audit:    audit.NewLogger(events),
+	})
+	send := func(method, target, body string) (int, string) {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set(adminauth.HeaderAdminToken, "rahasia")
+		request.Header.Set("Content-Type", "application/json")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		bytes, _ := io.ReadAll(response.Body)
+		return response.StatusCode, string(bytes)
+	}
+
+	status, body := send("POST", "/users/1/lock", `{"reason":"chargeback"}`)
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"locked_at":`)
+	left, _ := sessions.ListByUser(ctx, "1")
+	assert.Empty(t, left, "locking signs the user out")
+	status, body = send("POST", "/users/1/unlock", "")
+	assert.Equal(t, 200, status)
+	assert.NotContains(t, body, `"locked_at":`)
+
+	status, body = send("PUT", "/users/1/roles", `{"roles":["support","admin"]}`)
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"roles":["admin","support"]`)
+	status, body = send("PUT", "/users/1/roles", `{"roles":["Root"]}`)
+	assert.Equal(t, 422, status)
+	assert.Contains(t, body, `"field":"roles"`)
+
+	status, body = send("POST", "/users/1/password-reset", "")
+	assert.Equal(t, 200, status)
+	assert.Contains(t, body, `"password_reset_required":true`)
+	status, _ = send("POST", "/users/9/lock", "")
+	assert.Equal(t, 404, status)
+
+	recorded, _, _ := events.List(ctx, audit.Filter{Action: audit.ActionUserRoles})
+	assert.Len(t, recorded, 1)
+	assert.Equal(t, "user:1", recorded[0].Target)
+	assert.Equal(t, map[string]string{"from": "", "to": "admin,support"}, recorded[0].Details)
+	recorded, _, _ = events.List(ctx, audit.Filter{Action: audit.ActionUserLock})
+	assert.Equal(t, "chargeback", recorded[0].Details["reason"])
+}
+
+func TestConfigReload(t *testing.T) {
+	failure := errors.New("LOG_LEVEL: unknown level \"loud\"")
+	app := New(Config{
+		Auth:  adminauth.Config{Token: "rahasia"},
+		Users: user.NewMemoryRepository(),
+		Reload: func(ctx context.Context) error {
+			return failure
+		},
+	})
+
+	request := httptest.NewRequest("POST", "/config/reload", nil)
+	request.Header.Set(adminauth.HeaderAdminToken, "rahasia")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 422, response.StatusCode)
+	bytes, _ := io.ReadAll(response.Body)
+	assert.Equal(t, `{"error":"LOG_LEVEL: unknown level \"loud\""}`, string(bytes))
+
+	failure = nil
+	request = httptest.NewRequest("POST", "/config/reload", nil)
+	request.Header.Set(adminauth.HeaderAdminToken, "rahasia")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 204, response.StatusCode)
+}
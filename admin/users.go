@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"errors"
+	"strings"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/mapping"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// UserHandler manages accounts: locking them, granting roles and having
+// their owners pick a new password. Every change is audited.
+type UserHandler struct {
+	Users *user.Service
+	// Sessions, when set, has the sessions and refresh tokens of locked
+	// users and of those required to reset their password revoked.
+	Sessions *session.Manager
+	Audit    *audit.Logger
+}
+
+// Register mounts the routes on router, e.g. adminApp.Group("/users").
+func (h *UserHandler) Register(router fiber.Router) {
+	router.Get("/", h.list)
+	router.Get("/:id", h.get)
+	router.Post("/:id/restore", h.restore)
+	router.Post("/:id/lock", h.lock)
+	router.Post("/:id/unlock", h.unlock)
+	router.Put("/:id/roles", h.roles)
+	router.Post("/:id/password-reset", h.passwordReset)
+}
+
+func (h *UserHandler) list(ctx *fiber.Ctx) error {
+	users, _, err := h.Users.Users.List(ctx.UserContext(), user.ListOptions{
+		IncludeDeleted: ctx.QueryBool("include_deleted"),
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(mapping.UserResponses(users))
+}
+
+func (h *UserHandler) get(ctx *fiber.Ctx) error {
+	found, err := h.Users.Users.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return userError(ctx, err)
+	}
+	return ctx.JSON(mapping.UserResponse(found))
+}
+
+func (h *UserHandler) restore(ctx *fiber.Ctx) error {
+	err := h.Users.Users.Restore(ctx.UserContext(), ctx.Params("id"))
+	if errors.Is(err, user.ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "no deleted user with this id")
+	}
+	if err != nil {
+		return err
+	}
+	restored, err := h.Users.Users.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(mapping.UserResponse(restored))
+}
+
+// lock takes an optional {"reason": "..."} body, kept in the audit log.
+func (h *UserHandler) lock(ctx *fiber.Ctx) error {
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "malformed body")
+		}
+	}
+	locked, err := h.Users.Lock(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return userError(ctx, err)
+	}
+	if err := h.revoke(ctx, locked.ID); err != nil {
+		return err
+	}
+	h.Audit.Log(ctx, audit.ActionUserLock, audit.OutcomeSuccess, "user:"+locked.ID, map[string]string{
+		"reason": utils.CopyString(body.Reason),
+	})
+	return ctx.JSON(mapping.UserResponse(locked))
+}
+
+func (h *UserHandler) unlock(ctx *fiber.Ctx) error {
+	unlocked, err := h.Users.Unlock(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return userError(ctx, err)
+	}
+	h.Audit.Log(ctx, audit.ActionUserUnlock, audit.OutcomeSuccess, "user:"+unlocked.ID, nil)
+	return ctx.JSON(mapping.UserResponse(unlocked))
+}
+
+// roles replaces the roles of the user with those of {"roles": [...]}.
+func (h *UserHandler) roles(ctx *fiber.Ctx) error {
+	var body struct {
+		Roles []string `json:"roles"`
+	}
+	if err := ctx.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "malformed body")
+	}
+	previous, err := h.Users.Users.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return userError(ctx, err)
+	}
+	changed, err := h.Users.SetRoles(ctx.UserContext(), previous.ID, body.Roles)
+	if err != nil {
+		return userError(ctx, err)
+	}
+	h.Audit.Log(ctx, audit.ActionUserRoles, audit.OutcomeSuccess, "user:"+changed.ID, map[string]string{
+		"from": strings.Join(previous.Roles, ","),
+		"to":   strings.Join(changed.Roles, ","),
+	})
+	return ctx.JSON(mapping.UserResponse(changed))
+}
+
+// passwordReset signs the user out everywhere; they keep their password
+// to sign in again, only to change it.
+func (h *UserHandler) passwordReset(ctx *fiber.Ctx) error {
+	reset, err := h.Users.RequirePasswordReset(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return userError(ctx, err)
+	}
+	if err := h.revoke(ctx, reset.ID); err != nil {
+		return err
+	}
+	h.Audit.Log(ctx, audit.ActionUserReset, audit.OutcomeSuccess, "user:"+reset.ID, nil)
+	return ctx.JSON(mapping.UserResponse(reset))
+}
+
+func (h *UserHandler) revoke(ctx *fiber.Ctx, userID string) error {
+	if h.Sessions == nil {
+		return nil
+	}
+	return h.Sessions.RevokeUser(ctx.UserContext(), userID)
+}
+
+// userError answers the errors of package user with their status code.
+func userError(ctx *fiber.Ctx, err error) error {
+	var invalid *user.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": invalid.Error(),
+			"field": invalid.Field,
+		})
+	case errors.Is(err, user.ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, user.ErrVersionConflict):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return err
+}
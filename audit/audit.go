@@ -0,0 +1,216 @@
+// Package audit records security relevant actions in an append-only log:
+// who did what, from where and when.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/logger"
+	"belajar-golang-fiber/txn"
+)
+
+// Actions recorded by the application.
+const (
+	ActionRegister       = "account.register"
+	ActionLogin          = "account.login"
+	ActionLoginFailed    = "account.login_failed"
+	ActionLogout         = "account.logout"
+	ActionLogoutAll      = "account.logout_all"
+	ActionRefreshReused  = "account.refresh_reused"
+	ActionRememberReused = "account.remember_reused"
+	ActionPasswordChange = "account.password_change"
+	ActionSessionRevoke  = "account.session_revoke"
+	ActionFileUpload     = "file.upload"
+	ActionFileRejected   = "file.rejected"
+	ActionFileDownload   = "file.download"
+	ActionFileTrash      = "file.trash"
+	ActionFileRestore    = "file.restore"
+	ActionFilePurge      = "file.purge"
+	ActionFileReplace    = "file.replace"
+	ActionFileRevert     = "file.revert"
+	ActionAdminChange    = "admin.change"
+	ActionUserLock       = "user.lock"
+	ActionUserUnlock     = "user.unlock"
+	ActionUserRoles      = "user.roles"
+	ActionUserReset      = "user.password_reset"
+	ActionHoldPlace      = "legal_hold.place"
+	ActionHoldRelease    = "legal_hold.release"
+	ActionHoldBlocked    = "legal_hold.blocked"
+)
+
+// Outcomes of an action.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Event is one audit record.
+type Event struct {
+	ID        string            `json:"id"`
+	Time      time.Time         `json:"time"`
+	Action    string            `json:"action"`
+	Outcome   string            `json:"outcome"`
+	Actor     string            `json:"actor"`
+	IP        string            `json:"ip"`
+	RequestID string            `json:"request_id,omitempty"`
+	Target    string            `json:"target,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// Filter narrows a listing; empty fields match everything.
+type Filter struct {
+	Action string
+	Actor  string
+	Offset int
+	Limit  int
+}
+
+func (f Filter) match(event *Event) bool {
+	return (f.Action == "" || event.Action == f.Action) && (f.Actor == "" || event.Actor == f.Actor)
+}
+
+// Store keeps events. It can only be appended to.
+type Store interface {
+	Append(ctx context.Context, event *Event) error
+	// List returns matching events, newest first, and their total count.
+	List(ctx context.Context, filter Filter) ([]*Event, int, error)
+}
+
+// MemoryStore keeps events in process memory.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	events []*Event
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Append(ctx context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *event
+	s.events = append(s.events, &copied)
+	txn.OnRollback(ctx, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.events = slices.DeleteFunc(s.events, func(e *Event) bool { return e == &copied })
+	})
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]*Event, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return page(s.events, filter), count(s.events, filter), nil
+}
+
+// FileStore appends events as JSON lines to a file opened in append
+// mode, so prefork children can share it.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileStore opens, or creates, the log at path.
+func NewFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{path: path, file: file}, nil
+}
+
+// Append writes the event at once, or under a transaction once it
+// commits, since a written line cannot be taken back.
+func (s *FileStore) Append(ctx context.Context, event *Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if txn.From(ctx) != nil {
+		txn.AfterCommit(ctx, func(ctx context.Context) {
+			if err := s.write(line); err != nil {
+				logger.FromContext(ctx).Error("audit: event not recorded", "action", event.Action, "actor", event.Actor, "error", err)
+			}
+		})
+		return nil
+	}
+	return s.write(line)
+}
+
+func (s *FileStore) write(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// One write per event keeps lines from different processes whole.
+	_, err := s.file.Write(append(line, '\n'))
+	return err
+}
+
+// List reads the whole log; it is meant for occasional admin lookups.
+func (s *FileStore) List(ctx context.Context, filter Filter) ([]*Event, int, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	var events []*Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		event := &Event{}
+		if json.Unmarshal(scanner.Bytes(), event) == nil {
+			events = append(events, event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	return page(events, filter), count(events, filter), nil
+}
+
+// Close closes the log file.
+func (s *FileStore) Close() error {
+	return s.file.Close()
+}
+
+// page returns a page of the matching events, newest first.
+func page(events []*Event, filter Filter) []*Event {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	list := []*Event{}
+	skipped := 0
+	for i := len(events) - 1; i >= 0 && len(list) < limit; i-- {
+		if !filter.match(events[i]) {
+			continue
+		}
+		if skipped < filter.Offset {
+			skipped++
+			continue
+		}
+		copied := *events[i]
+		list = append(list, &copied)
+	}
+	return list
+}
+
+func count(events []*Event, filter Filter) int {
+	total := 0
+	for _, event := range events {
+		if filter.match(event) {
+			total++
+		}
+	}
+	return total
+}
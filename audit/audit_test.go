@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"belajar-golang-fiber/middleware/adminauth"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogRecordsRequest(t *testing.T) {
+	store := NewMemoryStore()
+	logger := NewLogger(store)
+	app := fiber.New()
+	app.Use(requestid.New())
+	app.Post("/login", func(ctx *fiber.Ctx) error {
+		logger.Log(ctx, ActionLoginFailed, OutcomeFailure, "", map[string]string{"username": "salman"})
+		return ctx.SendStatus(fiber.StatusUnauthorized)
+	})
+
+	request := httptest.NewRequest("POST", "/login", nil)
+	request.Header.Set(fiber.HeaderXRequestID, "req-1")
+	_, err := app.Test(request)
+	assert.Nil(t, err)
+
+	events, total, err := store.List(context.Background(), Filter{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, ActionLoginFailed, events[0].Action)
+	assert.Equal(t, OutcomeFailure, events[0].Outcome)
+	assert.Equal(t, "anonymous", events[0].Actor)
+	assert.Equal(t, "0.0.0.0", events[0].IP)
+	assert.Equal(t, "req-1", events[0].RequestID)
+	assert.Equal(t, "salman", events[0].Details["username"])
+	assert.NotEmpty(t, events[0].ID)
+	assert.False(t, events[0].Time.IsZero())
+}
+
+func TestNilLoggerRecordsNothing(t *testing.T) {
+	var logger *Logger
+	app := fiber.New()
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		logger.Log(ctx, ActionLogin, OutcomeSuccess, "", nil)
+		return ctx.SendString("OK")
+	})
+
+	response, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
+
+func TestChangesAndListHandler(t *testing.T) {
+	store := NewMemoryStore()
+	logger := NewLogger(store)
+	app := fiber.New()
+	app.Use(adminauth.New(adminauth.Config{Users: map[string]string{"root": "rahasia"}}))
+	app.Use(logger.Changes())
+	app.Get("/audit", ListHandler(store))
+	app.Delete("/users/:id", func(ctx *fiber.Ctx) error {
+		if ctx.Params("id") == "404" {
+			return fiber.ErrNotFound
+		}
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+
+	for _, id := range []string{"1", "404", "2"} {
+		request := httptest.NewRequest("DELETE", "/users/"+id, nil)
+		request.SetBasicAuth("root", "rahasia")
+		_, err := app.Test(request)
+		assert.Nil(t, err)
+	}
+
+	request := httptest.NewRequest("GET", "/audit?per_page=2&page=1", nil)
+	request.SetBasicAuth("root", "rahasia")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var list ListResponse
+	bytes, _ := io.ReadAll(response.Body)
+	assert.Nil(t, json.Unmarshal(bytes, &list))
+	assert.Equal(t, 3, list.Total)
+	assert.Len(t, list.Data, 2)
+	assert.Equal(t, "/users/2", list.Data[0].Target)
+	assert.Equal(t, "admin:root", list.Data[0].Actor)
+	assert.Equal(t, OutcomeFailure, list.Data[1].Outcome)
+	assert.Equal(t, "404", list.Data[1].Details["status"])
+
+	request = httptest.NewRequest("GET", "/audit?per_page=0", nil)
+	request.SetBasicAuth("root", "rahasia")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestFileStoreAppendsAndLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	store, err := NewFileStore(path)
+	assert.Nil(t, err)
+	logger := NewLogger(store)
+
+	logger.Append(context.Background(), &Event{Action: ActionLogin, Actor: "user:1"})
+	logger.Append(context.Background(), &Event{Action: ActionFileUpload, Actor: "user:2"})
+	logger.Append(context.Background(), &Event{Action: ActionLogin, Actor: "user:2"})
+	assert.Nil(t, store.Close())
+
+	reopened, err := NewFileStore(path)
+	assert.Nil(t, err)
+	defer reopened.Close()
+
+	events, total, err := reopened.List(context.Background(), Filter{Action: ActionLogin})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "user:2", events[0].Actor)
+	assert.Equal(t, "user:1", events[1].Actor)
+
+	events, total, err = reopened.List(context.Background(), Filter{Actor: "user:2", Offset: 1, Limit: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, events, 1)
+	assert.Equal(t, ActionFileUpload, events[0].Action)
+}
@@ -0,0 +1,36 @@
+package audit
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListResponse is a page of events.
+type ListResponse struct {
+	Data    []*Event `json:"data"`
+	Page    int      `json:"page"`
+	PerPage int      `json:"per_page"`
+	Total   int      `json:"total"`
+}
+
+// ListHandler answers ?page=1&per_page=50, optionally narrowed with
+// ?action= and ?actor=, newest events first.
+func ListHandler(store Store) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		page := ctx.QueryInt("page", 1)
+		perPage := ctx.QueryInt("per_page", 50)
+		if page < 1 || perPage < 1 || perPage > 500 {
+			return fiber.NewError(fiber.StatusBadRequest, "page must be at least 1 and per_page between 1 and 500")
+		}
+
+		events, total, err := store.List(ctx.UserContext(), Filter{
+			Action: ctx.Query("action"),
+			Actor:  ctx.Query("actor"),
+			Offset: (page - 1) * perPage,
+			Limit:  perPage,
+		})
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(ListResponse{Data: events, Page: page, PerPage: perPage, Total: total})
+	}
+}
@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"belajar-golang-fiber/logger"
+	"belajar-golang-fiber/middleware/adminauth"
+	"belajar-golang-fiber/middleware/realip"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Logger records events about the current request. A nil *Logger
+// records nothing, so handlers can take one optionally.
+type Logger struct {
+	Store Store
+}
+
+func NewLogger(store Store) *Logger {
+	return &Logger{Store: store}
+}
+
+// Log records action on target by whoever sent the request in ctx.
+func (l *Logger) Log(ctx *fiber.Ctx, action, outcome, target string, details map[string]string) {
+	if l == nil {
+		return
+	}
+	requestID, _ := ctx.Locals("requestid").(string)
+	l.Append(ctx.UserContext(), &Event{
+		Action:    action,
+		Outcome:   outcome,
+		Actor:     Actor(ctx),
+		IP:        realip.IP(ctx),
+		RequestID: utils.CopyString(requestID),
+		Target:    target,
+		Details:   details,
+	})
+}
+
+// Append records an event happening outside a request, filling in its ID
+// and time. Failures are logged rather than failing the action.
+func (l *Logger) Append(ctx context.Context, event *Event) {
+	if l == nil {
+		return
+	}
+	if event.ID == "" {
+		event.ID = utils.UUIDv4()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if err := l.Store.Append(ctx, event); err != nil {
+		logger.FromContext(ctx).Error("audit: event not recorded", "action", event.Action, "actor", event.Actor, "error", err)
+	}
+}
+
+// Actor names who sent the request: "user:<id>" for a signed in user,
+// "admin:<name>" or "admin" for the admin area and "anonymous" otherwise.
+func Actor(ctx *fiber.Ctx) string {
+	if id := session.UserID(ctx); id != "" {
+		return "user:" + id
+	}
+	if role, _ := ctx.Locals("role").(string); role == adminauth.RoleAdmin {
+		if name, _ := ctx.Locals("username").(string); name != "" {
+			return "admin:" + utils.CopyString(name)
+		}
+		return "admin"
+	}
+	return "anonymous"
+}
+
+// Changes records every request, other than GET, HEAD and OPTIONS, that
+// passes through it as ActionAdminChange.
+func (l *Logger) Changes() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		switch ctx.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return ctx.Next()
+		}
+
+		err := ctx.Next()
+		status := ctx.Response().StatusCode()
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			status = fiberErr.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+		outcome := OutcomeSuccess
+		if status >= fiber.StatusBadRequest {
+			outcome = OutcomeFailure
+		}
+		l.Log(ctx, ActionAdminChange, outcome, utils.CopyString(ctx.Path()), map[string]string{
+			"method": utils.CopyString(ctx.Method()),
+			"status": strconv.Itoa(status),
+		})
+		return err
+	}
+}
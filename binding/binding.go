@@ -0,0 +1,210 @@
+// Package binding decodes requests into typed structs.
+package binding
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"belajar-golang-fiber/msgpack"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Bind decodes the request body of ctx into a new T, choosing the parser
+// from the Content-Type (JSON, MessagePack, XML or a form), then overlays query string values into fields
+// tagged `query:"name"` and route parameters into fields tagged
+// `params:"name"`. Route parameters win over the query string, which wins
+// over the body.
+//
+// Errors are *fiber.Error values (400 or 415) and can be returned from a
+// handler as is.
+func Bind[T any](ctx *fiber.Ctx) (*T, error) {
+	out := new(T)
+	if err := bind(ctx, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func bind(ctx *fiber.Ctx, out any) error {
+	if err := bindBody(ctx, out); err != nil {
+		return err
+	}
+	return bindRequest(ctx, out)
+}
+
+// bindRequest overlays the query string and route parameters.
+func bindRequest(ctx *fiber.Ctx, out any) error {
+	value := reflect.ValueOf(out).Elem()
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	if err := bindTagged(value, "query", func(name string) (string, bool) {
+		raw := ctx.Context().QueryArgs().Peek(name)
+		return string(raw), raw != nil
+	}); err != nil {
+		return err
+	}
+	return bindTagged(value, "params", func(name string) (string, bool) {
+		raw := utils.CopyString(ctx.Params(name))
+		return raw, raw != ""
+	})
+}
+
+func bindBody(ctx *fiber.Ctx, out any) error {
+	body := ctx.Body()
+	if len(body) == 0 {
+		return nil
+	}
+
+	contentType := strings.ToLower(ctx.Get(fiber.HeaderContentType))
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.TrimSpace(contentType)
+
+	var err error
+	switch {
+	case contentType == fiber.MIMEApplicationJSON || strings.HasSuffix(contentType, "+json"):
+		if err = CheckJSON(body, JSONLimitsDefault); err == nil {
+			err = ctx.App().Config().JSONDecoder(body, out)
+		}
+	case msgpack.IsMIME(contentType):
+		// Converted to JSON, MessagePack meets the same limits and
+		// decoder as JSON does.
+		if body, err = msgpack.ToJSON(body); err == nil {
+			if err = CheckJSON(body, JSONLimitsDefault); err == nil {
+				err = ctx.App().Config().JSONDecoder(body, out)
+			}
+		}
+	case contentType == fiber.MIMEApplicationXML || contentType == fiber.MIMETextXML || strings.HasSuffix(contentType, "+xml"):
+		err = DecodeXML(body, out, XMLLimitsDefault)
+	case contentType == fiber.MIMEApplicationForm || contentType == fiber.MIMEMultipartForm:
+		// Form values point into fasthttp's request buffer, which is
+		// reused after the handler returns.
+		if err = ctx.BodyParser(out); err == nil {
+			copyStrings(reflect.ValueOf(out).Elem())
+		}
+	default:
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "unsupported content type "+contentType)
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+// taggedField is an exported field carrying a query or params tag.
+type taggedField struct {
+	index int
+	name  string
+}
+
+// taggedFields caches the tagged fields per struct type and tag, so the
+// tags are parsed once rather than on every request.
+var taggedFields sync.Map
+
+type fieldsKey struct {
+	typ reflect.Type
+	tag string
+}
+
+func fieldsFor(typ reflect.Type, tag string) []taggedField {
+	key := fieldsKey{typ, tag}
+	if cached, ok := taggedFields.Load(key); ok {
+		return cached.([]taggedField)
+	}
+
+	var fields []taggedField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		fields = append(fields, taggedField{index: i, name: name})
+	}
+	taggedFields.Store(key, fields)
+	return fields
+}
+
+func bindTagged(value reflect.Value, tag string, lookup func(string) (string, bool)) error {
+	for _, field := range fieldsFor(value.Type(), tag) {
+		raw, ok := lookup(field.name)
+		if !ok {
+			continue
+		}
+		if err := setString(value.Field(field.index), raw); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, tag+" "+field.name+": "+err.Error())
+		}
+	}
+	return nil
+}
+
+func setString(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.Pointer {
+		target := reflect.New(field.Type().Elem())
+		if err := setString(target.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(target)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	default:
+		return strconv.ErrSyntax
+	}
+	return nil
+}
+
+// copyStrings replaces every string reachable from value with a copy.
+func copyStrings(value reflect.Value) {
+	switch value.Kind() {
+	case reflect.String:
+		if value.CanSet() {
+			value.SetString(utils.CopyString(value.String()))
+		}
+	case reflect.Pointer:
+		if !value.IsNil() {
+			copyStrings(value.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			copyStrings(value.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			copyStrings(value.Index(i))
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package binding
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/msgpack"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type orderRequest struct {
+	UserID  string `params:"userId"`
+	Page    int    `query:"page"`
+	Notify  *bool  `query:"notify"`
+	Product string `json:"product" xml:"product" form:"product"`
+	Amount  int    `json:"amount" xml:"amount" form:"amount"`
+}
+
+func newApp() *fiber.App {
+	app := fiber.New()
+	app.Post("/users/:userId/orders", func(ctx *fiber.Ctx) error {
+		request, err := Bind[orderRequest](ctx)
+		if err != nil {
+			return err
+		}
+		notify := "unset"
+		if request.Notify != nil {
+			notify = "set"
+		}
+		return ctx.JSON(fiber.Map{
+			"user":    request.UserID,
+			"page":    request.Page,
+			"notify":  notify,
+			"product": request.Product,
+			"amount":  request.Amount,
+		})
+	})
+	return app
+}
+
+func TestBind(t *testing.T) {
+	app := newApp()
+	bodies := map[string]string{
+		fiber.MIMEApplicationJSON:            `{"product":"book","amount":2}`,
+		fiber.MIMEApplicationXMLCharsetUTF8:  `<orderRequest><product>book</product><amount>2</amount></orderRequest>`,
+		fiber.MIMEApplicationForm:            `product=book&amount=2`,
+		fiber.MIMEApplicationJSONCharsetUTF8: `{"product":"book","amount":2}`,
+		msgpack.MIMEApplicationMsgPack:       "\x82\xa7product\xa4book\xa6amount\x02",
+	}
+	for contentType, body := range bodies {
+		request := httptest.NewRequest("POST", "/users/42/orders?page=3&notify=true", strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode, contentType)
+
+		bytes, _ := io.ReadAll(response.Body)
+		assert.JSONEq(t, `{"user":"42","page":3,"notify":"set","product":"book","amount":2}`, string(bytes), contentType)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	app := newApp()
+
+	request := httptest.NewRequest("POST", "/users/42/orders", strings.NewReader(`product: book`))
+	request.Header.Set("Content-Type", "text/yaml")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusUnsupportedMediaType, response.StatusCode)
+
+	request = httptest.NewRequest("POST", "/users/42/orders?page=two", nil)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+
+	request = httptest.NewRequest("POST", "/users/42/orders", strings.NewReader(`{"amount":`))
+	request.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+
+	request = httptest.NewRequest("POST", "/users/42/orders", strings.NewReader("\x82\xa7product"))
+	request.Header.Set("Content-Type", "application/x-msgpack")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+}
+
+func TestWith(t *testing.T) {
+	app := fiber.New()
+	app.Post("/users/:userId/orders", func(ctx *fiber.Ctx) error {
+		return With(ctx, func(request *orderRequest) error {
+			return ctx.JSON(fiber.Map{"user": request.UserID, "product": request.Product, "page": request.Page})
+		})
+	})
+
+	for _, body := range []string{`{"product":"book"}`, `{}`} {
+		request := httptest.NewRequest("POST", "/users/7/orders?page=2", strings.NewReader(body))
+		request.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		bytes, _ := io.ReadAll(response.Body)
+		if body == `{}` {
+			// Nothing is left over from the previous request.
+			assert.Equal(t, `{"page":2,"product":"","user":"7"}`, string(bytes))
+		} else {
+			assert.Equal(t, `{"page":2,"product":"book","user":"7"}`, string(bytes))
+		}
+	}
+}
+
+func benchmarkBind(b *testing.B, handler fiber.Handler) {
+	app := fiber.New()
+	app.Post("/users/:userId/orders", handler)
+	h := app.Handler()
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fiber.MethodPost)
+	ctx.Request.SetRequestURI("/users/7/orders?page=2&notify=true")
+	ctx.Request.Header.SetContentType(fiber.MIMEApplicationJSON)
+	ctx.Request.SetBodyString(`{"product":"book","amount":2}`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h(ctx)
+	}
+}
+
+func BenchmarkBind(b *testing.B) {
+	benchmarkBind(b, func(ctx *fiber.Ctx) error {
+		request, err := Bind[orderRequest](ctx)
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(request.Product)
+	})
+}
+
+func BenchmarkWith(b *testing.B) {
+	benchmarkBind(b, func(ctx *fiber.Ctx) error {
+		return With(ctx, func(request *orderRequest) error {
+			return ctx.SendString(request.Product)
+		})
+	})
+}
@@ -0,0 +1,65 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+)
+
+// JSONLimits bounds the JSON documents accepted by CheckJSON. Zero fields
+// are not checked.
+type JSONLimits struct {
+	// MaxDepth is the deepest nesting of objects and arrays.
+	MaxDepth int
+	// MaxKeys caps the object keys in the whole document.
+	MaxKeys int
+	// MaxStringLength is the longest string, key or value, in bytes as
+	// sent, escapes included.
+	MaxStringLength int
+}
+
+// JSONLimitsDefault applies to JSON bodies decoded by Bind and With.
+// Change it only before serving requests.
+var JSONLimitsDefault = JSONLimits{
+	MaxDepth:        32,
+	MaxKeys:         1000,
+	MaxStringLength: 64 * 1024,
+}
+
+// ErrJSONTooComplex rejects documents beyond one of the JSONLimits.
+var ErrJSONTooComplex = errors.New("binding: JSON document exceeds limits")
+
+// CheckJSON scans data once, without allocating, and fails with an error
+// wrapping ErrJSONTooComplex when it goes beyond limits, so hostile
+// documents are refused before a decoder spends time and memory on them.
+// It does not validate the syntax; the decoder still does.
+func CheckJSON(data []byte, limits JSONLimits) error {
+	depth, keys := 0, 0
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '{', '[':
+			depth++
+			if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+				return fmt.Errorf("%w: nested deeper than %d", ErrJSONTooComplex, limits.MaxDepth)
+			}
+		case '}', ']':
+			depth--
+		case ':':
+			// Outside strings a colon ends an object key.
+			keys++
+			if limits.MaxKeys > 0 && keys > limits.MaxKeys {
+				return fmt.Errorf("%w: more than %d keys", ErrJSONTooComplex, limits.MaxKeys)
+			}
+		case '"':
+			start := i + 1
+			for i = start; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+			if limits.MaxStringLength > 0 && i-start > limits.MaxStringLength {
+				return fmt.Errorf("%w: string longer than %d bytes", ErrJSONTooComplex, limits.MaxStringLength)
+			}
+		}
+	}
+	return nil
+}
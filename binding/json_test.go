@@ -0,0 +1,47 @@
+package binding
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckJSON(t *testing.T) {
+	limits := JSONLimits{MaxDepth: 3, MaxKeys: 4, MaxStringLength: 8}
+
+	assert.Nil(t, CheckJSON([]byte(`{"a":{"b":[1,"x:{[y"]},"c":"\"q\""}`), limits))
+	assert.ErrorIs(t, CheckJSON([]byte(`{"a":{"b":[[1]]}}`), limits), ErrJSONTooComplex)
+	assert.ErrorIs(t, CheckJSON([]byte(`{"a":1,"b":2,"c":3,"d":4,"e":5}`), limits), ErrJSONTooComplex)
+	assert.ErrorIs(t, CheckJSON([]byte(`{"a":"123456789"}`), limits), ErrJSONTooComplex)
+	assert.ErrorIs(t, CheckJSON([]byte(`{"123456789":1}`), limits), ErrJSONTooComplex)
+	// Unterminated strings are left to the decoder to report.
+	assert.Nil(t, CheckJSON([]byte(`{"a":"12\"`), limits))
+	assert.Nil(t, CheckJSON([]byte(`{"a":1`), JSONLimits{}))
+}
+
+func TestBindRejectsComplexJSON(t *testing.T) {
+	app := newApp()
+	body := `{"product":` + strings.Repeat("[", 1000) + strings.Repeat("]", 1000) + `}`
+	request := httptest.NewRequest("POST", "/users/42/orders", strings.NewReader(body))
+	request.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+
+	bytes, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "binding: JSON document exceeds limits: nested deeper than 32", string(bytes))
+}
+
+func BenchmarkCheckJSON(b *testing.B) {
+	body := []byte(`{"username":"salman","password":"rahasia","name":"Salman","email":"salman@example.com","phone":"+6281234567890"}`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := CheckJSON(body, JSONLimitsDefault); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package binding
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// pools holds a *sync.Pool of *T per request type T.
+var pools sync.Map
+
+func poolFor[T any]() *sync.Pool {
+	typ := reflect.TypeFor[T]()
+	if pool, ok := pools.Load(typ); ok {
+		return pool.(*sync.Pool)
+	}
+	pool, _ := pools.LoadOrStore(typ, &sync.Pool{
+		New: func() any { return new(T) },
+	})
+	return pool.(*sync.Pool)
+}
+
+// With binds the request like Bind into a pooled T and calls fn with it.
+// The T is reset and reused by later requests once fn returns, so fn must
+// not keep it, or anything pointing into it, beyond the call. Use Bind
+// when the result has to outlive the handler.
+func With[T any](ctx *fiber.Ctx, fn func(*T) error) error {
+	pool := poolFor[T]()
+	out := pool.Get().(*T)
+	defer func() {
+		var zero T
+		*out = zero
+		pool.Put(out)
+	}()
+
+	if err := bind(ctx, out); err != nil {
+		return err
+	}
+	return fn(out)
+}
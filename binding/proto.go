@@ -0,0 +1,61 @@
+package binding
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// MIMEApplicationProtobuf is the media type of Protocol Buffers messages.
+// Requests may also be sent as application/protobuf.
+const MIMEApplicationProtobuf = "application/x-protobuf"
+
+// IsProtobuf reports whether contentType is a Protocol Buffers media
+// type.
+func IsProtobuf(contentType string) bool {
+	contentType, _, _ = strings.Cut(strings.ToLower(contentType), ";")
+	switch strings.TrimSpace(contentType) {
+	case MIMEApplicationProtobuf, "application/protobuf":
+		return true
+	}
+	return false
+}
+
+// BindProto is Bind for endpoints also taking Protocol Buffers: such a
+// body is decoded into a new M, which convert turns into the T other
+// bodies are decoded into. The query string and route parameters are
+// overlaid either way.
+func BindProto[T, M any, PM interface {
+	*M
+	proto.Message
+}](ctx *fiber.Ctx, convert func(PM) T) (*T, error) {
+	if !IsProtobuf(ctx.Get(fiber.HeaderContentType)) {
+		return Bind[T](ctx)
+	}
+	message := PM(new(M))
+	if err := proto.Unmarshal(ctx.Body(), message); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	out := convert(message)
+	if err := bindRequest(ctx, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WithProto is With for endpoints also taking Protocol Buffers, decoding
+// such a body as BindProto does.
+func WithProto[T, M any, PM interface {
+	*M
+	proto.Message
+}](ctx *fiber.Ctx, convert func(PM) T, fn func(*T) error) error {
+	if !IsProtobuf(ctx.Get(fiber.HeaderContentType)) {
+		return With(ctx, fn)
+	}
+	out, err := BindProto(ctx, convert)
+	if err != nil {
+		return err
+	}
+	return fn(out)
+}
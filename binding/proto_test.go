@@ -0,0 +1,61 @@
+package binding
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestBindProto(t *testing.T) {
+	app := fiber.New()
+	convert := func(message *wrapperspb.StringValue) orderRequest {
+		return orderRequest{Product: message.GetValue()}
+	}
+	app.Post("/users/:userId/orders", func(ctx *fiber.Ctx) error {
+		request, err := BindProto(ctx, convert)
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(fiber.Map{"user": request.UserID, "page": request.Page, "product": request.Product})
+	})
+	app.Put("/users/:userId/orders", func(ctx *fiber.Ctx) error {
+		return WithProto(ctx, convert, func(request *orderRequest) error {
+			return ctx.JSON(fiber.Map{"user": request.UserID, "page": request.Page, "product": request.Product})
+		})
+	})
+	send := func(contentType, body string, method ...string) (int, string) {
+		request := httptest.NewRequest(append(method, "POST")[0], "/users/42/orders?page=3", strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		bytes, _ := io.ReadAll(response.Body)
+		return response.StatusCode, string(bytes)
+	}
+
+	message, _ := proto.Marshal(wrapperspb.String("book"))
+	for _, contentType := range []string{MIMEApplicationProtobuf, "application/protobuf; charset=binary"} {
+		status, body := send(contentType, string(message))
+		assert.Equal(t, 200, status, contentType)
+		assert.JSONEq(t, `{"user":"42","page":3,"product":"book"}`, body, contentType)
+	}
+
+	status, body := send(fiber.MIMEApplicationJSON, `{"product":"pen"}`)
+	assert.Equal(t, 200, status, "other bodies are bound as usual")
+	assert.JSONEq(t, `{"user":"42","page":3,"product":"pen"}`, body)
+
+	status, _ = send(MIMEApplicationProtobuf, "\xff\xff")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+
+	status, body = send(MIMEApplicationProtobuf, string(message), "PUT")
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"user":"42","page":3,"product":"book"}`, body)
+	status, body = send(fiber.MIMEApplicationJSON, `{"product":"pen"}`, "PUT")
+	assert.Equal(t, 200, status, "WithProto binds other bodies like With")
+	assert.JSONEq(t, `{"user":"42","page":3,"product":"pen"}`, body)
+}
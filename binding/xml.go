@@ -0,0 +1,99 @@
+package binding
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+)
+
+// XMLLimits bounds the XML documents accepted by DecodeXML.
+type XMLLimits struct {
+	// MaxSize is the largest document, in bytes.
+	MaxSize int
+	// MaxDepth is the deepest element nesting.
+	MaxDepth int
+	// MaxTokens caps elements, text runs and comments together.
+	MaxTokens int
+	// MaxAttributes caps the attributes of one element.
+	MaxAttributes int
+}
+
+// XMLLimitsDefault applies to XML bodies decoded by Bind and With. Change
+// it only before serving requests.
+var XMLLimitsDefault = XMLLimits{
+	MaxSize:       1024 * 1024,
+	MaxDepth:      32,
+	MaxTokens:     10000,
+	MaxAttributes: 32,
+}
+
+var (
+	// ErrXMLDirective rejects documents carrying a DOCTYPE, and with it
+	// any entity declaration.
+	ErrXMLDirective = errors.New("binding: XML DTDs and entity declarations are not allowed")
+	// ErrXMLProcInst rejects processing instructions other than the XML
+	// declaration.
+	ErrXMLProcInst = errors.New("binding: XML processing instructions are not allowed")
+	// ErrXMLTooLarge rejects documents beyond one of the XMLLimits.
+	ErrXMLTooLarge = errors.New("binding: XML document exceeds limits")
+)
+
+// DecodeXML unmarshals data into out like xml.Unmarshal, but strictly and
+// with everything needed for entity tricks refused up front: a DOCTYPE
+// (and so every DTD, external entity and entity expansion), processing
+// instructions, unknown entity references and encodings other than
+// UTF-8. Documents beyond limits fail with ErrXMLTooLarge.
+func DecodeXML(data []byte, out any, limits XMLLimits) error {
+	if limits.MaxSize > 0 && len(data) > limits.MaxSize {
+		return ErrXMLTooLarge
+	}
+
+	raw := xml.NewDecoder(bytes.NewReader(data))
+	raw.Strict = true
+	guarded := &guardedTokens{decoder: raw, limits: limits}
+	return xml.NewTokenDecoder(guarded).Decode(out)
+}
+
+// guardedTokens hands out the raw tokens of decoder, failing on the first
+// one not allowed. The decoder reading from it checks that elements nest
+// properly.
+type guardedTokens struct {
+	decoder *xml.Decoder
+	limits  XMLLimits
+	depth   int
+	tokens  int
+}
+
+func (g *guardedTokens) Token() (xml.Token, error) {
+	token, err := g.decoder.RawToken()
+	if err != nil {
+		return nil, err
+	}
+
+	g.tokens++
+	if g.limits.MaxTokens > 0 && g.tokens > g.limits.MaxTokens {
+		return nil, ErrXMLTooLarge
+	}
+
+	switch token := token.(type) {
+	case xml.StartElement:
+		g.depth++
+		if g.limits.MaxDepth > 0 && g.depth > g.limits.MaxDepth {
+			return nil, ErrXMLTooLarge
+		}
+		if g.limits.MaxAttributes > 0 && len(token.Attr) > g.limits.MaxAttributes {
+			return nil, ErrXMLTooLarge
+		}
+	case xml.EndElement:
+		g.depth--
+	case xml.Directive:
+		return nil, ErrXMLDirective
+	case xml.ProcInst:
+		if token.Target != "xml" || g.tokens != 1 {
+			return nil, ErrXMLProcInst
+		}
+	}
+	// Token data points into the decoder's buffer, which the next call
+	// overwrites.
+	return xml.CopyToken(token), nil
+}
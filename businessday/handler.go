@@ -0,0 +1,74 @@
+package businessday
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler exposes the calendars of a Registry.
+type Handler struct {
+	Registry *Registry
+}
+
+// Register mounts the routes on router, e.g. app.Group("/api/v1/business-days").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/:country/next", h.next)
+	router.Get("/:country/deadline", h.deadline)
+}
+
+// next answers ?date=2026-12-24 (default today) with the next business day.
+func (h *Handler) next(ctx *fiber.Ctx) error {
+	calendar, err := h.Registry.Get(ctx.Params("country"))
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	date := time.Now().In(calendar.Location)
+	if value := ctx.Query("date"); value != "" {
+		if date, err = time.ParseInLocation(dateLayout, value, calendar.Location); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "date must be YYYY-MM-DD")
+		}
+	}
+
+	next := calendar.NextBusinessDay(date)
+	return ctx.JSON(fiber.Map{
+		"country":         calendar.Country,
+		"date":            date.Format(dateLayout),
+		"is_business_day": calendar.IsBusinessDay(date),
+		"next":            next.Format(dateLayout),
+	})
+}
+
+// deadline answers ?start=RFC3339&days=3&cutoff=17h with the SLA due time.
+func (h *Handler) deadline(ctx *fiber.Ctx) error {
+	calendar, err := h.Registry.Get(ctx.Params("country"))
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	start := time.Now()
+	if value := ctx.Query("start"); value != "" {
+		if start, err = time.Parse(time.RFC3339, value); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "start must be RFC 3339")
+		}
+	}
+	days, err := strconv.Atoi(ctx.Query("days", "1"))
+	if err != nil || days < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "days must be a non-negative integer")
+	}
+	var cutoff time.Duration
+	if value := ctx.Query("cutoff"); value != "" {
+		if cutoff, err = time.ParseDuration(value); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "cutoff must be a duration such as 17h")
+		}
+	}
+
+	return ctx.JSON(fiber.Map{
+		"country":  calendar.Country,
+		"start":    start.In(calendar.Location),
+		"days":     days,
+		"deadline": calendar.Deadline(start, days, cutoff),
+	})
+}
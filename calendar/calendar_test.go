@@ -0,0 +1,186 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/storage"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const invite = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"METHOD:REQUEST\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup-1@example.com\r\n" +
+	"SUMMARY:Daily standup\\, team\r\n" +
+	"DESCRIPTION:Bring your\\nupdates\r\n" +
+	"DTSTART;TZID=Asia/Jakarta:20250602T090000\r\n" +
+	"DTEND;TZID=Asia/Jakarta:20250602T091500\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=5\r\n" +
+	"ORGANIZER;CN=Salman:mailto:salman@example.com\r\n" +
+	"ATTENDEE;CN=Seif;PARTSTAT=NEEDS-ACTION:mailto:seif@exam\r\n" +
+	" ple.com\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"TRIGGER:-PT15M\r\n" +
+	"DESCRIPTION:Reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	events, err := Parse(strings.NewReader(invite))
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+
+	event := events[0]
+	assert.Equal(t, "Daily standup, team", event.Summary)
+	assert.Equal(t, "Bring your\nupdates", event.Description)
+	assert.Equal(t, "salman@example.com", event.Organizer)
+	assert.Equal(t, []Attendee{{Email: "seif@example.com", Name: "Seif", Status: StatusNeedsAction}}, event.Attendees)
+	assert.Equal(t, 15*time.Minute, event.End.Sub(event.Start))
+	assert.Equal(t, "Asia/Jakarta", event.Start.Location().String())
+}
+
+func TestOccurrences(t *testing.T) {
+	events, err := Parse(strings.NewReader(invite))
+	assert.Nil(t, err)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var days []string
+	for _, occurrence := range events[0].Occurrences(from, to) {
+		days = append(days, occurrence.Start.Format("Mon 02"))
+	}
+	assert.Equal(t, []string{"Mon 02", "Wed 04", "Fri 06", "Mon 09", "Wed 11"}, days)
+
+	rule := &Event{
+		Start: time.Date(2025, 1, 31, 10, 0, 0, 0, time.UTC),
+		End:   time.Date(2025, 1, 31, 11, 0, 0, 0, time.UTC),
+		RRule: "FREQ=DAILY;INTERVAL=2;UNTIL=20250206T000000Z",
+	}
+	assert.Len(t, rule.Occurrences(from, to), 3)
+
+	// Windows years after the series start are not cut short by the bound.
+	daily := &Event{
+		Start: time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC),
+		End:   time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC),
+		RRule: "FREQ=DAILY",
+	}
+	from = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	occurrences := daily.Occurrences(from, from.AddDate(0, 0, 7))
+	assert.Len(t, occurrences, 7)
+	assert.Equal(t, from.Add(9*time.Hour), occurrences[0].Start)
+	assert.Len(t, daily.Occurrences(daily.Start, to), MaxOccurrences)
+
+	// Skipped periods still count towards COUNT.
+	counted := &Event{
+		Start: time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC),
+		End:   time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC),
+		RRule: "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=300",
+	}
+	from, to = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	var want []Occurrence
+	for _, occurrence := range counted.Occurrences(counted.Start, to) {
+		if !occurrence.Start.Before(from) {
+			want = append(want, occurrence)
+		}
+	}
+	assert.NotEmpty(t, want)
+	assert.Equal(t, want, counted.Occurrences(from, to))
+}
+
+func TestImportAndRSVP(t *testing.T) {
+	ctx := context.Background()
+	fileService := files.NewService(storage.NewLocal(t.TempDir()), files.NewMemoryRepository())
+	service := NewService(NewMemoryRepository(), fileService)
+	fileService.AfterSave(service.ImportFile)
+	users := user.NewMemoryRepository()
+	users.Create(ctx, &user.User{ID: "1", Username: "seif", Email: "Seif@example.com", CreatedAt: time.Now()})
+	users.Create(ctx, &user.User{ID: "2", Username: "salman", Email: "salman@example.com", CreatedAt: time.Now()})
+
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app := fiber.New()
+	app.Use(sessions.Middleware())
+	app.Post("/login/:id", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, ctx.Params("id"))
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	handler := &Handler{Service: service, Users: users}
+	handler.Register(app.Group("/api/v1/events"))
+	login := func(id string) string {
+		response, err := app.Test(httptest.NewRequest("POST", "/login/"+id, nil))
+		assert.Nil(t, err)
+		token, _ := io.ReadAll(response.Body)
+		return "Bearer " + string(token)
+	}
+	seif, salman := login("1"), login("2")
+	send := func(auth, method, target string, body io.Reader, contentType string) *http.Response {
+		request := httptest.NewRequest(method, target, body)
+		request.Header.Set("Content-Type", contentType)
+		if auth != "" {
+			request.Header.Set("Authorization", auth)
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	file, _ := writer.CreateFormFile("file", "invite.ics")
+	file.Write([]byte(invite))
+	writer.Close()
+
+	assert.Equal(t, 401, send("", "POST", "/api/v1/events/import", bytes.NewReader(body.Bytes()), writer.FormDataContentType()).StatusCode)
+	response := send(seif, "POST", "/api/v1/events/import", body, writer.FormDataContentType())
+	assert.Equal(t, 201, response.StatusCode)
+
+	var imported []*Event
+	data, _ := io.ReadAll(response.Body)
+	assert.Nil(t, json.Unmarshal(data, &imported))
+	assert.Len(t, imported, 1)
+	id := imported[0].ID
+
+	// The event is only in the calendar of the user who imported it.
+	var listed []*Event
+	data, _ = io.ReadAll(send(salman, "GET", "/api/v1/events", nil, "").Body)
+	assert.Nil(t, json.Unmarshal(data, &listed))
+	assert.Len(t, listed, 0)
+	assert.Equal(t, 404, send(salman, "GET", "/api/v1/events/"+id, nil, "").StatusCode)
+	assert.Equal(t, 404, send(salman, "POST", "/api/v1/events/"+id+"/rsvp", strings.NewReader(`{"status":"accepted"}`), "application/json").StatusCode)
+
+	response = send(seif, "POST", "/api/v1/events/"+id+"/rsvp", strings.NewReader(`{"email":"salman@example.com","status":"accepted"}`), "application/json")
+	assert.Equal(t, 200, response.StatusCode)
+
+	event, err := service.Repository.Get(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, StatusAccepted, event.Attendees[0].Status, "the answer is for the account's email, not the one sent")
+
+	response = send(seif, "POST", "/api/v1/events/"+id+"/rsvp", strings.NewReader(`{"status":"maybe"}`), "application/json")
+	assert.Equal(t, 400, response.StatusCode)
+
+	response = send(seif, "GET", "/api/v1/events/"+id+"/occurrences?from=2025-06-01T00:00:00Z&to=2025-06-30T00:00:00Z", nil, "")
+	assert.Equal(t, 200, response.StatusCode)
+
+	var occurrences []Occurrence
+	data, _ = io.ReadAll(response.Body)
+	assert.Nil(t, json.Unmarshal(data, &occurrences))
+	assert.Len(t, occurrences, 5)
+}
@@ -0,0 +1,109 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Attendee participation statuses (RFC 5545 PARTSTAT).
+const (
+	StatusNeedsAction = "NEEDS-ACTION"
+	StatusAccepted    = "ACCEPTED"
+	StatusDeclined    = "DECLINED"
+	StatusTentative   = "TENTATIVE"
+)
+
+// ErrNotFound is returned when no event matches the given id.
+var ErrNotFound = errors.New("calendar: event not found")
+
+// Event is a calendar invite imported from an .ics file, in the calendar
+// of the user who uploaded it.
+type Event struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	UID         string     `json:"uid"`
+	Summary     string     `json:"summary"`
+	Description string     `json:"description,omitempty"`
+	Location    string     `json:"location,omitempty"`
+	Start       time.Time  `json:"start"`
+	End         time.Time  `json:"end"`
+	AllDay      bool       `json:"all_day"`
+	RRule       string     `json:"rrule,omitempty"`
+	Organizer   string     `json:"organizer,omitempty"`
+	Attendees   []Attendee `json:"attendees"`
+	FileID      string     `json:"file_id,omitempty"`
+}
+
+type Attendee struct {
+	Email  string `json:"email"`
+	Name   string `json:"name,omitempty"`
+	Status string `json:"status"`
+}
+
+// Repository persists events. Save replaces an event of the same user
+// with the same UID.
+type Repository interface {
+	Save(ctx context.Context, event *Event) error
+	Get(ctx context.Context, id string) (*Event, error)
+	// List returns the events of userID, earliest first.
+	List(ctx context.Context, userID string) ([]*Event, error)
+}
+
+// MemoryRepository keeps events in process memory.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	events map[string]*Event
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{events: map[string]*Event{}}
+}
+
+func (r *MemoryRepository) Save(ctx context.Context, event *Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, existing := range r.events {
+		if existing.UID == event.UID && existing.UserID == event.UserID && id != event.ID {
+			event.ID = id
+		}
+	}
+	copied := *event
+	copied.Attendees = append([]Attendee(nil), event.Attendees...)
+	r.events[event.ID] = &copied
+	return nil
+}
+
+func (r *MemoryRepository) Get(ctx context.Context, id string) (*Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	event, ok := r.events[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *event
+	copied.Attendees = append([]Attendee(nil), event.Attendees...)
+	return &copied, nil
+}
+
+func (r *MemoryRepository) List(ctx context.Context, userID string) ([]*Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := []*Event{}
+	for _, event := range r.events {
+		if event.UserID != userID {
+			continue
+		}
+		copied := *event
+		list = append(list, &copied)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Start.Before(list[j].Start)
+	})
+	return list, nil
+}
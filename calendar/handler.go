@@ -0,0 +1,191 @@
+package calendar
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/binding"
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/scan"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler exposes the calendar of the signed in user. Users answers for
+// them to invites, with the email of their account.
+type Handler struct {
+	Service *Service
+	Users   user.Repository
+}
+
+// Register mounts the routes on router, e.g. app.Group("/api/v1/events").
+func (h *Handler) Register(router fiber.Router) {
+	router.Use(session.Require())
+	router.Get("/", h.list)
+	router.Post("/import", h.importFile)
+	router.Get("/:id", h.get)
+	router.Get("/:id/occurrences", h.occurrences)
+	router.Post("/:id/rsvp", h.rsvp)
+}
+
+func (h *Handler) list(ctx *fiber.Ctx) error {
+	events, err := h.Service.Repository.List(ctx.UserContext(), session.UserID(ctx))
+	if err != nil {
+		return err
+	}
+	if ctx.Query("from") == "" && ctx.Query("to") == "" {
+		return ctx.JSON(events)
+	}
+
+	from, to, err := window(ctx)
+	if err != nil {
+		return err
+	}
+	occurrences := []Occurrence{}
+	for _, event := range events {
+		occurrences = append(occurrences, event.Occurrences(from, to)...)
+	}
+	return ctx.JSON(occurrences)
+}
+
+func (h *Handler) get(ctx *fiber.Ctx) error {
+	event, err := h.event(ctx)
+	if err != nil {
+		return notFound(err)
+	}
+	return ctx.JSON(event)
+}
+
+// event returns the event of the :id param, if in the user's calendar.
+func (h *Handler) event(ctx *fiber.Ctx) (*Event, error) {
+	event, err := h.Service.Repository.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return nil, err
+	}
+	if event.UserID != session.UserID(ctx) {
+		return nil, ErrNotFound
+	}
+	return event, nil
+}
+
+func (h *Handler) occurrences(ctx *fiber.Ctx) error {
+	event, err := h.event(ctx)
+	if err != nil {
+		return notFound(err)
+	}
+	from, to, err := window(ctx)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(event.Occurrences(from, to))
+}
+
+func (h *Handler) importFile(ctx *fiber.Ctx) error {
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "file is required")
+	}
+
+	reader, err := header.Open()
+	if err != nil {
+		return err
+	}
+	_, err = Parse(reader)
+	reader.Close()
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	}
+
+	reader, err = header.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// Saving runs the ImportFile hook, which stores the events.
+	name := header.Filename
+	if !strings.EqualFold(path.Ext(name), ".ics") {
+		name += ".ics"
+	}
+	tenant, _ := ctx.Locals("tenant").(string)
+	owner := files.Owner{UserID: session.UserID(ctx), Tenant: tenant}
+	file, err := h.Service.Files.Save(files.WithOwner(ctx.UserContext(), owner), name, reader, "upload")
+	if errors.Is(err, scan.ErrInfected) {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	events, err := h.Service.Repository.List(ctx.UserContext(), owner.UserID)
+	if err != nil {
+		return err
+	}
+	imported := []*Event{}
+	for _, event := range events {
+		if event.FileID == file.ID {
+			imported = append(imported, event)
+		}
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(imported)
+}
+
+type rsvpRequest struct {
+	Status string `json:"status" form:"status"`
+}
+
+// rsvp answers the invite as the signed in user, by the email of their
+// account.
+func (h *Handler) rsvp(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[rsvpRequest](ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := h.event(ctx); err != nil {
+		return notFound(err)
+	}
+	account, err := h.Users.Get(ctx.UserContext(), session.UserID(ctx))
+	if err != nil {
+		return err
+	}
+	if account.Email == "" {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "your account has no email")
+	}
+
+	event, err := h.Service.RSVP(ctx.UserContext(), ctx.Params("id"), account.Email, request.Status)
+	if errors.Is(err, ErrInvalidStatus) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return notFound(err)
+	}
+	return ctx.JSON(event)
+}
+
+func window(ctx *fiber.Ctx) (time.Time, time.Time, error) {
+	from := time.Now()
+	to := from.AddDate(0, 3, 0)
+	var err error
+	if value := ctx.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, fiber.NewError(fiber.StatusBadRequest, "from must be RFC 3339")
+		}
+	}
+	if value := ctx.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, fiber.NewError(fiber.StatusBadRequest, "to must be RFC 3339")
+		}
+	}
+	return from, to, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return fiber.ErrNotFound
+	}
+	return err
+}
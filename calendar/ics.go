@@ -0,0 +1,172 @@
+package calendar
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNoEvents is returned for calendars without a VEVENT.
+var ErrNoEvents = errors.New("calendar: no events found")
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the VEVENT components of an iCalendar (RFC 5545) stream.
+func Parse(r io.Reader) ([]*Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*Event
+	var current *Event
+	depth := 0
+	for _, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case prop.name == "BEGIN" && prop.value == "VEVENT":
+			current = &Event{}
+			depth = 0
+		case current == nil:
+			continue
+		case prop.name == "BEGIN":
+			depth++
+		case prop.name == "END" && prop.value == "VEVENT":
+			if current.UID == "" || current.Start.IsZero() {
+				return nil, fmt.Errorf("calendar: event %q is missing UID or DTSTART", current.Summary)
+			}
+			if current.End.IsZero() {
+				current.End = current.Start
+			}
+			events = append(events, current)
+			current = nil
+		case prop.name == "END":
+			depth--
+		case depth > 0:
+			// properties of nested components such as VALARM
+		default:
+			if err := apply(current, prop); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(events) == 0 {
+		return nil, ErrNoEvents
+	}
+	return events, nil
+}
+
+func apply(event *Event, prop property) error {
+	var err error
+	switch prop.name {
+	case "UID":
+		event.UID = prop.value
+	case "SUMMARY":
+		event.Summary = unescape(prop.value)
+	case "DESCRIPTION":
+		event.Description = unescape(prop.value)
+	case "LOCATION":
+		event.Location = unescape(prop.value)
+	case "DTSTART":
+		event.Start, event.AllDay, err = parseTime(prop)
+	case "DTEND":
+		event.End, _, err = parseTime(prop)
+	case "RRULE":
+		event.RRule = prop.value
+		_, err = ParseRule(prop.value)
+	case "ORGANIZER":
+		event.Organizer = mailto(prop.value)
+	case "ATTENDEE":
+		status := prop.params["PARTSTAT"]
+		if status == "" {
+			status = StatusNeedsAction
+		}
+		event.Attendees = append(event.Attendees, Attendee{
+			Email:  mailto(prop.value),
+			Name:   prop.params["CN"],
+			Status: status,
+		})
+	}
+	return err
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (property, error) {
+	head, value, found := strings.Cut(line, ":")
+	if !found {
+		return property{}, fmt.Errorf("calendar: malformed line %q", line)
+	}
+
+	parts := strings.Split(head, ";")
+	prop := property{
+		name:   strings.ToUpper(parts[0]),
+		params: map[string]string{},
+		value:  value,
+	}
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+	return prop, nil
+}
+
+func parseTime(prop property) (time.Time, bool, error) {
+	if prop.params["VALUE"] == "DATE" || len(prop.value) == 8 {
+		t, err := time.Parse("20060102", prop.value)
+		return t, true, err
+	}
+	if strings.HasSuffix(prop.value, "Z") {
+		t, err := time.Parse("20060102T150405Z", prop.value)
+		return t, false, err
+	}
+
+	location := time.UTC
+	if tzid := prop.params["TZID"]; tzid != "" {
+		loaded, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("calendar: unknown TZID %q", tzid)
+		}
+		location = loaded
+	}
+	t, err := time.ParseInLocation("20060102T150405", prop.value, location)
+	return t, false, err
+}
+
+func mailto(value string) string {
+	if len(value) > 7 && strings.EqualFold(value[:7], "mailto:") {
+		value = value[7:]
+	}
+	return strings.ToLower(value)
+}
+
+func unescape(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
@@ -0,0 +1,169 @@
+package calendar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxOccurrences bounds the occurrences returned for one window.
+const MaxOccurrences = 1000
+
+// Rule is the supported subset of an RRULE: FREQ, INTERVAL, COUNT, UNTIL
+// and BYDAY (weekday codes, for WEEKLY rules).
+type Rule struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    time.Time
+	ByDay    []time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// ParseRule parses an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4".
+func ParseRule(value string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+		case "UNTIL":
+			rule.Until, _, err = parseTime(property{value: val, params: map[string]string{}})
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday, ok := weekdays[strings.ToUpper(day)]
+				if !ok {
+					return rule, fmt.Errorf("calendar: unsupported BYDAY %q", day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		}
+		if err != nil {
+			return rule, fmt.Errorf("calendar: invalid %s in RRULE", key)
+		}
+	}
+
+	switch rule.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return rule, fmt.Errorf("calendar: unsupported FREQ %q", rule.Freq)
+	}
+	if rule.Interval < 1 {
+		rule.Interval = 1
+	}
+	return rule, nil
+}
+
+// Occurrence is one instance of a (possibly recurring) event.
+type Occurrence struct {
+	EventID string    `json:"event_id"`
+	Summary string    `json:"summary"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+}
+
+// Occurrences expands the event between from and to (inclusive of starts
+// inside the window), up to MaxOccurrences. Periods wholly before from are
+// skipped rather than expanded, so windows far from the series start cost
+// the same as the first.
+func (e *Event) Occurrences(from, to time.Time) []Occurrence {
+	duration := e.End.Sub(e.Start)
+	var list []Occurrence
+	add := func(start time.Time) {
+		if !start.Before(from) && !start.After(to) {
+			list = append(list, Occurrence{EventID: e.ID, Summary: e.Summary, Start: start, End: start.Add(duration)})
+		}
+	}
+
+	if e.RRule == "" {
+		add(e.Start)
+		return list
+	}
+	rule, err := ParseRule(e.RRule)
+	if err != nil {
+		add(e.Start)
+		return list
+	}
+
+	period, emitted := skip(e.Start, from, rule)
+	for ; len(list) < MaxOccurrences; period++ {
+		base := advance(e.Start, rule, period)
+		if base.After(to) || (!rule.Until.IsZero() && base.After(rule.Until)) {
+			break
+		}
+		for _, start := range expandPeriod(e.Start, base, rule) {
+			if (!rule.Until.IsZero() && start.After(rule.Until)) || (rule.Count > 0 && emitted >= rule.Count) {
+				return list
+			}
+			emitted++
+			add(start)
+		}
+	}
+	return list
+}
+
+// skip returns the first period that may start inside a window from from,
+// with the number of occurrences of the periods before it, for COUNT.
+func skip(start, from time.Time, rule Rule) (period, emitted int) {
+	// The longest a period can be, across DST shifts and month lengths,
+	// so the estimate never passes an occurrence at or after from.
+	longest := map[string]time.Duration{
+		"DAILY":   25 * time.Hour,
+		"WEEKLY":  7*24*time.Hour + time.Hour,
+		"MONTHLY": 31*24*time.Hour + time.Hour,
+		"YEARLY":  366*24*time.Hour + time.Hour,
+	}[rule.Freq] * time.Duration(rule.Interval)
+	period = int(from.Sub(start)/longest) - 1
+	if period < 1 {
+		return 0, 0
+	}
+	perPeriod := 1
+	if rule.Freq == "WEEKLY" && len(rule.ByDay) > 0 {
+		perPeriod = len(rule.ByDay)
+	}
+	return period, len(expandPeriod(start, start, rule)) + (period-1)*perPeriod
+}
+
+func advance(start time.Time, rule Rule, period int) time.Time {
+	n := period * rule.Interval
+	switch rule.Freq {
+	case "DAILY":
+		return start.AddDate(0, 0, n)
+	case "WEEKLY":
+		return start.AddDate(0, 0, 7*n)
+	case "MONTHLY":
+		return start.AddDate(0, n, 0)
+	default:
+		return start.AddDate(n, 0, 0)
+	}
+}
+
+// expandPeriod returns the starts within one period, in order.
+func expandPeriod(first, base time.Time, rule Rule) []time.Time {
+	if rule.Freq != "WEEKLY" || len(rule.ByDay) == 0 {
+		return []time.Time{base}
+	}
+
+	weekStart := base.AddDate(0, 0, -int((base.Weekday()+6)%7))
+	var starts []time.Time
+	for i := 0; i < 7; i++ {
+		day := weekStart.AddDate(0, 0, i)
+		for _, weekday := range rule.ByDay {
+			if day.Weekday() == weekday && !day.Before(first) {
+				starts = append(starts, day)
+			}
+		}
+	}
+	return starts
+}
@@ -0,0 +1,98 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/logger"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// ErrInvalidStatus is returned for RSVP statuses other than accepted,
+// declined or tentative.
+var ErrInvalidStatus = errors.New("calendar: invalid RSVP status")
+
+// Service imports invites and records RSVPs.
+type Service struct {
+	Repository Repository
+	Files      *files.Service
+}
+
+func NewService(repository Repository, fileService *files.Service) *Service {
+	return &Service{Repository: repository, Files: fileService}
+}
+
+// Import parses an .ics stream and stores its events in the calendar of
+// userID.
+func (s *Service) Import(ctx context.Context, r io.Reader, userID, fileID string) ([]*Event, error) {
+	events, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		event.ID = utils.UUIDv4()
+		event.UserID = userID
+		event.FileID = fileID
+		if err := s.Repository.Save(ctx, event); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// ImportFile is a files.Service hook importing every saved .ics file into
+// the calendar of its owner, so invites arriving by email or upload are
+// picked up the same way. Files without an owner have no calendar.
+func (s *Service) ImportFile(ctx context.Context, file *files.File) {
+	if !IsCalendar(file) || file.UserID == "" {
+		return
+	}
+
+	_, content, err := s.Files.Open(ctx, file.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error("calendar: open failed", "file_id", file.ID, "error", err)
+		return
+	}
+	defer content.Close()
+
+	if _, err := s.Import(ctx, content, file.UserID, file.ID); err != nil {
+		logger.FromContext(ctx).Error("calendar: import failed", "file_id", file.ID, "error", err)
+	}
+}
+
+// RSVP records the answer of an attendee, adding them when not invited.
+func (s *Service) RSVP(ctx context.Context, id, email, status string) (*Event, error) {
+	status = strings.ToUpper(status)
+	if status != StatusAccepted && status != StatusDeclined && status != StatusTentative {
+		return nil, ErrInvalidStatus
+	}
+
+	event, err := s.Repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	email = strings.ToLower(email)
+	found := false
+	for i := range event.Attendees {
+		if event.Attendees[i].Email == email {
+			event.Attendees[i].Status = status
+			found = true
+		}
+	}
+	if !found {
+		event.Attendees = append(event.Attendees, Attendee{Email: email, Status: status})
+	}
+
+	return event, s.Repository.Save(ctx, event)
+}
+
+// IsCalendar reports whether a stored file is an iCalendar file.
+func IsCalendar(file *files.File) bool {
+	return strings.HasPrefix(file.ContentType, "text/calendar") || strings.EqualFold(path.Ext(file.Name), ".ics")
+}
@@ -0,0 +1,30 @@
+// Package captcha tells people from scripts by having them solve a
+// challenge of a CAPTCHA provider, and counts the failures after which
+// it is asked for.
+package captcha
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+)
+
+// ErrRequired is returned for a challenge left unsolved, or solved
+// wrong, when one was required.
+var ErrRequired = errors.New("captcha: verification required")
+
+// Verifier checks the response token a client got from solving a
+// challenge. remoteIP is the address of the client, which providers may
+// compare to that of the solver.
+type Verifier interface {
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+// Stub accepts Token only, for tests and development.
+type Stub struct {
+	Token string
+}
+
+func (s Stub) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	return s.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1, nil
+}
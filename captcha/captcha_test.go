@@ -0,0 +1,78 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle(t *testing.T) {
+	now := time.Now()
+	throttle := NewThrottle(2, time.Minute)
+	throttle.now = func() time.Time { return now }
+
+	throttle.Fail("ip:1", "user:salman")
+	assert.False(t, throttle.Required("ip:1", "user:salman"))
+	throttle.Fail("ip:2", "user:salman")
+	assert.True(t, throttle.Required("ip:3", "user:salman"), "the account failed from two addresses")
+	assert.False(t, throttle.Required("ip:1", "user:budi"))
+
+	throttle.Reset("user:salman")
+	assert.False(t, throttle.Required("ip:1", "user:salman"))
+	throttle.Fail("ip:1")
+	assert.True(t, throttle.Required("ip:1"))
+
+	now = now.Add(time.Minute)
+	assert.False(t, throttle.Required("ip:1"), "failures expire with the window")
+}
+
+func TestThrottleSweep(t *testing.T) {
+	now := time.Now()
+	throttle := NewThrottle(2, time.Minute)
+	throttle.now = func() time.Time { return now }
+
+	for i := 0; i < 100; i++ {
+		throttle.Fail("ip:1", "user:"+strconv.Itoa(i))
+	}
+	assert.Len(t, throttle.failures, 101)
+
+	now = now.Add(time.Minute)
+	throttle.Fail("ip:2")
+	assert.Len(t, throttle.failures, 1, "expired keys are dropped without being looked up")
+}
+
+func TestStub(t *testing.T) {
+	ok, _ := Stub{Token: "solved"}.Verify(context.Background(), "solved", "")
+	assert.True(t, ok)
+	ok, _ = Stub{Token: "solved"}.Verify(context.Background(), "guess", "")
+	assert.False(t, ok)
+	ok, _ = Stub{}.Verify(context.Background(), "", "")
+	assert.False(t, ok)
+}
+
+func TestSiteverify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		assert.Equal(t, "secret", r.PostForm.Get("secret"))
+		assert.Equal(t, "10.0.0.1", r.PostForm.Get("remoteip"))
+		if r.PostForm.Get("response") == "solved" {
+			w.Write([]byte(`{"success":true}`))
+			return
+		}
+		w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+	}))
+	defer server.Close()
+
+	verifier := NewSiteverify(server.URL, "secret")
+	ok, err := verifier.Verify(context.Background(), "solved", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = verifier.Verify(context.Background(), "guess", "10.0.0.1")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,62 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"belajar-golang-fiber/httpclient"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RecaptchaURL verifies reCAPTCHA tokens. hCaptcha and Cloudflare
+// Turnstile answer the same form at their own siteverify URL.
+const RecaptchaURL = "https://www.google.com/recaptcha/api/siteverify"
+
+// Siteverify verifies tokens with the siteverify API of a provider.
+type Siteverify struct {
+	URL    string
+	Secret string
+	Client *httpclient.Client
+}
+
+// NewSiteverify verifies with the secret key of the site at url.
+func NewSiteverify(url, secret string) *Siteverify {
+	return &Siteverify{
+		URL:    url,
+		Secret: secret,
+		Client: httpclient.New(httpclient.Config{Name: "captcha", Timeout: 5 * time.Second}),
+	}
+}
+
+func (s *Siteverify) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	form := url.Values{"secret": {s.Secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	response, err := s.Client.Do(ctx, httpclient.Request{
+		Method: fiber.MethodPost,
+		URL:    s.URL,
+		Header: map[string]string{fiber.HeaderContentType: fiber.MIMEApplicationForm},
+		Body:   []byte(form.Encode()),
+	})
+	if err != nil {
+		return false, err
+	}
+	if response.Status != fiber.StatusOK {
+		return false, fmt.Errorf("captcha: siteverify returned %d", response.Status)
+	}
+	var verdict struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(response.Body, &verdict); err != nil {
+		return false, fmt.Errorf("captcha: siteverify answer: %w", err)
+	}
+	return verdict.Success, nil
+}
@@ -0,0 +1,84 @@
+package captcha
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle counts recent failures per key, such as a client address or
+// an account, and requires a challenge once any key of an attempt has
+// had too many.
+type Throttle struct {
+	// After is the number of failures within Window past which a
+	// challenge is required.
+	After  int
+	Window time.Duration
+
+	mu       sync.Mutex
+	failures map[string][]time.Time
+	swept    time.Time
+	now      func() time.Time
+}
+
+// NewThrottle requires a challenge after failures within window.
+func NewThrottle(after int, window time.Duration) *Throttle {
+	return &Throttle{After: after, Window: window, failures: map[string][]time.Time{}, now: time.Now}
+}
+
+// Required reports whether any of keys failed After times or more within
+// the window.
+func (t *Throttle) Required(keys ...string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, key := range keys {
+		if len(t.recent(key)) >= t.After {
+			return true
+		}
+	}
+	return false
+}
+
+// Fail records a failure of each of keys. Once per window it also drops
+// the keys whose failures all expired, so keys never looked up again,
+// e.g. made up usernames, do not pile up.
+func (t *Throttle) Fail(keys ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if now.Sub(t.swept) >= t.Window {
+		for key := range t.failures {
+			t.recent(key)
+		}
+		t.swept = now
+	}
+	for _, key := range keys {
+		t.failures[key] = append(t.recent(key), now)
+	}
+}
+
+// Reset forgets the failures of keys.
+func (t *Throttle) Reset(keys ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, key := range keys {
+		delete(t.failures, key)
+	}
+}
+
+// recent drops the failures of key older than the window.
+func (t *Throttle) recent(key string) []time.Time {
+	cutoff := t.now().Add(-t.Window)
+	failures := t.failures[key]
+	for len(failures) > 0 && !failures[0].After(cutoff) {
+		failures = failures[1:]
+	}
+	if len(failures) == 0 {
+		delete(t.failures, key)
+		return nil
+	}
+	t.failures[key] = failures
+	return failures
+}
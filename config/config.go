@@ -0,0 +1,966 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the application settings, read from environment variables.
+type Config struct {
+	Env  string
+	Addr string
+	// Prefork serves requests from one process per CPU. NewServer refuses
+	// it while state such as sessions is kept in process memory.
+	Prefork  bool
+	Language string
+	// PhoneRegion is the ISO 3166 region assumed for phone numbers
+	// entered without a country code.
+	PhoneRegion string
+	// HolidaysDir holds extra or overriding business-day calendars
+	// (<country>.json) on top of the embedded ones.
+	HolidaysDir string
+	// DedupeWindow is how long a repeated form or API submission is
+	// treated as a duplicate.
+	DedupeWindow time.Duration
+	// JobWorkers is how many background jobs, such as image variants,
+	// run at once.
+	JobWorkers int
+
+	IdleTimeout  time.Duration
+	WriteTimeout time.Duration
+	ReadTimeout  time.Duration
+	// ShutdownTimeout is how long in-flight requests get to finish once
+	// the process is told to stop.
+	ShutdownTimeout time.Duration
+	// RequestTimeout bounds the downstream calls made for one /api
+	// request through its ctx.UserContext(). RouteTimeouts sets tighter
+	// ones per route group ("/api/fx=3s,/api/address=2s").
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+
+	View       ViewConfig
+	Static     StaticConfig
+	Storage    StorageConfig
+	Database   DatabaseConfig
+	Ingest     IngestConfig
+	Inbound    InboundConfig
+	Address    AddressConfig
+	FX         FXConfig
+	TLS        TLSConfig
+	Admin      AdminConfig
+	Session    SessionConfig
+	RateLimit  RateLimitConfig
+	Tracing    TracingConfig
+	Profiling  ProfilingConfig
+	Pprof      bool
+	DebugStore DebugStoreConfig
+	Audit      AuditConfig
+	BodyLimit  BodyLimitConfig
+	Limits     LimitsConfig
+	JSONGuard  JSONGuardConfig
+	Log        LogConfig
+	HTTPClient HTTPClientConfig
+	Proxy      ProxyConfig
+	Webhooks   WebhookConfig
+	GRPC       GRPCConfig
+	OCR        OCRConfig
+	Moderation ModerationConfig
+	Events     EventsConfig
+	Hosts      HostsConfig
+	Tenancy    TenancyConfig
+	Cookies    CookiesConfig
+	Captcha    CaptchaConfig
+	// CSRF maps route group prefixes to the CSRF mode guarding them,
+	// "session" or "double-submit".
+	CSRF map[string]string
+	// TrustedProxies lists the networks of the proxies in front of the
+	// app, whose X-Forwarded-For and X-Real-IP headers name the client.
+	TrustedProxies []string
+	// ReportsAutoHide hides reported content once that many users
+	// reported it, until an admin decides. Zero leaves it to admins.
+	ReportsAutoHide int
+	// RedisURL, redis://[user:password@]host:port[/db], points at the
+	// Redis shared by instances. Empty keeps such state in memory.
+	RedisURL string
+	// ConsentCategories are the optional cookie categories visitors
+	// consent to; empty means preferences, analytics and marketing.
+	ConsentCategories []string
+	// Features are the feature flags turned on, e.g. "graphql_playground".
+	Features []string
+	// TermsReviewURL is where pages redirect signed in users who have not
+	// accepted the latest terms of service.
+	TermsReviewURL string
+	// IdempotencyTTL is how long the response to a request with an
+	// Idempotency-Key is replayed to its retries.
+	IdempotencyTTL time.Duration
+	// CustomDomainTarget is the host tenants point the CNAME record of
+	// their own domain at. Empty disables custom domains.
+	CustomDomainTarget string
+}
+
+// ViewConfig selects the template engine, its templates and layout.
+type ViewConfig struct {
+	Engine    string
+	Directory string
+	Layout    string
+	Reload    bool
+}
+
+// StaticConfig controls where /public assets are read from, and how long
+// any cache may keep them.
+type StaticConfig struct {
+	FromDisk  bool
+	ThemeDir  string
+	TenantDir string
+	SPADir    string
+	MaxAge    time.Duration
+}
+
+// DatabaseConfig selects where users and orders are kept: "memory",
+// "sqlite", "postgres" or "mongodb". URL is the driver's data source
+// name, or MongoDB URI naming the database; SQLite defaults to an
+// in-memory database, lost on restart. Pool sizes the connection pool of
+// SQL databases; MongoDB takes pool options such as maxPoolSize in URL.
+type DatabaseConfig struct {
+	Driver string
+	URL    string
+	Pool   PoolConfig
+}
+
+// PoolConfig sizes a connection pool; see database.PoolConfig.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// StorageConfig locates uploaded files and toggles the WebDAV endpoint.
+// Backend "local" keeps files below Dir, "s3" in the bucket described by
+// S3. Unfinished resumable uploads are discarded after UploadTTL. Uploads
+// are scanned by the clamd at ClamAVAddress when set. StreamUploads hands
+// request bodies to handlers while they are still being received, so
+// /upload/stream never buffers a whole file. Quota limits what each user
+// and tenant may store. Deleted files stay restorable in the trash for
+// TrashRetention and keep counting toward Quota until they are purged.
+// Uploading a name again makes a new version of the file, of which the
+// MaxVersions previous ones are kept. Previews of PDFs and office
+// documents are rendered with the PDFToPPM and Soffice commands; empty
+// looks them up on PATH.
+type StorageConfig struct {
+	Backend       string
+	Dir           string
+	S3            S3Config
+	WebDAV        bool
+	UploadTTL     time.Duration
+	ClamAVAddress string
+	StreamUploads bool
+	Quota         QuotaConfig
+	// TrashRetention is how long deleted files can be restored.
+	TrashRetention time.Duration
+	MaxVersions    int
+	PDFToPPM       string
+	Soffice        string
+}
+
+// QuotaConfig caps the bytes and file count stored per user and per
+// tenant. Zero means unlimited.
+type QuotaConfig struct {
+	UserBytes   int64
+	UserFiles   int
+	TenantBytes int64
+	TenantFiles int
+}
+
+// S3Config locates the bucket used by the "s3" storage backend. Endpoint
+// and PathStyle are for S3 compatible services such as MinIO.
+type S3Config struct {
+	Bucket      string
+	Prefix      string
+	Region      string
+	Endpoint    string
+	PathStyle   bool
+	AccessKey   string
+	SecretKey   string
+	PartSize    int
+	Concurrency int
+}
+
+// IngestConfig describes the drop directories watched for partner files.
+type IngestConfig struct {
+	Interval     time.Duration
+	Settle       time.Duration
+	Dir          string
+	SFTPAddr     string
+	SFTPUser     string
+	SFTPPassword string
+	SFTPDir      string
+	SFTPHostKey  string
+}
+
+// InboundConfig holds the secrets for inbound email webhooks.
+type InboundConfig struct {
+	MailgunKey    string
+	SendGridToken string
+}
+
+// AddressConfig configures the address autocomplete proxy, which is only
+// mounted when PlacesKey is set.
+type AddressConfig struct {
+	PlacesKey string
+	CacheTTL  time.Duration
+	RateLimit int
+}
+
+// FXConfig controls the exchange rate refresh behind /api/fx.
+type FXConfig struct {
+	Enabled    bool
+	Base       string
+	Interval   time.Duration
+	StaleAfter time.Duration
+}
+
+// TLSConfig selects how HTTPS is served: "off", "file" (CertFile and
+// KeyFile) or "acme" (automatic Let's Encrypt certificates for Domains).
+type TLSConfig struct {
+	Mode       string
+	CertFile   string
+	KeyFile    string
+	Domains    []string
+	Email      string
+	CacheDir   string
+	HTTPAddr   string
+	HSTSMaxAge int
+}
+
+// HostsConfig splits the app by host name, "api.example.com" or
+// "*.example.com": API hosts serve only the API and Web hosts everything
+// else. With both empty every host serves everything.
+type HostsConfig struct {
+	API []string
+	Web []string
+}
+
+// TenancyConfig serves several tenants from one deployment, each with
+// its data in a database schema of its own. Requests name their tenant
+// with a subdomain of BaseDomain, a custom domain or the Header; Required
+// rejects the others, except for admin and debug routes. Tenants lists
+// the tenant ids created at start. Each tenant may make Requests per
+// RequestWindow, zero meaning unlimited, unless its settings say
+// otherwise; its storage is limited by STORAGE_QUOTA_TENANT_*.
+type TenancyConfig struct {
+	Enabled       bool
+	BaseDomain    string
+	Header        string
+	Required      bool
+	Tenants       []string
+	Requests      int
+	RequestWindow time.Duration
+}
+
+// CookiesConfig protects the cookies the app sets once Keys, base64
+// encoded 32 byte keys, are set: the first one encrypts, or signs, and
+// all of them are accepted. Signed cookies stay readable by scripts,
+// Plaintext ones are left alone.
+type CookiesConfig struct {
+	Keys      []string
+	Signed    []string
+	Plaintext []string
+}
+
+// CaptchaConfig has logins from a client address or for an account that
+// failed After times within Window solve a CAPTCHA, verified with Secret
+// at the siteverify VerifyURL of the provider. After zero never asks,
+// and is the default without a Secret.
+type CaptchaConfig struct {
+	VerifyURL string
+	Secret    string
+	After     int
+	Window    time.Duration
+}
+
+// Validate refuses an After without a Secret: no challenge could be
+// solved, so anyone could lock accounts out with a few wrong passwords.
+func (c CaptchaConfig) Validate() error {
+	if c.After > 0 && c.Secret == "" {
+		return errors.New("LOGIN_CAPTCHA_AFTER: requires CAPTCHA_SECRET")
+	}
+	return nil
+}
+
+// AdminConfig holds the credentials guarding admin and debug routes,
+// and the networks allowed and denied to reach them, in CIDR notation.
+// AllowNetworks defaults to loopback and private networks.
+type AdminConfig struct {
+	Token         string
+	User          string
+	Password      string
+	AllowNetworks []string
+	DenyNetworks  []string
+}
+
+// SessionConfig controls user sign-in sessions.
+type SessionConfig struct {
+	CookieName string
+	TTL        time.Duration
+	// RefreshTTL is how long the refresh tokens handed to API clients at
+	// sign-in live, each use rotating them.
+	RefreshTTL time.Duration
+	// RememberTTL is how long browsers signing in with "remember" stay
+	// signed in without using the site.
+	RememberTTL time.Duration
+	// RememberGrace is how long a rotated remember-me token still signs
+	// in the requests a browser sent in parallel with it.
+	RememberGrace time.Duration
+}
+
+// RateLimitConfig is the per-client limit on /api. Mode "monitor" only
+// reports would-be rejections, until EnforceFrom when set.
+type RateLimitConfig struct {
+	Mode        string
+	Max         int
+	Window      time.Duration
+	EnforceFrom time.Time
+}
+
+// TracingConfig controls request tracing. Failed requests and ones
+// slower than Slow are always traced, others at Rate, which Routes
+// overrides per route ("/api/v1/login=1,/api/v1/reference/*=0").
+type TracingConfig struct {
+	Enabled bool
+	Rate    float64
+	Slow    time.Duration
+	Routes  map[string]float64
+}
+
+// ProfilingConfig enables continuous profiling. Profiles are pushed to
+// Pyroscope when ServerAddress is set; Labels alone adds the per-route
+// labels for an agent scraping /debug/pprof, such as Parca.
+type ProfilingConfig struct {
+	ServerAddress   string
+	ApplicationName string
+	User            string
+	Password        string
+	Labels          bool
+}
+
+// DebugStoreConfig controls retention of requests that failed with a 5xx.
+type DebugStoreConfig struct {
+	Enabled     bool
+	TTL         time.Duration
+	MaxBodySize int
+}
+
+// AuditConfig locates the audit log. Events are kept in memory, per
+// process, when Path is empty.
+type AuditConfig struct {
+	Path string
+}
+
+// BodyLimitConfig caps JSON, XML and form request bodies, in bytes,
+// before they are parsed. Multipart uploads are not affected.
+type BodyLimitConfig struct {
+	JSON int
+	XML  int
+	Form int
+}
+
+// LimitsConfig bounds what one client can take from the server. MaxBody
+// caps every request body, uploads included, and ReadBufferSize the
+// request line and headers together, in bytes; Concurrency caps the
+// connections served at once. The clientguard middleware answers requests
+// with more than MaxHeaders headers or MaxHeaderBytes of them with 431,
+// and streamed bodies slower than MinBodyRate bytes per second, after
+// BodyGrace, with 408.
+type LimitsConfig struct {
+	MaxBody        int
+	ReadBufferSize int
+	Concurrency    int
+	MaxHeaders     int
+	MaxHeaderBytes int
+	MinBodyRate    int
+	BodyGrace      time.Duration
+}
+
+// JSONGuardConfig bounds the nesting depth, key count and string length
+// of JSON bodies before they are decoded.
+type JSONGuardConfig struct {
+	MaxDepth        int
+	MaxKeys         int
+	MaxStringLength int
+}
+
+// LogConfig selects the level ("debug", "info", "warn", "error") and
+// format ("text" or "json") of application logs.
+type LogConfig struct {
+	Level  string
+	Format string
+}
+
+// HTTPClientConfig tunes the retries and circuit breaker of outbound
+// calls to other services. Negative values disable them.
+type HTTPClientConfig struct {
+	Retries          int
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+// ProxyConfig forwards Prefix to Upstreams, if any. HealthPath enables
+// health checks every HealthInterval.
+type ProxyConfig struct {
+	Prefix         string
+	Upstreams      []string
+	PreserveHost   bool
+	Timeout        time.Duration
+	HealthPath     string
+	HealthInterval time.Duration
+}
+
+// WebhookConfig sizes the delivery of webhooks: Workers send at once and
+// a failing delivery is tried MaxAttempts times, waiting Backoff before
+// the first retry and twice as long before each further one. Webhooks
+// received at /webhooks/github and /webhooks/stripe are verified with
+// GitHubSecret and StripeSecret; each provider is only accepted when its
+// secret is set.
+type WebhookConfig struct {
+	Workers      int
+	MaxAttempts  int
+	Backoff      time.Duration
+	GitHubSecret string
+	StripeSecret string
+}
+
+// GRPCConfig enables the gRPC server on Addr, next to the HTTP one. When
+// Token is set, callers must send it as a bearer token. GatewayTarget is
+// the gRPC server the /api/internal routes call, by default this one.
+type GRPCConfig struct {
+	Addr          string
+	Token         string
+	GatewayTarget string
+}
+
+// OCRConfig sets up text recognition of scanned uploads with the
+// tesseract command at Tesseract, looked up on PATH when empty; without
+// it uploads are not recognized. Languages selects its trained models,
+// e.g. "eng+ind".
+type OCRConfig struct {
+	Tesseract string
+	Languages string
+}
+
+// ModerationConfig lists the words that flag uploads for review and those
+// that block them. When APIURL is set, content is also classified by that
+// service, called with APIToken as a bearer token.
+type ModerationConfig struct {
+	FlagWords  []string
+	BlockWords []string
+	APIURL     string
+	APIToken   string
+}
+
+// EventsConfig selects the broker domain events are published to: "nats"
+// with a nats:// URL, "kafka" with the URL of a Kafka REST Proxy, or none
+// when empty. Encoding is "json" or "protobuf"; Prefix is put before the
+// event type to name subjects and topics. When ConsumerGroup is set, the
+// app consumes events as that group too; with NATS, from the JetStream
+// stream Stream.
+type EventsConfig struct {
+	Broker        string
+	URL           string
+	Encoding      string
+	Prefix        string
+	ConsumerGroup string
+	Stream        string
+}
+
+// Load builds a Config from the environment, falling back to defaults.
+// Variables not set in the environment are read from the KEY=value file
+// named by CONFIG_FILE, if any; Load panics when it cannot be read.
+func Load() *Config {
+	cfg, _, err := load()
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+// Read builds a Config as Load does, for a running server to reload, but
+// returns an error instead of falling back to defaults for invalid values.
+func Read() (*Config, error) {
+	cfg, invalid, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := errors.Join(append(invalid, cfg.Validate())...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// build reads the settings through the get functions.
+func build() *Config {
+	env := getString("APP_ENV", "development")
+	logFormat := "text"
+	if env == "production" {
+		logFormat = "json"
+	}
+	captchaSecret := getString("CAPTCHA_SECRET", "")
+	captchaAfter := 0
+	if captchaSecret != "" {
+		captchaAfter = 5
+	}
+
+	return &Config{
+		Env:      env,
+		Addr:     getString("APP_ADDR", "localhost:3000"),
+		Prefork:  getBool("APP_PREFORK", false),
+		Language: getString("APP_LANGUAGE", "en"),
+
+		PhoneRegion: getString("APP_PHONE_REGION", "ID"),
+		HolidaysDir: getString("APP_HOLIDAYS_DIR", ""),
+
+		DedupeWindow: getDuration("APP_DEDUPE_WINDOW", 10*time.Minute),
+		JobWorkers:   getInt("APP_JOB_WORKERS", 2),
+
+		IdleTimeout:  getDuration("APP_IDLE_TIMEOUT", 5*time.Second),
+		WriteTimeout: getDuration("APP_WRITE_TIMEOUT", 5*time.Second),
+		ReadTimeout:  getDuration("APP_READ_TIMEOUT", 5*time.Second),
+
+		ShutdownTimeout: getDuration("APP_SHUTDOWN_TIMEOUT", 10*time.Second),
+
+		RequestTimeout: getDuration("APP_REQUEST_TIMEOUT", 10*time.Second),
+		RouteTimeouts:  getDurations("APP_ROUTE_TIMEOUTS"),
+
+		View: ViewConfig{
+			Engine:    getString("VIEW_ENGINE", "mustache"),
+			Directory: getString("VIEW_DIRECTORY", "./template"),
+			Layout:    getString("VIEW_LAYOUT", ""),
+			Reload:    getBool("VIEW_RELOAD", env != "production"),
+		},
+		Static: StaticConfig{
+			FromDisk:  getBool("STATIC_FROM_DISK", false),
+			ThemeDir:  getString("STATIC_THEME_DIR", ""),
+			TenantDir: getString("STATIC_TENANT_DIR", ""),
+			SPADir:    getString("STATIC_SPA_DIR", ""),
+			MaxAge:    getDuration("STATIC_MAX_AGE", 24*time.Hour),
+		},
+		Database: DatabaseConfig{
+			Driver: getString("DATABASE_DRIVER", "memory"),
+			URL:    getString("DATABASE_URL", ""),
+			Pool: PoolConfig{
+				MaxOpenConns:    getInt("DATABASE_MAX_OPEN_CONNS", 20),
+				MaxIdleConns:    getInt("DATABASE_MAX_IDLE_CONNS", 10),
+				ConnMaxLifetime: getDuration("DATABASE_CONN_MAX_LIFETIME", 30*time.Minute),
+				ConnMaxIdleTime: getDuration("DATABASE_CONN_MAX_IDLE_TIME", 5*time.Minute),
+			},
+		},
+		Storage: StorageConfig{
+			Backend: getString("STORAGE_BACKEND", "local"),
+			Dir:     getString("STORAGE_DIR", "./target"),
+			S3: S3Config{
+				Bucket:      getString("STORAGE_S3_BUCKET", ""),
+				Prefix:      getString("STORAGE_S3_PREFIX", ""),
+				Region:      getString("STORAGE_S3_REGION", "us-east-1"),
+				Endpoint:    getString("STORAGE_S3_ENDPOINT", ""),
+				PathStyle:   getBool("STORAGE_S3_PATH_STYLE", false),
+				AccessKey:   getString("STORAGE_S3_ACCESS_KEY", ""),
+				SecretKey:   getString("STORAGE_S3_SECRET_KEY", ""),
+				PartSize:    getInt("STORAGE_S3_PART_SIZE", 8*1024*1024),
+				Concurrency: getInt("STORAGE_S3_CONCURRENCY", 4),
+			},
+			WebDAV:         getBool("WEBDAV_ENABLED", false),
+			UploadTTL:      getDuration("STORAGE_UPLOAD_TTL", 24*time.Hour),
+			ClamAVAddress:  getString("STORAGE_CLAMAV_ADDR", ""),
+			StreamUploads:  getBool("STORAGE_STREAM_UPLOADS", false),
+			TrashRetention: getDuration("STORAGE_TRASH_RETENTION", 30*24*time.Hour),
+			MaxVersions:    getInt("STORAGE_MAX_VERSIONS", 10),
+			PDFToPPM:       getString("PREVIEW_PDFTOPPM", ""),
+			Soffice:        getString("PREVIEW_SOFFICE", ""),
+			Quota: QuotaConfig{
+				UserBytes:   int64(getInt("STORAGE_QUOTA_USER_BYTES", 0)),
+				UserFiles:   getInt("STORAGE_QUOTA_USER_FILES", 0),
+				TenantBytes: int64(getInt("STORAGE_QUOTA_TENANT_BYTES", 0)),
+				TenantFiles: getInt("STORAGE_QUOTA_TENANT_FILES", 0),
+			},
+		},
+		Ingest: IngestConfig{
+			Interval:     getDuration("INGEST_INTERVAL", time.Minute),
+			Settle:       getDuration("INGEST_SETTLE", 10*time.Second),
+			Dir:          getString("INGEST_DIR", ""),
+			SFTPAddr:     getString("INGEST_SFTP_ADDR", ""),
+			SFTPUser:     getString("INGEST_SFTP_USER", ""),
+			SFTPPassword: getString("INGEST_SFTP_PASSWORD", ""),
+			SFTPDir:      getString("INGEST_SFTP_DIR", "."),
+			SFTPHostKey:  getString("INGEST_SFTP_HOST_KEY", ""),
+		},
+		Inbound: InboundConfig{
+			MailgunKey:    getString("INBOUND_MAILGUN_KEY", ""),
+			SendGridToken: getString("INBOUND_SENDGRID_TOKEN", ""),
+		},
+		Address: AddressConfig{
+			PlacesKey: getString("ADDRESS_PLACES_KEY", ""),
+			CacheTTL:  getDuration("ADDRESS_CACHE_TTL", 24*time.Hour),
+			RateLimit: getInt("ADDRESS_RATE_LIMIT", 60),
+		},
+		FX: FXConfig{
+			Enabled:    getBool("FX_ENABLED", false),
+			Base:       getString("FX_BASE", "USD"),
+			Interval:   getDuration("FX_REFRESH_INTERVAL", 6*time.Hour),
+			StaleAfter: getDuration("FX_STALE_AFTER", 36*time.Hour),
+		},
+		TLS: TLSConfig{
+			Mode:       getString("TLS_MODE", "off"),
+			CertFile:   getString("TLS_CERT_FILE", ""),
+			KeyFile:    getString("TLS_KEY_FILE", ""),
+			Domains:    getList("TLS_DOMAINS"),
+			Email:      getString("TLS_ACME_EMAIL", ""),
+			CacheDir:   getString("TLS_ACME_CACHE_DIR", "./certs"),
+			HTTPAddr:   getString("TLS_HTTP_ADDR", ":80"),
+			HSTSMaxAge: getInt("TLS_HSTS_MAX_AGE", 31536000),
+		},
+		Admin: AdminConfig{
+			Token:    getString("ADMIN_TOKEN", ""),
+			User:     getString("ADMIN_USER", ""),
+			Password: getString("ADMIN_PASSWORD", ""),
+			AllowNetworks: getList("ADMIN_ALLOW_NETWORKS",
+				"127.0.0.0/8", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"),
+			DenyNetworks: getList("ADMIN_DENY_NETWORKS"),
+		},
+		Session: SessionConfig{
+			CookieName:  getString("SESSION_COOKIE_NAME", "session_id"),
+			TTL:         getDuration("SESSION_TTL", 24*time.Hour),
+			RefreshTTL:  getDuration("SESSION_REFRESH_TTL", 30*24*time.Hour),
+			RememberTTL: getDuration("SESSION_REMEMBER_TTL", 30*24*time.Hour),
+
+			RememberGrace: getDuration("SESSION_REMEMBER_GRACE", 30*time.Second),
+		},
+		RateLimit: RateLimitConfig{
+			Mode:        getString("RATE_LIMIT_MODE", "monitor"),
+			Max:         getInt("RATE_LIMIT_MAX", 300),
+			Window:      getDuration("RATE_LIMIT_WINDOW", time.Minute),
+			EnforceFrom: getTime("RATE_LIMIT_ENFORCE_FROM"),
+		},
+		Tracing: TracingConfig{
+			Enabled: getBool("TRACING_ENABLED", false),
+			Rate:    getFloat("TRACING_SAMPLE_RATE", 0.1),
+			Slow:    getDuration("TRACING_SLOW", time.Second),
+			Routes:  getRates("TRACING_ROUTE_RATES"),
+		},
+		Profiling: ProfilingConfig{
+			ServerAddress:   getString("PROFILING_SERVER_ADDRESS", ""),
+			ApplicationName: getString("PROFILING_APPLICATION_NAME", "belajar-golang-fiber"),
+			User:            getString("PROFILING_USER", ""),
+			Password:        getString("PROFILING_PASSWORD", ""),
+			Labels:          getBool("PROFILING_LABELS", false),
+		},
+		Pprof: getBool("DEBUG_PPROF_ENABLED", env != "production"),
+		DebugStore: DebugStoreConfig{
+			Enabled:     getBool("DEBUG_STORE_ENABLED", false),
+			TTL:         getDuration("DEBUG_STORE_TTL", 24*time.Hour),
+			MaxBodySize: getInt("DEBUG_STORE_MAX_BODY", 64*1024),
+		},
+		Audit: AuditConfig{
+			Path: getString("AUDIT_LOG_PATH", ""),
+		},
+		BodyLimit: BodyLimitConfig{
+			JSON: getInt("BODY_LIMIT_JSON", 1024*1024),
+			XML:  getInt("BODY_LIMIT_XML", 1024*1024),
+			Form: getInt("BODY_LIMIT_FORM", 64*1024),
+		},
+		Limits: LimitsConfig{
+			MaxBody:        getInt("LIMIT_MAX_BODY", 4*1024*1024),
+			ReadBufferSize: getInt("LIMIT_READ_BUFFER_SIZE", 8*1024),
+			Concurrency:    getInt("LIMIT_CONCURRENCY", 256*1024),
+			MaxHeaders:     getInt("LIMIT_MAX_HEADERS", 100),
+			MaxHeaderBytes: getInt("LIMIT_MAX_HEADER_BYTES", 8*1024),
+			MinBodyRate:    getInt("LIMIT_MIN_BODY_RATE", 1024),
+			BodyGrace:      getDuration("LIMIT_BODY_GRACE", 10*time.Second),
+		},
+		JSONGuard: JSONGuardConfig{
+			MaxDepth:        getInt("JSON_MAX_DEPTH", 32),
+			MaxKeys:         getInt("JSON_MAX_KEYS", 1000),
+			MaxStringLength: getInt("JSON_MAX_STRING_LENGTH", 64*1024),
+		},
+		Log: LogConfig{
+			Level:  getString("LOG_LEVEL", "info"),
+			Format: getString("LOG_FORMAT", logFormat),
+		},
+		HTTPClient: HTTPClientConfig{
+			Retries:          getInt("HTTP_CLIENT_RETRIES", 2),
+			FailureThreshold: getInt("HTTP_CLIENT_FAILURE_THRESHOLD", 5),
+			OpenTimeout:      getDuration("HTTP_CLIENT_OPEN_TIMEOUT", 30*time.Second),
+		},
+		Proxy: ProxyConfig{
+			Prefix:         getString("PROXY_PREFIX", "/external"),
+			Upstreams:      getList("PROXY_UPSTREAMS"),
+			PreserveHost:   getBool("PROXY_PRESERVE_HOST", false),
+			Timeout:        getDuration("PROXY_TIMEOUT", 30*time.Second),
+			HealthPath:     getString("PROXY_HEALTH_PATH", ""),
+			HealthInterval: getDuration("PROXY_HEALTH_INTERVAL", 10*time.Second),
+		},
+		Webhooks: WebhookConfig{
+			Workers:      getInt("WEBHOOK_WORKERS", 2),
+			MaxAttempts:  getInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			Backoff:      getDuration("WEBHOOK_BACKOFF", 30*time.Second),
+			GitHubSecret: getString("WEBHOOK_GITHUB_SECRET", ""),
+			StripeSecret: getString("WEBHOOK_STRIPE_SECRET", ""),
+		},
+		GRPC: GRPCConfig{
+			Addr:          getString("GRPC_ADDR", ""),
+			Token:         getString("GRPC_TOKEN", ""),
+			GatewayTarget: getString("GRPC_GATEWAY_TARGET", localTarget(getString("GRPC_ADDR", ""))),
+		},
+		OCR: OCRConfig{
+			Tesseract: getString("OCR_TESSERACT", ""),
+			Languages: getString("OCR_LANGUAGES", "eng+ind"),
+		},
+		Moderation: ModerationConfig{
+			FlagWords:  getList("MODERATION_FLAG_WORDS"),
+			BlockWords: getList("MODERATION_BLOCK_WORDS"),
+			APIURL:     getString("MODERATION_API_URL", ""),
+			APIToken:   getString("MODERATION_API_TOKEN", ""),
+		},
+		Events: EventsConfig{
+			Broker:        getString("EVENTS_BROKER", ""),
+			URL:           getString("EVENTS_URL", ""),
+			Encoding:      getString("EVENTS_ENCODING", "json"),
+			Prefix:        getString("EVENTS_PREFIX", "app."),
+			ConsumerGroup: getString("EVENTS_CONSUMER_GROUP", ""),
+			Stream:        getString("EVENTS_NATS_STREAM", "EVENTS"),
+		},
+		Hosts: HostsConfig{
+			API: getList("HOSTS_API"),
+			Web: getList("HOSTS_WEB"),
+		},
+		Tenancy: TenancyConfig{
+			Enabled:    getBool("TENANCY_ENABLED", false),
+			BaseDomain: getString("TENANCY_BASE_DOMAIN", ""),
+			Header:     getString("TENANCY_HEADER", "X-Tenant-ID"),
+			Required:   getBool("TENANCY_REQUIRED", false),
+			Tenants:    getList("TENANTS"),
+
+			Requests:      getInt("TENANCY_QUOTA_REQUESTS", 0),
+			RequestWindow: getDuration("TENANCY_QUOTA_WINDOW", 24*time.Hour),
+		},
+		Cookies: CookiesConfig{
+			Keys:      getList("COOKIE_KEYS"),
+			Signed:    getList("COOKIE_SIGNED", "consent"),
+			Plaintext: getList("COOKIE_PLAINTEXT", "lang", "csrf_token"),
+		},
+		Captcha: CaptchaConfig{
+			VerifyURL: getString("CAPTCHA_VERIFY_URL", "https://www.google.com/recaptcha/api/siteverify"),
+			Secret:    captchaSecret,
+			After:     getInt("LOGIN_CAPTCHA_AFTER", captchaAfter),
+			Window:    getDuration("LOGIN_CAPTCHA_WINDOW", 15*time.Minute),
+		},
+		CSRF: getPairs("CSRF_GROUPS",
+			"/api=double-submit", "/graphql=double-submit", "/upload=session",
+			"/uploads=session", "/files=session", "/trash=session",
+			"/admin=double-submit", "/dav=double-submit"),
+		ReportsAutoHide: getInt("REPORTS_AUTO_HIDE", 0),
+		RedisURL:        getString("REDIS_URL", ""),
+		IdempotencyTTL:  getDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		TermsReviewURL:  getString("TERMS_REVIEW_URL", "/api/v1/terms"),
+
+		CustomDomainTarget: getString("CUSTOM_DOMAIN_TARGET", ""),
+
+		ConsentCategories: getList("CONSENT_CATEGORIES"),
+		TrustedProxies:    getList("TRUSTED_PROXIES"),
+		Features:          getList("FEATURES", defaultFeatures(env)...),
+	}
+}
+
+// defaultFeatures are the feature flags on when FEATURES is unset.
+func defaultFeatures(env string) []string {
+	if env == "development" {
+		return []string{"graphql_playground"}
+	}
+	return nil
+}
+
+// Validate reports the settings a server cannot run with.
+func (c *Config) Validate() error {
+	var errs []error
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: unknown level %q", c.Log.Level))
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT: unknown format %q", c.Log.Format))
+	}
+	if c.RateLimit.Mode != "enforce" && c.RateLimit.Mode != "monitor" {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_MODE: unknown mode %q", c.RateLimit.Mode))
+	}
+	if c.RateLimit.Max <= 0 {
+		errs = append(errs, errors.New("RATE_LIMIT_MAX: must be positive"))
+	}
+	if c.RateLimit.Window <= 0 {
+		errs = append(errs, errors.New("RATE_LIMIT_WINDOW: must be positive"))
+	}
+	for prefix, mode := range c.CSRF {
+		if mode != "session" && mode != "double-submit" {
+			errs = append(errs, fmt.Errorf("CSRF_GROUPS: unknown mode %q for %s", mode, prefix))
+		}
+	}
+	errs = append(errs,
+		validNetworks("ADMIN_ALLOW_NETWORKS", c.Admin.AllowNetworks),
+		validNetworks("ADMIN_DENY_NETWORKS", c.Admin.DenyNetworks),
+		validNetworks("TRUSTED_PROXIES", c.TrustedProxies),
+		c.Captcha.Validate())
+	return errors.Join(errs...)
+}
+
+// validNetworks checks that networks are in CIDR notation or single
+// addresses, as the middleware reading them expects.
+func validNetworks(key string, networks []string) error {
+	var errs []error
+	for _, network := range networks {
+		network = strings.TrimSpace(network)
+		if _, err := netip.ParsePrefix(network); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(network); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid network %q", key, network))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AdminUsers returns the basic auth credentials for admin routes.
+func (c *Config) AdminUsers() map[string]string {
+	if c.Admin.User == "" || c.Admin.Password == "" {
+		return map[string]string{}
+	}
+	return map[string]string{c.Admin.User: c.Admin.Password}
+}
+
+// IsProduction reports whether the app runs with APP_ENV=production.
+func (c *Config) IsProduction() bool {
+	return c.Env == "production"
+}
+
+func getString(key, fallback string) string {
+	if value := lookup(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getList(key string, fallback ...string) []string {
+	var list []string
+	for _, item := range strings.Split(lookup(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	if list == nil {
+		return fallback
+	}
+	return list
+}
+
+func getBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(lookup(key))
+	if invalid(key, err) {
+		return fallback
+	}
+	return value
+}
+
+func getInt(key string, fallback int) int {
+	value, err := strconv.Atoi(lookup(key))
+	if invalid(key, err) {
+		return fallback
+	}
+	return value
+}
+
+func getFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(lookup(key), 64)
+	if invalid(key, err) {
+		return fallback
+	}
+	return value
+}
+
+// getPairs reads a comma separated list of key=value pairs, skipping
+// malformed entries.
+func getPairs(key string, fallback ...string) map[string]string {
+	pairs := map[string]string{}
+	for _, item := range getList(key, fallback...) {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		pairs[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return pairs
+}
+
+// getRates reads a comma separated list of key=rate pairs, skipping
+// malformed entries.
+func getRates(key string) map[string]float64 {
+	rates := map[string]float64{}
+	for _, item := range getList(key) {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			continue
+		}
+		rates[strings.TrimSpace(name)] = rate
+	}
+	return rates
+}
+
+// getDurations reads a comma separated list of key=duration pairs,
+// skipping malformed entries.
+func getDurations(key string) map[string]time.Duration {
+	durations := map[string]time.Duration{}
+	for _, item := range getList(key) {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		durations[strings.TrimSpace(name)] = duration
+	}
+	return durations
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(lookup(key))
+	if invalid(key, err) {
+		return fallback
+	}
+	return value
+}
+
+// getTime reads an RFC 3339 timestamp, returning the zero time when unset
+// or invalid.
+func getTime(key string) time.Time {
+	value, err := time.Parse(time.RFC3339, lookup(key))
+	if invalid(key, err) {
+		return time.Time{}
+	}
+	return value
+}
+
+// localTarget is the address to dial for a server listening on addr,
+// which may leave out the host.
+func localTarget(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
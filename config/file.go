@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// reading is the state of the load in progress, which the get functions
+// read through lookup and invalid.
+var (
+	reading  sync.Mutex
+	fileVars map[string]string
+	problems []error
+)
+
+// load builds a Config from the environment and CONFIG_FILE, returning
+// the values that were set but could not be parsed alongside it.
+func load() (*Config, []error, error) {
+	reading.Lock()
+	defer reading.Unlock()
+
+	fileVars, problems = nil, nil
+	defer func() { fileVars, problems = nil, nil }()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		vars, err := readFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		fileVars = vars
+	}
+	cfg := build()
+	return cfg, problems, nil
+}
+
+// lookup returns the environment variable key, else its value in
+// CONFIG_FILE.
+func lookup(key string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fileVars[key]
+}
+
+// invalid reports whether parsing the variable key failed, noting it when
+// it was set.
+func invalid(key string, err error) bool {
+	if err == nil {
+		return false
+	}
+	if value := lookup(key); value != "" {
+		problems = append(problems, fmt.Errorf("%s: invalid value %q", key, value))
+	}
+	return true
+}
+
+// readFile reads KEY=value lines, skipping blank ones and # comments.
+// Values may be quoted.
+func readFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=value", path, line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[strings.TrimSpace(key)] = value
+	}
+	return vars, scanner.Err()
+}
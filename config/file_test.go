@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.env")
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("LOG_LEVEL", "warn")
+	assert.Nil(t, os.WriteFile(path, []byte(`# reloaded on SIGHUP
+LOG_LEVEL=debug
+export RATE_LIMIT_MAX=50
+FEATURES="beta, gamma"
+`), 0o600))
+
+	cfg, err := Read()
+	assert.Nil(t, err)
+	assert.Equal(t, "warn", cfg.Log.Level, "the environment wins")
+	assert.Equal(t, 50, cfg.RateLimit.Max)
+	assert.Equal(t, []string{"beta", "gamma"}, cfg.Features)
+
+	assert.Nil(t, os.WriteFile(path, []byte("RATE_LIMIT_MAX=many\nRATE_LIMIT_MODE=strict\n"), 0o600))
+	_, err = Read()
+	assert.ErrorContains(t, err, `RATE_LIMIT_MAX: invalid value "many"`)
+	assert.ErrorContains(t, err, `RATE_LIMIT_MODE: unknown mode "strict"`)
+	assert.Equal(t, 300, Load().RateLimit.Max, "Load falls back to defaults")
+
+	assert.Nil(t, os.WriteFile(path, []byte("ADMIN_ALLOW_NETWORKS=10.0.0.0/8,10.0.0.0/33\n"), 0o600))
+	_, err = Read()
+	assert.ErrorContains(t, err, `ADMIN_ALLOW_NETWORKS: invalid network "10.0.0.0/33"`)
+
+	assert.Nil(t, os.WriteFile(path, []byte("RATE_LIMIT_MAX\n"), 0o600))
+	_, err = Read()
+	assert.ErrorContains(t, err, "expected KEY=value")
+}
@@ -0,0 +1,68 @@
+package consent
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for Manager.
+type Config struct {
+	// Next defines a function to skip the middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Categories are the optional categories visitors choose from;
+	// CategoryNecessary is always granted and never listed.
+	//
+	// Optional. Default: preferences, analytics and marketing
+	Categories []string
+
+	// Store keeps the choices of signed in users, so that they follow
+	// them across browsers.
+	//
+	// Optional. Default: NewMemoryStore()
+	Store Store
+
+	// CookieName is the cookie the choices are kept in. It is readable by
+	// scripts, so that consent banners can tell whether to show.
+	//
+	// Optional. Default: "consent"
+	CookieName string
+
+	// CookieSecure marks the cookie Secure; enable it behind HTTPS.
+	//
+	// Optional. Default: false
+	CookieSecure bool
+
+	// MaxAge is how long a choice is remembered before asking again.
+	//
+	// Optional. Default: 180 days
+	MaxAge time.Duration
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Categories: []string{CategoryPreferences, CategoryAnalytics, CategoryMarketing},
+	CookieName: "consent",
+	MaxAge:     180 * 24 * time.Hour,
+}
+
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if len(cfg.Categories) == 0 {
+		cfg.Categories = ConfigDefault.Categories
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = ConfigDefault.CookieName
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = ConfigDefault.MaxAge
+	}
+	return cfg
+}
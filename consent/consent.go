@@ -0,0 +1,173 @@
+// Package consent records which optional cookie and tracking categories
+// visitors agreed to, in a cookie and, for signed in users, in a store.
+// Until a visitor decides, only CategoryNecessary is granted.
+package consent
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Categories of cookies and tracking.
+const (
+	CategoryNecessary   = "necessary"
+	CategoryPreferences = "preferences"
+	CategoryAnalytics   = "analytics"
+	CategoryMarketing   = "marketing"
+)
+
+// BindKey is the name the granted categories are bound to in views, e.g.
+// {{if .consent.analytics}} or {{#consent.analytics}}.
+const BindKey = "consent"
+
+const localState = "consent"
+
+// State is a visitor's choice.
+type State struct {
+	// Decided is false until the visitor chose, e.g. to show a banner.
+	Decided bool `json:"decided"`
+	// Categories tells for every category whether it is granted.
+	Categories map[string]bool `json:"categories"`
+	UpdatedAt  *time.Time      `json:"updated_at,omitempty"`
+}
+
+// Manager resolves and records choices.
+type Manager struct {
+	config Config
+}
+
+func NewManager(config ...Config) *Manager {
+	return &Manager{config: configDefault(config...)}
+}
+
+// Categories returns the optional categories.
+func (m *Manager) Categories() []string {
+	return m.config.Categories
+}
+
+// Middleware resolves the choice of the request, from its cookie or else
+// the store of the signed in user, for Current and Granted, and binds
+// the granted categories to views. It must run after the session
+// middleware.
+func (m *Manager) Middleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if m.config.Next != nil && m.config.Next(ctx) {
+			return ctx.Next()
+		}
+		state, err := m.resolve(ctx)
+		if err != nil {
+			return err
+		}
+		ctx.Locals(localState, state)
+		if err := ctx.Bind(fiber.Map{BindKey: state.Categories}); err != nil {
+			return err
+		}
+		return ctx.Next()
+	}
+}
+
+func (m *Manager) resolve(ctx *fiber.Ctx) (*State, error) {
+	if raw := ctx.Cookies(m.config.CookieName); raw != "" {
+		return m.state(strings.Split(raw, "."), nil), nil
+	}
+	if userID := session.UserID(ctx); userID != "" {
+		record, err := m.config.Store.Get(ctx.UserContext(), userID)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			// Carry the choice over to this browser.
+			m.setCookie(ctx, record.Granted)
+			return m.state(record.Granted, &record.UpdatedAt), nil
+		}
+	}
+	return m.undecided(), nil
+}
+
+// Save records the categories granted, dropping unknown ones, and
+// returns the new state.
+func (m *Manager) Save(ctx *fiber.Ctx, granted []string) (*State, error) {
+	granted = slices.DeleteFunc(slices.Clone(granted), func(category string) bool {
+		return !slices.Contains(m.config.Categories, category)
+	})
+	slices.Sort(granted)
+	granted = slices.Compact(granted)
+	now := time.Now().UTC()
+	if userID := session.UserID(ctx); userID != "" {
+		if err := m.config.Store.Save(ctx.UserContext(), &Record{UserID: userID, Granted: granted, UpdatedAt: now}); err != nil {
+			return nil, err
+		}
+	}
+	m.setCookie(ctx, granted)
+	state := m.state(granted, &now)
+	ctx.Locals(localState, state)
+	return state, nil
+}
+
+// Withdraw forgets the choice, so that the visitor is asked again.
+func (m *Manager) Withdraw(ctx *fiber.Ctx) error {
+	if userID := session.UserID(ctx); userID != "" {
+		if err := m.config.Store.Delete(ctx.UserContext(), userID); err != nil {
+			return err
+		}
+	}
+	ctx.ClearCookie(m.config.CookieName)
+	ctx.Locals(localState, m.undecided())
+	return nil
+}
+
+// setCookie stores the granted categories joined by dots, "-" standing
+// for none, so that a decision never leaves the cookie empty.
+func (m *Manager) setCookie(ctx *fiber.Ctx, granted []string) {
+	value := strings.Join(granted, ".")
+	if value == "" {
+		value = "-"
+	}
+	ctx.Cookie(&fiber.Cookie{
+		Name:     m.config.CookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(m.config.MaxAge.Seconds()),
+		Secure:   m.config.CookieSecure,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func (m *Manager) state(granted []string, updatedAt *time.Time) *State {
+	state := m.undecided()
+	state.Decided = true
+	state.UpdatedAt = updatedAt
+	for _, category := range granted {
+		if _, known := state.Categories[category]; known {
+			state.Categories[category] = true
+		}
+	}
+	return state
+}
+
+func (m *Manager) undecided() *State {
+	categories := map[string]bool{CategoryNecessary: true}
+	for _, category := range m.config.Categories {
+		categories[category] = false
+	}
+	return &State{Categories: categories}
+}
+
+// Current returns the choice of the request; requests the middleware
+// skipped are undecided with nothing optional granted.
+func Current(ctx *fiber.Ctx) *State {
+	if state, ok := ctx.Locals(localState).(*State); ok {
+		return state
+	}
+	return &State{Categories: map[string]bool{CategoryNecessary: true}}
+}
+
+// Granted reports whether the visitor agreed to category.
+func Granted(ctx *fiber.Ctx, category string) bool {
+	return Current(ctx).Categories[category]
+}
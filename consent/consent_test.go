@@ -0,0 +1,119 @@
+package consent
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestApp(t *testing.T, manager *Manager) (*fiber.App, func(id string) string) {
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app := fiber.New()
+	app.Use(sessions.Middleware())
+	app.Post("/login/:id", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, ctx.Params("id"))
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	app.Use(manager.Middleware())
+	handler := &Handler{Manager: manager}
+	handler.Register(app.Group("/consent"))
+
+	login := func(id string) string {
+		response, err := app.Test(httptest.NewRequest("POST", "/login/"+id, nil))
+		assert.Nil(t, err)
+		return response.Cookies()[0].Value
+	}
+	return app, login
+}
+
+func send(t *testing.T, app *fiber.App, token, cookie, method, body string) (*http.Response, State) {
+	request := httptest.NewRequest(method, "/consent", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	if cookie != "" {
+		request.Header.Set("Cookie", "consent="+cookie)
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	var state State
+	json.NewDecoder(response.Body).Decode(&state)
+	return response, state
+}
+
+func cookieOf(response *http.Response) string {
+	for _, cookie := range response.Cookies() {
+		if cookie.Name == "consent" {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func TestConsent(t *testing.T) {
+	app, login := newTestApp(t, NewManager())
+
+	_, state := send(t, app, "", "", "GET", "")
+	assert.False(t, state.Decided)
+	assert.Equal(t, map[string]bool{"necessary": true, "preferences": false, "analytics": false, "marketing": false}, state.Categories)
+
+	response, _ := send(t, app, "", "", "PUT", `{"tracking":true}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, response.StatusCode)
+
+	response, state = send(t, app, "", "", "PUT", `{"analytics":true,"marketing":false}`)
+	assert.Equal(t, fiber.StatusOK, response.StatusCode)
+	assert.True(t, state.Decided)
+	assert.True(t, state.Categories["analytics"])
+	assert.Equal(t, "analytics", cookieOf(response))
+
+	_, state = send(t, app, "", "analytics.bogus", "GET", "")
+	assert.True(t, state.Categories["analytics"])
+	assert.False(t, state.Categories["marketing"])
+	_, state = send(t, app, "", "-", "GET", "")
+	assert.True(t, state.Decided)
+	assert.False(t, state.Categories["analytics"])
+
+	// A signed in user's choice follows them to other browsers.
+	token := login("1")
+	response, _ = send(t, app, token, "", "PUT", `{"marketing":true,"preferences":true}`)
+	assert.Equal(t, "marketing.preferences", cookieOf(response))
+	response, state = send(t, app, token, "", "GET", "")
+	assert.True(t, state.Categories["marketing"])
+	assert.NotNil(t, state.UpdatedAt)
+	assert.Equal(t, "marketing.preferences", cookieOf(response))
+
+	_, state = send(t, app, token, "", "DELETE", "")
+	assert.False(t, state.Decided)
+	_, state = send(t, app, token, "", "GET", "")
+	assert.False(t, state.Decided)
+}
+
+func TestGranted(t *testing.T) {
+	app := fiber.New()
+	app.Use(NewManager(Config{Categories: []string{CategoryAnalytics}}).Middleware())
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		if Granted(ctx, CategoryAnalytics) {
+			return ctx.SendString("tracked")
+		}
+		return ctx.SendString("untracked")
+	})
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("Cookie", "consent=analytics")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "tracked", string(body))
+}
@@ -0,0 +1,59 @@
+package consent
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler lets visitors read and change their choice.
+type Handler struct {
+	Manager *Manager
+}
+
+// Register mounts the routes on router, e.g. app.Group("/api/v1/consent").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/", h.get)
+	router.Put("/", h.put)
+	router.Delete("/", h.delete)
+}
+
+func (h *Handler) get(ctx *fiber.Ctx) error {
+	return ctx.JSON(Current(ctx))
+}
+
+// put takes {"analytics": true, "marketing": false, ...}; categories
+// left out are not granted.
+func (h *Handler) put(ctx *fiber.Ctx) error {
+	var choices map[string]bool
+	if err := ctx.BodyParser(&choices); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "malformed body")
+	}
+	var granted []string
+	for category, agreed := range choices {
+		if category == CategoryNecessary {
+			continue
+		}
+		if !slices.Contains(h.Manager.Categories(), category) {
+			return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "unknown category",
+				"field": category,
+			})
+		}
+		if agreed {
+			granted = append(granted, category)
+		}
+	}
+	state, err := h.Manager.Save(ctx, granted)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(state)
+}
+
+func (h *Handler) delete(ctx *fiber.Ctx) error {
+	if err := h.Manager.Withdraw(ctx); err != nil {
+		return err
+	}
+	return ctx.JSON(Current(ctx))
+}
@@ -0,0 +1,61 @@
+package consent
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Record is the choice of a signed in user.
+type Record struct {
+	UserID    string
+	Granted   []string
+	UpdatedAt time.Time
+}
+
+// Store persists the choices of signed in users.
+type Store interface {
+	// Get returns the record of userID, or nil when they did not choose.
+	Get(ctx context.Context, userID string) (*Record, error)
+	Save(ctx context.Context, record *Record) error
+	Delete(ctx context.Context, userID string) error
+}
+
+// MemoryStore keeps records in process memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]*Record
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]*Record{}}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, userID string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[userID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *record
+	copied.Granted = slices.Clone(record.Granted)
+	return &copied, nil
+}
+
+func (s *MemoryStore) Save(ctx context.Context, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *record
+	copied.Granted = slices.Clone(record.Granted)
+	s.records[record.UserID] = &copied
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, userID)
+	return nil
+}
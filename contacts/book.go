@@ -0,0 +1,208 @@
+package contacts
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Contact is an address book entry.
+type Contact struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email,omitempty"`
+	Phone        string    `json:"phone,omitempty"`
+	Organization string    `json:"organization,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ImportResult summarises an import.
+type ImportResult struct {
+	Created int `json:"created"`
+	Merged  int `json:"merged"`
+	Skipped int `json:"skipped"`
+}
+
+// Book is an in-memory address book that deduplicates on import.
+type Book struct {
+	mu       sync.RWMutex
+	contacts []*Contact
+}
+
+func NewBook() *Book {
+	return &Book{}
+}
+
+// Books keeps an address book per user.
+type Books struct {
+	mu    sync.Mutex
+	books map[string]*Book
+}
+
+func NewBooks() *Books {
+	return &Books{books: map[string]*Book{}}
+}
+
+// For returns the address book of userID, creating it on first use.
+func (b *Books) For(userID string) *Book {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	book, ok := b.books[userID]
+	if !ok {
+		book = NewBook()
+		b.books[userID] = book
+	}
+	return book
+}
+
+// Import adds cards, merging each into an existing contact with the same
+// email or phone number (or, lacking both, the same name) and only filling
+// in fields the existing contact does not have.
+func (b *Book) Import(cards []Card) ImportResult {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var result ImportResult
+	for _, card := range cards {
+		card.Email = strings.ToLower(strings.TrimSpace(card.Email))
+		card.Name = strings.TrimSpace(card.Name)
+		if card.Name == "" && card.Email == "" && card.Phone == "" {
+			result.Skipped++
+			continue
+		}
+
+		if existing := b.match(card); existing != nil {
+			fill(&existing.Name, card.Name)
+			fill(&existing.Email, card.Email)
+			fill(&existing.Phone, card.Phone)
+			fill(&existing.Organization, card.Organization)
+			result.Merged++
+			continue
+		}
+
+		b.contacts = append(b.contacts, &Contact{
+			ID:           utils.UUIDv4(),
+			Name:         card.Name,
+			Email:        card.Email,
+			Phone:        card.Phone,
+			Organization: card.Organization,
+			CreatedAt:    time.Now(),
+		})
+		result.Created++
+	}
+	return result
+}
+
+// List returns the contacts sorted by name.
+func (b *Book) List() []Contact {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	list := make([]Contact, 0, len(b.contacts))
+	for _, contact := range b.contacts {
+		list = append(list, *contact)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	return list
+}
+
+func (b *Book) match(card Card) *Contact {
+	phone := digits(card.Phone)
+	for _, contact := range b.contacts {
+		switch {
+		case card.Email != "" && contact.Email == card.Email:
+			return contact
+		case phone != "" && digits(contact.Phone) == phone:
+			return contact
+		case card.Email == "" && phone == "" && contact.Email == "" && contact.Phone == "" &&
+			strings.EqualFold(contact.Name, card.Name):
+			return contact
+		}
+	}
+	return nil
+}
+
+func fill(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// digits normalises a phone number for comparison, ignoring formatting and
+// a leading international or trunk prefix.
+func digits(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	value := b.String()
+	if len(value) > 10 {
+		value = value[len(value)-10:]
+	}
+	return value
+}
+
+var errNoHeader = errors.New("contacts: CSV needs a header row with a name, email or phone column")
+
+// ParseCSV reads contacts from a CSV file with a header row. Recognised
+// columns: name, email, phone and organization (and common synonyms).
+func ParseCSV(r io.Reader) ([]Card, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "name", "full name", "display name":
+			columns["name"] = i
+		case "email", "e-mail", "email address":
+			columns["email"] = i
+		case "phone", "mobile", "tel", "telephone", "phone number":
+			columns["phone"] = i
+		case "organization", "organisation", "company", "org":
+			columns["organization"] = i
+		}
+	}
+	if len(columns) == 0 {
+		return nil, errNoHeader
+	}
+
+	var cards []Card
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return cards, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		cards = append(cards, Card{
+			Name:         value("name"),
+			Email:        value("email"),
+			Phone:        value("phone"),
+			Organization: value("organization"),
+		})
+	}
+}
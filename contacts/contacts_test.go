@@ -0,0 +1,121 @@
+package contacts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVCardRoundTrip(t *testing.T) {
+	var body bytes.Buffer
+	card := Card{Name: "Salman Seif", Email: "salman@example.com", Phone: "+62 812-3456-7890", Organization: "Belajar, Inc"}
+	assert.Nil(t, WriteVCard(&body, card))
+	assert.Contains(t, body.String(), "N:Seif;Salman;;;\r\n")
+	assert.Contains(t, body.String(), "ORG:Belajar\\, Inc\r\n")
+
+	cards, err := ParseVCards(&body)
+	assert.Nil(t, err)
+	assert.Equal(t, []Card{card}, cards)
+}
+
+func TestImportDeduplicates(t *testing.T) {
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app := fiber.New()
+	app.Use(sessions.Middleware())
+	app.Post("/login/:id", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, ctx.Params("id"))
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	handler := &Handler{Books: NewBooks()}
+	handler.Register(app.Group("/api/v1/contacts"))
+	signIn := func(id string) string {
+		response, err := app.Test(httptest.NewRequest("POST", "/login/"+id, nil))
+		assert.Nil(t, err)
+		token, _ := io.ReadAll(response.Body)
+		return "Bearer " + string(token)
+	}
+	salman := signIn("salman")
+
+	response, err := app.Test(httptest.NewRequest("GET", "/api/v1/contacts", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	vcards := "BEGIN:VCARD\r\nVERSION:4.0\r\nN:Seif;Salman;;;\r\nEMAIL:Salman@Example.com\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\r\nVERSION:3.0\r\nFN:Budi\r\nTEL:0812 3456 7890\r\nEND:VCARD\r\n"
+	request := httptest.NewRequest("POST", "/api/v1/contacts/import", strings.NewReader(vcards))
+	request.Header.Set("Content-Type", "text/vcard")
+	request.Header.Set("Authorization", salman)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	result := ImportResult{}
+	data, _ := io.ReadAll(response.Body)
+	assert.Nil(t, json.Unmarshal(data, &result))
+	assert.Equal(t, ImportResult{Created: 2}, result)
+
+	csv := "Name,Email,Phone,Company\n" +
+		"Salman S,salman@example.com,,Belajar\n" +
+		"Budi Santoso,,+62 812-3456-7890,\n" +
+		",,,\n" +
+		"Ani,ani@example.com,,\n"
+	request = httptest.NewRequest("POST", "/api/v1/contacts/import", strings.NewReader(csv))
+	request.Header.Set("Content-Type", "text/csv")
+	request.Header.Set("Authorization", salman)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	data, _ = io.ReadAll(response.Body)
+	assert.Nil(t, json.Unmarshal(data, &result))
+	assert.Equal(t, ImportResult{Created: 1, Merged: 2, Skipped: 1}, result)
+
+	list := handler.Books.For("salman").List()
+	assert.Len(t, list, 3)
+	assert.Equal(t, "Salman Seif", list[2].Name)
+	assert.Equal(t, "Belajar", list[2].Organization)
+
+	request = httptest.NewRequest("GET", "/api/v1/contacts", nil)
+	request.Header.Set("Authorization", signIn("budi"))
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	data, _ = io.ReadAll(response.Body)
+	assert.JSONEq(t, `[]`, string(data), "every user has an address book of their own")
+}
+
+func TestUserVCard(t *testing.T) {
+	users := user.NewMemoryRepository()
+	users.Create(context.Background(), &user.User{ID: "1", Username: "salman", Name: "Salman Seif", Email: "salman@example.com", CreatedAt: time.Now()})
+
+	app := fiber.New()
+	app.Get("/api/v1/users/:id/vcard", UserVCard(users))
+
+	request := httptest.NewRequest("GET", "/api/v1/users/1/vcard", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `attachment; filename="salman.vcf"`, response.Header.Get("Content-Disposition"))
+
+	cards, err := ParseVCards(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, []Card{{Name: "Salman Seif", Email: "salman@example.com"}}, cards)
+
+	request = httptest.NewRequest("GET", "/api/v1/users/2/vcard", nil)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+}
@@ -0,0 +1,108 @@
+package contacts
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const mimeVCard = "text/vcard; charset=utf-8"
+
+// Handler exposes the address book of the signed in user.
+type Handler struct {
+	Books *Books
+}
+
+// Register mounts the routes on router, e.g. app.Group("/api/v1/contacts").
+func (h *Handler) Register(router fiber.Router) {
+	router.Use(session.Require())
+	router.Get("/", h.list)
+	router.Get("/export", h.export)
+	router.Post("/import", h.importContacts)
+}
+
+func (h *Handler) book(ctx *fiber.Ctx) *Book {
+	return h.Books.For(session.UserID(ctx))
+}
+
+func (h *Handler) list(ctx *fiber.Ctx) error {
+	return ctx.JSON(h.book(ctx).List())
+}
+
+// export writes the cards straight into the response body, which fasthttp
+// pools, rather than into a buffer of its own.
+func (h *Handler) export(ctx *fiber.Ctx) error {
+	ctx.Attachment("contacts.vcf")
+	ctx.Set(fiber.HeaderContentType, mimeVCard)
+	for _, contact := range h.book(ctx).List() {
+		WriteVCard(ctx, Card{
+			Name:         contact.Name,
+			Email:        contact.Email,
+			Phone:        contact.Phone,
+			Organization: contact.Organization,
+		})
+	}
+	return nil
+}
+
+// importContacts accepts either a multipart "file" field or a raw body;
+// the format is taken from the file extension or Content-Type.
+func (h *Handler) importContacts(ctx *fiber.Ctx) error {
+	var reader io.Reader
+	format := strings.ToLower(string(ctx.Request().Header.ContentType()))
+
+	if header, err := ctx.FormFile("file"); err == nil {
+		file, err := header.Open()
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader = file
+		format = strings.ToLower(header.Filename)
+	} else {
+		reader = bytes.NewReader(ctx.Body())
+	}
+
+	var cards []Card
+	var err error
+	switch {
+	case strings.HasSuffix(format, ".vcf") || strings.Contains(format, "vcard"):
+		cards, err = ParseVCards(reader)
+	case strings.HasSuffix(format, ".csv") || strings.Contains(format, "csv"):
+		cards, err = ParseCSV(reader)
+	default:
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "expected a vCard or CSV file")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	}
+
+	return ctx.JSON(h.book(ctx).Import(cards))
+}
+
+// UserVCard exports the user identified by the :id param as a vCard.
+func UserVCard(users user.Repository) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		found, err := users.Get(ctx.UserContext(), ctx.Params("id"))
+		if errors.Is(err, user.ErrNotFound) {
+			return fiber.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		name := found.Name
+		if name == "" {
+			name = found.Username
+		}
+		ctx.Attachment(found.Username + ".vcf")
+		ctx.Set(fiber.HeaderContentType, mimeVCard)
+		return WriteVCard(ctx, Card{Name: name, Email: found.Email, Phone: found.Phone})
+	}
+}
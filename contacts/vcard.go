@@ -0,0 +1,135 @@
+package contacts
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Card is the subset of a vCard the address book understands.
+type Card struct {
+	Name         string
+	Email        string
+	Phone        string
+	Organization string
+}
+
+// ParseVCards reads every BEGIN:VCARD ... END:VCARD block (3.0 and 4.0).
+func ParseVCards(r io.Reader) ([]Card, error) {
+	var cards []Card
+	var current *Card
+	var structured string
+
+	scanner := bufio.NewScanner(r)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		head, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		name, _, _ := strings.Cut(head, ";")
+		if _, after, ok := strings.Cut(name, "."); ok {
+			name = after // grouped properties, e.g. item1.EMAIL
+		}
+		name = strings.ToUpper(name)
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCARD"):
+			current, structured = &Card{}, ""
+		case current == nil:
+			continue
+		case name == "END":
+			if current.Name == "" {
+				current.Name = structured
+			}
+			cards = append(cards, *current)
+			current = nil
+		case name == "FN":
+			current.Name = unescape(value)
+		case name == "N":
+			parts := strings.Split(value, ";")
+			if len(parts) > 1 {
+				structured = strings.TrimSpace(unescape(parts[1]) + " " + unescape(parts[0]))
+			}
+		case name == "EMAIL" && current.Email == "":
+			current.Email = unescape(value)
+		case name == "TEL" && current.Phone == "":
+			current.Phone = strings.TrimPrefix(unescape(value), "tel:")
+		case name == "ORG":
+			current.Organization = unescape(strings.Split(value, ";")[0])
+		}
+	}
+	return cards, nil
+}
+
+// WriteVCard writes card as a vCard 3.0 block.
+func WriteVCard(w io.Writer, card Card) error {
+	first, last := splitName(card.Name)
+	lines := []string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		"FN:" + escape(card.Name),
+		"N:" + escape(last) + ";" + escape(first) + ";;;",
+	}
+	if card.Email != "" {
+		lines = append(lines, "EMAIL;TYPE=INTERNET:"+escape(card.Email))
+	}
+	if card.Phone != "" {
+		lines = append(lines, "TEL;TYPE=CELL:"+escape(card.Phone))
+	}
+	if card.Organization != "" {
+		lines = append(lines, "ORG:"+escape(card.Organization))
+	}
+	lines = append(lines, "END:VCARD")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, fold(line)+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitName(name string) (string, string) {
+	name = strings.TrimSpace(name)
+	if i := strings.LastIndex(name, " "); i > 0 {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
+
+// fold breaks lines longer than 75 octets without splitting a UTF-8 rune.
+func fold(line string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`).Replace(value)
+}
+
+func unescape(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
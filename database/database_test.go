@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebind(t *testing.T) {
+	query := "SELECT id FROM users WHERE username = ? AND version = ?"
+	assert.Equal(t, query, (&DB{Driver: DriverSQLite}).Rebind(query))
+	assert.Equal(t, "SELECT id FROM users WHERE username = $1 AND version = $2", (&DB{Driver: DriverPostgres}).Rebind(query))
+}
+
+func TestOpen(t *testing.T) {
+	_, err := Open("oracle", "")
+	assert.ErrorContains(t, err, `unknown driver "oracle"`)
+}
+
+func TestPool(t *testing.T) {
+	db, err := Open(DriverSQLite, "file::memory:")
+	assert.Nil(t, err)
+	defer db.Close()
+
+	db.SetPool(PoolConfig{MaxOpenConns: 10})
+	assert.Equal(t, 1, db.Stats().MaxOpenConnections, "SQLite keeps one connection")
+	assert.Nil(t, db.Check(context.Background()))
+
+	db.Publish("test")
+	var published map[string]int64
+	assert.Nil(t, json.Unmarshal([]byte(expvar.Get("database").(*expvar.Map).Get("test").String()), &published))
+	assert.Equal(t, int64(1), published["max_open"])
+	assert.Equal(t, int64(1), published["open"])
+	assert.Equal(t, int64(0), published["in_use"])
+	assert.Contains(t, published, "wait_duration_ms")
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(DriverSQLite, "file::memory:")
+	assert.Nil(t, err)
+	defer db.Close()
+
+	assert.Nil(t, db.Migrate(ctx))
+	assert.Nil(t, db.Migrate(ctx))
+
+	extra := fstest.MapFS{
+		"migrations/9000_add_notes.sql": {Data: []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY);\nINSERT INTO notes (id) VALUES (1);")},
+		"migrations/9001_broken.sql":    {Data: []byte("CREATE TABLE broken (")},
+	}
+	err = db.MigrateFS(ctx, extra, "migrations")
+	assert.ErrorContains(t, err, "migration 9001_broken")
+
+	rows, err := db.Query(ctx, "SELECT version FROM schema_migrations ORDER BY version")
+	assert.Nil(t, err)
+	var versions []string
+	for rows.Next() {
+		var version string
+		assert.Nil(t, rows.Scan(&version))
+		versions = append(versions, version)
+	}
+	rows.Close()
+	assert.Equal(t, "0001_create_users", versions[0])
+	assert.Equal(t, "9000_add_notes", versions[len(versions)-1], "migrations before the broken one stay applied")
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(DriverSQLite, "file::memory:")
+	assert.Nil(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(ctx, "CREATE TABLE tags (name VARCHAR(32) PRIMARY KEY)")
+	assert.Nil(t, err)
+	_, err = db.Exec(ctx, "INSERT INTO tags (name) VALUES (?)", "go")
+	assert.Nil(t, err)
+	_, err = db.Exec(ctx, "INSERT INTO tags (name) VALUES (?)", "go")
+	assert.True(t, IsUniqueViolation(err))
+	assert.False(t, IsUniqueViolation(nil))
+}
+
+func TestSchema(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := Open(DriverSQLite, "file:"+dir+"/app.db?_busy_timeout=1000")
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Nil(t, db.Migrate(ctx))
+
+	acme, err := db.Schema(ctx, "acme")
+	assert.Nil(t, err)
+	defer acme.Close()
+	_, err = acme.Exec(ctx, "INSERT INTO users (id, username, password, version, created_at, updated_at) VALUES ('1', 'salman', '', 1, '2026-01-01', '2026-01-01')")
+	assert.Nil(t, err)
+	assert.FileExists(t, dir+"/app_acme.db")
+
+	rows, err := db.Query(ctx, "SELECT id FROM users")
+	assert.Nil(t, err)
+	assert.False(t, rows.Next(), "the main database has no users")
+	rows.Close()
+
+	_, err = db.Schema(ctx, "Acme; DROP")
+	assert.ErrorContains(t, err, "invalid schema name")
+
+	assert.Equal(t, "postgres://app@db/app?search_path=acme&sslmode=disable", withSearchPath("postgres://app@db/app?sslmode=disable", "acme"))
+	assert.Equal(t, "host=db search_path=acme", withSearchPath("host=db", "acme"))
+	assert.Equal(t, "file::memory:", sqliteSchemaFile("file::memory:?cache=shared", "acme"))
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/logger"
+)
+
+// Migrations are written in the SQL both SQLite and PostgreSQL accept:
+// VARCHAR, TEXT, INTEGER and TIMESTAMP columns, holding UTC times. Their
+// statements are split on semicolons, so comments must not contain any.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrate applies, in name order, the migrations not applied yet, each in
+// a transaction of its own.
+func (db *DB) Migrate(ctx context.Context) error {
+	return db.MigrateFS(ctx, migrations, "migrations")
+}
+
+// MigrateFS applies the *.sql migrations found in dir.
+func (db *DB) MigrateFS(ctx context.Context, fsys fs.FS, dir string) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version VARCHAR(255) PRIMARY KEY,
+	applied_at TIMESTAMP NOT NULL
+)`); err != nil {
+		return err
+	}
+
+	applied := map[string]bool{}
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	for _, entry := range entries {
+		version := strings.TrimSuffix(entry.Name(), ".sql")
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" || applied[version] {
+			continue
+		}
+		script, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := db.apply(ctx, version, string(script)); err != nil {
+			return err
+		}
+		logger.FromContext(ctx).Info("database: migrated", "version", version)
+	}
+	return nil
+}
+
+func (db *DB) apply(ctx context.Context, version, script string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range strings.Split(script, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("database: migration %s: %w", version, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, db.Rebind("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)"), version, time.Now().UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
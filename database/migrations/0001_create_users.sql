@@ -0,0 +1,15 @@
+CREATE TABLE users (
+	id VARCHAR(36) PRIMARY KEY,
+	username VARCHAR(255) NOT NULL,
+	name VARCHAR(255) NOT NULL DEFAULT '',
+	email VARCHAR(255) NOT NULL DEFAULT '',
+	phone VARCHAR(32) NOT NULL DEFAULT '',
+	password TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	deleted_at TIMESTAMP NULL
+);
+
+-- Usernames stay taken by deleted users, who can be restored.
+CREATE UNIQUE INDEX users_username ON users (username);
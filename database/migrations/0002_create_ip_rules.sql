@@ -0,0 +1,7 @@
+CREATE TABLE ip_rules (
+	id VARCHAR(36) PRIMARY KEY,
+	network VARCHAR(64) NOT NULL,
+	action VARCHAR(8) NOT NULL,
+	note TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+)
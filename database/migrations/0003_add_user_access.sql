@@ -0,0 +1,4 @@
+-- Roles are stored comma separated.
+ALTER TABLE users ADD COLUMN roles TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN locked_at TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN password_reset INTEGER NOT NULL DEFAULT 0
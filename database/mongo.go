@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+// DriverMongo selects MongoDB, for deployments without a relational
+// database. It is opened with OpenMongo rather than Open.
+const DriverMongo = "mongodb"
+
+// DefaultMongoDatabase is used when the URI names no database.
+const DefaultMongoDatabase = "app"
+
+// OpenMongo connects to the deployment at uri, e.g.
+// "mongodb://localhost:27017/app", and returns the database named in its
+// path.
+func OpenMongo(ctx context.Context, uri string) (*mongo.Database, error) {
+	parsed, err := connstring.ParseAndValidate(uri)
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	name := parsed.Database
+	if name == "" {
+		name = DefaultMongoDatabase
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	return client.Database(name), nil
+}
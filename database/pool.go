@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"expvar"
+	"time"
+)
+
+// stats is published on /debug/vars as "database".
+var stats = expvar.NewMap("database")
+
+// PoolConfig sizes the connection pool of a DB. Zero values keep the
+// database/sql defaults: unlimited open connections, 2 idle ones, and
+// connections kept for as long as they work.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// SetPool sizes the connection pool. SQLite keeps its single connection.
+func (db *DB) SetPool(pool PoolConfig) {
+	if db.Driver == DriverSQLite {
+		return
+	}
+	if pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
+}
+
+// Publish reports the statistics of the pool on /debug/vars, under name
+// in "database": connections open, in use and idle, and how often and
+// how long requests waited for one because the pool was exhausted.
+func (db *DB) Publish(name string) {
+	stats.Set(name, expvar.Func(func() any {
+		s := db.Stats()
+		return map[string]any{
+			"max_open":             s.MaxOpenConnections,
+			"open":                 s.OpenConnections,
+			"in_use":               s.InUse,
+			"idle":                 s.Idle,
+			"wait_count":           s.WaitCount,
+			"wait_duration_ms":     s.WaitDuration.Milliseconds(),
+			"max_idle_closed":      s.MaxIdleClosed,
+			"max_idle_time_closed": s.MaxIdleTimeClosed,
+			"max_lifetime_closed":  s.MaxLifetimeClosed,
+		}
+	}))
+}
+
+// Check reports whether the database answers, e.g. for health.Check.
+func (db *DB) Check(ctx context.Context) error {
+	return db.PingContext(ctx)
+}
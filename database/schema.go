@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var schemaName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// ValidSchema reports whether name can name a schema: lower case letters,
+// digits and underscores, starting with a letter.
+func ValidSchema(name string) bool {
+	return schemaName.MatchString(name)
+}
+
+// Schema opens a pool whose statements run in the schema name, created and
+// migrated to the latest version unless it exists: a PostgreSQL schema of
+// the database, or for SQLite a database file of its own next to the
+// main one, e.g. app_acme.db for app.db. In-memory SQLite databases get
+// a private in-memory database of their own.
+func (db *DB) Schema(ctx context.Context, name string) (*DB, error) {
+	if !ValidSchema(name) {
+		return nil, fmt.Errorf("database: invalid schema name %q", name)
+	}
+	dsn := db.dsn
+	switch db.Driver {
+	case DriverPostgres:
+		if _, err := db.ExecContext(ctx, `CREATE SCHEMA IF NOT EXISTS "`+name+`"`); err != nil {
+			return nil, err
+		}
+		dsn = withSearchPath(dsn, name)
+	case DriverSQLite:
+		dsn = sqliteSchemaFile(dsn, name)
+	}
+
+	schema, err := Open(db.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Migrate(ctx); err != nil {
+		schema.Close()
+		return nil, err
+	}
+	return schema, nil
+}
+
+// withSearchPath adds the search_path run-time parameter to a PostgreSQL
+// connection URL or key=value connection string.
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			query := u.Query()
+			query.Set("search_path", schema)
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+	}
+	return strings.TrimSpace(dsn + " search_path=" + schema)
+}
+
+// sqliteSchemaFile names the database file of schema next to the one of
+// dsn, keeping its options.
+func sqliteSchemaFile(dsn, schema string) string {
+	file, options, _ := strings.Cut(dsn, "?")
+	if strings.Contains(file, ":memory:") || strings.Contains(options, "mode=memory") {
+		// Private, unlike shared cache ones.
+		return "file::memory:"
+	}
+	ext := path.Ext(file)
+	file = strings.TrimSuffix(file, ext) + "_" + schema + ext
+	if options != "" {
+		return file + "?" + options
+	}
+	return file
+}
@@ -0,0 +1,75 @@
+package debugstore
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for the retention middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Store receives the sanitized requests.
+	//
+	// Optional. Default: NewMemoryStore()
+	Store Store
+
+	// TTL is how long an entry is kept.
+	//
+	// Optional. Default: 24h
+	TTL time.Duration
+
+	// MaxBodySize caps the number of body bytes kept per entry.
+	//
+	// Optional. Default: 64KB
+	MaxBodySize int
+
+	// RedactHeaders lists headers whose values are replaced before saving.
+	//
+	// Optional. Default: Authorization, Cookie, X-Api-Key, X-Admin-Token
+	RedactHeaders []string
+
+	// RedactFields lists query parameters and body field names (JSON keys,
+	// XML elements and attributes, form fields) whose values are replaced
+	// before saving.
+	// Names containing one match too, e.g. new_password or refresh_token.
+	//
+	// Optional. Default: password, token, secret
+	RedactFields []string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	TTL:           24 * time.Hour,
+	MaxBodySize:   64 * 1024,
+	RedactHeaders: []string{fiber.HeaderAuthorization, fiber.HeaderCookie, "X-Api-Key", "X-Admin-Token"},
+	RedactFields:  []string{"password", "token", "secret"},
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		cfg := ConfigDefault
+		cfg.Store = NewMemoryStore()
+		return cfg
+	}
+
+	cfg := config[0]
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = ConfigDefault.TTL
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = ConfigDefault.MaxBodySize
+	}
+	if cfg.RedactHeaders == nil {
+		cfg.RedactHeaders = ConfigDefault.RedactHeaders
+	}
+	if cfg.RedactFields == nil {
+		cfg.RedactFields = ConfigDefault.RedactFields
+	}
+	return cfg
+}
@@ -0,0 +1,217 @@
+package debugstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const (
+	redacted = "[REDACTED]"
+	// omitted replaces bodies that cannot be parsed, and so not redacted.
+	omitted = "[body omitted]"
+)
+
+// New creates a middleware that saves a sanitized copy of every request
+// answered with a 5xx status, so failures can be replayed later.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		err := ctx.Next()
+
+		status := ctx.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+		if status < fiber.StatusInternalServerError {
+			return err
+		}
+
+		entry := capture(ctx, cfg)
+		entry.Status = status
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		_ = cfg.Store.Save(entry)
+
+		return err
+	}
+}
+
+func capture(ctx *fiber.Ctx, cfg Config) Entry {
+	now := time.Now()
+	entry := Entry{
+		ID:        utils.UUIDv4(),
+		Method:    utils.CopyString(ctx.Method()),
+		Path:      utils.CopyString(ctx.Path()),
+		Query:     sanitizeQuery(string(ctx.Request().URI().QueryString()), cfg.RedactFields),
+		Headers:   map[string]string{},
+		CreatedAt: now,
+		ExpiresAt: now.Add(cfg.TTL),
+	}
+
+	for key, value := range ctx.GetReqHeaders() {
+		key = utils.CopyString(key)
+		if containsFold(cfg.RedactHeaders, key) {
+			entry.Headers[key] = redacted
+			continue
+		}
+		entry.Headers[key] = utils.CopyString(strings.Join(value, ", "))
+	}
+
+	body := ctx.Body()
+	if len(body) > cfg.MaxBodySize {
+		body = body[:cfg.MaxBodySize]
+		entry.Truncated = true
+	}
+	entry.Body = sanitizeBody(string(ctx.Request().Header.ContentType()), body, cfg.RedactFields)
+
+	return entry
+}
+
+// sanitizeBody redacts fields in JSON, XML and form bodies. Bodies of
+// other types, or that do not parse, e.g. once truncated, are omitted.
+func sanitizeBody(contentType string, body []byte, fields []string) string {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch {
+	case len(body) == 0:
+		return ""
+	case contentType == fiber.MIMEMultipartForm:
+		return "[multipart body omitted]"
+	case contentType == fiber.MIMEApplicationJSON || strings.HasSuffix(contentType, "+json"):
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			return omitted
+		}
+		sanitized, err := json.Marshal(redactJSON(value, fields))
+		if err != nil {
+			return omitted
+		}
+		return string(sanitized)
+	case contentType == fiber.MIMEApplicationXML || contentType == fiber.MIMETextXML || strings.HasSuffix(contentType, "+xml"):
+		sanitized, err := redactXML(body, fields)
+		if err != nil {
+			return omitted
+		}
+		return sanitized
+	case contentType == fiber.MIMEApplicationForm:
+		return sanitizeQuery(string(body), fields)
+	default:
+		return omitted
+	}
+}
+
+// sanitizeQuery redacts fields in a query string or form body. Strings
+// that do not parse are omitted.
+func sanitizeQuery(query string, fields []string) string {
+	if query == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return omitted
+	}
+	for key := range values {
+		if sensitive(fields, key) {
+			values[key] = []string{redacted}
+		}
+	}
+	return values.Encode()
+}
+
+func redactJSON(value interface{}, fields []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if sensitive(fields, key) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactJSON(child, fields)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactJSON(child, fields)
+		}
+	}
+	return value
+}
+
+// redactXML re-encodes body with the content of elements, and the
+// values of attributes, named after fields replaced.
+func redactXML(body []byte, fields []string) (string, error) {
+	var out bytes.Buffer
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	encoder := xml.NewEncoder(&out)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			for i, attr := range start.Attr {
+				if sensitive(fields, attr.Name.Local) {
+					start.Attr[i].Value = redacted
+				}
+			}
+			token = start
+			if sensitive(fields, start.Name.Local) {
+				if err := decoder.Skip(); err != nil {
+					return "", err
+				}
+				if err := encoder.EncodeElement(redacted, start); err != nil {
+					return "", err
+				}
+				continue
+			}
+		}
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// sensitive reports whether a field named name holds one of fields, e.g.
+// "new_password" a password.
+func sensitive(fields []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, field := range fields {
+		if strings.Contains(name, strings.ToLower(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package debugstore
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetainFailedRequest(t *testing.T) {
+	store := NewMemoryStore()
+	app := fiber.New()
+	app.Use(New(Config{Store: store}))
+	app.Post("/register", func(ctx *fiber.Ctx) error {
+		return errors.New("Ups")
+	})
+	app.Post("/login", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("OK")
+	})
+
+	body := strings.NewReader(`{"username":"Salman","password":"123"}`)
+	request := httptest.NewRequest("POST", "/register?invite_token=abc&ref=home", body)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer secret")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 500, response.StatusCode)
+
+	request = httptest.NewRequest("POST", "/login", nil)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	entries := store.List()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "/register", entries[0].Path)
+	assert.Equal(t, 500, entries[0].Status)
+	assert.Equal(t, "Ups", entries[0].Error)
+	assert.Equal(t, redacted, entries[0].Headers["Authorization"])
+	assert.Equal(t, `{"password":"[REDACTED]","username":"Salman"}`, entries[0].Body)
+	assert.Equal(t, "invite_token=%5BREDACTED%5D&ref=home", entries[0].Query)
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	store.Save(Entry{ID: "1", ExpiresAt: now.Add(time.Minute)})
+	_, ok := store.Get("1")
+	assert.True(t, ok)
+
+	store.now = func() time.Time { return now.Add(2 * time.Minute) }
+	_, ok = store.Get("1")
+	assert.False(t, ok)
+}
+
+func TestSanitizeBody(t *testing.T) {
+	fields := ConfigDefault.RedactFields
+	for _, tt := range []struct {
+		contentType, body, want string
+	}{
+		{"application/json", `{"current_password":"a","new_password":"b","refresh_token":"c","name":"d"}`,
+			`{"current_password":"[REDACTED]","name":"d","new_password":"[REDACTED]","refresh_token":"[REDACTED]"}`},
+		{"application/json", `{"password":"123"`, omitted},
+		{"application/problem+json", `{"Secret":1}`, `{"Secret":"[REDACTED]"}`},
+		{"application/xml; charset=utf-8", `<login user="salman" api_token="t"><password>123<b>4</b></password><name>Salman</name></login>`,
+			`<login user="salman" api_token="[REDACTED]"><password>[REDACTED]</password><name>Salman</name></login>`},
+		{"text/xml", `<login><password>123`, omitted},
+		{"application/x-www-form-urlencoded", "new_password=123&name=Salman", "name=Salman&new_password=%5BREDACTED%5D"},
+		{"text/plain", "password=123", omitted},
+		{"", `{"password":"123"}`, omitted},
+	} {
+		assert.Equal(t, tt.want, sanitizeBody(tt.contentType, []byte(tt.body), fields), tt.contentType+" "+tt.body)
+	}
+}
@@ -0,0 +1,21 @@
+package debugstore
+
+import "github.com/gofiber/fiber/v2"
+
+// ListHandler returns every retained request, newest first.
+func ListHandler(store Store) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		return ctx.JSON(store.List())
+	}
+}
+
+// GetHandler returns a single retained request by its :id param.
+func GetHandler(store Store) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		entry, ok := store.Get(ctx.Params("id"))
+		if !ok {
+			return fiber.ErrNotFound
+		}
+		return ctx.JSON(entry)
+	}
+}
@@ -0,0 +1,85 @@
+package debugstore
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry is a sanitized copy of a request that ended with a 5xx response.
+type Entry struct {
+	ID        string            `json:"id"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Query     string            `json:"query,omitempty"`
+	Status    int               `json:"status"`
+	Error     string            `json:"error,omitempty"`
+	Headers   map[string]string `json:"headers"`
+	Body      string            `json:"body,omitempty"`
+	Truncated bool              `json:"truncated,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// Store persists failed requests until their TTL expires.
+type Store interface {
+	Save(entry Entry) error
+	Get(id string) (Entry, bool)
+	List() []Entry
+}
+
+// MemoryStore is an in-process Store; expired entries are dropped lazily.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: map[string]Entry{},
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Save(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	s.entries[entry.ID] = entry
+	return nil
+}
+
+func (s *MemoryStore) Get(id string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	entry, ok := s.entries[id]
+	return entry, ok
+}
+
+func (s *MemoryStore) List() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	entries := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries
+}
+
+func (s *MemoryStore) prune() {
+	now := s.now()
+	for id, entry := range s.entries {
+		if !entry.ExpiresAt.IsZero() && now.After(entry.ExpiresAt) {
+			delete(s.entries, id)
+		}
+	}
+}
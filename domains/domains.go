@@ -0,0 +1,215 @@
+// Package domains lets tenants serve the application under domain names
+// of their own. A domain is added for a tenant, pointed at the Target
+// host with a CNAME record and then verified; verified domains get their
+// certificate issued on demand and their requests resolved to the tenant.
+package domains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+var (
+	// ErrNotFound is returned when no domain matches the given name.
+	ErrNotFound = errors.New("domains: not found")
+	// ErrTaken is returned when adding a domain that was added before.
+	ErrTaken = errors.New("domains: already added")
+	// ErrNotVerified is returned by Verify while the domain's CNAME record
+	// does not point at the target.
+	ErrNotVerified = errors.New("domains: CNAME record does not point at the target")
+)
+
+// TenantKey is the Locals key Middleware stores the tenant id under.
+const TenantKey = "tenant"
+
+// Domain is a domain name serving one tenant.
+type Domain struct {
+	Name     string `json:"name"`
+	TenantID string `json:"tenant_id"`
+	// Target is the host the domain's CNAME record must point at.
+	Target     string     `json:"target"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	CheckedAt  *time.Time `json:"checked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ValidationError describes input rejected by Add.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Resolver looks up CNAME records. *net.Resolver satisfies it.
+type Resolver interface {
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// Service adds, verifies and resolves custom domains.
+type Service struct {
+	Store Store
+	// Target is the host tenants point their CNAME records at, e.g.
+	// "custom.example.com".
+	Target   string
+	Resolver Resolver
+}
+
+func NewService(store Store, target string) *Service {
+	return &Service{Store: store, Target: normalize(target), Resolver: net.DefaultResolver}
+}
+
+// Add records name as a domain of the tenant, pending verification.
+func (s *Service) Add(ctx context.Context, tenantID, name string) (*Domain, error) {
+	tenantID = strings.TrimSpace(tenantID)
+	if tenantID == "" {
+		return nil, &ValidationError{Field: "tenant_id", Message: "is required"}
+	}
+	name = normalize(name)
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	if name == s.Target || strings.HasSuffix(name, "."+s.Target) {
+		return nil, &ValidationError{Field: "name", Message: "cannot be the target or below it"}
+	}
+
+	domain := &Domain{
+		Name:      name,
+		TenantID:  tenantID,
+		Target:    s.Target,
+		CreatedAt: time.Now(),
+	}
+	if err := s.Store.Create(ctx, domain); err != nil {
+		return nil, err
+	}
+	return domain, nil
+}
+
+// Verify looks up the CNAME record of the domain with name and marks the
+// domain verified once it points at the target. A domain stays verified
+// until it is removed.
+func (s *Service) Verify(ctx context.Context, name string) (*Domain, error) {
+	domain, err := s.Store.Get(ctx, normalize(name))
+	if err != nil {
+		return nil, err
+	}
+	if domain.Verified {
+		return domain, nil
+	}
+
+	now := time.Now()
+	domain.CheckedAt = &now
+	cname, lookupErr := s.Resolver.LookupCNAME(ctx, domain.Name)
+	if lookupErr == nil && normalize(cname) == s.Target {
+		domain.Verified = true
+		domain.VerifiedAt = &now
+	}
+	if err := s.Store.Update(ctx, domain); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case domain.Verified:
+		return domain, nil
+	case lookupErr != nil:
+		return domain, fmt.Errorf("%w: %v", ErrNotVerified, lookupErr)
+	default:
+		return domain, fmt.Errorf("%w: it points at %q, not %q", ErrNotVerified, normalize(cname), s.Target)
+	}
+}
+
+// Remove deletes the domain with name; its requests no longer resolve to
+// the tenant and its certificate is not renewed.
+func (s *Service) Remove(ctx context.Context, name string) error {
+	return s.Store.Delete(ctx, normalize(name))
+}
+
+// Lookup returns the verified domain serving host, which may carry a port.
+func (s *Service) Lookup(ctx context.Context, host string) (*Domain, error) {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	domain, err := s.Store.Get(ctx, normalize(host))
+	if err != nil {
+		return nil, err
+	}
+	if !domain.Verified {
+		return nil, ErrNotFound
+	}
+	return domain, nil
+}
+
+// HostPolicy allows certificates for the hosts given and for every
+// verified domain, so tenant certificates are issued on demand by the
+// first TLS handshake for them.
+func (s *Service) HostPolicy(hosts ...string) autocert.HostPolicy {
+	return func(ctx context.Context, host string) error {
+		if slices.Contains(hosts, host) {
+			return nil
+		}
+		if _, err := s.Lookup(ctx, host); err != nil {
+			return fmt.Errorf("domains: no certificate for %q: %w", host, err)
+		}
+		return nil
+	}
+}
+
+// Middleware stores the tenant of requests made to a verified domain
+// under TenantKey. Requests to other hosts pass through unchanged.
+func (s *Service) Middleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		domain, err := s.Lookup(ctx.UserContext(), ctx.Hostname())
+		switch {
+		case err == nil:
+			ctx.Locals(TenantKey, domain.TenantID)
+		case !errors.Is(err, ErrNotFound):
+			return err
+		}
+		return ctx.Next()
+	}
+}
+
+// Tenant returns the tenant id stored by Middleware, or "".
+func Tenant(ctx *fiber.Ctx) string {
+	id, _ := ctx.Locals(TenantKey).(string)
+	return id
+}
+
+func normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+// validateName accepts fully qualified host names, such as
+// "shop.example.com", but no IP addresses or wildcards.
+func validateName(name string) error {
+	invalid := &ValidationError{Field: "name", Message: "must be a host name such as shop.example.com"}
+	if len(name) > 253 || net.ParseIP(name) != nil {
+		return invalid
+	}
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		return invalid
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return invalid
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return invalid
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package domains
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// records is a Resolver answering from a map.
+type records map[string]string
+
+func (r records) LookupCNAME(ctx context.Context, host string) (string, error) {
+	if cname, ok := r[host]; ok {
+		return cname, nil
+	}
+	return "", errors.New("no such host")
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	dns := records{}
+	service := NewService(NewMemoryStore(), "custom.example.com.")
+	service.Resolver = dns
+
+	for _, name := range []string{"", "localhost", "10.0.0.1", "*.shop.test", "-shop.test", "app.custom.example.com"} {
+		_, err := service.Add(ctx, "acme", name)
+		var invalid *ValidationError
+		assert.ErrorAs(t, err, &invalid, name)
+	}
+
+	domain, err := service.Add(ctx, "acme", "Shop.Acme.Test.")
+	assert.Nil(t, err)
+	assert.Equal(t, "shop.acme.test", domain.Name)
+	assert.Equal(t, "custom.example.com", domain.Target)
+	_, err = service.Add(ctx, "other", "shop.acme.test")
+	assert.ErrorIs(t, err, ErrTaken)
+
+	_, err = service.Verify(ctx, "shop.acme.test")
+	assert.ErrorIs(t, err, ErrNotVerified)
+	dns["shop.acme.test"] = "elsewhere.test."
+	domain, err = service.Verify(ctx, "shop.acme.test")
+	assert.ErrorIs(t, err, ErrNotVerified)
+	assert.False(t, domain.Verified)
+	assert.NotNil(t, domain.CheckedAt)
+	_, err = service.Lookup(ctx, "shop.acme.test")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	dns["shop.acme.test"] = "Custom.Example.com."
+	domain, err = service.Verify(ctx, "shop.acme.test")
+	assert.Nil(t, err)
+	assert.True(t, domain.Verified)
+
+	found, err := service.Lookup(ctx, "shop.acme.test:443")
+	assert.Nil(t, err)
+	assert.Equal(t, "acme", found.TenantID)
+
+	policy := service.HostPolicy("app.example.com")
+	assert.Nil(t, policy(ctx, "app.example.com"))
+	assert.Nil(t, policy(ctx, "shop.acme.test"))
+	assert.NotNil(t, policy(ctx, "unknown.test"))
+
+	assert.Nil(t, service.Remove(ctx, "shop.acme.test"))
+	assert.NotNil(t, policy(ctx, "shop.acme.test"))
+}
+
+func TestMiddlewareAndHandler(t *testing.T) {
+	dns := records{"shop.acme.test": "custom.example.com"}
+	service := NewService(NewMemoryStore(), "custom.example.com")
+	service.Resolver = dns
+
+	app := fiber.New()
+	app.Use(service.Middleware())
+	handler := &Handler{Service: service}
+	handler.Register(app.Group("/admin/domains"))
+	app.Get("/whoami", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("tenant=" + Tenant(ctx))
+	})
+
+	send := func(method, host, path, body string) (int, string) {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Host = host
+		request.Header.Set("Content-Type", "application/json")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		data, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+		return response.StatusCode, string(data)
+	}
+
+	status, body := send("POST", "app.example.com", "/admin/domains", `{"tenant_id":"acme","name":"shop.acme.test"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.Contains(t, body, `"target":"custom.example.com"`)
+
+	status, body = send("GET", "shop.acme.test", "/whoami", "")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "tenant=", body)
+
+	dns["shop.acme.test"] = "other.test"
+	status, body = send("POST", "app.example.com", "/admin/domains/shop.acme.test/verify", "")
+	assert.Equal(t, fiber.StatusConflict, status)
+	assert.Contains(t, body, `points at \"other.test\"`)
+
+	dns["shop.acme.test"] = "custom.example.com"
+	status, body = send("POST", "app.example.com", "/admin/domains/shop.acme.test/verify", "")
+	assert.Equal(t, fiber.StatusOK, status)
+	var verified Domain
+	assert.Nil(t, json.Unmarshal([]byte(body), &verified))
+	assert.True(t, verified.Verified)
+
+	_, body = send("GET", "shop.acme.test:8080", "/whoami", "")
+	assert.Equal(t, "tenant=acme", body)
+	_, body = send("GET", "app.example.com", "/whoami", "")
+	assert.Equal(t, "tenant=", body)
+
+	status, _ = send("DELETE", "app.example.com", "/admin/domains/shop.acme.test", "")
+	assert.Equal(t, fiber.StatusNoContent, status)
+	status, _ = send("GET", "app.example.com", "/admin/domains/shop.acme.test", "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+}
@@ -0,0 +1,98 @@
+package domains
+
+import (
+	"errors"
+
+	"belajar-golang-fiber/binding"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler manages custom domains in the admin area.
+type Handler struct {
+	Service *Service
+}
+
+// Register mounts the routes on router, e.g. adminApp.Group("/domains").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/", h.list)
+	router.Post("/", h.add)
+	router.Get("/:name", h.get)
+	router.Post("/:name/verify", h.verify)
+	router.Delete("/:name", h.remove)
+}
+
+type addRequest struct {
+	TenantID string `json:"tenant_id" form:"tenant_id"`
+	Name     string `json:"name" form:"name"`
+}
+
+// list answers every domain, or those of one ?tenant_id=.
+func (h *Handler) list(ctx *fiber.Ctx) error {
+	domains, err := h.Service.Store.List(ctx.UserContext(), ctx.Query("tenant_id"))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(domains)
+}
+
+// add answers 201 with the domain, whose target the tenant then points a
+// CNAME record at before calling verify.
+func (h *Handler) add(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[addRequest](ctx)
+	if err != nil {
+		return err
+	}
+	domain, err := h.Service.Add(ctx.UserContext(), request.TenantID, request.Name)
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(domain)
+}
+
+func (h *Handler) get(ctx *fiber.Ctx) error {
+	domain, err := h.Service.Store.Get(ctx.UserContext(), normalize(ctx.Params("name")))
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.JSON(domain)
+}
+
+// verify answers the verified domain, or 409 with the reason the CNAME
+// record does not match yet.
+func (h *Handler) verify(ctx *fiber.Ctx) error {
+	domain, err := h.Service.Verify(ctx.UserContext(), ctx.Params("name"))
+	if errors.Is(err, ErrNotVerified) {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":  err.Error(),
+			"domain": domain,
+		})
+	}
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.JSON(domain)
+}
+
+func (h *Handler) remove(ctx *fiber.Ctx) error {
+	if err := h.Service.Remove(ctx.UserContext(), ctx.Params("name")); err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func answerError(ctx *fiber.Ctx, err error) error {
+	var invalid *ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": invalid.Error(),
+			"field": invalid.Field,
+		})
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrTaken):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return err
+}
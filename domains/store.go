@@ -0,0 +1,88 @@
+package domains
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Store persists domains, keyed by name.
+type Store interface {
+	// Create fails with ErrTaken when the name exists.
+	Create(ctx context.Context, domain *Domain) error
+	Get(ctx context.Context, name string) (*Domain, error)
+	// List returns the domains of the tenant, or all of them for "",
+	// sorted by name.
+	List(ctx context.Context, tenantID string) ([]*Domain, error)
+	Update(ctx context.Context, domain *Domain) error
+	Delete(ctx context.Context, name string) error
+}
+
+// MemoryStore keeps domains in process memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	domains map[string]*Domain
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{domains: map[string]*Domain{}}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, domain *Domain) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.domains[domain.Name]; ok {
+		return ErrTaken
+	}
+	copied := *domain
+	s.domains[domain.Name] = &copied
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, name string) (*Domain, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	domain, ok := s.domains[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *domain
+	return &copied, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, tenantID string) ([]*Domain, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	domains := []*Domain{}
+	for _, domain := range s.domains {
+		if tenantID == "" || domain.TenantID == tenantID {
+			copied := *domain
+			domains = append(domains, &copied)
+		}
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].Name < domains[j].Name
+	})
+	return domains, nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, domain *Domain) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.domains[domain.Name]; !ok {
+		return ErrNotFound
+	}
+	copied := *domain
+	s.domains[domain.Name] = &copied
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.domains[name]; !ok {
+		return ErrNotFound
+	}
+	delete(s.domains, name)
+	return nil
+}
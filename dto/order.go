@@ -0,0 +1,34 @@
+package dto
+
+import "time"
+
+type OrderItem struct {
+	SKU       string `json:"sku" xml:"sku" form:"sku"`
+	Name      string `json:"name" xml:"name" form:"name"`
+	Quantity  int    `json:"quantity" xml:"quantity" form:"quantity"`
+	UnitPrice int64  `json:"unit_price" xml:"unit_price" form:"unit_price"`
+}
+
+type CreateOrderRequest struct {
+	Currency string      `json:"currency" xml:"currency" form:"currency"`
+	Items    []OrderItem `json:"items" xml:"item"`
+}
+
+type OrderResponse struct {
+	ID        string      `json:"id"`
+	Number    string      `json:"number,omitempty"`
+	UserID    string      `json:"user_id"`
+	Status    string      `json:"status"`
+	Currency  string      `json:"currency"`
+	Items     []OrderItem `json:"items"`
+	Total     int64       `json:"total"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type OrderListResponse struct {
+	Data    []OrderResponse `json:"data"`
+	Page    int             `json:"page"`
+	PerPage int             `json:"per_page"`
+	Total   int             `json:"total"`
+}
@@ -0,0 +1,107 @@
+// Package dto holds the request and response bodies of the HTTP API.
+// They are kept apart from the persistence models so a new column never
+// shows up in a response by accident; package mapping converts between
+// the two.
+package dto
+
+import "time"
+
+type RegisterRequest struct {
+	Username string `json:"username" xml:"username" form:"username"`
+	Password string `json:"password" xml:"password" form:"password"`
+	Name     string `json:"name" xml:"name" form:"name"`
+	Email    string `json:"email" xml:"email" form:"email"`
+	Phone    string `json:"phone" xml:"phone" form:"phone"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username" xml:"username" form:"username"`
+	Password string `json:"password" xml:"password" form:"password"`
+	// Remember keeps a browser signed in past its session; see
+	// session.Manager.Remember.
+	Remember bool `json:"remember" xml:"remember" form:"remember"`
+	// Captcha is the response token of a solved challenge, required once
+	// logins failed too often.
+	Captcha string `json:"captcha" xml:"captcha" form:"captcha"`
+}
+
+// LoginResponse carries the session token for API clients; browsers get
+// the same token as a cookie. RefreshToken, when refresh tokens are on,
+// gets a new session from POST /refresh, once.
+type LoginResponse struct {
+	User             UserResponse `json:"user"`
+	Token            string       `json:"token"`
+	ExpiresAt        time.Time    `json:"expires_at"`
+	RefreshToken     string       `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time   `json:"refresh_expires_at,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" xml:"refresh_token" form:"refresh_token"`
+}
+
+type UserResponse struct {
+	ID                    string     `json:"id"`
+	Username              string     `json:"username"`
+	Name                  string     `json:"name"`
+	Email                 string     `json:"email,omitempty"`
+	Phone                 string     `json:"phone,omitempty"`
+	Roles                 []string   `json:"roles,omitempty"`
+	LockedAt              *time.Time `json:"locked_at,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required,omitempty"`
+	Version               int        `json:"version"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+	DeletedAt             *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ReplaceUserRequest is the body of PUT /users/:id. Every field is
+// replaced. Passwords are changed at PUT /me/password only.
+type ReplaceUserRequest struct {
+	Username string `json:"username" xml:"username" form:"username"`
+	Name     string `json:"name" xml:"name" form:"name"`
+	Email    string `json:"email" xml:"email" form:"email"`
+	Phone    string `json:"phone" xml:"phone" form:"phone"`
+}
+
+// PatchUserRequest is the body of PATCH /users/:id. Only the fields
+// present are changed.
+type PatchUserRequest struct {
+	Username *string `json:"username" xml:"username" form:"username"`
+	Name     *string `json:"name" xml:"name" form:"name"`
+	Email    *string `json:"email" xml:"email" form:"email"`
+	Phone    *string `json:"phone" xml:"phone" form:"phone"`
+}
+
+// ProfileRequest is the body of PATCH /me. Only the fields present are
+// changed.
+type ProfileRequest struct {
+	Name  *string `json:"name" xml:"name" form:"name"`
+	Email *string `json:"email" xml:"email" form:"email"`
+	Phone *string `json:"phone" xml:"phone" form:"phone"`
+}
+
+// ChangePasswordRequest is the body of PUT /me/password.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" xml:"current_password" form:"current_password"`
+	NewPassword     string `json:"new_password" xml:"new_password" form:"new_password"`
+}
+
+// SessionResponse is one of the signed in user's sessions; Current marks
+// the one of the request.
+type SessionResponse struct {
+	ID        string    `json:"id"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type UserListResponse struct {
+	Data    []UserResponse `json:"data"`
+	Page    int            `json:"page"`
+	PerPage int            `json:"per_page"`
+	Total   int            `json:"total"`
+}
@@ -0,0 +1,58 @@
+package errorpage
+
+import (
+	"belajar-golang-fiber/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for the error handler.
+type Config struct {
+	// Bundle translates the page texts. Requests that failed before the
+	// i18n middleware ran get their language negotiated from it.
+	//
+	// Optional. Default: nil, texts fall back to the status text.
+	Bundle *i18n.Bundle
+
+	// Template is rendered for statuses without an errors/<status>
+	// template of their own.
+	//
+	// Optional. Default: "errors/error"
+	Template string
+
+	// APIPrefix marks paths answered with JSON whatever the Accept header.
+	//
+	// Optional. Default: "/api/"
+	APIPrefix string
+
+	// Fallback answers clients accepting neither HTML nor JSON.
+	//
+	// Optional. Default: fiber.DefaultErrorHandler
+	Fallback fiber.ErrorHandler
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Template:  "errors/error",
+	APIPrefix: "/api/",
+	Fallback:  fiber.DefaultErrorHandler,
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.Template == "" {
+		cfg.Template = ConfigDefault.Template
+	}
+	if cfg.APIPrefix == "" {
+		cfg.APIPrefix = ConfigDefault.APIPrefix
+	}
+	if cfg.Fallback == nil {
+		cfg.Fallback = ConfigDefault.Fallback
+	}
+	return cfg
+}
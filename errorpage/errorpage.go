@@ -0,0 +1,100 @@
+// Package errorpage answers errors with an HTML page for browsers and a
+// {"error": message} body for API clients.
+package errorpage
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"belajar-golang-fiber/i18n"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// New returns a fiber.ErrorHandler. Pages are rendered without a layout
+// from errors/<status>, e.g. errors/404, falling back to Config.Template.
+// Details of unexpected errors are logged, never shown.
+func New(config ...Config) fiber.ErrorHandler {
+	cfg := configDefault(config...)
+
+	return func(ctx *fiber.Ctx, err error) error {
+		code := fiber.StatusInternalServerError
+		message := utils.StatusMessage(code)
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			code = fiberErr.Code
+			message = fiberErr.Message
+		} else {
+			slog.Error("unhandled error", "method", ctx.Method(), "path", ctx.Path(), "error", err)
+		}
+
+		ctx.Vary(fiber.HeaderAccept)
+		switch negotiate(ctx, cfg) {
+		case fiber.MIMEApplicationJSON:
+			return ctx.Status(code).JSON(fiber.Map{"error": message})
+		case fiber.MIMETextHTML:
+			renderErr := render(ctx, cfg, code)
+			if renderErr == nil {
+				return nil
+			}
+			slog.Error("errorpage: render failed", "status", code, "error", renderErr)
+		}
+		if fiberErr == nil {
+			err = fiber.NewError(code, message)
+		}
+		ctx.Response().ResetBody()
+		return cfg.Fallback(ctx, err)
+	}
+}
+
+// negotiate picks JSON for API paths and otherwise the representation the
+// client prefers. A request without Accept gets the fallback.
+func negotiate(ctx *fiber.Ctx, cfg Config) string {
+	if strings.HasPrefix(ctx.Path(), cfg.APIPrefix) {
+		return fiber.MIMEApplicationJSON
+	}
+	if ctx.Get(fiber.HeaderAccept) == "" {
+		return ""
+	}
+	return ctx.Accepts(fiber.MIMEApplicationJSON, fiber.MIMETextHTML)
+}
+
+func render(ctx *fiber.Ctx, cfg Config, code int) error {
+	lang := i18n.Locale(ctx)
+	if lang == "" && cfg.Bundle != nil {
+		lang = i18n.Detect(ctx, cfg.Bundle)
+		ctx.Set(fiber.HeaderContentLanguage, lang)
+	}
+	translate := func(key, fallback string) string {
+		if cfg.Bundle == nil {
+			return fallback
+		}
+		if text := cfg.Bundle.Translate(lang, key); text != key {
+			return text
+		}
+		return fallback
+	}
+
+	status := strconv.Itoa(code)
+	title := translate("error_"+status+"_title", utils.StatusMessage(code))
+	bind := fiber.Map{
+		"Lang":    lang,
+		"Status":  code,
+		"Title":   title,
+		"Message": translate("error_"+status+"_message", translate("error_message", title)),
+		"Home":    translate("error_home", "Back to the home page"),
+	}
+	if lang == "" {
+		bind["Lang"] = "en"
+	}
+
+	ctx.Status(code)
+	if err := ctx.Render("errors/"+status, bind, ""); err == nil {
+		return nil
+	}
+	ctx.Response().ResetBody()
+	return ctx.Render(cfg.Template, bind, "")
+}
@@ -0,0 +1,108 @@
+package errorpage
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"belajar-golang-fiber/i18n"
+	"belajar-golang-fiber/view"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(t *testing.T, engineName string) *fiber.App {
+	engine, err := view.New(view.Config{Engine: engineName, Directory: "../template"})
+	assert.Nil(t, err)
+	bundle, err := i18n.Load("en")
+	assert.Nil(t, err)
+
+	app := fiber.New(fiber.Config{
+		Views:        engine,
+		ViewsLayout:  "layouts/main",
+		ErrorHandler: New(Config{Bundle: bundle}),
+	})
+	app.Get("/down", func(ctx *fiber.Ctx) error {
+		return fiber.ErrServiceUnavailable
+	})
+	app.Get("/boom", func(ctx *fiber.Ctx) error {
+		return errors.New("dial tcp 10.0.0.3:5432: connection refused")
+	})
+	app.Get("/api/v1/missing", func(ctx *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusNotFound, "user not found")
+	})
+	return app
+}
+
+func request(t *testing.T, app *fiber.App, path, accept, language string) (int, string, string) {
+	req := httptest.NewRequest("GET", path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if language != "" {
+		req.Header.Set("Accept-Language", language)
+	}
+	response, err := app.Test(req)
+	assert.Nil(t, err)
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	return response.StatusCode, response.Header.Get("Content-Type"), string(body)
+}
+
+const browser = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+func TestHTMLPages(t *testing.T) {
+	for _, name := range []string{"mustache", "html"} {
+		app := newApp(t, name)
+
+		status, contentType, body := request(t, app, "/nowhere", browser, "")
+		assert.Equal(t, fiber.StatusNotFound, status, name)
+		assert.Contains(t, contentType, fiber.MIMETextHTML, name)
+		assert.Contains(t, body, `<html lang="en">`, name)
+		assert.Contains(t, body, `<h1 id="error-title"><span>404</span> Page not found</h1>`, name)
+		assert.Contains(t, body, `<a href="/">Back to the home page</a>`, name)
+		assert.NotContains(t, body, "Cannot GET", name)
+
+		status, _, body = request(t, app, "/down", browser, "id-ID,id;q=0.9")
+		assert.Equal(t, fiber.StatusServiceUnavailable, status, name)
+		assert.Contains(t, body, `<html lang="id">`, name)
+		assert.Contains(t, body, "Layanan tidak tersedia", name)
+
+		status, _, body = request(t, app, "/boom", browser, "")
+		assert.Equal(t, fiber.StatusInternalServerError, status, name)
+		assert.Contains(t, body, "Something went wrong", name)
+		assert.NotContains(t, body, "10.0.0.3", name)
+	}
+}
+
+func TestJSONForAPIClients(t *testing.T) {
+	app := newApp(t, "html")
+
+	status, contentType, body := request(t, app, "/down", "application/json", "")
+	assert.Equal(t, fiber.StatusServiceUnavailable, status)
+	assert.Contains(t, contentType, fiber.MIMEApplicationJSON)
+	assert.JSONEq(t, `{"error":"Service Unavailable"}`, body)
+
+	status, _, body = request(t, app, "/api/v1/missing", browser, "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.JSONEq(t, `{"error":"user not found"}`, body)
+
+	status, _, body = request(t, app, "/boom", "*/*", "")
+	assert.Equal(t, fiber.StatusInternalServerError, status)
+	assert.JSONEq(t, `{"error":"Internal Server Error"}`, body)
+}
+
+func TestFallback(t *testing.T) {
+	app := newApp(t, "html")
+
+	status, contentType, body := request(t, app, "/down", "", "")
+	assert.Equal(t, fiber.StatusServiceUnavailable, status)
+	assert.Contains(t, contentType, fiber.MIMETextPlain)
+	assert.Equal(t, "Service Unavailable", body)
+
+	status, _, body = request(t, app, "/boom", "text/plain", "")
+	assert.Equal(t, fiber.StatusInternalServerError, status)
+	assert.Equal(t, "Internal Server Error", body)
+}
@@ -0,0 +1,355 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/logger"
+)
+
+// Headers set on dead letters.
+const (
+	HeaderDeadLetterError    = "Dead-Letter-Error"
+	HeaderDeadLetterAttempts = "Dead-Letter-Attempts"
+	// HeaderIdempotencyKey, when a message carries it, is what duplicates
+	// are recognized by instead of the event id.
+	HeaderIdempotencyKey = "Idempotency-Key"
+)
+
+// Delivery is a message received from a broker, to be acknowledged once
+// handled.
+type Delivery struct {
+	Message
+	// token is what the receiver acknowledges the delivery with.
+	token any
+}
+
+// Receiver reads the messages of a subscription. Messages not
+// acknowledged are delivered again, at the latest after the receiver is
+// closed and subscribed anew.
+type Receiver interface {
+	// Receive waits a little for the next messages, returning none when
+	// there are none yet.
+	Receive(ctx context.Context) ([]*Delivery, error)
+	// Ack acknowledges a delivery, and with it the ones before it.
+	Ack(ctx context.Context, delivery *Delivery) error
+	Close() error
+}
+
+// Subscriber is a broker messages can be consumed from. Consumers of the
+// same group share the messages of the subjects between them.
+type Subscriber interface {
+	Subscribe(ctx context.Context, group string, subjects []string) (Receiver, error)
+}
+
+// Handler handles one message. Handlers must be idempotent: a message is
+// handled at least once, and more often when acknowledging it failed.
+type Handler func(ctx context.Context, message Message) error
+
+// Ledger remembers the idempotency keys of the messages handled, so that
+// redelivered and republished messages are skipped.
+type Ledger interface {
+	Handled(ctx context.Context, key string) (bool, error)
+	Mark(ctx context.Context, key string) error
+}
+
+// MemoryLedger keeps keys in process memory for TTL.
+type MemoryLedger struct {
+	TTL time.Duration
+
+	mu   sync.Mutex
+	keys map[string]time.Time
+}
+
+func NewMemoryLedger(ttl time.Duration) *MemoryLedger {
+	return &MemoryLedger{TTL: ttl, keys: map[string]time.Time{}}
+}
+
+func (l *MemoryLedger) Handled(ctx context.Context, key string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	expires, ok := l.keys[key]
+	return ok && time.Now().Before(expires), nil
+}
+
+func (l *MemoryLedger) Mark(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for key, expires := range l.keys {
+		if !now.Before(expires) {
+			delete(l.keys, key)
+		}
+	}
+	l.keys[key] = now.Add(l.TTL)
+	return nil
+}
+
+// IdempotencyKey is the Idempotency-Key header of message, else its event
+// id: the Event-ID header, or the "id" of a JSON body for brokers that
+// drop headers.
+func IdempotencyKey(message Message) string {
+	if key := message.Header[HeaderIdempotencyKey]; key != "" {
+		return key
+	}
+	if id := message.Header[HeaderID]; id != "" {
+		return id
+	}
+	var event struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(message.Body, &event) == nil {
+		return event.ID
+	}
+	return ""
+}
+
+// Consumer hands the messages of a group to the handlers of their
+// subject, one at a time. A failing message is retried MaxAttempts times
+// with backoff and then published to DeadLetters; only then is the next
+// one handled. Messages are acknowledged once handled or dead lettered.
+type Consumer struct {
+	Group      string
+	Subscriber Subscriber
+	// MaxAttempts is how often a message is handled before it is dead
+	// lettered. Zero means 5.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled for each
+	// further one up to a minute. Zero means one second.
+	Backoff time.Duration
+	// DeadLetters receives the messages that kept failing on
+	// DeadLetterPrefix and their subject. Nil drops them after logging.
+	DeadLetters      Broker
+	DeadLetterPrefix string
+	// Ledger skips messages handled before. Nil handles duplicates again.
+	Ledger Ledger
+
+	handlers map[string]Handler
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+// NewConsumer remembers handled messages for a day and dead letters on
+// "dlq." subjects.
+func NewConsumer(group string, subscriber Subscriber) *Consumer {
+	return &Consumer{
+		Group:            group,
+		Subscriber:       subscriber,
+		DeadLetterPrefix: "dlq.",
+		Ledger:           NewMemoryLedger(24 * time.Hour),
+		handlers:         map[string]Handler{},
+		sleep:            sleep,
+	}
+}
+
+// Handle registers the handler of subject. Handlers are not safe to add
+// once the consumer runs.
+func (c *Consumer) Handle(subject string, handler Handler) {
+	c.handlers[subject] = handler
+}
+
+// Run consumes until ctx is done, subscribing again after failures.
+func (c *Consumer) Run(ctx context.Context) {
+	log := logger.FromContext(ctx).With("group", c.Group)
+	subjects := slices.Sorted(maps.Keys(c.handlers))
+	for failures := 0; ctx.Err() == nil; failures++ {
+		receiver, err := c.Subscriber.Subscribe(ctx, c.Group, subjects)
+		if err == nil {
+			err = c.consume(ctx, receiver)
+			receiver.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Error("events: consumer failed", "error", err)
+		c.sleep(ctx, c.backoff(failures))
+	}
+}
+
+func (c *Consumer) consume(ctx context.Context, receiver Receiver) error {
+	for ctx.Err() == nil {
+		deliveries, err := receiver.Receive(ctx)
+		if err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			if err := c.process(ctx, receiver, delivery); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// process handles delivery and acknowledges it, even once ctx is done.
+// It only returns early when ctx is done during a retry, leaving the
+// delivery for next time.
+func (c *Consumer) process(ctx context.Context, receiver Receiver, delivery *Delivery) error {
+	log := logger.FromContext(ctx).With("group", c.Group, "subject", delivery.Subject)
+	key := IdempotencyKey(delivery.Message)
+	if key != "" && c.Ledger != nil {
+		handled, err := c.Ledger.Handled(ctx, key)
+		if err != nil {
+			return err
+		}
+		if handled {
+			return receiver.Ack(context.WithoutCancel(ctx), delivery)
+		}
+	}
+
+	handler, ok := c.handlers[delivery.Subject]
+	if !ok {
+		log.Warn("events: no handler for subject")
+		return receiver.Ack(context.WithoutCancel(ctx), delivery)
+	}
+	maxAttempts := c.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	for attempt := 1; ; attempt++ {
+		err := c.handle(ctx, handler, delivery.Message)
+		if err == nil {
+			break
+		}
+		log.Warn("events: handling message failed", "key", key, "attempt", attempt, "error", err)
+		if attempt >= maxAttempts {
+			if err := c.deadLetter(ctx, delivery.Message, attempt, err); err != nil {
+				return err
+			}
+			break
+		}
+		if err := c.sleep(ctx, c.backoff(attempt-1)); err != nil {
+			return err
+		}
+	}
+
+	if key != "" && c.Ledger != nil {
+		if err := c.Ledger.Mark(ctx, key); err != nil {
+			log.Error("events: marking message handled failed", "key", key, "error", err)
+		}
+	}
+	return receiver.Ack(context.WithoutCancel(ctx), delivery)
+}
+
+// handle runs handler, turning a panic into an error. Stopping the
+// consumer does not cancel a handler midway.
+func (c *Consumer) handle(ctx context.Context, handler Handler, message Message) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return handler(context.WithoutCancel(ctx), message)
+}
+
+// deadLetter publishes message to the dead letters, retrying until it is
+// or ctx is done so that no message is lost.
+func (c *Consumer) deadLetter(ctx context.Context, message Message, attempts int, cause error) error {
+	log := logger.FromContext(ctx).With("group", c.Group, "subject", message.Subject)
+	if c.DeadLetters == nil {
+		log.Error("events: dropping message that kept failing", "key", IdempotencyKey(message), "error", cause)
+		return nil
+	}
+	header := maps.Clone(message.Header)
+	if header == nil {
+		header = map[string]string{}
+	}
+	header[HeaderDeadLetterError] = cause.Error()
+	header[HeaderDeadLetterAttempts] = strconv.Itoa(attempts)
+	letter := Message{Subject: c.DeadLetterPrefix + message.Subject, Key: message.Key, Header: header, Body: message.Body}
+	for failures := 0; ; failures++ {
+		err := c.DeadLetters.Publish(ctx, letter)
+		if err == nil {
+			return nil
+		}
+		log.Error("events: publishing dead letter failed", "error", err)
+		if err := c.sleep(ctx, c.backoff(failures)); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Consumer) backoff(retry int) time.Duration {
+	backoff := c.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	return min(backoff<<min(retry, 16), time.Minute)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Workers runs consumers in the background between Start and Stop, e.g.
+// from the OnListen and OnShutdown hooks of the app.
+type Workers struct {
+	consumers []*Consumer
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// Add registers a consumer. Consumers added after Start run from the next
+// Start on.
+func (w *Workers) Add(consumer *Consumer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.consumers = append(w.consumers, consumer)
+}
+
+// Start runs every consumer; it does nothing when they run already.
+func (w *Workers) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	for _, consumer := range w.consumers {
+		w.done.Add(1)
+		go func() {
+			defer w.done.Done()
+			consumer.Run(ctx)
+		}()
+	}
+}
+
+// Stop cancels the consumers and waits up to timeout for the messages
+// being handled. Those cut short are delivered again later.
+func (w *Workers) Stop(timeout time.Duration) error {
+	w.mu.Lock()
+	cancel := w.cancel
+	w.cancel = nil
+	w.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		w.done.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("events: consumers did not stop in time")
+	}
+}
@@ -0,0 +1,216 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func noSleep(ctx context.Context, d time.Duration) error {
+	return ctx.Err()
+}
+
+// recorder collects what handlers saw.
+type recorder struct {
+	mu   sync.Mutex
+	seen []string
+}
+
+func (r *recorder) add(s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen = append(r.seen, s)
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.seen...)
+}
+
+func TestConsumer(t *testing.T) {
+	broker := NewMemory()
+	deadLetters := NewMemory()
+	consumer := NewConsumer("test", broker)
+	consumer.MaxAttempts = 3
+	consumer.DeadLetters = deadLetters
+	consumer.sleep = noSleep
+
+	var handled recorder
+	attempts := map[string]int{}
+	consumer.Handle("app.user.registered", func(ctx context.Context, message Message) error {
+		key := IdempotencyKey(message)
+		attempts[key]++
+		if key == "poison" {
+			return errors.New("cannot handle")
+		}
+		if key == "flaky" && attempts[key] < 2 {
+			panic("boom")
+		}
+		handled.add(key)
+		return nil
+	})
+
+	ctx := context.Background()
+	publish := func(key string) {
+		broker.Publish(ctx, Message{Subject: "app.user.registered", Header: map[string]string{HeaderID: key}})
+	}
+	publish("a")
+	publish("a") // a duplicate
+	publish("poison")
+	publish("flaky")
+	broker.Publish(ctx, Message{Subject: "app.other", Body: []byte(`{"id":"b"}`)})
+	broker.Publish(ctx, Message{Subject: "app.user.registered", Body: []byte(`{"id":"c"}`)})
+
+	workers := &Workers{}
+	workers.Add(consumer)
+	workers.Start()
+	assert.Eventually(t, func() bool { return len(handled.list()) == 3 }, time.Second, 10*time.Millisecond)
+	assert.Nil(t, workers.Stop(time.Second))
+
+	assert.Equal(t, []string{"a", "flaky", "c"}, handled.list())
+	assert.Equal(t, 3, attempts["poison"])
+	letters := deadLetters.Messages()
+	assert.Len(t, letters, 1)
+	assert.Equal(t, "dlq.app.user.registered", letters[0].Subject)
+	assert.Equal(t, "cannot handle", letters[0].Header[HeaderDeadLetterError])
+	assert.Equal(t, "3", letters[0].Header[HeaderDeadLetterAttempts])
+}
+
+func TestKafkaRESTConsumer(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	served := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path+" "+string(body))
+		mu.Unlock()
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/consumers/workers":
+			w.Write([]byte(`{"instance_id":"i1","base_uri":"http://unreachable/consumers/workers/instances/i1"}`))
+		case strings.HasSuffix(r.URL.Path, "/records"):
+			assert.Equal(t, "application/vnd.kafka.binary.v2+json", r.Header.Get("Accept"))
+			if served {
+				w.Write([]byte(`[]`))
+				return
+			}
+			served = true
+			w.Write([]byte(`[{"topic":"app.file.uploaded","key":"ZjE=","value":"eyJpZCI6ImUxIn0=","partition":2,"offset":41}]`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	received := make(chan Message, 1)
+	consumer := NewConsumer("workers", NewKafkaREST(server.URL))
+	consumer.Handle("app.file.uploaded", func(ctx context.Context, message Message) error {
+		received <- message
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		consumer.Run(ctx)
+		close(done)
+	}()
+	message := <-received
+	assert.Equal(t, "f1", message.Key)
+	assert.Equal(t, "e1", IdempotencyKey(message))
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, calls, `POST /consumers/workers/instances/i1/subscription {"topics":["app.file.uploaded"]}`)
+	assert.Contains(t, calls, `POST /consumers/workers/instances/i1/offsets {"offsets":[{"topic":"app.file.uploaded","partition":2,"offset":41}]}`)
+	assert.Equal(t, "DELETE /consumers/workers/instances/i1 ", calls[len(calls)-1])
+}
+
+// fakeJetStream serves one stored message to pulls of consumer "workers"
+// on stream "EVENTS" and reports acknowledgements.
+func fakeJetStream(t *testing.T, acks chan<- string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte(`INFO {"headers":true}` + "\r\n"))
+		delivered := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			var payload []byte
+			if fields[0] == "PUB" || fields[0] == "HPUB" {
+				size, _ := strconv.Atoi(fields[len(fields)-1])
+				payload = make([]byte, size+2)
+				io.ReadFull(reader, payload)
+				payload = payload[:size]
+			}
+			switch {
+			case fields[0] == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case fields[0] == "PUB" && fields[1] == "$JS.API.CONSUMER.CREATE.EVENTS.workers":
+				answer := `{"type":"io.nats.jetstream.api.v1.consumer_create_response"}`
+				fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", fields[2], len(answer), answer)
+			case fields[0] == "PUB" && fields[1] == "$JS.API.CONSUMER.MSG.NEXT.EVENTS.workers":
+				if !delivered {
+					delivered = true
+					header := "NATS/1.0\r\nEvent-ID: e1\r\n\r\n"
+					body := `{}`
+					fmt.Fprintf(conn, "HMSG app.user.registered 1 $JS.ACK.EVENTS.workers.1.1.1.0.0 %d %d\r\n%s%s\r\n", len(header), len(header)+len(body), header, body)
+				}
+				status := "NATS/1.0 408 Request Timeout\r\n\r\n"
+				fmt.Fprintf(conn, "HMSG %s 1 %d %d\r\n%s\r\n", fields[2], len(status), len(status), status)
+			case fields[0] == "PUB" && strings.HasPrefix(fields[1], "$JS.ACK."):
+				acks <- fields[1] + " " + string(payload)
+			}
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestJetStreamConsumer(t *testing.T) {
+	acks := make(chan string, 1)
+	broker := NewNATS("nats://" + fakeJetStream(t, acks))
+	broker.Stream = "EVENTS"
+
+	received := make(chan Message, 1)
+	consumer := NewConsumer("workers", broker)
+	consumer.Handle("app.user.registered", func(ctx context.Context, message Message) error {
+		received <- message
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go consumer.Run(ctx)
+
+	message := <-received
+	assert.Equal(t, "e1", message.Header[HeaderID])
+	assert.Equal(t, "$JS.ACK.EVENTS.workers.1.1.1.0.0 +ACK", <-acks)
+
+	var body map[string]any
+	assert.Nil(t, json.Unmarshal(message.Body, &body))
+}
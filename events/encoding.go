@@ -0,0 +1,78 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"belajar-golang-fiber/events/eventsv1"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Encoder turns events into message bodies.
+type Encoder interface {
+	Encode(event Event) ([]byte, error)
+	ContentType() string
+}
+
+// NewEncoder returns the encoder named "json" or "protobuf".
+func NewEncoder(name string) (Encoder, error) {
+	switch name {
+	case "", "json":
+		return JSON{}, nil
+	case "protobuf":
+		return Protobuf{}, nil
+	}
+	return nil, fmt.Errorf("events: unknown encoding %q", name)
+}
+
+// JSON encodes events as JSON objects.
+type JSON struct{}
+
+func (JSON) Encode(event Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+func (JSON) ContentType() string {
+	return "application/json"
+}
+
+// Protobuf encodes events as eventsv1.Envelope messages.
+type Protobuf struct{}
+
+func (Protobuf) Encode(event Event) ([]byte, error) {
+	envelope := &eventsv1.Envelope{
+		Id:     event.ID,
+		Type:   event.Type,
+		Source: event.Source,
+		Time:   timestamppb.New(event.Time),
+	}
+	switch data := event.Data.(type) {
+	case UserRegistered:
+		envelope.Data = &eventsv1.Envelope_UserRegistered{UserRegistered: &eventsv1.UserRegistered{
+			UserId:   data.UserID,
+			Username: data.Username,
+			Name:     data.Name,
+			Email:    data.Email,
+		}}
+	case FileUploaded:
+		envelope.Data = &eventsv1.Envelope_FileUploaded{FileUploaded: &eventsv1.FileUploaded{
+			FileId:      data.FileID,
+			Name:        data.Name,
+			ContentType: data.ContentType,
+			Size:        data.Size,
+			Checksum:    data.Checksum,
+			UserId:      data.UserID,
+			Tenant:      data.Tenant,
+			Version:     int32(data.Version),
+		}}
+	default:
+		return nil, fmt.Errorf("events: no protobuf message for %T", event.Data)
+	}
+	return proto.Marshal(envelope)
+}
+
+func (Protobuf) ContentType() string {
+	return "application/x-protobuf"
+}
@@ -0,0 +1,169 @@
+// Package events publishes domain events, such as a user registering or a
+// file being uploaded, to a message broker for other services to consume.
+// Events are encoded as JSON or protobuf (see eventsv1) and published on
+// a subject named after their type, e.g. "app.user.registered".
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"belajar-golang-fiber/jobs"
+	"belajar-golang-fiber/logger"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// JobKind is the kind of the jobs publishing events.
+const JobKind = "events.publish"
+
+// Event types.
+const (
+	TypeUserRegistered = "user.registered"
+	TypeFileUploaded   = "file.uploaded"
+)
+
+// Data is the payload of an event.
+type Data interface {
+	EventType() string
+}
+
+// UserRegistered is published after a user registered.
+type UserRegistered struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+}
+
+func (UserRegistered) EventType() string { return TypeUserRegistered }
+
+// FileUploaded is published after a file, or a new version of it, was
+// stored.
+type FileUploaded struct {
+	FileID      string `json:"file_id"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+	UserID      string `json:"user_id,omitempty"`
+	Tenant      string `json:"tenant,omitempty"`
+	Version     int    `json:"version"`
+}
+
+func (FileUploaded) EventType() string { return TypeFileUploaded }
+
+// Event is the envelope every payload is published in.
+type Event struct {
+	ID     string    `json:"id"`
+	Type   string    `json:"type"`
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+	Data   Data      `json:"data"`
+}
+
+// Message is an encoded event as handed to a broker.
+type Message struct {
+	Subject string            `json:"subject"`
+	Key     string            `json:"key,omitempty"`
+	Header  map[string]string `json:"header,omitempty"`
+	Body    []byte            `json:"body"`
+}
+
+// Headers set on every message.
+const (
+	HeaderID          = "Event-ID"
+	HeaderType        = "Event-Type"
+	HeaderContentType = "Content-Type"
+)
+
+// Broker delivers messages to a message broker.
+type Broker interface {
+	Publish(ctx context.Context, message Message) error
+	Close() error
+}
+
+// Publisher encodes events and hands them to Broker.
+type Publisher struct {
+	Broker  Broker
+	Encoder Encoder
+	// Prefix is put before the event type to name the subject, e.g.
+	// "app." publishes user.registered on "app.user.registered".
+	Prefix string
+	// Source names the publishing app in every event.
+	Source string
+
+	queue *jobs.Queue
+}
+
+// NewPublisher encodes events as JSON.
+func NewPublisher(broker Broker) *Publisher {
+	return &Publisher{Broker: broker, Encoder: JSON{}, Prefix: "app.", Source: "belajar-golang-fiber"}
+}
+
+// Attach publishes through jobs on queue, so that a broker being slow or
+// down delays events rather than the requests raising them. Unattached
+// publishers publish right away.
+func (p *Publisher) Attach(queue *jobs.Queue) {
+	p.queue = queue
+	queue.Handle(JobKind, func(ctx context.Context, job *jobs.Job) error {
+		var message Message
+		if err := job.Decode(&message); err != nil {
+			return err
+		}
+		return p.Broker.Publish(ctx, message)
+	})
+}
+
+// Publish sends data as a new event.
+func (p *Publisher) Publish(ctx context.Context, data Data) error {
+	if data == nil {
+		return errors.New("events: no data")
+	}
+	event := Event{
+		ID:     utils.UUIDv4(),
+		Type:   data.EventType(),
+		Source: p.Source,
+		Time:   time.Now().UTC(),
+		Data:   data,
+	}
+	body, err := p.Encoder.Encode(event)
+	if err != nil {
+		return fmt.Errorf("events: encoding %s: %w", event.Type, err)
+	}
+	message := Message{
+		Subject: p.Prefix + event.Type,
+		Key:     key(data),
+		Header: map[string]string{
+			HeaderID:          event.ID,
+			HeaderType:        event.Type,
+			HeaderContentType: p.Encoder.ContentType(),
+		},
+		Body: body,
+	}
+	if p.queue != nil {
+		return p.queue.Enqueue(ctx, JobKind, message)
+	}
+	return p.Broker.Publish(ctx, message)
+}
+
+// PublishLogged is Publish logging errors instead of returning them, for
+// use in hooks that cannot fail.
+func (p *Publisher) PublishLogged(ctx context.Context, data Data) {
+	if err := p.Publish(ctx, data); err != nil {
+		logger.FromContext(ctx).Error("events: publish failed", "event", data.EventType(), "error", err)
+	}
+}
+
+// key keeps the events of one entity in order on partitioned brokers.
+func key(data Data) string {
+	switch data := data.(type) {
+	case UserRegistered:
+		return data.UserID
+	case FileUploaded:
+		return data.FileID
+	}
+	return ""
+}
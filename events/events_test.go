@@ -0,0 +1,161 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/events/eventsv1"
+	"belajar-golang-fiber/jobs"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPublishJSON(t *testing.T) {
+	broker := NewMemory()
+	publisher := NewPublisher(broker)
+
+	err := publisher.Publish(context.Background(), UserRegistered{UserID: "u1", Username: "budi"})
+	assert.Nil(t, err)
+
+	messages := broker.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "app.user.registered", messages[0].Subject)
+	assert.Equal(t, "u1", messages[0].Key)
+	assert.Equal(t, "application/json", messages[0].Header[HeaderContentType])
+
+	var event struct {
+		ID   string         `json:"id"`
+		Type string         `json:"type"`
+		Data UserRegistered `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(messages[0].Body, &event))
+	assert.Equal(t, messages[0].Header[HeaderID], event.ID)
+	assert.Equal(t, TypeUserRegistered, event.Type)
+	assert.Equal(t, "budi", event.Data.Username)
+}
+
+func TestPublishProtobuf(t *testing.T) {
+	broker := NewMemory()
+	publisher := NewPublisher(broker)
+	publisher.Encoder, _ = NewEncoder("protobuf")
+
+	err := publisher.Publish(context.Background(), FileUploaded{FileID: "f1", Name: "a.pdf", Size: 42, Version: 2})
+	assert.Nil(t, err)
+
+	var envelope eventsv1.Envelope
+	assert.Nil(t, proto.Unmarshal(broker.Messages()[0].Body, &envelope))
+	assert.Equal(t, TypeFileUploaded, envelope.GetType())
+	assert.Equal(t, "a.pdf", envelope.GetFileUploaded().GetName())
+	assert.Equal(t, int64(42), envelope.GetFileUploaded().GetSize())
+	assert.Equal(t, int32(2), envelope.GetFileUploaded().GetVersion())
+
+	_, err = NewEncoder("xml")
+	assert.Error(t, err)
+}
+
+func TestPublishThroughQueue(t *testing.T) {
+	broker := NewMemory()
+	publisher := NewPublisher(broker)
+	queue := jobs.NewQueue(10)
+	publisher.Attach(queue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, publisher.Publish(ctx, UserRegistered{UserID: "u1"}))
+	assert.Len(t, broker.Messages(), 0)
+
+	go queue.Run(ctx, 1)
+	assert.Eventually(t, func() bool { return len(broker.Messages()) == 1 }, time.Second, 10*time.Millisecond)
+	cancel()
+	assert.Equal(t, "app.user.registered", broker.Messages()[0].Subject)
+}
+
+// fakeNATS accepts one connection, records what was published and denies
+// subjects starting with "denied.".
+func fakeNATS(t *testing.T, published chan<- string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte(`INFO {"headers":true}` + "\r\n"))
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch fields[0] {
+			case "CONNECT":
+				published <- strings.TrimSpace(line)
+			case "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case "HPUB":
+				size, _ := strconv.Atoi(fields[3])
+				frame := make([]byte, size+2)
+				io.ReadFull(reader, frame)
+				if strings.HasPrefix(fields[1], "denied.") {
+					conn.Write([]byte("-ERR 'Permissions Violation for Publish to \"" + fields[1] + "\"'\r\n"))
+					continue
+				}
+				published <- fields[1] + " " + string(frame[:size])
+			}
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestNATS(t *testing.T) {
+	published := make(chan string, 10)
+	addr := fakeNATS(t, published)
+	broker := NewNATS("nats://secret@" + addr)
+	defer broker.Close()
+
+	err := broker.Publish(context.Background(), Message{
+		Subject: "app.user.registered",
+		Header:  map[string]string{HeaderType: "user.registered"},
+		Body:    []byte(`{"id":"1"}`),
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, <-published, `"auth_token":"secret"`)
+	assert.Equal(t, "app.user.registered NATS/1.0\r\nEvent-Type: user.registered\r\n\r\n{\"id\":\"1\"}", <-published)
+
+	err = broker.Publish(context.Background(), Message{Subject: "denied.x", Header: map[string]string{"A": "b"}})
+	assert.ErrorContains(t, err, "Permissions Violation")
+}
+
+func TestKafkaREST(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/app.file.uploaded", r.URL.Path)
+		assert.Equal(t, "application/vnd.kafka.binary.v2+json", r.Header.Get("Content-Type"))
+		var records kafkaRecords
+		json.NewDecoder(r.Body).Decode(&records)
+		if string(records.Records[0].Key) == "bad" {
+			w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":50002,"error":"not authorized"}]}`))
+			return
+		}
+		assert.Equal(t, "f1", string(records.Records[0].Key))
+		assert.Equal(t, "{}", string(records.Records[0].Value))
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":7,"error_code":null,"error":null}]}`))
+	}))
+	defer server.Close()
+
+	broker := NewKafkaREST(server.URL + "/")
+	assert.Nil(t, broker.Publish(context.Background(), Message{Subject: "app.file.uploaded", Key: "f1", Body: []byte("{}")}))
+	err := broker.Publish(context.Background(), Message{Subject: "app.file.uploaded", Key: "bad", Body: []byte("{}")})
+	assert.ErrorContains(t, err, "not authorized")
+}
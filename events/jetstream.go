@@ -0,0 +1,151 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// pullBatch and pullExpires bound one pull from JetStream.
+const (
+	pullBatch   = 10
+	pullExpires = time.Second
+)
+
+// Subscribe pulls from the durable JetStream consumer named group on
+// Stream, created or updated to take subjects, over a connection of its
+// own. Messages are acknowledged one by one; JetStream delivers those it
+// got no acknowledgement for again after the consumer's ack wait.
+func (n *NATS) Subscribe(ctx context.Context, group string, subjects []string) (Receiver, error) {
+	if n.Stream == "" {
+		return nil, errors.New("events: nats: subscribing needs a JetStream stream")
+	}
+	conn, err := dialNATS(ctx, n.URL, n.Name, n.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("events: nats: %w", err)
+	}
+	receiver := &jetStreamReceiver{
+		nats:  n,
+		conn:  conn,
+		group: group,
+		inbox: "_INBOX." + strings.ReplaceAll(utils.UUIDv4(), "-", ""),
+	}
+	if err := receiver.setup(ctx, subjects); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("events: nats: %w", err)
+	}
+	return receiver, nil
+}
+
+type jetStreamReceiver struct {
+	nats  *NATS
+	conn  *natsConn
+	group string
+	inbox string
+	pulls int
+}
+
+type jetStreamError struct {
+	Error *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error"`
+}
+
+func (r *jetStreamReceiver) setup(ctx context.Context, subjects []string) error {
+	r.conn.setDeadline(ctx, r.nats.Timeout)
+	if err := r.conn.write("SUB " + r.inbox + ".* 1\r\n"); err != nil {
+		return err
+	}
+	config, err := json.Marshal(map[string]any{
+		"stream_name": r.nats.Stream,
+		"config": map[string]any{
+			"durable_name":    r.group,
+			"ack_policy":      "explicit",
+			"deliver_policy":  "all",
+			"filter_subjects": subjects,
+			"ack_wait":        (30 * time.Second).Nanoseconds(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	reply := r.inbox + ".api"
+	subject := "$JS.API.CONSUMER.CREATE." + r.nats.Stream + "." + r.group
+	if err := r.conn.write(r.conn.pub(subject, reply, nil, config)); err != nil {
+		return err
+	}
+	for {
+		msg, err := r.conn.next()
+		if err != nil {
+			return err
+		}
+		if msg.Subject != reply {
+			continue
+		}
+		if msg.Status == 503 {
+			return errors.New("jetstream is not enabled")
+		}
+		var answer jetStreamError
+		if err := json.Unmarshal(msg.Body, &answer); err != nil {
+			return err
+		}
+		if answer.Error != nil {
+			return fmt.Errorf("creating consumer: %s (%d)", answer.Error.Description, answer.Error.Code)
+		}
+		return nil
+	}
+}
+
+// Receive asks for a batch of messages and reads them until the batch is
+// full or the request expires.
+func (r *jetStreamReceiver) Receive(ctx context.Context) ([]*Delivery, error) {
+	r.pulls++
+	reply := r.inbox + "." + strconv.Itoa(r.pulls)
+	request, _ := json.Marshal(map[string]any{"batch": pullBatch, "expires": pullExpires.Nanoseconds()})
+	subject := "$JS.API.CONSUMER.MSG.NEXT." + r.nats.Stream + "." + r.group
+
+	r.conn.setDeadline(ctx, pullExpires+r.nats.Timeout)
+	if err := r.conn.write(r.conn.pub(subject, reply, nil, request)); err != nil {
+		return nil, err
+	}
+	var deliveries []*Delivery
+	for len(deliveries) < pullBatch {
+		msg, err := r.conn.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case msg.Status == 0 && strings.HasPrefix(msg.Reply, "$JS.ACK."):
+			deliveries = append(deliveries, &Delivery{
+				Message: Message{Subject: msg.Subject, Header: msg.Header, Body: msg.Body},
+				token:   msg.Reply,
+			})
+		case msg.Subject != reply || msg.Status == 100:
+			// Answers to earlier pulls and heartbeats.
+		case msg.Status == 404 || msg.Status == 408:
+			return deliveries, nil
+		case msg.Status >= 400:
+			if len(deliveries) > 0 {
+				return deliveries, nil
+			}
+			return nil, fmt.Errorf("events: nats: pull failed with status %d", msg.Status)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *jetStreamReceiver) Ack(ctx context.Context, delivery *Delivery) error {
+	r.conn.setDeadline(ctx, r.nats.Timeout)
+	return r.conn.write(r.conn.pub(delivery.token.(string), "", nil, []byte("+ACK")))
+}
+
+func (r *jetStreamReceiver) Close() error {
+	return r.conn.Close()
+}
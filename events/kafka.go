@@ -0,0 +1,205 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/httpclient"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// KafkaREST publishes to Kafka through a Confluent REST Proxy at URL,
+// using the message subject as the topic. The v2 API it speaks carries no
+// record headers; the event id and type are in the body either way.
+type KafkaREST struct {
+	URL    string
+	Client *httpclient.Client
+}
+
+// NewKafkaREST publishes through the REST Proxy at rawURL. The job queue
+// retries failed publishes, so the client does not.
+func NewKafkaREST(rawURL string) *KafkaREST {
+	return &KafkaREST{
+		URL: strings.TrimSuffix(rawURL, "/"),
+		Client: httpclient.New(httpclient.Config{
+			Name:    "kafka",
+			Timeout: 10 * time.Second,
+			Retries: -1,
+		}),
+	}
+}
+
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Key   []byte `json:"key,omitempty"`
+	Value []byte `json:"value"`
+}
+
+type kafkaOffsets struct {
+	Offsets []struct {
+		Partition int    `json:"partition"`
+		Offset    int64  `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (k *KafkaREST) Publish(ctx context.Context, message Message) error {
+	record := kafkaRecord{Value: message.Body}
+	if message.Key != "" {
+		record.Key = []byte(message.Key)
+	}
+	// Binary records are base64 encoded, as []byte is by encoding/json.
+	body, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{record}})
+	if err != nil {
+		return err
+	}
+	response, err := k.Client.Do(ctx, httpclient.Request{
+		Method: fiber.MethodPost,
+		URL:    k.URL + "/topics/" + url.PathEscape(message.Subject),
+		Header: map[string]string{
+			fiber.HeaderContentType: "application/vnd.kafka.binary.v2+json",
+			fiber.HeaderAccept:      "application/vnd.kafka.v2+json",
+		},
+		Body: body,
+	})
+	if err != nil {
+		return err
+	}
+	if response.Status != fiber.StatusOK {
+		return fmt.Errorf("events: kafka rest proxy returned %d: %s", response.Status, response.Body)
+	}
+	var offsets kafkaOffsets
+	if err := json.Unmarshal(response.Body, &offsets); err != nil {
+		return fmt.Errorf("events: kafka rest proxy answer: %w", err)
+	}
+	for _, offset := range offsets.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("events: kafka rejected the record: %s", offset.Error)
+		}
+	}
+	return nil
+}
+
+func (k *KafkaREST) Close() error {
+	return nil
+}
+
+// Subscribe creates a consumer instance of group on the REST Proxy,
+// subscribed to subjects as topics. Offsets are committed as messages
+// are acknowledged, so a new instance resumes after the last one
+// handled.
+func (k *KafkaREST) Subscribe(ctx context.Context, group string, subjects []string) (Receiver, error) {
+	base := k.URL + "/consumers/" + url.PathEscape(group)
+	var instance struct {
+		InstanceID string `json:"instance_id"`
+	}
+	err := k.call(ctx, fiber.MethodPost, base, map[string]string{
+		"format":             "binary",
+		"auto.offset.reset":  "earliest",
+		"auto.commit.enable": "false",
+	}, &instance)
+	if err != nil {
+		return nil, err
+	}
+	receiver := &kafkaReceiver{kafka: k, base: base + "/instances/" + url.PathEscape(instance.InstanceID)}
+	if err := k.call(ctx, fiber.MethodPost, receiver.base+"/subscription", map[string]any{"topics": subjects}, nil); err != nil {
+		receiver.Close()
+		return nil, err
+	}
+	return receiver, nil
+}
+
+// call sends body as JSON to the consumer API and decodes the answer
+// into out, when not nil.
+func (k *KafkaREST) call(ctx context.Context, method, target string, body, out any) error {
+	request := httpclient.Request{
+		Method: method,
+		URL:    target,
+		Header: map[string]string{
+			fiber.HeaderContentType: "application/vnd.kafka.v2+json",
+			fiber.HeaderAccept:      "application/vnd.kafka.v2+json",
+		},
+	}
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		request.Body = raw
+	}
+	return k.do(ctx, request, out)
+}
+
+func (k *KafkaREST) do(ctx context.Context, request httpclient.Request, out any) error {
+	response, err := k.Client.Do(ctx, request)
+	if err != nil {
+		return err
+	}
+	if response.Status >= fiber.StatusMultipleChoices {
+		return fmt.Errorf("events: kafka rest proxy returned %d: %s", response.Status, response.Body)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Body, out); err != nil {
+		return fmt.Errorf("events: kafka rest proxy answer: %w", err)
+	}
+	return nil
+}
+
+type kafkaReceiver struct {
+	kafka *KafkaREST
+	base  string
+}
+
+type kafkaOffset struct {
+	Topic     string `json:"topic"`
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+func (r *kafkaReceiver) Receive(ctx context.Context) ([]*Delivery, error) {
+	var records []struct {
+		kafkaOffset
+		Key   []byte `json:"key"`
+		Value []byte `json:"value"`
+	}
+	err := r.kafka.do(ctx, httpclient.Request{
+		Method: fiber.MethodGet,
+		URL:    r.base + "/records?timeout=1000",
+		Header: map[string]string{fiber.HeaderAccept: "application/vnd.kafka.binary.v2+json"},
+	}, &records)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]*Delivery, len(records))
+	for i, record := range records {
+		deliveries[i] = &Delivery{
+			Message: Message{Subject: record.Topic, Key: string(record.Key), Body: record.Value},
+			token:   record.kafkaOffset,
+		}
+	}
+	return deliveries, nil
+}
+
+// Ack commits the offset of delivery; the proxy commits the one after it,
+// where the group resumes.
+func (r *kafkaReceiver) Ack(ctx context.Context, delivery *Delivery) error {
+	offset := delivery.token.(kafkaOffset)
+	return r.kafka.call(ctx, fiber.MethodPost, r.base+"/offsets", map[string]any{"offsets": []kafkaOffset{offset}}, nil)
+}
+
+// Close deletes the consumer instance, handing its partitions to the
+// rest of the group.
+func (r *kafkaReceiver) Close() error {
+	return r.kafka.call(context.Background(), fiber.MethodDelete, r.base, nil, nil)
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Memory keeps published messages in process memory, for tests and for
+// running without a broker. Every subscription reads them from the
+// start; groups are not shared.
+type Memory struct {
+	mu        sync.Mutex
+	messages  []Message
+	published chan struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{published: make(chan struct{})}
+}
+
+func (m *Memory) Publish(ctx context.Context, message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, message)
+	close(m.published)
+	m.published = make(chan struct{})
+	return nil
+}
+
+// Messages returns the published messages, oldest first.
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func (m *Memory) Subscribe(ctx context.Context, group string, subjects []string) (Receiver, error) {
+	return &memoryReceiver{memory: m, subjects: subjects}, nil
+}
+
+type memoryReceiver struct {
+	memory   *Memory
+	subjects []string
+	next     int
+}
+
+// Receive waits up to a second for messages published after the last
+// ones received.
+func (r *memoryReceiver) Receive(ctx context.Context) ([]*Delivery, error) {
+	r.memory.mu.Lock()
+	messages, published := r.memory.messages[r.next:], r.memory.published
+	r.memory.mu.Unlock()
+	if len(messages) == 0 {
+		select {
+		case <-published:
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+		}
+		return nil, nil
+	}
+	r.next += len(messages)
+	var deliveries []*Delivery
+	for _, message := range messages {
+		if slices.Contains(r.subjects, message.Subject) {
+			deliveries = append(deliveries, &Delivery{Message: message})
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *memoryReceiver) Ack(ctx context.Context, delivery *Delivery) error {
+	return nil
+}
+
+func (r *memoryReceiver) Close() error {
+	return nil
+}
@@ -0,0 +1,326 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATS publishes to a NATS server with its text protocol. Every publish
+// waits for the server to answer a PING sent after it, so errors such as
+// a denied subject are returned rather than lost. The connection is made
+// on first use and again after any failure.
+type NATS struct {
+	// URL is nats://host:port, or tls://host:port for TLS. Credentials
+	// go in its user info: user:password, or a token alone.
+	URL string
+	// Name identifies the connection in the server's monitoring.
+	Name    string
+	Timeout time.Duration
+	// Stream is the JetStream stream Subscribe pulls from; it must
+	// capture the subjects subscribed to.
+	Stream string
+
+	mu   sync.Mutex
+	conn *natsConn
+}
+
+func NewNATS(url string) *NATS {
+	return &NATS{URL: url, Name: "belajar-golang-fiber", Timeout: 5 * time.Second}
+}
+
+func (n *NATS) Publish(ctx context.Context, message Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.publish(ctx, message); err != nil {
+		n.close()
+		return fmt.Errorf("events: nats: %w", err)
+	}
+	return nil
+}
+
+func (n *NATS) publish(ctx context.Context, message Message) error {
+	if n.conn == nil {
+		conn, err := dialNATS(ctx, n.URL, n.Name, n.Timeout)
+		if err != nil {
+			return err
+		}
+		n.conn = conn
+	}
+	n.conn.setDeadline(ctx, n.Timeout)
+	frame := n.conn.pub(message.Subject, "", message.Header, message.Body) + "PING\r\n"
+	if err := n.conn.write(frame); err != nil {
+		return err
+	}
+	return n.conn.awaitPong()
+}
+
+func (n *NATS) close() {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+}
+
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.close()
+	return nil
+}
+
+// natsConn is one connection speaking the NATS text protocol.
+type natsConn struct {
+	net.Conn
+	reader  *bufio.Reader
+	headers bool
+}
+
+type natsInfo struct {
+	Headers     bool `json:"headers"`
+	TLSRequired bool `json:"tls_required"`
+}
+
+type natsConnect struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Name     string `json:"name,omitempty"`
+	Lang     string `json:"lang"`
+	Version  string `json:"version"`
+	Protocol int    `json:"protocol"`
+	Headers  bool   `json:"headers"`
+	NoEcho   bool   `json:"no_echo"`
+	User     string `json:"user,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+	Token    string `json:"auth_token,omitempty"`
+}
+
+func dialNATS(ctx context.Context, rawURL, name string, timeout time.Duration) (*natsConn, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := target.Host
+	if target.Port() == "" {
+		host = net.JoinHostPort(target.Hostname(), "4222")
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	raw, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	conn := &natsConn{Conn: raw, reader: bufio.NewReader(raw)}
+	if err := conn.handshake(ctx, target, name, timeout); err != nil {
+		raw.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (c *natsConn) handshake(ctx context.Context, target *url.URL, name string, timeout time.Duration) error {
+	c.setDeadline(ctx, timeout)
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	payload, ok := strings.CutPrefix(line, "INFO ")
+	if !ok {
+		return fmt.Errorf("expected INFO, got %q", line)
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(payload), &info); err != nil {
+		return err
+	}
+	c.headers = info.Headers
+	// The server greets in plain text, then expects the TLS handshake.
+	if target.Scheme == "tls" || info.TLSRequired {
+		c.Conn = tls.Client(c.Conn, &tls.Config{ServerName: target.Hostname()})
+		c.reader = bufio.NewReader(c.Conn)
+	}
+
+	connect := natsConnect{
+		Name:     name,
+		Lang:     "go",
+		Version:  "1.0.0",
+		Protocol: 1,
+		Headers:  info.Headers,
+		NoEcho:   true,
+	}
+	if user := target.User; user != nil {
+		if pass, ok := user.Password(); ok {
+			connect.User, connect.Pass = user.Username(), pass
+		} else {
+			connect.Token = user.Username()
+		}
+	}
+	options, err := json.Marshal(connect)
+	if err != nil {
+		return err
+	}
+	if err := c.write("CONNECT " + string(options) + "\r\nPING\r\n"); err != nil {
+		return err
+	}
+	return c.awaitPong()
+}
+
+// pub frames a message, with headers when the server takes them.
+func (c *natsConn) pub(subject, reply string, header map[string]string, body []byte) string {
+	var frame strings.Builder
+	if reply != "" {
+		reply += " "
+	}
+	if c.headers && len(header) > 0 {
+		var head strings.Builder
+		head.WriteString("NATS/1.0\r\n")
+		for _, name := range slices.Sorted(maps.Keys(header)) {
+			head.WriteString(name + ": " + header[name] + "\r\n")
+		}
+		head.WriteString("\r\n")
+		fmt.Fprintf(&frame, "HPUB %s %s%d %d\r\n%s", subject, reply, head.Len(), head.Len()+len(body), head.String())
+	} else {
+		fmt.Fprintf(&frame, "PUB %s %s%d\r\n", subject, reply, len(body))
+	}
+	frame.Write(body)
+	frame.WriteString("\r\n")
+	return frame.String()
+}
+
+func (c *natsConn) write(frame string) error {
+	_, err := c.Write([]byte(frame))
+	return err
+}
+
+// awaitPong reads until the server answers our PING, answering its own.
+func (c *natsConn) awaitPong() error {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		if line == "PONG" {
+			return nil
+		}
+		if err := c.control(line); err != nil {
+			return err
+		}
+	}
+}
+
+// control handles the lines that are neither messages nor PONG.
+func (c *natsConn) control(line string) error {
+	switch {
+	case line == "PING":
+		return c.write("PONG\r\n")
+	case strings.HasPrefix(line, "-ERR"):
+		return errors.New(strings.Trim(strings.TrimPrefix(line, "-ERR "), "'"))
+	}
+	// +OK and INFO updates need no answer.
+	return nil
+}
+
+func (c *natsConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// natsMsg is a message received on a subscription. Status is set on the
+// status messages of JetStream, e.g. 404 when there are none.
+type natsMsg struct {
+	Subject string
+	Reply   string
+	Header  map[string]string
+	Status  int
+	Body    []byte
+}
+
+// next reads the next message, handling control lines on the way.
+func (c *natsConn) next() (*natsMsg, error) {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 0 && (fields[0] == "MSG" || fields[0] == "HMSG") {
+			return c.readMsg(fields)
+		}
+		if err := c.control(line); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readMsg reads the payload of "MSG subject sid [reply] size" or
+// "HMSG subject sid [reply] header-size total-size".
+func (c *natsConn) readMsg(fields []string) (*natsMsg, error) {
+	sizes := 1
+	if fields[0] == "HMSG" {
+		sizes = 2
+	}
+	if len(fields) != 3+sizes && len(fields) != 4+sizes {
+		return nil, fmt.Errorf("malformed %s", fields[0])
+	}
+	msg := &natsMsg{Subject: fields[1]}
+	if len(fields) == 4+sizes {
+		msg.Reply = fields[3]
+	}
+	total, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return nil, err
+	}
+	headerSize := 0
+	if sizes == 2 {
+		if headerSize, err = strconv.Atoi(fields[len(fields)-2]); err != nil || headerSize > total {
+			return nil, fmt.Errorf("malformed HMSG")
+		}
+	}
+	payload := make([]byte, total+2)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return nil, err
+	}
+	msg.Body = payload[headerSize:total]
+	if headerSize > 0 {
+		msg.Header, msg.Status = parseNATSHeader(string(payload[:headerSize]))
+	}
+	return msg, nil
+}
+
+// parseNATSHeader parses "NATS/1.0 [status description]" and the header
+// lines after it.
+func parseNATSHeader(raw string) (map[string]string, int) {
+	lines := strings.Split(strings.TrimRight(raw, "\r\n"), "\r\n")
+	status := 0
+	if version := strings.Fields(lines[0]); len(version) > 1 {
+		status, _ = strconv.Atoi(version[1])
+	}
+	header := map[string]string{}
+	for _, line := range lines[1:] {
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			header[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return header, status
+}
+
+func (c *natsConn) setDeadline(ctx context.Context, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.SetDeadline(deadline)
+}
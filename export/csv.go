@@ -0,0 +1,51 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+)
+
+func writeCSV(w *bufio.Writer, headers []string, rows func(emit func([]string) error) error) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(headers); err != nil {
+		return err
+	}
+
+	escaped := make([]string, len(headers))
+	written := 0
+	err := rows(func(record []string) error {
+		for i, value := range record {
+			escaped[i] = escapeFormula(value)
+		}
+		if err := out.Write(escaped); err != nil {
+			return err
+		}
+		written++
+		if written%100 == 0 {
+			out.Flush()
+			if err := out.Error(); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// escapeFormula keeps spreadsheet programs from evaluating user supplied
+// values such as "=HYPERLINK(...)".
+func escapeFormula(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,109 @@
+// Package export renders list endpoints as CSV or XLSX files. Rows are
+// fetched a page at a time and written as they arrive, so large lists are
+// never held in memory.
+package export
+
+import (
+	"bufio"
+	"context"
+
+	"belajar-golang-fiber/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Formats understood in ?format=.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+const mimeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// Column is one field of the exported rows.
+type Column[T any] struct {
+	Header string
+	Value  func(row T) string
+}
+
+// FetchFunc returns the rows from offset, at most limit of them, and the
+// total count.
+type FetchFunc[T any] func(ctx context.Context, offset, limit int) ([]T, int, error)
+
+// Config describes an export.
+type Config[T any] struct {
+	// Filename is the download name without extension.
+	Filename string
+	Columns  []Column[T]
+	Fetch    FetchFunc[T]
+	// Batch is the page size used with Fetch. Default: 500
+	Batch int
+}
+
+// Requested returns the export format asked for with ?format=, or "" when
+// the regular response is wanted.
+func Requested(ctx *fiber.Ctx) string {
+	switch format := ctx.Query("format"); format {
+	case FormatCSV, FormatXLSX:
+		return format
+	}
+	return ""
+}
+
+// Stream sends every row of cfg in format as an attachment.
+func Stream[T any](ctx *fiber.Ctx, format string, cfg Config[T]) error {
+	if cfg.Batch <= 0 {
+		cfg.Batch = 500
+	}
+
+	var write func(w *bufio.Writer, headers []string, rows func(emit func([]string) error) error) error
+	switch format {
+	case FormatCSV:
+		ctx.Attachment(cfg.Filename + ".csv")
+		ctx.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		write = writeCSV
+	case FormatXLSX:
+		ctx.Attachment(cfg.Filename + ".xlsx")
+		ctx.Set(fiber.HeaderContentType, mimeXLSX)
+		write = writeXLSX
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "format must be csv or xlsx")
+	}
+
+	headers := make([]string, len(cfg.Columns))
+	for i, column := range cfg.Columns {
+		headers[i] = column.Header
+	}
+
+	// The stream writer runs after the handler has returned, when the
+	// request context may already be cancelled; a client going away shows
+	// up as a failed write instead.
+	requestCtx := context.WithoutCancel(ctx.UserContext())
+	rows := func(emit func([]string) error) error {
+		record := make([]string, len(cfg.Columns))
+		for offset := 0; ; offset += cfg.Batch {
+			page, total, err := cfg.Fetch(requestCtx, offset, cfg.Batch)
+			if err != nil {
+				return err
+			}
+			for _, row := range page {
+				for i, column := range cfg.Columns {
+					record[i] = column.Value(row)
+				}
+				if err := emit(record); err != nil {
+					return err
+				}
+			}
+			if len(page) == 0 || offset+len(page) >= total {
+				return nil
+			}
+		}
+	}
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := write(w, headers, rows); err != nil {
+			logger.FromContext(requestCtx).Warn("export: aborted", "filename", cfg.Filename, "error", err)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,125 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+func newApp(items []item) *fiber.App {
+	app := fiber.New()
+	app.Get("/items", func(ctx *fiber.Ctx) error {
+		if format := Requested(ctx); format != "" {
+			return Stream(ctx, format, Config[item]{
+				Filename: "items",
+				Batch:    7,
+				Columns: []Column[item]{
+					{Header: "id", Value: func(i item) string { return strconv.Itoa(i.ID) }},
+					{Header: "name", Value: func(i item) string { return i.Name }},
+				},
+				Fetch: func(ctx context.Context, offset, limit int) ([]item, int, error) {
+					end := offset + limit
+					if end > len(items) {
+						end = len(items)
+					}
+					return items[offset:end], len(items), nil
+				},
+			})
+		}
+		return ctx.JSON(items)
+	})
+	return app
+}
+
+func items(n int) []item {
+	list := make([]item, n)
+	for i := range list {
+		list[i] = item{ID: i + 1, Name: "item <" + strconv.Itoa(i+1) + ">"}
+	}
+	list[0].Name = "=HYPERLINK(\"http://evil\")"
+	return list
+}
+
+func TestCSV(t *testing.T) {
+	app := newApp(items(250))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/items?format=csv", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", response.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="items.csv"`, response.Header.Get("Content-Disposition"))
+
+	records, err := csv.NewReader(response.Body).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, records, 251)
+	assert.Equal(t, []string{"id", "name"}, records[0])
+	assert.Equal(t, `'=HYPERLINK("http://evil")`, records[1][1])
+	assert.Equal(t, []string{"250", "item <250>"}, records[250])
+}
+
+func TestXLSX(t *testing.T) {
+	app := newApp(items(20))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/items?format=xlsx", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, mimeXLSX, response.Header.Get("Content-Type"))
+
+	body, _ := io.ReadAll(response.Body)
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	assert.Nil(t, err)
+	var sheet []byte
+	for _, file := range archive.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			reader, _ := file.Open()
+			sheet, _ = io.ReadAll(reader)
+		}
+	}
+	assert.Contains(t, string(sheet), `<row r="21">`)
+	assert.Contains(t, string(sheet), `item &lt;20&gt;`)
+}
+
+func TestRegularResponse(t *testing.T) {
+	app := newApp(items(2))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/items?format=pdf", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.MIMEApplicationJSON, response.Header.Get("Content-Type"))
+}
+
+func BenchmarkWriteXLSX(b *testing.B) {
+	list := items(1000)
+	rows := func(emit func([]string) error) error {
+		record := make([]string, 2)
+		for _, i := range list {
+			record[0], record[1] = strconv.Itoa(i.ID), i.Name
+			if err := emit(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	w := bufio.NewWriter(io.Discard)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := writeXLSX(w, []string{"id", "name"}, rows); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
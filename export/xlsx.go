@@ -0,0 +1,100 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// The smallest package Excel, LibreOffice and Numbers open: one sheet of
+// inline strings.
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+func writeXLSX(w *bufio.Writer, headers []string, rows func(emit func([]string) error) error) error {
+	archive := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, part.body); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	// Rows are built in one buffer reused for the whole export.
+	var row []byte
+	index := 0
+	writeRow := func(record []string) error {
+		index++
+		row = append(row[:0], `<row r="`...)
+		row = strconv.AppendInt(row, int64(index), 10)
+		row = append(row, `">`...)
+		for _, value := range record {
+			row = append(row, `<c t="inlineStr"><is><t xml:space="preserve">`...)
+			row = appendEscaped(row, value)
+			row = append(row, `</t></is></c>`...)
+		}
+		row = append(row, `</row>`...)
+		_, err := sheet.Write(row)
+		if err == nil && index%100 == 0 {
+			// Push compressed output on to the client now and then.
+			if err := archive.Flush(); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+		return err
+	}
+
+	if err := writeRow(headers); err != nil {
+		return err
+	}
+	if err := rows(writeRow); err != nil {
+		return err
+	}
+	io.WriteString(sheet, `</sheetData></worksheet>`)
+	return archive.Close()
+}
+
+// appendEscaped appends value escaped as XML character data, dropping
+// characters XML 1.0 cannot represent.
+func appendEscaped(dst []byte, value string) []byte {
+	for _, r := range value {
+		switch {
+		case r == '<':
+			dst = append(dst, "&lt;"...)
+		case r == '>':
+			dst = append(dst, "&gt;"...)
+		case r == '&':
+			dst = append(dst, "&amp;"...)
+		case r == '\r':
+			dst = append(dst, "&#xD;"...)
+		case r == '\t' || r == '\n':
+			dst = append(dst, byte(r))
+		case r < 0x20 || r == 0xFFFE || r == 0xFFFF || r == utf8.RuneError:
+			dst = append(dst, "\uFFFD"...)
+		default:
+			dst = utf8.AppendRune(dst, r)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,58 @@
+// Package features holds feature flags: named switches, turned on in
+// configuration, that guard what is not ready for everyone. The flags
+// can be replaced while the server runs, e.g. on a configuration reload.
+package features
+
+import (
+	"slices"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Flags is the set of flags turned on.
+type Flags struct {
+	enabled atomic.Pointer[map[string]bool]
+}
+
+// New returns the flags with names turned on.
+func New(names ...string) *Flags {
+	flags := &Flags{}
+	flags.Set(names)
+	return flags
+}
+
+// Set turns on names and every other flag off.
+func (f *Flags) Set(names []string) {
+	enabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		enabled[name] = true
+	}
+	f.enabled.Store(&enabled)
+}
+
+// Enabled reports whether the flag name is on.
+func (f *Flags) Enabled(name string) bool {
+	return (*f.enabled.Load())[name]
+}
+
+// List returns the flags turned on, sorted.
+func (f *Flags) List() []string {
+	names := []string{}
+	for name := range *f.enabled.Load() {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Require answers 404 Not Found while the flag name is off, so the routes
+// it guards do not seem to exist.
+func (f *Flags) Require(name string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !f.Enabled(name) {
+			return fiber.ErrNotFound
+		}
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package features
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequire(t *testing.T) {
+	flags := New("beta")
+	app := fiber.New()
+	app.Get("/beta", flags.Require("beta"), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("beta")
+	})
+
+	response, err := app.Test(httptest.NewRequest("GET", "/beta", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	flags.Set([]string{"other", "another"})
+	assert.False(t, flags.Enabled("beta"))
+	assert.Equal(t, []string{"another", "other"}, flags.List())
+	response, _ = app.Test(httptest.NewRequest("GET", "/beta", nil))
+	assert.Equal(t, 404, response.StatusCode)
+}
@@ -12,7 +12,11 @@ import (
 	"strings"
 	"testing"
 
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/server"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
 	"github.com/gofiber/template/mustache/v2"
 	"github.com/stretchr/testify/assert"
 )
@@ -146,7 +150,7 @@ func TestFormUpload(t *testing.T) {
 			return err
 		}
 
-		err = ctx.SaveFile(file, "./target/"+file.Filename)
+		err = ctx.SaveFile(file, "./target/"+files.SanitizeName(file.Filename))
 		if err != nil {
 			return err
 		}
@@ -340,7 +344,9 @@ func TestRoutingGroup(t *testing.T) {
 }
 
 func TestStatic(t *testing.T) {
-	app.Static("/public", "./source")
+	app.Use("/public", filesystem.New(filesystem.Config{
+		Root: staticFS(false),
+	}))
 
 	request := httptest.NewRequest("GET", "/public/contoh.txt", nil)
 	response, err := app.Test(request)
@@ -390,6 +396,20 @@ func TestView(t *testing.T) {
 	assert.Contains(t, string(bytes), "This is content")
 }
 
+func TestApiNotFound(t *testing.T) {
+	app.Use("/api", server.APINotFound)
+
+	request := httptest.NewRequest("GET", "/api/unknown", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `{"error":"Not Found"}`, string(bytes))
+}
+
 func TestClient(t *testing.T) {
 	client := fiber.AcquireClient()
 	defer fiber.ReleaseClient(client)
@@ -0,0 +1,106 @@
+package files
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errUnsatisfiable is returned by byteRange for a range past the end of
+// the content.
+var errUnsatisfiable = errors.New("files: range not satisfiable")
+
+// lastModified is the Last-Modified of file, to the second as HTTP dates
+// have it.
+func lastModified(file *File) time.Time {
+	modified := file.UpdatedAt
+	if modified.IsZero() {
+		modified = file.CreatedAt
+	}
+	return modified.UTC().Truncate(time.Second)
+}
+
+// notModified evaluates If-None-Match and, without it, If-Modified-Since
+// as RFC 9110 orders them. Dates that do not parse, or lie ahead of the
+// clock of the server, are ignored.
+func notModified(ctx *fiber.Ctx, etag string, modified time.Time) bool {
+	if match := ctx.Get(fiber.HeaderIfNoneMatch); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(ctx.Get(fiber.HeaderIfModifiedSince))
+	if err != nil || since.After(time.Now()) {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// rangeApplies reports whether the Range header is to be honored: there
+// is one and If-Range, if any, names the current content by its strong
+// ETag or exact Last-Modified date.
+func rangeApplies(ctx *fiber.Ctx, etag string, modified time.Time) bool {
+	if ctx.Get(fiber.HeaderRange) == "" {
+		return false
+	}
+	condition := ctx.Get(fiber.HeaderIfRange)
+	switch {
+	case condition == "":
+		return true
+	case strings.HasPrefix(condition, `"`):
+		return condition == etag
+	case strings.HasPrefix(condition, "W/"):
+		return false
+	}
+	at, err := http.ParseTime(condition)
+	return err == nil && at.Equal(modified)
+}
+
+// byteRange parses a Range header of a single byte range over content of
+// size bytes. ok is false for headers to ignore, such as other units or
+// several ranges, which are answered with the whole content.
+func byteRange(header string, size int64) (start, length int64, ok bool, err error) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false, nil
+	}
+	if first == "" {
+		// A suffix: the last bytes of the content.
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false, nil
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, true, errUnsatisfiable
+		}
+		n = min(n, size)
+		return size - n, n, true, nil
+	}
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false, nil
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false, nil
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return 0, 0, true, errUnsatisfiable
+	}
+	return start, end - start + 1, true, nil
+}
@@ -0,0 +1,86 @@
+package files
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"strconv"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/middleware/cachecontrol"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DownloadHandler serves stored files to signed in users allowed to
+// manage them, see Owns.
+type DownloadHandler struct {
+	Service *Service
+	Audit   *audit.Logger
+}
+
+// Register mounts the routes on router, e.g. app.Group("/files").
+func (h *DownloadHandler) Register(router fiber.Router) {
+	router.Get("/:id", session.Require(), h.download)
+}
+
+// download hands the opened file to fasthttp as the body stream. With
+// local storage that is an *os.File of known size, which fasthttp copies
+// to the connection with sendfile(2): the content never passes through
+// user space, however large the file.
+//
+// Conditional requests get 304 Not Modified by ETag or Last-Modified, and
+// a single byte Range, subject to If-Range, gets 206 Partial Content so
+// interrupted downloads resume.
+func (h *DownloadHandler) download(ctx *fiber.Ctx) error {
+	file, content, err := h.Service.Open(ctx.UserContext(), ctx.Params("id"))
+	if errors.Is(err, ErrNotFound) {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !Owns(ctx, file) {
+		content.Close()
+		return fiber.ErrNotFound
+	}
+
+	h.Audit.Log(ctx, audit.ActionFileDownload, audit.OutcomeSuccess, "file:"+file.ID, map[string]string{"name": file.Name})
+
+	etag := `"` + file.Checksum + `"`
+	modified := lastModified(file)
+	ctx.Set(fiber.HeaderContentType, file.ContentType)
+	ctx.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	ctx.Set(fiber.HeaderETag, etag)
+	ctx.Set(fiber.HeaderAcceptRanges, "bytes")
+	cachecontrol.LastModified(ctx, modified)
+	if notModified(ctx, etag, modified) {
+		content.Close()
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	if rangeApplies(ctx, etag, modified) {
+		start, length, ok, err := byteRange(ctx.Get(fiber.HeaderRange), file.Size)
+		if errors.Is(err, errUnsatisfiable) {
+			content.Close()
+			ctx.Set(fiber.HeaderContentRange, "bytes */"+strconv.FormatInt(file.Size, 10))
+			return ctx.SendStatus(fiber.StatusRequestedRangeNotSatisfiable)
+		}
+		if ok {
+			if _, err := content.Seek(start, io.SeekStart); err != nil {
+				content.Close()
+				return err
+			}
+			ctx.Set(fiber.HeaderContentRange, "bytes "+strconv.FormatInt(start, 10)+"-"+
+				strconv.FormatInt(start+length-1, 10)+"/"+strconv.FormatInt(file.Size, 10))
+			ctx.Status(fiber.StatusPartialContent)
+			return ctx.SendStream(struct {
+				io.Reader
+				io.Closer
+			}{io.LimitReader(content, length), content}, int(length))
+		}
+	}
+	// fasthttp closes content once it has been sent.
+	return ctx.SendStream(content, int(file.Size))
+}
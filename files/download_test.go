@@ -0,0 +1,202 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownload(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	file, err := service.Save(WithOwner(context.Background(), Owner{UserID: "42"}), "laporan akhir.txt", bytes.NewReader([]byte("isi laporan")), "upload")
+	assert.Nil(t, err)
+
+	app, signIn := sessionApp(t)
+	handler := &DownloadHandler{Service: service}
+	handler.Register(app.Group("/files"))
+	owner := signIn("42")
+	get := func(auth, id string) *http.Response {
+		request := httptest.NewRequest("GET", "/files/"+id, nil)
+		if auth != "" {
+			request.Header.Set("Authorization", auth)
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	assert.Equal(t, 401, get("", file.ID).StatusCode)
+	assert.Equal(t, 404, get(signIn("7"), file.ID).StatusCode, "files of other users are not found")
+
+	response := get(owner, file.ID)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `attachment; filename="laporan akhir.txt"`, response.Header.Get("Content-Disposition"))
+	assert.Equal(t, "11", response.Header.Get("Content-Length"))
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "isi laporan", string(body))
+
+	request := httptest.NewRequest("GET", "/files/"+file.ID, nil)
+	request.Header.Set("Authorization", owner)
+	request.Header.Set("If-None-Match", response.Header.Get("ETag"))
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 304, response.StatusCode)
+
+	assert.Equal(t, 404, get(owner, "missing").StatusCode)
+}
+
+func TestDownloadConditional(t *testing.T) {
+	repository := NewMemoryRepository()
+	service := NewService(storage.NewLocal(t.TempDir()), repository)
+	file, err := service.Save(WithOwner(context.Background(), Owner{UserID: "42"}), "laporan.txt", bytes.NewReader([]byte("isi laporan")), "upload")
+	assert.Nil(t, err)
+	// Stored times are finer than the seconds of HTTP dates.
+	modified := time.Date(2026, 3, 4, 5, 6, 7, 900_000_000, time.UTC)
+	file.UpdatedAt = modified
+	assert.Nil(t, repository.Update(context.Background(), file))
+
+	app, signIn := sessionApp(t)
+	handler := &DownloadHandler{Service: service}
+	handler.Register(app.Group("/files"))
+	owner := signIn("42")
+	get := func(header ...string) (*http.Response, string) {
+		request := httptest.NewRequest("GET", "/files/"+file.ID, nil)
+		request.Header.Set("Authorization", owner)
+		for i := 0; i < len(header); i += 2 {
+			request.Header.Set(header[i], header[i+1])
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		body, _ := io.ReadAll(response.Body)
+		return response, string(body)
+	}
+	date := func(at time.Time) string { return at.UTC().Format(http.TimeFormat) }
+
+	response, _ := get()
+	assert.Equal(t, "Wed, 04 Mar 2026 05:06:07 GMT", response.Header.Get("Last-Modified"))
+	assert.Equal(t, "bytes", response.Header.Get("Accept-Ranges"))
+	etag := response.Header.Get("ETag")
+
+	response, _ = get("If-Modified-Since", date(modified))
+	assert.Equal(t, 304, response.StatusCode, "the same second, though the file is 900ms younger")
+	response, _ = get("If-Modified-Since", date(modified.Add(time.Hour)))
+	assert.Equal(t, 304, response.StatusCode)
+	response, _ = get("If-Modified-Since", date(modified.Add(-time.Second)))
+	assert.Equal(t, 200, response.StatusCode)
+	response, _ = get("If-Modified-Since", date(time.Now().Add(time.Hour)))
+	assert.Equal(t, 200, response.StatusCode, "dates ahead of the server clock are ignored")
+	response, _ = get("If-Modified-Since", "yesterday")
+	assert.Equal(t, 200, response.StatusCode)
+	response, _ = get("If-None-Match", `"other"`, "If-Modified-Since", date(modified))
+	assert.Equal(t, 200, response.StatusCode, "If-None-Match takes precedence")
+	response, _ = get("If-None-Match", "W/"+etag)
+	assert.Equal(t, 304, response.StatusCode)
+
+	response, body := get("Range", "bytes=4-6")
+	assert.Equal(t, 206, response.StatusCode)
+	assert.Equal(t, "bytes 4-6/11", response.Header.Get("Content-Range"))
+	assert.Equal(t, "lap", body)
+	response, body = get("Range", "bytes=-5")
+	assert.Equal(t, 206, response.StatusCode)
+	assert.Equal(t, "poran", body)
+	response, body = get("Range", "bytes=8-100")
+	assert.Equal(t, "bytes 8-10/11", response.Header.Get("Content-Range"))
+	assert.Equal(t, "ran", body)
+	response, _ = get("Range", "bytes=11-")
+	assert.Equal(t, 416, response.StatusCode)
+	assert.Equal(t, "bytes */11", response.Header.Get("Content-Range"))
+	response, body = get("Range", "bytes=0-1,4-6")
+	assert.Equal(t, 200, response.StatusCode, "several ranges get the whole file")
+	assert.Equal(t, "isi laporan", body)
+
+	response, body = get("Range", "bytes=4-", "If-Range", etag)
+	assert.Equal(t, 206, response.StatusCode)
+	assert.Equal(t, "laporan", body)
+	response, body = get("Range", "bytes=4-", "If-Range", `"changed"`)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "isi laporan", body)
+	response, _ = get("Range", "bytes=4-", "If-Range", date(modified))
+	assert.Equal(t, 206, response.StatusCode)
+	response, _ = get("Range", "bytes=4-", "If-Range", date(modified.Add(-time.Second)))
+	assert.Equal(t, 200, response.StatusCode, "a date other than Last-Modified means the file changed")
+}
+
+// benchmarkDownload serves an 8 MiB file over a real connection, where
+// fasthttp can use sendfile, and reports the bytes allocated per request
+// on both ends.
+func benchmarkDownload(b *testing.B, handler func(service *Service) fiber.Handler) {
+	service := NewService(storage.NewLocal(b.TempDir()), NewMemoryRepository())
+	file, err := service.Save(WithOwner(context.Background(), Owner{UserID: "42"}), "big.bin", bytes.NewReader(make([]byte, 8<<20)), "upload")
+	if err != nil {
+		b.Fatal(err)
+	}
+	store := session.NewMemoryStore()
+	token, hash, err := session.NewToken()
+	if err != nil {
+		b.Fatal(err)
+	}
+	store.Create(context.Background(), &session.Session{ID: "1", UserID: "42", TokenHash: hash, LastSeen: time.Now(), ExpiresAt: time.Now().Add(time.Hour)})
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(session.NewManager(session.Config{Store: store}).Middleware())
+	app.Get("/files/:id", handler(service))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	go app.Listener(listener)
+	defer app.Shutdown()
+
+	request, _ := http.NewRequest("GET", "http://"+listener.Addr().String()+"/files/"+file.ID, nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	buffer := make([]byte, 64*1024)
+	b.SetBytes(file.Size)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if response.StatusCode != fiber.StatusOK {
+			b.Fatal(response.Status)
+		}
+		io.CopyBuffer(io.Discard, response.Body, buffer)
+		response.Body.Close()
+	}
+}
+
+func BenchmarkDownloadReadAll(b *testing.B) {
+	benchmarkDownload(b, func(service *Service) fiber.Handler {
+		return func(ctx *fiber.Ctx) error {
+			_, content, err := service.Open(ctx.UserContext(), ctx.Params("id"))
+			if err != nil {
+				return err
+			}
+			defer content.Close()
+			data, err := io.ReadAll(content)
+			if err != nil {
+				return err
+			}
+			return ctx.Send(data)
+		}
+	})
+}
+
+func BenchmarkDownloadSendfile(b *testing.B) {
+	benchmarkDownload(b, func(service *Service) fiber.Handler {
+		handler := &DownloadHandler{Service: service}
+		return handler.download
+	})
+}
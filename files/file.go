@@ -0,0 +1,159 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when no file matches the given id.
+var ErrNotFound = errors.New("files: not found")
+
+// ErrLocked is wrapped by the errors of checks refusing to change or
+// remove a file, e.g. one under legal hold.
+var ErrLocked = errors.New("files: locked")
+
+// File is the metadata kept for every stored object.
+type File struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Key         string `json:"-"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+	ContentType string `json:"content_type"`
+	Source      string `json:"source"`
+	// Metadata holds client supplied fields sent along with an upload.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// UserID and Tenant are the owner the file counts against.
+	UserID    string    `json:"user_id,omitempty"`
+	Tenant    string    `json:"tenant,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Version counts the contents the file had; UpdatedAt is when the
+	// current one was stored.
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Versions holds the previous contents, oldest first.
+	Versions []Version `json:"-"`
+	// DeletedAt is set while the file is in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Version is an earlier content of a file, kept when it was replaced.
+type Version struct {
+	Number      int               `json:"version"`
+	Key         string            `json:"-"`
+	Size        int64             `json:"size"`
+	Checksum    string            `json:"checksum"`
+	ContentType string            `json:"content_type"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// StoredSize is the size of the file including its previous versions.
+func (f *File) StoredSize() int64 {
+	size := f.Size
+	for _, version := range f.Versions {
+		size += version.Size
+	}
+	return size
+}
+
+// current describes the content the file has now as a Version.
+func (f *File) current() Version {
+	return Version{
+		Number:      f.Version,
+		Key:         f.Key,
+		Size:        f.Size,
+		Checksum:    f.Checksum,
+		ContentType: f.ContentType,
+		Metadata:    f.Metadata,
+		CreatedAt:   f.UpdatedAt,
+	}
+}
+
+func (f *File) clone() *File {
+	copied := *f
+	copied.Versions = append([]Version(nil), f.Versions...)
+	return &copied
+}
+
+// Trashed reports whether the file is in the trash.
+func (f *File) Trashed() bool {
+	return f.DeletedAt != nil
+}
+
+// Repository persists file metadata.
+type Repository interface {
+	Create(ctx context.Context, file *File) error
+	Get(ctx context.Context, id string) (*File, error)
+	List(ctx context.Context) ([]*File, error)
+	Update(ctx context.Context, file *File) error
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryRepository keeps metadata in process memory.
+type MemoryRepository struct {
+	mu    sync.RWMutex
+	files map[string]*File
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{files: map[string]*File{}}
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, file *File) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.files[file.ID] = file.clone()
+	return nil
+}
+
+func (r *MemoryRepository) Get(ctx context.Context, id string) (*File, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	file, ok := r.files[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return file.clone(), nil
+}
+
+func (r *MemoryRepository) List(ctx context.Context) ([]*File, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]*File, 0, len(r.files))
+	for _, file := range r.files {
+		list = append(list, file.clone())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *MemoryRepository) Update(ctx context.Context, file *File) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.files[file.ID]; !ok {
+		return ErrNotFound
+	}
+	r.files[file.ID] = file.clone()
+	return nil
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.files[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.files, id)
+	return nil
+}
@@ -0,0 +1,316 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"slices"
+	"strconv"
+	"strings"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/scan"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Form fields of a multipart upload besides the files themselves.
+const (
+	// FieldMetadata holds a JSON object of string values recorded on
+	// every uploaded file.
+	FieldMetadata = "metadata"
+	// FieldMetadataPrefix followed by a file's original name holds a JSON
+	// object merged over FieldMetadata for that file only.
+	FieldMetadataPrefix = "metadata:"
+)
+
+// maxFieldSize caps a non-file field of a streamed upload.
+const maxFieldSize = 64 * 1024
+
+// Handler accepts multipart uploads of one or more files.
+type Handler struct {
+	Service *Service
+	Audit   *audit.Logger
+	// MaxFiles caps the files accepted in one request. Zero means 20.
+	MaxFiles int
+}
+
+// Result reports what happened to one file of an upload.
+type Result struct {
+	Field    string            `json:"field"`
+	Original string            `json:"original_name"`
+	Stored   bool              `json:"stored"`
+	ID       string            `json:"id,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	Size     int64             `json:"size,omitempty"`
+	Checksum string            `json:"checksum,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// UploadResponse lists a Result per file, in request order.
+type UploadResponse struct {
+	Stored int      `json:"stored"`
+	Failed int      `json:"failed"`
+	Files  []Result `json:"files"`
+
+	overQuota bool
+}
+
+// add records the outcome of saving one file.
+func (r *UploadResponse) add(result Result, err error) {
+	if err != nil {
+		result.Error = err.Error()
+		r.overQuota = r.overQuota || errors.Is(err, ErrQuotaExceeded)
+		r.Failed++
+	} else {
+		r.Stored++
+	}
+	r.Files = append(r.Files, result)
+}
+
+// status is 201 when all files were stored, 207 when only some were and
+// 422 when none were, or 413 when none were for lack of quota.
+func (r *UploadResponse) status() int {
+	switch {
+	case r.Stored == 0 && r.overQuota:
+		return fiber.StatusRequestEntityTooLarge
+	case r.Stored == 0:
+		return fiber.StatusUnprocessableEntity
+	case r.Failed > 0:
+		return fiber.StatusMultiStatus
+	}
+	return fiber.StatusCreated
+}
+
+// Register mounts the routes on router, e.g. app.Group("/upload").
+func (h *Handler) Register(router fiber.Router) {
+	router.Use(session.Require())
+	router.Post("/", h.upload)
+	router.Post("/stream", h.stream)
+	router.Get("/quota", h.quota)
+}
+
+// quota answers the QuotaStatus of the signed in user and tenant.
+func (h *Handler) quota(ctx *fiber.Ctx) error {
+	status, err := h.Service.QuotaStatus(WithOwner(ctx.UserContext(), ownerOf(ctx)))
+	if err != nil {
+		return err
+	}
+	status.setHeaders(ctx)
+	return ctx.JSON(status)
+}
+
+// QuotaGuard answers 413 Request Entity Too Large to uploads announcing
+// more bytes than their owner has left, before their body is received.
+func QuotaGuard(service *Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		length := int64(ctx.Request().Header.ContentLength())
+		if length <= 0 {
+			return ctx.Next()
+		}
+		err := service.CheckQuota(WithOwner(ctx.UserContext(), ownerOf(ctx)), length)
+		if errors.Is(err, ErrQuotaExceeded) {
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		return ctx.Next()
+	}
+}
+
+// respond sends response along with the quota left afterwards.
+func (h *Handler) respond(ctx *fiber.Ctx, userCtx context.Context, response UploadResponse) error {
+	if status, err := h.Service.QuotaStatus(userCtx); err == nil {
+		status.setHeaders(ctx)
+	}
+	return ctx.Status(response.status()).JSON(response)
+}
+
+// upload stores every file part of the request. It answers 201 when all
+// files were stored, 207 when only some were and 422 when none were.
+func (h *Handler) upload(ctx *fiber.Ctx) error {
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "expected a multipart form")
+	}
+
+	shared, err := parseMetadata(form.Value[FieldMetadata], FieldMetadata)
+	if err != nil {
+		return err
+	}
+
+	maxFiles := h.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = 20
+	}
+	count := 0
+	for _, headers := range form.File {
+		count += len(headers)
+	}
+	if count == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "at least one file is required")
+	}
+	if count > maxFiles {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, "too many files")
+	}
+
+	userCtx := WithOwner(ctx.UserContext(), ownerOf(ctx))
+	response := UploadResponse{Files: []Result{}}
+	for _, field := range slices.Sorted(maps.Keys(form.File)) {
+		for _, header := range form.File[field] {
+			result := Result{Field: field, Original: header.Filename}
+
+			own, err := parseMetadata(form.Value[FieldMetadataPrefix+header.Filename], FieldMetadataPrefix+result.Original)
+			if err != nil {
+				return err
+			}
+			metadata := merge(shared, own)
+
+			file, err := header.Open()
+			if err == nil {
+				var saved *File
+				saved, err = h.Service.SaveWithMetadata(userCtx, header.Filename, file, "upload", metadata)
+				file.Close()
+				auditSave(h.Audit, ctx, header.Filename, saved, err)
+				if err == nil {
+					result.Stored = true
+					result.ID = saved.ID
+					result.Name = saved.Name
+					result.Size = saved.Size
+					result.Checksum = saved.Checksum
+					result.Metadata = saved.Metadata
+				}
+			}
+			response.add(result, err)
+		}
+	}
+
+	return h.respond(ctx, userCtx, response)
+}
+
+// stream stores the file parts of the request as they arrive, so with
+// fiber.Config.StreamRequestBody set no file is spooled to memory or disk
+// first. Parts are handled in order: the metadata fields must come before
+// the files they describe. It answers like upload.
+func (h *Handler) stream(ctx *fiber.Ctx) error {
+	mediaType, params, err := mime.ParseMediaType(ctx.Get(fiber.HeaderContentType))
+	if err != nil || mediaType != fiber.MIMEMultipartForm || params["boundary"] == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "expected a multipart form")
+	}
+	body := ctx.Request().BodyStream()
+	if body == nil {
+		body = bytes.NewReader(ctx.Body())
+	}
+	reader := multipart.NewReader(body, params["boundary"])
+
+	maxFiles := h.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = 20
+	}
+	values := map[string][]string{}
+	userCtx := WithOwner(ctx.UserContext(), ownerOf(ctx))
+	response := UploadResponse{Files: []Result{}}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "malformed multipart form")
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, maxFieldSize+1))
+			part.Close()
+			if err != nil || len(value) > maxFieldSize {
+				return fiber.NewError(fiber.StatusRequestEntityTooLarge, part.FormName()+" is too large")
+			}
+			values[part.FormName()] = append(values[part.FormName()], string(value))
+			continue
+		}
+
+		result := Result{Field: part.FormName(), Original: part.FileName()}
+		if len(response.Files) >= maxFiles {
+			part.Close()
+			response.add(result, errors.New("too many files"))
+			continue
+		}
+
+		shared, err := parseMetadata(values[FieldMetadata], FieldMetadata)
+		if err != nil {
+			return err
+		}
+		own, err := parseMetadata(values[FieldMetadataPrefix+result.Original], FieldMetadataPrefix+result.Original)
+		if err != nil {
+			return err
+		}
+
+		saved, err := h.Service.SaveWithMetadata(userCtx, result.Original, part, "upload", merge(shared, own))
+		part.Close()
+		auditSave(h.Audit, ctx, result.Original, saved, err)
+		if err == nil {
+			result.Stored = true
+			result.ID = saved.ID
+			result.Name = saved.Name
+			result.Size = saved.Size
+			result.Checksum = saved.Checksum
+			result.Metadata = saved.Metadata
+		}
+		response.add(result, err)
+	}
+	if len(response.Files) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "at least one file is required")
+	}
+
+	return h.respond(ctx, userCtx, response)
+}
+
+// auditSave records a stored upload, or one refused by the scanner.
+func auditSave(logger *audit.Logger, ctx *fiber.Ctx, name string, file *File, err error) {
+	switch {
+	case err == nil:
+		logger.Log(ctx, audit.ActionFileUpload, audit.OutcomeSuccess, "file:"+file.ID, map[string]string{
+			"name":     file.Name,
+			"size":     strconv.FormatInt(file.Size, 10),
+			"checksum": file.Checksum,
+		})
+	case errors.Is(err, scan.ErrInfected):
+		logger.Log(ctx, audit.ActionFileRejected, audit.OutcomeFailure, "", map[string]string{
+			"name":   name,
+			"reason": err.Error(),
+		})
+	}
+}
+
+func parseMetadata(values []string, field string) (map[string]string, error) {
+	if len(values) == 0 || strings.TrimSpace(values[0]) == "" {
+		return nil, nil
+	}
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(values[0]), &metadata); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, field+" must be a JSON object of strings")
+	}
+	return metadata, nil
+}
+
+func merge(shared, own map[string]string) map[string]string {
+	if len(shared) == 0 && len(own) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(shared)+len(own))
+	for key, value := range shared {
+		merged[key] = value
+	}
+	for key, value := range own {
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,174 @@
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// sessionApp returns an app resolving sessions and a function signing in
+// userID, returning the Authorization header to send.
+func sessionApp(t *testing.T, config ...fiber.Config) (*fiber.App, func(userID string) string) {
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app := fiber.New(config...)
+	app.Use(sessions.Middleware())
+	app.Post("/login/:id", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, ctx.Params("id"))
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	return app, func(userID string) string {
+		response, err := app.Test(httptest.NewRequest("POST", "/login/"+userID, nil))
+		assert.Nil(t, err)
+		token, _ := io.ReadAll(response.Body)
+		return "Bearer " + string(token)
+	}
+}
+
+func TestUploadMultipleFiles(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	app, signIn := sessionApp(t)
+	handler := &Handler{Service: service}
+	handler.Register(app.Group("/upload"))
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField(FieldMetadata, `{"album":"liburan"}`)
+	writer.WriteField(FieldMetadataPrefix+"b.txt", `{"caption":"pantai"}`)
+	for _, name := range []string{"a.txt", "b.txt", "a.txt"} {
+		part, _ := writer.CreateFormFile("files", name)
+		part.Write([]byte("isi " + name))
+	}
+	writer.Close()
+
+	request := httptest.NewRequest("POST", "/upload", bytes.NewReader(body.Bytes()))
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	request = httptest.NewRequest("POST", "/upload", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("Authorization", signIn("1"))
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var result UploadResponse
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&result))
+	assert.Equal(t, 3, result.Stored)
+	assert.Equal(t, "a.txt", result.Files[0].Name)
+	assert.Equal(t, "b.txt", result.Files[1].Name)
+	assert.Equal(t, "a (1).txt", result.Files[2].Name)
+	assert.Equal(t, "a.txt", result.Files[2].Original)
+	assert.Equal(t, int64(9), result.Files[0].Size)
+	assert.Len(t, result.Files[0].Checksum, 64)
+	assert.Equal(t, map[string]string{"album": "liburan"}, result.Files[0].Metadata)
+	assert.Equal(t, map[string]string{"album": "liburan", "caption": "pantai"}, result.Files[1].Metadata)
+}
+
+func TestUploadInvalidMetadata(t *testing.T) {
+	app, signIn := sessionApp(t)
+	handler := &Handler{Service: NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())}
+	handler.Register(app.Group("/upload"))
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField(FieldMetadata, `[1, 2]`)
+	part, _ := writer.CreateFormFile("file", "a.txt")
+	part.Write([]byte("isi"))
+	writer.Close()
+
+	request := httptest.NewRequest("POST", "/upload", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("Authorization", signIn("1"))
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestStreamUpload(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	app, signIn := sessionApp(t, fiber.Config{StreamRequestBody: true})
+	handler := &Handler{Service: service, MaxFiles: 2}
+	handler.Register(app.Group("/upload"))
+
+	content := bytes.Repeat([]byte("x"), 6*1024*1024)
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField(FieldMetadata, `{"album":"liburan"}`)
+	part, _ := writer.CreateFormFile("files", "besar.bin")
+	part.Write(content)
+	writer.WriteField(FieldMetadataPrefix+"kecil.txt", `{"caption":"pantai"}`)
+	part, _ = writer.CreateFormFile("files", "kecil.txt")
+	part.Write([]byte("isi"))
+	part, _ = writer.CreateFormFile("files", "lebih.txt")
+	part.Write([]byte("isi"))
+	writer.Close()
+
+	request := httptest.NewRequest("POST", "/upload/stream", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("Authorization", signIn("1"))
+	response, err := app.Test(request, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, 207, response.StatusCode)
+
+	var result UploadResponse
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&result))
+	assert.Equal(t, 2, result.Stored)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, int64(len(content)), result.Files[0].Size)
+	assert.Equal(t, map[string]string{"album": "liburan"}, result.Files[0].Metadata)
+	assert.Equal(t, map[string]string{"album": "liburan", "caption": "pantai"}, result.Files[1].Metadata)
+	assert.Equal(t, "too many files", result.Files[2].Error)
+}
+
+func TestUploadRefusesTraversalNames(t *testing.T) {
+	root := t.TempDir()
+	service := NewService(storage.NewLocal(filepath.Join(root, "files")), NewMemoryRepository())
+	app, signIn := sessionApp(t)
+	handler := &Handler{Service: service}
+	handler.Register(app.Group("/upload"))
+
+	names := []string{"../../evil.txt", `..\..\evil.txt`, "..%2f..%2fevil.txt", "/etc/passwd", ".."}
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for _, name := range names {
+		part, _ := writer.CreateFormFile("files", name)
+		part.Write([]byte("isi"))
+	}
+	writer.Close()
+
+	request := httptest.NewRequest("POST", "/upload", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("Authorization", signIn("1"))
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var result UploadResponse
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&result))
+	assert.Equal(t, len(names), result.Stored)
+	assert.Equal(t, "evil.txt", result.Files[0].Name)
+	assert.Equal(t, "evil (1).txt", result.Files[1].Name)
+	assert.Equal(t, "..%2f..%2fevil.txt", result.Files[2].Name)
+	assert.Equal(t, "passwd", result.Files[3].Name)
+	assert.Equal(t, "unnamed", result.Files[4].Name)
+
+	entries, err := os.ReadDir(root)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1, "nothing is written next to the storage root")
+}
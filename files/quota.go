@@ -0,0 +1,279 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrQuotaExceeded is returned by Save when a file would take its owner
+// over a quota. Nothing of the file is kept.
+var ErrQuotaExceeded = errors.New("files: storage quota exceeded")
+
+// Quota headers, set on uploads for the scope closest to its limit.
+const (
+	HeaderQuotaLimitBytes     = "X-Quota-Limit-Bytes"
+	HeaderQuotaRemainingBytes = "X-Quota-Remaining-Bytes"
+	HeaderQuotaLimitFiles     = "X-Quota-Limit-Files"
+	HeaderQuotaRemainingFiles = "X-Quota-Remaining-Files"
+)
+
+// Owner is who a stored file counts against. Files saved without an
+// owner, such as ingested ones, count against nobody.
+type Owner struct {
+	UserID string
+	Tenant string
+}
+
+type ownerKey struct{}
+
+// WithOwner returns a context saving files on behalf of owner.
+func WithOwner(ctx context.Context, owner Owner) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// OwnerFrom returns the owner set by WithOwner, if any.
+func OwnerFrom(ctx context.Context) Owner {
+	owner, _ := ctx.Value(ownerKey{}).(Owner)
+	return owner
+}
+
+// ownerOf is the signed in user and the tenant of the request.
+func ownerOf(ctx *fiber.Ctx) Owner {
+	tenant, _ := ctx.Locals("tenant").(string)
+	return Owner{UserID: session.UserID(ctx), Tenant: tenant}
+}
+
+// Limits caps the bytes and number of files of one user or tenant. Zero
+// means unlimited.
+type Limits struct {
+	Bytes int64 `json:"bytes"`
+	Files int   `json:"files"`
+}
+
+// Usage is what a user or tenant stores.
+type Usage struct {
+	Bytes int64 `json:"bytes"`
+	Files int   `json:"files"`
+}
+
+// ScopeStatus is the quota of one user or tenant. Remaining is -1 where
+// there is no limit.
+type ScopeStatus struct {
+	ID        string `json:"id"`
+	Limit     Limits `json:"limit"`
+	Used      Usage  `json:"used"`
+	Remaining Usage  `json:"remaining"`
+}
+
+// QuotaStatus holds the scopes that apply to an owner.
+type QuotaStatus struct {
+	User   *ScopeStatus `json:"user,omitempty"`
+	Tenant *ScopeStatus `json:"tenant,omitempty"`
+}
+
+// Quota limits what every user and every tenant may store. Usage is
+// computed from the repository, plus the files being written at the
+// moment, so concurrent uploads cannot overshoot together.
+type Quota struct {
+	User   Limits
+	Tenant Limits
+	// ForTenant, when set, returns the limits of a tenant in place of
+	// Tenant, e.g. from its plan.
+	ForTenant func(ctx context.Context, tenant string) (Limits, error)
+
+	mu       sync.Mutex
+	inflight map[string]*Usage
+}
+
+func NewQuota(user, tenant Limits) *Quota {
+	return &Quota{User: user, Tenant: tenant, inflight: map[string]*Usage{}}
+}
+
+// scope is one limited user or tenant of an owner.
+type scope struct {
+	key    string
+	id     string
+	limits Limits
+	used   Usage
+}
+
+func (q *Quota) scopes(ctx context.Context, repository Repository, owner Owner) ([]*scope, error) {
+	var scopes []*scope
+	if owner.UserID != "" && q.User != (Limits{}) {
+		scopes = append(scopes, &scope{key: "user:" + owner.UserID, id: owner.UserID, limits: q.User})
+	}
+	if owner.Tenant != "" {
+		limits := q.Tenant
+		if q.ForTenant != nil {
+			var err error
+			if limits, err = q.ForTenant(ctx, owner.Tenant); err != nil {
+				return nil, err
+			}
+		}
+		if limits != (Limits{}) {
+			scopes = append(scopes, &scope{key: "tenant:" + owner.Tenant, id: owner.Tenant, limits: limits})
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+
+	list, err := repository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range list {
+		for _, s := range scopes {
+			if s.key == "user:"+file.UserID || s.key == "tenant:"+file.Tenant {
+				s.used.Bytes += file.StoredSize()
+				s.used.Files++
+			}
+		}
+	}
+	return scopes, nil
+}
+
+// reserve accounts for files more files of owner, refusing them when the
+// file count is used up, and returns r wrapped to refuse bytes beyond the
+// remaining ones. release must be called once the file is saved or
+// given up.
+func (q *Quota) reserve(ctx context.Context, repository Repository, owner Owner, r io.Reader, files int) (io.Reader, func(), error) {
+	scopes, err := q.scopes(ctx, repository, owner)
+	if err != nil || len(scopes) == 0 {
+		return r, func() {}, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, s := range scopes {
+		if files > 0 && s.limits.Files > 0 && s.used.Files+q.inflightOf(s.key).Files+files > s.limits.Files {
+			return nil, nil, ErrQuotaExceeded
+		}
+	}
+	for _, s := range scopes {
+		q.addInflight(s.key, 0, files)
+	}
+
+	reader := &quotaReader{Reader: r, quota: q, scopes: scopes, files: files}
+	return reader, reader.release, nil
+}
+
+// inflightOf is what is being written for key. It and addInflight
+// must be called with mu held.
+func (q *Quota) inflightOf(key string) Usage {
+	if usage, ok := q.inflight[key]; ok {
+		return *usage
+	}
+	return Usage{}
+}
+
+func (q *Quota) addInflight(key string, bytes int64, files int) {
+	usage, ok := q.inflight[key]
+	if !ok {
+		usage = &Usage{}
+		q.inflight[key] = usage
+	}
+	usage.Bytes += bytes
+	usage.Files += files
+	if *usage == (Usage{}) {
+		delete(q.inflight, key)
+	}
+}
+
+// quotaReader fails with ErrQuotaExceeded once more bytes were read than
+// the owner has left.
+type quotaReader struct {
+	io.Reader
+	quota  *Quota
+	scopes []*scope
+	files  int
+	read   int64
+}
+
+func (r *quotaReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n == 0 {
+		return n, err
+	}
+	r.quota.mu.Lock()
+	defer r.quota.mu.Unlock()
+	for _, s := range r.scopes {
+		if s.limits.Bytes > 0 && s.used.Bytes+r.quota.inflightOf(s.key).Bytes+int64(n) > s.limits.Bytes {
+			return 0, ErrQuotaExceeded
+		}
+	}
+	for _, s := range r.scopes {
+		r.quota.addInflight(s.key, int64(n), 0)
+	}
+	r.read += int64(n)
+	return n, err
+}
+
+func (r *quotaReader) release() {
+	r.quota.mu.Lock()
+	defer r.quota.mu.Unlock()
+	for _, s := range r.scopes {
+		r.quota.addInflight(s.key, -r.read, -r.files)
+	}
+}
+
+// Status reports the quota of owner, including files being written.
+func (q *Quota) Status(ctx context.Context, repository Repository, owner Owner) (QuotaStatus, error) {
+	scopes, err := q.scopes(ctx, repository, owner)
+	if err != nil {
+		return QuotaStatus{}, err
+	}
+
+	var status QuotaStatus
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, s := range scopes {
+		inflight := q.inflightOf(s.key)
+		used := Usage{Bytes: s.used.Bytes + inflight.Bytes, Files: s.used.Files + inflight.Files}
+		scopeStatus := &ScopeStatus{ID: s.id, Limit: s.limits, Used: used, Remaining: Usage{Bytes: -1, Files: -1}}
+		if s.limits.Bytes > 0 {
+			scopeStatus.Remaining.Bytes = max(s.limits.Bytes-used.Bytes, 0)
+		}
+		if s.limits.Files > 0 {
+			scopeStatus.Remaining.Files = max(s.limits.Files-used.Files, 0)
+		}
+		if s.key == "user:"+owner.UserID {
+			status.User = scopeStatus
+		} else {
+			status.Tenant = scopeStatus
+		}
+	}
+	return status, nil
+}
+
+// setHeaders sets the quota headers from the scope with the fewest bytes,
+// and the one with the fewest files, left.
+func (s QuotaStatus) setHeaders(ctx *fiber.Ctx) {
+	var bytesScope, filesScope *ScopeStatus
+	for _, scope := range []*ScopeStatus{s.User, s.Tenant} {
+		if scope == nil {
+			continue
+		}
+		if scope.Limit.Bytes > 0 && (bytesScope == nil || scope.Remaining.Bytes < bytesScope.Remaining.Bytes) {
+			bytesScope = scope
+		}
+		if scope.Limit.Files > 0 && (filesScope == nil || scope.Remaining.Files < filesScope.Remaining.Files) {
+			filesScope = scope
+		}
+	}
+	if bytesScope != nil {
+		ctx.Set(HeaderQuotaLimitBytes, strconv.FormatInt(bytesScope.Limit.Bytes, 10))
+		ctx.Set(HeaderQuotaRemainingBytes, strconv.FormatInt(bytesScope.Remaining.Bytes, 10))
+	}
+	if filesScope != nil {
+		ctx.Set(HeaderQuotaLimitFiles, strconv.Itoa(filesScope.Limit.Files))
+		ctx.Set(HeaderQuotaRemainingFiles, strconv.Itoa(filesScope.Remaining.Files))
+	}
+}
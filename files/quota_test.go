@@ -0,0 +1,142 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceQuota(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	service.Quota = NewQuota(Limits{Bytes: 10, Files: 2}, Limits{Bytes: 15})
+	salman := WithOwner(context.Background(), Owner{UserID: "1", Tenant: "acme"})
+	budi := WithOwner(context.Background(), Owner{UserID: "2", Tenant: "acme"})
+
+	file, err := service.Save(salman, "a.txt", strings.NewReader("123456"), "upload")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", file.UserID)
+	assert.Equal(t, "acme", file.Tenant)
+
+	_, err = service.Save(salman, "b.txt", strings.NewReader("12345"), "upload")
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	_, err = service.Storage.Stat(context.Background(), "b.txt")
+	assert.NotNil(t, err, "a file over quota is removed")
+
+	_, err = service.Save(salman, "b.txt", strings.NewReader("1234"), "upload")
+	assert.Nil(t, err)
+	_, err = service.Save(salman, "c.txt", strings.NewReader(""), "upload")
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+
+	// The tenant has 5 of 15 bytes left.
+	_, err = service.Save(budi, "d.txt", strings.NewReader("123456"), "upload")
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	assert.Nil(t, service.CheckQuota(budi, 5))
+	assert.True(t, errors.Is(service.CheckQuota(budi, 6), ErrQuotaExceeded))
+
+	status, err := service.QuotaStatus(salman)
+	assert.Nil(t, err)
+	assert.Equal(t, &ScopeStatus{ID: "1", Limit: Limits{Bytes: 10, Files: 2}, Used: Usage{Bytes: 10, Files: 2}, Remaining: Usage{}}, status.User)
+	assert.Equal(t, &ScopeStatus{ID: "acme", Limit: Limits{Bytes: 15}, Used: Usage{Bytes: 10, Files: 2}, Remaining: Usage{Bytes: 5, Files: -1}}, status.Tenant)
+
+	// Files without an owner count against nobody.
+	_, err = service.Save(context.Background(), "ingest.txt", strings.NewReader("12345678901234567890"), "ingest")
+	assert.Nil(t, err)
+	assert.Empty(t, service.Quota.inflight)
+}
+
+func TestUploadQuotaHeaders(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	service.Quota = NewQuota(Limits{}, Limits{Bytes: 8, Files: 5})
+	app, signIn := sessionApp(t)
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("tenant", "acme")
+		return ctx.Next()
+	})
+	handler := &Handler{Service: service}
+	handler.Register(app.Group("/upload"))
+	auth := signIn("1")
+
+	upload := func(contents ...string) (int, UploadResponse, map[string]string) {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		for _, content := range contents {
+			part, _ := writer.CreateFormFile("files", "a.txt")
+			part.Write([]byte(content))
+		}
+		writer.Close()
+		request := httptest.NewRequest("POST", "/upload", body)
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		request.Header.Set("Authorization", auth)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		var result UploadResponse
+		json.NewDecoder(response.Body).Decode(&result)
+		return response.StatusCode, result, map[string]string{
+			"bytes": response.Header.Get(HeaderQuotaRemainingBytes),
+			"files": response.Header.Get(HeaderQuotaRemainingFiles),
+		}
+	}
+
+	status, result, headers := upload("12345", "123456")
+	assert.Equal(t, 207, status)
+	assert.Equal(t, ErrQuotaExceeded.Error(), result.Files[1].Error)
+	assert.Equal(t, map[string]string{"bytes": "3", "files": "4"}, headers)
+
+	status, _, _ = upload("1234")
+	assert.Equal(t, 413, status)
+
+	request := httptest.NewRequest("GET", "/upload/quota", nil)
+	request.Header.Set("Authorization", auth)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, "8", response.Header.Get(HeaderQuotaLimitBytes))
+	var quota QuotaStatus
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&quota))
+	assert.Nil(t, quota.User)
+	assert.Equal(t, Usage{Bytes: 3, Files: 4}, quota.Tenant.Remaining)
+}
+
+func TestQuotaPerTenant(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	service.Quota = NewQuota(Limits{}, Limits{Bytes: 4})
+	service.Quota.ForTenant = func(ctx context.Context, tenant string) (Limits, error) {
+		if tenant == "pro" {
+			return Limits{Bytes: 10}, nil
+		}
+		return service.Quota.Tenant, nil
+	}
+
+	_, err := service.Save(WithOwner(context.Background(), Owner{Tenant: "free"}), "a.txt", strings.NewReader("123456"), "upload")
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	_, err = service.Save(WithOwner(context.Background(), Owner{Tenant: "pro"}), "b.txt", strings.NewReader("123456"), "upload")
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("tenant", ctx.Get("X-Tenant-ID"))
+		return ctx.Next()
+	})
+	app.Post("/upload", QuotaGuard(service), func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusCreated)
+	})
+	upload := func(tenant, body string) int {
+		request := httptest.NewRequest("POST", "/upload", strings.NewReader(body))
+		request.Header.Set("X-Tenant-ID", tenant)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+	assert.Equal(t, 413, upload("pro", "12345"), "refused before the body is read")
+	assert.Equal(t, 201, upload("pro", "1234"))
+	assert.Equal(t, 201, upload("free", "1234"))
+}
@@ -0,0 +1,382 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/logger"
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Errors returned by Uploads.
+var (
+	ErrUploadNotFound   = errors.New("files: upload not found")
+	ErrOffsetMismatch   = errors.New("files: upload offset mismatch")
+	ErrUploadTooLarge   = errors.New("files: upload exceeds its declared length")
+	ErrUploadIncomplete = errors.New("files: upload is not complete")
+)
+
+// uploadsDir holds the received chunks of unfinished uploads.
+const uploadsDir = ".uploads"
+
+// Upload is the state of a resumable upload. Every received chunk is
+// kept as its own object in storage, which works the same on disk and
+// on object stores without append support.
+type Upload struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Length    int64             `json:"length"`
+	Offset    int64             `json:"offset"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Parts     []string          `json:"-"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// UploadRepository persists upload state.
+type UploadRepository interface {
+	Create(ctx context.Context, upload *Upload) error
+	Get(ctx context.Context, id string) (*Upload, error)
+	Update(ctx context.Context, upload *Upload) error
+	Delete(ctx context.Context, id string) error
+	Expired(ctx context.Context, now time.Time) ([]*Upload, error)
+}
+
+// MemoryUploadRepository keeps upload state in process memory.
+type MemoryUploadRepository struct {
+	mu      sync.RWMutex
+	uploads map[string]*Upload
+}
+
+func NewMemoryUploadRepository() *MemoryUploadRepository {
+	return &MemoryUploadRepository{uploads: map[string]*Upload{}}
+}
+
+func (r *MemoryUploadRepository) Create(ctx context.Context, upload *Upload) error {
+	return r.Update(ctx, upload)
+}
+
+func (r *MemoryUploadRepository) Get(ctx context.Context, id string) (*Upload, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	upload, ok := r.uploads[id]
+	if !ok {
+		return nil, ErrUploadNotFound
+	}
+	copied := *upload
+	copied.Parts = append([]string(nil), upload.Parts...)
+	return &copied, nil
+}
+
+func (r *MemoryUploadRepository) Update(ctx context.Context, upload *Upload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *upload
+	copied.Parts = append([]string(nil), upload.Parts...)
+	r.uploads[upload.ID] = &copied
+	return nil
+}
+
+func (r *MemoryUploadRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.uploads[id]; !ok {
+		return ErrUploadNotFound
+	}
+	delete(r.uploads, id)
+	return nil
+}
+
+func (r *MemoryUploadRepository) Expired(ctx context.Context, now time.Time) ([]*Upload, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var expired []*Upload
+	for _, upload := range r.uploads {
+		if !now.Before(upload.ExpiresAt) {
+			copied := *upload
+			copied.Parts = append([]string(nil), upload.Parts...)
+			expired = append(expired, &copied)
+		}
+	}
+	return expired, nil
+}
+
+// StorageUploadRepository keeps upload state as JSON next to the chunks,
+// so every process sharing the storage can resume an upload.
+type StorageUploadRepository struct {
+	Storage storage.Storage
+}
+
+func NewStorageUploadRepository(store storage.Storage) *StorageUploadRepository {
+	return &StorageUploadRepository{Storage: store}
+}
+
+func (r *StorageUploadRepository) Create(ctx context.Context, upload *Upload) error {
+	return r.Update(ctx, upload)
+}
+
+func (r *StorageUploadRepository) Get(ctx context.Context, id string) (*Upload, error) {
+	if id == "" || strings.ContainsAny(id, "/\\.") {
+		return nil, ErrUploadNotFound
+	}
+	content, err := r.Storage.Open(ctx, stateKey(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	var state struct {
+		*Upload
+		Parts []string `json:"parts"`
+	}
+	state.Upload = &Upload{}
+	if err := json.NewDecoder(content).Decode(&state); err != nil {
+		return nil, err
+	}
+	state.Upload.Parts = state.Parts
+	return state.Upload, nil
+}
+
+func (r *StorageUploadRepository) Update(ctx context.Context, upload *Upload) error {
+	writer, err := r.Storage.Create(ctx, stateKey(upload.ID))
+	if err != nil {
+		return err
+	}
+	err = json.NewEncoder(writer).Encode(struct {
+		*Upload
+		Parts []string `json:"parts"`
+	}{upload, upload.Parts})
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (r *StorageUploadRepository) Delete(ctx context.Context, id string) error {
+	return r.Storage.Remove(ctx, stateKey(id))
+}
+
+func (r *StorageUploadRepository) Expired(ctx context.Context, now time.Time) ([]*Upload, error) {
+	infos, err := r.Storage.List(ctx, uploadsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var expired []*Upload
+	for _, info := range infos {
+		upload, err := r.Get(ctx, info.Name())
+		if err != nil {
+			continue
+		}
+		if !now.Before(upload.ExpiresAt) {
+			expired = append(expired, upload)
+		}
+	}
+	return expired, nil
+}
+
+func stateKey(id string) string {
+	return uploadsDir + "/" + id + "/upload.json"
+}
+
+// Uploads runs resumable uploads: a client declares the length up front,
+// sends chunks at the offset the server reports, picks up from that
+// offset after an interruption and finalizes once everything arrived.
+type Uploads struct {
+	Service    *Service
+	Repository UploadRepository
+	// TTL is how long an unfinished upload is kept. Zero means 24 hours.
+	TTL time.Duration
+	// MaxLength caps the declared length. Zero means no limit.
+	MaxLength int64
+
+	locks sync.Map
+}
+
+func NewUploads(service *Service, repository UploadRepository) *Uploads {
+	return &Uploads{Service: service, Repository: repository}
+}
+
+// Create starts an upload of length bytes to be stored as name.
+func (u *Uploads) Create(ctx context.Context, name string, length int64, metadata map[string]string) (*Upload, error) {
+	if length < 0 || (u.MaxLength > 0 && length > u.MaxLength) {
+		return nil, ErrUploadTooLarge
+	}
+	if err := u.Service.CheckQuota(ctx, length); err != nil {
+		return nil, err
+	}
+	ttl := u.TTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	now := time.Now()
+	upload := &Upload{
+		ID:        utils.UUIDv4(),
+		Name:      SanitizeName(name),
+		Length:    length,
+		Metadata:  metadata,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := u.Repository.Create(ctx, upload); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// Get returns the state of an upload.
+func (u *Uploads) Get(ctx context.Context, id string) (*Upload, error) {
+	return u.Repository.Get(ctx, id)
+}
+
+// Append stores the chunk read from r at offset, which must equal the
+// current offset of the upload. Bytes received before r fails are kept,
+// so the client resumes from the returned offset.
+func (u *Uploads) Append(ctx context.Context, id string, offset int64, r io.Reader) (*Upload, error) {
+	unlock := u.lock(id)
+	defer unlock()
+
+	upload, err := u.Repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset {
+		return upload, ErrOffsetMismatch
+	}
+
+	part := fmt.Sprintf("%s/%s/%020d", uploadsDir, upload.ID, offset)
+	writer, err := u.Service.Storage.Create(ctx, part)
+	if err != nil {
+		return nil, err
+	}
+	// Reading one byte past the remaining length detects oversized chunks.
+	remaining := upload.Length - upload.Offset
+	written, err := copyBuffered(writer, io.LimitReader(r, remaining+1))
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if written > remaining {
+		_ = u.Service.Storage.Remove(ctx, part)
+		return upload, ErrUploadTooLarge
+	}
+	if written == 0 {
+		_ = u.Service.Storage.Remove(ctx, part)
+		return upload, err
+	}
+
+	upload.Parts = append(upload.Parts, part)
+	upload.Offset += written
+	if updateErr := u.Repository.Update(ctx, upload); updateErr != nil {
+		return nil, updateErr
+	}
+	return upload, err
+}
+
+// Finalize assembles a complete upload into a stored file and discards
+// its chunks.
+func (u *Uploads) Finalize(ctx context.Context, id string) (*File, error) {
+	unlock := u.lock(id)
+	defer unlock()
+
+	upload, err := u.Repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Offset != upload.Length {
+		return nil, ErrUploadIncomplete
+	}
+
+	readers := make([]io.Reader, 0, len(upload.Parts))
+	for _, part := range upload.Parts {
+		content, err := u.Service.Storage.Open(ctx, part)
+		if err != nil {
+			return nil, err
+		}
+		defer content.Close()
+		readers = append(readers, content)
+	}
+	file, err := u.Service.SaveWithMetadata(ctx, upload.Name, io.MultiReader(readers...), "upload", upload.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	u.discard(ctx, upload)
+	return file, nil
+}
+
+// Abort discards an unfinished upload.
+func (u *Uploads) Abort(ctx context.Context, id string) error {
+	unlock := u.lock(id)
+	defer unlock()
+
+	upload, err := u.Repository.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	u.discard(ctx, upload)
+	return nil
+}
+
+// Expire discards uploads past their expiry and returns how many.
+func (u *Uploads) Expire(ctx context.Context) (int, error) {
+	expired, err := u.Repository.Expired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, upload := range expired {
+		unlock := u.lock(upload.ID)
+		u.discard(ctx, upload)
+		unlock()
+	}
+	return len(expired), nil
+}
+
+// Run expires abandoned uploads every interval until ctx is done.
+func (u *Uploads) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := u.Expire(ctx); err != nil {
+			logger.FromContext(ctx).Error("files: expiring uploads failed", "error", err)
+		}
+	}
+}
+
+func (u *Uploads) discard(ctx context.Context, upload *Upload) {
+	_ = u.Service.Storage.Remove(ctx, uploadsDir+"/"+upload.ID)
+	_ = u.Repository.Delete(ctx, upload.ID)
+	u.locks.Delete(upload.ID)
+}
+
+// lock serializes operations on one upload, so concurrent chunks cannot
+// both be accepted at the same offset.
+func (u *Uploads) lock(id string) func() {
+	value, _ := u.locks.LoadOrStore(id, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
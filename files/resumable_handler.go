@@ -0,0 +1,179 @@
+package files
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/middleware/cachecontrol"
+	"belajar-golang-fiber/scan"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Headers of the resumable upload protocol, following tus 1.0.
+const (
+	HeaderTusResumable   = "Tus-Resumable"
+	HeaderUploadLength   = "Upload-Length"
+	HeaderUploadOffset   = "Upload-Offset"
+	HeaderUploadMetadata = "Upload-Metadata"
+	HeaderUploadExpires  = "Upload-Expires"
+
+	tusVersion       = "1.0.0"
+	mimeOffsetStream = "application/offset+octet-stream"
+)
+
+// UploadsHandler exposes resumable uploads:
+//
+//	POST   /              create, with Upload-Length and Upload-Metadata
+//	HEAD   /:id           current Upload-Offset
+//	PATCH  /:id           a chunk at Upload-Offset
+//	POST   /:id/finalize  store the completed upload as a file
+//	DELETE /:id           abort
+type UploadsHandler struct {
+	Uploads *Uploads
+	Audit   *audit.Logger
+}
+
+// Register mounts the routes on router, e.g. app.Group("/uploads").
+func (h *UploadsHandler) Register(router fiber.Router) {
+	router.Use(func(ctx *fiber.Ctx) error {
+		ctx.Set(HeaderTusResumable, tusVersion)
+		return ctx.Next()
+	})
+	router.Use(session.Require())
+	router.Post("/", h.create)
+	router.Head("/:id", h.head)
+	router.Patch("/:id", h.patch)
+	router.Post("/:id/finalize", h.finalize)
+	router.Delete("/:id", h.abort)
+}
+
+func (h *UploadsHandler) create(ctx *fiber.Ctx) error {
+	length, err := strconv.ParseInt(ctx.Get(HeaderUploadLength), 10, 64)
+	if err != nil || length < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, HeaderUploadLength+" is required")
+	}
+	metadata, err := parseUploadMetadata(utils.CopyString(ctx.Get(HeaderUploadMetadata)))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	name := metadata["filename"]
+	delete(metadata, "filename")
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+
+	upload, err := h.Uploads.Create(WithOwner(ctx.UserContext(), ownerOf(ctx)), name, length, metadata)
+	if errors.Is(err, ErrUploadTooLarge) || errors.Is(err, ErrQuotaExceeded) {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx.Location(strings.TrimSuffix(ctx.OriginalURL(), "/") + "/" + upload.ID)
+	h.setState(ctx, upload)
+	return ctx.Status(fiber.StatusCreated).JSON(upload)
+}
+
+func (h *UploadsHandler) head(ctx *fiber.Ctx) error {
+	upload, err := h.Uploads.Get(ctx.UserContext(), uploadID(ctx))
+	if err != nil {
+		return uploadError(err)
+	}
+	h.setState(ctx, upload)
+	cachecontrol.NoStore.Apply(ctx)
+	return ctx.SendStatus(fiber.StatusOK)
+}
+
+func (h *UploadsHandler) patch(ctx *fiber.Ctx) error {
+	if !strings.HasPrefix(string(ctx.Request().Header.ContentType()), mimeOffsetStream) {
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "expected "+mimeOffsetStream)
+	}
+	offset, err := strconv.ParseInt(ctx.Get(HeaderUploadOffset), 10, 64)
+	if err != nil || offset < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, HeaderUploadOffset+" is required")
+	}
+
+	upload, err := h.Uploads.Append(ctx.UserContext(), uploadID(ctx), offset, bytes.NewReader(ctx.Body()))
+	if upload != nil {
+		h.setState(ctx, upload)
+	}
+	if err != nil {
+		return uploadError(err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UploadsHandler) finalize(ctx *fiber.Ctx) error {
+	id := uploadID(ctx)
+	var name string
+	if upload, err := h.Uploads.Get(ctx.UserContext(), id); err == nil {
+		name = upload.Name
+	}
+	file, err := h.Uploads.Finalize(WithOwner(ctx.UserContext(), ownerOf(ctx)), id)
+	auditSave(h.Audit, ctx, name, file, err)
+	if err != nil {
+		return uploadError(err)
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(file)
+}
+
+func (h *UploadsHandler) abort(ctx *fiber.Ctx) error {
+	if err := h.Uploads.Abort(ctx.UserContext(), uploadID(ctx)); err != nil {
+		return uploadError(err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UploadsHandler) setState(ctx *fiber.Ctx, upload *Upload) {
+	ctx.Set(HeaderUploadOffset, strconv.FormatInt(upload.Offset, 10))
+	ctx.Set(HeaderUploadLength, strconv.FormatInt(upload.Length, 10))
+	ctx.Set(HeaderUploadExpires, upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// parseUploadMetadata decodes "key base64value,key base64value".
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.New(HeaderUploadMetadata + " values must be base64")
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// uploadID copies the :id param, which outlives the request as a lock key.
+func uploadID(ctx *fiber.Ctx) string {
+	return utils.CopyString(ctx.Params("id"))
+}
+
+func uploadError(err error) error {
+	switch {
+	case errors.Is(err, ErrUploadNotFound):
+		return fiber.ErrNotFound
+	case errors.Is(err, ErrOffsetMismatch):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, ErrUploadTooLarge), errors.Is(err, ErrQuotaExceeded):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, ErrUploadIncomplete):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, scan.ErrInfected):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	}
+	return err
+}
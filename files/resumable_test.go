@@ -0,0 +1,116 @@
+package files
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumableUpload(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+		testResumableUpload(t, service, NewMemoryUploadRepository())
+	})
+	t.Run("storage", func(t *testing.T) {
+		service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+		testResumableUpload(t, service, NewStorageUploadRepository(service.Storage))
+	})
+}
+
+func testResumableUpload(t *testing.T, service *Service, repository UploadRepository) {
+	uploads := NewUploads(service, repository)
+	app, signIn := sessionApp(t)
+	handler := &UploadsHandler{Uploads: uploads}
+	handler.Register(app.Group("/uploads"))
+	auth := signIn("1")
+	// send makes the request as the signed in user.
+	send := func(request *http.Request) (*http.Response, error) {
+		request.Header.Set("Authorization", auth)
+		return app.Test(request)
+	}
+
+	response, err := app.Test(httptest.NewRequest("POST", "/uploads", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	request := httptest.NewRequest("POST", "/uploads", nil)
+	request.Header.Set(HeaderUploadLength, "30")
+	request.Header.Set(HeaderUploadMetadata, "filename "+base64.StdEncoding.EncodeToString([]byte("contoh.txt"))+",album "+base64.StdEncoding.EncodeToString([]byte("liburan")))
+	response, err = send(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+	location := response.Header.Get("Location")
+	assert.True(t, strings.HasPrefix(location, "/uploads/"))
+
+	patch := func(offset, chunk string) *http.Response {
+		request := httptest.NewRequest("PATCH", location, strings.NewReader(chunk))
+		request.Header.Set("Content-Type", mimeOffsetStream)
+		request.Header.Set(HeaderUploadOffset, offset)
+		response, err := send(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	assert.Equal(t, 204, patch("0", "this is sample ").StatusCode)
+	// A retried chunk at a stale offset is refused with the real offset.
+	stale := patch("0", "this is sample ")
+	assert.Equal(t, 409, stale.StatusCode)
+	assert.Equal(t, "15", stale.Header.Get(HeaderUploadOffset))
+
+	response, err = send(httptest.NewRequest("POST", location+"/finalize", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 409, response.StatusCode)
+
+	response, err = send(httptest.NewRequest("HEAD", location, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "15", response.Header.Get(HeaderUploadOffset))
+
+	assert.Equal(t, 413, patch("15", "file for upload and more").StatusCode)
+	assert.Equal(t, 204, patch("15", "file for upload").StatusCode)
+
+	response, err = send(httptest.NewRequest("POST", location+"/finalize", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+	var file File
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&file))
+	assert.Equal(t, "contoh.txt", file.Name)
+	assert.Equal(t, int64(30), file.Size)
+	assert.Equal(t, map[string]string{"album": "liburan"}, file.Metadata)
+
+	_, content, err := service.Open(context.Background(), file.ID)
+	assert.Nil(t, err)
+	defer content.Close()
+	bytes, _ := io.ReadAll(content)
+	assert.Equal(t, "this is sample file for upload", string(bytes))
+
+	response, err = send(httptest.NewRequest("HEAD", location, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	_, err = service.Storage.Stat(context.Background(), uploadsDir+"/"+strings.TrimPrefix(location, "/uploads/"))
+	assert.NotNil(t, err)
+}
+
+func TestExpireUploads(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	uploads := NewUploads(service, NewStorageUploadRepository(service.Storage))
+
+	upload, err := uploads.Create(context.Background(), "a.txt", 10, nil)
+	assert.Nil(t, err)
+	upload.ExpiresAt = upload.CreatedAt
+	assert.Nil(t, uploads.Repository.Update(context.Background(), upload))
+
+	count, err := uploads.Expire(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	_, err = uploads.Get(context.Background(), upload.ID)
+	assert.ErrorIs(t, err, ErrUploadNotFound)
+}
@@ -0,0 +1,437 @@
+package files
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/logger"
+	"belajar-golang-fiber/scan"
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Service stores file contents in a Storage and records their metadata.
+type Service struct {
+	Storage    storage.Storage
+	Repository Repository
+	// Scanner, when set, checks every file while it is written. Files
+	// failing the scan are removed again and Save returns an error
+	// wrapping scan.ErrInfected.
+	Scanner scan.Scanner
+	// Quota, when set, limits what the owner of the context, see
+	// WithOwner, may store. Files going over it fail with
+	// ErrQuotaExceeded.
+	Quota *Quota
+	// MaxVersions is how many previous contents of a file are kept when
+	// it is replaced. Uploading a name again only replaces the earlier
+	// file when it is set; otherwise the upload gets a new name.
+	MaxVersions int
+
+	checks []func(ctx context.Context, file *File) error
+	hooks  []func(ctx context.Context, file *File)
+	locks  sync.Map
+}
+
+// versionsDir holds the previous contents of replaced files.
+const versionsDir = ".versions"
+
+func NewService(store storage.Storage, repository Repository) *Service {
+	return &Service{Storage: store, Repository: repository}
+}
+
+// AfterSave registers a hook called, in order, after every stored file.
+// Hooks are not safe to register once the service is serving requests.
+func (s *Service) AfterSave(hook func(ctx context.Context, file *File)) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// BeforeSave registers a check called, in order, with every file about
+// to be stored or replaced, before its content is written: it has its
+// id, name, metadata and owner. An error refuses the file and is returned
+// by the save. Checks are not safe to register once the service is
+// serving requests.
+func (s *Service) BeforeSave(check func(ctx context.Context, file *File) error) {
+	s.checks = append(s.checks, check)
+}
+
+func (s *Service) check(ctx context.Context, file *File) error {
+	for _, check := range s.checks {
+		if err := check(ctx, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save streams r into storage under a sanitized name, computing its size
+// and SHA-256 checksum on the way. A name already taken gets a " (n)"
+// suffix instead of overwriting the earlier file, unless MaxVersions is
+// set.
+func (s *Service) Save(ctx context.Context, name string, r io.Reader, source string) (*File, error) {
+	return s.SaveWithMetadata(ctx, name, r, source, nil)
+}
+
+// SaveWithMetadata is Save recording metadata with the file. With
+// MaxVersions set, a name the signed in owner of ctx already stored is
+// replaced instead, keeping the earlier content as a version. Anonymous
+// uploads never replace each other.
+func (s *Service) SaveWithMetadata(ctx context.Context, name string, r io.Reader, source string, metadata map[string]string) (*File, error) {
+	owner := OwnerFrom(ctx)
+	name = SanitizeName(name)
+	if s.MaxVersions > 0 && owner.UserID != "" {
+		existing, err := s.findByName(ctx, owner, name)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return s.Replace(ctx, existing.ID, r, metadata)
+		}
+	}
+	if s.Quota != nil {
+		limited, release, err := s.Quota.reserve(ctx, s.Repository, owner, r, 1)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		r = limited
+	}
+	name = s.uniqueName(ctx, name)
+	file := &File{
+		ID:          utils.UUIDv4(),
+		Name:        name,
+		Key:         name,
+		ContentType: contentType(name),
+		Source:      source,
+		Metadata:    metadata,
+		UserID:      owner.UserID,
+		Tenant:      owner.Tenant,
+		Version:     1,
+	}
+	if err := s.check(ctx, file); err != nil {
+		return nil, err
+	}
+
+	size, checksum, err := s.write(ctx, name, r)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	file.Size = size
+	file.Checksum = checksum
+	file.CreatedAt = now
+	file.UpdatedAt = now
+	if err := s.Repository.Create(ctx, file); err != nil {
+		return nil, err
+	}
+
+	for _, hook := range s.hooks {
+		hook(ctx, file)
+	}
+	return file, nil
+}
+
+// Replace stores r as the new content of the file id. The content it had
+// is kept as a version; beyond MaxVersions the oldest ones are removed.
+// Nil metadata keeps the metadata of the file.
+func (s *Service) Replace(ctx context.Context, id string, r io.Reader, metadata map[string]string) (*File, error) {
+	unlock := s.lock(id)
+	defer unlock()
+
+	file, err := s.Repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if file.Trashed() {
+		return nil, ErrNotFound
+	}
+	draft := file.clone()
+	draft.Version++
+	if metadata != nil {
+		draft.Metadata = metadata
+	}
+	if err := s.check(ctx, draft); err != nil {
+		return nil, err
+	}
+	if s.Quota != nil {
+		limited, release, err := s.Quota.reserve(ctx, s.Repository, Owner{UserID: file.UserID, Tenant: file.Tenant}, r, 0)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		r = limited
+	}
+
+	// The current content always stays at the key of the file: the new
+	// one is written aside and swapped in once it is complete.
+	pending := versionKey(file.ID, file.Version+1) + ".tmp"
+	size, checksum, err := s.write(ctx, pending, r)
+	if err != nil {
+		return nil, err
+	}
+	previous := file.current()
+	previous.Key = versionKey(file.ID, file.Version)
+	if err := s.Storage.Rename(ctx, file.Key, previous.Key); err != nil {
+		_ = s.Storage.Remove(ctx, pending)
+		return nil, err
+	}
+	if err := s.Storage.Rename(ctx, pending, file.Key); err != nil {
+		_ = s.Storage.Rename(ctx, previous.Key, file.Key)
+		_ = s.Storage.Remove(ctx, pending)
+		return nil, err
+	}
+
+	file.Versions = append(file.Versions, previous)
+	var pruned []Version
+	if excess := len(file.Versions) - s.MaxVersions; excess > 0 {
+		pruned = file.Versions[:excess]
+		file.Versions = slices.Clone(file.Versions[excess:])
+	}
+	file.Version++
+	file.Size = size
+	file.Checksum = checksum
+	if metadata != nil {
+		file.Metadata = metadata
+	}
+	file.UpdatedAt = time.Now()
+	if err := s.Repository.Update(ctx, file); err != nil {
+		return nil, err
+	}
+	for _, version := range pruned {
+		_ = s.Storage.Remove(ctx, version.Key)
+	}
+
+	for _, hook := range s.hooks {
+		hook(ctx, file)
+	}
+	return file, nil
+}
+
+// Versions returns the previous versions of the file id, newest first.
+func (s *Service) Versions(ctx context.Context, id string) ([]Version, error) {
+	file, err := s.Repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if file.Trashed() {
+		return nil, ErrNotFound
+	}
+	versions := slices.Clone(file.Versions)
+	slices.Reverse(versions)
+	return versions, nil
+}
+
+// RestoreVersion makes the content of version number the current one
+// again. It is stored as a new version, so the history is kept.
+func (s *Service) RestoreVersion(ctx context.Context, id string, number int) (*File, error) {
+	file, err := s.Repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(file.Versions, func(version Version) bool { return version.Number == number })
+	if file.Trashed() || index < 0 {
+		return nil, ErrNotFound
+	}
+	version := file.Versions[index]
+	content, err := s.Storage.Open(ctx, version.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	return s.Replace(ctx, id, content, version.Metadata)
+}
+
+// write streams r into storage at key and returns its size and SHA-256
+// checksum. Nothing is left at key when it fails.
+func (s *Service) write(ctx context.Context, key string, r io.Reader) (int64, string, error) {
+	writer, err := s.Storage.Create(ctx, key)
+	if err != nil {
+		return 0, "", err
+	}
+
+	hash := sha256.New()
+	var size int64
+	if s.Scanner == nil {
+		size, err = copyBuffered(io.MultiWriter(writer, hash), r)
+	} else {
+		size, err = s.copyScanned(ctx, key, io.MultiWriter(writer, hash), r)
+	}
+	if aborter, ok := writer.(storage.Aborter); ok && err != nil {
+		_ = aborter.CloseWithError(err)
+	} else if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = s.Storage.Remove(ctx, key)
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// findByName returns the file named name that owner stores, if any.
+func (s *Service) findByName(ctx context.Context, owner Owner, name string) (*File, error) {
+	list, err := s.Repository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range list {
+		if file.Name == name && file.UserID == owner.UserID && file.Tenant == owner.Tenant && !file.Trashed() {
+			return file, nil
+		}
+	}
+	return nil, nil
+}
+
+// lock serializes replacing the content of one file.
+func (s *Service) lock(id string) func() {
+	value, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// versionKey is where version number of the file id is kept.
+func versionKey(id string, number int) string {
+	return versionsDir + "/" + id + "/" + strconv.Itoa(number)
+}
+
+// QuotaStatus reports the quota of the owner of ctx. It is empty without
+// a Quota.
+func (s *Service) QuotaStatus(ctx context.Context) (QuotaStatus, error) {
+	if s.Quota == nil {
+		return QuotaStatus{}, nil
+	}
+	return s.Quota.Status(ctx, s.Repository, OwnerFrom(ctx))
+}
+
+// CheckQuota returns ErrQuotaExceeded when the owner of ctx has no room
+// left for a file of size bytes, so uploads announcing their size can be
+// refused before they are received.
+func (s *Service) CheckQuota(ctx context.Context, size int64) error {
+	status, err := s.QuotaStatus(ctx)
+	if err != nil {
+		return err
+	}
+	for _, scope := range []*ScopeStatus{status.User, status.Tenant} {
+		if scope != nil && (scope.Remaining.Files == 0 || (scope.Remaining.Bytes >= 0 && size > scope.Remaining.Bytes)) {
+			return ErrQuotaExceeded
+		}
+	}
+	return nil
+}
+
+// Open returns the metadata and contents of a stored file. Files in the
+// trash are not found.
+func (s *Service) Open(ctx context.Context, id string) (*File, storage.File, error) {
+	file, err := s.Repository.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file.Trashed() {
+		return nil, nil, ErrNotFound
+	}
+	content, err := s.Storage.Open(ctx, file.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, content, nil
+}
+
+func (s *Service) uniqueName(ctx context.Context, name string) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 1; ; i++ {
+		if _, err := s.Storage.Stat(ctx, candidate); err != nil {
+			return candidate
+		}
+		candidate = base + " (" + strconv.Itoa(i) + ")" + ext
+	}
+}
+
+// copyScanned copies r to dst while the scanner reads the same bytes, so
+// content is scanned without being read twice.
+func (s *Service) copyScanned(ctx context.Context, name string, dst io.Writer, r io.Reader) (int64, error) {
+	reader, writer := io.Pipe()
+	type outcome struct {
+		result scan.Result
+		err    error
+	}
+	verdict := make(chan outcome, 1)
+	go func() {
+		result, err := s.Scanner.Scan(ctx, reader)
+		// Unblock the copy should the scanner stop reading early.
+		reader.CloseWithError(errScanEnded)
+		verdict <- outcome{result, err}
+	}()
+
+	size, err := copyBuffered(io.MultiWriter(dst, writer), r)
+	writer.CloseWithError(err)
+	scanned := <-verdict
+
+	switch {
+	case scanned.err != nil:
+		logger.FromContext(ctx).Error("files: scan failed", "name", name, "error", scanned.err)
+		return size, fmt.Errorf("files: scan failed: %w", scanned.err)
+	case !scanned.result.Clean:
+		logger.FromContext(ctx).Warn("files: upload rejected", "name", name, "signature", scanned.result.Signature)
+		return size, fmt.Errorf("%w: %s", scan.ErrInfected, scanned.result.Signature)
+	case err != nil:
+		return size, err
+	}
+	return size, nil
+}
+
+var errScanEnded = errors.New("files: scanner stopped reading")
+
+// copyBuffers are reused by uploads, which would otherwise allocate a
+// 32 KiB buffer for every file or chunk they copy.
+var copyBuffers = sync.Pool{
+	New: func() any {
+		buffer := make([]byte, 32*1024)
+		return &buffer
+	},
+}
+
+// copyBuffered copies through a pooled buffer. The source is wrapped so
+// io.CopyBuffer cannot hand off to a WriteTo method, such as the one of
+// *os.File, that allocates its own buffer.
+func copyBuffered(dst io.Writer, src io.Reader) (int64, error) {
+	buffer := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(buffer)
+	return io.CopyBuffer(dst, struct{ io.Reader }{src}, *buffer)
+}
+
+// SanitizeName keeps only the base name of a client supplied file name.
+func SanitizeName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" || name == ".." || name == "" {
+		return "unnamed"
+	}
+	return name
+}
+
+func contentType(name string) string {
+	if value := mime.TypeByExtension(path.Ext(name)); value != "" {
+		return value
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,101 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/scan"
+	"belajar-golang-fiber/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceSave(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+
+	file, err := service.Save(context.Background(), "../../contoh.txt", strings.NewReader("this is sample file for upload"), "upload")
+	assert.Nil(t, err)
+	assert.Equal(t, "contoh.txt", file.Name)
+	assert.Equal(t, int64(30), file.Size)
+	assert.Equal(t, "text/plain; charset=utf-8", file.ContentType)
+	assert.Len(t, file.Checksum, 64)
+
+	found, content, err := service.Open(context.Background(), file.ID)
+	assert.Nil(t, err)
+	defer content.Close()
+	assert.Equal(t, file.Checksum, found.Checksum)
+
+	bytes, err := io.ReadAll(content)
+	assert.Nil(t, err)
+	assert.Equal(t, "this is sample file for upload", string(bytes))
+}
+
+func TestSanitizeName(t *testing.T) {
+	assert.Equal(t, "passwd", SanitizeName("../../etc/passwd"))
+	assert.Equal(t, "evil.txt", SanitizeName(`..\..\evil.txt`))
+	assert.Equal(t, "unnamed", SanitizeName(".."))
+	assert.Equal(t, "ab.txt", SanitizeName("a\x00b.txt"))
+}
+
+// multipartFile reads like an uploaded file spooled to disk, without the
+// WriteTo shortcut of bytes.Reader.
+func multipartFile(content []byte) io.Reader {
+	return struct{ io.Reader }{bytes.NewReader(content)}
+}
+
+func BenchmarkServiceSave(b *testing.B) {
+	service := NewService(storage.NewLocal(b.TempDir()), NewMemoryRepository())
+	content := []byte(strings.Repeat("x", 256*1024))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := service.Save(context.Background(), "bench-"+strconv.Itoa(i)+".txt", multipartFile(content), "upload"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type rejectScanner struct{}
+
+func (rejectScanner) Scan(ctx context.Context, r io.Reader) (scan.Result, error) {
+	content, err := io.ReadAll(r)
+	if strings.Contains(string(content), "EICAR") {
+		return scan.Result{Signature: "Eicar-Test-Signature"}, err
+	}
+	return scan.Result{Clean: true}, err
+}
+
+func TestServiceSaveScanned(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	service.Scanner = rejectScanner{}
+
+	file, err := service.Save(context.Background(), "ok.txt", strings.NewReader("this is sample file for upload"), "upload")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30), file.Size)
+
+	_, err = service.Save(context.Background(), "virus.txt", strings.NewReader("X5O EICAR test"), "upload")
+	assert.ErrorIs(t, err, scan.ErrInfected)
+	_, err = service.Storage.Stat(context.Background(), "virus.txt")
+	assert.NotNil(t, err)
+	list, _ := service.Repository.List(context.Background())
+	assert.Len(t, list, 1)
+
+	// A scanner giving up early must not leave the upload hanging.
+	service.Scanner = unavailableScanner{}
+	_, err = service.Save(context.Background(), "big.txt", multipartFile(make([]byte, 1<<20)), "upload")
+	assert.ErrorContains(t, err, "scan failed")
+	_, err = service.Storage.Stat(context.Background(), "big.txt")
+	assert.NotNil(t, err)
+}
+
+type unavailableScanner struct{}
+
+func (unavailableScanner) Scan(ctx context.Context, r io.Reader) (scan.Result, error) {
+	return scan.Result{}, errors.New("connection refused")
+}
@@ -0,0 +1,172 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"time"
+
+	"belajar-golang-fiber/logger"
+)
+
+// Trash keeps deleted files restorable for Retention before purging them.
+// Trashed files stay in the repository, so they keep counting toward the
+// quota until they are purged.
+type Trash struct {
+	Service *Service
+	// Retention is how long a trashed file can be restored. Zero means
+	// 30 days.
+	Retention time.Duration
+
+	checks []func(ctx context.Context, file *File) error
+}
+
+func NewTrash(service *Service) *Trash {
+	return &Trash{Service: service}
+}
+
+// BeforeRemove registers a check called, in order, before a trashed file
+// is removed for good. An error keeps the file: Delete returns it and
+// Purge skips the file. Checks are not safe to register once the trash
+// is in use.
+func (t *Trash) BeforeRemove(check func(ctx context.Context, file *File) error) {
+	t.checks = append(t.checks, check)
+}
+
+// Move puts a file in the trash.
+func (t *Trash) Move(ctx context.Context, id string) (*File, error) {
+	file, err := t.Service.Repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if file.Trashed() {
+		return nil, ErrNotFound
+	}
+	now := time.Now()
+	file.DeletedAt = &now
+	if err := t.Service.Repository.Update(ctx, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Restore takes a file out of the trash.
+func (t *Trash) Restore(ctx context.Context, id string) (*File, error) {
+	file, err := t.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	file.DeletedAt = nil
+	if err := t.Service.Repository.Update(ctx, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Get returns a file in the trash.
+func (t *Trash) Get(ctx context.Context, id string) (*File, error) {
+	file, err := t.Service.Repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !file.Trashed() {
+		return nil, ErrNotFound
+	}
+	return file, nil
+}
+
+// List returns the files in the trash.
+func (t *Trash) List(ctx context.Context) ([]*File, error) {
+	all, err := t.Service.Repository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	trashed := []*File{}
+	for _, file := range all {
+		if file.Trashed() {
+			trashed = append(trashed, file)
+		}
+	}
+	return trashed, nil
+}
+
+// PurgeAt is when a trashed file is purged.
+func (t *Trash) PurgeAt(file *File) time.Time {
+	retention := t.Retention
+	if retention <= 0 {
+		retention = 30 * 24 * time.Hour
+	}
+	return file.DeletedAt.Add(retention)
+}
+
+// Delete removes a trashed file for good, before its retention ends.
+func (t *Trash) Delete(ctx context.Context, id string) (*File, error) {
+	file, err := t.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return file, t.remove(ctx, file)
+}
+
+// Purge removes the files trashed longer than Retention and returns them.
+// Files a BeforeRemove check keeps stay in the trash until they pass.
+func (t *Trash) Purge(ctx context.Context) ([]*File, error) {
+	trashed, err := t.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var purged []*File
+	for _, file := range trashed {
+		if now.Before(t.PurgeAt(file)) {
+			continue
+		}
+		if err := t.remove(ctx, file); errors.Is(err, ErrLocked) {
+			logger.FromContext(ctx).Info("files: kept in trash", "id", file.ID, "reason", err.Error())
+			continue
+		} else if err != nil {
+			return purged, err
+		}
+		purged = append(purged, file)
+	}
+	return purged, nil
+}
+
+// Run purges expired files every interval until ctx is done.
+func (t *Trash) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		purged, err := t.Purge(ctx)
+		if err != nil {
+			logger.FromContext(ctx).Error("files: purging trash failed", "error", err)
+		}
+		for _, file := range purged {
+			logger.FromContext(ctx).Info("files: purged from trash", "id", file.ID, "name", file.Name)
+		}
+	}
+}
+
+func (t *Trash) remove(ctx context.Context, file *File) error {
+	for _, check := range t.checks {
+		if err := check(ctx, file); err != nil {
+			return err
+		}
+	}
+	keys := []string{file.Key}
+	for _, version := range file.Versions {
+		keys = append(keys, version.Key)
+	}
+	for _, key := range keys {
+		if err := t.Service.Storage.Remove(ctx, key); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return t.Service.Repository.Delete(ctx, file.ID)
+}
@@ -0,0 +1,117 @@
+package files
+
+import (
+	"errors"
+	"time"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TrashHandler deletes files into the trash and recovers them:
+//
+//	DELETE /files/:id          move to the trash
+//	GET    /trash              list the trash
+//	POST   /trash/:id/restore  take out of the trash
+//	DELETE /trash/:id          purge now
+//
+// Users only see the files they uploaded; see Owns.
+type TrashHandler struct {
+	Trash *Trash
+	Audit *audit.Logger
+}
+
+// TrashedFile is a file in the trash and when it will be purged.
+type TrashedFile struct {
+	*File
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// Register mounts the delete route on files, e.g. app.Group("/files"), and
+// the others on trash, e.g. app.Group("/trash").
+func (h *TrashHandler) Register(files, trash fiber.Router) {
+	files.Delete("/:id", session.Require(), h.move)
+	trash.Use(session.Require())
+	trash.Get("/", h.list)
+	trash.Post("/:id/restore", h.restore)
+	trash.Delete("/:id", h.purge)
+}
+
+func (h *TrashHandler) move(ctx *fiber.Ctx) error {
+	file, err := h.Trash.Service.Repository.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil || !Owns(ctx, file) {
+		return trashError(err)
+	}
+	file, err = h.Trash.Move(ctx.UserContext(), file.ID)
+	if err != nil {
+		return trashError(err)
+	}
+	h.Audit.Log(ctx, audit.ActionFileTrash, audit.OutcomeSuccess, "file:"+file.ID, map[string]string{"name": file.Name})
+	return ctx.JSON(h.trashed(file))
+}
+
+func (h *TrashHandler) list(ctx *fiber.Ctx) error {
+	trashed, err := h.Trash.List(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	response := []TrashedFile{}
+	for _, file := range trashed {
+		if Owns(ctx, file) {
+			response = append(response, h.trashed(file))
+		}
+	}
+	return ctx.JSON(response)
+}
+
+func (h *TrashHandler) restore(ctx *fiber.Ctx) error {
+	file, err := h.Trash.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil || !Owns(ctx, file) {
+		return trashError(err)
+	}
+	file, err = h.Trash.Restore(ctx.UserContext(), file.ID)
+	if err != nil {
+		return trashError(err)
+	}
+	h.Audit.Log(ctx, audit.ActionFileRestore, audit.OutcomeSuccess, "file:"+file.ID, map[string]string{"name": file.Name})
+	return ctx.JSON(file)
+}
+
+func (h *TrashHandler) purge(ctx *fiber.Ctx) error {
+	file, err := h.Trash.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil || !Owns(ctx, file) {
+		return trashError(err)
+	}
+	if _, err := h.Trash.Delete(ctx.UserContext(), file.ID); err != nil {
+		return trashError(err)
+	}
+	h.Audit.Log(ctx, audit.ActionFilePurge, audit.OutcomeSuccess, "file:"+file.ID, map[string]string{"name": file.Name})
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *TrashHandler) trashed(file *File) TrashedFile {
+	return TrashedFile{File: file, PurgeAt: h.Trash.PurgeAt(file)}
+}
+
+// Owns reports whether the request may manage file. Files of other users
+// and tenants are answered as not found. Files without an owner, such as
+// email attachments and ingested ones, are only managed by admins, from
+// the admin routes.
+func Owns(ctx *fiber.Ctx, file *File) bool {
+	owner := ownerOf(ctx)
+	return file.UserID != "" && file.UserID == owner.UserID &&
+		(file.Tenant == "" || file.Tenant == owner.Tenant)
+}
+
+// trashError maps err to a response; nil means the file is not visible.
+func trashError(err error) error {
+	if err == nil || errors.Is(err, ErrNotFound) {
+		return fiber.ErrNotFound
+	}
+	if errors.Is(err, ErrLocked) {
+		return fiber.NewError(fiber.StatusLocked, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	service.Quota = NewQuota(Limits{Files: 1}, Limits{})
+	trash := NewTrash(service)
+	trash.Retention = time.Hour
+	ctx := WithOwner(context.Background(), Owner{UserID: "1"})
+
+	file, err := service.Save(ctx, "a.txt", strings.NewReader("isi"), "upload")
+	assert.Nil(t, err)
+
+	_, err = trash.Move(ctx, file.ID)
+	assert.Nil(t, err)
+	_, _, err = service.Open(ctx, file.ID)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	_, err = service.Save(ctx, "b.txt", strings.NewReader("isi"), "upload")
+	assert.True(t, errors.Is(err, ErrQuotaExceeded), "trashed files count toward the quota")
+
+	_, err = trash.Restore(ctx, file.ID)
+	assert.Nil(t, err)
+	_, content, err := service.Open(ctx, file.ID)
+	assert.Nil(t, err)
+	content.Close()
+
+	_, err = trash.Move(ctx, file.ID)
+	assert.Nil(t, err)
+	purged, err := trash.Purge(ctx)
+	assert.Nil(t, err)
+	assert.Empty(t, purged, "retention has not ended")
+
+	trash.Retention = time.Nanosecond
+	purged, err = trash.Purge(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, purged, 1)
+	_, err = service.Storage.Stat(ctx, file.Key)
+	assert.NotNil(t, err)
+	_, err = service.Save(ctx, "b.txt", strings.NewReader("isi"), "upload")
+	assert.Nil(t, err, "purging frees the quota")
+}
+
+func TestTrashHandler(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	mine, _ := service.Save(WithOwner(context.Background(), Owner{UserID: "1", Tenant: "acme"}), "a.txt", strings.NewReader("isi"), "upload")
+	theirs, _ := service.Save(WithOwner(context.Background(), Owner{UserID: "1", Tenant: "other"}), "b.txt", strings.NewReader("isi"), "upload")
+	ownerless, _ := service.Save(WithOwner(context.Background(), Owner{Tenant: "acme"}), "c.txt", strings.NewReader("isi"), "email:mailgun")
+
+	app, signIn := sessionApp(t)
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("tenant", "acme")
+		return ctx.Next()
+	})
+	handler := &TrashHandler{Trash: NewTrash(service)}
+	handler.Register(app.Group("/files"), app.Group("/trash"))
+	auth := signIn("1")
+	send := func(method, target string) *http.Response {
+		request := httptest.NewRequest(method, target, nil)
+		request.Header.Set("Authorization", auth)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	response, err := app.Test(httptest.NewRequest("DELETE", "/files/"+mine.ID, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+	assert.Equal(t, 404, send("DELETE", "/files/"+theirs.ID).StatusCode)
+	assert.Equal(t, 404, send("DELETE", "/files/"+ownerless.ID).StatusCode, "files without an owner are for admins")
+	assert.Equal(t, 404, send("DELETE", "/files/"+mine.ID+"x").StatusCode)
+	assert.Equal(t, 200, send("DELETE", "/files/"+mine.ID).StatusCode)
+
+	var trashed []TrashedFile
+	assert.Nil(t, json.NewDecoder(send("GET", "/trash").Body).Decode(&trashed))
+	assert.Len(t, trashed, 1)
+	assert.Equal(t, mine.ID, trashed[0].ID)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), trashed[0].PurgeAt, time.Minute)
+
+	assert.Equal(t, 200, send("POST", "/trash/"+mine.ID+"/restore").StatusCode)
+	assert.Equal(t, 404, send("POST", "/trash/"+mine.ID+"/restore").StatusCode, "the file is no longer in the trash")
+
+	send("DELETE", "/files/"+mine.ID)
+	assert.Equal(t, 204, send("DELETE", "/trash/"+mine.ID).StatusCode)
+	_, err = service.Repository.Get(context.Background(), mine.ID)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
@@ -0,0 +1,110 @@
+package files
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/scan"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// VersionsHandler replaces the content of files and recovers earlier
+// versions:
+//
+//	PUT  /:id                             new content, as the request body
+//	GET  /:id/versions                    previous versions, newest first
+//	POST /:id/versions/:version/restore   make a version current again
+type VersionsHandler struct {
+	Service *Service
+	Audit   *audit.Logger
+}
+
+// Register mounts the routes on router, e.g. app.Group("/files").
+func (h *VersionsHandler) Register(router fiber.Router) {
+	router.Put("/:id", session.Require(), h.replace)
+	router.Get("/:id/versions", session.Require(), h.list)
+	router.Post("/:id/versions/:version/restore", session.Require(), h.restore)
+}
+
+func (h *VersionsHandler) replace(ctx *fiber.Ctx) error {
+	if err := h.owned(ctx); err != nil {
+		return err
+	}
+	var body io.Reader = ctx.Request().BodyStream()
+	if body == nil {
+		body = bytes.NewReader(ctx.Body())
+	}
+	file, err := h.Service.Replace(ctx.UserContext(), ctx.Params("id"), body, nil)
+	if err != nil {
+		return versionError(err)
+	}
+	h.Audit.Log(ctx, audit.ActionFileReplace, audit.OutcomeSuccess, "file:"+file.ID, map[string]string{
+		"name":     file.Name,
+		"version":  strconv.Itoa(file.Version),
+		"size":     strconv.FormatInt(file.Size, 10),
+		"checksum": file.Checksum,
+	})
+	return ctx.JSON(file)
+}
+
+func (h *VersionsHandler) list(ctx *fiber.Ctx) error {
+	if err := h.owned(ctx); err != nil {
+		return err
+	}
+	versions, err := h.Service.Versions(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return versionError(err)
+	}
+	return ctx.JSON(versions)
+}
+
+func (h *VersionsHandler) restore(ctx *fiber.Ctx) error {
+	number, err := ctx.ParamsInt("version")
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+	if err := h.owned(ctx); err != nil {
+		return err
+	}
+	file, err := h.Service.RestoreVersion(ctx.UserContext(), ctx.Params("id"), number)
+	if err != nil {
+		return versionError(err)
+	}
+	h.Audit.Log(ctx, audit.ActionFileRevert, audit.OutcomeSuccess, "file:"+file.ID, map[string]string{
+		"name":     file.Name,
+		"from":     strconv.Itoa(number),
+		"version":  strconv.Itoa(file.Version),
+		"checksum": file.Checksum,
+	})
+	return ctx.JSON(file)
+}
+
+// owned fails unless the file of the :id param may be managed by the
+// request.
+func (h *VersionsHandler) owned(ctx *fiber.Ctx) error {
+	file, err := h.Service.Repository.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil || file.Trashed() || !Owns(ctx, file) {
+		return versionError(err)
+	}
+	return nil
+}
+
+// versionError maps err to a response; nil means the file is not visible.
+func versionError(err error) error {
+	switch {
+	case err == nil, errors.Is(err, ErrNotFound):
+		return fiber.ErrNotFound
+	case errors.Is(err, ErrQuotaExceeded):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, scan.ErrInfected):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, ErrLocked):
+		return fiber.NewError(fiber.StatusLocked, err.Error())
+	}
+	return err
+}
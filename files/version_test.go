@@ -0,0 +1,93 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReuploadKeepsVersions(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	service.MaxVersions = 2
+	salman := WithOwner(context.Background(), Owner{UserID: "1"})
+
+	first, err := service.Save(salman, "a.txt", strings.NewReader("satu"), "upload")
+	assert.Nil(t, err)
+	for _, content := range []string{"dua", "tiga", "empat"} {
+		file, err := service.Save(salman, "a.txt", strings.NewReader(content), "upload")
+		assert.Nil(t, err)
+		assert.Equal(t, first.ID, file.ID)
+		assert.Equal(t, "a.txt", file.Name)
+	}
+
+	file, content, err := service.Open(salman, first.ID)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(content)
+	content.Close()
+	assert.Equal(t, "empat", string(body))
+	assert.Equal(t, 4, file.Version)
+	assert.Equal(t, int64(5+4+3), file.StoredSize(), "the oldest version is dropped")
+
+	versions, err := service.Versions(salman, first.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3, 2}, []int{versions[0].Number, versions[1].Number})
+	_, err = service.Storage.Stat(salman, versionKey(first.ID, 1))
+	assert.NotNil(t, err)
+
+	other, err := service.Save(WithOwner(context.Background(), Owner{UserID: "2"}), "a.txt", strings.NewReader("lain"), "upload")
+	assert.Nil(t, err)
+	assert.Equal(t, "a (1).txt", other.Name, "other users get their own file")
+
+	file, err = service.RestoreVersion(salman, first.ID, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, file.Version)
+	_, content, _ = service.Open(salman, first.ID)
+	body, _ = io.ReadAll(content)
+	content.Close()
+	assert.Equal(t, "dua", string(body))
+}
+
+func TestVersionsHandler(t *testing.T) {
+	service := NewService(storage.NewLocal(t.TempDir()), NewMemoryRepository())
+	service.MaxVersions = 5
+	file, _ := service.Save(WithOwner(context.Background(), Owner{UserID: "1"}), "a.txt", strings.NewReader("satu"), "upload")
+
+	app, signIn := sessionApp(t)
+	handler := &VersionsHandler{Service: service}
+	handler.Register(app.Group("/files"))
+	auth := signIn("1")
+	send := func(method, target string, body io.Reader) *http.Response {
+		request := httptest.NewRequest(method, target, body)
+		request.Header.Set("Authorization", auth)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	response, err := app.Test(httptest.NewRequest("PUT", "/files/"+file.ID, strings.NewReader("dua")))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	response = send("PUT", "/files/"+file.ID, strings.NewReader("dua"))
+	assert.Equal(t, 200, response.StatusCode)
+	var replaced File
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&replaced))
+	assert.Equal(t, 2, replaced.Version)
+	assert.Equal(t, int64(3), replaced.Size)
+
+	var versions []Version
+	assert.Nil(t, json.NewDecoder(send("GET", "/files/"+file.ID+"/versions", nil).Body).Decode(&versions))
+	assert.Len(t, versions, 1)
+	assert.Equal(t, file.Checksum, versions[0].Checksum)
+
+	assert.Equal(t, 200, send("POST", "/files/"+file.ID+"/versions/1/restore", nil).StatusCode)
+	assert.Equal(t, 404, send("POST", "/files/"+file.ID+"/versions/9/restore", nil).StatusCode)
+}
@@ -0,0 +1,97 @@
+package fx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticProvider struct {
+	rates Rates
+	err   error
+}
+
+func (p *staticProvider) Latest(ctx context.Context, base string) (Rates, error) {
+	return p.rates, p.err
+}
+
+func TestConvert(t *testing.T) {
+	provider := &staticProvider{rates: Rates{
+		Base:      "USD",
+		Rates:     map[string]float64{"IDR": 16000, "EUR": 0.8, "JPY": 150},
+		FetchedAt: time.Now(),
+	}}
+	service := NewService(provider, "usd")
+	service.MinorUnits = map[string]int{"JPY": 0, "IDR": 2}
+
+	_, err := service.Convert(1, "USD", "IDR")
+	assert.Equal(t, ErrUnavailable, err)
+
+	assert.Nil(t, service.Refresh(context.Background()))
+
+	conversion, err := service.Convert(10, "usd", "idr")
+	assert.Nil(t, err)
+	assert.Equal(t, 160000.0, conversion.Result)
+	assert.False(t, conversion.Stale)
+
+	conversion, err = service.Convert(10, "EUR", "JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, 1875.0, conversion.Result)
+
+	_, err = service.Convert(1, "USD", "XXX")
+	assert.Equal(t, ErrUnknownCurrency, err)
+
+	// A failed refresh keeps the old rates, which eventually go stale.
+	provider.err = context.DeadlineExceeded
+	assert.NotNil(t, service.Refresh(context.Background()))
+	service.StaleAfter = 0
+	conversion, err = service.Convert(1, "USD", "EUR")
+	assert.Nil(t, err)
+	assert.True(t, conversion.Stale)
+}
+
+func TestConvertHandler(t *testing.T) {
+	service := NewService(&staticProvider{rates: Rates{
+		Base:      "USD",
+		Rates:     map[string]float64{"IDR": 16000},
+		FetchedAt: time.Now().Add(-48 * time.Hour),
+	}}, "USD")
+	assert.Nil(t, service.Refresh(context.Background()))
+
+	app := fiber.New()
+	app.Get("/api/fx/convert", ConvertHandler(service))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/api/fx/convert?from=USD&to=IDR&amount=2", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.NotEmpty(t, response.Header.Get("Warning"))
+	body, _ := io.ReadAll(response.Body)
+	assert.Contains(t, string(body), `"result":32000`)
+	assert.Contains(t, string(body), `"stale":true`)
+
+	response, err = app.Test(httptest.NewRequest("GET", "/api/fx/convert?from=USD&to=IDR&amount=two", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestFrankfurter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/latest", r.URL.Path)
+		assert.Equal(t, "EUR", r.URL.Query().Get("from"))
+		w.Write([]byte(`{"amount":1.0,"base":"EUR","date":"2026-10-16","rates":{"USD":1.08}}`))
+	}))
+	defer server.Close()
+
+	provider := NewFrankfurter()
+	provider.BaseURL = server.URL
+	rates, err := provider.Latest(context.Background(), "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, "EUR", rates.Base)
+	assert.Equal(t, 1.08, rates.Rates["USD"])
+}
@@ -0,0 +1,35 @@
+package fx
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ConvertHandler answers GET ?from=USD&to=IDR&amount=12.5. Stale rates are
+// still served, flagged in the body and with a Warning header.
+func ConvertHandler(service *Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		amount, err := strconv.ParseFloat(ctx.Query("amount", "1"), 64)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "amount must be a number")
+		}
+
+		conversion, err := service.Convert(amount, ctx.Query("from"), ctx.Query("to"))
+		if errors.Is(err, ErrUnknownCurrency) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, ErrUnavailable) {
+			return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+
+		if conversion.Stale {
+			ctx.Set("Warning", `110 - "Response is Stale"`)
+		}
+		return ctx.JSON(conversion)
+	}
+}
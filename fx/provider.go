@@ -0,0 +1,57 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"belajar-golang-fiber/httpclient"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Rates are the prices of one unit of Base in other currencies.
+type Rates struct {
+	Base      string             `json:"base"`
+	Rates     map[string]float64 `json:"rates"`
+	FetchedAt time.Time          `json:"fetched_at"`
+}
+
+// Provider fetches the latest rates.
+type Provider interface {
+	Latest(ctx context.Context, base string) (Rates, error)
+}
+
+// Frankfurter reads the ECB reference rates published by frankfurter.app.
+type Frankfurter struct {
+	BaseURL string
+	Client  *httpclient.Client
+}
+
+func NewFrankfurter() *Frankfurter {
+	return &Frankfurter{
+		BaseURL: "https://api.frankfurter.app",
+		Client:  httpclient.New(httpclient.Config{Name: "fx", Timeout: 10 * time.Second}),
+	}
+}
+
+func (f *Frankfurter) Latest(ctx context.Context, base string) (Rates, error) {
+	response, err := f.Client.Get(ctx, f.BaseURL+"/latest?from="+url.QueryEscape(base), nil)
+	if err != nil {
+		return Rates{}, err
+	}
+	if response.Status != fiber.StatusOK {
+		return Rates{}, fmt.Errorf("fx: provider returned %d", response.Status)
+	}
+
+	var body struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(response.Body, &body); err != nil {
+		return Rates{}, err
+	}
+	return Rates{Base: body.Base, Rates: body.Rates, FetchedAt: time.Now()}, nil
+}
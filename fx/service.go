@@ -0,0 +1,133 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/logger"
+)
+
+var (
+	// ErrUnavailable is returned before the first successful fetch.
+	ErrUnavailable = errors.New("fx: rates unavailable")
+	// ErrUnknownCurrency is returned for currencies without a rate.
+	ErrUnknownCurrency = errors.New("fx: unknown currency")
+)
+
+// Conversion is the result of Convert. Stale is set when the rates are
+// older than the service's StaleAfter, e.g. because the provider is down.
+type Conversion struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Amount float64   `json:"amount"`
+	Result float64   `json:"result"`
+	Rate   float64   `json:"rate"`
+	AsOf   time.Time `json:"as_of"`
+	Stale  bool      `json:"stale"`
+}
+
+// Service keeps the latest rates in memory and converts amounts with
+// them. Every caller that converts money should go through Convert so
+// the same rate and rounding are used everywhere.
+type Service struct {
+	Provider Provider
+	Base     string
+	// StaleAfter is the age after which conversions are flagged stale.
+	StaleAfter time.Duration
+	// MinorUnits maps a currency to its number of decimals; results are
+	// rounded to it. Currencies missing from it are rounded to 2.
+	MinorUnits map[string]int
+
+	mu    sync.RWMutex
+	rates Rates
+}
+
+func NewService(provider Provider, base string) *Service {
+	return &Service{
+		Provider:   provider,
+		Base:       strings.ToUpper(base),
+		StaleAfter: 36 * time.Hour,
+		MinorUnits: map[string]int{},
+	}
+}
+
+// Refresh fetches new rates, keeping the previous ones on failure.
+func (s *Service) Refresh(ctx context.Context) error {
+	rates, err := s.Provider.Latest(ctx, s.Base)
+	if err != nil {
+		return err
+	}
+	rates.Rates[rates.Base] = 1
+
+	s.mu.Lock()
+	s.rates = rates
+	s.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the rates every interval until ctx is done.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(ctx); err != nil {
+			logger.FromContext(ctx).Error("fx: refresh failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rates returns the rates currently in use.
+func (s *Service) Rates() Rates {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rates
+}
+
+// Convert converts amount from one currency to another, crossing through
+// the base currency when neither side is the base.
+func (s *Service) Convert(amount float64, from, to string) (Conversion, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	rates := s.Rates()
+	if rates.Rates == nil {
+		return Conversion{}, ErrUnavailable
+	}
+
+	fromRate, ok := rates.Rates[from]
+	if !ok {
+		return Conversion{}, ErrUnknownCurrency
+	}
+	toRate, ok := rates.Rates[to]
+	if !ok {
+		return Conversion{}, ErrUnknownCurrency
+	}
+
+	rate := toRate / fromRate
+	return Conversion{
+		From:   from,
+		To:     to,
+		Amount: amount,
+		Result: s.round(amount*rate, to),
+		Rate:   rate,
+		AsOf:   rates.FetchedAt,
+		Stale:  time.Since(rates.FetchedAt) > s.StaleAfter,
+	}, nil
+}
+
+func (s *Service) round(value float64, currency string) float64 {
+	units, ok := s.MinorUnits[currency]
+	if !ok {
+		units = 2
+	}
+	scale := math.Pow10(units)
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,307 @@
+// Package gateway serves REST routes by calling gRPC methods, in the
+// style of grpc-gateway: the request message is built from the path
+// parameters, the query string and the JSON body, and the response
+// message is answered as JSON. The request's deadline and selected
+// headers are passed on to the gRPC call, and its status code is
+// translated to an HTTP one.
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// MetadataHeaderPrefix marks request headers forwarded as metadata and
+// response headers carrying the metadata a call returned, as in
+// grpc-gateway: "Grpc-Metadata-Tenant: x" becomes "tenant: x".
+const MetadataHeaderPrefix = "Grpc-Metadata-"
+
+// HeaderTimeout sets a deadline tighter than the request's, in the gRPC
+// wire format such as "500m" or "5S".
+const HeaderTimeout = "Grpc-Timeout"
+
+// Gateway calls the methods of Conn.
+type Gateway struct {
+	Conn grpc.ClientConnInterface
+	// Headers are forwarded as metadata under their lower-cased names.
+	Headers []string
+	// Token, when set, is sent as "authorization: Bearer <token>" in
+	// place of the caller's Authorization header.
+	Token string
+	// Timeout bounds calls of requests without a deadline. Zero leaves
+	// them unbounded.
+	Timeout time.Duration
+}
+
+// New forwards the Authorization, Accept-Language and X-Request-ID
+// headers.
+func New(conn grpc.ClientConnInterface) *Gateway {
+	return &Gateway{
+		Conn:    conn,
+		Headers: []string{fiber.HeaderAuthorization, fiber.HeaderAcceptLanguage, fiber.HeaderXRequestID},
+	}
+}
+
+// Unary returns a handler calling the method with the full name, e.g.
+// "app.v1.Users.GetUser". Its package must be linked into the binary; an
+// unknown name panics, like registering a malformed route.
+func (g *Gateway) Unary(name string) fiber.Handler {
+	descriptor, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		panic(fmt.Sprintf("gateway: %s: %v", name, err))
+	}
+	method, ok := descriptor.(protoreflect.MethodDescriptor)
+	if !ok || method.IsStreamingClient() || method.IsStreamingServer() {
+		panic(fmt.Sprintf("gateway: %s is not a unary method", name))
+	}
+	input := messageType(method.Input())
+	output := messageType(method.Output())
+	fullMethod := "/" + string(method.Parent().FullName()) + "/" + string(method.Name())
+
+	return func(ctx *fiber.Ctx) error {
+		in := input.New().Interface()
+		if err := decode(ctx, in); err != nil {
+			return writeError(ctx, status.New(codes.InvalidArgument, err.Error()))
+		}
+
+		callCtx, cancel, err := g.context(ctx)
+		if err != nil {
+			return writeError(ctx, status.New(codes.InvalidArgument, err.Error()))
+		}
+		defer cancel()
+
+		out := output.New().Interface()
+		var header metadata.MD
+		err = g.Conn.Invoke(callCtx, fullMethod, in, out, grpc.Header(&header))
+		for key, values := range header {
+			for _, value := range values {
+				ctx.Response().Header.Add(MetadataHeaderPrefix+key, value)
+			}
+		}
+		if err != nil {
+			return writeError(ctx, status.Convert(err))
+		}
+
+		body, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(out)
+		if err != nil {
+			return err
+		}
+		ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return ctx.Send(body)
+	}
+}
+
+func messageType(descriptor protoreflect.MessageDescriptor) protoreflect.MessageType {
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(descriptor.FullName())
+	if err != nil {
+		panic(fmt.Sprintf("gateway: %s: %v", descriptor.FullName(), err))
+	}
+	return messageType
+}
+
+// context derives the call's context from the request's, carrying its
+// deadline and the forwarded headers.
+func (g *Gateway) context(ctx *fiber.Ctx) (context.Context, context.CancelFunc, error) {
+	callCtx, cancel := ctx.UserContext(), context.CancelFunc(func() {})
+	if value := ctx.Get(HeaderTimeout); value != "" {
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		callCtx, cancel = context.WithTimeout(callCtx, timeout)
+	} else if _, ok := callCtx.Deadline(); !ok && g.Timeout > 0 {
+		callCtx, cancel = context.WithTimeout(callCtx, g.Timeout)
+	}
+
+	md := metadata.MD{}
+	for _, name := range g.Headers {
+		if value := ctx.Get(name); value != "" {
+			md.Append(strings.ToLower(name), value)
+		}
+	}
+	ctx.Request().Header.VisitAll(func(key, value []byte) {
+		name := textproto.CanonicalMIMEHeaderKey(string(key))
+		if rest, ok := strings.CutPrefix(name, MetadataHeaderPrefix); ok && rest != "" {
+			md.Append(strings.ToLower(rest), string(value))
+		}
+	})
+	if g.Token != "" {
+		md.Set("authorization", "Bearer "+g.Token)
+	}
+	return metadata.NewOutgoingContext(callCtx, md), cancel, nil
+}
+
+// parseTimeout reads the gRPC timeout format: at most 8 digits and a
+// unit of H, M, S, m (milliseconds), u or n.
+func parseTimeout(value string) (time.Duration, error) {
+	units := map[byte]time.Duration{
+		'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+		'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond,
+	}
+	if len(value) < 2 || len(value) > 9 {
+		return 0, fmt.Errorf("malformed %s %q", HeaderTimeout, value)
+	}
+	unit, ok := units[value[len(value)-1]]
+	n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if !ok || err != nil || n <= 0 {
+		return 0, fmt.Errorf("malformed %s %q", HeaderTimeout, value)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// decode fills in from the JSON body, then the query string, then the
+// path parameters, each naming fields by their proto or JSON names.
+func decode(ctx *fiber.Ctx, in proto.Message) error {
+	if body := ctx.Body(); len(body) > 0 {
+		if err := protojson.Unmarshal(body, in); err != nil {
+			return fmt.Errorf("malformed body: %w", err)
+		}
+	}
+	message := in.ProtoReflect()
+	var err error
+	ctx.Request().URI().QueryArgs().VisitAll(func(key, value []byte) {
+		if err == nil {
+			err = setField(message, string(key), string(value))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range ctx.Route().Params {
+		if err := setField(message, name, ctx.Params(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setField parses value into the scalar field called name. Repeated
+// fields get value appended.
+func setField(message protoreflect.Message, name, value string) error {
+	fields := message.Descriptor().Fields()
+	field := fields.ByName(protoreflect.Name(name))
+	if field == nil {
+		field = fields.ByJSONName(name)
+	}
+	if field == nil || field.IsMap() || field.Message() != nil {
+		return fmt.Errorf("unknown parameter %q", name)
+	}
+	parsed, err := parseScalar(field, value)
+	if err != nil {
+		return fmt.Errorf("parameter %q: %w", name, err)
+	}
+	if field.IsList() {
+		message.Mutable(field).List().Append(parsed)
+		return nil
+	}
+	message.Set(field, parsed)
+	return nil
+}
+
+func parseScalar(field protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(value)), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(value)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(value, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(value, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(value, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(value, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(value, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.EnumKind:
+		if v := field.Enum().Values().ByName(protoreflect.Name(value)); v != nil {
+			return protoreflect.ValueOfEnum(v.Number()), nil
+		}
+		n, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported kind %s", field.Kind())
+}
+
+// writeError answers {"error": message, "code": "NOT_FOUND"} with the
+// HTTP status matching the gRPC one.
+func writeError(ctx *fiber.Ctx, s *status.Status) error {
+	return ctx.Status(HTTPStatus(s.Code())).JSON(fiber.Map{
+		"error": s.Message(),
+		"code":  codeName(s.Code()),
+	})
+}
+
+// HTTPStatus maps a gRPC status code to the HTTP status grpc-gateway
+// uses for it.
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return fiber.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return fiber.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return fiber.StatusGatewayTimeout
+	case codes.NotFound:
+		return fiber.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return fiber.StatusConflict
+	case codes.PermissionDenied:
+		return fiber.StatusForbidden
+	case codes.Unauthenticated:
+		return fiber.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return fiber.StatusTooManyRequests
+	case codes.Unimplemented:
+		return fiber.StatusNotImplemented
+	case codes.Unavailable:
+		return fiber.StatusServiceUnavailable
+	}
+	return fiber.StatusInternalServerError
+}
+
+// codeName is the code as spelled in google.rpc.Code, e.g. "NOT_FOUND".
+func codeName(code codes.Code) string {
+	if code == codes.Canceled {
+		return "CANCELLED"
+	}
+	var name strings.Builder
+	previous := ' '
+	for _, r := range code.String() {
+		if unicode.IsUpper(r) && unicode.IsLower(previous) {
+			name.WriteByte('_')
+		}
+		name.WriteRune(unicode.ToUpper(r))
+		previous = r
+	}
+	return name.String()
+}
@@ -0,0 +1,156 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/order"
+	"belajar-golang-fiber/rpc"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func dial(t *testing.T, options ...grpc.ServerOption) *grpc.ClientConn {
+	server := rpc.NewServer("internal", options...)
+	(&rpc.Users{Service: user.NewService(user.NewMemoryRepository(), "ID")}).Register(server)
+	(&rpc.Orders{Service: order.NewService(order.NewMemoryRepository(), nil)}).Register(server)
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func newApp(gateway *Gateway) *fiber.App {
+	app := fiber.New()
+	app.Post("/users", gateway.Unary("app.v1.Users.RegisterUser"))
+	app.Get("/users", gateway.Unary("app.v1.Users.ListUsers"))
+	app.Get("/users/:id", gateway.Unary("app.v1.Users.GetUser"))
+	app.Get("/users/:user_id/orders", gateway.Unary("app.v1.Orders.ListOrders"))
+	app.Post("/users/:user_id/orders", gateway.Unary("app.v1.Orders.PlaceOrder"))
+	return app
+}
+
+func TestGateway(t *testing.T) {
+	gateway := New(dial(t))
+	gateway.Token = "internal"
+	app := newApp(gateway)
+
+	request := httptest.NewRequest("POST", "/users", strings.NewReader(`{"username":"budi","password":"secret123","name":"Budi"}`))
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	var created map[string]any
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&created))
+	assert.Equal(t, "budi", created["username"])
+	id := created["id"].(string)
+
+	response, err = app.Test(httptest.NewRequest("GET", "/users/"+id, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest("GET", "/users?limit=1&offset=0", nil))
+	assert.Nil(t, err)
+	var list map[string]any
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&list))
+	assert.Equal(t, float64(1), list["total"])
+
+	request = httptest.NewRequest("POST", "/users/"+id+"/orders", strings.NewReader(`{"currency":"IDR","items":[{"sku":"A-1","quantity":2,"unit_price":"500"}]}`))
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	var placed map[string]any
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&placed))
+	assert.Equal(t, id, placed["user_id"])
+	assert.Equal(t, "1000", placed["total"])
+
+	// Status codes are translated.
+	response, err = app.Test(httptest.NewRequest("GET", "/users/missing", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	var failure map[string]string
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&failure))
+	assert.Equal(t, "NOT_FOUND", failure["code"])
+
+	response, err = app.Test(httptest.NewRequest("POST", "/users", strings.NewReader(`{"username":"budi","password":"secret123"}`)))
+	assert.Nil(t, err)
+	assert.Equal(t, 409, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest("GET", "/users?page=2", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestGatewayUnauthenticated(t *testing.T) {
+	app := newApp(New(dial(t)))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/users", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	// The caller's Authorization header is forwarded.
+	request := httptest.NewRequest("GET", "/users", nil)
+	request.Header.Set("Authorization", "Bearer internal")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
+
+func TestMetadataAndDeadline(t *testing.T) {
+	var received metadata.MD
+	var deadline time.Time
+	conn := dial(t, grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		received, _ = metadata.FromIncomingContext(ctx)
+		deadline, _ = ctx.Deadline()
+		grpc.SetHeader(ctx, metadata.Pairs("served-by", "test"))
+		return handler(ctx, req)
+	}))
+	gateway := New(conn)
+	gateway.Token = "internal"
+	app := newApp(gateway)
+
+	request := httptest.NewRequest("GET", "/users", nil)
+	request.Header.Set("Grpc-Metadata-Tenant", "acme")
+	request.Header.Set("X-Request-ID", "req-1")
+	request.Header.Set(HeaderTimeout, "2S")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, []string{"acme"}, received.Get("tenant"))
+	assert.Equal(t, []string{"req-1"}, received.Get("x-request-id"))
+	assert.WithinDuration(t, time.Now().Add(2*time.Second), deadline, time.Second)
+	assert.Equal(t, "test", response.Header.Get("Grpc-Metadata-Served-By"))
+
+	request = httptest.NewRequest("GET", "/users", nil)
+	request.Header.Set(HeaderTimeout, "soon")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHTTPStatus(t *testing.T) {
+	assert.Equal(t, 504, HTTPStatus(codes.DeadlineExceeded))
+	assert.Equal(t, 429, HTTPStatus(codes.ResourceExhausted))
+	assert.Equal(t, "INVALID_ARGUMENT", codeName(codes.InvalidArgument))
+	assert.Equal(t, "OK", codeName(codes.OK))
+}
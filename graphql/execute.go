@@ -0,0 +1,517 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Request is a GraphQL request as POSTed by clients.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response holds the result of a request. Data is absent when the
+// request could not be executed at all.
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error is a GraphQL error. Resolvers may return one to add Extensions,
+// e.g. a machine readable code.
+type Error struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Execute runs the operation of req. Field errors are reported next to
+// the data resolved so far, as the spec asks.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	return s.execute(ctx, req, true)
+}
+
+func (s *Schema) execute(ctx context.Context, req Request, mutations bool) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	root := s.Query
+	if op.kind == "mutation" {
+		if !mutations {
+			return &Response{Errors: []*Error{{Message: "mutations must be sent with POST"}}}
+		}
+		if s.Mutation == nil {
+			return &Response{Errors: []*Error{{Message: "the schema has no mutations"}}}
+		}
+		root = s.Mutation
+	}
+	s.once.Do(s.collectInputs)
+	variables, err := s.coerceVariables(op, req.Variables)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	e := &executor{ctx: ctx, doc: doc, variables: variables}
+	data, _ := e.selectionSet(root, nil, op.selections, nil)
+	if data == nil {
+		// A null root is still reported as "data": null.
+		return &Response{Data: json.RawMessage("null"), Errors: e.errors}
+	}
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, errors.New("operationName is required when the document has several operations")
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+func (s *Schema) coerceVariables(op *operation, provided map[string]any) (map[string]any, error) {
+	variables := map[string]any{}
+	for _, def := range op.variables {
+		t, ok := s.inputType(def.typ)
+		if !ok {
+			return nil, fmt.Errorf("variable $%s has unknown type %s", def.name, def.typ)
+		}
+		value, given := provided[def.name]
+		if !given && def.hasDefault {
+			value, given = def.value, true
+		}
+		if !given {
+			if _, required := t.(*NonNull); required {
+				return nil, fmt.Errorf("variable $%s of type %s is required", def.name, def.typ)
+			}
+			continue
+		}
+		coerced, err := coerceInput(t, value, nil)
+		if err != nil {
+			return nil, fmt.Errorf("variable $%s: %w", def.name, err)
+		}
+		variables[def.name] = coerced
+	}
+	return variables, nil
+}
+
+// coerceInput checks value against t and converts it for resolvers.
+// Variables in literals are replaced from variables, which are already
+// coerced.
+func coerceInput(t Type, value any, variables map[string]any) (any, error) {
+	if name, ok := value.(variable); ok {
+		value, ok = variables[string(name)]
+		if !ok {
+			value = nil
+		}
+		if nonNull, required := t.(*NonNull); required && value == nil {
+			return nil, fmt.Errorf("expected a %s, got null", nonNull.Of)
+		}
+		return value, nil
+	}
+
+	if nonNull, ok := t.(*NonNull); ok {
+		if value == nil {
+			return nil, fmt.Errorf("expected a %s, got null", nonNull.Of)
+		}
+		return coerceInput(nonNull.Of, value, variables)
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		items, ok := value.([]any)
+		if !ok {
+			items = []any{value}
+		}
+		coerced := make([]any, 0, len(items))
+		for i, item := range items {
+			c, err := coerceInput(t.Of, item, variables)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			coerced = append(coerced, c)
+		}
+		return coerced, nil
+	case *Scalar:
+		return t.Parse(value)
+	case *InputObject:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected a %s object, got %s", t.Name, describe(value))
+		}
+		return coerceArgs(t.Fields, object, variables, t.Name)
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+// coerceArgs applies defaults and checks every given value against its
+// definition.
+func coerceArgs(defs Args, given map[string]any, variables map[string]any, owner string) (map[string]any, error) {
+	for name := range given {
+		if _, ok := defs[name]; !ok {
+			return nil, fmt.Errorf("unknown argument %q on %s", name, owner)
+		}
+	}
+	coerced := map[string]any{}
+	for name, def := range defs {
+		value, ok := given[name]
+		if v, isVariable := value.(variable); isVariable {
+			if _, set := variables[string(v)]; !set {
+				ok = false
+			}
+		}
+		if !ok {
+			if def.Default != nil {
+				coerced[name] = def.Default
+				continue
+			}
+			if _, required := def.Type.(*NonNull); required {
+				return nil, fmt.Errorf("argument %q of %s is required", name, owner)
+			}
+			continue
+		}
+		c, err := coerceInput(def.Type, value, variables)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q of %s: %w", name, owner, err)
+		}
+		coerced[name] = c
+	}
+	return coerced, nil
+}
+
+type executor struct {
+	ctx       context.Context
+	doc       *document
+	variables map[string]any
+	errors    []*Error
+}
+
+// selectionSet resolves the fields of object for source. It returns nil
+// and false when a non-null field failed, which makes the whole object
+// null.
+func (e *executor) selectionSet(object *Object, source any, selections []selection, path []any) (*orderedMap, bool) {
+	fields := e.collectFields(object, selections, map[string]bool{}, nil)
+	result := &orderedMap{}
+	for _, group := range fields {
+		first := group.fields[0]
+		value, ok := e.field(object, source, first, group.fields, append(path[:len(path):len(path)], group.key))
+		if !ok {
+			return nil, false
+		}
+		result.set(group.key, value)
+	}
+	return result, true
+}
+
+type fieldGroup struct {
+	key    string
+	fields []*field
+}
+
+// collectFields flattens fragments and merges fields with the same
+// response key, keeping their first appearance order.
+func (e *executor) collectFields(object *Object, selections []selection, visited map[string]bool, groups []fieldGroup) []fieldGroup {
+	for _, sel := range selections {
+		if !e.included(sel.directives) {
+			continue
+		}
+		switch {
+		case sel.field != nil:
+			key := sel.field.responseKey()
+			index := -1
+			for i := range groups {
+				if groups[i].key == key {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				groups = append(groups, fieldGroup{key: key})
+				index = len(groups) - 1
+			}
+			groups[index].fields = append(groups[index].fields, sel.field)
+		case sel.inline != nil:
+			if sel.inline.typeCondition == "" || sel.inline.typeCondition == object.Name {
+				groups = e.collectFields(object, sel.inline.selections, visited, groups)
+			}
+		default:
+			if visited[sel.spread] {
+				continue
+			}
+			visited[sel.spread] = true
+			f, ok := e.doc.fragments[sel.spread]
+			if !ok {
+				e.errors = append(e.errors, &Error{Message: fmt.Sprintf("unknown fragment %q", sel.spread)})
+				continue
+			}
+			if f.typeCondition == object.Name {
+				groups = e.collectFields(object, f.selections, visited, groups)
+			}
+		}
+	}
+	return groups
+}
+
+// included evaluates @skip(if:) and @include(if:).
+func (e *executor) included(directives []directive) bool {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+		var condition bool
+		for _, arg := range d.arguments {
+			if arg.name != "if" {
+				continue
+			}
+			value, _ := coerceInput(NonNullOf(Boolean), arg.value, e.variables)
+			condition, _ = value.(bool)
+		}
+		if d.name == "skip" && condition || d.name == "include" && !condition {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *executor) field(object *Object, source any, f *field, merged []*field, path []any) (any, bool) {
+	if f.name == "__typename" {
+		return object.Name, true
+	}
+	def, ok := object.Fields[f.name]
+	if !ok {
+		return e.fail(nil, path, fmt.Errorf("cannot query field %q on type %s", f.name, object.Name))
+	}
+
+	given := map[string]any{}
+	for _, arg := range f.arguments {
+		given[arg.name] = arg.value
+	}
+	args, err := coerceArgs(def.Args, given, e.variables, object.Name+"."+f.name)
+	if err != nil {
+		return e.fail(def.Type, path, err)
+	}
+
+	var value any
+	if def.Resolve != nil {
+		value, err = e.resolve(def, Params{Context: e.ctx, Source: source, Args: args})
+	} else {
+		value = defaultResolve(source, f.name)
+	}
+	if err != nil {
+		return e.fail(def.Type, path, err)
+	}
+
+	var selections []selection
+	for _, m := range merged {
+		selections = append(selections, m.selections...)
+	}
+	return e.complete(def.Type, f, selections, value, path)
+}
+
+// resolve calls the resolver, turning a panic into a field error.
+func (e *executor) resolve(def *Field, p Params) (value any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error: %v", r)
+		}
+	}()
+	return def.Resolve(p)
+}
+
+// complete shapes a resolved value after t and the selections.
+func (e *executor) complete(t Type, f *field, selections []selection, value any, path []any) (any, bool) {
+	if nonNull, ok := t.(*NonNull); ok {
+		reported := len(e.errors)
+		completed, ok := e.complete(nonNull.Of, f, selections, value, path)
+		if !ok || (completed == nil && len(e.errors) > reported) {
+			// The error making it null is already reported.
+			return nil, false
+		}
+		if completed == nil {
+			return e.fail(t, path, fmt.Errorf("%s cannot be null", f.responseKey()))
+		}
+		return completed, true
+	}
+	if isNil(value) {
+		return nil, true
+	}
+
+	switch t := t.(type) {
+	case *List:
+		items := reflect.ValueOf(value)
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			return e.fail(t, path, fmt.Errorf("%s must resolve to a list", f.responseKey()))
+		}
+		list := make([]any, 0, items.Len())
+		for i := 0; i < items.Len(); i++ {
+			item, ok := e.complete(t.Of, f, selections, items.Index(i).Interface(), append(path[:len(path):len(path)], i))
+			if !ok {
+				return nil, true
+			}
+			list = append(list, item)
+		}
+		return list, true
+	case *Scalar:
+		if len(selections) > 0 {
+			return e.fail(t, path, fmt.Errorf("%s of type %s has no fields", f.responseKey(), t.Name))
+		}
+		serialized, err := t.Serialize(value)
+		if err != nil {
+			return e.fail(t, path, err)
+		}
+		return serialized, true
+	case *Object:
+		if len(selections) == 0 {
+			return e.fail(t, path, fmt.Errorf("%s of type %s needs a selection of fields", f.responseKey(), t.Name))
+		}
+		object, ok := e.selectionSet(t, value, selections, path)
+		if !ok {
+			return nil, true
+		}
+		return object, true
+	}
+	return e.fail(t, path, fmt.Errorf("%s is not an output type", t))
+}
+
+// fail records err for the field at path. The field becomes null, or
+// its parent does when t is non-null.
+func (e *executor) fail(t Type, path []any, err error) (any, bool) {
+	var gqlErr *Error
+	if errors.As(err, &gqlErr) {
+		copied := *gqlErr
+		gqlErr = &copied
+	} else {
+		gqlErr = &Error{Message: err.Error()}
+	}
+	gqlErr.Path = path
+	e.errors = append(e.errors, gqlErr)
+	_, required := t.(*NonNull)
+	return nil, !required
+}
+
+// defaultResolve reads name from a map or a struct.
+func defaultResolve(source any, name string) any {
+	v := reflect.ValueOf(source)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !value.IsValid() {
+			return nil
+		}
+		return value.Interface()
+	case reflect.Struct:
+		index, ok := structFieldIndex(v.Type(), name)
+		if !ok {
+			return nil
+		}
+		return v.Field(index).Interface()
+	}
+	return nil
+}
+
+var structFields sync.Map // reflect.Type -> map[string]int
+
+// structFieldIndex finds the exported field called name, comparing the
+// Go name or json tag without case and underscores.
+func structFieldIndex(t reflect.Type, name string) (int, bool) {
+	cached, ok := structFields.Load(t)
+	if !ok {
+		indexes := map[string]int{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag != "" && tag != "-" {
+				indexes[fold(tag)] = i
+			}
+			if _, taken := indexes[fold(f.Name)]; !taken {
+				indexes[fold(f.Name)] = i
+			}
+		}
+		cached, _ = structFields.LoadOrStore(t, indexes)
+	}
+	index, ok := cached.(map[string]int)[fold(name)]
+	return index, ok
+}
+
+func fold(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// orderedMap is a JSON object keeping the order fields were requested in.
+type orderedMap struct {
+	keys   []string
+	values []any
+}
+
+func (m *orderedMap) set(key string, value any) {
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		encodedKey, _ := json.Marshal(key)
+		b.Write(encodedKey)
+		b.WriteByte(':')
+		encoded, err := json.Marshal(m.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(encoded)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
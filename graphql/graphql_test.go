@@ -0,0 +1,190 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type book struct {
+	ID     string
+	Title  string `json:"title"`
+	Pages  int    `json:"page_count"`
+	Author *author
+}
+
+type author struct {
+	Name string
+}
+
+func testSchema() *Schema {
+	authorType := &Object{Name: "Author", Fields: Fields{
+		"name": {Type: NonNullOf(String)},
+	}}
+	bookType := &Object{Name: "Book", Fields: Fields{
+		"id":        {Type: NonNullOf(ID)},
+		"title":     {Type: NonNullOf(String)},
+		"pageCount": {Type: Int},
+		"author":    {Type: authorType},
+		"broken": {Type: NonNullOf(String), Resolve: func(p Params) (any, error) {
+			return nil, &Error{Message: "rusak", Extensions: map[string]any{"code": "BROKEN"}}
+		}},
+	}}
+	books := []*book{
+		{ID: "1", Title: "Laskar Pelangi", Pages: 529, Author: &author{Name: "Andrea Hirata"}},
+		{ID: "2", Title: "Bumi Manusia", Pages: 535},
+	}
+	bookInput := &InputObject{Name: "BookInput", Fields: Args{
+		"title": {Type: NonNullOf(String)},
+		"pages": {Type: Int, Default: 100},
+	}}
+	return &Schema{
+		Query: &Object{Name: "Query", Fields: Fields{
+			"books": {Type: NonNullOf(ListOf(NonNullOf(bookType))), Resolve: func(p Params) (any, error) {
+				return books, nil
+			}},
+			"book": {Type: bookType, Args: Args{"id": {Type: NonNullOf(ID)}}, Resolve: func(p Params) (any, error) {
+				for _, b := range books {
+					if b.ID == p.Args["id"] {
+						return b, nil
+					}
+				}
+				return nil, nil
+			}},
+		}},
+		Mutation: &Object{Name: "Mutation", Fields: Fields{
+			"addBook": {Type: NonNullOf(bookType), Args: Args{"input": {Type: NonNullOf(bookInput)}}, Resolve: func(p Params) (any, error) {
+				input := p.Args["input"].(map[string]any)
+				return map[string]any{"id": "3", "title": input["title"], "pageCount": input["pages"]}, nil
+			}},
+		}},
+	}
+}
+
+func execute(t *testing.T, schema *Schema, query string, variables map[string]any) (string, []*Error) {
+	response := schema.Execute(context.Background(), Request{Query: query, Variables: variables})
+	data, err := json.Marshal(response.Data)
+	assert.Nil(t, err)
+	return string(data), response.Errors
+}
+
+func TestExecuteQuery(t *testing.T) {
+	schema := testSchema()
+
+	data, errs := execute(t, schema, `
+		# Aliases, fragments and directives.
+		query Books($withAuthor: Boolean = false) {
+			first: book(id: 1) { ...details author @include(if: $withAuthor) { name } }
+			books { __typename id ... on Book { title } }
+		}
+		fragment details on Book { title pageCount }`, map[string]any{"withAuthor": true})
+	assert.Empty(t, errs)
+	assert.Equal(t, `{"first":{"title":"Laskar Pelangi","pageCount":529,"author":{"name":"Andrea Hirata"}},`+
+		`"books":[{"__typename":"Book","id":"1","title":"Laskar Pelangi"},{"__typename":"Book","id":"2","title":"Bumi Manusia"}]}`, data)
+
+	data, errs = execute(t, schema, `{ book(id: "2") { title author { name } } missing: book(id: "9") { id } }`, nil)
+	assert.Empty(t, errs)
+	assert.Equal(t, `{"book":{"title":"Bumi Manusia","author":null},"missing":null}`, data)
+}
+
+func TestExecuteErrors(t *testing.T) {
+	schema := testSchema()
+
+	data, errs := execute(t, schema, `{ book(id: 1) { title broken } }`, nil)
+	assert.Equal(t, `{"book":null}`, data, "a failed non-null field nulls its parent")
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "rusak", errs[0].Message)
+	assert.Equal(t, []any{"book", "broken"}, errs[0].Path)
+	assert.Equal(t, "BROKEN", errs[0].Extensions["code"])
+
+	_, errs = execute(t, schema, `{ book { title } }`, nil)
+	assert.Contains(t, errs[0].Message, `argument "id" of Query.book is required`)
+
+	_, errs = execute(t, schema, `{ book(id: 1) { title `, nil)
+	assert.Contains(t, errs[0].Message, "syntax error")
+
+	_, errs = execute(t, schema, `query($id: ID!) { book(id: $id) { title } }`, nil)
+	assert.Contains(t, errs[0].Message, "variable $id of type ID! is required")
+
+	_, errs = execute(t, schema, `{ books { nope } }`, nil)
+	assert.Contains(t, errs[0].Message, `cannot query field "nope" on type Book`)
+}
+
+func TestExecuteMutation(t *testing.T) {
+	data, errs := execute(t, testSchema(), `mutation Add($title: String!) {
+		addBook(input: {title: $title}) { id title pageCount }
+	}`, map[string]any{"title": "Cantik Itu Luka"})
+	assert.Empty(t, errs)
+	assert.Equal(t, `{"addBook":{"id":"3","title":"Cantik Itu Luka","pageCount":100}}`, data)
+
+	_, errs = execute(t, testSchema(), `mutation { addBook(input: {title: 1}) { id } }`, nil)
+	assert.Contains(t, errs[0].Message, "expected a string")
+}
+
+func TestParseStrings(t *testing.T) {
+	doc, err := parse(`{ a(s: "tab\té", b: """
+		baris satu
+		  baris dua
+	""") }`)
+	assert.Nil(t, err)
+	args := doc.operations[0].selections[0].field.arguments
+	assert.Equal(t, "tab\té", args[0].value)
+	assert.Equal(t, "baris satu\n  baris dua", args[1].value)
+
+	_, err = parse(`{ a(s: "tidak selesai) }`)
+	assert.True(t, errors.As(err, new(syntaxError)))
+}
+
+func TestParseDepth(t *testing.T) {
+	nested := func(depth int, open, close string) string {
+		return strings.Repeat(open, depth) + strings.Repeat(close, depth)
+	}
+	_, err := parse("{ a" + nested(maxDepth-1, "{ a ", "} ") + "}")
+	assert.Nil(t, err)
+
+	for _, source := range []string{
+		"{ a" + nested(maxDepth, "{ a ", "} ") + "}",
+		"{ a" + strings.Repeat("{ a ", 2_000_000),
+		"{ a(b: " + nested(maxDepth+1, "[", "]") + ") }",
+		"{ a(b: " + strings.Repeat("{c: ", maxDepth+1) + "1" + strings.Repeat("}", maxDepth+1) + ") }",
+		"query($v: " + nested(maxDepth+1, "[", "]") + ") { a }",
+	} {
+		_, err := parse(source)
+		assert.ErrorContains(t, err, "nested deeper than", source[:20])
+	}
+}
+
+func TestHandler(t *testing.T) {
+	app := fiber.New()
+	handler := &Handler{Schema: testSchema()}
+	handler.Register(app.Group("/graphql"))
+
+	request := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"query($id: ID!) { book(id: $id) { title } }","variables":{"id":"1"}}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	var body map[string]any
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Equal(t, map[string]any{"book": map[string]any{"title": "Laskar Pelangi"}}, body["data"])
+
+	response, err = app.Test(httptest.NewRequest("GET", "/graphql?query="+strings.ReplaceAll(`mutation { addBook(input: {title: "x"}) { id } }`, " ", "%20"), nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode, "mutations need POST")
+
+	request = httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"{ books { title } }","variables":`+strings.Repeat("[", 100)+strings.Repeat("]", 100)+`}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode, "bodies meet binding.JSONLimitsDefault")
+
+	response, err = app.Test(httptest.NewRequest("GET", "/graphql/playground", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode, "the playground is off by default")
+}
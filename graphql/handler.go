@@ -0,0 +1,109 @@
+package graphql
+
+import (
+	"context"
+
+	"belajar-golang-fiber/binding"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler serves a Schema over HTTP:
+//
+//	POST /            {"query": ..., "operationName": ..., "variables": {...}}
+//	GET  /?query=...  queries only
+//	GET  /playground  GraphiQL, when Playground is set
+//
+// Bodies and variables are checked against binding.JSONLimitsDefault and
+// decoded with the app's JSONDecoder, like those of binding.Bind.
+// Requests that cannot be executed at all answer 400; field errors are
+// reported with 200 next to the data.
+type Handler struct {
+	Schema     *Schema
+	Playground bool
+}
+
+type fiberCtxKey struct{}
+
+// FiberCtx returns the request a resolver runs for, e.g. to read the
+// session. It is nil outside of Handler.
+func FiberCtx(ctx context.Context) *fiber.Ctx {
+	c, _ := ctx.Value(fiberCtxKey{}).(*fiber.Ctx)
+	return c
+}
+
+// Register mounts the routes on router, e.g. app.Group("/graphql").
+func (h *Handler) Register(router fiber.Router) {
+	router.Post("/", h.post)
+	router.Get("/", h.get)
+	if h.Playground {
+		router.Get("/playground", h.playground)
+	}
+}
+
+func (h *Handler) post(ctx *fiber.Ctx) error {
+	var req Request
+	if err := decode(ctx, ctx.Body(), &req); err != nil {
+		return h.respond(ctx, &Response{Errors: []*Error{{Message: "expected a JSON body with a query"}}})
+	}
+	return h.respond(ctx, h.Schema.execute(h.context(ctx), req, true))
+}
+
+func (h *Handler) get(ctx *fiber.Ctx) error {
+	req := Request{Query: ctx.Query("query"), OperationName: ctx.Query("operationName")}
+	if variables := ctx.Query("variables"); variables != "" {
+		if err := decode(ctx, []byte(variables), &req.Variables); err != nil {
+			return h.respond(ctx, &Response{Errors: []*Error{{Message: "variables must be a JSON object"}}})
+		}
+	}
+	return h.respond(ctx, h.Schema.execute(h.context(ctx), req, false))
+}
+
+func decode(ctx *fiber.Ctx, data []byte, out any) error {
+	if err := binding.CheckJSON(data, binding.JSONLimitsDefault); err != nil {
+		return err
+	}
+	return ctx.App().Config().JSONDecoder(data, out)
+}
+
+func (h *Handler) context(ctx *fiber.Ctx) context.Context {
+	return context.WithValue(ctx.UserContext(), fiberCtxKey{}, ctx)
+}
+
+func (h *Handler) respond(ctx *fiber.Ctx, response *Response) error {
+	if response.Data == nil {
+		ctx.Status(fiber.StatusBadRequest)
+	}
+	return ctx.JSON(response)
+}
+
+// playground serves GraphiQL, loaded from jsDelivr, pointed at the
+// endpoint itself.
+func (h *Handler) playground(ctx *fiber.Ctx) error {
+	ctx.Type("html", "utf-8")
+	return ctx.SendString(playgroundPage)
+}
+
+const playgroundPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>GraphQL playground</title>
+<link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/graphiql@3.8.3/graphiql.min.css">
+<style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+</head>
+<body>
+<div id="graphiql"></div>
+<script src="https://cdn.jsdelivr.net/npm/react@18.3.1/umd/react.production.min.js"></script>
+<script src="https://cdn.jsdelivr.net/npm/react-dom@18.3.1/umd/react-dom.production.min.js"></script>
+<script src="https://cdn.jsdelivr.net/npm/graphiql@3.8.3/graphiql.min.js"></script>
+<script>
+  const endpoint = location.pathname.replace(/\/playground\/?$/, "/");
+  const fetcher = GraphiQL.createFetcher({ url: endpoint });
+  ReactDOM.createRoot(document.getElementById("graphiql")).render(
+    React.createElement(GraphiQL, { fetcher, schema: null })
+  );
+</script>
+</body>
+</html>
+`
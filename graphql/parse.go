@@ -0,0 +1,565 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed request: its operations and fragments.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string
+	name       string
+	variables  []variableDefinition
+	selections []selection
+}
+
+type variableDefinition struct {
+	name       string
+	typ        typeRef
+	hasDefault bool
+	value      any
+}
+
+// typeRef is a type as written in a variable definition, e.g. [ID!]!.
+type typeRef struct {
+	name    string
+	list    *typeRef
+	nonNull bool
+}
+
+func (t typeRef) String() string {
+	s := t.name
+	if t.list != nil {
+		s = "[" + t.list.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selections    []selection
+}
+
+// selection is a field, a fragment spread or an inline fragment.
+type selection struct {
+	field      *field
+	spread     string
+	inline     *fragment
+	directives []directive
+}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []argument
+	selections []selection
+}
+
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value any
+}
+
+type directive struct {
+	name      string
+	arguments []argument
+}
+
+// Literal values are int64, float64, string, bool, nil, []any and
+// map[string]any, plus these two.
+type (
+	variable  string
+	enumValue string
+)
+
+type token struct {
+	kind  byte // 'n'ame, 'i'nt, 'f'loat, 's'tring, 'p'unctuator or 0 at the end
+	value string
+	pos   int
+}
+
+// maxDepth caps the nesting of selection sets, list and object values
+// and list types, so a deeply nested document cannot exhaust the stack.
+const maxDepth = 32
+
+type parser struct {
+	source string
+	pos    int
+	tok    token
+	depth  int
+}
+
+func parse(source string) (doc *document, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			syntax, ok := r.(syntaxError)
+			if !ok {
+				panic(r)
+			}
+			err = syntax
+		}
+	}()
+
+	p := &parser{source: source}
+	p.next()
+	doc = &document{fragments: map[string]*fragment{}}
+	for p.tok.kind != 0 {
+		switch {
+		case p.tok.kind == 'p' && p.tok.value == "{":
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: p.selectionSet()})
+		case p.tok.kind == 'n' && (p.tok.value == "query" || p.tok.value == "mutation"):
+			doc.operations = append(doc.operations, p.operation())
+		case p.tok.kind == 'n' && p.tok.value == "fragment":
+			f := p.fragmentDefinition()
+			if _, exists := doc.fragments[f.name]; exists {
+				p.fail("fragment " + f.name + " is defined twice")
+			}
+			doc.fragments[f.name] = f
+		default:
+			p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, syntaxError("the document has no operation")
+	}
+	return doc, nil
+}
+
+type syntaxError string
+
+func (e syntaxError) Error() string { return "syntax error: " + string(e) }
+
+func (p *parser) fail(message string) {
+	panic(syntaxError(fmt.Sprintf("%s at offset %d", message, p.tok.pos)))
+}
+
+// enter descends a level, failing beyond maxDepth; leave goes back up.
+func (p *parser) enter() {
+	if p.depth++; p.depth > maxDepth {
+		p.fail(fmt.Sprintf("nested deeper than %d levels", maxDepth))
+	}
+}
+
+func (p *parser) leave() { p.depth-- }
+
+func (p *parser) unexpected() {
+	if p.tok.kind == 0 {
+		p.fail("unexpected end of document")
+	}
+	p.fail(fmt.Sprintf("unexpected %q", p.tok.value))
+}
+
+func (p *parser) operation() *operation {
+	op := &operation{kind: p.tok.value}
+	p.next()
+	if p.tok.kind == 'n' {
+		op.name = p.name()
+	}
+	if p.peek("(") {
+		p.next()
+		for !p.peek(")") {
+			op.variables = append(op.variables, p.variableDefinition())
+		}
+		p.next()
+	}
+	p.directives()
+	op.selections = p.selectionSet()
+	return op
+}
+
+func (p *parser) variableDefinition() variableDefinition {
+	p.expect("$")
+	def := variableDefinition{name: p.name()}
+	p.expect(":")
+	def.typ = p.typeRef()
+	if p.peek("=") {
+		p.next()
+		def.hasDefault = true
+		def.value = p.value(true)
+	}
+	p.directives()
+	return def
+}
+
+func (p *parser) typeRef() typeRef {
+	p.enter()
+	defer p.leave()
+	var t typeRef
+	if p.peek("[") {
+		p.next()
+		inner := p.typeRef()
+		t.list = &inner
+		p.expect("]")
+	} else {
+		t.name = p.name()
+	}
+	if p.peek("!") {
+		p.next()
+		t.nonNull = true
+	}
+	return t
+}
+
+func (p *parser) fragmentDefinition() *fragment {
+	p.next()
+	f := &fragment{name: p.name()}
+	if f.name == "on" {
+		p.fail("a fragment cannot be named on")
+	}
+	if p.tok.kind != 'n' || p.tok.value != "on" {
+		p.fail("expected a type condition")
+	}
+	p.next()
+	f.typeCondition = p.name()
+	p.directives()
+	f.selections = p.selectionSet()
+	return f
+}
+
+func (p *parser) selectionSet() []selection {
+	p.enter()
+	defer p.leave()
+	p.expect("{")
+	var selections []selection
+	for !p.peek("}") {
+		selections = append(selections, p.selection())
+	}
+	p.next()
+	if len(selections) == 0 {
+		p.fail("empty selection set")
+	}
+	return selections
+}
+
+func (p *parser) selection() selection {
+	if p.peek("...") {
+		p.next()
+		if p.tok.kind == 'n' && p.tok.value != "on" {
+			name := p.name()
+			return selection{spread: name, directives: p.directives()}
+		}
+		inline := &fragment{}
+		if p.tok.kind == 'n' {
+			p.next()
+			inline.typeCondition = p.name()
+		}
+		directives := p.directives()
+		inline.selections = p.selectionSet()
+		return selection{inline: inline, directives: directives}
+	}
+
+	f := &field{name: p.name()}
+	if p.peek(":") {
+		p.next()
+		f.alias, f.name = f.name, p.name()
+	}
+	f.arguments = p.arguments()
+	directives := p.directives()
+	if p.peek("{") {
+		f.selections = p.selectionSet()
+	}
+	return selection{field: f, directives: directives}
+}
+
+func (p *parser) arguments() []argument {
+	if !p.peek("(") {
+		return nil
+	}
+	p.next()
+	var arguments []argument
+	for !p.peek(")") {
+		name := p.name()
+		p.expect(":")
+		arguments = append(arguments, argument{name: name, value: p.value(false)})
+	}
+	p.next()
+	return arguments
+}
+
+func (p *parser) directives() []directive {
+	var directives []directive
+	for p.peek("@") {
+		p.next()
+		directives = append(directives, directive{name: p.name(), arguments: p.arguments()})
+	}
+	return directives
+}
+
+// value parses a literal; constant ones may not contain variables.
+func (p *parser) value(constant bool) any {
+	tok := p.tok
+	switch tok.kind {
+	case 'p':
+		switch tok.value {
+		case "$":
+			if constant {
+				p.fail("variables are not allowed here")
+			}
+			p.next()
+			return variable(p.name())
+		case "[":
+			p.enter()
+			defer p.leave()
+			p.next()
+			list := []any{}
+			for !p.peek("]") {
+				list = append(list, p.value(constant))
+			}
+			p.next()
+			return list
+		case "{":
+			p.enter()
+			defer p.leave()
+			p.next()
+			object := map[string]any{}
+			for !p.peek("}") {
+				name := p.name()
+				p.expect(":")
+				object[name] = p.value(constant)
+			}
+			p.next()
+			return object
+		}
+	case 'i':
+		p.next()
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			p.fail("integer out of range")
+		}
+		return n
+	case 'f':
+		p.next()
+		f, _ := strconv.ParseFloat(tok.value, 64)
+		return f
+	case 's':
+		p.next()
+		return tok.value
+	case 'n':
+		p.next()
+		switch tok.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return enumValue(tok.value)
+	}
+	p.unexpected()
+	return nil
+}
+
+func (p *parser) name() string {
+	if p.tok.kind != 'n' {
+		p.fail("expected a name")
+	}
+	name := p.tok.value
+	p.next()
+	return name
+}
+
+func (p *parser) peek(punctuator string) bool {
+	return p.tok.kind == 'p' && p.tok.value == punctuator
+}
+
+func (p *parser) expect(punctuator string) {
+	if !p.peek(punctuator) {
+		p.fail("expected " + punctuator)
+	}
+	p.next()
+}
+
+const byteOrderMark = "\uFEFF"
+
+// next reads the following token, skipping whitespace, commas and
+// comments.
+func (p *parser) next() {
+	s := p.source
+	for p.pos < len(s) {
+		c := s[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else if c == '#' {
+			for p.pos < len(s) && s[p.pos] != '\n' && s[p.pos] != '\r' {
+				p.pos++
+			}
+		} else if strings.HasPrefix(s[p.pos:], byteOrderMark) {
+			p.pos += len(byteOrderMark)
+		} else {
+			break
+		}
+	}
+	start := p.pos
+	p.tok = token{pos: start}
+	if p.pos >= len(s) {
+		return
+	}
+
+	c := s[p.pos]
+	switch {
+	case strings.HasPrefix(s[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{kind: 'p', value: "...", pos: start}
+	case strings.ContainsRune("!$&():=@[]{}|", rune(c)):
+		p.pos++
+		p.tok = token{kind: 'p', value: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(s) && (s[p.pos] == '_' || isLetter(s[p.pos]) || isDigit(s[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: 'n', value: s[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		p.number()
+	case strings.HasPrefix(s[p.pos:], `"""`):
+		p.blockString()
+	case c == '"':
+		p.string()
+	default:
+		r, _ := utf8.DecodeRuneInString(s[p.pos:])
+		p.tok = token{kind: 'p', value: string(r), pos: start}
+		p.fail(fmt.Sprintf("unexpected character %q", r))
+	}
+}
+
+func (p *parser) number() {
+	s, start := p.source, p.pos
+	kind := byte('i')
+	if s[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() {
+		begin := p.pos
+		for p.pos < len(s) && isDigit(s[p.pos]) {
+			p.pos++
+		}
+		if p.pos == begin {
+			p.tok.pos = p.pos
+			p.fail("invalid number")
+		}
+	}
+	digits()
+	if p.pos < len(s) && s[p.pos] == '.' {
+		kind = 'f'
+		p.pos++
+		digits()
+	}
+	if p.pos < len(s) && (s[p.pos] == 'e' || s[p.pos] == 'E') {
+		kind = 'f'
+		p.pos++
+		if p.pos < len(s) && (s[p.pos] == '+' || s[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+	p.tok = token{kind: kind, value: s[start:p.pos], pos: start}
+}
+
+func (p *parser) string() {
+	s, start := p.source, p.pos
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.pos >= len(s) || s[p.pos] == '\n' || s[p.pos] == '\r' {
+			p.tok.pos = start
+			p.fail("unterminated string")
+		}
+		c := s[p.pos]
+		if c == '"' {
+			p.pos++
+			break
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+		if p.pos+1 >= len(s) {
+			p.fail("unterminated string")
+		}
+		escape := s[p.pos+1]
+		p.pos += 2
+		switch escape {
+		case '"', '\\', '/':
+			b.WriteByte(escape)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if p.pos+4 > len(s) {
+				p.fail("invalid unicode escape")
+			}
+			code, err := strconv.ParseUint(s[p.pos:p.pos+4], 16, 32)
+			if err != nil {
+				p.fail("invalid unicode escape")
+			}
+			b.WriteRune(rune(code))
+			p.pos += 4
+		default:
+			p.fail(fmt.Sprintf("invalid escape \\%c", escape))
+		}
+	}
+	p.tok = token{kind: 's', value: b.String(), pos: start}
+}
+
+// blockString reads a """ string, removing the common indentation and
+// blank first and last lines as the spec asks.
+func (p *parser) blockString() {
+	s, start := p.source, p.pos
+	p.pos += 3
+	end := strings.Index(strings.ReplaceAll(s[p.pos:], `\"""`, `    `), `"""`)
+	if end < 0 {
+		p.tok.pos = start
+		p.fail("unterminated block string")
+	}
+	raw := strings.ReplaceAll(s[p.pos:p.pos+end], `\"""`, `"""`)
+	p.pos += end + 3
+
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && (indent < 0 || len(line)-len(trimmed) < indent) {
+			indent = len(line) - len(trimmed)
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		lines[i] = lines[i][min(indent, len(lines[i])):]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	p.tok = token{kind: 's', value: strings.Join(lines, "\n"), pos: start}
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
@@ -0,0 +1,341 @@
+// Package graphql executes GraphQL queries and mutations against a schema
+// of Go resolvers and serves them from Fiber at a single endpoint. It
+// covers the query language used by clients — variables, aliases,
+// fragments, @skip and @include — but not subscriptions or
+// introspection beyond __typename.
+//
+// It stands in for gqlgen, which generates its resolvers with a build
+// step and whose modules this build does not have; the schema of package
+// account is small enough to write out by hand.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Type is the type of a field, argument or variable.
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type. Serialize turns a resolved value into its JSON
+// form and Parse an argument or variable into the Go value handed to
+// resolvers.
+type Scalar struct {
+	Name      string
+	Serialize func(value any) (any, error)
+	Parse     func(value any) (any, error)
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+// Object is an output type with fields.
+type Object struct {
+	Name   string
+	Fields Fields
+}
+
+func (o *Object) String() string { return o.Name }
+
+// Fields maps field names to their definitions.
+type Fields map[string]*Field
+
+// Field is a field of an Object. Without Resolve the value is looked up
+// on the parent: a map key, or a struct field with the same name or json
+// tag, ignoring case and underscores.
+type Field struct {
+	Type        Type
+	Args        Args
+	Description string
+	Resolve     func(p Params) (any, error)
+}
+
+// Args maps argument names to their definitions.
+type Args map[string]*Arg
+
+// Arg is an argument of a field or a field of an InputObject.
+type Arg struct {
+	Type    Type
+	Default any
+}
+
+// InputObject is the type of structured arguments. Resolvers receive
+// them as map[string]any.
+type InputObject struct {
+	Name   string
+	Fields Args
+}
+
+func (i *InputObject) String() string { return i.Name }
+
+// List is a list of Of.
+type List struct {
+	Of Type
+}
+
+func (l *List) String() string { return "[" + l.Of.String() + "]" }
+
+// NonNull is Of without null.
+type NonNull struct {
+	Of Type
+}
+
+func (n *NonNull) String() string { return n.Of.String() + "!" }
+
+// ListOf returns the list type of t.
+func ListOf(t Type) *List { return &List{Of: t} }
+
+// NonNullOf returns the non-null type of t.
+func NonNullOf(t Type) *NonNull { return &NonNull{Of: t} }
+
+// Params are handed to resolvers.
+type Params struct {
+	Context context.Context
+	// Source is the value resolved for the parent object; nil for the
+	// fields of Query and Mutation.
+	Source any
+	Args   map[string]any
+}
+
+// Schema holds the root types. Mutation may be nil.
+type Schema struct {
+	Query    *Object
+	Mutation *Object
+
+	once   sync.Once
+	inputs map[string]Type
+}
+
+// inputType looks up a type named in a variable definition.
+func (s *Schema) inputType(ref typeRef) (Type, bool) {
+	var t Type
+	if ref.list != nil {
+		inner, ok := s.inputType(*ref.list)
+		if !ok {
+			return nil, false
+		}
+		t = ListOf(inner)
+	} else {
+		found, ok := s.inputs[ref.name]
+		if !ok {
+			return nil, false
+		}
+		t = found
+	}
+	if ref.nonNull {
+		t = NonNullOf(t)
+	}
+	return t, true
+}
+
+// collectInputs registers the scalars and input objects reachable from
+// the root types, so variables can name them.
+func (s *Schema) collectInputs() {
+	s.inputs = map[string]Type{}
+	for _, scalar := range []*Scalar{String, Int, Float, Boolean, ID} {
+		s.inputs[scalar.Name] = scalar
+	}
+	seen := map[*Object]bool{}
+	var visitInput func(t Type)
+	visitInput = func(t Type) {
+		switch t := t.(type) {
+		case *NonNull:
+			visitInput(t.Of)
+		case *List:
+			visitInput(t.Of)
+		case *Scalar:
+			s.inputs[t.Name] = t
+		case *InputObject:
+			if _, done := s.inputs[t.Name]; done {
+				return
+			}
+			s.inputs[t.Name] = t
+			for _, arg := range t.Fields {
+				visitInput(arg.Type)
+			}
+		}
+	}
+	var visitOutput func(t Type)
+	visitOutput = func(t Type) {
+		switch t := t.(type) {
+		case *NonNull:
+			visitOutput(t.Of)
+		case *List:
+			visitOutput(t.Of)
+		case *Object:
+			if seen[t] {
+				return
+			}
+			seen[t] = true
+			for _, f := range t.Fields {
+				for _, arg := range f.Args {
+					visitInput(arg.Type)
+				}
+				visitOutput(f.Type)
+			}
+		}
+	}
+	visitOutput(s.Query)
+	if s.Mutation != nil {
+		visitOutput(s.Mutation)
+	}
+}
+
+// The built-in scalars.
+var (
+	String = &Scalar{
+		Name: "String",
+		Serialize: func(value any) (any, error) {
+			switch v := value.(type) {
+			case string:
+				return v, nil
+			case fmt.Stringer:
+				return v.String(), nil
+			}
+			return fmt.Sprint(value), nil
+		},
+		Parse: func(value any) (any, error) {
+			if s, ok := value.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("expected a string, got %s", describe(value))
+		},
+	}
+	Int = &Scalar{
+		Name: "Int",
+		Serialize: func(value any) (any, error) {
+			n, ok := toInt(value)
+			if !ok {
+				return nil, fmt.Errorf("cannot represent %v as Int", value)
+			}
+			return n, nil
+		},
+		Parse: func(value any) (any, error) {
+			n, ok := toInt(value)
+			if !ok {
+				return nil, fmt.Errorf("expected an Int, got %s", describe(value))
+			}
+			return n, nil
+		},
+	}
+	Float = &Scalar{
+		Name: "Float",
+		Serialize: func(value any) (any, error) {
+			if f, ok := toFloat(value); ok {
+				return f, nil
+			}
+			return nil, fmt.Errorf("cannot represent %v as Float", value)
+		},
+		Parse: func(value any) (any, error) {
+			if f, ok := toFloat(value); ok {
+				return f, nil
+			}
+			return nil, fmt.Errorf("expected a Float, got %s", describe(value))
+		},
+	}
+	Boolean = &Scalar{
+		Name: "Boolean",
+		Serialize: func(value any) (any, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("cannot represent %v as Boolean", value)
+		},
+		Parse: func(value any) (any, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("expected a Boolean, got %s", describe(value))
+		},
+	}
+	ID = &Scalar{
+		Name:      "ID",
+		Serialize: String.Serialize,
+		Parse: func(value any) (any, error) {
+			switch v := value.(type) {
+			case string:
+				return v, nil
+			case int64:
+				return strconv.FormatInt(v, 10), nil
+			}
+			if n, ok := toInt(value); ok {
+				return strconv.Itoa(n), nil
+			}
+			return nil, fmt.Errorf("expected an ID, got %s", describe(value))
+		},
+	}
+	// DateTime is a time.Time as an RFC 3339 string.
+	DateTime = &Scalar{
+		Name: "DateTime",
+		Serialize: func(value any) (any, error) {
+			if t, ok := value.(time.Time); ok {
+				return t.Format(time.RFC3339Nano), nil
+			}
+			return nil, fmt.Errorf("cannot represent %v as DateTime", value)
+		},
+		Parse: func(value any) (any, error) {
+			if s, ok := value.(string); ok {
+				return time.Parse(time.RFC3339, s)
+			}
+			return nil, fmt.Errorf("expected a DateTime, got %s", describe(value))
+		},
+	}
+)
+
+func toInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return 0, false
+		}
+		return int(v), true
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt32 || v > math.MaxInt32 {
+			return 0, false
+		}
+		return int(v), true
+	}
+	return 0, false
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	}
+	if n, ok := toInt(value); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func describe(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case int64, float64:
+		return "a number"
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	case enumValue:
+		return "an enum value"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,32 @@
+package health
+
+import "time"
+
+// Config defines the config for the readiness handler.
+type Config struct {
+	// Checks are what the application needs to serve requests, by name.
+	//
+	// Optional. Default: nil, always ready
+	Checks map[string]Check
+
+	// Timeout bounds each check.
+	//
+	// Optional. Default: 2 * time.Second
+	Timeout time.Duration
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Timeout: 2 * time.Second,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+	cfg := config[0]
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = ConfigDefault.Timeout
+	}
+	return cfg
+}
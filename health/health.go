@@ -0,0 +1,68 @@
+// Package health answers the readiness probes of load balancers and
+// orchestrators on /readyz: 200 while every check passes, 503 otherwise,
+// so traffic moves away from an instance that lost its database.
+package health
+
+import (
+	"context"
+	"sync"
+
+	"belajar-golang-fiber/logger"
+	"belajar-golang-fiber/middleware/cachecontrol"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Check reports whether a dependency can be used.
+type Check func(ctx context.Context) error
+
+// Statuses of a check and of the whole response.
+const (
+	StatusOK          = "ok"
+	StatusFailing     = "failing"
+	StatusUnavailable = "unavailable"
+)
+
+// Response is the body of the readiness handler. Failures are only
+// named; their errors are logged.
+type Response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// New creates the readiness handler, running the checks concurrently.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), cfg.Timeout)
+		defer cancel()
+
+		response := Response{Status: StatusOK, Checks: make(map[string]string, len(cfg.Checks))}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for name, check := range cfg.Checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := check(ctx)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					logger.FromContext(ctx).Warn("health: check failed", "check", name, "error", err)
+					response.Checks[name] = StatusFailing
+					response.Status = StatusUnavailable
+					return
+				}
+				response.Checks[name] = StatusOK
+			}()
+		}
+		wg.Wait()
+
+		cachecontrol.NoStore.Apply(c)
+		if response.Status != StatusOK {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+		return c.JSON(response)
+	}
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReady(t *testing.T) {
+	failing := false
+	app := fiber.New()
+	app.Get("/readyz", New(Config{
+		Checks: map[string]Check{
+			"database": func(ctx context.Context) error {
+				if failing {
+					return errors.New("connection refused")
+				}
+				return nil
+			},
+			"cache": func(ctx context.Context) error { return nil },
+		},
+	}))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, response.StatusCode)
+	assert.Equal(t, "no-store", response.Header.Get("Cache-Control"))
+	var body Response
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Equal(t, Response{Status: StatusOK, Checks: map[string]string{"database": StatusOK, "cache": StatusOK}}, body)
+
+	failing = true
+	response, _ = app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, fiber.StatusServiceUnavailable, response.StatusCode)
+	body = Response{}
+	json.NewDecoder(response.Body).Decode(&body)
+	assert.Equal(t, Response{Status: StatusUnavailable, Checks: map[string]string{"database": StatusFailing, "cache": StatusOK}}, body)
+}
+
+func TestTimeout(t *testing.T) {
+	app := fiber.New()
+	app.Get("/readyz", New(Config{
+		Timeout: 10 * time.Millisecond,
+		Checks: map[string]Check{
+			"database": func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+	}))
+
+	response, err := app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusServiceUnavailable, response.StatusCode)
+}
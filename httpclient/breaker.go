@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit states.
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// breaker counts consecutive failures and opens after threshold of them.
+type breaker struct {
+	threshold int
+	timeout   time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// allow reports whether a request may go out. Once the open period is
+// over, only one trial request is allowed until it reports back.
+func (b *breaker) allow() bool {
+	if b.threshold < 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state() {
+	case StateClosed:
+		return true
+	case StateHalfOpen:
+		if !b.trial {
+			b.trial = true
+			return true
+		}
+	}
+	return false
+}
+
+func (b *breaker) record(failed bool) {
+	if b.threshold < 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if !failed {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// State returns one of StateClosed, StateOpen or StateHalfOpen.
+func (b *breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state()
+}
+
+func (b *breaker) state() string {
+	switch {
+	case b.openedAt.IsZero():
+		return StateClosed
+	case b.now().Sub(b.openedAt) < b.timeout:
+		return StateOpen
+	default:
+		return StateHalfOpen
+	}
+}
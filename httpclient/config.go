@@ -0,0 +1,92 @@
+package httpclient
+
+import (
+	"time"
+)
+
+// Config defines the config for a Client.
+type Config struct {
+	// Name identifies the client in the "httpclient" statistics.
+	//
+	// Required.
+	Name string
+
+	// Timeout bounds each attempt, unless the request sets its own or the
+	// context has an earlier deadline.
+	//
+	// Optional. Default: 10s
+	Timeout time.Duration
+
+	// Retries is how many times a failed idempotent request is repeated.
+	// Negative disables retries.
+	//
+	// Optional. Default: 2
+	Retries int
+
+	// BaseDelay is the wait before the first retry; it doubles with every
+	// further retry, up to MaxDelay, with jitter.
+	//
+	// Optional. Default: 100ms, 2s
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// FailureThreshold consecutive failures open the circuit, rejecting
+	// requests with ErrCircuitOpen for OpenTimeout. A single trial request
+	// is let through afterwards; it closes the circuit again on success.
+	// Negative disables the breaker.
+	//
+	// Optional. Default: 5, 30s
+	FailureThreshold int
+	OpenTimeout      time.Duration
+
+	// UserAgent is sent with every request.
+	//
+	// Optional. Default: "belajar-golang-fiber"
+	UserAgent string
+
+	// Signer, when set, signs every attempt, e.g. HMAC or SigV4 for
+	// partner APIs authenticating callers by signature.
+	//
+	// Optional. Default: nil
+	Signer Signer
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Timeout:          10 * time.Second,
+	Retries:          2,
+	BaseDelay:        100 * time.Millisecond,
+	MaxDelay:         2 * time.Second,
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+	UserAgent:        "belajar-golang-fiber",
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+	cfg := config[0]
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = ConfigDefault.Timeout
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = ConfigDefault.Retries
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = ConfigDefault.BaseDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = ConfigDefault.MaxDelay
+	}
+	if cfg.FailureThreshold == 0 {
+		cfg.FailureThreshold = ConfigDefault.FailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = ConfigDefault.OpenTimeout
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = ConfigDefault.UserAgent
+	}
+	return cfg
+}
@@ -0,0 +1,267 @@
+// Package httpclient wraps fiber's client for calls to other services.
+// Every attempt is bounded by a timeout, idempotent requests failing on
+// the network or with 429, 502, 503 or 504 are retried with exponential
+// backoff, and a circuit breaker stops calling a service that keeps
+// failing. Outcomes and latencies are published on /debug/vars as
+// "httpclient", one map per client.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrCircuitOpen is returned without calling the service while its
+// circuit is open.
+var ErrCircuitOpen = errors.New("httpclient: circuit open")
+
+// stats is published on /debug/vars as "httpclient".
+var stats = expvar.NewMap("httpclient")
+
+// latencyBuckets are the upper bounds of the latency histogram.
+var latencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// Request is one outbound call.
+type Request struct {
+	Method string
+	URL    string
+	Header map[string]string
+	Body   []byte
+	// Idempotent marks a POST or PATCH as safe to repeat, for instance
+	// because it carries an Idempotency-Key. Other methods but CONNECT
+	// are idempotent already.
+	Idempotent bool
+	// Timeout overrides Config.Timeout for this request.
+	Timeout time.Duration
+}
+
+// Response is what the service answered.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Client sends requests to other services.
+type Client struct {
+	config  Config
+	client  *fiber.Client
+	breaker *breaker
+	stats   *expvar.Map
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// New creates a client and publishes its statistics.
+func New(config ...Config) *Client {
+	cfg := configDefault(config...)
+	if cfg.Name == "" {
+		panic("httpclient: Name cannot be empty")
+	}
+
+	client := fiber.AcquireClient()
+	client.UserAgent = cfg.UserAgent
+	c := &Client{
+		config:  cfg,
+		client:  client,
+		breaker: &breaker{threshold: cfg.FailureThreshold, timeout: cfg.OpenTimeout, now: time.Now},
+		stats:   new(expvar.Map).Init(),
+		sleep:   sleep,
+	}
+	c.stats.Set("state", expvar.Func(func() any { return c.breaker.State() }))
+	stats.Set(cfg.Name, c.stats)
+	return c
+}
+
+// Stats returns the counters of the client.
+func (c *Client) Stats() *expvar.Map {
+	return c.stats
+}
+
+// State returns the state of the circuit breaker.
+func (c *Client) State() string {
+	return c.breaker.State()
+}
+
+// Get is a shorthand for a GET request to url.
+func (c *Client) Get(ctx context.Context, url string, header map[string]string) (*Response, error) {
+	return c.Do(ctx, Request{Method: fiber.MethodGet, URL: url, Header: header})
+}
+
+// Do sends request, retrying it when it is idempotent and failed in a
+// way worth retrying. The last response is returned once retries run
+// out, whatever its status; errors are only returned when no response
+// was received. An attempt in flight is bounded by its timeout, which is
+// shortened to the deadline of ctx, but is not cut short when ctx is
+// cancelled.
+func (c *Client) Do(ctx context.Context, request Request) (*Response, error) {
+	if request.Method == "" {
+		request.Method = fiber.MethodGet
+	}
+	attempts := 1
+	if idempotent(request) && c.config.Retries > 0 {
+		attempts += c.config.Retries
+	}
+	c.stats.Add("requests", 1)
+
+	for attempt := 0; ; attempt++ {
+		if !c.breaker.allow() {
+			c.stats.Add("rejected", 1)
+			return nil, ErrCircuitOpen
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		timeout := c.timeout(ctx, request)
+		if timeout <= 0 {
+			return nil, context.DeadlineExceeded
+		}
+
+		signed, err := c.sign(request)
+		if err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		response, err := c.send(signed, timeout)
+		c.observe(time.Since(start), response, err)
+		c.breaker.record(err != nil || response.Status >= fiber.StatusInternalServerError)
+
+		if attempt+1 >= attempts || !retryable(response, err) {
+			return response, err
+		}
+		c.stats.Add("retries", 1)
+		if err := c.sleep(ctx, c.delay(attempt, response)); err != nil {
+			return response, err
+		}
+	}
+}
+
+func (c *Client) send(request Request, timeout time.Duration) (*Response, error) {
+	agent := c.client.Get(request.URL)
+	agent.Request().Header.SetMethod(request.Method)
+	for key, value := range request.Header {
+		agent.Set(key, value)
+	}
+	if request.Body != nil {
+		agent.Body(request.Body)
+	}
+	// Retries are ours to make, with backoff and the breaker in mind.
+	agent.RetryIf(func(*fiber.Request) bool { return false })
+	agent.Timeout(timeout)
+
+	raw := fiber.AcquireResponse()
+	defer fiber.ReleaseResponse(raw)
+	status, body, errs := agent.SetResponse(raw).Bytes()
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	header := http.Header{}
+	raw.Header.VisitAll(func(key, value []byte) {
+		header.Add(string(key), string(value))
+	})
+	return &Response{Status: status, Header: header, Body: body}, nil
+}
+
+// sign returns request signed by the Signer, if any, leaving the
+// header of request as it was.
+func (c *Client) sign(request Request) (Request, error) {
+	if c.config.Signer == nil {
+		return request, nil
+	}
+	header := make(map[string]string, len(request.Header)+4)
+	maps.Copy(header, request.Header)
+	request.Header = header
+	return request, c.config.Signer.Sign(&request, time.Now())
+}
+
+// timeout is the time the next attempt may take.
+func (c *Client) timeout(ctx context.Context, request Request) time.Duration {
+	timeout := c.config.Timeout
+	if request.Timeout > 0 {
+		timeout = request.Timeout
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+	return timeout
+}
+
+// delay is the backoff before retry attempt+1, between half and all of
+// BaseDelay doubled attempt times, or what Retry-After asks for; both
+// capped at MaxDelay.
+func (c *Client) delay(attempt int, response *Response) time.Duration {
+	if response != nil {
+		if seconds, err := strconv.Atoi(response.Header.Get(fiber.HeaderRetryAfter)); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, c.config.MaxDelay)
+		}
+	}
+	delay := min(c.config.BaseDelay<<attempt, c.config.MaxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (c *Client) observe(latency time.Duration, response *Response, err error) {
+	c.stats.Add("attempts", 1)
+	c.stats.Add("latency_ms_total", latency.Milliseconds())
+	bucket := "latency_le_inf"
+	for _, bound := range latencyBuckets {
+		if latency <= bound {
+			bucket = "latency_le_" + bound.String()
+			break
+		}
+	}
+	c.stats.Add(bucket, 1)
+
+	if err != nil {
+		c.stats.Add("errors", 1)
+		return
+	}
+	c.stats.Add("status_"+strconv.Itoa(response.Status/100)+"xx", 1)
+}
+
+func idempotent(request Request) bool {
+	switch request.Method {
+	case fiber.MethodPost, fiber.MethodPatch:
+		return request.Idempotent
+	case fiber.MethodConnect:
+		return false
+	}
+	return true
+}
+
+func retryable(response *Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch response.Status {
+	case fiber.StatusTooManyRequests, fiber.StatusBadGateway, fiber.StatusServiceUnavailable, fiber.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
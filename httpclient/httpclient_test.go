@@ -0,0 +1,136 @@
+package httpclient
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func noSleep(ctx context.Context, d time.Duration) error {
+	return ctx.Err()
+}
+
+func counter(c *Client, key string) int64 {
+	value, ok := c.Stats().Get(key).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return value.Value()
+}
+
+func TestRetriesIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, "rahasia", r.Header.Get("X-Token"))
+		w.Header().Set("X-Kind", "rates")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	client := New(Config{Name: "retry"})
+	client.sleep = noSleep
+	response, err := client.Get(context.Background(), server.URL, map[string]string{"X-Token": "rahasia"})
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.Status)
+	assert.Equal(t, `{"ok":true}`, string(response.Body))
+	assert.Equal(t, "rates", response.Header.Get("X-Kind"))
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, int64(2), counter(client, "retries"))
+	assert.Equal(t, int64(2), counter(client, "status_5xx"))
+	assert.Equal(t, int64(1), counter(client, "status_2xx"))
+	assert.Equal(t, StateClosed, client.State())
+}
+
+func TestDoesNotRetryPost(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := New(Config{Name: "post"})
+	client.sleep = noSleep
+	response, err := client.Do(context.Background(), Request{Method: "POST", URL: server.URL, Body: []byte("{}")})
+	assert.Nil(t, err)
+	assert.Equal(t, 502, response.Status)
+	assert.Equal(t, int32(1), calls.Load())
+
+	response, err = client.Do(context.Background(), Request{Method: "POST", URL: server.URL, Idempotent: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 502, response.Status)
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	client := New(Config{Name: "breaker", Retries: -1, FailureThreshold: 2, OpenTimeout: time.Minute})
+	client.breaker.now = func() time.Time { return now }
+
+	for range 2 {
+		response, err := client.Get(context.Background(), server.URL, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 500, response.Status)
+	}
+	assert.Equal(t, StateOpen, client.State())
+	_, err := client.Get(context.Background(), server.URL, nil)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, int64(1), counter(client, "rejected"))
+
+	now = now.Add(time.Minute)
+	healthy.Store(true)
+	assert.Equal(t, StateHalfOpen, client.State())
+	response, err := client.Get(context.Background(), server.URL, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.Status)
+	assert.Equal(t, StateClosed, client.State())
+}
+
+func TestTimeoutAndBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := New(Config{Name: "timeout", Retries: -1})
+	start := time.Now()
+	_, err := client.Do(context.Background(), Request{URL: server.URL, Timeout: 20 * time.Millisecond})
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+	assert.Equal(t, int64(1), counter(client, "errors"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.Get(ctx, server.URL, nil)
+	assert.Equal(t, context.Canceled, err)
+
+	client = New(Config{Name: "backoff", BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond})
+	for attempt, upper := range []time.Duration{100, 200, 300, 300} {
+		delay := client.delay(attempt, nil)
+		assert.GreaterOrEqual(t, delay, upper*time.Millisecond/2)
+		assert.LessOrEqual(t, delay, upper*time.Millisecond)
+	}
+	retryAfter := &Response{Header: http.Header{"Retry-After": {"1"}}}
+	assert.Equal(t, 300*time.Millisecond, client.delay(0, retryAfter))
+}
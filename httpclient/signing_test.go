@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The get-vanilla cases of the AWS Signature Version 4 test suite.
+func TestSigV4(t *testing.T) {
+	signer := SigV4{
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:    "us-east-1",
+		Service:   "service",
+	}
+	at := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	request := Request{Method: "GET", URL: "https://example.amazonaws.com/", Header: map[string]string{}}
+	assert.Nil(t, signer.Sign(&request, at))
+	assert.Equal(t, "20150830T123600Z", request.Header["X-Amz-Date"])
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		request.Header["Authorization"])
+
+	request = Request{Method: "GET", URL: "https://example.amazonaws.com/?Param2=value2&Param1=value1", Header: map[string]string{}}
+	assert.Nil(t, signer.Sign(&request, at))
+	assert.Contains(t, request.Header["Authorization"], "Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500")
+}
+
+func TestCanonicalRequest(t *testing.T) {
+	canonical, err := CanonicalRequest("post", "https://partner.example/v1/orders/a%20b?z=1&a=2&a=1&q=x+y", "1700000000", []byte("{}"))
+	assert.Nil(t, err)
+	assert.Equal(t, "POST\n/v1/orders/a%20b\na=1&a=2&q=x%20y&z=1\n1700000000\n"+
+		"44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", canonical)
+
+	// However the client escaped the same request.
+	same, err := CanonicalRequest("POST", "https://partner.example/v1/orders/a b?a=1&q=x%20y&z=1&a=2", "1700000000", []byte("{}"))
+	assert.Nil(t, err)
+	assert.Equal(t, canonical, same)
+
+	canonical, _ = CanonicalRequest("GET", "https://partner.example", "1", nil)
+	assert.Equal(t, "GET\n/\n\n1\ne3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", canonical)
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	request := Request{Method: "POST", URL: "https://partner.example/v1/orders?page=2", Header: map[string]string{}, Body: []byte(`{"id":1}`)}
+	assert.Nil(t, HMAC{KeyID: "app", Secret: "rahasia"}.Sign(&request, at))
+	assert.Equal(t, "app", request.Header[HeaderKeyID])
+	assert.Equal(t, "1700000000", request.Header[HeaderTimestamp])
+
+	assert.Nil(t, VerifyHMAC("rahasia", request, 5*time.Minute, at))
+	assert.Nil(t, VerifyHMAC("rahasia", request, 5*time.Minute, at.Add(-4*time.Minute)), "the partner's clock runs behind")
+	assert.Nil(t, VerifyHMAC("rahasia", request, 5*time.Minute, at.Add(4*time.Minute)))
+	assert.Equal(t, ErrSignatureExpired, VerifyHMAC("rahasia", request, 5*time.Minute, at.Add(6*time.Minute)))
+	assert.Equal(t, ErrSignatureExpired, VerifyHMAC("rahasia", request, 5*time.Minute, at.Add(-6*time.Minute)))
+	assert.Equal(t, ErrSignatureInvalid, VerifyHMAC("salah", request, 5*time.Minute, at))
+
+	tampered := request
+	tampered.Body = []byte(`{"id":2}`)
+	assert.Equal(t, ErrSignatureInvalid, VerifyHMAC("rahasia", tampered, 5*time.Minute, at))
+	tampered = request
+	tampered.URL = "https://partner.example/v1/orders?page=3"
+	assert.Equal(t, ErrSignatureInvalid, VerifyHMAC("rahasia", tampered, 5*time.Minute, at))
+}
+
+func TestSignsEveryAttempt(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request := Request{Method: r.Method, URL: "http://" + r.Host + r.URL.String(), Body: body, Header: map[string]string{
+			HeaderTimestamp: r.Header.Get(HeaderTimestamp),
+			HeaderSignature: r.Header.Get(HeaderSignature),
+		}}
+		assert.Nil(t, VerifyHMAC("rahasia", request, time.Minute, time.Now()))
+		if attempts++; attempts < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := New(Config{Name: "signed", Signer: HMAC{KeyID: "app", Secret: "rahasia"}})
+	client.sleep = noSleep
+	header := map[string]string{"X-Token": "rahasia"}
+	response, err := client.Do(context.Background(), Request{Method: "PUT", URL: server.URL + "/orders/1?x=y", Header: header, Body: []byte("{}")})
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.Status)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, map[string]string{"X-Token": "rahasia"}, header, "the caller's header is left alone")
+}
@@ -0,0 +1,109 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Bundle holds the message catalogs, keyed by language then message key.
+type Bundle struct {
+	Default  string
+	messages map[string]map[string]string
+}
+
+// NewBundle returns an empty bundle falling back to the given language.
+func NewBundle(fallback string) *Bundle {
+	return &Bundle{Default: fallback, messages: map[string]map[string]string{}}
+}
+
+// Load returns the bundle built from the embedded locales directory.
+func Load(fallback string) (*Bundle, error) {
+	bundle := NewBundle(fallback)
+	return bundle, bundle.LoadFS(locales, "locales")
+}
+
+// LoadFS adds every <lang>.json catalog found in dir.
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("i18n: %s: %w", entry.Name(), err)
+		}
+		b.Add(strings.TrimSuffix(entry.Name(), ".json"), messages)
+	}
+	return nil
+}
+
+// Add merges messages into the catalog for lang.
+func (b *Bundle) Add(lang string, messages map[string]string) {
+	lang = strings.ToLower(lang)
+	if b.messages[lang] == nil {
+		b.messages[lang] = map[string]string{}
+	}
+	for key, message := range messages {
+		b.messages[lang][key] = message
+	}
+}
+
+// Languages lists the languages with a catalog.
+func (b *Bundle) Languages() []string {
+	langs := make([]string, 0, len(b.messages))
+	for lang := range b.messages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Has reports whether lang has a catalog.
+func (b *Bundle) Has(lang string) bool {
+	_, ok := b.messages[strings.ToLower(lang)]
+	return ok
+}
+
+// Messages returns the catalog for lang merged over the default language.
+func (b *Bundle) Messages(lang string) map[string]string {
+	merged := map[string]string{}
+	for key, message := range b.messages[b.Default] {
+		merged[key] = message
+	}
+	for key, message := range b.messages[strings.ToLower(lang)] {
+		merged[key] = message
+	}
+	return merged
+}
+
+// Translate formats the message for key in lang, falling back to the
+// default language and finally to the key itself.
+func (b *Bundle) Translate(lang, key string, args ...interface{}) string {
+	message, ok := b.messages[strings.ToLower(lang)][key]
+	if !ok {
+		message, ok = b.messages[b.Default][key]
+	}
+	if !ok {
+		message = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
@@ -0,0 +1,141 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Locals keys set by the middleware.
+const (
+	LocaleKey   = "locale"
+	MessagesKey = "messages"
+	bundleKey   = "i18n.bundle"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Bundle holds the catalogs.
+	//
+	// Required.
+	Bundle *Bundle
+
+	// QueryKey selects a language explicitly, e.g. ?lang=id.
+	//
+	// Optional. Default: "lang"
+	QueryKey string
+
+	// CookieName remembers the chosen language.
+	//
+	// Optional. Default: "lang"
+	CookieName string
+}
+
+// New creates a middleware resolving the caller's language from the query,
+// then the cookie, then Accept-Language. The language and its messages are
+// stored in Locals so templates can use {{messages.key}}.
+func New(config Config) fiber.Handler {
+	if config.Bundle == nil {
+		panic("i18n: Bundle cannot be nil")
+	}
+	if config.QueryKey == "" {
+		config.QueryKey = "lang"
+	}
+	if config.CookieName == "" {
+		config.CookieName = "lang"
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		if config.Next != nil && config.Next(ctx) {
+			return ctx.Next()
+		}
+
+		lang := detect(ctx, config)
+		ctx.Locals(bundleKey, config.Bundle)
+		ctx.Locals(LocaleKey, lang)
+		ctx.Locals(MessagesKey, config.Bundle.Messages(lang))
+		ctx.Set(fiber.HeaderContentLanguage, lang)
+		ctx.Vary(fiber.HeaderAcceptLanguage)
+		return ctx.Next()
+	}
+}
+
+// T translates key for the language of the current request.
+func T(ctx *fiber.Ctx, key string, args ...interface{}) string {
+	bundle, ok := ctx.Locals(bundleKey).(*Bundle)
+	if !ok {
+		return key
+	}
+	return bundle.Translate(Locale(ctx), key, args...)
+}
+
+// Locale returns the language chosen for the current request.
+func Locale(ctx *fiber.Ctx) string {
+	lang, _ := ctx.Locals(LocaleKey).(string)
+	return lang
+}
+
+// Detect resolves the caller's language the way the middleware does with
+// its default query key and cookie, for code running outside of it.
+func Detect(ctx *fiber.Ctx, bundle *Bundle) string {
+	return detect(ctx, Config{Bundle: bundle, QueryKey: "lang", CookieName: "lang"})
+}
+
+func detect(ctx *fiber.Ctx, config Config) string {
+	bundle := config.Bundle
+	if lang := strings.ToLower(ctx.Query(config.QueryKey)); lang != "" && bundle.Has(lang) {
+		return lang
+	}
+	if lang := strings.ToLower(ctx.Cookies(config.CookieName)); lang != "" && bundle.Has(lang) {
+		return lang
+	}
+	for _, tag := range acceptLanguages(ctx.Get(fiber.HeaderAcceptLanguage)) {
+		if bundle.Has(tag) {
+			return tag
+		}
+		if base, _, found := strings.Cut(tag, "-"); found && bundle.Has(base) {
+			return base
+		}
+	}
+	return bundle.Default
+}
+
+// acceptLanguages returns the language tags of an Accept-Language header,
+// highest quality first.
+func acceptLanguages(header string) []string {
+	type tag struct {
+		name    string
+		quality float64
+	}
+
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if name == "" || name == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if value, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = value
+			}
+		}
+		if quality > 0 {
+			tags = append(tags, tag{strings.ToLower(name), quality})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].quality > tags[j].quality
+	})
+
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.name
+	}
+	return names
+}
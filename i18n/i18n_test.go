@@ -0,0 +1,86 @@
+package i18n
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"belajar-golang-fiber/view"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocaleDetection(t *testing.T) {
+	bundle, err := Load("en")
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	app.Use(New(Config{Bundle: bundle}))
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(T(ctx, "hello_world"))
+	})
+	app.Get("/hello", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(T(ctx, "hello_name", ctx.Query("name", "World")))
+	})
+
+	cases := []struct {
+		path     string
+		accept   string
+		cookie   string
+		expected string
+	}{
+		{"/", "", "", "Hello, World!"},
+		{"/", "id-ID,id;q=0.9,en;q=0.8", "", "Halo, Dunia!"},
+		{"/", "fr;q=0.9,en;q=0.5,id;q=0.1", "", "Hello, World!"},
+		{"/", "en", "id", "Halo, Dunia!"},
+		{"/?lang=en", "id", "id", "Hello, World!"},
+		{"/hello?name=Salman&lang=id", "", "", "Halo, Salman"},
+	}
+
+	for _, c := range cases {
+		request := httptest.NewRequest("GET", c.path, nil)
+		if c.accept != "" {
+			request.Header.Set("Accept-Language", c.accept)
+		}
+		if c.cookie != "" {
+			request.AddCookie(&http.Cookie{Name: "lang", Value: c.cookie})
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+
+		bytes, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, string(bytes), c.path+" "+c.accept)
+	}
+}
+
+func TestLocalizedView(t *testing.T) {
+	bundle, err := Load("en")
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "hello.mustache"), []byte("<h1>{{messages.hello_world}}</h1>"), 0o644))
+	engine, err := view.New(view.Config{Engine: "mustache", Directory: dir})
+	assert.Nil(t, err)
+
+	app := fiber.New(fiber.Config{Views: engine, PassLocalsToViews: true})
+	app.Use(New(Config{Bundle: bundle}))
+	app.Get("/view", func(ctx *fiber.Ctx) error {
+		return ctx.Render("hello", fiber.Map{})
+	})
+
+	request := httptest.NewRequest("GET", "/view?lang=id", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "id", response.Header.Get("Content-Language"))
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "<h1>Halo, Dunia!</h1>", string(bytes))
+}
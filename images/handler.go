@@ -0,0 +1,67 @@
+package images
+
+import (
+	"errors"
+	"io/fs"
+	"strconv"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/middleware/cachecontrol"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler serves the variants of stored images, to those allowed to
+// manage the image, see files.Owns.
+type Handler struct {
+	Processor *Processor
+}
+
+// Register mounts the routes on router, e.g. app.Group("/files").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/:id/:variant", h.variant)
+}
+
+// variant answers 404 with Retry-After while the variant is still being
+// generated.
+func (h *Handler) variant(ctx *fiber.Ctx) error {
+	if _, ok := h.Processor.Variant(ctx.Params("variant")); !ok {
+		return fiber.ErrNotFound
+	}
+	file, err := h.Processor.Files.Repository.Get(ctx.UserContext(), ctx.Params("id"))
+	if errors.Is(err, files.ErrNotFound) || err == nil && (file.Trashed() || !files.Owns(ctx, file)) {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !Supported(file.ContentType) {
+		return fiber.ErrNotFound
+	}
+
+	store := h.Processor.Files.Storage
+	key := Key(file, ctx.Params("variant"))
+	info, err := store.Stat(ctx.UserContext(), key)
+	if errors.Is(err, fs.ErrNotExist) {
+		ctx.Set(fiber.HeaderRetryAfter, "5")
+		return fiber.NewError(fiber.StatusNotFound, "variant is not ready yet")
+	}
+	if err != nil {
+		return err
+	}
+	// Replacing the file regenerates its variants under the same key, so
+	// they are revalidated rather than cached for good.
+	ctx.Set(fiber.HeaderETag, `"`+file.ID+"-"+strconv.Itoa(file.Version)+`"`)
+	cachecontrol.Policy{Private: true, NoCache: true}.Apply(ctx)
+	if ctx.Fresh() {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	content, err := store.Open(ctx.UserContext(), key)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, ContentType(file))
+	return ctx.SendStream(content, int(info.Size()))
+}
@@ -0,0 +1,223 @@
+// Package images derives resized variants, such as thumbnails, from
+// uploaded images. Variants are generated in the background after an
+// upload and stored next to the original; re-encoding drops EXIF data,
+// including camera details and GPS position.
+package images
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/jobs"
+	"belajar-golang-fiber/logger"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// JobKind is the kind of the jobs generating variants.
+const JobKind = "images.variants"
+
+// ErrTooLarge is returned for images over MaxPixels.
+var ErrTooLarge = errors.New("images: image too large")
+
+// Variant is a derived size of an image, fitting inside Width x Height.
+// Crop fills the box exactly instead, cutting off the overflow.
+type Variant struct {
+	Name   string
+	Width  int
+	Height int
+	Crop   bool
+}
+
+// DefaultVariants are a square thumbnail and a web sized copy.
+var DefaultVariants = []Variant{
+	{Name: "thumb", Width: 200, Height: 200, Crop: true},
+	{Name: "web", Width: 1600, Height: 1600},
+}
+
+// Processor generates and locates variants.
+type Processor struct {
+	Files    *files.Service
+	Variants []Variant
+	// MaxPixels refuses larger images before decoding them. Zero means
+	// 50 megapixels.
+	MaxPixels int
+	// Quality of JPEG variants. Zero means 82.
+	Quality int
+}
+
+// NewProcessor uses DefaultVariants.
+func NewProcessor(service *files.Service) *Processor {
+	return &Processor{Files: service, Variants: DefaultVariants}
+}
+
+// Attach enqueues variant generation on queue for every image saved
+// through the file service.
+func (p *Processor) Attach(queue *jobs.Queue) {
+	queue.Handle(JobKind, func(ctx context.Context, job *jobs.Job) error {
+		var payload struct {
+			FileID string `json:"file_id"`
+		}
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return p.Generate(ctx, payload.FileID)
+	})
+	p.Files.AfterSave(func(ctx context.Context, file *files.File) {
+		if !Supported(file.ContentType) {
+			return
+		}
+		if err := queue.Enqueue(ctx, JobKind, map[string]string{"file_id": file.ID}); err != nil {
+			logger.FromContext(ctx).Error("images: enqueue failed", "file_id", file.ID, "error", err)
+		}
+	})
+}
+
+// Supported reports whether variants are made for a content type.
+func Supported(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// Variant returns the variant called name.
+func (p *Processor) Variant(name string) (Variant, bool) {
+	for _, variant := range p.Variants {
+		if variant.Name == name {
+			return variant, true
+		}
+	}
+	return Variant{}, false
+}
+
+// Key is where the variant of file is stored. PNG and GIF sources give
+// PNG variants to keep transparency, JPEG sources give JPEG.
+func Key(file *files.File, variant string) string {
+	return ".variants/" + file.ID + "/" + variant + "." + extension(file.ContentType)
+}
+
+// ContentType of the variants of file.
+func ContentType(file *files.File) string {
+	return "image/" + extension(file.ContentType)
+}
+
+func extension(contentType string) string {
+	if contentType == "image/jpeg" {
+		return "jpeg"
+	}
+	return "png"
+}
+
+// Generate writes every variant of the file with id.
+func (p *Processor) Generate(ctx context.Context, id string) error {
+	file, content, err := p.Files.Open(ctx, id)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(content)
+	content.Close()
+	if err != nil {
+		return err
+	}
+
+	img, err := p.decode(data)
+	if err != nil {
+		return err
+	}
+	if file.ContentType == "image/jpeg" {
+		img = orient(img, orientation(data))
+	}
+
+	for _, variant := range p.Variants {
+		if err := p.store(ctx, file, variant, resize(img, variant)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Processor) decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	maxPixels := p.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = 50_000_000
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// store writes the variant under a temporary name first, so it is never
+// served half written.
+func (p *Processor) store(ctx context.Context, file *files.File, variant Variant, img image.Image) error {
+	key := Key(file, variant.Name)
+	temporary := key + ".tmp"
+	writer, err := p.Files.Storage.Create(ctx, temporary)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(key, ".jpeg") {
+		quality := p.Quality
+		if quality <= 0 {
+			quality = 82
+		}
+		err = jpeg.Encode(writer, img, &jpeg.Options{Quality: quality})
+	} else {
+		err = png.Encode(writer, img)
+	}
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = p.Files.Storage.Rename(ctx, temporary, key)
+	}
+	if err != nil {
+		_ = p.Files.Storage.Remove(ctx, temporary)
+	}
+	return err
+}
+
+// resize scales img into the variant box, never enlarging it.
+func resize(img image.Image, variant Variant) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	if variant.Crop {
+		// Cut the largest centered area with the box's aspect ratio.
+		cw, ch := w, w*variant.Height/variant.Width
+		if ch > h {
+			cw, ch = h*variant.Width/variant.Height, h
+		}
+		x := bounds.Min.X + (w-cw)/2
+		y := bounds.Min.Y + (h-ch)/2
+		bounds = image.Rect(x, y, x+cw, y+ch)
+		w, h = cw, ch
+	}
+
+	scale := min(float64(variant.Width)/float64(w), float64(variant.Height)/float64(h), 1)
+	tw, th := max(int(float64(w)*scale), 1), max(int(float64(h)*scale), 1)
+
+	out := image.NewRGBA(image.Rect(0, 0, tw, th))
+	if tw == w && th == h {
+		draw.Draw(out, out.Bounds(), img, bounds.Min, draw.Src)
+		return out
+	}
+	xdraw.CatmullRom.Scale(out, out.Bounds(), img, bounds, xdraw.Src, nil)
+	return out
+}
@@ -0,0 +1,191 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/jobs"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePNG(w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buffer bytes.Buffer
+	png.Encode(&buffer, img)
+	return buffer.Bytes()
+}
+
+func TestVariants(t *testing.T) {
+	service := files.NewService(storage.NewLocal(t.TempDir()), files.NewMemoryRepository())
+	processor := NewProcessor(service)
+	queue := jobs.NewQueue(10)
+	processor.Attach(queue)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Requests come from user 1 of tenant acme.
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app := fiber.New()
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("tenant", "acme")
+		return ctx.Next()
+	})
+	app.Use(sessions.Middleware())
+	app.Post("/login", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, "1")
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	handler := &Handler{Processor: processor}
+	handler.Register(app.Group("/files"))
+	response, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+	assert.Nil(t, err)
+	token, _ := io.ReadAll(response.Body)
+	send := func(request *http.Request) (*http.Response, error) {
+		request.Header.Set("Authorization", "Bearer "+string(token))
+		return app.Test(request)
+	}
+
+	file, err := service.Save(files.WithOwner(ctx, files.Owner{UserID: "1", Tenant: "acme"}), "foto.png", bytes.NewReader(encodePNG(800, 400)), "upload")
+	assert.Nil(t, err)
+	theirs, err := service.Save(files.WithOwner(ctx, files.Owner{UserID: "1", Tenant: "other"}), "foto.png", bytes.NewReader(encodePNG(80, 40)), "upload")
+	assert.Nil(t, err)
+	ownerless, err := service.Save(files.WithOwner(ctx, files.Owner{Tenant: "acme"}), "foto.png", bytes.NewReader(encodePNG(80, 40)), "email:mailgun")
+	assert.Nil(t, err)
+
+	response, err = send(httptest.NewRequest("GET", "/files/"+file.ID+"/thumb", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Equal(t, "5", response.Header.Get("Retry-After"))
+
+	go queue.Run(ctx, 1)
+	assert.Eventually(t, func() bool {
+		_, err := service.Storage.Stat(ctx, Key(file, "web"))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	response, err = send(httptest.NewRequest("GET", "/files/"+file.ID+"/thumb", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "image/png", response.Header.Get("Content-Type"))
+	assert.Equal(t, "private, no-cache", response.Header.Get("Cache-Control"))
+	thumb, err := png.Decode(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, image.Pt(200, 200), thumb.Bounds().Size())
+
+	request := httptest.NewRequest("GET", "/files/"+file.ID+"/thumb", nil)
+	request.Header.Set("If-None-Match", response.Header.Get("ETag"))
+	response, err = send(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 304, response.StatusCode)
+
+	response, err = send(httptest.NewRequest("GET", "/files/"+file.ID+"/web", nil))
+	assert.Nil(t, err)
+	web, err := png.Decode(response.Body)
+	assert.Nil(t, err)
+	// Smaller than the box, so kept at its size.
+	assert.Equal(t, image.Pt(800, 400), web.Bounds().Size())
+
+	response, err = send(httptest.NewRequest("GET", "/files/"+file.ID+"/huge", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+
+	assert.Eventually(t, func() bool {
+		_, err := service.Storage.Stat(ctx, Key(theirs, "thumb"))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	response, err = send(httptest.NewRequest("GET", "/files/"+theirs.ID+"/thumb", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode, "images of other tenants are not found")
+	assert.Eventually(t, func() bool {
+		_, err := service.Storage.Stat(ctx, Key(ownerless, "thumb"))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	response, err = send(httptest.NewRequest("GET", "/files/"+ownerless.ID+"/thumb", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode, "images without an owner are for admins")
+
+	// Replacing the image revalidates its variants.
+	_, err = service.Replace(ctx, file.ID, bytes.NewReader(encodePNG(400, 800)), nil)
+	assert.Nil(t, err)
+	response, err = send(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	_, err = files.NewTrash(service).Move(ctx, file.ID)
+	assert.Nil(t, err)
+	response, err = send(httptest.NewRequest("GET", "/files/"+file.ID+"/thumb", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode, "trashed images are not served")
+}
+
+// withOrientation inserts an EXIF segment after the SOI marker of a JPEG.
+func withOrientation(data []byte, value uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], value)
+	tiff = append(append(tiff, entry...), 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	header := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(segment)+2))
+
+	out := append([]byte{}, data[:2]...)
+	out = append(append(out, header...), segment...)
+	return append(out, data[2:]...)
+}
+
+func TestOrientation(t *testing.T) {
+	var buffer bytes.Buffer
+	jpeg.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, 40, 20)), nil)
+	data := withOrientation(buffer.Bytes(), 6)
+	assert.Equal(t, 6, orientation(data))
+	assert.Equal(t, 1, orientation(buffer.Bytes()))
+
+	service := files.NewService(storage.NewLocal(t.TempDir()), files.NewMemoryRepository())
+	processor := NewProcessor(service)
+	file, err := service.Save(context.Background(), "foto.jpg", bytes.NewReader(data), "upload")
+	assert.Nil(t, err)
+	assert.Nil(t, processor.Generate(context.Background(), file.ID))
+
+	variant, err := service.Storage.Open(context.Background(), Key(file, "web"))
+	assert.Nil(t, err)
+	defer variant.Close()
+	encoded, _ := io.ReadAll(variant)
+	assert.NotContains(t, string(encoded), "Exif")
+	img, err := jpeg.Decode(bytes.NewReader(encoded))
+	assert.Nil(t, err)
+	// Rotated upright.
+	assert.Equal(t, image.Pt(20, 40), img.Bounds().Size())
+}
+
+func TestOrient(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	rotated := orient(src, 6).(*image.RGBA)
+	assert.Equal(t, image.Pt(2, 3), rotated.Bounds().Size())
+	// The top left corner moves to the top right on a clockwise turn.
+	assert.Equal(t, uint8(255), rotated.RGBAAt(1, 0).R)
+}
@@ -0,0 +1,65 @@
+package inbound
+
+import (
+	"net/mail"
+	"strings"
+
+	"belajar-golang-fiber/files"
+)
+
+// Email is a provider independent view of an inbound message.
+type Email struct {
+	Provider    string        `json:"provider"`
+	From        string        `json:"from"`
+	To          []string      `json:"to"`
+	Subject     string        `json:"subject"`
+	Text        string        `json:"text"`
+	HTML        string        `json:"html"`
+	MessageID   string        `json:"message_id"`
+	InReplyTo   string        `json:"in_reply_to"`
+	Attachments []*files.File `json:"attachments"`
+}
+
+// ReplyTarget finds the first recipient using the reply address scheme
+// reply+<kind>-<id>@domain and returns its kind and id.
+func (e *Email) ReplyTarget() (kind string, id string, ok bool) {
+	for _, to := range e.To {
+		local, _, found := strings.Cut(to, "@")
+		if !found {
+			continue
+		}
+		tag, found := strings.CutPrefix(local, "reply+")
+		if !found {
+			continue
+		}
+		kind, id, found = strings.Cut(tag, "-")
+		if found && kind != "" && id != "" {
+			return kind, id, true
+		}
+	}
+	return "", "", false
+}
+
+func parseAddresses(value string) []string {
+	list, err := mail.ParseAddressList(value)
+	if err != nil {
+		if value = strings.TrimSpace(value); value != "" {
+			return []string{value}
+		}
+		return nil
+	}
+
+	addresses := make([]string, 0, len(list))
+	for _, address := range list {
+		addresses = append(addresses, strings.ToLower(address.Address))
+	}
+	return addresses
+}
+
+func headerValue(headers, name string) string {
+	msg, err := mail.ReadMessage(strings.NewReader(headers + "\r\n\r\n"))
+	if err != nil {
+		return ""
+	}
+	return msg.Header.Get(name)
+}
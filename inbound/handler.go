@@ -0,0 +1,104 @@
+package inbound
+
+import (
+	"context"
+	"crypto/subtle"
+	"mime/multipart"
+	"time"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReplyHandler receives an email addressed to reply+<kind>-<id>@...
+type ReplyHandler func(ctx context.Context, id string, email *Email) error
+
+// Config defines the config for the inbound email handler.
+type Config struct {
+	// Files stores the attachments.
+	Files *files.Service
+
+	// Replies maps a reply kind (e.g. "comment") to its handler. Emails
+	// without a reply address, or with an unknown kind, are only stored.
+	Replies map[string]ReplyHandler
+
+	// MailgunKey verifies Mailgun webhook signatures.
+	MailgunKey string
+
+	// Tolerance is how old, or how far ahead, a Mailgun signature may be.
+	//
+	// Optional. Default: 5 * time.Minute
+	Tolerance time.Duration
+
+	// SendGridToken must be the password of the Basic credentials in the
+	// URL configured in SendGrid (https://sendgrid:<token>@host/...), which
+	// does not sign its posts. Unlike a query parameter, it does not end up
+	// in access logs.
+	SendGridToken string
+}
+
+type parser func(form *multipart.Form) (*Email, []*multipart.FileHeader)
+
+// Handler accepts inbound email webhooks at a route with a :provider param.
+func Handler(cfg Config) fiber.Handler {
+	if cfg.Tolerance <= 0 {
+		cfg.Tolerance = 5 * time.Minute
+	}
+	seen := newTokens()
+
+	return func(ctx *fiber.Ctx) error {
+		form, err := ctx.MultipartForm()
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "expected multipart form")
+		}
+
+		var parse parser
+		switch ctx.Params("provider") {
+		case "sendgrid":
+			if cfg.SendGridToken == "" || subtle.ConstantTimeCompare([]byte(basicPassword(ctx.Get(fiber.HeaderAuthorization))), []byte(cfg.SendGridToken)) != 1 {
+				return fiber.ErrUnauthorized
+			}
+			parse = parseSendGrid
+		case "mailgun":
+			if cfg.MailgunKey == "" || verifyMailgun(form, cfg.MailgunKey, cfg.Tolerance, seen) != nil {
+				return fiber.ErrUnauthorized
+			}
+			parse = parseMailgun
+		default:
+			return fiber.ErrNotFound
+		}
+
+		email, headers := parse(form)
+		for _, header := range headers {
+			file, err := saveAttachment(ctx.UserContext(), cfg.Files, email.Provider, header)
+			if err != nil {
+				return err
+			}
+			email.Attachments = append(email.Attachments, file)
+		}
+
+		if kind, id, ok := email.ReplyTarget(); ok {
+			if handle, exists := cfg.Replies[kind]; exists {
+				if err := handle(ctx.UserContext(), id, email); err != nil {
+					return err
+				}
+			} else {
+				logger.From(ctx).Warn("inbound: no reply handler", "kind", kind)
+			}
+		}
+
+		return ctx.JSON(email)
+	}
+}
+
+func saveAttachment(ctx context.Context, service *files.Service, provider string, header *multipart.FileHeader) (*files.File, error) {
+	reader, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return service.Save(ctx, header.Filename, reader, "email:"+provider)
+}
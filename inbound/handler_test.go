@@ -0,0 +1,115 @@
+package inbound
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(t *testing.T, replies map[string]ReplyHandler) *fiber.App {
+	app := fiber.New()
+	app.Post("/inbound/email/:provider", Handler(Config{
+		Files:         files.NewService(storage.NewLocal(t.TempDir()), files.NewMemoryRepository()),
+		Replies:       replies,
+		MailgunKey:    "key-rahasia",
+		SendGridToken: "rahasia",
+	}))
+	return app
+}
+
+func TestSendGridInbound(t *testing.T) {
+	var repliedTo string
+	app := newApp(t, map[string]ReplyHandler{
+		"comment": func(ctx context.Context, id string, email *Email) error {
+			repliedTo = id
+			return nil
+		},
+	})
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	writer.WriteField("from", "Salman <salman@example.com>")
+	writer.WriteField("to", "reply+comment-42@app.example.com")
+	writer.WriteField("subject", "Re: hello")
+	writer.WriteField("text", "Thanks!")
+	writer.WriteField("headers", "Message-Id: <abc@example.com>\nIn-Reply-To: <xyz@app.example.com>")
+	writer.WriteField("attachments", "1")
+	file, _ := writer.CreateFormFile("attachment1", "contoh.txt")
+	file.Write([]byte("this is sample file for upload"))
+	writer.Close()
+
+	send := func(url, user, password string) *http.Response {
+		request := httptest.NewRequest("POST", url, bytes.NewReader(body.Bytes()))
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		if user != "" {
+			request.SetBasicAuth(user, password)
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+	assert.Equal(t, 401, send("/inbound/email/sendgrid?token=rahasia", "", "").StatusCode, "the token is not taken from the URL")
+	assert.Equal(t, 401, send("/inbound/email/sendgrid", "sendgrid", "salah").StatusCode)
+	response := send("/inbound/email/sendgrid", "sendgrid", "rahasia")
+	assert.Equal(t, 200, response.StatusCode)
+
+	email := new(Email)
+	bytes, _ := io.ReadAll(response.Body)
+	assert.Nil(t, json.Unmarshal(bytes, email))
+	assert.Equal(t, "Re: hello", email.Subject)
+	assert.Equal(t, "<abc@example.com>", email.MessageID)
+	assert.Equal(t, "<xyz@app.example.com>", email.InReplyTo)
+	assert.Len(t, email.Attachments, 1)
+	assert.Equal(t, "email:sendgrid", email.Attachments[0].Source)
+	assert.Equal(t, "42", repliedTo)
+}
+
+func TestMailgunSignature(t *testing.T) {
+	app := newApp(t, nil)
+
+	send := func(timestamp, token, signature string) int {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		writer.WriteField("recipient", "support@app.example.com")
+		writer.WriteField("subject", "Hello")
+		writer.WriteField("timestamp", timestamp)
+		writer.WriteField("token", token)
+		writer.WriteField("signature", signature)
+		writer.Close()
+
+		request := httptest.NewRequest("POST", "/inbound/email/mailgun", body)
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+
+	sign := func(timestamp, token string) string {
+		mac := hmac.New(sha256.New, []byte("key-rahasia"))
+		mac.Write([]byte(timestamp + token))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	assert.Equal(t, 401, send(now, "abc", "00"))
+	assert.Equal(t, 200, send(now, "abc", sign(now, "abc")))
+	assert.Equal(t, 401, send(now, "abc", sign(now, "abc")), "tokens are only accepted once")
+
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	assert.Equal(t, 401, send(old, "def", sign(old, "def")), "stale signatures are refused")
+}
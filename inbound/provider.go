@@ -0,0 +1,137 @@
+package inbound
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"mime/multipart"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errSignature = errors.New("inbound: invalid signature")
+
+// parseSendGrid reads a SendGrid Inbound Parse multipart post.
+func parseSendGrid(form *multipart.Form) (*Email, []*multipart.FileHeader) {
+	headers := formValue(form, "headers")
+	email := &Email{
+		Provider:  "sendgrid",
+		From:      formValue(form, "from"),
+		To:        parseAddresses(formValue(form, "to")),
+		Subject:   formValue(form, "subject"),
+		Text:      formValue(form, "text"),
+		HTML:      formValue(form, "html"),
+		MessageID: headerValue(headers, "Message-Id"),
+		InReplyTo: headerValue(headers, "In-Reply-To"),
+	}
+
+	count, _ := strconv.Atoi(formValue(form, "attachments"))
+	return email, attachments(form, "attachment", "", count)
+}
+
+// parseMailgun reads a Mailgun routes/store() multipart post.
+func parseMailgun(form *multipart.Form) (*Email, []*multipart.FileHeader) {
+	email := &Email{
+		Provider:  "mailgun",
+		From:      formValue(form, "from"),
+		To:        parseAddresses(formValue(form, "recipient")),
+		Subject:   formValue(form, "subject"),
+		Text:      formValue(form, "body-plain"),
+		HTML:      formValue(form, "body-html"),
+		MessageID: formValue(form, "Message-Id"),
+		InReplyTo: formValue(form, "In-Reply-To"),
+	}
+
+	count, _ := strconv.Atoi(formValue(form, "attachment-count"))
+	return email, attachments(form, "attachment", "-", count)
+}
+
+// verifyMailgun checks the HMAC Mailgun adds to every webhook post, that
+// it was signed within tolerance, and that its token was not seen before.
+func verifyMailgun(form *multipart.Form, key string, tolerance time.Duration, seen *tokens) error {
+	timestamp := formValue(form, "timestamp")
+	token := formValue(form, "token")
+	signature, err := hex.DecodeString(formValue(form, "signature"))
+	if err != nil || token == "" {
+		return errSignature
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errSignature
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return errSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + token))
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return errSignature
+	}
+	if !seen.add(token, tolerance) {
+		return errSignature
+	}
+	return nil
+}
+
+// tokens remembers the Mailgun tokens of verified posts for as long as
+// their signatures are fresh, so a captured post cannot be replayed.
+type tokens struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newTokens() *tokens {
+	return &tokens{seen: map[string]time.Time{}}
+}
+
+// add records token, reporting false when it was already seen.
+func (t *tokens) add(token string, tolerance time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for seen, expires := range t.seen {
+		if now.After(expires) {
+			delete(t.seen, seen)
+		}
+	}
+	if _, ok := t.seen[token]; ok {
+		return false
+	}
+	// Twice the tolerance covers timestamps up to tolerance in the future.
+	t.seen[token] = now.Add(2 * tolerance)
+	return true
+}
+
+// basicPassword returns the password of the request's Basic credentials.
+func basicPassword(auth string) string {
+	if len(auth) <= 6 || !strings.EqualFold(auth[:6], "basic ") {
+		return ""
+	}
+	raw, err := base64.StdEncoding.DecodeString(auth[6:])
+	if err != nil {
+		return ""
+	}
+	_, password, _ := strings.Cut(string(raw), ":")
+	return password
+}
+
+func formValue(form *multipart.Form, key string) string {
+	if values := form.Value[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func attachments(form *multipart.Form, prefix, separator string, count int) []*multipart.FileHeader {
+	var list []*multipart.FileHeader
+	for i := 1; i <= count; i++ {
+		list = append(list, form.File[prefix+separator+strconv.Itoa(i)]...)
+	}
+	return list
+}
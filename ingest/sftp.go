@@ -0,0 +1,110 @@
+package ingest
+
+import (
+	"context"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTPSource reads from a directory on a partner's SFTP server. The
+// connection is opened lazily and re-established after failures.
+type SFTPSource struct {
+	Addr     string
+	User     string
+	Password string
+	Dir      string
+	HostKey  ssh.PublicKey
+	Settle   time.Duration
+
+	mu     sync.Mutex
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+func (s *SFTPSource) List(ctx context.Context) ([]string, error) {
+	client, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := client.ReadDir(s.Dir)
+	if err != nil {
+		s.reset()
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-s.Settle)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() || skipped(entry.Name()) || entry.ModTime().After(cutoff) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+func (s *SFTPSource) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	client, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	return client.Open(path.Join(s.Dir, path.Base(name)))
+}
+
+func (s *SFTPSource) Remove(ctx context.Context, name string) error {
+	client, err := s.connect()
+	if err != nil {
+		return err
+	}
+	return client.Remove(path.Join(s.Dir, path.Base(name)))
+}
+
+// Close drops the connection, if any.
+func (s *SFTPSource) Close() error {
+	s.reset()
+	return nil
+}
+
+func (s *SFTPSource) connect() (*sftp.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	conn, err := ssh.Dial("tcp", s.Addr, &ssh.ClientConfig{
+		User:            s.User,
+		Auth:            []ssh.AuthMethod{ssh.Password(s.Password)},
+		HostKeyCallback: ssh.FixedHostKey(s.HostKey),
+		Timeout:         10 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	s.conn, s.client = conn, client
+	return client, nil
+}
+
+func (s *SFTPSource) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil {
+		s.client.Close()
+		s.conn.Close()
+	}
+	s.conn, s.client = nil, nil
+}
@@ -0,0 +1,62 @@
+package ingest
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Source is a drop directory files are collected from.
+type Source interface {
+	List(ctx context.Context) ([]string, error)
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	Remove(ctx context.Context, name string) error
+}
+
+// LocalSource reads from a directory on the local disk.
+type LocalSource struct {
+	Dir string
+
+	// Settle skips files modified more recently than this, so partners
+	// still writing a file are not picked up half way.
+	Settle time.Duration
+}
+
+func (s *LocalSource) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-s.Settle)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || skipped(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+func (s *LocalSource) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.Dir, filepath.Base(name)))
+}
+
+func (s *LocalSource) Remove(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(s.Dir, filepath.Base(name)))
+}
+
+// skipped filters hidden and in-progress files.
+func skipped(name string) bool {
+	return strings.HasPrefix(name, ".") ||
+		strings.HasSuffix(name, ".part") ||
+		strings.HasSuffix(name, ".tmp")
+}
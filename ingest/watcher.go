@@ -0,0 +1,69 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/logger"
+)
+
+// Watcher periodically moves files from a Source into the files subsystem.
+type Watcher struct {
+	Source   Source
+	Files    *files.Service
+	Label    string
+	Interval time.Duration
+}
+
+// Run polls until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.Poll(ctx); err != nil {
+			logger.FromContext(ctx).Error("ingest: poll failed", "source", w.Label, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll ingests every file currently in the source and removes it there.
+// A failing file is left in place to be retried on the next poll.
+func (w *Watcher) Poll(ctx context.Context) (int, error) {
+	names, err := w.Source.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	ingested := 0
+	for _, name := range names {
+		if err := w.ingest(ctx, name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ingested++
+	}
+	return ingested, errors.Join(errs...)
+}
+
+func (w *Watcher) ingest(ctx context.Context, name string) error {
+	reader, err := w.Source.Open(ctx, name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Files.Save(ctx, name, reader, w.Label)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	return w.Source.Remove(ctx, name)
+}
@@ -0,0 +1,49 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcherPoll(t *testing.T) {
+	drop := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(drop, "contoh.txt"), []byte("this is sample file for upload"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(drop, "partial.txt.part"), []byte("still writing"), 0o644))
+
+	repository := files.NewMemoryRepository()
+	service := files.NewService(storage.NewLocal(t.TempDir()), repository)
+	var saved []*files.File
+	service.AfterSave(func(ctx context.Context, file *files.File) {
+		saved = append(saved, file)
+	})
+
+	watcher := &Watcher{
+		Source: &LocalSource{Dir: drop},
+		Files:  service,
+		Label:  "partner",
+	}
+
+	ingested, err := watcher.Poll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, ingested)
+
+	list, err := repository.List(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "contoh.txt", list[0].Name)
+	assert.Equal(t, int64(30), list[0].Size)
+	assert.Equal(t, "partner", list[0].Source)
+	assert.Len(t, saved, 1)
+
+	_, err = os.Stat(filepath.Join(drop, "contoh.txt"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(drop, "partial.txt.part"))
+	assert.Nil(t, err)
+}
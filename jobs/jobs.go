@@ -0,0 +1,166 @@
+// Package jobs runs background work outside the request that caused it.
+// Jobs are kept in process memory, so with prefork each child works off
+// the jobs it enqueued itself, and pending jobs are lost on restart.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/logger"
+	"belajar-golang-fiber/txn"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// ErrUnknownKind is returned when enqueueing a kind nobody handles.
+var ErrUnknownKind = errors.New("jobs: unknown kind")
+
+// stats is published on /debug/vars as "jobs".
+var stats = expvar.NewMap("jobs")
+
+// Job is one unit of work.
+type Job struct {
+	ID       string
+	Kind     string
+	Payload  json.RawMessage
+	Attempts int
+}
+
+// Decode unmarshals the payload into v.
+func (j *Job) Decode(v any) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// HandlerFunc performs a job. A returned error retries the job until
+// MaxAttempts is reached.
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// Queue hands jobs to a fixed number of workers.
+type Queue struct {
+	// MaxAttempts is how often a failing job runs. Zero means 3.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled for each
+	// further one. Zero means one second.
+	Backoff time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+	pending  chan *Job
+	wg       sync.WaitGroup
+}
+
+// NewQueue buffers up to size jobs before Enqueue blocks.
+func NewQueue(size int) *Queue {
+	return &Queue{
+		handlers: map[string]HandlerFunc{},
+		pending:  make(chan *Job, size),
+	}
+}
+
+// Handle registers the handler of a kind of job.
+func (q *Queue) Handle(kind string, handler HandlerFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = handler
+}
+
+// Enqueue schedules a job of kind with payload encoded as JSON. It blocks
+// while the queue is full, until ctx is done. Under a transaction the job
+// is only scheduled once the transaction commits.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) error {
+	q.mu.RLock()
+	_, ok := q.handlers[kind]
+	q.mu.RUnlock()
+	if !ok {
+		return ErrUnknownKind
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	job := &Job{ID: utils.UUIDv4(), Kind: kind, Payload: raw}
+	if txn.From(ctx) != nil {
+		txn.AfterCommit(ctx, func(ctx context.Context) {
+			if err := q.push(ctx, job); err != nil {
+				logger.FromContext(ctx).Error("jobs: job not enqueued", "kind", job.Kind, "job_id", job.ID, "error", err)
+			}
+		})
+		return nil
+	}
+	return q.push(ctx, job)
+}
+
+func (q *Queue) push(ctx context.Context, job *Job) error {
+	select {
+	case q.pending <- job:
+		stats.Add("enqueued", 1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run starts workers and blocks until ctx is done and they finished the
+// jobs they were running.
+func (q *Queue) Run(ctx context.Context, workers int) {
+	if workers <= 0 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-q.pending:
+					q.run(ctx, job)
+				}
+			}
+		}()
+	}
+	q.wg.Wait()
+}
+
+func (q *Queue) run(ctx context.Context, job *Job) {
+	q.mu.RLock()
+	handler := q.handlers[job.Kind]
+	q.mu.RUnlock()
+
+	job.Attempts++
+	err := handler(ctx, job)
+	if err == nil {
+		stats.Add("succeeded", 1)
+		return
+	}
+
+	maxAttempts := q.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	if job.Attempts >= maxAttempts || ctx.Err() != nil {
+		stats.Add("failed", 1)
+		logger.FromContext(ctx).Error("jobs: job failed", "kind", job.Kind, "job_id", job.ID, "attempts", job.Attempts, "error", err)
+		return
+	}
+
+	stats.Add("retried", 1)
+	backoff := q.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	delay := backoff << (job.Attempts - 1)
+	time.AfterFunc(delay, func() {
+		select {
+		case q.pending <- job:
+		case <-ctx.Done():
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueueRetries(t *testing.T) {
+	queue := NewQueue(10)
+	queue.Backoff = time.Millisecond
+
+	var attempts atomic.Int32
+	done := make(chan string, 1)
+	queue.Handle("greet", func(ctx context.Context, job *Job) error {
+		if attempts.Add(1) < 3 {
+			return errors.New("not yet")
+		}
+		var payload struct{ Name string }
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		done <- payload.Name
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go queue.Run(ctx, 2)
+
+	assert.ErrorIs(t, queue.Enqueue(ctx, "unknown", nil), ErrUnknownKind)
+	assert.Nil(t, queue.Enqueue(ctx, "greet", map[string]string{"Name": "salman"}))
+
+	select {
+	case name := <-done:
+		assert.Equal(t, "salman", name)
+		assert.Equal(t, int32(3), attempts.Load())
+	case <-time.After(time.Second):
+		t.Fatal("job did not run")
+	}
+}
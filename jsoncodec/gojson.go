@@ -0,0 +1,13 @@
+//go:build gojson
+
+package jsoncodec
+
+import json "github.com/goccy/go-json"
+
+// Name identifies the implementation compiled in.
+const Name = "github.com/goccy/go-json"
+
+var (
+	Marshal   = json.Marshal
+	Unmarshal = json.Unmarshal
+)
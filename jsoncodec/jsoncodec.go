@@ -0,0 +1,7 @@
+// Package jsoncodec selects the JSON implementation used by the app.
+// encoding/json is the default; building with -tags gojson switches to
+// github.com/goccy/go-json, which is API compatible and faster.
+//
+// Pass Marshal and Unmarshal as fiber.Config JSONEncoder and JSONDecoder
+// so ctx.JSON, BodyParser and binding.Bind share the choice.
+package jsoncodec
@@ -0,0 +1,89 @@
+package jsoncodec
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/dto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func userPage(size int) dto.UserListResponse {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	page := dto.UserListResponse{Page: 1, PerPage: size, Total: size}
+	for i := 0; i < size; i++ {
+		page.Data = append(page.Data, dto.UserResponse{
+			ID:        "0d6c5a8e-6a43-4c1e-9f0e-" + strconv.Itoa(100000000000+i),
+			Username:  "user" + strconv.Itoa(i),
+			Name:      "User Number " + strconv.Itoa(i),
+			Email:     "user" + strconv.Itoa(i) + "@example.com",
+			Phone:     "+628123456" + strconv.Itoa(1000+i),
+			CreatedAt: created,
+			UpdatedAt: created,
+		})
+	}
+	return page
+}
+
+func TestCompatibleWithEncodingJSON(t *testing.T) {
+	page := userPage(3)
+
+	expected, err := json.Marshal(page)
+	assert.Nil(t, err)
+	actual, err := Marshal(page)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(expected), string(actual))
+
+	var decoded dto.UserListResponse
+	assert.Nil(t, Unmarshal(actual, &decoded))
+	assert.Equal(t, page, decoded)
+}
+
+// Run with and without -tags gojson to compare against encoding/json:
+//
+//	go test -bench . -benchmem ./jsoncodec
+//	go test -tags gojson -bench . -benchmem ./jsoncodec
+func BenchmarkMarshalUser(b *testing.B) {
+	user := userPage(1).Data[0]
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(user); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalUserPage(b *testing.B) {
+	page := userPage(100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(page); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalRegisterRequest(b *testing.B) {
+	body := []byte(`{"username":"salman","password":"rahasia","name":"Salman Seif","email":"salman@example.com","phone":"+6281234567890"}`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var request dto.RegisterRequest
+		if err := Unmarshal(body, &request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalUserPage(b *testing.B) {
+	body, _ := json.Marshal(userPage(100))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var page dto.UserListResponse
+		if err := Unmarshal(body, &page); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
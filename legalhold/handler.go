@@ -0,0 +1,88 @@
+package legalhold
+
+import (
+	"errors"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/binding"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler manages holds in the admin area.
+type Handler struct {
+	Service *Service
+}
+
+// Register mounts the routes on router, e.g. adminApp.Group("/legal-holds").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/", h.list)
+	router.Post("/", h.place)
+	router.Get("/:id", h.get)
+	router.Delete("/:id", h.release)
+}
+
+type placeRequest struct {
+	Kind      string `json:"kind" form:"kind"`
+	TargetID  string `json:"target_id" form:"target_id"`
+	Reason    string `json:"reason" form:"reason"`
+	Reference string `json:"reference" form:"reference"`
+}
+
+// list answers the holds in place, optionally of one ?kind= and
+// ?target_id=.
+func (h *Handler) list(ctx *fiber.Ctx) error {
+	holds, err := h.Service.Store.List(ctx.UserContext(), Filter{Kind: ctx.Query("kind"), TargetID: ctx.Query("target_id")})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(holds)
+}
+
+func (h *Handler) place(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[placeRequest](ctx)
+	if err != nil {
+		return err
+	}
+	hold, err := h.Service.Place(ctx.UserContext(), Input{
+		Kind:      request.Kind,
+		TargetID:  request.TargetID,
+		Reason:    request.Reason,
+		Reference: request.Reference,
+		PlacedBy:  audit.Actor(ctx),
+	})
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(hold)
+}
+
+func (h *Handler) get(ctx *fiber.Ctx) error {
+	hold, err := h.Service.Store.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.JSON(hold)
+}
+
+func (h *Handler) release(ctx *fiber.Ctx) error {
+	hold, err := h.Service.Release(ctx.UserContext(), ctx.Params("id"), audit.Actor(ctx))
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.JSON(hold)
+}
+
+func answerError(ctx *fiber.Ctx, err error) error {
+	var invalid *ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": invalid.Error(),
+			"field": invalid.Field,
+		})
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return err
+}
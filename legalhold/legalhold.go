@@ -0,0 +1,182 @@
+// Package legalhold lets admins place legal holds on users and files.
+// Held entities cannot be deleted, nor purged when their retention ends,
+// and held files cannot have their content replaced, until every hold
+// on them is released.
+package legalhold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// ErrNotFound is returned when no hold matches the given id.
+var ErrNotFound = errors.New("legalhold: not found")
+
+// Kinds of entities holds are placed on.
+const (
+	KindUser = "user"
+	KindFile = "file"
+)
+
+// Hold keeps one entity from being deleted or changed, e.g. for one
+// litigation matter. An entity can be under several holds.
+type Hold struct {
+	ID       string `json:"id"`
+	Kind     string `json:"kind"`
+	TargetID string `json:"target_id"`
+	Reason   string `json:"reason"`
+	// Reference is an outside identifier, such as a case number.
+	Reference string    `json:"reference,omitempty"`
+	PlacedBy  string    `json:"placed_by"`
+	PlacedAt  time.Time `json:"placed_at"`
+}
+
+// ValidationError describes input rejected by Place.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Input places a hold.
+type Input struct {
+	Kind      string
+	TargetID  string
+	Reason    string
+	Reference string
+	PlacedBy  string
+}
+
+// Exists reports whether the entity id of a kind exists.
+type Exists func(ctx context.Context, id string) (bool, error)
+
+// Service places and releases holds and answers whether entities are
+// held. Placing, releasing and blocked deletions are recorded in Audit.
+type Service struct {
+	Store Store
+	Audit *audit.Logger
+
+	kinds map[string]Exists
+}
+
+func NewService(store Store, auditLog *audit.Logger) *Service {
+	return &Service{Store: store, Audit: auditLog, kinds: map[string]Exists{}}
+}
+
+// Handle makes entities of kind holdable. Kinds are not safe to add once
+// the service is in use.
+func (s *Service) Handle(kind string, exists Exists) {
+	s.kinds[kind] = exists
+}
+
+// Place puts the entity of input under a new hold.
+func (s *Service) Place(ctx context.Context, input Input) (*Hold, error) {
+	exists, ok := s.kinds[input.Kind]
+	if !ok {
+		return nil, &ValidationError{Field: "kind", Message: "is not holdable"}
+	}
+	reason := strings.TrimSpace(input.Reason)
+	switch {
+	case input.TargetID == "":
+		return nil, &ValidationError{Field: "target_id", Message: "is required"}
+	case reason == "":
+		return nil, &ValidationError{Field: "reason", Message: "is required"}
+	case len(reason) > 1000:
+		return nil, &ValidationError{Field: "reason", Message: "is too long"}
+	}
+	found, err := exists(ctx, input.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &ValidationError{Field: "target_id", Message: "does not exist"}
+	}
+
+	hold := &Hold{
+		ID:        utils.UUIDv4(),
+		Kind:      input.Kind,
+		TargetID:  input.TargetID,
+		Reason:    reason,
+		Reference: strings.TrimSpace(input.Reference),
+		PlacedBy:  input.PlacedBy,
+		PlacedAt:  time.Now().UTC(),
+	}
+	if err := s.Store.Create(ctx, hold); err != nil {
+		return nil, err
+	}
+	s.Audit.Append(ctx, &audit.Event{
+		Action:  audit.ActionHoldPlace,
+		Outcome: audit.OutcomeSuccess,
+		Actor:   input.PlacedBy,
+		Target:  hold.Kind + ":" + hold.TargetID,
+		Details: map[string]string{"hold": hold.ID, "reason": hold.Reason, "reference": hold.Reference},
+	})
+	return hold, nil
+}
+
+// Release removes the hold id; its entity stays held while other holds
+// remain.
+func (s *Service) Release(ctx context.Context, id, releasedBy string) (*Hold, error) {
+	hold, err := s.Store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Store.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	s.Audit.Append(ctx, &audit.Event{
+		Action:  audit.ActionHoldRelease,
+		Outcome: audit.OutcomeSuccess,
+		Actor:   releasedBy,
+		Target:  hold.Kind + ":" + hold.TargetID,
+		Details: map[string]string{"hold": hold.ID, "reference": hold.Reference},
+	})
+	return hold, nil
+}
+
+// Held reports whether the entity id of kind is under any hold.
+func (s *Service) Held(ctx context.Context, kind, id string) (bool, error) {
+	holds, err := s.Store.List(ctx, Filter{Kind: kind, TargetID: id})
+	return len(holds) > 0, err
+}
+
+// FileGuard refuses held files, wrapping files.ErrLocked. It is meant as
+// a files.Trash BeforeRemove and files.Service BeforeSave check.
+func (s *Service) FileGuard(ctx context.Context, file *files.File) error {
+	return s.guard(ctx, KindFile, file.ID, files.ErrLocked)
+}
+
+// UserGuard refuses held users, wrapping user.ErrLocked. It is meant as
+// a user.Service BeforeDelete check.
+func (s *Service) UserGuard(ctx context.Context, held *user.User) error {
+	return s.guard(ctx, KindUser, held.ID, user.ErrLocked)
+}
+
+func (s *Service) guard(ctx context.Context, kind, id string, locked error) error {
+	held, err := s.Held(ctx, kind, id)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return nil
+	}
+	s.Audit.Append(ctx, &audit.Event{
+		Action:  audit.ActionHoldBlocked,
+		Outcome: audit.OutcomeFailure,
+		Actor:   "system",
+		Target:  kind + ":" + id,
+	})
+	return fmt.Errorf("%w: under legal hold", locked)
+}
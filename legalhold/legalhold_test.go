@@ -0,0 +1,109 @@
+package legalhold
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/storage"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func send(t *testing.T, app *fiber.App, method, target, body string) (int, []byte) {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	var raw json.RawMessage
+	json.NewDecoder(response.Body).Decode(&raw)
+	return response.StatusCode, raw
+}
+
+func TestHolds(t *testing.T) {
+	ctx := context.Background()
+	fileService := files.NewService(storage.NewLocal(t.TempDir()), files.NewMemoryRepository())
+	trash := files.NewTrash(fileService)
+	trash.Retention = time.Nanosecond
+	users := user.NewMemoryRepository()
+	userService := user.NewService(users, "ID")
+
+	auditStore := audit.NewMemoryStore()
+	holds := NewService(NewMemoryStore(), audit.NewLogger(auditStore))
+	holds.Handle(KindFile, func(ctx context.Context, id string) (bool, error) {
+		_, err := fileService.Repository.Get(ctx, id)
+		return err == nil, nil
+	})
+	holds.Handle(KindUser, func(ctx context.Context, id string) (bool, error) {
+		_, err := users.Get(ctx, id)
+		return err == nil, nil
+	})
+	fileService.BeforeSave(holds.FileGuard)
+	trash.BeforeRemove(holds.FileGuard)
+	userService.BeforeDelete(holds.UserGuard)
+
+	app := fiber.New()
+	handler := &Handler{Service: holds}
+	handler.Register(app.Group("/legal-holds"))
+
+	held, err := fileService.Save(ctx, "contract.pdf", strings.NewReader("%PDF"), "upload")
+	assert.Nil(t, err)
+	free, err := fileService.Save(ctx, "flyer.pdf", strings.NewReader("%PDF"), "upload")
+	assert.Nil(t, err)
+	member, err := userService.Register(ctx, user.RegisterInput{Username: "budi", Password: "secret123", Email: "budi@example.com"})
+	assert.Nil(t, err)
+
+	status, _ := send(t, app, "POST", "/legal-holds", `{"kind":"file","target_id":"missing","reason":"litigation"}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+	status, _ = send(t, app, "POST", "/legal-holds", `{"kind":"order","target_id":"1","reason":"litigation"}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+	status, body := send(t, app, "POST", "/legal-holds", `{"kind":"file","target_id":"`+held.ID+`","reason":"litigation","reference":"case-42"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	var fileHold Hold
+	assert.Nil(t, json.Unmarshal(body, &fileHold))
+	assert.Equal(t, "case-42", fileHold.Reference)
+	status, _ = send(t, app, "POST", "/legal-holds", `{"kind":"user","target_id":"`+member.ID+`","reason":"litigation"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+
+	status, body = send(t, app, "GET", "/legal-holds?kind=file", "")
+	assert.Equal(t, fiber.StatusOK, status)
+	var listed []Hold
+	assert.Nil(t, json.Unmarshal(body, &listed))
+	assert.Len(t, listed, 1)
+
+	// Held entities are kept; trashing stays possible, as it keeps the
+	// content.
+	_, err = fileService.Replace(ctx, held.ID, strings.NewReader("changed"), nil)
+	assert.ErrorIs(t, err, files.ErrLocked)
+	for _, file := range []*files.File{held, free} {
+		_, err = trash.Move(ctx, file.ID)
+		assert.Nil(t, err)
+	}
+	_, err = trash.Delete(ctx, held.ID)
+	assert.ErrorIs(t, err, files.ErrLocked)
+	purged, err := trash.Purge(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, purged, 1)
+	assert.Equal(t, free.ID, purged[0].ID)
+	assert.True(t, errors.Is(userService.Delete(ctx, member.ID), user.ErrLocked))
+
+	blocked, _, err := auditStore.List(ctx, audit.Filter{Action: audit.ActionHoldBlocked})
+	assert.Nil(t, err)
+	assert.Len(t, blocked, 4)
+
+	// Once released, deletion goes through.
+	status, _ = send(t, app, "DELETE", "/legal-holds/"+fileHold.ID, "")
+	assert.Equal(t, fiber.StatusOK, status)
+	status, _ = send(t, app, "DELETE", "/legal-holds/"+fileHold.ID, "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	_, err = trash.Delete(ctx, held.ID)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,82 @@
+package legalhold
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Filter narrows List; empty fields match every hold.
+type Filter struct {
+	Kind     string
+	TargetID string
+}
+
+func (f Filter) matches(h *Hold) bool {
+	return (f.Kind == "" || h.Kind == f.Kind) &&
+		(f.TargetID == "" || h.TargetID == f.TargetID)
+}
+
+// Store persists holds.
+type Store interface {
+	Create(ctx context.Context, hold *Hold) error
+	Get(ctx context.Context, id string) (*Hold, error)
+	// List returns the holds matching filter, oldest first.
+	List(ctx context.Context, filter Filter) ([]*Hold, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore keeps holds in process memory.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	holds map[string]*Hold
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{holds: map[string]*Hold{}}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, hold *Hold) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *hold
+	s.holds[hold.ID] = &copied
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Hold, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hold, ok := s.holds[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *hold
+	return &copied, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]*Hold, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	holds := []*Hold{}
+	for _, hold := range s.holds {
+		if filter.matches(hold) {
+			copied := *hold
+			holds = append(holds, &copied)
+		}
+	}
+	sort.Slice(holds, func(i, j int) bool {
+		return holds[i].PlacedAt.Before(holds[j].PlacedAt)
+	})
+	return holds, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.holds[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.holds, id)
+	return nil
+}
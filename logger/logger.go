@@ -0,0 +1,101 @@
+// Package logger sets up leveled, structured logging on log/slog and gives
+// every request a child logger carrying its request ID, so whatever a
+// handler or the services it calls log can be correlated.
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"belajar-golang-fiber/middleware/realip"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// LocalsKey is the ctx.Locals key holding the request logger.
+const LocalsKey = "logger"
+
+// Config selects the level and format of a logger.
+type Config struct {
+	// Level is "debug", "info", "warn" or "error". Default: "info"
+	Level string
+	// Format is "json" or "text". Default: "text"
+	Format string
+	// Output receives the records. Default: os.Stderr
+	Output io.Writer
+	// LevelVar, when set, is set to Level and controls the logger from
+	// then on, so the level can be changed while it runs.
+	LevelVar *slog.LevelVar
+}
+
+// New builds a logger from cfg.
+func New(cfg Config) *slog.Logger {
+	output := cfg.Output
+	if output == nil {
+		output = os.Stderr
+	}
+	options := &slog.HandlerOptions{Level: ParseLevel(cfg.Level)}
+	if cfg.LevelVar != nil {
+		cfg.LevelVar.Set(ParseLevel(cfg.Level))
+		options.Level = cfg.LevelVar
+	}
+	if strings.EqualFold(cfg.Format, "json") {
+		return slog.New(slog.NewJSONHandler(output, options))
+	}
+	return slog.New(slog.NewTextHandler(output, options))
+}
+
+// ParseLevel reads a level name, falling back to info.
+func ParseLevel(name string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying logger, for FromContext.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or slog.Default().
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// From returns the logger of the request in ctx, or slog.Default() when
+// Middleware did not run.
+func From(ctx *fiber.Ctx) *slog.Logger {
+	if logger, ok := ctx.Locals(LocalsKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// Middleware hands every request a child of base with its request ID,
+// method and path, under LocalsKey and in ctx.UserContext(). It belongs
+// after the requestid middleware.
+func Middleware(base *slog.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		requestID, _ := ctx.Locals("requestid").(string)
+		child := base.With(
+			slog.String("request_id", utils.CopyString(requestID)),
+			slog.String("method", utils.CopyString(ctx.Method())),
+			slog.String("path", utils.CopyString(ctx.Path())),
+			slog.String("ip", realip.IP(ctx)),
+		)
+		ctx.Locals(LocalsKey, child)
+		ctx.SetUserContext(NewContext(ctx.UserContext(), child))
+		return ctx.Next()
+	}
+}
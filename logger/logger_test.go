@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLogger(t *testing.T) {
+	output := new(bytes.Buffer)
+	base := New(Config{Level: "warn", Format: "json", Output: output})
+
+	app := fiber.New()
+	app.Use(requestid.New(), Middleware(base))
+	app.Get("/users/:id", func(ctx *fiber.Ctx) error {
+		From(ctx).Info("not logged below warn")
+		lookup(ctx.UserContext(), ctx.Params("id"))
+		return ctx.SendString("OK")
+	})
+
+	request := httptest.NewRequest("GET", "/users/42", nil)
+	request.Header.Set(fiber.HeaderXRequestID, "req-1")
+	_, err := app.Test(request)
+	assert.Nil(t, err)
+
+	var record map[string]any
+	assert.Nil(t, json.Unmarshal(output.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "user not found", record["msg"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "GET", record["method"])
+	assert.Equal(t, "/users/42", record["path"])
+	assert.Equal(t, "0.0.0.0", record["ip"])
+	assert.Equal(t, "42", record["user_id"])
+}
+
+// lookup stands in for a service called with the request context.
+func lookup(ctx context.Context, id string) {
+	FromContext(ctx).Warn("user not found", slog.String("user_id", id))
+}
+
+func TestFallsBackToDefault(t *testing.T) {
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+	assert.Equal(t, slog.LevelDebug, ParseLevel("debug"))
+	assert.Equal(t, slog.LevelInfo, ParseLevel("loud"))
+}
@@ -1,39 +1,53 @@
 package main
 
 import (
-	"fmt"
-	"time"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/gofiber/fiber/v2"
+	"belajar-golang-fiber/config"
+	"belajar-golang-fiber/server"
 )
 
 func main() {
-	app := fiber.New(fiber.Config{
-		IdleTimeout:  5 * time.Second,
-		WriteTimeout: 5 * time.Second,
-		ReadTimeout:  5 * time.Second,
-		Prefork:      true,
-	})
-
-	app.Use("/api", func(ctx *fiber.Ctx) error {
-		fmt.Println("Middleware before processing request")
-		err := ctx.Next()
-		fmt.Println("Middleware after processing request")
-		return err
-	})
-
-	app.Get("/", func(ctx *fiber.Ctx) error {
-		return ctx.SendString("Hello, World!")
-	})
-
-	if fiber.IsChild() {
-		fmt.Println("Child process")
-	} else {
-		fmt.Println("Parent process")
+	server.Assets = staticFS(false)
+	srv, err := server.NewServer(config.Load())
+	if err != nil {
+		panic(err)
 	}
 
-	err := app.Listen("localhost:3000")
-	if err != nil {
+	stopped := stopOnSignal(srv)
+	reloadOnSignal(srv)
+	if err := srv.Start(); err != nil {
 		panic(err)
 	}
+	<-stopped
+}
+
+// stopOnSignal stops srv on SIGINT or SIGTERM. The returned channel is
+// closed once it stopped.
+func stopOnSignal(srv *server.Server) <-chan struct{} {
+	stopped := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		if err := srv.Stop(); err != nil {
+			log.Printf("shutdown: %v", err)
+		}
+		close(stopped)
+	}()
+	return stopped
+}
+
+// reloadOnSignal reloads the configuration of srv on SIGHUP.
+func reloadOnSignal(srv *server.Server) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			srv.Reload()
+		}
+	}()
 }
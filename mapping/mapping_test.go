@@ -0,0 +1,64 @@
+package mapping
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterInput(t *testing.T) {
+	input := RegisterInput(dto.RegisterRequest{
+		Username: "salman",
+		Password: "rahasia",
+		Name:     "Salman Seif",
+		Email:    "salman@example.com",
+		Phone:    "08123456789",
+	})
+	assert.Equal(t, user.RegisterInput{
+		Username: "salman",
+		Password: "rahasia",
+		Name:     "Salman Seif",
+		Email:    "salman@example.com",
+		Phone:    "08123456789",
+	}, input)
+
+	assert.Equal(t, user.LoginInput{Username: "salman", Password: "rahasia"},
+		LoginInput(dto.LoginRequest{Username: "salman", Password: "rahasia"}))
+}
+
+func TestUserResponseOmitsPersistenceFields(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	response := UserResponse(&user.User{
+		ID:        "1",
+		Username:  "salman",
+		Password:  []byte("$2a$10$hash"),
+		Version:   3,
+		CreatedAt: created,
+		UpdatedAt: created,
+	})
+
+	body, err := json.Marshal(response)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id":"1","username":"salman","name":"","version":3,"created_at":"2026-01-02T03:04:05Z","updated_at":"2026-01-02T03:04:05Z"}`, string(body))
+
+	assert.Len(t, UserResponses([]*user.User{{ID: "1"}, {ID: "2"}}), 2)
+	assert.Empty(t, UserResponses(nil))
+}
+
+func TestUpdateInputs(t *testing.T) {
+	replace := ReplaceUserInput(dto.ReplaceUserRequest{Username: "salman", Name: "Salman"})
+	assert.Equal(t, "salman", *replace.Username)
+	assert.Equal(t, "", *replace.Email)
+	assert.Nil(t, replace.Password)
+
+	name := "Salman Seif"
+	patch := PatchUserInput(dto.PatchUserRequest{Name: &name})
+	assert.Equal(t, &name, patch.Name)
+	assert.Nil(t, patch.Username)
+	assert.Nil(t, patch.Email)
+}
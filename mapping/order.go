@@ -0,0 +1,50 @@
+package mapping
+
+import (
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/order"
+)
+
+func PlaceOrderInput(request dto.CreateOrderRequest) order.PlaceInput {
+	items := make([]order.Item, 0, len(request.Items))
+	for _, item := range request.Items {
+		items = append(items, order.Item{
+			SKU:       item.SKU,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+	return order.PlaceInput{Currency: request.Currency, Items: items}
+}
+
+func OrderResponse(o *order.Order) dto.OrderResponse {
+	items := make([]dto.OrderItem, 0, len(o.Items))
+	for _, item := range o.Items {
+		items = append(items, dto.OrderItem{
+			SKU:       item.SKU,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+	return dto.OrderResponse{
+		ID:        o.ID,
+		Number:    o.Number,
+		UserID:    o.UserID,
+		Status:    o.Status,
+		Currency:  o.Currency,
+		Items:     items,
+		Total:     o.Total,
+		CreatedAt: o.CreatedAt,
+		UpdatedAt: o.UpdatedAt,
+	}
+}
+
+func OrderResponses(orders []*order.Order) []dto.OrderResponse {
+	responses := make([]dto.OrderResponse, 0, len(orders))
+	for _, o := range orders {
+		responses = append(responses, OrderResponse(o))
+	}
+	return responses
+}
@@ -0,0 +1,87 @@
+package mapping
+
+import (
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/order"
+	"belajar-golang-fiber/rpc/appv1"
+	"belajar-golang-fiber/user"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RegisterRequestMessage is the request of a Protocol Buffers client.
+func RegisterRequestMessage(message *appv1.RegisterUserRequest) dto.RegisterRequest {
+	return dto.RegisterRequest{
+		Username: message.GetUsername(),
+		Password: message.GetPassword(),
+		Name:     message.GetName(),
+		Email:    message.GetEmail(),
+		Phone:    message.GetPhone(),
+	}
+}
+
+// CreateOrderRequestMessage is the request of a Protocol Buffers client.
+// The user and tenant of message are ignored: they come from the route
+// and the request.
+func CreateOrderRequestMessage(message *appv1.PlaceOrderRequest) dto.CreateOrderRequest {
+	items := make([]dto.OrderItem, 0, len(message.GetItems()))
+	for _, item := range message.GetItems() {
+		items = append(items, dto.OrderItem{
+			SKU:       item.GetSku(),
+			Name:      item.GetName(),
+			Quantity:  int(item.GetQuantity()),
+			UnitPrice: item.GetUnitPrice(),
+		})
+	}
+	return dto.CreateOrderRequest{Currency: message.GetCurrency(), Items: items}
+}
+
+func UserMessage(u *user.User) *appv1.User {
+	return &appv1.User{
+		Id:        u.ID,
+		Username:  u.Username,
+		Name:      u.Name,
+		Email:     u.Email,
+		Phone:     u.Phone,
+		CreatedAt: timestamppb.New(u.CreatedAt),
+		UpdatedAt: timestamppb.New(u.UpdatedAt),
+	}
+}
+
+func UserMessages(users []*user.User) []*appv1.User {
+	messages := make([]*appv1.User, 0, len(users))
+	for _, u := range users {
+		messages = append(messages, UserMessage(u))
+	}
+	return messages
+}
+
+func OrderMessage(o *order.Order) *appv1.Order {
+	message := &appv1.Order{
+		Id:        o.ID,
+		Number:    o.Number,
+		UserId:    o.UserID,
+		Status:    o.Status,
+		Currency:  o.Currency,
+		Total:     o.Total,
+		CreatedAt: timestamppb.New(o.CreatedAt),
+		UpdatedAt: timestamppb.New(o.UpdatedAt),
+	}
+	for _, item := range o.Items {
+		message.Items = append(message.Items, &appv1.Item{
+			Sku:       item.SKU,
+			Name:      item.Name,
+			Quantity:  int32(item.Quantity),
+			UnitPrice: item.UnitPrice,
+		})
+	}
+	return message
+}
+
+func OrderMessages(orders []*order.Order) []*appv1.Order {
+	messages := make([]*appv1.Order, 0, len(orders))
+	for _, o := range orders {
+		messages = append(messages, OrderMessage(o))
+	}
+	return messages
+}
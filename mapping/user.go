@@ -0,0 +1,95 @@
+// Package mapping converts between the transport structs in package dto,
+// or the Protocol Buffers messages of rpc/appv1, and the domain models.
+package mapping
+
+import (
+	"belajar-golang-fiber/dto"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/user"
+)
+
+func RegisterInput(request dto.RegisterRequest) user.RegisterInput {
+	return user.RegisterInput{
+		Username: request.Username,
+		Password: request.Password,
+		Name:     request.Name,
+		Email:    request.Email,
+		Phone:    request.Phone,
+	}
+}
+
+func LoginInput(request dto.LoginRequest) user.LoginInput {
+	return user.LoginInput{
+		Username: request.Username,
+		Password: request.Password,
+	}
+}
+
+func ReplaceUserInput(request dto.ReplaceUserRequest) user.UpdateInput {
+	return user.UpdateInput{
+		Username: &request.Username,
+		Name:     &request.Name,
+		Email:    &request.Email,
+		Phone:    &request.Phone,
+	}
+}
+
+func PatchUserInput(request dto.PatchUserRequest) user.UpdateInput {
+	return user.UpdateInput{
+		Username: request.Username,
+		Name:     request.Name,
+		Email:    request.Email,
+		Phone:    request.Phone,
+	}
+}
+
+func ProfileInput(request dto.ProfileRequest) user.UpdateInput {
+	return user.UpdateInput{
+		Name:  request.Name,
+		Email: request.Email,
+		Phone: request.Phone,
+	}
+}
+
+func UserResponse(u *user.User) dto.UserResponse {
+	return dto.UserResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		Name:      u.Name,
+		Email:     u.Email,
+		Phone:     u.Phone,
+		Roles:     u.Roles,
+		LockedAt:  u.LockedAt,
+		Version:   u.Version,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+		DeletedAt: u.DeletedAt,
+
+		PasswordResetRequired: u.PasswordResetRequired,
+	}
+}
+
+func UserResponses(users []*user.User) []dto.UserResponse {
+	responses := make([]dto.UserResponse, 0, len(users))
+	for _, u := range users {
+		responses = append(responses, UserResponse(u))
+	}
+	return responses
+}
+
+// SessionResponses lists sessions, marking the one with id current.
+func SessionResponses(sessions []*session.Session, current string) []dto.SessionResponse {
+	responses := make([]dto.SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		responses = append(responses, dto.SessionResponse{
+			ID:        s.ID,
+			UserAgent: s.UserAgent,
+			IP:        s.IP,
+			Current:   s.ID == current,
+			CreatedAt: s.CreatedAt,
+			LastSeen:  s.LastSeen,
+			ExpiresAt: s.ExpiresAt,
+		})
+	}
+	return responses
+}
@@ -0,0 +1,72 @@
+package adminauth
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderAdminToken carries the static admin token.
+const HeaderAdminToken = "X-Admin-Token"
+
+// RoleAdmin is stored under the "role" local for authenticated requests.
+const RoleAdmin = "admin"
+
+// New creates a middleware that only lets requests through when they carry
+// the admin token or valid basic auth credentials. With neither configured
+// every request is rejected.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		if cfg.Token != "" && equal(token(ctx), cfg.Token) {
+			ctx.Locals("role", RoleAdmin)
+			return ctx.Next()
+		}
+
+		if username, password, ok := basicAuth(ctx); ok {
+			if expected, exists := cfg.Users[username]; exists && equal(password, expected) {
+				ctx.Locals("username", username)
+				ctx.Locals("role", RoleAdmin)
+				return ctx.Next()
+			}
+		}
+
+		ctx.Set(fiber.HeaderWWWAuthenticate, `Basic realm="`+cfg.Realm+`"`)
+		return fiber.ErrUnauthorized
+	}
+}
+
+func token(ctx *fiber.Ctx) string {
+	if value := ctx.Get(HeaderAdminToken); value != "" {
+		return value
+	}
+	auth := ctx.Get(fiber.HeaderAuthorization)
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return auth[7:]
+	}
+	return ""
+}
+
+func basicAuth(ctx *fiber.Ctx) (string, string, bool) {
+	auth := ctx.Get(fiber.HeaderAuthorization)
+	if len(auth) <= 6 || !strings.EqualFold(auth[:6], "basic ") {
+		return "", "", false
+	}
+	raw, err := base64.StdEncoding.DecodeString(auth[6:])
+	if err != nil {
+		return "", "", false
+	}
+	username, password, found := strings.Cut(string(raw), ":")
+	return username, password, found
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
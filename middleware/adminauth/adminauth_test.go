@@ -0,0 +1,43 @@
+package adminauth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAuth(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{
+		Token: "rahasia",
+		Users: map[string]string{"admin": "123"},
+	}))
+	app.Get("/debug", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("OK")
+	})
+
+	request := httptest.NewRequest("GET", "/debug", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	request = httptest.NewRequest("GET", "/debug", nil)
+	request.Header.Set(HeaderAdminToken, "rahasia")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	request = httptest.NewRequest("GET", "/debug", nil)
+	request.SetBasicAuth("admin", "123")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	request = httptest.NewRequest("GET", "/debug", nil)
+	request.SetBasicAuth("admin", "salah")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+}
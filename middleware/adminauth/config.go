@@ -0,0 +1,45 @@
+package adminauth
+
+import "github.com/gofiber/fiber/v2"
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Token is accepted from the X-Admin-Token header or as a Bearer token.
+	//
+	// Optional. Default: ""
+	Token string
+
+	// Users defines basic auth credentials allowed in addition to Token.
+	//
+	// Optional. Default: map[string]string{}
+	Users map[string]string
+
+	// Realm is sent in the WWW-Authenticate header.
+	//
+	// Optional. Default: "Admin"
+	Realm string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Users: map[string]string{},
+	Realm: "Admin",
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.Users == nil {
+		cfg.Users = ConfigDefault.Users
+	}
+	if cfg.Realm == "" {
+		cfg.Realm = ConfigDefault.Realm
+	}
+	return cfg
+}
@@ -0,0 +1,57 @@
+// Package analytics records page views: successful GET requests answered
+// with HTML.
+package analytics
+
+import (
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// PageView is one page served.
+type PageView struct {
+	Path      string
+	Referrer  string
+	UserAgent string
+	Status    int
+	Duration  time.Duration
+}
+
+// New creates a middleware that hands page views to Record once they are
+// served.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+		if ctx.Method() != fiber.MethodGet {
+			return ctx.Next()
+		}
+		start := time.Now()
+		err := ctx.Next()
+		status := ctx.Response().StatusCode()
+		contentType := string(ctx.Response().Header.ContentType())
+		if err != nil || status >= 300 || !strings.HasPrefix(contentType, fiber.MIMETextHTML) {
+			return err
+		}
+		cfg.Record(ctx, PageView{
+			Path:      utils.CopyString(ctx.Path()),
+			Referrer:  utils.CopyString(ctx.Get(fiber.HeaderReferer)),
+			UserAgent: utils.CopyString(ctx.Get(fiber.HeaderUserAgent)),
+			Status:    status,
+			Duration:  time.Since(start),
+		})
+		return nil
+	}
+}
+
+func logView(ctx *fiber.Ctx, view PageView) {
+	logger.FromContext(ctx.UserContext()).Info("analytics: page view",
+		"path", view.Path, "referrer", view.Referrer, "status", view.Status, "duration", view.Duration)
+}
@@ -0,0 +1,42 @@
+package analytics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageViews(t *testing.T) {
+	var views []PageView
+	app := fiber.New()
+	app.Use(New(Config{
+		Next: func(c *fiber.Ctx) bool { return c.Query("optout") != "" },
+		Record: func(ctx *fiber.Ctx, view PageView) {
+			views = append(views, view)
+		},
+	}))
+	app.Get("/page", func(ctx *fiber.Ctx) error {
+		ctx.Type("html")
+		return ctx.SendString("<p>hi</p>")
+	})
+	app.Get("/api", func(ctx *fiber.Ctx) error {
+		return ctx.JSON(fiber.Map{})
+	})
+
+	request := httptest.NewRequest("GET", "/page", nil)
+	request.Header.Set("Referer", "https://example.com/")
+	_, err := app.Test(request)
+	assert.Nil(t, err)
+	// Neither JSON, nor skipped requests, nor errors are page views.
+	for _, target := range []string{"/api", "/page?optout=1", "/missing"} {
+		_, err := app.Test(httptest.NewRequest("GET", target, nil))
+		assert.Nil(t, err)
+	}
+
+	assert.Len(t, views, 1)
+	assert.Equal(t, "/page", views[0].Path)
+	assert.Equal(t, "https://example.com/", views[0].Referrer)
+	assert.Equal(t, 200, views[0].Status)
+}
@@ -0,0 +1,34 @@
+package analytics
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true,
+	// e.g. when the visitor did not consent to analytics.
+	Next func(c *fiber.Ctx) bool
+
+	// Record receives every page view.
+	//
+	// Optional. Default: logs the view
+	Record func(ctx *fiber.Ctx, view PageView)
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Record: logView,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.Record == nil {
+		cfg.Record = ConfigDefault.Record
+	}
+	return cfg
+}
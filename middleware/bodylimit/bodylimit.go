@@ -0,0 +1,85 @@
+// Package bodylimit rejects oversized JSON, XML and form bodies with 413
+// before anything parses them. Multipart bodies are left to the upload
+// handlers, which limit each file on its own.
+package bodylimit
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"belajar-golang-fiber/binding"
+	"belajar-golang-fiber/msgpack"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// New creates a middleware checking the body against the limit for its
+// Content-Type. The declared Content-Length is checked first, so most
+// oversized requests are refused without reading their body; with
+// fiber.Config.StreamRequestBody a body without one is read no further
+// than the limit.
+//
+// Limits apply to the body as sent: a compressed body is measured before
+// it is decompressed.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		limit := cfg.limit(ctx.Get(fiber.HeaderContentType))
+		if limit < 0 {
+			return ctx.Next()
+		}
+
+		request := ctx.Request()
+		stream := request.BodyStream()
+		if request.Header.ContentLength() > limit {
+			return tooLarge(ctx, stream, limit)
+		}
+		if stream != nil {
+			body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "reading body failed")
+			}
+			if len(body) > limit {
+				return tooLarge(ctx, stream, limit)
+			}
+			request.SetBody(body)
+		} else if len(request.Body()) > limit {
+			return tooLarge(ctx, nil, limit)
+		}
+		return ctx.Next()
+	}
+}
+
+// limit returns the limit for contentType, or -1 when it has none.
+func (cfg Config) limit(contentType string) int {
+	contentType = strings.ToLower(contentType)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.TrimSpace(contentType)
+
+	switch {
+	case contentType == fiber.MIMEApplicationJSON || strings.HasSuffix(contentType, "+json") || msgpack.IsMIME(contentType) || binding.IsProtobuf(contentType):
+		return cfg.JSON
+	case contentType == fiber.MIMEApplicationXML || contentType == fiber.MIMETextXML || strings.HasSuffix(contentType, "+xml"):
+		return cfg.XML
+	case contentType == fiber.MIMEApplicationForm:
+		return cfg.Form
+	}
+	return -1
+}
+
+// tooLarge answers 413. A streamed body is still partly unread on the
+// connection, which is therefore closed after the response.
+func tooLarge(ctx *fiber.Ctx, stream io.Reader, limit int) error {
+	if stream != nil {
+		ctx.Context().SetConnectionClose()
+	}
+	return fiber.NewError(fiber.StatusRequestEntityTooLarge, "request body exceeds "+strconv.Itoa(limit)+" bytes")
+}
@@ -0,0 +1,72 @@
+package bodylimit
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(streaming bool) *fiber.App {
+	app := fiber.New(fiber.Config{StreamRequestBody: streaming})
+	app.Use(New(Config{JSON: 16, XML: -1, Form: 8}))
+	app.Post("/", func(ctx *fiber.Ctx) error {
+		return ctx.Send(ctx.Body())
+	})
+	return app
+}
+
+func post(t *testing.T, app *fiber.App, contentType, body string, chunked bool) (int, string) {
+	request := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	request.Header.Set("Content-Type", contentType)
+	if chunked {
+		request.ContentLength = -1
+		request.TransferEncoding = []string{"chunked"}
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	bytes, _ := io.ReadAll(response.Body)
+	return response.StatusCode, string(bytes)
+}
+
+func TestLimitsPerContentType(t *testing.T) {
+	app := newApp(false)
+
+	status, body := post(t, app, "application/json", `{"name":"salman"}`, false)
+	assert.Equal(t, 413, status)
+	assert.Equal(t, "request body exceeds 16 bytes", body)
+
+	status, _ = post(t, app, "application/json", `{"a":1}`, false)
+	assert.Equal(t, 200, status)
+
+	status, _ = post(t, app, "application/problem+json; charset=utf-8", `{"name":"salman"}`, false)
+	assert.Equal(t, 413, status)
+
+	status, _ = post(t, app, "application/x-www-form-urlencoded", "name=salman", false)
+	assert.Equal(t, 413, status)
+
+	// XML is unlimited here, and other types are never checked.
+	status, _ = post(t, app, "application/xml", "<user><name>salman</name></user>", false)
+	assert.Equal(t, 200, status)
+	status, _ = post(t, app, "text/plain", strings.Repeat("x", 100), false)
+	assert.Equal(t, 200, status)
+}
+
+func TestLimitsChunkedAndStreamedBodies(t *testing.T) {
+	for _, streaming := range []bool{false, true} {
+		app := newApp(streaming)
+
+		status, _ := post(t, app, "application/json", strings.Repeat(" ", 4096), false)
+		assert.Equal(t, 413, status)
+
+		status, _ = post(t, app, "application/json", `{"name":"salman","password":"rahasia"}`, true)
+		assert.Equal(t, 413, status)
+
+		status, body := post(t, app, "application/json", `{"a":1}`, true)
+		assert.Equal(t, 200, status)
+		assert.Equal(t, `{"a":1}`, body)
+	}
+}
@@ -0,0 +1,50 @@
+package bodylimit
+
+import "github.com/gofiber/fiber/v2"
+
+// Config defines the config for middleware. A limit below zero turns
+// checking off for that content type.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// JSON caps application/json, +json, MessagePack and Protocol
+	// Buffers bodies, in bytes.
+	//
+	// Optional. Default: 1 MiB
+	JSON int
+
+	// XML caps application/xml, text/xml and +xml bodies, in bytes.
+	//
+	// Optional. Default: 1 MiB
+	XML int
+
+	// Form caps application/x-www-form-urlencoded bodies, in bytes.
+	//
+	// Optional. Default: 64 KiB
+	Form int
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	JSON: 1024 * 1024,
+	XML:  1024 * 1024,
+	Form: 64 * 1024,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+	cfg := config[0]
+	if cfg.JSON == 0 {
+		cfg.JSON = ConfigDefault.JSON
+	}
+	if cfg.XML == 0 {
+		cfg.XML = ConfigDefault.XML
+	}
+	if cfg.Form == 0 {
+		cfg.Form = ConfigDefault.Form
+	}
+	return cfg
+}
@@ -0,0 +1,118 @@
+// Package cachecontrol sets the caching headers of a response together,
+// from one Policy: Cache-Control, a matching Expires for HTTP/1.0 caches,
+// and Vary. New gives a route group a default policy, which handlers
+// override by applying their own.
+package cachecontrol
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Policy is how long, and by whom, a response may be cached.
+type Policy struct {
+	// Private keeps shared caches, such as CDNs, from storing the
+	// response; only the browser of the user may.
+	Private bool
+	// MaxAge is how long the response stays fresh.
+	MaxAge time.Duration
+	// Immutable tells browsers not to revalidate the response while it
+	// is fresh, even on reload.
+	Immutable bool
+	// NoCache lets caches store the response as long as they revalidate
+	// it before every use.
+	NoCache bool
+	// NoStore keeps the response out of every cache.
+	NoStore bool
+	// Vary lists the request headers the response depends on.
+	Vary []string
+}
+
+// Policies of the app.
+var (
+	// NoStore suits API responses, which depend on who asks and when.
+	NoStore = Policy{NoStore: true}
+	// Revalidate suits pages and assets whose URL stays the same when
+	// they change.
+	Revalidate = Policy{NoCache: true}
+	// Immutable suits content that never changes under its URL, such as
+	// hashed assets and stored file variants.
+	Immutable = Policy{MaxAge: 365 * 24 * time.Hour, Immutable: true}
+)
+
+// Public lets any cache keep the response for maxAge.
+func Public(maxAge time.Duration) Policy {
+	return Policy{MaxAge: maxAge}
+}
+
+// String returns the Cache-Control value of p.
+func (p Policy) String() string {
+	if p.NoStore {
+		return "no-store"
+	}
+	var directives []string
+	switch {
+	case p.Private:
+		directives = append(directives, "private")
+	case p.MaxAge > 0 && !p.NoCache:
+		directives = append(directives, "public")
+	}
+	if p.NoCache {
+		directives = append(directives, "no-cache")
+	} else if p.MaxAge > 0 {
+		directives = append(directives, "max-age="+strconv.Itoa(int(p.MaxAge.Seconds())))
+		if p.Immutable {
+			directives = append(directives, "immutable")
+		}
+	}
+	return strings.Join(directives, ", ")
+}
+
+// Apply sets the Cache-Control, Expires and Vary headers of p, replacing
+// the caching headers set before.
+func (p Policy) Apply(c *fiber.Ctx) {
+	c.Set(fiber.HeaderCacheControl, p.String())
+	expires := time.Unix(0, 0)
+	if !p.NoStore && !p.NoCache && p.MaxAge > 0 {
+		expires = time.Now().Add(p.MaxAge)
+	}
+	c.Set(fiber.HeaderExpires, expires.UTC().Format(http.TimeFormat))
+	if len(p.Vary) > 0 {
+		c.Vary(p.Vary...)
+	}
+}
+
+// LastModified sets the Last-Modified header to t, to the second as HTTP
+// dates have it.
+func LastModified(c *fiber.Ctx, t time.Time) {
+	c.Set(fiber.HeaderLastModified, t.UTC().Format(http.TimeFormat))
+}
+
+// New creates a middleware applying Config.Policy to responses whose
+// handler set no Cache-Control. Failed requests are never cached: their
+// responses get NoStore instead.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		err := c.Next()
+		if err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			policy := NoStore
+			policy.Vary = cfg.Policy.Vary
+			policy.Apply(c)
+			return err
+		}
+		if len(c.Response().Header.Peek(fiber.HeaderCacheControl)) == 0 {
+			cfg.Policy.Apply(c)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,57 @@
+package cachecontrol
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyString(t *testing.T) {
+	assert.Equal(t, "no-store", NoStore.String())
+	assert.Equal(t, "no-cache", Revalidate.String())
+	assert.Equal(t, "public, max-age=31536000, immutable", Immutable.String())
+	assert.Equal(t, "public, max-age=3600", Public(time.Hour).String())
+	assert.Equal(t, "private, max-age=60", Policy{Private: true, MaxAge: time.Minute}.String())
+	assert.Equal(t, "private, no-cache", Policy{Private: true, NoCache: true}.String())
+	assert.Equal(t, "no-store", Policy{NoStore: true, MaxAge: time.Hour}.String())
+}
+
+func TestNew(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{Policy: Policy{MaxAge: time.Hour, Vary: []string{fiber.HeaderAcceptEncoding}}}))
+	app.Get("/asset", func(c *fiber.Ctx) error {
+		LastModified(c, time.Date(2026, 1, 2, 3, 4, 5, 6, time.FixedZone("WIB", 7*3600)))
+		return c.SendString("body")
+	})
+	app.Get("/own", func(c *fiber.Ctx) error {
+		Policy{Private: true, NoCache: true}.Apply(c)
+		return c.SendString("mine")
+	})
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		Immutable.Apply(c)
+		return fiber.ErrNotFound
+	})
+
+	response, err := app.Test(httptest.NewRequest("GET", "/asset", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "public, max-age=3600", response.Header.Get(fiber.HeaderCacheControl))
+	expires, err := http.ParseTime(response.Header.Get(fiber.HeaderExpires))
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expires, 2*time.Second)
+	assert.Equal(t, "Thu, 01 Jan 2026 20:04:05 GMT", response.Header.Get(fiber.HeaderLastModified))
+	assert.Equal(t, fiber.HeaderAcceptEncoding, response.Header.Get(fiber.HeaderVary))
+
+	response, err = app.Test(httptest.NewRequest("GET", "/own", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "private, no-cache", response.Header.Get(fiber.HeaderCacheControl), "handlers override the default")
+	assert.Equal(t, "Thu, 01 Jan 1970 00:00:00 GMT", response.Header.Get(fiber.HeaderExpires))
+
+	response, err = app.Test(httptest.NewRequest("GET", "/missing", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Equal(t, "no-store", response.Header.Get(fiber.HeaderCacheControl), "errors are never cached")
+}
@@ -0,0 +1,32 @@
+package cachecontrol
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Policy applies to the responses of the route group.
+	//
+	// Optional. Default: NoStore
+	Policy Policy
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Policy: NoStore,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+	cfg := config[0]
+	if cfg.Policy.String() == "" {
+		cfg.Policy.NoStore = true
+	}
+	return cfg
+}
@@ -0,0 +1,68 @@
+// Package clientguard rejects clients that hold the server up: requests
+// with too many or too large headers get 431, and bodies streamed too
+// slowly, as slowloris-style attacks send them, get 408.
+//
+// Bodies are only watched while streamed, with
+// fiber.Config.StreamRequestBody; buffered bodies, and the first 8 KiB of
+// streamed ones, are read within fiber.Config.ReadTimeout before any
+// handler runs. The server should set ReadTimeout or IdleTimeout, which
+// replace the deadline set for the body once the request is answered.
+package clientguard
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// New creates a middleware guarding the requests of slow or abusive
+// clients.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		header := &c.Request().Header
+		if cfg.MaxHeaders >= 0 && header.Len() > cfg.MaxHeaders {
+			return tooLarge(c, "more than "+strconv.Itoa(cfg.MaxHeaders)+" request headers")
+		}
+		size := len(header.RawHeaders())
+		if size == 0 {
+			size = len(header.Header())
+		}
+		if cfg.MaxHeaderBytes >= 0 && size > cfg.MaxHeaderBytes {
+			return tooLarge(c, "request headers exceed "+strconv.Itoa(cfg.MaxHeaderBytes)+" bytes")
+		}
+
+		// The connection is given until the body arrived at MinBodyRate,
+		// after BodyGrace; a chunked body gets as long as the largest one
+		// allowed. Handlers see the body fail to read past the deadline.
+		conn := c.Context().Conn()
+		if c.Request().BodyStream() == nil || conn == nil || cfg.MinBodyRate < 0 {
+			return c.Next()
+		}
+		length := header.ContentLength()
+		if length < 0 {
+			length = c.App().Config().BodyLimit
+		}
+		deadline := time.Now().Add(cfg.BodyGrace + time.Duration(float64(length)/float64(cfg.MinBodyRate)*float64(time.Second)))
+		conn.SetReadDeadline(deadline)
+		err := c.Next()
+		// The server sets its own deadline for the next request.
+		conn.SetReadDeadline(time.Time{})
+		if time.Now().After(deadline) && (err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest) {
+			c.Context().SetConnectionClose()
+			return fiber.NewError(fiber.StatusRequestTimeout, "request body sent too slowly")
+		}
+		return err
+	}
+}
+
+func tooLarge(c *fiber.Ctx, message string) error {
+	c.Context().SetConnectionClose()
+	return fiber.NewError(fiber.StatusRequestHeaderFieldsTooLarge, message)
+}
@@ -0,0 +1,76 @@
+package clientguard
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaders(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{MaxHeaders: 5, MaxHeaderBytes: 200}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	response, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, response.StatusCode)
+
+	request := httptest.NewRequest("GET", "/", nil)
+	for i := range 6 {
+		request.Header.Set("X-Header-"+strconv.Itoa(i), "1")
+	}
+	response, _ = app.Test(request)
+	assert.Equal(t, fiber.StatusRequestHeaderFieldsTooLarge, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "more than 5 request headers", string(body))
+
+	request = httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("Cookie", strings.Repeat("a", 200))
+	response, _ = app.Test(request)
+	assert.Equal(t, fiber.StatusRequestHeaderFieldsTooLarge, response.StatusCode)
+	body, _ = io.ReadAll(response.Body)
+	assert.Equal(t, "request headers exceed 200 bytes", string(body))
+}
+
+func TestStalledBody(t *testing.T) {
+	app := fiber.New(fiber.Config{StreamRequestBody: true, DisableStartupMessage: true})
+	app.Use(New(Config{MinBodyRate: 1 << 20, BodyGrace: 50 * time.Millisecond}))
+	app.Post("/", func(c *fiber.Ctx) error {
+		if _, err := io.ReadAll(c.Request().BodyStream()); err != nil {
+			return err
+		}
+		return c.SendString("ok")
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go app.Listener(listener)
+	defer app.Shutdown()
+
+	response, err := http.Post("http://"+listener.Addr().String(), "text/plain", strings.NewReader(strings.Repeat("a", 20000)))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, response.StatusCode)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert.Nil(t, err)
+	defer conn.Close()
+	// Handlers get the body once its first 8 KiB arrived.
+	io.WriteString(conn, "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 100000\r\n\r\n"+strings.Repeat("a", 9000))
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	response, err = http.ReadResponse(bufio.NewReader(conn), nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, fiber.StatusRequestTimeout, response.StatusCode)
+		assert.True(t, response.Close)
+	}
+}
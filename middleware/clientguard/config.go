@@ -0,0 +1,66 @@
+package clientguard
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware. A limit below zero turns
+// that check off.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// MaxHeaders caps the number of request headers.
+	//
+	// Optional. Default: 100
+	MaxHeaders int
+
+	// MaxHeaderBytes caps the size of the request headers, in bytes.
+	// fiber.Config.ReadBufferSize bounds the request line and headers
+	// together before this runs.
+	//
+	// Optional. Default: 8 KiB
+	MaxHeaderBytes int
+
+	// MinBodyRate is the average rate, in bytes per second, streamed
+	// bodies must arrive at, on top of BodyGrace.
+	//
+	// Optional. Default: 1 KiB
+	MinBodyRate int
+
+	// BodyGrace is the time streamed bodies get on top of what
+	// MinBodyRate allows them.
+	//
+	// Optional. Default: 10 * time.Second
+	BodyGrace time.Duration
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	MaxHeaders:     100,
+	MaxHeaderBytes: 8 * 1024,
+	MinBodyRate:    1024,
+	BodyGrace:      10 * time.Second,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+	cfg := config[0]
+	if cfg.MaxHeaders == 0 {
+		cfg.MaxHeaders = ConfigDefault.MaxHeaders
+	}
+	if cfg.MaxHeaderBytes == 0 {
+		cfg.MaxHeaderBytes = ConfigDefault.MaxHeaderBytes
+	}
+	if cfg.MinBodyRate == 0 {
+		cfg.MinBodyRate = ConfigDefault.MinBodyRate
+	}
+	if cfg.BodyGrace <= 0 {
+		cfg.BodyGrace = ConfigDefault.BodyGrace
+	}
+	return cfg
+}
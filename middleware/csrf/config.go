@@ -0,0 +1,89 @@
+package csrf
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Modes of the middleware.
+const (
+	// ModeSession expects the token bound to the signed in session, as
+	// rendered in forms, and the double-submit cookie from visitors who
+	// are not signed in.
+	ModeSession = "session"
+	// ModeDoubleSubmit expects the value of the CSRF cookie, which
+	// scripts read and send back in the header.
+	ModeDoubleSubmit = "double-submit"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Mode is ModeSession or ModeDoubleSubmit.
+	//
+	// Optional. Default: ModeSession
+	Mode string
+
+	// Exempt lets requests through unchecked, for those a browser cannot
+	// be tricked into sending.
+	//
+	// Optional. Default: TokenAuthenticated
+	Exempt func(c *fiber.Ctx) bool
+
+	// CookieName is the double-submit cookie. It is readable by scripts.
+	//
+	// Optional. Default: "csrf_token"
+	CookieName string
+
+	// CookieSecure marks the cookie Secure; enable it behind HTTPS.
+	//
+	// Optional. Default: false
+	CookieSecure bool
+
+	// Header carries the token of requests sent by scripts.
+	//
+	// Optional. Default: "X-CSRF-Token"
+	Header string
+
+	// FormField carries the token of form submissions.
+	//
+	// Optional. Default: "_csrf"
+	FormField string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Mode:       ModeSession,
+	Exempt:     TokenAuthenticated,
+	CookieName: "csrf_token",
+	Header:     "X-CSRF-Token",
+	FormField:  "_csrf",
+}
+
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	switch cfg.Mode {
+	case "":
+		cfg.Mode = ConfigDefault.Mode
+	case ModeSession, ModeDoubleSubmit:
+	default:
+		panic("csrf: unknown Mode " + cfg.Mode)
+	}
+	if cfg.Exempt == nil {
+		cfg.Exempt = ConfigDefault.Exempt
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = ConfigDefault.CookieName
+	}
+	if cfg.Header == "" {
+		cfg.Header = ConfigDefault.Header
+	}
+	if cfg.FormField == "" {
+		cfg.FormField = ConfigDefault.FormField
+	}
+	return cfg
+}
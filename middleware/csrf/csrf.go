@@ -0,0 +1,124 @@
+// Package csrf rejects state-changing requests a third-party site could
+// have made a browser send. Safe requests get a token, in Locals under
+// LocalsKey for templates and in a cookie for scripts; the others must
+// send it back in a header or form field. Requests authenticated by a
+// bearer token or API key are exempt: browsers never add those
+// credentials on their own, unlike cookies and Basic credentials.
+package csrf
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// LocalsKey is the ctx.Locals key holding the token to render, as
+// {{csrfField .csrf}}.
+const LocalsKey = "csrf"
+
+// TokenAuthenticated reports whether the request carries a Bearer token
+// or an X-Api-Key header. Basic credentials do not count: browsers send
+// them again on their own once the user entered them.
+func TokenAuthenticated(c *fiber.Ctx) bool {
+	return hasScheme(c, "bearer") || c.Get("X-Api-Key") != ""
+}
+
+// Cookieless reports whether the request carries no ambient credentials
+// to abuse: no cookies, and no Basic credentials from a browser. Those
+// of other clients, e.g. WebDAV ones, which send neither Origin nor
+// Sec-Fetch-Site, are not ambient.
+func Cookieless(c *fiber.Ctx) bool {
+	if len(c.Request().Header.Peek(fiber.HeaderCookie)) != 0 {
+		return false
+	}
+	return !hasScheme(c, "basic") || (c.Get(fiber.HeaderOrigin) == "" && c.Get("Sec-Fetch-Site") == "")
+}
+
+// hasScheme reports whether the Authorization header uses scheme.
+func hasScheme(c *fiber.Ctx, scheme string) bool {
+	found, _, _ := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	return strings.EqualFold(found, scheme)
+}
+
+// Token returns the token of the request, or "" when New did not run.
+func Token(c *fiber.Ctx) string {
+	token, _ := c.Locals(LocalsKey).(string)
+	return token
+}
+
+// New creates a middleware checking the CSRF token of unsafe requests.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		expected := sessionToken(c, cfg)
+		if expected == "" {
+			expected = utils.CopyString(c.Cookies(cfg.CookieName))
+			if expected == "" {
+				expected = newToken()
+				c.Cookie(&fiber.Cookie{
+					Name:     cfg.CookieName,
+					Value:    expected,
+					Path:     "/",
+					Expires:  time.Now().Add(24 * time.Hour),
+					Secure:   cfg.CookieSecure,
+					SameSite: fiber.CookieSameSiteLaxMode,
+				})
+			}
+		}
+		c.Locals(LocalsKey, expected)
+
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace:
+			return c.Next()
+		}
+		if cfg.Exempt(c) {
+			return c.Next()
+		}
+
+		given := c.Get(cfg.Header)
+		if given == "" {
+			given = c.FormValue(cfg.FormField)
+		}
+		if given == "" || subtle.ConstantTimeCompare([]byte(given), []byte(expected)) != 1 {
+			return fiber.NewError(fiber.StatusForbidden, "invalid CSRF token")
+		}
+		return c.Next()
+	}
+}
+
+// sessionToken is the token bound to the session of the request in
+// ModeSession, derived from what only the server knows of it.
+func sessionToken(c *fiber.Ctx, cfg Config) string {
+	if cfg.Mode != ModeSession {
+		return ""
+	}
+	current := session.Current(c)
+	if current == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(current.TokenHash))
+	mac.Write([]byte("csrf"))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newToken() string {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
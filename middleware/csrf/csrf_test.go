@@ -0,0 +1,153 @@
+package csrf
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(config Config) *fiber.App {
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app := fiber.New()
+	app.Use(sessions.Middleware())
+	app.Use(New(config))
+	app.Post("/login", func(c *fiber.Ctx) error {
+		_, _, err := sessions.Issue(c, "42")
+		return err
+	})
+	app.Get("/form", func(c *fiber.Ctx) error {
+		return c.SendString(Token(c))
+	})
+	app.Post("/form", func(c *fiber.Ctx) error {
+		return c.SendString("saved")
+	})
+	return app
+}
+
+func send(t *testing.T, app *fiber.App, request *http.Request, cookies ...*http.Cookie) (*http.Response, string) {
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	return response, string(body)
+}
+
+func cookie(response *http.Response, name string) *http.Cookie {
+	for _, cookie := range response.Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestDoubleSubmit(t *testing.T) {
+	app := newApp(Config{Mode: ModeDoubleSubmit})
+
+	response, token := send(t, app, httptest.NewRequest("GET", "/form", nil))
+	issued := cookie(response, "csrf_token")
+	assert.NotNil(t, issued)
+	assert.Equal(t, token, issued.Value)
+	assert.False(t, issued.HttpOnly, "scripts read the cookie")
+
+	request := httptest.NewRequest("POST", "/form", nil)
+	response, _ = send(t, app, request, issued)
+	assert.Equal(t, 403, response.StatusCode)
+
+	request = httptest.NewRequest("POST", "/form", nil)
+	request.Header.Set("X-CSRF-Token", "forged")
+	response, _ = send(t, app, request, issued)
+	assert.Equal(t, 403, response.StatusCode)
+
+	request = httptest.NewRequest("POST", "/form", nil)
+	request.Header.Set("X-CSRF-Token", token)
+	response, body := send(t, app, request, issued)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "saved", body)
+	assert.Nil(t, cookie(response, "csrf_token"), "the cookie is kept")
+}
+
+func TestSession(t *testing.T) {
+	app := newApp(Config{Exempt: Cookieless})
+
+	response, _ := send(t, app, httptest.NewRequest("POST", "/login", nil))
+	assert.Equal(t, 200, response.StatusCode)
+	var signedIn *http.Cookie
+	for _, c := range response.Cookies() {
+		if c.Name != "csrf_token" {
+			signedIn = c
+		}
+	}
+	assert.NotNil(t, signedIn)
+
+	response, token := send(t, app, httptest.NewRequest("GET", "/form", nil), signedIn)
+	assert.NotEmpty(t, token)
+	assert.Nil(t, cookie(response, "csrf_token"), "signed in users get the session token")
+	_, again := send(t, app, httptest.NewRequest("GET", "/form", nil), signedIn)
+	assert.Equal(t, token, again)
+
+	form := url.Values{"_csrf": {token}}
+	request := httptest.NewRequest("POST", "/form", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", fiber.MIMEApplicationForm)
+	response, _ = send(t, app, request, signedIn)
+	assert.Equal(t, 200, response.StatusCode)
+
+	// A double-submit token is no good once signed in.
+	response, _ = send(t, app, httptest.NewRequest("GET", "/form", nil))
+	anonymous := cookie(response, "csrf_token")
+	request = httptest.NewRequest("POST", "/form", nil)
+	request.Header.Set("X-CSRF-Token", anonymous.Value)
+	response, _ = send(t, app, request, signedIn, anonymous)
+	assert.Equal(t, 403, response.StatusCode)
+}
+
+func TestExempt(t *testing.T) {
+	app := newApp(Config{Mode: ModeDoubleSubmit})
+	consent := &http.Cookie{Name: "consent", Value: "analytics"}
+
+	request := httptest.NewRequest("POST", "/form", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	response, _ := send(t, app, request, consent)
+	assert.Equal(t, 200, response.StatusCode)
+
+	request = httptest.NewRequest("POST", "/form", nil)
+	request.Header.Set("X-Api-Key", "secret")
+	response, _ = send(t, app, request, consent)
+	assert.Equal(t, 200, response.StatusCode)
+
+	// Browsers send Basic credentials again on their own.
+	request = httptest.NewRequest("POST", "/form", nil)
+	request.SetBasicAuth("admin", "secret")
+	response, _ = send(t, app, request, consent)
+	assert.Equal(t, 403, response.StatusCode)
+
+	app = newApp(Config{Mode: ModeDoubleSubmit, Exempt: Cookieless})
+	response, _ = send(t, app, httptest.NewRequest("POST", "/form", nil))
+	assert.Equal(t, 200, response.StatusCode)
+	response, _ = send(t, app, httptest.NewRequest("POST", "/form", nil), consent)
+	assert.Equal(t, 403, response.StatusCode)
+
+	request = httptest.NewRequest("PUT", "/form", nil)
+	request.SetBasicAuth("admin", "secret")
+	response, _ = send(t, app, request)
+	assert.Equal(t, 405, response.StatusCode, "Basic credentials of clients other than browsers are not ambient")
+	request = httptest.NewRequest("POST", "/form", nil)
+	request.SetBasicAuth("admin", "secret")
+	request.Header.Set("Sec-Fetch-Site", "cross-site")
+	response, _ = send(t, app, request)
+	assert.Equal(t, 403, response.StatusCode)
+}
+
+func TestUnknownMode(t *testing.T) {
+	assert.Panics(t, func() { New(Config{Mode: "cookie"}) })
+}
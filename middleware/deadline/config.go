@@ -0,0 +1,52 @@
+package deadline
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Timeout is how long downstream calls of a request may take in
+	// total. Zero leaves the context without a deadline.
+	//
+	// Optional. Default: 0
+	Timeout time.Duration
+
+	// Status answers requests whose handler failed after Timeout ran
+	// out: 504 when the time went to downstream calls, or 503 to have
+	// clients treat the route as overloaded.
+	//
+	// Optional. Default: fiber.StatusGatewayTimeout
+	Status int
+
+	// PollInterval is how often the connection is checked for a client
+	// that went away. Negative turns the check off.
+	//
+	// Optional. Default: 250 * time.Millisecond
+	PollInterval time.Duration
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Status:       fiber.StatusGatewayTimeout,
+	PollInterval: 250 * time.Millisecond,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+	cfg := config[0]
+	if cfg.Status == 0 {
+		cfg.Status = ConfigDefault.Status
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = ConfigDefault.PollInterval
+	}
+	return cfg
+}
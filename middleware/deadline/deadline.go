@@ -0,0 +1,114 @@
+// Package deadline gives every request a context that ends at the route's
+// timeout or as soon as the client disconnects. Handlers pass
+// ctx.UserContext() to repositories and outbound clients, which then stop
+// working for requests nobody waits for any more.
+package deadline
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrClientGone is the cause of a request context cancelled because the
+// client closed its connection.
+var ErrClientGone = errors.New("deadline: client disconnected")
+
+// New creates a middleware replacing ctx.UserContext() with a child that
+// is cancelled after Timeout, when the client disconnects, or when the
+// handler returns. A handler failing once Timeout has run out is answered
+// with Status instead of its own error.
+//
+// Handlers cannot be interrupted: they end early only by passing
+// ctx.UserContext() downstream and returning the error they get back.
+// Clients without context support can be given Remaining as their
+// timeout. Work outliving the handler, such as a body stream writer, must
+// detach from the context with context.WithoutCancel.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		requestCtx, cancel := context.WithCancelCause(ctx.UserContext())
+		defer cancel(context.Canceled)
+		if cfg.Timeout > 0 {
+			var cancelTimeout context.CancelFunc
+			requestCtx, cancelTimeout = context.WithTimeout(requestCtx, cfg.Timeout)
+			defer cancelTimeout()
+		}
+		if cfg.PollInterval > 0 {
+			stop := watch(ctx.Context().Conn(), cfg.PollInterval, func() { cancel(ErrClientGone) })
+			defer stop()
+		}
+
+		ctx.SetUserContext(requestCtx)
+		err := ctx.Next()
+		if err != nil && cfg.Timeout > 0 && errors.Is(requestCtx.Err(), context.DeadlineExceeded) {
+			return fiber.NewError(cfg.Status, "request timed out after "+cfg.Timeout.String())
+		}
+		return err
+	}
+}
+
+// Remaining returns the time left until the deadline of ctx, or fallback
+// when it has none. It is never negative.
+func Remaining(ctx context.Context, fallback time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return fallback
+	}
+	return max(time.Until(deadline), 0)
+}
+
+// ClientGone reports whether ctx ended because the client disconnected.
+func ClientGone(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrClientGone)
+}
+
+// watch calls gone once conn is found closed by the peer, checking every
+// interval until stop is called. stop returns once checking has ended.
+func watch(conn net.Conn, interval time.Duration, gone func()) (stop func()) {
+	if !canPeek(conn) {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if peerClosed(conn) {
+				gone()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// rawConn unwraps TLS connections to the socket underneath.
+func rawConn(conn net.Conn) net.Conn {
+	for {
+		wrapper, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			return conn
+		}
+		conn = wrapper.NetConn()
+	}
+}
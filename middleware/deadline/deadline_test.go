@@ -0,0 +1,122 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutEndsRequestContext(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{Timeout: 20 * time.Millisecond}))
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		select {
+		case <-ctx.UserContext().Done():
+			return ctx.SendString(ctx.UserContext().Err().Error())
+		case <-time.After(time.Second):
+			return ctx.SendString("finished")
+		}
+	})
+
+	response, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Nil(t, err)
+	body := make([]byte, 64)
+	n, _ := response.Body.Read(body)
+	assert.Equal(t, context.DeadlineExceeded.Error(), string(body[:n]))
+}
+
+// slowCall stands in for a downstream call honouring its context.
+func slowCall(ctx context.Context, took time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(took):
+		return nil
+	}
+}
+
+func TestTimedOutHandlerAnswersStatus(t *testing.T) {
+	app := fiber.New()
+	api := app.Group("/api", New(Config{Timeout: time.Second}))
+	api.Get("/fast", func(ctx *fiber.Ctx) error {
+		if err := slowCall(ctx.UserContext(), time.Millisecond); err != nil {
+			return err
+		}
+		return ctx.SendString("OK")
+	})
+	slow := api.Group("/slow", New(Config{Timeout: 20 * time.Millisecond}))
+	slow.Get("/", func(ctx *fiber.Ctx) error {
+		return slowCall(ctx.UserContext(), time.Second)
+	})
+	shed := api.Group("/shed", New(Config{Timeout: 20 * time.Millisecond, Status: fiber.StatusServiceUnavailable}))
+	shed.Get("/", func(ctx *fiber.Ctx) error {
+		assert.Less(t, Remaining(ctx.UserContext(), time.Hour), 21*time.Millisecond)
+		return slowCall(ctx.UserContext(), time.Second)
+	})
+
+	response, err := app.Test(httptest.NewRequest("GET", "/api/fast", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	started := time.Now()
+	response, err = app.Test(httptest.NewRequest("GET", "/api/slow", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 504, response.StatusCode)
+	assert.Less(t, time.Since(started), 500*time.Millisecond)
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "request timed out after 20ms", string(body))
+
+	response, err = app.Test(httptest.NewRequest("GET", "/api/shed", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 503, response.StatusCode)
+
+	assert.Equal(t, time.Minute, Remaining(context.Background(), time.Minute))
+}
+
+func TestDisconnectCancelsRequestContext(t *testing.T) {
+	cause := make(chan error, 1)
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(New(Config{PollInterval: 10 * time.Millisecond}))
+	app.Get("/slow", func(ctx *fiber.Ctx) error {
+		select {
+		case <-ctx.UserContext().Done():
+			cause <- context.Cause(ctx.UserContext())
+		case <-time.After(2 * time.Second):
+			cause <- nil
+		}
+		return nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go app.Listener(listener)
+	defer app.Shutdown()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert.Nil(t, err)
+	_, err = conn.Write([]byte("GET /slow HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	assert.Nil(t, err)
+	time.Sleep(50 * time.Millisecond)
+	conn.Close()
+
+	select {
+	case err := <-cause:
+		assert.True(t, errors.Is(err, ErrClientGone))
+	case <-time.After(3 * time.Second):
+		t.Fatal("handler did not finish")
+	}
+}
+
+func TestClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	assert.False(t, ClientGone(ctx))
+	cancel(ErrClientGone)
+	assert.True(t, ClientGone(ctx))
+}
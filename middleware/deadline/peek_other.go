@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package deadline
+
+import "net"
+
+// Disconnects are not detected on this platform; requests still end at
+// their timeout.
+func canPeek(conn net.Conn) bool {
+	return false
+}
+
+func peerClosed(conn net.Conn) bool {
+	return false
+}
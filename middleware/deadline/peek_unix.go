@@ -0,0 +1,44 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package deadline
+
+import (
+	"net"
+	"syscall"
+)
+
+func canPeek(conn net.Conn) bool {
+	_, ok := rawConn(conn).(syscall.Conn)
+	return ok
+}
+
+// peerClosed peeks at the socket without blocking or consuming anything:
+// a read of zero bytes means the peer closed the connection, while no
+// data, or data of a pipelined request, means it is still there. A client
+// that only shuts down its sending side looks closed too.
+func peerClosed(conn net.Conn) bool {
+	sc, ok := rawConn(conn).(syscall.Conn)
+	if !ok {
+		return false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	closed := false
+	buf := make([]byte, 1)
+	err = raw.Read(func(fd uintptr) bool {
+		n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		switch {
+		case err == syscall.EAGAIN || err == syscall.EWOULDBLOCK || err == syscall.EINTR:
+		case err != nil:
+			closed = true
+		case n == 0:
+			closed = true
+		}
+		// Never wait for the socket to become readable.
+		return true
+	})
+	return closed || err != nil
+}
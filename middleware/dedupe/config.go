@@ -0,0 +1,67 @@
+package dedupe
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Policy decides how a repeated submission is answered.
+type Policy int
+
+const (
+	// PolicyReplay answers 200 with the body of the original response.
+	PolicyReplay Policy = iota
+	// PolicyConflict answers 409 with the body of the original response.
+	PolicyConflict
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Store keeps the fingerprints of recent submissions.
+	//
+	// Optional. Default: NewMemoryStore()
+	Store Store
+
+	// Window is how long a submission counts as a duplicate.
+	//
+	// Optional. Default: 10 * time.Minute
+	Window time.Duration
+
+	// Policy selects the answer to duplicates.
+	//
+	// Optional. Default: PolicyReplay
+	Policy Policy
+
+	// Identity returns who submitted the request; the same content from
+	// different submitters is not a duplicate.
+	//
+	// Optional. Default: the signed-in user, else the client IP
+	Identity func(c *fiber.Ctx) string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Window: 10 * time.Minute,
+	Policy: PolicyReplay,
+}
+
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = ConfigDefault.Window
+	}
+	if cfg.Identity == nil {
+		cfg.Identity = defaultIdentity
+	}
+	return cfg
+}
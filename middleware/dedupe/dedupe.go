@@ -0,0 +1,115 @@
+// Package dedupe detects repeated form and API submissions by hashing
+// their content, catching double clicks and client retries that do not
+// send an idempotency key.
+package dedupe
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"belajar-golang-fiber/middleware/realip"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderDuplicate is set on answers to duplicate submissions.
+const HeaderDuplicate = "X-Duplicate-Submission"
+
+// New creates a middleware that answers a POST, PUT or PATCH whose
+// submitter, route and content match a successful request within the
+// window with that request's response, according to Policy. A duplicate
+// arriving while the original is still being handled gets 409.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+		switch ctx.Method() {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch:
+		default:
+			return ctx.Next()
+		}
+
+		key := fingerprint(ctx, cfg.Identity(ctx))
+		reserved, original := cfg.Store.Reserve(key, cfg.Window)
+		if !reserved {
+			ctx.Set(HeaderDuplicate, "true")
+			if original == nil {
+				return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "duplicate submission in progress",
+				})
+			}
+			return replay(ctx, cfg.Policy, original)
+		}
+
+		err := ctx.Next()
+		status := ctx.Response().StatusCode()
+		if err != nil || status < 200 || status >= 300 {
+			cfg.Store.Release(key)
+			return err
+		}
+		cfg.Store.Complete(key, Response{
+			Status:      status,
+			ContentType: string(ctx.Response().Header.ContentType()),
+			Location:    string(ctx.Response().Header.Peek(fiber.HeaderLocation)),
+			Body:        append([]byte(nil), ctx.Response().Body()...),
+		}, cfg.Window)
+		return nil
+	}
+}
+
+func replay(ctx *fiber.Ctx, policy Policy, original *Response) error {
+	status := fiber.StatusOK
+	if policy == PolicyConflict {
+		status = fiber.StatusConflict
+	}
+	if original.ContentType != "" {
+		ctx.Set(fiber.HeaderContentType, original.ContentType)
+	}
+	if original.Location != "" {
+		ctx.Set(fiber.HeaderLocation, original.Location)
+	}
+	return ctx.Status(status).Send(original.Body)
+}
+
+var compactBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// fingerprint hashes who sent what to which route. JSON bodies are
+// compacted first so whitespace does not matter.
+func fingerprint(ctx *fiber.Ctx, identity string) string {
+	body := ctx.Body()
+	if strings.HasPrefix(string(ctx.Request().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		compacted := compactBuffers.Get().(*bytes.Buffer)
+		defer func() {
+			compacted.Reset()
+			compactBuffers.Put(compacted)
+		}()
+		if json.Compact(compacted, body) == nil {
+			body = compacted.Bytes()
+		}
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{identity, ctx.Method(), ctx.Path(), string(ctx.Request().URI().QueryString())} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func defaultIdentity(ctx *fiber.Ctx) string {
+	if id := session.UserID(ctx); id != "" {
+		return "user:" + id
+	}
+	return "ip:" + realip.IP(ctx)
+}
@@ -0,0 +1,78 @@
+package dedupe
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(policy Policy) (*fiber.App, *int) {
+	created := 0
+	app := fiber.New()
+	app.Post("/orders", New(Config{Policy: policy}), func(ctx *fiber.Ctx) error {
+		if strings.Contains(string(ctx.Body()), "fail") {
+			return fiber.ErrBadRequest
+		}
+		created++
+		ctx.Location("/orders/" + strconv.Itoa(created))
+		return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"id": created})
+	})
+	return app, &created
+}
+
+func post(t *testing.T, app *fiber.App, body string) (int, string, *httpHeaders) {
+	request := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	bytes, _ := io.ReadAll(response.Body)
+	return response.StatusCode, string(bytes), &httpHeaders{
+		duplicate: response.Header.Get(HeaderDuplicate),
+		location:  response.Header.Get("Location"),
+	}
+}
+
+type httpHeaders struct {
+	duplicate string
+	location  string
+}
+
+func TestReplay(t *testing.T) {
+	app, created := newApp(PolicyReplay)
+
+	status, body, headers := post(t, app, `{"sku":"BK-1","quantity":1}`)
+	assert.Equal(t, 201, status)
+	assert.Equal(t, `{"id":1}`, body)
+	assert.Empty(t, headers.duplicate)
+
+	status, body, headers = post(t, app, `{ "sku": "BK-1", "quantity": 1 }`)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, `{"id":1}`, body)
+	assert.Equal(t, "true", headers.duplicate)
+	assert.Equal(t, "/orders/1", headers.location)
+	assert.Equal(t, 1, *created)
+
+	status, _, _ = post(t, app, `{"sku":"BK-1","quantity":2}`)
+	assert.Equal(t, 201, status)
+	assert.Equal(t, 2, *created)
+}
+
+func TestConflictAndFailures(t *testing.T) {
+	app, created := newApp(PolicyConflict)
+
+	status, _, _ := post(t, app, `{"sku":"fail"}`)
+	assert.Equal(t, 400, status)
+	status, _, _ = post(t, app, `{"sku":"fail"}`)
+	assert.Equal(t, 400, status)
+
+	post(t, app, `{"sku":"BK-1"}`)
+	status, body, _ := post(t, app, `{"sku":"BK-1"}`)
+	assert.Equal(t, 409, status)
+	assert.Equal(t, `{"id":1}`, body)
+	assert.Equal(t, 1, *created)
+}
@@ -0,0 +1,72 @@
+package dedupe
+
+import (
+	"sync"
+	"time"
+)
+
+// Response is the stored answer to the first submission.
+type Response struct {
+	Status      int
+	ContentType string
+	Location    string
+	Body        []byte
+}
+
+// Store remembers submissions by fingerprint.
+type Store interface {
+	// Reserve marks key as in flight until ttl, returning false with the
+	// stored response (nil while still in flight) when key is known.
+	Reserve(key string, ttl time.Duration) (bool, *Response)
+	// Complete stores the response for a reserved key.
+	Complete(key string, response Response, ttl time.Duration)
+	// Release forgets a reserved key, e.g. after a failed request.
+	Release(key string)
+}
+
+type entry struct {
+	response *Response
+	expires  time.Time
+}
+
+// MemoryStore is an in-process Store; expired entries are dropped lazily.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]entry{}, now: time.Now}
+}
+
+func (s *MemoryStore) Reserve(key string, ttl time.Duration) (bool, *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+	if existing, ok := s.entries[key]; ok {
+		return false, existing.response
+	}
+	s.entries[key] = entry{expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryStore) Complete(key string, response Response, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = entry{response: &response, expires: s.now().Add(ttl)}
+}
+
+func (s *MemoryStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+}
@@ -0,0 +1,54 @@
+package https
+
+import "github.com/gofiber/fiber/v2"
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Redirect sends plain HTTP requests to the HTTPS URL.
+	//
+	// Optional. Default: true
+	Redirect bool
+
+	// RedirectCode is the status used for redirects.
+	//
+	// Optional. Default: 308
+	RedirectCode int
+
+	// HSTSMaxAge is the Strict-Transport-Security max-age in seconds;
+	// 0 disables the header.
+	//
+	// Optional. Default: 31536000
+	HSTSMaxAge int
+
+	// HSTSIncludeSubdomains adds includeSubDomains to the header.
+	//
+	// Optional. Default: false
+	HSTSIncludeSubdomains bool
+
+	// HSTSPreload adds preload to the header.
+	//
+	// Optional. Default: false
+	HSTSPreload bool
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Redirect:     true,
+	RedirectCode: fiber.StatusPermanentRedirect,
+	HSTSMaxAge:   31536000,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.RedirectCode == 0 {
+		cfg.RedirectCode = ConfigDefault.RedirectCode
+	}
+	return cfg
+}
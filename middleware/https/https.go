@@ -0,0 +1,64 @@
+package https
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// acmeChallengePrefix must stay reachable over plain HTTP.
+const acmeChallengePrefix = "/.well-known/acme-challenge/"
+
+// New creates a middleware that redirects plain HTTP requests to HTTPS and
+// adds the Strict-Transport-Security header to secure responses. Behind a
+// TLS terminating proxy the scheme is taken from X-Forwarded-Proto.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(cfg.HSTSMaxAge)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		if ctx.Protocol() != "https" {
+			if cfg.Redirect && !strings.HasPrefix(ctx.Path(), acmeChallengePrefix) {
+				return ctx.Redirect("https://"+stripPort(ctx.Hostname())+string(ctx.Request().URI().RequestURI()), cfg.RedirectCode)
+			}
+			return ctx.Next()
+		}
+
+		if hsts != "" {
+			ctx.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+		return ctx.Next()
+	}
+}
+
+// RedirectHandler is a net/http handler for the plain HTTP port that sends
+// every request to the same URL over HTTPS.
+func RedirectHandler(code int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://"+stripPort(r.Host)+r.URL.RequestURI(), code)
+	})
+}
+
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
@@ -0,0 +1,43 @@
+package https
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectAndHSTS(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{Redirect: true, HSTSMaxAge: 600, HSTSIncludeSubdomains: true}))
+	app.Get("/*", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("OK")
+	})
+
+	request := httptest.NewRequest("GET", "http://example.com/hello?name=Salman", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 308, response.StatusCode)
+	assert.Equal(t, "https://example.com/hello?name=Salman", response.Header.Get("Location"))
+
+	request = httptest.NewRequest("GET", "http://example.com/.well-known/acme-challenge/token", nil)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Empty(t, response.Header.Get("Strict-Transport-Security"))
+
+	request = httptest.NewRequest("GET", "http://example.com/hello", nil)
+	request.Header.Set("X-Forwarded-Proto", "https")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "max-age=600; includeSubDomains", response.Header.Get("Strict-Transport-Security"))
+}
+
+func TestRedirectHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	RedirectHandler(308).ServeHTTP(recorder, httptest.NewRequest("GET", "http://example.com:80/a?b=c", nil))
+	assert.Equal(t, 308, recorder.Code)
+	assert.Equal(t, "https://example.com/a?b=c", recorder.Header().Get("Location"))
+}
@@ -0,0 +1,84 @@
+package idempotency
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Store keeps the responses by key.
+	//
+	// Optional. Default: NewMemoryStore()
+	Store Store
+
+	// Header is the request header carrying the key.
+	//
+	// Optional. Default: "Idempotency-Key"
+	Header string
+
+	// TTL is how long a response is replayed.
+	//
+	// Optional. Default: 24 * time.Hour
+	TTL time.Duration
+
+	// LockTTL is how long a key stays claimed by a request still being
+	// handled, in case the instance handling it dies.
+	//
+	// Optional. Default: time.Minute
+	LockTTL time.Duration
+
+	// Required rejects requests without a key with 400.
+	//
+	// Optional. Default: false
+	Required bool
+
+	// Identity returns who sent the request; keys of different senders
+	// never collide.
+	//
+	// Optional. Default: the signed-in user, else the client IP
+	Identity func(c *fiber.Ctx) string
+
+	// Fingerprint summarizes the request, so that a key reused for a
+	// different request is rejected.
+	//
+	// Optional. Default: a hash of method, path, query and body
+	Fingerprint func(c *fiber.Ctx) string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Header:  "Idempotency-Key",
+	TTL:     24 * time.Hour,
+	LockTTL: time.Minute,
+}
+
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	if cfg.Header == "" {
+		cfg.Header = ConfigDefault.Header
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = ConfigDefault.TTL
+	}
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = ConfigDefault.LockTTL
+	}
+	if cfg.Identity == nil {
+		cfg.Identity = defaultIdentity
+	}
+	if cfg.Fingerprint == nil {
+		cfg.Fingerprint = BodyFingerprint
+	}
+	return cfg
+}
@@ -0,0 +1,158 @@
+// Package idempotency replays the stored response to a POST or PATCH
+// whose Idempotency-Key was seen before, so that clients can retry
+// requests with side effects safely.
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"belajar-golang-fiber/logger"
+	"belajar-golang-fiber/middleware/realip"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderReplayed is set on replayed responses.
+const HeaderReplayed = "Idempotent-Replayed"
+
+// maxKeyLength bounds the keys accepted, UUIDs being the usual choice.
+const maxKeyLength = 255
+
+// replayedHeaders are the response headers stored with the body.
+var replayedHeaders = []string{fiber.HeaderContentType, fiber.HeaderLocation, fiber.HeaderETag}
+
+// New creates a middleware that handles the first POST or PATCH with a
+// given key and answers later ones with its response. A key reused with
+// a different request gets 422, and one whose request is still being
+// handled 409. Failed requests — errors, 5xx, 408, 409 and 429 — are not
+// stored, so their retries are handled anew.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+		switch ctx.Method() {
+		case fiber.MethodPost, fiber.MethodPatch:
+		default:
+			return ctx.Next()
+		}
+
+		key := ctx.Get(cfg.Header)
+		if key == "" {
+			if cfg.Required {
+				return fiber.NewError(fiber.StatusBadRequest, cfg.Header+" header is required")
+			}
+			return ctx.Next()
+		}
+		if len(key) > maxKeyLength {
+			return fiber.NewError(fiber.StatusBadRequest, cfg.Header+" header is too long")
+		}
+		key = cfg.Identity(ctx) + ":" + key
+
+		fingerprint := cfg.Fingerprint(ctx)
+		existing, err := cfg.Store.Begin(ctx.UserContext(), key, fingerprint, cfg.LockTTL)
+		if err != nil {
+			logger.FromContext(ctx.UserContext()).Error("idempotency: store failed", "error", err)
+			return fiber.ErrServiceUnavailable
+		}
+		if existing != nil {
+			return answer(ctx, existing, fingerprint)
+		}
+
+		handlerErr := ctx.Next()
+		status := ctx.Response().StatusCode()
+		if handlerErr != nil || !storable(status) {
+			if err := cfg.Store.Release(ctx.UserContext(), key); err != nil {
+				logger.FromContext(ctx.UserContext()).Error("idempotency: releasing key failed", "error", err)
+			}
+			return handlerErr
+		}
+		record := Record{
+			Fingerprint: fingerprint,
+			Done:        true,
+			Status:      status,
+			Header:      map[string]string{},
+			Body:        append([]byte(nil), ctx.Response().Body()...),
+		}
+		for _, name := range replayedHeaders {
+			if value := ctx.Response().Header.Peek(name); len(value) > 0 {
+				record.Header[name] = string(value)
+			}
+		}
+		if err := cfg.Store.Finish(ctx.UserContext(), key, record, cfg.TTL); err != nil {
+			// The request was handled; a retry may be handled again.
+			logger.FromContext(ctx.UserContext()).Error("idempotency: storing response failed", "error", err)
+		}
+		return nil
+	}
+}
+
+func answer(ctx *fiber.Ctx, existing *Record, fingerprint string) error {
+	if existing.Fingerprint != fingerprint {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "idempotency key reused for a different request",
+		})
+	}
+	if !existing.Done {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "request with this idempotency key in progress",
+		})
+	}
+	for name, value := range existing.Header {
+		ctx.Set(name, value)
+	}
+	ctx.Set(HeaderReplayed, "true")
+	return ctx.Status(existing.Status).Send(existing.Body)
+}
+
+func storable(status int) bool {
+	switch status {
+	case fiber.StatusRequestTimeout, fiber.StatusConflict, fiber.StatusTooManyRequests:
+		return false
+	}
+	return status < 500
+}
+
+// BodyFingerprint hashes method, path, query and body. JSON bodies are
+// compacted first so whitespace does not matter.
+func BodyFingerprint(ctx *fiber.Ctx) string {
+	body := ctx.Body()
+	if strings.HasPrefix(string(ctx.Request().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		var compacted bytes.Buffer
+		if json.Compact(&compacted, body) == nil {
+			body = compacted.Bytes()
+		}
+	}
+	return hash(ctx, body)
+}
+
+// RouteFingerprint hashes method, path, query and the declared body
+// length, for routes such as uploads whose bodies are streamed rather
+// than read up front.
+func RouteFingerprint(ctx *fiber.Ctx) string {
+	return hash(ctx, ctx.Request().Header.Peek(fiber.HeaderContentLength))
+}
+
+func hash(ctx *fiber.Ctx, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{ctx.Method(), ctx.Path(), string(ctx.Request().URI().QueryString())} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func defaultIdentity(ctx *fiber.Ctx) string {
+	if id := session.UserID(ctx); id != "" {
+		return "user:" + id
+	}
+	return "ip:" + realip.IP(ctx)
+}
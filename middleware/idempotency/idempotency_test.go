@@ -0,0 +1,119 @@
+package idempotency
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(store Store) (*fiber.App, *int) {
+	created := 0
+	app := fiber.New()
+	app.Post("/payments", New(Config{Store: store}), func(ctx *fiber.Ctx) error {
+		switch string(ctx.Body()) {
+		case `{"fail":true}`:
+			return fiber.ErrBadRequest
+		case `{"busy":true}`:
+			return ctx.SendStatus(fiber.StatusServiceUnavailable)
+		}
+		created++
+		ctx.Location("/payments/" + strconv.Itoa(created))
+		return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"id": created})
+	})
+	return app, &created
+}
+
+func post(t *testing.T, app *fiber.App, key, body string) (*http.Response, string) {
+	request := httptest.NewRequest("POST", "/payments", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		request.Header.Set("Idempotency-Key", key)
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	bytes, _ := io.ReadAll(response.Body)
+	return response, string(bytes)
+}
+
+func TestReplay(t *testing.T) {
+	app, created := newApp(NewMemoryStore())
+
+	response, body := post(t, app, "k1", `{"amount": 10}`)
+	assert.Equal(t, fiber.StatusCreated, response.StatusCode)
+	assert.Equal(t, `{"id":1}`, body)
+	assert.Empty(t, response.Header.Get(HeaderReplayed))
+
+	response, body = post(t, app, "k1", `{"amount":10}`)
+	assert.Equal(t, fiber.StatusCreated, response.StatusCode)
+	assert.Equal(t, `{"id":1}`, body)
+	assert.Equal(t, "/payments/1", response.Header.Get("Location"))
+	assert.Equal(t, "true", response.Header.Get(HeaderReplayed))
+	assert.Equal(t, 1, *created)
+
+	response, _ = post(t, app, "k1", `{"amount":20}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, response.StatusCode)
+
+	response, body = post(t, app, "k2", `{"amount":10}`)
+	assert.Equal(t, `{"id":2}`, body)
+	response, _ = post(t, app, "", `{"amount":10}`)
+	assert.Equal(t, fiber.StatusCreated, response.StatusCode)
+	assert.Equal(t, 3, *created)
+
+	response, _ = post(t, app, strings.Repeat("k", 256), `{"amount":10}`)
+	assert.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+}
+
+func TestFailuresAreNotStored(t *testing.T) {
+	app, created := newApp(NewMemoryStore())
+
+	response, _ := post(t, app, "k1", `{"fail":true}`)
+	assert.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+	response, _ = post(t, app, "k2", `{"busy":true}`)
+	assert.Equal(t, fiber.StatusServiceUnavailable, response.StatusCode)
+
+	// Retrying the same keys with the same requests is handled anew.
+	response, _ = post(t, app, "k1", `{"fail":true}`)
+	assert.Empty(t, response.Header.Get(HeaderReplayed))
+	response, _ = post(t, app, "k2", `{"busy":true}`)
+	assert.Empty(t, response.Header.Get(HeaderReplayed))
+	assert.Equal(t, 0, *created)
+}
+
+func TestInFlight(t *testing.T) {
+	store := NewMemoryStore()
+	app, _ := newApp(store)
+
+	// Claim the key as a request still being handled would.
+	fingerprint := ""
+	probe := fiber.New()
+	probe.Post("/payments", func(ctx *fiber.Ctx) error {
+		fingerprint = BodyFingerprint(ctx)
+		return nil
+	})
+	probe.Test(httptest.NewRequest("POST", "/payments", strings.NewReader(`{}`)))
+	_, err := store.Begin(t.Context(), "ip:0.0.0.0:k1", fingerprint, ConfigDefault.LockTTL)
+	assert.Nil(t, err)
+
+	response, _ := post(t, app, "k1", `{}`)
+	assert.Equal(t, fiber.StatusConflict, response.StatusCode)
+}
+
+func TestRequired(t *testing.T) {
+	app := fiber.New()
+	app.Post("/", New(Config{Required: true}), func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusCreated)
+	})
+	response, err := app.Test(httptest.NewRequest("POST", "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusMethodNotAllowed, response.StatusCode)
+}
@@ -0,0 +1,132 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/redis"
+)
+
+// Record is what a store keeps under a key: the fingerprint of the
+// request that claimed it and, once Done, its response.
+type Record struct {
+	Fingerprint string            `json:"fingerprint"`
+	Done        bool              `json:"done"`
+	Status      int               `json:"status,omitempty"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// Store keeps records by key. Stores shared between instances, such as
+// RedisStore, protect against retries reaching another instance.
+type Store interface {
+	// Begin claims key for the request with fingerprint until ttl. When
+	// key is claimed already it returns the record found instead.
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error)
+	// Finish stores the response of a claimed key until ttl.
+	Finish(ctx context.Context, key string, record Record, ttl time.Duration) error
+	// Release forgets a claimed key, e.g. after a failed request.
+	Release(ctx context.Context, key string) error
+}
+
+type entry struct {
+	record  Record
+	expires time.Time
+}
+
+// MemoryStore is an in-process Store; expired entries are dropped lazily.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]entry{}, now: time.Now}
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+	if existing, ok := s.entries[key]; ok {
+		record := existing.record
+		return &record, nil
+	}
+	s.entries[key] = entry{record: Record{Fingerprint: fingerprint}, expires: now.Add(ttl)}
+	return nil, nil
+}
+
+func (s *MemoryStore) Finish(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = entry{record: record, expires: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// RedisStore keeps records as JSON in Redis under Prefix and the key.
+type RedisStore struct {
+	Client *redis.Client
+	Prefix string
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{Client: client, Prefix: "idempotency:"}
+}
+
+func (s *RedisStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	claim, err := json.Marshal(Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+	// A claim expiring between SETNX and GET is claimed again.
+	for range 3 {
+		claimed, err := s.Client.SetNX(ctx, s.Prefix+key, string(claim), ttl)
+		if err != nil || claimed {
+			return nil, err
+		}
+		value, err := s.Client.Get(ctx, s.Prefix+key)
+		if errors.Is(err, redis.ErrNil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var record Record
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			return nil, err
+		}
+		return &record, nil
+	}
+	return nil, errors.New("idempotency: key keeps expiring")
+}
+
+func (s *RedisStore) Finish(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.Client.Set(ctx, s.Prefix+key, string(value), ttl)
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return s.Client.Del(ctx, s.Prefix+key)
+}
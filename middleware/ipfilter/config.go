@@ -0,0 +1,52 @@
+package ipfilter
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Allow lists the networks, in CIDR notation or as single addresses,
+	// requests may come from. Empty allows every network not denied.
+	//
+	// Optional. Default: nil
+	Allow []string
+
+	// Deny lists the networks requests may not come from, even when
+	// allowed.
+	//
+	// Optional. Default: nil
+	Deny []string
+
+	// Store holds rules managed at runtime, added to Allow and Deny.
+	//
+	// Optional. Default: nil
+	Store Store
+
+	// ReloadInterval is how often Run reloads the rules of Store, so
+	// changes made by other processes apply.
+	//
+	// Optional. Default: 30 * time.Second
+	ReloadInterval time.Duration
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	ReloadInterval: 30 * time.Second,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+	cfg := config[0]
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = ConfigDefault.ReloadInterval
+	}
+	return cfg
+}
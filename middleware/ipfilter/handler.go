@@ -0,0 +1,90 @@
+package ipfilter
+
+import (
+	"errors"
+	"time"
+
+	"belajar-golang-fiber/binding"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Handler manages the rules of a filter's Store in the admin area.
+// Changes apply to this process at once, to others on their next reload.
+type Handler struct {
+	Filter *Filter
+}
+
+// Register mounts the routes on router, e.g. adminApp.Group("/ip-rules").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/", h.list)
+	router.Post("/", h.add)
+	router.Delete("/:id", h.remove)
+}
+
+type addRequest struct {
+	Network string `json:"network" form:"network"`
+	Action  string `json:"action" form:"action"`
+	Note    string `json:"note" form:"note"`
+}
+
+func (h *Handler) list(ctx *fiber.Ctx) error {
+	rules, err := h.Filter.config.Store.List(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(rules)
+}
+
+// add answers 201 with the rule, or 422 with the field found invalid.
+// Networks are stored normalized, e.g. 10.1.2.3/8 as 10.0.0.0/8.
+func (h *Handler) add(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[addRequest](ctx)
+	if err != nil {
+		return err
+	}
+	network, err := ParsePrefix(request.Network)
+	if err != nil {
+		return invalid(ctx, "network", "must be an address or a network in CIDR notation")
+	}
+	if request.Action != ActionAllow && request.Action != ActionDeny {
+		return invalid(ctx, "action", "must be allow or deny")
+	}
+
+	rule := &Rule{
+		ID:        utils.UUIDv4(),
+		Network:   network.String(),
+		Action:    request.Action,
+		Note:      request.Note,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := h.Filter.config.Store.Create(ctx.UserContext(), rule); err != nil {
+		return err
+	}
+	if err := h.Filter.Reload(ctx.UserContext()); err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(rule)
+}
+
+func (h *Handler) remove(ctx *fiber.Ctx) error {
+	err := h.Filter.config.Store.Delete(ctx.UserContext(), ctx.Params("id"))
+	if errors.Is(err, ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	if err := h.Filter.Reload(ctx.UserContext()); err != nil {
+		return err
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func invalid(ctx *fiber.Ctx, field, message string) error {
+	return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error": field + ": " + message,
+		"field": field,
+	})
+}
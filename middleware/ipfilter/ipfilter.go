@@ -0,0 +1,168 @@
+// Package ipfilter lets requests through only from trusted networks, e.g.
+// to the admin area and debug routes. Rules come from the config and from
+// a Store, whose changes apply without a restart.
+package ipfilter
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"belajar-golang-fiber/logger"
+	"belajar-golang-fiber/middleware/realip"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Actions of a rule.
+const (
+	ActionAllow = "allow"
+	ActionDeny  = "deny"
+)
+
+// Rules are the networks to allow and deny. A denied address is refused
+// even when allowed; without allowed networks every other one is allowed.
+type Rules struct {
+	Allow []netip.Prefix
+	Deny  []netip.Prefix
+}
+
+// Allows reports whether requests from addr are let through.
+func (r *Rules) Allows(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, network := range r.Deny {
+		if network.Contains(addr) {
+			return false
+		}
+	}
+	if len(r.Allow) == 0 {
+		return true
+	}
+	for _, network := range r.Allow {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePrefix parses a network in CIDR notation, or a single address.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	network, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return network.Masked(), nil
+}
+
+// Filter checks requests against its rules, which Reload replaces.
+type Filter struct {
+	config Config
+	static Rules
+	rules  atomic.Pointer[Rules]
+}
+
+// New creates a filter; it panics on an invalid network in the config.
+// The rules of the Store are loaded by Reload or Run.
+func New(config ...Config) *Filter {
+	cfg := configDefault(config...)
+
+	f := &Filter{config: cfg}
+	for _, s := range cfg.Allow {
+		f.static.Allow = append(f.static.Allow, mustParse(s))
+	}
+	for _, s := range cfg.Deny {
+		f.static.Deny = append(f.static.Deny, mustParse(s))
+	}
+	f.rules.Store(&f.static)
+	return f
+}
+
+func mustParse(s string) netip.Prefix {
+	network, err := ParsePrefix(s)
+	if err != nil {
+		panic("ipfilter: invalid network " + s)
+	}
+	return network
+}
+
+// Handler returns the middleware answering 403 to requests from networks
+// not allowed.
+func (f *Filter) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if f.config.Next != nil && f.config.Next(c) {
+			return c.Next()
+		}
+		addr, err := netip.ParseAddr(realip.IP(c))
+		if err != nil || !f.Rules().Allows(addr) {
+			return fiber.NewError(fiber.StatusForbidden, "forbidden from this network")
+		}
+		return c.Next()
+	}
+}
+
+// Rules returns the rules in force.
+func (f *Filter) Rules() *Rules {
+	return f.rules.Load()
+}
+
+// Reload puts the rules of the config and the Store in force. Rules of
+// the Store that no longer parse are skipped.
+func (f *Filter) Reload(ctx context.Context) error {
+	if f.config.Store == nil {
+		return nil
+	}
+	stored, err := f.config.Store.List(ctx)
+	if err != nil {
+		return err
+	}
+	rules := &Rules{
+		Allow: append([]netip.Prefix{}, f.static.Allow...),
+		Deny:  append([]netip.Prefix{}, f.static.Deny...),
+	}
+	for _, rule := range stored {
+		network, err := ParsePrefix(rule.Network)
+		if err != nil {
+			logger.FromContext(ctx).Warn("ipfilter: invalid rule skipped", "rule_id", rule.ID, "network", rule.Network)
+			continue
+		}
+		if rule.Action == ActionDeny {
+			rules.Deny = append(rules.Deny, network)
+		} else {
+			rules.Allow = append(rules.Allow, network)
+		}
+	}
+	f.rules.Store(rules)
+	return nil
+}
+
+// Run reloads the rules every ReloadInterval until ctx is done. Failed
+// reloads keep the rules in force.
+func (f *Filter) Run(ctx context.Context) {
+	if f.config.Store == nil {
+		return
+	}
+	ticker := time.NewTicker(f.config.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		if err := f.Reload(ctx); err != nil {
+			logger.FromContext(ctx).Error("ipfilter: reloading rules failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package ipfilter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules(t *testing.T) {
+	rules := &Rules{
+		Allow: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")},
+		Deny:  []netip.Prefix{netip.MustParsePrefix("10.9.0.0/16")},
+	}
+	assert.True(t, rules.Allows(netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, rules.Allows(netip.MustParseAddr("::ffff:10.1.2.3")))
+	assert.True(t, rules.Allows(netip.MustParseAddr("2001:db8::1")))
+	assert.False(t, rules.Allows(netip.MustParseAddr("10.9.0.1")))
+	assert.False(t, rules.Allows(netip.MustParseAddr("192.168.1.1")))
+
+	open := &Rules{Deny: rules.Deny}
+	assert.True(t, open.Allows(netip.MustParseAddr("192.168.1.1")))
+	assert.False(t, open.Allows(netip.MustParseAddr("10.9.0.1")))
+}
+
+func TestParsePrefix(t *testing.T) {
+	network, err := ParsePrefix("10.1.2.3/8")
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.0/8", network.String())
+	network, _ = ParsePrefix(" 192.168.1.1 ")
+	assert.Equal(t, "192.168.1.1/32", network.String())
+	network, _ = ParsePrefix("::ffff:192.168.1.1")
+	assert.Equal(t, "192.168.1.1/32", network.String())
+	_, err = ParsePrefix("10.0.0.0/33")
+	assert.NotNil(t, err)
+	assert.Panics(t, func() { New(Config{Allow: []string{"localhost"}}) })
+}
+
+func get(t *testing.T, app *fiber.App, ip string) int {
+	request := httptest.NewRequest("GET", "/admin", nil)
+	request.Header.Set("X-Real-IP", ip)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	return response.StatusCode
+}
+
+func TestHandler(t *testing.T) {
+	store := NewMemoryStore()
+	filter := New(Config{Allow: []string{"10.0.0.0/8"}, Store: store})
+	app := fiber.New(fiber.Config{ProxyHeader: "X-Real-IP"})
+	app.Use("/admin", filter.Handler())
+	app.Get("/admin", func(c *fiber.Ctx) error {
+		return c.SendString("admin")
+	})
+
+	assert.Equal(t, fiber.StatusOK, get(t, app, "10.1.2.3"))
+	assert.Equal(t, fiber.StatusForbidden, get(t, app, "192.168.1.1"))
+	assert.Equal(t, fiber.StatusForbidden, get(t, app, "not an ip"))
+
+	// Rules of the store apply once reloaded.
+	ctx := context.Background()
+	store.Create(ctx, &Rule{ID: "1", Network: "192.168.1.0/24", Action: ActionAllow})
+	store.Create(ctx, &Rule{ID: "2", Network: "10.1.2.3", Action: ActionDeny, CreatedAt: time.Now()})
+	store.Create(ctx, &Rule{ID: "3", Network: "nonsense", Action: ActionDeny, CreatedAt: time.Now()})
+	assert.Equal(t, fiber.StatusForbidden, get(t, app, "192.168.1.1"))
+	assert.Nil(t, filter.Reload(ctx))
+	assert.Equal(t, fiber.StatusOK, get(t, app, "192.168.1.1"))
+	assert.Equal(t, fiber.StatusForbidden, get(t, app, "10.1.2.3"))
+	assert.Equal(t, fiber.StatusOK, get(t, app, "10.1.2.4"))
+
+	store.Delete(ctx, "2")
+	assert.Nil(t, filter.Reload(ctx))
+	assert.Equal(t, fiber.StatusOK, get(t, app, "10.1.2.3"))
+}
+
+func TestAdminHandler(t *testing.T) {
+	filter := New(Config{Store: NewMemoryStore()})
+	app := fiber.New(fiber.Config{ProxyHeader: "X-Real-IP"})
+	app.Use(filter.Handler())
+	handler := &Handler{Filter: filter}
+	handler.Register(app.Group("/ip-rules"))
+
+	add := func(body string) (int, map[string]string) {
+		request := httptest.NewRequest("POST", "/ip-rules", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Real-IP", "10.0.0.1")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		var answer map[string]string
+		json.NewDecoder(response.Body).Decode(&answer)
+		return response.StatusCode, answer
+	}
+
+	status, answer := add(`{"network":"192.168.1.7/24","action":"deny","note":"office guests"}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	assert.Equal(t, "192.168.1.0/24", answer["network"])
+	assert.Equal(t, fiber.StatusForbidden, get(t, app, "192.168.1.20"), "rules apply at once")
+
+	status, answer = add(`{"network":"example.com","action":"deny"}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+	assert.Equal(t, "network", answer["field"])
+	status, answer = add(`{"network":"10.0.0.0/8","action":"maybe"}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+	assert.Equal(t, "action", answer["field"])
+
+	rules, _ := filter.config.Store.List(context.Background())
+	assert.Len(t, rules, 1)
+	remove := func() int {
+		request := httptest.NewRequest("DELETE", "/ip-rules/"+rules[0].ID, nil)
+		request.Header.Set("X-Real-IP", "10.0.0.1")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+	assert.Equal(t, fiber.StatusNoContent, remove())
+	assert.NotEqual(t, fiber.StatusForbidden, get(t, app, "192.168.1.20"))
+	assert.Equal(t, fiber.StatusNotFound, remove())
+}
+
+func TestSQLStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.Open(database.DriverSQLite, "file::memory:")
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Nil(t, db.Migrate(ctx))
+
+	store := NewSQLStore(db)
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.Nil(t, store.Create(ctx, &Rule{ID: "2", Network: "10.0.0.0/8", Action: ActionAllow, CreatedAt: created.Add(time.Hour)}))
+	assert.Nil(t, store.Create(ctx, &Rule{ID: "1", Network: "10.9.0.0/16", Action: ActionDeny, Note: "lab", CreatedAt: created}))
+
+	rules, err := store.List(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Rule{
+		{ID: "1", Network: "10.9.0.0/16", Action: ActionDeny, Note: "lab", CreatedAt: created},
+		{ID: "2", Network: "10.0.0.0/8", Action: ActionAllow, CreatedAt: created.Add(time.Hour)},
+	}, rules)
+
+	assert.Nil(t, store.Delete(ctx, "1"))
+	assert.ErrorIs(t, store.Delete(ctx, "1"), ErrNotFound)
+}
@@ -0,0 +1,56 @@
+package ipfilter
+
+import (
+	"context"
+
+	"belajar-golang-fiber/database"
+)
+
+// SQLStore keeps rules in the ip_rules table of a SQL database; see
+// database.Migrate.
+type SQLStore struct {
+	DB *database.DB
+}
+
+func NewSQLStore(db *database.DB) *SQLStore {
+	return &SQLStore{DB: db}
+}
+
+func (s *SQLStore) Create(ctx context.Context, rule *Rule) error {
+	_, err := s.DB.Exec(ctx, "INSERT INTO ip_rules (id, network, action, note, created_at) VALUES (?, ?, ?, ?, ?)",
+		rule.ID, rule.Network, rule.Action, rule.Note, rule.CreatedAt.UTC())
+	return err
+}
+
+func (s *SQLStore) List(ctx context.Context) ([]*Rule, error) {
+	rows, err := s.DB.Query(ctx, "SELECT id, network, action, note, created_at FROM ip_rules ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rules := []*Rule{}
+	for rows.Next() {
+		rule := &Rule{}
+		if err := rows.Scan(&rule.ID, &rule.Network, &rule.Action, &rule.Note, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		rule.CreatedAt = rule.CreatedAt.UTC()
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (s *SQLStore) Delete(ctx context.Context, id string) error {
+	result, err := s.DB.Exec(ctx, "DELETE FROM ip_rules WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
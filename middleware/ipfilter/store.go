@@ -0,0 +1,72 @@
+package ipfilter
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when no rule has the given id.
+var ErrNotFound = errors.New("ipfilter: rule not found")
+
+// Rule allows or denies a network, in CIDR notation or as a single
+// address.
+type Rule struct {
+	ID        string    `json:"id"`
+	Network   string    `json:"network"`
+	Action    string    `json:"action"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store persists rules.
+type Store interface {
+	Create(ctx context.Context, rule *Rule) error
+	// List returns every rule, oldest first.
+	List(ctx context.Context) ([]*Rule, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore keeps rules in process memory.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	rules map[string]*Rule
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{rules: map[string]*Rule{}}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, rule *Rule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *rule
+	s.rules[rule.ID] = &copied
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context) ([]*Rule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rules := make([]*Rule, 0, len(s.rules))
+	for _, rule := range s.rules {
+		copied := *rule
+		rules = append(rules, &copied)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+	return rules, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rules[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.rules, id)
+	return nil
+}
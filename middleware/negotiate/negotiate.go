@@ -0,0 +1,85 @@
+// Package negotiate answers API clients in the representation they
+// prefer: JSON, or MessagePack or Protocol Buffers when they ask for it.
+package negotiate
+
+import (
+	"strings"
+
+	"belajar-golang-fiber/binding"
+	"belajar-golang-fiber/msgpack"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// New creates a middleware converting the JSON responses, errors
+// included, of requests that prefer application/msgpack to MessagePack.
+// Handlers on hot paths skip the JSON step with Send.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		c.Vary(fiber.HeaderAccept)
+		if !PrefersMsgPack(c) {
+			return c.Next()
+		}
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+
+		contentType, _, _ := strings.Cut(string(c.Response().Header.ContentType()), ";")
+		if contentType != fiber.MIMEApplicationJSON || len(c.Response().Body()) == 0 || c.Response().IsBodyStream() {
+			return nil
+		}
+		body, err := msgpack.FromJSON(c.Response().Body())
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, msgpack.MIMEApplicationMsgPack)
+		c.Response().SetBodyRaw(body)
+		return nil
+	}
+}
+
+// PrefersMsgPack reports whether the request prefers MessagePack to
+// JSON, which those accepting anything get.
+func PrefersMsgPack(c *fiber.Ctx) bool {
+	return c.Accepts(fiber.MIMEApplicationJSON, msgpack.MIMEApplicationMsgPack) == msgpack.MIMEApplicationMsgPack
+}
+
+// Send answers with v encoded straight to MessagePack for clients
+// preferring it, and as JSON otherwise.
+func Send(c *fiber.Ctx, v any) error {
+	if !PrefersMsgPack(c) {
+		return c.JSON(v)
+	}
+	body, err := msgpack.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, msgpack.MIMEApplicationMsgPack)
+	return c.Send(body)
+}
+
+// SendProto is Send for endpoints also serving Protocol Buffers: clients
+// preferring application/x-protobuf get the message built by message,
+// others v.
+func SendProto(c *fiber.Ctx, v any, message func() proto.Message) error {
+	contentType := c.Accepts(fiber.MIMEApplicationJSON, msgpack.MIMEApplicationMsgPack,
+		binding.MIMEApplicationProtobuf, "application/protobuf")
+	if !binding.IsProtobuf(contentType) {
+		return Send(c, v)
+	}
+	body, err := proto.Marshal(message())
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Send(body)
+}
@@ -0,0 +1,130 @@
+package negotiate
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/msgpack"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestNew(t *testing.T) {
+	app := fiber.New()
+	app.Use(New())
+	app.Get("/json", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"id": 7, "name": "salman"})
+	})
+	app.Get("/send", func(c *fiber.Ctx) error {
+		return Send(c, struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		}{7, "salman"})
+	})
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusConflict, "taken")
+	})
+	app.Get("/text", func(c *fiber.Ctx) error {
+		return c.SendString("plain")
+	})
+	get := func(path, accept string) (string, string, int) {
+		request := httptest.NewRequest("GET", path, nil)
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		body, _ := io.ReadAll(response.Body)
+		if response.Header.Get("Content-Type") == msgpack.MIMEApplicationMsgPack {
+			converted, err := msgpack.ToJSON(body)
+			assert.Nil(t, err)
+			body = converted
+		}
+		assert.Equal(t, "Accept", response.Header.Get("Vary"))
+		return response.Header.Get("Content-Type"), string(body), response.StatusCode
+	}
+
+	for _, path := range []string{"/json", "/send"} {
+		contentType, body, _ := get(path, "application/msgpack")
+		assert.Equal(t, msgpack.MIMEApplicationMsgPack, contentType, path)
+		assert.JSONEq(t, `{"id":7,"name":"salman"}`, body, path)
+
+		contentType, body, _ = get(path, "*/*")
+		assert.Equal(t, fiber.MIMEApplicationJSON, contentType, path)
+		assert.JSONEq(t, `{"id":7,"name":"salman"}`, body, path)
+
+		contentType, _, _ = get(path, "application/json;q=0.5, application/msgpack")
+		assert.Equal(t, msgpack.MIMEApplicationMsgPack, contentType, path)
+		contentType, _, _ = get(path, "")
+		assert.Equal(t, fiber.MIMEApplicationJSON, contentType, path)
+	}
+
+	contentType, body, status := get("/fail", "application/msgpack")
+	assert.Equal(t, 409, status)
+	assert.Equal(t, fiber.MIMETextPlainCharsetUTF8, contentType, "the default error handler writes text")
+	assert.Equal(t, "taken", body)
+
+	contentType, body, _ = get("/text", "application/msgpack")
+	assert.Equal(t, fiber.MIMETextPlainCharsetUTF8, contentType)
+	assert.Equal(t, "plain", body)
+}
+
+func TestErrorsAsMsgPack(t *testing.T) {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		},
+	})
+	app.Use(New())
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusConflict, "taken")
+	})
+
+	request := httptest.NewRequest("GET", "/fail", nil)
+	request.Header.Set("Accept", "application/msgpack")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 409, response.StatusCode)
+	assert.Equal(t, msgpack.MIMEApplicationMsgPack, response.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(response.Body)
+	converted, err := msgpack.ToJSON(body)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"error":"taken"}`, string(converted))
+}
+
+func TestSendProto(t *testing.T) {
+	app := fiber.New()
+	app.Use(New())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return SendProto(c, fiber.Map{"value": "salman"}, func() proto.Message {
+			return wrapperspb.String("salman")
+		})
+	})
+	get := func(accept string) (string, []byte) {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.Header.Set("Accept", accept)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		body, _ := io.ReadAll(response.Body)
+		return response.Header.Get("Content-Type"), body
+	}
+
+	for _, accept := range []string{"application/x-protobuf", "application/protobuf", "application/json;q=0.5, application/x-protobuf"} {
+		contentType, body := get(accept)
+		assert.True(t, strings.HasSuffix(accept, contentType), accept)
+		var message wrapperspb.StringValue
+		assert.Nil(t, proto.Unmarshal(body, &message), accept)
+		assert.Equal(t, "salman", message.GetValue(), accept)
+	}
+
+	contentType, body := get("*/*")
+	assert.Equal(t, fiber.MIMEApplicationJSON, contentType)
+	assert.JSONEq(t, `{"value":"salman"}`, string(body))
+	contentType, _ = get("application/msgpack")
+	assert.Equal(t, msgpack.MIMEApplicationMsgPack, contentType)
+}
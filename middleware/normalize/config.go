@@ -0,0 +1,37 @@
+package normalize
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Form is the Unicode normalization applied to the decoded path. The
+	// compatibility forms also fold look-alikes such as fullwidth letters
+	// and slashes into their ASCII counterparts.
+	//
+	// Optional. Default: norm.NFKC
+	Form *norm.Form
+}
+
+var nfkc = norm.NFKC
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Form: &nfkc,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+	cfg := config[0]
+	if cfg.Form == nil {
+		cfg.Form = ConfigDefault.Form
+	}
+	return cfg
+}
@@ -0,0 +1,79 @@
+// Package normalize rewrites every request path to one canonical form
+// before routing, so middleware matching on path prefixes, such as the
+// admin and debug guards or the security header overrides, sees a single
+// spelling of each path however the client encoded it.
+package normalize
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrInvalidPath is returned for paths without a safe canonical form.
+var ErrInvalidPath = errors.New("normalize: invalid request path")
+
+// New creates a middleware replacing the request path with Canonical of
+// the path as sent, answering 400 when there is none. It belongs before
+// any middleware that looks at the path.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		canonical, err := Canonical(string(ctx.Request().URI().PathOriginal()), *cfg.Form)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if canonical != ctx.Path() {
+			ctx.Path(canonical)
+		}
+		return ctx.Next()
+	}
+}
+
+// Canonical percent-decodes raw once, normalizes it to form and cleans it
+// into an absolute path without empty, "." or ".." segments, keeping a
+// trailing slash. Paths still percent-encoded after decoding, carrying
+// control characters or backslashes, or not valid UTF-8 are refused with
+// ErrInvalidPath rather than guessed at.
+func Canonical(raw string, form norm.Form) (string, error) {
+	decoded, err := url.PathUnescape(raw)
+	if err != nil || stillEncoded(decoded) || !utf8.ValidString(decoded) {
+		return "", ErrInvalidPath
+	}
+	decoded = form.String(decoded)
+	if strings.ContainsFunc(decoded, func(r rune) bool { return r == '\\' || unicode.IsControl(r) }) {
+		return "", ErrInvalidPath
+	}
+
+	cleaned := path.Clean("/" + decoded)
+	if strings.HasSuffix(decoded, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned, nil
+}
+
+// stillEncoded reports whether s contains a percent escape, which after
+// one round of decoding means the client encoded the path twice.
+func stillEncoded(s string) bool {
+	for i := 0; i+2 < len(s); i++ {
+		if s[i] == '%' && isHex(s[i+1]) && isHex(s[i+2]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
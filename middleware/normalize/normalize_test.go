@@ -0,0 +1,63 @@
+package normalize
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestCanonical(t *testing.T) {
+	cases := map[string]string{
+		"/admin/users":                    "/admin/users",
+		"/%61dmin/users":                  "/admin/users",
+		"/admin%2fusers":                  "/admin/users",
+		"//admin///users/":                "/admin/users/",
+		"/public/../admin/./users":        "/admin/users",
+		"/../../admin":                    "/admin",
+		"/%EF%BD%81%EF%BD%84min/users":    "/admin/users",
+		"/files%EF%BC%8F..%EF%BC%8Fadmin": "/admin",
+		"/caf%C3%A9":                      "/café",
+		"/":                               "/",
+	}
+	for raw, expected := range cases {
+		canonical, err := Canonical(raw, norm.NFKC)
+		assert.Nil(t, err, raw)
+		assert.Equal(t, expected, canonical, raw)
+	}
+
+	for _, raw := range []string{"/%2561dmin", "/a%00b", "/a%5c..%5cadmin", "/%zz", "/%C3"} {
+		_, err := Canonical(raw, norm.NFKC)
+		assert.Equal(t, ErrInvalidPath, err, raw)
+	}
+}
+
+func TestNewGuardsSeeCanonicalPath(t *testing.T) {
+	app := fiber.New()
+	app.Use(New())
+	app.Use("/admin", func(ctx *fiber.Ctx) error {
+		return fiber.ErrUnauthorized
+	})
+	app.Get("/admin/users", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("rahasia")
+	})
+	app.Get("/public/:name", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(ctx.Params("name"))
+	})
+
+	for _, target := range []string{"/%61dmin/users", "/public/../admin/users", "//admin/users", "/ADMIN/users"} {
+		response, err := app.Test(httptest.NewRequest("GET", target, nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, response.StatusCode, target)
+	}
+
+	response, err := app.Test(httptest.NewRequest("GET", "/%2561dmin/users", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest("GET", "/public/contoh.txt?q=%2e%2e", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, response.StatusCode)
+}
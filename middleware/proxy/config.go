@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Upstreams are the base URLs requests are forwarded to, round robin
+	// among the healthy ones. A path in the URL is put before the
+	// forwarded path.
+	//
+	// Required.
+	Upstreams []string
+
+	// StripPrefix is removed from the request path before forwarding,
+	// usually the prefix the proxy is mounted on.
+	//
+	// Optional. Default: ""
+	StripPrefix string
+
+	// PreserveHost forwards the Host header of the client instead of the
+	// host of the upstream.
+	//
+	// Optional. Default: false
+	PreserveHost bool
+
+	// ForwardHeaders lists the client request headers forwarded to the
+	// upstream. Credentials of this app, Cookie, Authorization,
+	// X-Admin-Token and X-Api-Key, are never forwarded; set what the
+	// upstream needs with RequestHeaders.
+	//
+	// Optional. Default: content negotiation, conditional and range
+	// headers, Content-Type, Content-Encoding, User-Agent and
+	// X-Request-ID
+	ForwardHeaders []string
+
+	// RequestHeaders and ResponseHeaders are set on the forwarded request
+	// and on the response to the client. An empty value removes the
+	// header.
+	//
+	// Optional. Default: nil
+	RequestHeaders  map[string]string
+	ResponseHeaders map[string]string
+
+	// Timeout bounds connecting and every read from or write to an
+	// upstream, not the whole exchange, so large bodies keep streaming
+	// as long as they make progress.
+	//
+	// Optional. Default: 30s
+	Timeout time.Duration
+
+	// HealthPath is requested on every upstream each HealthInterval by
+	// Run. Upstreams answering with an error or failing to connect are
+	// skipped until they pass a check again. Empty disables checks.
+	//
+	// Optional. Default: "", 10s
+	HealthPath     string
+	HealthInterval time.Duration
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	ForwardHeaders: []string{
+		fiber.HeaderAccept, fiber.HeaderAcceptEncoding, fiber.HeaderAcceptLanguage,
+		fiber.HeaderContentType, fiber.HeaderContentEncoding,
+		fiber.HeaderIfMatch, fiber.HeaderIfNoneMatch, fiber.HeaderIfModifiedSince,
+		fiber.HeaderIfUnmodifiedSince, fiber.HeaderRange, fiber.HeaderIfRange,
+		fiber.HeaderCacheControl, fiber.HeaderUserAgent, fiber.HeaderXRequestID,
+	},
+	Timeout:        30 * time.Second,
+	HealthInterval: 10 * time.Second,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+	cfg := config[0]
+	if cfg.ForwardHeaders == nil {
+		cfg.ForwardHeaders = ConfigDefault.ForwardHeaders
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = ConfigDefault.Timeout
+	}
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = ConfigDefault.HealthInterval
+	}
+	return cfg
+}
@@ -0,0 +1,295 @@
+// Package proxy forwards a route group to upstream services. Response
+// bodies are streamed to the client as they arrive instead of being
+// buffered, and upstreams failing their health checks are taken out of
+// rotation until they recover.
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"belajar-golang-fiber/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// hopHeaders apply to one connection and are not forwarded.
+var hopHeaders = []string{
+	fiber.HeaderConnection,
+	fiber.HeaderKeepAlive,
+	fiber.HeaderProxyAuthenticate,
+	fiber.HeaderProxyAuthorization,
+	fiber.HeaderTE,
+	fiber.HeaderTrailer,
+	fiber.HeaderTransferEncoding,
+	fiber.HeaderUpgrade,
+}
+
+// credentialHeaders authenticate the client to this app, not to the
+// upstream, and are never forwarded.
+var credentialHeaders = []string{
+	fiber.HeaderCookie,
+	fiber.HeaderAuthorization,
+	"X-Admin-Token",
+	"X-Api-Key",
+}
+
+type upstream struct {
+	origin  string
+	base    string
+	client  *fasthttp.HostClient
+	healthy atomic.Bool
+}
+
+// Proxy forwards requests to a set of upstreams.
+type Proxy struct {
+	config    Config
+	upstreams []*upstream
+	next      atomic.Uint32
+}
+
+// New builds a proxy; it panics on an invalid upstream URL.
+func New(config ...Config) *Proxy {
+	cfg := configDefault(config...)
+	if len(cfg.Upstreams) == 0 {
+		panic("proxy: Upstreams cannot be empty")
+	}
+
+	p := &Proxy{config: cfg}
+	for _, raw := range cfg.Upstreams {
+		target, err := url.Parse(raw)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			panic("proxy: invalid upstream " + raw)
+		}
+		isTLS := target.Scheme == "https"
+		u := &upstream{
+			origin: target.Scheme + "://" + target.Host,
+			base:   strings.TrimSuffix(target.Path, "/"),
+			client: &fasthttp.HostClient{
+				Addr:                     fasthttp.AddMissingPort(target.Host, isTLS),
+				IsTLS:                    isTLS,
+				ReadTimeout:              cfg.Timeout,
+				WriteTimeout:             cfg.Timeout,
+				StreamResponseBody:       true,
+				NoDefaultUserAgentHeader: true,
+				DisablePathNormalizing:   true,
+				// The client decides whether to try again.
+				RetryIf: func(*fasthttp.Request) bool { return false },
+			},
+		}
+		u.healthy.Store(true)
+		p.upstreams = append(p.upstreams, u)
+	}
+	return p
+}
+
+// Handler returns the middleware forwarding every request.
+func (p *Proxy) Handler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if p.config.Next != nil && p.config.Next(ctx) {
+			return ctx.Next()
+		}
+
+		u := p.pick()
+		if u == nil {
+			return fiber.NewError(fiber.StatusServiceUnavailable, "no healthy upstream")
+		}
+
+		request := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(request)
+		p.forwardRequest(ctx, u, request)
+
+		response := fasthttp.AcquireResponse()
+		if err := u.client.Do(request, response); err != nil {
+			fasthttp.ReleaseResponse(response)
+			if p.config.HealthPath != "" {
+				u.healthy.Store(false)
+			}
+			logger.From(ctx).Warn("proxy: upstream failed", "upstream", u.origin, "error", err)
+			if errors.Is(err, fasthttp.ErrTimeout) {
+				return fiber.NewError(fiber.StatusGatewayTimeout, "upstream timed out")
+			}
+			return fiber.NewError(fiber.StatusBadGateway, "upstream unreachable")
+		}
+		p.forwardResponse(ctx, u, response)
+		return nil
+	}
+}
+
+func (p *Proxy) pick() *upstream {
+	start := p.next.Add(1)
+	for i := range p.upstreams {
+		u := p.upstreams[(int(start)+i)%len(p.upstreams)]
+		if u.healthy.Load() {
+			return u
+		}
+	}
+	return nil
+}
+
+func (p *Proxy) forwardRequest(ctx *fiber.Ctx, u *upstream, request *fasthttp.Request) {
+	in := &ctx.Request().Header
+	request.Header.SetMethodBytes(in.Method())
+	for _, header := range p.config.ForwardHeaders {
+		if isHopHeader(header) || isCredentialHeader(header) {
+			continue
+		}
+		if value := in.Peek(header); len(value) > 0 {
+			request.Header.SetBytesV(header, value)
+		}
+	}
+	request.SetBodyRaw(ctx.Body())
+
+	forwardedPath := strings.TrimPrefix(ctx.Path(), p.config.StripPrefix)
+	if !strings.HasPrefix(forwardedPath, "/") {
+		forwardedPath = "/" + forwardedPath
+	}
+	request.SetRequestURI(u.origin)
+	request.URI().SetPath(u.base + forwardedPath)
+	request.URI().SetQueryStringBytes(ctx.Request().URI().QueryString())
+	if p.config.PreserveHost {
+		request.Header.SetHostBytes(ctx.Request().URI().Host())
+		request.UseHostHeader = true
+	}
+
+	forwardedFor := ctx.IP()
+	if prior := ctx.Get(fiber.HeaderXForwardedFor); prior != "" {
+		forwardedFor = prior + ", " + forwardedFor
+	}
+	request.Header.Set(fiber.HeaderXForwardedFor, forwardedFor)
+	request.Header.Set(fiber.HeaderXForwardedHost, ctx.Hostname())
+	request.Header.Set(fiber.HeaderXForwardedProto, ctx.Protocol())
+	setHeaders(&request.Header, p.config.RequestHeaders)
+}
+
+func (p *Proxy) forwardResponse(ctx *fiber.Ctx, u *upstream, response *fasthttp.Response) {
+	out := ctx.Response()
+	out.SetStatusCode(response.StatusCode())
+	response.Header.VisitAll(func(key, value []byte) {
+		switch name := string(key); {
+		// Cookies of the upstream would be set for this app's domain.
+		case isHopHeader(name), name == fiber.HeaderContentLength, strings.EqualFold(name, fiber.HeaderSetCookie):
+		case name == fiber.HeaderLocation:
+			out.Header.Set(name, p.rewriteLocation(u, string(value)))
+		default:
+			out.Header.Add(name, string(value))
+		}
+	})
+	for key, value := range p.config.ResponseHeaders {
+		if value == "" {
+			out.Header.Del(key)
+		} else {
+			out.Header.Set(key, value)
+		}
+	}
+
+	stream := response.BodyStream()
+	if stream == nil {
+		out.SetBody(response.Body())
+		fasthttp.ReleaseResponse(response)
+		return
+	}
+	size := response.Header.ContentLength()
+	if size < 0 {
+		size = -1
+	}
+	out.SetBodyStream(&upstreamBody{Reader: stream, response: response}, size)
+}
+
+// rewriteLocation points redirects to the upstream back at the proxy.
+func (p *Proxy) rewriteLocation(u *upstream, location string) string {
+	rest, ok := strings.CutPrefix(location, u.origin+u.base)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "?")) {
+		return location
+	}
+	return p.config.StripPrefix + rest
+}
+
+func setHeaders(header *fasthttp.RequestHeader, values map[string]string) {
+	for key, value := range values {
+		if value == "" {
+			header.Del(key)
+		} else {
+			header.Set(key, value)
+		}
+	}
+}
+
+func isHopHeader(name string) bool {
+	return containsFold(hopHeaders, name)
+}
+
+func isCredentialHeader(name string) bool {
+	return containsFold(credentialHeaders, name)
+}
+
+func containsFold(list []string, name string) bool {
+	for _, header := range list {
+		if strings.EqualFold(name, header) {
+			return true
+		}
+	}
+	return false
+}
+
+// upstreamBody releases the upstream response once fasthttp has sent its
+// body to the client.
+type upstreamBody struct {
+	io.Reader
+	response *fasthttp.Response
+}
+
+func (b *upstreamBody) Close() error {
+	err := b.response.CloseBodyStream()
+	fasthttp.ReleaseResponse(b.response)
+	return err
+}
+
+// Run checks every upstream each HealthInterval until ctx is done. It
+// returns at once when HealthPath is empty.
+func (p *Proxy) Run(ctx context.Context) {
+	if p.config.HealthPath == "" {
+		return
+	}
+	ticker := time.NewTicker(p.config.HealthInterval)
+	defer ticker.Stop()
+	for {
+		p.CheckHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckHealth requests HealthPath from every upstream once; those
+// answering below 500 are put in rotation, the others taken out.
+func (p *Proxy) CheckHealth(ctx context.Context) {
+	for _, u := range p.upstreams {
+		healthy := u.check(p.config.HealthPath, p.config.Timeout)
+		if u.healthy.Swap(healthy) != healthy {
+			logger.FromContext(ctx).Info("proxy: upstream health changed", "upstream", u.origin, "healthy", healthy)
+		}
+	}
+}
+
+func (u *upstream) check(path string, timeout time.Duration) bool {
+	request := fasthttp.AcquireRequest()
+	response := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(request)
+	defer fasthttp.ReleaseResponse(response)
+
+	request.SetRequestURI(u.origin + u.base + path)
+	request.Header.SetMethod(fiber.MethodGet)
+	if err := u.client.DoTimeout(request, response, timeout); err != nil {
+		return false
+	}
+	return response.StatusCode() < fiber.StatusInternalServerError
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForwardsWithRewrites(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/users":
+			assert.Equal(t, "page=2", r.URL.RawQuery)
+			assert.Equal(t, "0.0.0.0", r.Header.Get("X-Forwarded-For"))
+			assert.Equal(t, "example.com", r.Header.Get("X-Forwarded-Host"))
+			assert.Equal(t, "internal", r.Header.Get("X-Caller"))
+			assert.Equal(t, "application/json", r.Header.Get("Accept"))
+			for _, header := range []string{"Authorization", "Cookie", "X-Admin-Token", "X-Api-Key", "X-Custom"} {
+				assert.Empty(t, r.Header.Get(header), header)
+			}
+			assert.NotEqual(t, "example.com", r.Host)
+			http.SetCookie(w, &http.Cookie{Name: "session_id", Value: "upstream"})
+			w.Header().Set("X-Powered-By", "upstream")
+			w.Header().Set("X-Upstream", "ya")
+			w.Write([]byte(`[{"id":1}]`))
+		case "/v1/old":
+			http.Redirect(w, r, "http://"+r.Host+"/v1/new?x=1", http.StatusFound)
+		case "/v1/echo":
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		}
+	}))
+	defer upstream.Close()
+
+	app := fiber.New()
+	app.Use("/external", New(Config{
+		Upstreams:       []string{upstream.URL + "/v1/"},
+		StripPrefix:     "/external",
+		RequestHeaders:  map[string]string{"X-Caller": "internal"},
+		ResponseHeaders: map[string]string{"X-Powered-By": ""},
+	}).Handler())
+
+	request := httptest.NewRequest("GET", "http://example.com/external/users?page=2", nil)
+	request.Header.Set("Authorization", "Bearer rahasia")
+	request.Header.Set("Cookie", "session_id=rahasia")
+	request.Header.Set("X-Admin-Token", "rahasia")
+	request.Header.Set("X-Api-Key", "rahasia")
+	request.Header.Set("X-Custom", "not listed")
+	request.Header.Set("Accept", "application/json")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Empty(t, response.Header.Values("Set-Cookie"), "upstream cookies are not passed on")
+	assert.Equal(t, "ya", response.Header.Get("X-Upstream"))
+	assert.Empty(t, response.Header.Get("X-Powered-By"))
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, `[{"id":1}]`, string(body))
+
+	response, err = app.Test(httptest.NewRequest("GET", "/external/old", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 302, response.StatusCode)
+	assert.Equal(t, "/external/new?x=1", response.Header.Get("Location"))
+
+	response, err = app.Test(httptest.NewRequest("POST", "/external/echo", strings.NewReader("halo")))
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+	body, _ = io.ReadAll(response.Body)
+	assert.Equal(t, "halo", string(body))
+}
+
+func TestPreserveHost(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.Host))
+	}))
+	defer upstream.Close()
+
+	app := fiber.New()
+	app.Use(New(Config{Upstreams: []string{upstream.URL}, PreserveHost: true}).Handler())
+	response, err := app.Test(httptest.NewRequest("PUT", "http://example.com/", nil))
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "PUT example.com", string(body))
+}
+
+func TestStreamsLargeBodies(t *testing.T) {
+	chunk := strings.Repeat("x", 64*1024)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for range 128 {
+			w.Write([]byte(chunk))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer upstream.Close()
+
+	app := fiber.New()
+	app.Use("/external", New(Config{Upstreams: []string{upstream.URL}, StripPrefix: "/external"}).Handler())
+
+	response, err := app.Test(httptest.NewRequest("GET", "/external/besar.bin", nil), -1)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	size, err := io.Copy(io.Discard, response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(128*len(chunk)), size)
+}
+
+func TestHealthChecks(t *testing.T) {
+	var downHealthy atomic.Bool
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("up"))
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !downHealthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("down"))
+	}))
+	defer down.Close()
+
+	proxy := New(Config{Upstreams: []string{up.URL, down.URL}, HealthPath: "/healthz"})
+	app := fiber.New()
+	app.Use(proxy.Handler())
+
+	bodies := func() map[string]int {
+		seen := map[string]int{}
+		for range 4 {
+			response, err := app.Test(httptest.NewRequest("GET", "/", nil))
+			assert.Nil(t, err)
+			body, _ := io.ReadAll(response.Body)
+			seen[string(body)]++
+		}
+		return seen
+	}
+
+	proxy.CheckHealth(context.Background())
+	assert.Equal(t, map[string]int{"up": 4}, bodies())
+
+	downHealthy.Store(true)
+	proxy.CheckHealth(context.Background())
+	assert.Equal(t, map[string]int{"up": 2, "down": 2}, bodies())
+
+	up.Close()
+	down.Close()
+	response, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 502, response.StatusCode)
+	proxy.CheckHealth(context.Background())
+	response, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 503, response.StatusCode)
+}
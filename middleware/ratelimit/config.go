@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"time"
+
+	"belajar-golang-fiber/middleware/realip"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Modes of a policy.
+const (
+	// ModeEnforce rejects requests over the limit with 429.
+	ModeEnforce = "enforce"
+	// ModeMonitor lets every request through and only reports the ones
+	// that would have been rejected.
+	ModeMonitor = "monitor"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Name identifies the policy in headers and statistics.
+	//
+	// Required.
+	Name string
+
+	// Max is the number of requests allowed per key and window.
+	//
+	// Optional. Default: 100
+	Max int
+
+	// Limit returns the Max of the request, e.g. from the plan of its
+	// tenant. Zero or less means Max.
+	//
+	// Optional. Default: nil
+	Limit func(c *fiber.Ctx) int
+
+	// Window is the length of a counting window.
+	//
+	// Optional. Default: time.Minute
+	Window time.Duration
+
+	// Mode is ModeEnforce or ModeMonitor.
+	//
+	// Optional. Default: ModeEnforce
+	Mode string
+
+	// EnforceFrom ends the trial of a policy in ModeMonitor: from then on
+	// it enforces without a config change. Zero keeps monitoring.
+	//
+	// Optional. Default: zero
+	EnforceFrom time.Time
+
+	// KeyGenerator returns the key requests are counted under.
+	//
+	// Optional. Default: realip.IP(c)
+	KeyGenerator func(c *fiber.Ctx) string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Max:    100,
+	Window: time.Minute,
+	Mode:   ModeEnforce,
+	KeyGenerator: func(c *fiber.Ctx) string {
+		return realip.IP(c)
+	},
+}
+
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Max <= 0 {
+		cfg.Max = ConfigDefault.Max
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = ConfigDefault.Window
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = ConfigDefault.Mode
+	}
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = ConfigDefault.KeyGenerator
+	}
+	return cfg
+}
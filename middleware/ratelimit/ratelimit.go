@@ -0,0 +1,180 @@
+// Package ratelimit limits requests per key with fixed windows. New
+// policies can run in monitor mode first: requests over the limit are
+// let through but flagged in headers and counted, so the limit can be
+// tuned against real traffic before it is enforced.
+package ratelimit
+
+import (
+	"expvar"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Response headers.
+const (
+	HeaderLimit     = "X-RateLimit-Limit"
+	HeaderRemaining = "X-RateLimit-Remaining"
+	HeaderReset     = "X-RateLimit-Reset"
+	// HeaderWarning is set instead of rejecting in monitor mode.
+	HeaderWarning = "X-RateLimit-Warning"
+)
+
+// stats is published on /debug/vars as "ratelimit", one map per policy.
+var stats = expvar.NewMap("ratelimit")
+
+// Stats counts what a policy did.
+type Stats struct {
+	Allowed  expvar.Int
+	Rejected expvar.Int
+	// WouldReject counts requests over the limit let through in monitor
+	// mode.
+	WouldReject expvar.Int
+}
+
+type window struct {
+	count int
+	reset time.Time
+}
+
+// Policy is a configured limiter.
+type Policy struct {
+	config  atomic.Pointer[Config]
+	stats   *Stats
+	now     func() time.Time
+	mu      sync.Mutex
+	windows map[string]*window
+}
+
+// NewPolicy builds a policy and publishes its statistics.
+func NewPolicy(config ...Config) *Policy {
+	cfg := configDefault(config...)
+	if cfg.Name == "" {
+		panic("ratelimit: Name cannot be empty")
+	}
+
+	policy := &Policy{
+		stats:   &Stats{},
+		now:     time.Now,
+		windows: map[string]*window{},
+	}
+	policy.config.Store(&cfg)
+	published := new(expvar.Map).Init()
+	published.Set("allowed", &policy.stats.Allowed)
+	published.Set("rejected", &policy.stats.Rejected)
+	published.Set("would_reject", &policy.stats.WouldReject)
+	stats.Set(cfg.Name, published)
+	return policy
+}
+
+// New creates a middleware enforcing or monitoring a new policy.
+func New(config ...Config) fiber.Handler {
+	return NewPolicy(config...).Handler()
+}
+
+// Stats returns the counters of the policy.
+func (p *Policy) Stats() *Stats {
+	return p.stats
+}
+
+// Update changes the Max, Window, Mode and EnforceFrom of a running
+// policy, with the defaults of New. Windows already counting keep their
+// end.
+func (p *Policy) Update(limits Config) {
+	cfg := *p.config.Load()
+	cfg.Max, cfg.Window, cfg.Mode, cfg.EnforceFrom = limits.Max, limits.Window, limits.Mode, limits.EnforceFrom
+	cfg = configDefault(cfg)
+	p.config.Store(&cfg)
+}
+
+// Enforcing reports whether requests over the limit are rejected now.
+func (p *Policy) Enforcing() bool {
+	return p.enforcing(p.config.Load())
+}
+
+func (p *Policy) enforcing(cfg *Config) bool {
+	if cfg.Mode == ModeEnforce {
+		return true
+	}
+	return !cfg.EnforceFrom.IsZero() && !p.now().Before(cfg.EnforceFrom)
+}
+
+// Handler returns the middleware for the policy.
+func (p *Policy) Handler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		cfg := p.config.Load()
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		limit := cfg.Max
+		if cfg.Limit != nil {
+			if own := cfg.Limit(ctx); own > 0 {
+				limit = own
+			}
+		}
+		count, reset := p.hit(cfg.KeyGenerator(ctx), cfg.Window)
+		remaining := limit - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		resetIn := strconv.Itoa(int(reset.Sub(p.now()).Round(time.Second) / time.Second))
+
+		ctx.Set(HeaderLimit, strconv.Itoa(limit))
+		ctx.Set(HeaderRemaining, strconv.Itoa(remaining))
+		ctx.Set(HeaderReset, resetIn)
+
+		if count <= limit {
+			p.stats.Allowed.Add(1)
+			return ctx.Next()
+		}
+
+		if p.enforcing(cfg) {
+			p.stats.Rejected.Add(1)
+			ctx.Set(fiber.HeaderRetryAfter, resetIn)
+			return fiber.ErrTooManyRequests
+		}
+
+		p.stats.WouldReject.Add(1)
+		ctx.Set(HeaderWarning, `policy="`+cfg.Name+`"; would-reject`)
+		return ctx.Next()
+	}
+}
+
+// Usage returns the requests counted for key in the current window and
+// when the window ends, or zero values when none was.
+func (p *Policy) Usage(key string) (int, time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	current, ok := p.windows[key]
+	if !ok || !p.now().Before(current.reset) {
+		return 0, time.Time{}
+	}
+	return current.count, current.reset
+}
+
+// hit counts a request for key and returns the count in the current
+// window and when the window ends.
+func (p *Policy) hit(key string, length time.Duration) (int, time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	current, ok := p.windows[key]
+	if !ok || !now.Before(current.reset) {
+		if len(p.windows) > 10000 {
+			for k, w := range p.windows {
+				if !now.Before(w.reset) {
+					delete(p.windows, k)
+				}
+			}
+		}
+		current = &window{reset: now.Add(length)}
+		p.windows[key] = current
+	}
+	current.count++
+	return current.count, current.reset
+}
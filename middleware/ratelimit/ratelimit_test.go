@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(policy *Policy) *fiber.App {
+	app := fiber.New()
+	app.Use(policy.Handler())
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+	return app
+}
+
+func TestEnforce(t *testing.T) {
+	policy := NewPolicy(Config{Name: "test-enforce", Max: 2, Window: time.Minute})
+	app := newApp(policy)
+
+	for i := 0; i < 2; i++ {
+		response, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+	}
+	response, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 429, response.StatusCode)
+	assert.Equal(t, "0", response.Header.Get(HeaderRemaining))
+	assert.NotEmpty(t, response.Header.Get("Retry-After"))
+	assert.Equal(t, int64(1), policy.Stats().Rejected.Value())
+}
+
+func TestLimitPerKey(t *testing.T) {
+	policy := NewPolicy(Config{
+		Name: "test-limit",
+		Max:  1,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.Get("X-Plan")
+		},
+		Limit: func(c *fiber.Ctx) int {
+			if c.Get("X-Plan") == "pro" {
+				return 3
+			}
+			return 0
+		},
+	})
+	app := newApp(policy)
+
+	request := func(plan string) int {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Plan", plan)
+		response, err := app.Test(r)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+	assert.Equal(t, 200, request("free"))
+	assert.Equal(t, 429, request("free"))
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 200, request("pro"))
+	}
+	assert.Equal(t, 429, request("pro"))
+
+	count, reset := policy.Usage("pro")
+	assert.Equal(t, 4, count)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), reset, time.Second)
+	count, _ = policy.Usage("enterprise")
+	assert.Equal(t, 0, count)
+}
+
+func TestMonitorThenEnforce(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	policy := NewPolicy(Config{
+		Name:        "test-monitor",
+		Max:         1,
+		Window:      time.Minute,
+		Mode:        ModeMonitor,
+		EnforceFrom: now.Add(time.Hour),
+	})
+	policy.now = func() time.Time { return now }
+	app := newApp(policy)
+
+	response, _ := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Empty(t, response.Header.Get(HeaderWarning))
+
+	response, _ = app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Contains(t, response.Header.Get(HeaderWarning), `policy="test-monitor"`)
+	assert.Equal(t, int64(1), policy.Stats().WouldReject.Value())
+
+	// The trial is over: the same traffic is now rejected.
+	now = now.Add(time.Hour)
+	app.Test(httptest.NewRequest("GET", "/", nil))
+	response, _ = app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, 429, response.StatusCode)
+	assert.Equal(t, int64(1), policy.Stats().Rejected.Value())
+}
+
+func TestUpdate(t *testing.T) {
+	policy := NewPolicy(Config{Name: "test-update", Max: 1, Mode: ModeMonitor})
+	app := newApp(policy)
+
+	app.Test(httptest.NewRequest("GET", "/", nil))
+	response, _ := app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, 200, response.StatusCode)
+
+	policy.Update(Config{Max: 2, Mode: ModeEnforce})
+	assert.True(t, policy.Enforcing())
+	response, _ = app.Test(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, 429, response.StatusCode)
+	assert.Equal(t, "2", response.Header.Get(HeaderLimit))
+}
+
+func TestPublishedStats(t *testing.T) {
+	NewPolicy(Config{Name: "test-published"})
+	assert.Contains(t, stats.Get("test-published").String(), `"would_reject": 0`)
+}
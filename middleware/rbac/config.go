@@ -0,0 +1,37 @@
+package rbac
+
+import "github.com/gofiber/fiber/v2"
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Roles lists the roles allowed through; any one of them is enough.
+	//
+	// Required.
+	Roles []string
+
+	// ContextKey is the Locals key holding the caller's role or roles
+	// (string or []string), set by an authentication middleware.
+	//
+	// Optional. Default: "role"
+	ContextKey string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	ContextKey: "role",
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.ContextKey == "" {
+		cfg.ContextKey = ConfigDefault.ContextKey
+	}
+	return cfg
+}
@@ -0,0 +1,45 @@
+package rbac
+
+import "github.com/gofiber/fiber/v2"
+
+// New creates a middleware that answers 403 unless the caller holds one of
+// the configured roles.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		if HasRole(ctx, cfg.ContextKey, cfg.Roles...) {
+			return ctx.Next()
+		}
+		return fiber.ErrForbidden
+	}
+}
+
+// Require is shorthand for New with the default context key.
+func Require(roles ...string) fiber.Handler {
+	return New(Config{Roles: roles})
+}
+
+// HasRole reports whether the role(s) stored under key include any of roles.
+func HasRole(ctx *fiber.Ctx, key string, roles ...string) bool {
+	var held []string
+	switch value := ctx.Locals(key).(type) {
+	case string:
+		held = []string{value}
+	case []string:
+		held = value
+	}
+
+	for _, h := range held {
+		for _, role := range roles {
+			if h == role {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package rbac
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRole(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(ctx *fiber.Ctx) error {
+		if role := ctx.Get("X-Role"); role != "" {
+			ctx.Locals("role", role)
+		}
+		return ctx.Next()
+	})
+	app.Get("/admin", Require("admin"), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("OK")
+	})
+
+	request := httptest.NewRequest("GET", "/admin", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 403, response.StatusCode)
+
+	request = httptest.NewRequest("GET", "/admin", nil)
+	request.Header.Set("X-Role", "user")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 403, response.StatusCode)
+
+	request = httptest.NewRequest("GET", "/admin", nil)
+	request.Header.Set("X-Role", "admin")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+}
@@ -0,0 +1,38 @@
+package realip
+
+import "github.com/gofiber/fiber/v2"
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// TrustedProxies lists the networks, in CIDR notation or as single
+	// addresses, of the proxies whose headers are believed. Empty trusts
+	// none: the client is always the peer.
+	//
+	// Optional. Default: nil
+	TrustedProxies []string
+
+	// Headers carry the client address set by trusted proxies, tried in
+	// order. Lists, as in X-Forwarded-For, are read right to left.
+	//
+	// Optional. Default: []string{"X-Forwarded-For", "X-Real-IP"}
+	Headers []string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Headers: []string{fiber.HeaderXForwardedFor, "X-Real-IP"},
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+	cfg := config[0]
+	if len(cfg.Headers) == 0 {
+		cfg.Headers = ConfigDefault.Headers
+	}
+	return cfg
+}
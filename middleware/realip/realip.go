@@ -0,0 +1,100 @@
+// Package realip finds the address of the client behind trusted proxies.
+// Forwarding headers are only believed when the peer is a trusted proxy,
+// and of X-Forwarded-For only the entries added by trusted proxies, so a
+// client cannot pass itself off as another address by sending them.
+package realip
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// LocalsKey is the Locals key the client address is stored under.
+const LocalsKey = "realip"
+
+// New creates a middleware storing the client address for IP; it panics
+// on an invalid network in TrustedProxies.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	var trusted []netip.Prefix
+	for _, s := range cfg.TrustedProxies {
+		network, err := parsePrefix(s)
+		if err != nil {
+			panic("realip: invalid trusted proxy " + s)
+		}
+		trusted = append(trusted, network)
+	}
+	isTrusted := func(addr netip.Addr) bool {
+		for _, network := range trusted {
+			if network.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+		peer, _ := netip.AddrFromSlice(c.Context().RemoteIP())
+		client := peer.Unmap()
+		if isTrusted(client) {
+			for _, header := range cfg.Headers {
+				if addr, ok := fromHeader(c.Get(header), isTrusted); ok {
+					client = addr
+					break
+				}
+			}
+		}
+		c.Locals(LocalsKey, client.String())
+		return c.Next()
+	}
+}
+
+// fromHeader returns the rightmost address of the list in value not of a
+// trusted proxy, or the leftmost when all of them are.
+func fromHeader(value string, isTrusted func(netip.Addr) bool) (netip.Addr, bool) {
+	var client netip.Addr
+	for value != "" {
+		i := strings.LastIndexByte(value, ',')
+		entry := strings.TrimSpace(value[i+1:])
+		value = value[:max(i, 0)]
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			// What a trusted proxy did not add is unknown.
+			break
+		}
+		client = addr.Unmap()
+		if !isTrusted(client) {
+			break
+		}
+	}
+	return client, client.IsValid()
+}
+
+// IP returns the address of the client sending the request, as found by
+// the middleware, or the peer's when it did not run.
+func IP(c *fiber.Ctx) string {
+	if ip, ok := c.Locals(LocalsKey).(string); ok {
+		return ip
+	}
+	return utils.CopyString(c.IP())
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	return netip.ParsePrefix(s)
+}
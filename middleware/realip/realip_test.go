@@ -0,0 +1,65 @@
+package realip
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func clientIP(t *testing.T, app *fiber.App, headers map[string]string) string {
+	request := httptest.NewRequest("GET", "/", nil)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	return response.Header.Get("X-Client-IP")
+}
+
+func newApp(config ...Config) *fiber.App {
+	app := fiber.New()
+	app.Use(New(config...))
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set("X-Client-IP", IP(c))
+		return nil
+	})
+	return app
+}
+
+func TestUntrustedPeer(t *testing.T) {
+	// Test requests come from 0.0.0.0.
+	app := newApp(Config{TrustedProxies: []string{"10.0.0.0/8"}})
+	assert.Equal(t, "0.0.0.0", clientIP(t, app, map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Real-IP": "203.0.113.8"}))
+}
+
+func TestTrustedPeer(t *testing.T) {
+	app := newApp(Config{TrustedProxies: []string{"0.0.0.0", "10.0.0.0/8"}})
+
+	assert.Equal(t, "0.0.0.0", clientIP(t, app, nil))
+	assert.Equal(t, "203.0.113.7", clientIP(t, app, map[string]string{"X-Forwarded-For": "203.0.113.7"}))
+	// Entries left of the first untrusted one could be made up by the
+	// client.
+	assert.Equal(t, "203.0.113.7", clientIP(t, app, map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7, 10.1.1.1"}))
+	assert.Equal(t, "2001:db8::7", clientIP(t, app, map[string]string{"X-Forwarded-For": "2001:db8::7,10.1.1.1"}))
+	assert.Equal(t, "10.2.2.2", clientIP(t, app, map[string]string{"X-Forwarded-For": "10.2.2.2, 10.1.1.1"}))
+	assert.Equal(t, "0.0.0.0", clientIP(t, app, map[string]string{"X-Forwarded-For": "unknown"}))
+	assert.Equal(t, "203.0.113.8", clientIP(t, app, map[string]string{"X-Real-IP": "203.0.113.8"}))
+	assert.Equal(t, "203.0.113.7", clientIP(t, app, map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Real-IP": "203.0.113.8"}))
+}
+
+func TestHeaders(t *testing.T) {
+	app := newApp(Config{TrustedProxies: []string{"0.0.0.0"}, Headers: []string{"CF-Connecting-IP"}})
+	assert.Equal(t, "203.0.113.9", clientIP(t, app, map[string]string{"CF-Connecting-IP": "203.0.113.9", "X-Forwarded-For": "203.0.113.7"}))
+	assert.Panics(t, func() { New(Config{TrustedProxies: []string{"proxy.internal"}}) })
+}
+
+func TestIPWithoutMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set("X-Client-IP", IP(c))
+		return nil
+	})
+	assert.Equal(t, "0.0.0.0", clientIP(t, app, map[string]string{"X-Forwarded-For": "203.0.113.7"}))
+}
@@ -0,0 +1,57 @@
+package secure
+
+import "github.com/gofiber/fiber/v2"
+
+// Policy is the set of security headers sent with a response. Empty
+// fields are not sent.
+type Policy struct {
+	ContentSecurityPolicy string
+	FrameOptions          string
+	ContentTypeOptions    string
+	ReferrerPolicy        string
+	PermissionsPolicy     string
+}
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Policy applies to every route without an override.
+	//
+	// Optional. Default: PolicyDefault
+	Policy Policy
+
+	// Overrides replaces Policy for paths starting with the given prefix;
+	// the longest matching prefix wins.
+	//
+	// Optional. Default: nil
+	Overrides map[string]Policy
+}
+
+// PolicyDefault is a strict policy suited to JSON APIs and server rendered
+// pages without inline scripts.
+var PolicyDefault = Policy{
+	ContentSecurityPolicy: "default-src 'self'; base-uri 'self'; object-src 'none'; frame-ancestors 'none'; form-action 'self'",
+	FrameOptions:          "DENY",
+	ContentTypeOptions:    "nosniff",
+	ReferrerPolicy:        "strict-origin-when-cross-origin",
+	PermissionsPolicy:     "camera=(), microphone=(), geolocation=(), payment=()",
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Policy: PolicyDefault,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.Policy == (Policy{}) {
+		cfg.Policy = ConfigDefault.Policy
+	}
+	return cfg
+}
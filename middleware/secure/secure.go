@@ -0,0 +1,49 @@
+package secure
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// New creates a middleware setting CSP, X-Frame-Options,
+// X-Content-Type-Options, Referrer-Policy and Permissions-Policy.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	prefixes := make([]string, 0, len(cfg.Overrides))
+	for prefix := range cfg.Overrides {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		policy := cfg.Policy
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(ctx.Path(), prefix) {
+				policy = cfg.Overrides[prefix]
+				break
+			}
+		}
+
+		set(ctx, fiber.HeaderContentSecurityPolicy, policy.ContentSecurityPolicy)
+		set(ctx, fiber.HeaderXFrameOptions, policy.FrameOptions)
+		set(ctx, fiber.HeaderXContentTypeOptions, policy.ContentTypeOptions)
+		set(ctx, fiber.HeaderReferrerPolicy, policy.ReferrerPolicy)
+		set(ctx, fiber.HeaderPermissionsPolicy, policy.PermissionsPolicy)
+		return ctx.Next()
+	}
+}
+
+func set(ctx *fiber.Ctx, key, value string) {
+	if value != "" {
+		ctx.Set(key, value)
+	}
+}
@@ -0,0 +1,47 @@
+package secure
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyOverrides(t *testing.T) {
+	monitor := PolicyDefault
+	monitor.ContentSecurityPolicy = "default-src 'self' 'unsafe-inline'"
+	monitor.FrameOptions = "SAMEORIGIN"
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Overrides: map[string]Policy{
+			"/admin":         {ContentTypeOptions: "nosniff"},
+			"/admin/monitor": monitor,
+		},
+	}))
+	app.Get("/*", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("OK")
+	})
+
+	request := httptest.NewRequest("GET", "/", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, PolicyDefault.ContentSecurityPolicy, response.Header.Get("Content-Security-Policy"))
+	assert.Equal(t, "DENY", response.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "nosniff", response.Header.Get("X-Content-Type-Options"))
+	assert.Equal(t, PolicyDefault.ReferrerPolicy, response.Header.Get("Referrer-Policy"))
+	assert.Equal(t, PolicyDefault.PermissionsPolicy, response.Header.Get("Permissions-Policy"))
+
+	request = httptest.NewRequest("GET", "/admin/monitor", nil)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, "default-src 'self' 'unsafe-inline'", response.Header.Get("Content-Security-Policy"))
+	assert.Equal(t, "SAMEORIGIN", response.Header.Get("X-Frame-Options"))
+
+	request = httptest.NewRequest("GET", "/admin/users", nil)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Empty(t, response.Header.Get("Content-Security-Policy"))
+	assert.Equal(t, "nosniff", response.Header.Get("X-Content-Type-Options"))
+}
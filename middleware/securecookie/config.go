@@ -0,0 +1,42 @@
+package securecookie
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Keys are base64 encoded 32 byte keys, see GenerateKey. The first
+	// one protects the cookies set; every one is accepted on cookies
+	// received, so a new key can be put first while the cookies made
+	// with the previous ones are still in browsers.
+	//
+	// Required.
+	Keys []string
+
+	// Signed names the cookies that are signed but not encrypted: their
+	// value stays readable, e.g. by scripts, but cannot be changed.
+	//
+	// Optional. Default: nil
+	Signed []string
+
+	// Except names the cookies left in plaintext, such as those set by
+	// scripts.
+	//
+	// Optional. Default: nil
+	Except []string
+}
+
+func configDefault(config ...Config) Config {
+	var cfg Config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if len(cfg.Keys) == 0 {
+		panic("securecookie: Keys cannot be empty")
+	}
+	return cfg
+}
@@ -0,0 +1,219 @@
+// Package securecookie protects the cookies an app sets: request cookies
+// are decrypted, or verified, before handlers read them and response
+// cookies encrypted, or signed, after, so handlers only see plaintext.
+// Cookies that fail to decrypt or verify, made up or changed by clients,
+// are dropped from the request. Values are bound to their cookie name and
+// cannot be moved from one cookie to another.
+package securecookie
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+var errInvalid = errors.New("securecookie: invalid value")
+
+// key is one of Config.Keys, split in keys for encrypting and signing.
+type key struct {
+	aead cipher.AEAD
+	sign []byte
+}
+
+func parseKey(encoded string) (*key, error) {
+	secret, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(secret) != 32 {
+		return nil, errors.New("securecookie: keys must be 32 bytes, base64 encoded")
+	}
+	block, err := aes.NewCipher(derive(secret, "encrypt"))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &key{aead: aead, sign: derive(secret, "sign")}, nil
+}
+
+// derive returns a key of its own for purpose.
+func derive(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("securecookie " + purpose))
+	return mac.Sum(nil)
+}
+
+// GenerateKey returns a new random key for Config.Keys.
+func GenerateKey() string {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(secret)
+}
+
+// Codec encrypts and signs cookie values with the first of its keys and
+// decrypts and verifies them with any.
+type Codec struct {
+	keys []*key
+}
+
+// NewCodec parses keys, as in Config.Keys.
+func NewCodec(keys ...string) (*Codec, error) {
+	codec := &Codec{}
+	for _, encoded := range keys {
+		k, err := parseKey(encoded)
+		if err != nil {
+			return nil, err
+		}
+		codec.keys = append(codec.keys, k)
+	}
+	if len(codec.keys) == 0 {
+		return nil, errors.New("securecookie: no keys")
+	}
+	return codec, nil
+}
+
+// Encrypt returns value encrypted for the cookie name.
+func (c *Codec) Encrypt(name, value string) (string, error) {
+	aead := c.keys[0].aead
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the value Encrypt encrypted for the cookie name.
+func (c *Codec) Decrypt(name, encrypted string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", errInvalid
+	}
+	for _, k := range c.keys {
+		size := k.aead.NonceSize()
+		if len(sealed) < size {
+			return "", errInvalid
+		}
+		if value, err := k.aead.Open(nil, sealed[:size], sealed[size:], []byte(name)); err == nil {
+			return string(value), nil
+		}
+	}
+	return "", errInvalid
+}
+
+// Sign returns value followed by its signature for the cookie name.
+func (c *Codec) Sign(name, value string) string {
+	return value + "." + base64.RawURLEncoding.EncodeToString(signature(c.keys[0], name, value))
+}
+
+// Verify returns the value Sign signed for the cookie name.
+func (c *Codec) Verify(name, signed string) (string, error) {
+	i := strings.LastIndexByte(signed, '.')
+	if i < 0 {
+		return "", errInvalid
+	}
+	value := signed[:i]
+	mac, err := base64.RawURLEncoding.DecodeString(signed[i+1:])
+	if err != nil {
+		return "", errInvalid
+	}
+	for _, k := range c.keys {
+		if hmac.Equal(mac, signature(k, name, value)) {
+			return value, nil
+		}
+	}
+	return "", errInvalid
+}
+
+func signature(k *key, name, value string) []byte {
+	mac := hmac.New(sha256.New, k.sign)
+	mac.Write([]byte(name + "=" + value))
+	return mac.Sum(nil)
+}
+
+// New creates a middleware protecting cookies with the keys of config.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+	codec, err := NewCodec(cfg.Keys...)
+	if err != nil {
+		panic(err)
+	}
+
+	// open turns the value of a received cookie back to plaintext, and
+	// seal protects the value of a cookie set.
+	open := func(name, value string) (string, error) {
+		if slices.Contains(cfg.Signed, name) {
+			return codec.Verify(name, value)
+		}
+		return codec.Decrypt(name, value)
+	}
+	seal := func(name, value string) (string, error) {
+		if slices.Contains(cfg.Signed, name) {
+			return codec.Sign(name, value), nil
+		}
+		return codec.Encrypt(name, value)
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		header := &c.Request().Header
+		var opened [][2]string
+		header.VisitAllCookie(func(name, value []byte) {
+			if slices.Contains(cfg.Except, string(name)) {
+				return
+			}
+			plain, err := open(string(name), string(value))
+			if err != nil {
+				plain = ""
+			}
+			opened = append(opened, [2]string{string(name), plain})
+		})
+		for _, cookie := range opened {
+			if cookie[1] == "" {
+				header.DelCookie(cookie[0])
+			} else {
+				header.SetCookie(cookie[0], cookie[1])
+			}
+		}
+
+		err := c.Next()
+
+		var names []string
+		c.Response().Header.VisitAllCookie(func(name, _ []byte) {
+			if !slices.Contains(cfg.Except, string(name)) {
+				names = append(names, string(name))
+			}
+		})
+		for _, name := range names {
+			cookie := fasthttp.AcquireCookie()
+			cookie.SetKey(name)
+			// Cleared cookies have no value to protect.
+			if c.Response().Header.Cookie(cookie) && len(cookie.Value()) > 0 {
+				sealed, sealErr := seal(name, string(cookie.Value()))
+				if sealErr != nil {
+					fasthttp.ReleaseCookie(cookie)
+					return fmt.Errorf("securecookie: %w", sealErr)
+				}
+				cookie.SetValue(sealed)
+				c.Response().Header.SetCookie(cookie)
+			}
+			fasthttp.ReleaseCookie(cookie)
+		}
+		return err
+	}
+}
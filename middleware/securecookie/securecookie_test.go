@@ -0,0 +1,88 @@
+package securecookie
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(keys ...string) *fiber.App {
+	app := fiber.New()
+	app.Use(New(Config{Keys: keys, Signed: []string{"consent"}, Except: []string{"lang"}}))
+	app.Get("/set", func(c *fiber.Ctx) error {
+		for _, name := range []string{"lastname", "consent", "lang"} {
+			c.Cookie(&fiber.Cookie{Name: name, Value: c.Query(name)})
+		}
+		return nil
+	})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(c.Cookies("lastname") + "|" + c.Cookies("consent") + "|" + c.Cookies("lang"))
+	})
+	return app
+}
+
+func get(t *testing.T, app *fiber.App, target string, cookies ...*http.Cookie) (map[string]string, string) {
+	request := httptest.NewRequest("GET", target, nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	set := map[string]string{}
+	for _, cookie := range response.Cookies() {
+		set[cookie.Name] = cookie.Value
+	}
+	body, _ := io.ReadAll(response.Body)
+	return set, string(body)
+}
+
+func TestRoundTrip(t *testing.T) {
+	key := GenerateKey()
+	app := newApp(key)
+
+	set, _ := get(t, app, "/set?lastname=Seif&consent=analytics&lang=id")
+	assert.NotContains(t, set["lastname"], "Seif")
+	assert.True(t, strings.HasPrefix(set["consent"], "analytics."), "signed cookies stay readable")
+	assert.Equal(t, "id", set["lang"])
+
+	cookies := []*http.Cookie{
+		{Name: "lastname", Value: set["lastname"]},
+		{Name: "consent", Value: set["consent"]},
+		{Name: "lang", Value: "en"},
+	}
+	_, body := get(t, app, "/", cookies...)
+	assert.Equal(t, "Seif|analytics|en", body)
+
+	// Rotated: the old key still opens cookies, the new one seals them.
+	rotated := newApp(GenerateKey(), key)
+	_, body = get(t, rotated, "/", cookies...)
+	assert.Equal(t, "Seif|analytics|en", body)
+	reset, _ := get(t, rotated, "/set?lastname=Seif")
+	_, body = get(t, app, "/", &http.Cookie{Name: "lastname", Value: reset["lastname"]})
+	assert.Equal(t, "||", body, "the old key alone does not open cookies of the new one")
+}
+
+func TestRejectsTampering(t *testing.T) {
+	app := newApp(GenerateKey())
+	set, _ := get(t, app, "/set?lastname=Seif&consent=analytics")
+
+	_, body := get(t, app, "/",
+		&http.Cookie{Name: "lastname", Value: "Seif"},
+		&http.Cookie{Name: "consent", Value: "marketing" + strings.TrimPrefix(set["consent"], "analytics")},
+	)
+	assert.Equal(t, "||", body)
+
+	// A value is only valid for the cookie it was set as.
+	_, body = get(t, app, "/", &http.Cookie{Name: "consent", Value: set["lastname"]}, &http.Cookie{Name: "lastname", Value: set["lastname"]})
+	assert.Equal(t, "Seif||", body)
+}
+
+func TestKeys(t *testing.T) {
+	assert.Panics(t, func() { New() })
+	assert.Panics(t, func() { New(Config{Keys: []string{"short"}}) })
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Exporter receives the sampled spans.
+	//
+	// Optional. Default: NewWriterExporter(os.Stdout)
+	Exporter Exporter
+
+	// Sampler decides which finished requests are exported.
+	//
+	// Optional. Default: Sampler{Rate: 0.1, Errors: true, Slow: time.Second}
+	Sampler *Sampler
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Sampler: &Sampler{Rate: 0.1, Errors: true, Slow: time.Second},
+}
+
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Exporter == nil {
+		cfg.Exporter = NewWriterExporter(os.Stdout)
+	}
+	if cfg.Sampler == nil {
+		cfg.Sampler = ConfigDefault.Sampler
+	}
+	return cfg
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"time"
+)
+
+// Reasons a span was kept, reported in Span.SampledBy.
+const (
+	ReasonParent = "parent"
+	ReasonError  = "error"
+	ReasonSlow   = "slow"
+	ReasonRate   = "rate"
+)
+
+// Sampler keeps every failed or slow request and a fraction of the rest,
+// so traces stay affordable at high request rates without losing the
+// interesting ones.
+type Sampler struct {
+	// Rate is the fraction, 0 to 1, of ordinary requests kept.
+	Rate float64
+	// Errors keeps every request answered with a 5xx or failing with an
+	// error.
+	Errors bool
+	// Slow keeps every request taking at least this long. Zero disables
+	// it.
+	Slow time.Duration
+	// Routes overrides Rate per route, keyed by the registered path such
+	// as "/api/v1/users/:id". A key ending in "*" matches by prefix, and
+	// the longest matching key wins.
+	Routes map[string]float64
+}
+
+// Decide returns why span should be kept, or "" to drop it.
+func (s *Sampler) Decide(span *Span) string {
+	if span.ParentSampled {
+		return ReasonParent
+	}
+	if s.Errors && (span.Status >= 500 || span.Error != "") {
+		return ReasonError
+	}
+	if s.Slow > 0 && span.Duration >= s.Slow {
+		return ReasonSlow
+	}
+	if keep(span.TraceID, s.rate(span.Route)) {
+		return ReasonRate
+	}
+	return ""
+}
+
+func (s *Sampler) rate(route string) float64 {
+	if rate, ok := s.Routes[route]; ok {
+		return rate
+	}
+	rate, longest := s.Rate, -1
+	for pattern, override := range s.Routes {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && strings.HasPrefix(route, prefix) && len(prefix) > longest {
+			rate, longest = override, len(prefix)
+		}
+	}
+	return rate
+}
+
+// keep samples by trace ID rather than at random, so every service
+// seeing the same trace with the same rate makes the same decision.
+func keep(traceID [16]byte, rate float64) bool {
+	switch {
+	case rate <= 0:
+		return false
+	case rate >= 1:
+		return true
+	}
+	return binary.BigEndian.Uint64(traceID[8:]) < uint64(rate*math.MaxUint64)
+}
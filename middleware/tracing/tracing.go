@@ -0,0 +1,166 @@
+// Package tracing records a span per request and exports the ones chosen
+// by a Sampler. Trace context is read from and passed on with the W3C
+// traceparent header.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// HeaderTraceParent carries the trace context between services.
+const HeaderTraceParent = "traceparent"
+
+// stats is published on /debug/vars as "tracing".
+var stats = expvar.NewMap("tracing")
+
+// Span describes one request.
+type Span struct {
+	TraceID       [16]byte      `json:"-"`
+	SpanID        [8]byte       `json:"-"`
+	ParentID      [8]byte       `json:"-"`
+	ParentSampled bool          `json:"-"`
+	Method        string        `json:"method"`
+	Path          string        `json:"path"`
+	Route         string        `json:"route"`
+	Status        int           `json:"status"`
+	Error         string        `json:"error,omitempty"`
+	Start         time.Time     `json:"start"`
+	Duration      time.Duration `json:"duration"`
+	SampledBy     string        `json:"sampled_by"`
+}
+
+// TraceParent formats the span as a traceparent header value.
+func (s *Span) TraceParent(sampled bool) string {
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(s.TraceID[:]) + "-" + hex.EncodeToString(s.SpanID[:]) + "-" + flags
+}
+
+// MarshalJSON adds the hex encoded IDs.
+func (s *Span) MarshalJSON() ([]byte, error) {
+	type plain Span
+	var parentID string
+	if s.ParentID != [8]byte{} {
+		parentID = hex.EncodeToString(s.ParentID[:])
+	}
+	return json.Marshal(struct {
+		TraceID  string `json:"trace_id"`
+		SpanID   string `json:"span_id"`
+		ParentID string `json:"parent_id,omitempty"`
+		*plain
+	}{hex.EncodeToString(s.TraceID[:]), hex.EncodeToString(s.SpanID[:]), parentID, (*plain)(s)})
+}
+
+// Exporter ships sampled spans.
+type Exporter interface {
+	Export(span *Span)
+}
+
+// WriterExporter writes spans as JSON lines.
+type WriterExporter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewWriterExporter writes spans to out.
+func NewWriterExporter(out io.Writer) *WriterExporter {
+	return &WriterExporter{out: out}
+}
+
+// Export implements Exporter.
+func (e *WriterExporter) Export(span *Span) {
+	line, err := json.Marshal(span)
+	if err != nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.out.Write(append(line, '\n'))
+}
+
+// New creates a tracing middleware.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		span := &Span{
+			Method: ctx.Method(),
+			Path:   utils.CopyString(ctx.Path()),
+			Start:  time.Now(),
+		}
+		if !parseTraceParent(ctx.Get(HeaderTraceParent), span) {
+			rand.Read(span.TraceID[:])
+		}
+		rand.Read(span.SpanID[:])
+		ctx.Locals("trace_id", hex.EncodeToString(span.TraceID[:]))
+
+		err := ctx.Next()
+
+		span.Duration = time.Since(span.Start)
+		span.Route = utils.CopyString(ctx.Route().Path)
+		span.Status = ctx.Response().StatusCode()
+		if err != nil {
+			span.Error = err.Error()
+			span.Status = fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				span.Status = e.Code
+			}
+		}
+
+		span.SampledBy = cfg.Sampler.Decide(span)
+		if span.SampledBy == "" {
+			stats.Add("dropped", 1)
+			return err
+		}
+		stats.Add("sampled_"+span.SampledBy, 1)
+		cfg.Exporter.Export(span)
+		return err
+	}
+}
+
+// TraceID returns the hex trace ID of the current request, or "".
+func TraceID(ctx *fiber.Ctx) string {
+	id, _ := ctx.Locals("trace_id").(string)
+	return id
+}
+
+// parseTraceParent fills the trace and parent IDs of span from a version
+// 00 traceparent header.
+func parseTraceParent(header string, span *Span) bool {
+	parts := strings.Split(header, "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return false
+	}
+	var traceID [16]byte
+	var parentID [8]byte
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || traceID == [16]byte{} {
+		return false
+	}
+	if _, err := hex.Decode(parentID[:], []byte(parts[2])); err != nil || parentID == [8]byte{} {
+		return false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	span.TraceID = traceID
+	span.ParentID = parentID
+	span.ParentSampled = flags[0]&1 == 1
+	return true
+}
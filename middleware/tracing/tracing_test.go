@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryExporter struct {
+	spans []*Span
+}
+
+func (e *memoryExporter) Export(span *Span) {
+	e.spans = append(e.spans, span)
+}
+
+func TestSamplerDecide(t *testing.T) {
+	sampler := &Sampler{
+		Rate:   0,
+		Errors: true,
+		Slow:   time.Second,
+		Routes: map[string]float64{
+			"/api/v1/login": 1,
+			"/api/*":        0,
+			"/api/v1/*":     1,
+		},
+	}
+
+	assert.Equal(t, ReasonParent, sampler.Decide(&Span{ParentSampled: true, Status: 200}))
+	assert.Equal(t, ReasonError, sampler.Decide(&Span{Status: 503}))
+	assert.Equal(t, ReasonError, sampler.Decide(&Span{Status: 200, Error: "boom"}))
+	assert.Equal(t, ReasonSlow, sampler.Decide(&Span{Status: 200, Duration: 2 * time.Second}))
+	assert.Equal(t, "", sampler.Decide(&Span{Status: 404, Route: "/"}))
+	assert.Equal(t, ReasonRate, sampler.Decide(&Span{Status: 200, Route: "/api/v1/login"}))
+	assert.Equal(t, ReasonRate, sampler.Decide(&Span{Status: 200, Route: "/api/v1/users/:id"}))
+	assert.Equal(t, "", sampler.Decide(&Span{Status: 200, Route: "/api/fx/convert"}))
+}
+
+func TestSamplerRateIsDeterministic(t *testing.T) {
+	var low, high Span
+	high.TraceID[8] = 0xf0
+	low.TraceID[8] = 0x10
+	sampler := &Sampler{Rate: 0.5}
+
+	assert.Equal(t, ReasonRate, sampler.Decide(&low))
+	assert.Equal(t, "", sampler.Decide(&high))
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter := &memoryExporter{}
+	app := fiber.New()
+	app.Use(New(Config{Exporter: exporter, Sampler: &Sampler{Errors: true}}))
+	app.Get("/ok", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(TraceID(ctx))
+	})
+	app.Get("/fail/:id", func(ctx *fiber.Ctx) error {
+		return errors.New("boom")
+	})
+
+	response, err := app.Test(httptest.NewRequest("GET", "/ok", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Len(t, exporter.spans, 0)
+
+	response, err = app.Test(httptest.NewRequest("GET", "/fail/7", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 500, response.StatusCode)
+	assert.Len(t, exporter.spans, 1)
+	assert.Equal(t, "/fail/:id", exporter.spans[0].Route)
+	assert.Equal(t, "/fail/7", exporter.spans[0].Path)
+	assert.Equal(t, ReasonError, exporter.spans[0].SampledBy)
+
+	request := httptest.NewRequest("GET", "/ok", nil)
+	request.Header.Set(HeaderTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Len(t, exporter.spans, 2)
+	span := exporter.spans[1]
+	assert.Equal(t, ReasonParent, span.SampledBy)
+	assert.Contains(t, span.TraceParent(true), "00-4bf92f3577b34da6a3ce929d0e0e4736-")
+
+	line, err := span.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Contains(t, string(line), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+	assert.Contains(t, string(line), `"parent_id":"00f067aa0ba902b7"`)
+}
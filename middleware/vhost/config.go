@@ -0,0 +1,46 @@
+package vhost
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Hosts maps host names to the handler serving their requests. A name
+	// is either exact, "api.example.com", or a wildcard matching any
+	// subdomain, "*.example.com"; exact names win over wildcards and
+	// longer wildcards over shorter ones. Handlers call c.Next() to carry
+	// on with the app's routes, see Only and Except, or serve the request
+	// themselves, see App.
+	//
+	// Required.
+	Hosts map[string]fiber.Handler
+
+	// Default serves requests to hosts not in Hosts.
+	//
+	// Optional. Default: carry on with the app's routes
+	Default fiber.Handler
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Default: func(c *fiber.Ctx) error {
+		return c.Next()
+	},
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.Default == nil {
+		cfg.Default = ConfigDefault.Default
+	}
+	return cfg
+}
@@ -0,0 +1,142 @@
+// Package vhost applies different routes and middleware depending on the
+// host name a request was made to, e.g. api.example.com and
+// www.example.com served by one app.
+package vhost
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HostKey is the Locals key of the Hosts entry a request matched.
+const HostKey = "vhost"
+
+// New creates a middleware handing requests to the handler of their host.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+	match := matcher(keys(cfg.Hosts))
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		pattern, ok := match(c.Hostname())
+		if !ok {
+			return cfg.Default(c)
+		}
+		c.Locals(HostKey, pattern)
+		return cfg.Hosts[pattern](c)
+	}
+}
+
+// Host returns the Hosts entry the request matched, or "".
+func Host(c *fiber.Ctx) string {
+	pattern, _ := c.Locals(HostKey).(string)
+	return pattern
+}
+
+// Match reports whether a request was made to one of the host names,
+// given as in Config.Hosts. It suits the Next field of other middleware,
+// to skip them on some hosts.
+func Match(patterns ...string) func(c *fiber.Ctx) bool {
+	match := matcher(patterns)
+	return func(c *fiber.Ctx) bool {
+		_, ok := match(c.Hostname())
+		return ok
+	}
+}
+
+// Only carries on with the routes under the path prefixes and answers
+// 404 for every other path.
+func Only(prefixes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !under(c.Path(), prefixes) {
+			return fiber.ErrNotFound
+		}
+		return c.Next()
+	}
+}
+
+// Except answers 404 for the routes under the path prefixes and carries
+// on with every other path.
+func Except(prefixes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if under(c.Path(), prefixes) {
+			return fiber.ErrNotFound
+		}
+		return c.Next()
+	}
+}
+
+// App serves the host's requests with app, with its own routes,
+// middleware and error handler, instead of the app using New.
+func App(app *fiber.App) fiber.Handler {
+	handler := app.Handler()
+	return func(c *fiber.Ctx) error {
+		handler(c.Context())
+		return nil
+	}
+}
+
+// under reports whether path is below one of prefixes, ignoring case as
+// the router does.
+func under(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if len(path) < len(prefix) || !strings.EqualFold(path[:len(prefix)], prefix) {
+			continue
+		}
+		if len(path) == len(prefix) || path[len(prefix)] == '/' {
+			return true
+		}
+	}
+	return false
+}
+
+// matcher returns the lookup of a host, which may carry a port, in
+// patterns.
+func matcher(patterns []string) func(host string) (string, bool) {
+	exact := map[string]string{}
+	wildcard := map[string]string{}
+	var suffixes []string
+	for _, pattern := range patterns {
+		name := strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(name, "*"); ok {
+			wildcard[suffix] = pattern
+			suffixes = append(suffixes, suffix)
+		} else {
+			exact[name] = pattern
+		}
+	}
+	sort.Slice(suffixes, func(i, j int) bool {
+		return len(suffixes[i]) > len(suffixes[j])
+	})
+
+	return func(host string) (string, bool) {
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			host = name
+		}
+		host = strings.TrimSuffix(strings.ToLower(host), ".")
+		if pattern, ok := exact[host]; ok {
+			return pattern, true
+		}
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return wildcard[suffix], true
+			}
+		}
+		return "", false
+	}
+}
+
+func keys(hosts map[string]fiber.Handler) []string {
+	patterns := make([]string, 0, len(hosts))
+	for pattern := range hosts {
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
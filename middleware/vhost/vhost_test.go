@@ -0,0 +1,101 @@
+package vhost
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func send(t *testing.T, app *fiber.App, host, path string) (int, string) {
+	request := httptest.NewRequest("GET", path, nil)
+	request.Host = host
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	return response.StatusCode, string(body)
+}
+
+func TestRouteSets(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{Hosts: map[string]fiber.Handler{
+		"api.example.com": Only("/api"),
+		"www.example.com": Except("/api"),
+	}}))
+	app.Use(func(c *fiber.Ctx) error {
+		c.Set("X-Vhost", Host(c))
+		return c.Next()
+	})
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("home") })
+	app.Get("/api/status", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	app.Get("/apidocs", func(c *fiber.Ctx) error { return c.SendString("docs") })
+
+	status, body := send(t, app, "api.example.com", "/api/status")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "ok", body)
+	status, _ = send(t, app, "API.example.com:8443", "/")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	status, _ = send(t, app, "api.example.com", "/apidocs")
+	assert.Equal(t, fiber.StatusNotFound, status)
+
+	status, _ = send(t, app, "www.example.com", "/api/status")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	status, _ = send(t, app, "www.example.com", "/API/status")
+	assert.Equal(t, fiber.StatusNotFound, status, "routes match any case")
+	status, _ = send(t, app, "api.example.com", "/Api/status")
+	assert.Equal(t, fiber.StatusOK, status)
+	status, body = send(t, app, "www.example.com", "/apidocs")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "docs", body)
+
+	// Unlisted hosts serve every route.
+	status, _ = send(t, app, "localhost:3000", "/api/status")
+	assert.Equal(t, fiber.StatusOK, status)
+	status, _ = send(t, app, "localhost:3000", "/")
+	assert.Equal(t, fiber.StatusOK, status)
+}
+
+func TestWildcardsAndApps(t *testing.T) {
+	tenants := fiber.New()
+	tenants.Get("/", func(c *fiber.Ctx) error { return c.SendString("tenant " + c.Hostname()) })
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Hosts: map[string]fiber.Handler{
+			"*.example.com":       App(tenants),
+			"*.admin.example.com": func(c *fiber.Ctx) error { return c.SendString("admin " + Host(c)) },
+			"example.com":         func(c *fiber.Ctx) error { return c.SendString("apex") },
+		},
+		Default: func(c *fiber.Ctx) error { return fiber.ErrMisdirectedRequest },
+	}))
+
+	_, body := send(t, app, "acme.example.com", "/")
+	assert.Equal(t, "tenant acme.example.com", body)
+	_, body = send(t, app, "eu.admin.example.com", "/")
+	assert.Equal(t, "admin *.admin.example.com", body)
+	_, body = send(t, app, "example.com", "/")
+	assert.Equal(t, "apex", body)
+	status, _ := send(t, app, "acme.example.com", "/missing")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	status, _ = send(t, app, "example.org", "/")
+	assert.Equal(t, fiber.StatusMisdirectedRequest, status)
+}
+
+func TestMatch(t *testing.T) {
+	app := fiber.New()
+	api := Match("api.example.com", "*.api.example.com")
+	app.Get("/", func(c *fiber.Ctx) error {
+		if api(c) {
+			return c.SendString("api")
+		}
+		return c.SendString("web")
+	})
+
+	_, body := send(t, app, "eu.api.example.com", "/")
+	assert.Equal(t, "api", body)
+	_, body = send(t, app, "www.example.com", "/")
+	assert.Equal(t, "web", body)
+}
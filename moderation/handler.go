@@ -0,0 +1,76 @@
+package moderation
+
+import (
+	"errors"
+
+	"belajar-golang-fiber/audit"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Handler is the admin API of the review queue.
+type Handler struct {
+	Moderator *Moderator
+}
+
+// Register mounts the routes on router, e.g. adminApp.Group("/moderation").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/", h.list)
+	router.Get("/:id", h.get)
+	router.Post("/:id/approve", h.decide(true))
+	router.Post("/:id/reject", h.decide(false))
+}
+
+// list answers the cases with ?status=, pending ones by default; "all"
+// lists every case.
+func (h *Handler) list(ctx *fiber.Ctx) error {
+	status := ctx.Query("status", StatusPending)
+	switch status {
+	case "all":
+		status = ""
+	case StatusPending, StatusBlocked, StatusApproved, StatusRejected:
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "unknown status")
+	}
+	cases, err := h.Moderator.Cases.List(ctx.UserContext(), status)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(cases)
+}
+
+func (h *Handler) get(ctx *fiber.Ctx) error {
+	c, err := h.Moderator.Cases.Get(ctx.UserContext(), ctx.Params("id"))
+	if errors.Is(err, ErrNotFound) {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(c)
+}
+
+// decide takes an optional {"note": "..."} body.
+func (h *Handler) decide(approved bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var body struct {
+			Note string `json:"note"`
+		}
+		if len(ctx.Body()) > 0 {
+			if err := ctx.BodyParser(&body); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "malformed body")
+			}
+		}
+		c, err := h.Moderator.Decide(ctx.UserContext(), ctx.Params("id"), approved, audit.Actor(ctx), utils.CopyString(body.Note))
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return fiber.ErrNotFound
+		case errors.Is(err, ErrDecided):
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		case err != nil:
+			return err
+		}
+		return ctx.JSON(c)
+	}
+}
@@ -0,0 +1,271 @@
+// Package moderation checks user generated content — uploads, and any
+// text a feature hands to Moderator.Check — against keyword rules and,
+// optionally, an external classification API. Content is allowed,
+// flagged for an admin to review, or blocked outright; flagged and
+// blocked content is kept as a Case in the review queue.
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/httpclient"
+	"belajar-golang-fiber/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// ErrBlocked is wrapped by the error Check returns for blocked content.
+var ErrBlocked = errors.New("moderation: content blocked")
+
+// Action is what happens to checked content, from least to most severe.
+type Action int
+
+const (
+	Allow Action = iota
+	Flag
+	Block
+)
+
+var actionNames = []string{"allow", "flag", "block"}
+
+func (a Action) String() string {
+	if a < 0 || int(a) >= len(actionNames) {
+		return "unknown"
+	}
+	return actionNames[a]
+}
+
+func (a Action) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Action) UnmarshalText(text []byte) error {
+	i := slices.Index(actionNames, string(text))
+	if i < 0 {
+		return fmt.Errorf("moderation: unknown action %q", text)
+	}
+	*a = Action(i)
+	return nil
+}
+
+// Content is one piece of user generated content. Ref identifies what
+// it belongs to, e.g. "file:<id>".
+type Content struct {
+	Kind   string `json:"kind"`
+	Ref    string `json:"ref"`
+	UserID string `json:"user_id,omitempty"`
+	Text   string `json:"text"`
+}
+
+// Verdict is the outcome of a check.
+type Verdict struct {
+	Action  Action   `json:"action"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// merge keeps the more severe action and every reason.
+func (v Verdict) merge(other Verdict) Verdict {
+	return Verdict{Action: max(v.Action, other.Action), Reasons: append(v.Reasons, other.Reasons...)}
+}
+
+// Checker judges content.
+type Checker interface {
+	Check(ctx context.Context, content Content) (Verdict, error)
+}
+
+// Keywords flags or blocks content containing any of its words or
+// phrases, ignoring case.
+type Keywords struct {
+	flag, block *regexp.Regexp
+}
+
+// NewKeywords flags content with any of flag and blocks content with any
+// of block.
+func NewKeywords(flag, block []string) *Keywords {
+	return &Keywords{flag: compile(flag), block: compile(block)}
+}
+
+// compile matches whole words only, so "class" does not hit "ass".
+func compile(words []string) *regexp.Regexp {
+	var quoted []string
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)(?:^|[^\pL\pN])(` + strings.Join(quoted, "|") + `)(?:$|[^\pL\pN])`)
+}
+
+func (k *Keywords) Check(ctx context.Context, content Content) (Verdict, error) {
+	if k.block != nil {
+		if match := k.block.FindStringSubmatch(content.Text); match != nil {
+			return Verdict{Action: Block, Reasons: []string{"keyword: " + strings.ToLower(match[1])}}, nil
+		}
+	}
+	if k.flag != nil {
+		if match := k.flag.FindStringSubmatch(content.Text); match != nil {
+			return Verdict{Action: Flag, Reasons: []string{"keyword: " + strings.ToLower(match[1])}}, nil
+		}
+	}
+	return Verdict{}, nil
+}
+
+// API asks an external classification service: Content is POSTed as
+// JSON to URL, which answers a Verdict such as
+// {"action": "flag", "reasons": ["spam"]}.
+type API struct {
+	URL    string
+	Token  string
+	Client *httpclient.Client
+}
+
+// NewAPI calls url with a bearer token when token is set.
+func NewAPI(url, token string) *API {
+	return &API{
+		URL:    url,
+		Token:  token,
+		Client: httpclient.New(httpclient.Config{Name: "moderation", Timeout: 5 * time.Second}),
+	}
+}
+
+func (a *API) Check(ctx context.Context, content Content) (Verdict, error) {
+	body, err := json.Marshal(content)
+	if err != nil {
+		return Verdict{}, err
+	}
+	header := map[string]string{fiber.HeaderContentType: fiber.MIMEApplicationJSON}
+	if a.Token != "" {
+		header[fiber.HeaderAuthorization] = "Bearer " + a.Token
+	}
+	response, err := a.Client.Do(ctx, httpclient.Request{
+		Method:     fiber.MethodPost,
+		URL:        a.URL,
+		Header:     header,
+		Body:       body,
+		Idempotent: true,
+	})
+	if err != nil {
+		return Verdict{}, err
+	}
+	if response.Status != fiber.StatusOK {
+		return Verdict{}, fmt.Errorf("moderation: api returned %d", response.Status)
+	}
+	var verdict Verdict
+	if err := json.Unmarshal(response.Body, &verdict); err != nil {
+		return Verdict{}, fmt.Errorf("moderation: api answer: %w", err)
+	}
+	return verdict, nil
+}
+
+// Moderator runs every checker on content and keeps the cases that need
+// a look.
+type Moderator struct {
+	Checkers []Checker
+	Cases    Store
+
+	hooks []func(ctx context.Context, c *Case)
+}
+
+func NewModerator(cases Store, checkers ...Checker) *Moderator {
+	return &Moderator{Checkers: checkers, Cases: cases}
+}
+
+// OnDecision registers a hook called, in order, after an admin approved
+// or rejected a case. Hooks are not safe to register once the moderator
+// is in use.
+func (m *Moderator) OnDecision(hook func(ctx context.Context, c *Case)) {
+	m.hooks = append(m.hooks, hook)
+}
+
+// Check judges content with every checker; the most severe verdict wins.
+// A checker failing flags the content, so a person looks at it rather
+// than nobody. Flagged content is queued for review and allowed; blocked
+// content is recorded and refused with an error wrapping ErrBlocked.
+func (m *Moderator) Check(ctx context.Context, content Content) (Verdict, error) {
+	var verdict Verdict
+	for _, checker := range m.Checkers {
+		result, err := checker.Check(ctx, content)
+		if err != nil {
+			logger.FromContext(ctx).Warn("moderation: checker failed", "kind", content.Kind, "ref", content.Ref, "error", err)
+			result = Verdict{Action: Flag, Reasons: []string{"checker unavailable"}}
+		}
+		verdict = verdict.merge(result)
+		if verdict.Action == Block {
+			break
+		}
+	}
+	if verdict.Action == Allow {
+		return verdict, nil
+	}
+
+	status := StatusPending
+	if verdict.Action == Block {
+		status = StatusBlocked
+	}
+	c := &Case{
+		ID:        utils.UUIDv4(),
+		Kind:      content.Kind,
+		Ref:       content.Ref,
+		UserID:    content.UserID,
+		Excerpt:   excerpt(content.Text),
+		Action:    verdict.Action,
+		Reasons:   verdict.Reasons,
+		Status:    status,
+		CreatedAt: time.Now(),
+	}
+	if err := m.Cases.Create(ctx, c); err != nil {
+		return verdict, err
+	}
+	if verdict.Action == Block {
+		return verdict, fmt.Errorf("%w: %s", ErrBlocked, strings.Join(verdict.Reasons, ", "))
+	}
+	return verdict, nil
+}
+
+// Decide records an admin's decision on the case with id. Blocked cases
+// can be decided as well, to overturn a rule.
+func (m *Moderator) Decide(ctx context.Context, id string, approved bool, reviewer, note string) (*Case, error) {
+	c, err := m.Cases.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status == StatusApproved || c.Status == StatusRejected {
+		return nil, ErrDecided
+	}
+	now := time.Now()
+	c.Status = StatusRejected
+	if approved {
+		c.Status = StatusApproved
+	}
+	c.ReviewedAt = &now
+	c.ReviewedBy = reviewer
+	c.Note = note
+	if err := m.Cases.Update(ctx, c); err != nil {
+		return nil, err
+	}
+	for _, hook := range m.hooks {
+		hook(ctx, c)
+	}
+	return c, nil
+}
+
+// excerpt keeps the start of text for reviewers.
+func excerpt(text string) string {
+	const limit = 500
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -0,0 +1,149 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeywords(t *testing.T) {
+	keywords := NewKeywords([]string{"casino"}, []string{"buy followers", "scam"})
+	ctx := context.Background()
+
+	verdict, _ := keywords.Check(ctx, Content{Text: "Best CASINO bonus"})
+	assert.Equal(t, Flag, verdict.Action)
+	assert.Equal(t, []string{"keyword: casino"}, verdict.Reasons)
+
+	verdict, _ = keywords.Check(ctx, Content{Text: "buy  followers"})
+	assert.Equal(t, Allow, verdict.Action, "phrases match as written")
+	verdict, _ = keywords.Check(ctx, Content{Text: "casino, buy followers!"})
+	assert.Equal(t, Block, verdict.Action)
+
+	verdict, _ = keywords.Check(ctx, Content{Text: "scampi for dinner"})
+	assert.Equal(t, Allow, verdict.Action)
+}
+
+type failing struct{}
+
+func (failing) Check(ctx context.Context, content Content) (Verdict, error) {
+	return Verdict{}, errors.New("down")
+}
+
+func TestModerator(t *testing.T) {
+	ctx := context.Background()
+	moderator := NewModerator(NewMemoryStore(), NewKeywords([]string{"casino"}, []string{"scam"}))
+
+	verdict, err := moderator.Check(ctx, Content{Kind: "comment", Ref: "comment:1", Text: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, Allow, verdict.Action)
+
+	_, err = moderator.Check(ctx, Content{Kind: "comment", Ref: "comment:2", Text: "casino night"})
+	assert.Nil(t, err)
+	_, err = moderator.Check(ctx, Content{Kind: "comment", Ref: "comment:3", Text: "a scam"})
+	assert.ErrorIs(t, err, ErrBlocked)
+
+	pending, _ := moderator.Cases.List(ctx, StatusPending)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "comment:2", pending[0].Ref)
+	blocked, _ := moderator.Cases.List(ctx, StatusBlocked)
+	assert.Len(t, blocked, 1)
+
+	// A checker that fails flags the content for review.
+	moderator.Checkers = append(moderator.Checkers, failing{})
+	verdict, err = moderator.Check(ctx, Content{Kind: "comment", Ref: "comment:4", Text: "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, Flag, verdict.Action)
+}
+
+func TestAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		var content Content
+		json.NewDecoder(r.Body).Decode(&content)
+		if strings.Contains(content.Text, "spam") {
+			w.Write([]byte(`{"action":"flag","reasons":["spam"]}`))
+			return
+		}
+		w.Write([]byte(`{"action":"allow"}`))
+	}))
+	defer server.Close()
+
+	api := NewAPI(server.URL, "key")
+	verdict, err := api.Check(context.Background(), Content{Text: "spam spam"})
+	assert.Nil(t, err)
+	assert.Equal(t, Verdict{Action: Flag, Reasons: []string{"spam"}}, verdict)
+	verdict, err = api.Check(context.Background(), Content{Text: "hi"})
+	assert.Nil(t, err)
+	assert.Equal(t, Allow, verdict.Action)
+}
+
+func TestUploads(t *testing.T) {
+	ctx := context.Background()
+	service := files.NewService(storage.NewLocal(t.TempDir()), files.NewMemoryRepository())
+	trash := files.NewTrash(service)
+	moderator := NewModerator(NewMemoryStore(), NewKeywords([]string{"casino"}, []string{"scam"}))
+	uploads := &Uploads{Moderator: moderator, Files: service, Trash: trash}
+	uploads.Attach()
+
+	app := fiber.New()
+	handler := &Handler{Moderator: moderator}
+	handler.Register(app.Group("/moderation"))
+
+	// Blocked by name, never stored.
+	_, err := service.Save(ctx, "scam.txt", strings.NewReader("hi"), "upload")
+	assert.ErrorIs(t, err, ErrBlocked)
+	stored, _ := service.Repository.List(ctx)
+	assert.Len(t, stored, 0)
+
+	// Blocked by content, hidden in the trash.
+	hidden, err := service.Save(ctx, "notes.txt", strings.NewReader("this is a scam"), "upload")
+	assert.Nil(t, err)
+	_, err = trash.Get(ctx, hidden.ID)
+	assert.Nil(t, err)
+
+	// Flagged by metadata, stored until rejected.
+	flagged, err := service.SaveWithMetadata(ctx, "photo.png", strings.NewReader("png"), "upload", map[string]string{"title": "casino trip"})
+	assert.Nil(t, err)
+
+	response, err := app.Test(httptest.NewRequest("GET", "/moderation", nil))
+	assert.Nil(t, err)
+	var pending []Case
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&pending))
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "file:"+flagged.ID, pending[0].Ref)
+
+	request := httptest.NewRequest("POST", "/moderation/"+pending[0].ID+"/reject", strings.NewReader(`{"note":"gambling ad"}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	_, err = trash.Get(ctx, flagged.ID)
+	assert.Nil(t, err)
+
+	response, err = app.Test(httptest.NewRequest("POST", "/moderation/"+pending[0].ID+"/approve", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 409, response.StatusCode)
+
+	// Approving the blocked content restores it.
+	blocked, _ := moderator.Cases.List(ctx, StatusBlocked)
+	assert.Len(t, blocked, 2)
+	for _, c := range blocked {
+		if c.Kind == KindUploadContent {
+			response, err = app.Test(httptest.NewRequest("POST", "/moderation/"+c.ID+"/approve", nil))
+			assert.Nil(t, err)
+			assert.Equal(t, 200, response.StatusCode)
+		}
+	}
+	_, err = trash.Get(ctx, hidden.ID)
+	assert.ErrorIs(t, err, files.ErrNotFound)
+}
@@ -0,0 +1,107 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when no case matches the given id.
+	ErrNotFound = errors.New("moderation: case not found")
+	// ErrDecided is returned when deciding a case decided before.
+	ErrDecided = errors.New("moderation: case already decided")
+)
+
+// Case statuses. Pending cases wait in the review queue; blocked ones
+// were refused by a rule and can still be overturned.
+const (
+	StatusPending  = "pending"
+	StatusBlocked  = "blocked"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Case is content that was flagged or blocked.
+type Case struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Ref        string     `json:"ref"`
+	UserID     string     `json:"user_id,omitempty"`
+	Excerpt    string     `json:"excerpt"`
+	Action     Action     `json:"action"`
+	Reasons    []string   `json:"reasons,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	Note       string     `json:"note,omitempty"`
+}
+
+// Store persists cases.
+type Store interface {
+	Create(ctx context.Context, c *Case) error
+	Get(ctx context.Context, id string) (*Case, error)
+	// List returns the cases with status, or all when empty, oldest
+	// first.
+	List(ctx context.Context, status string) ([]*Case, error)
+	Update(ctx context.Context, c *Case) error
+}
+
+// MemoryStore keeps cases in process memory.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	cases map[string]*Case
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{cases: map[string]*Case{}}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, c *Case) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *c
+	s.cases[c.ID] = &copied
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Case, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.cases[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *c
+	return &copied, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, status string) ([]*Case, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cases := []*Case{}
+	for _, c := range s.cases {
+		if status == "" || c.Status == status {
+			copied := *c
+			cases = append(cases, &copied)
+		}
+	}
+	sort.Slice(cases, func(i, j int) bool {
+		return cases[i].CreatedAt.Before(cases[j].CreatedAt)
+	})
+	return cases, nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, c *Case) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.cases[c.ID]; !ok {
+		return ErrNotFound
+	}
+	copied := *c
+	s.cases[c.ID] = &copied
+	return nil
+}
@@ -0,0 +1,107 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/logger"
+)
+
+// Kinds of the content checked by Uploads.
+const (
+	KindUpload        = "upload"
+	KindUploadContent = "upload.content"
+)
+
+// Uploads moderates the files saved through Files: their name and
+// metadata before they are stored, and the start of text files right
+// after. Text files blocked once stored, and flagged uploads an admin
+// rejects, are moved to Trash; approving a blocked one restores it.
+type Uploads struct {
+	Moderator *Moderator
+	Files     *files.Service
+	Trash     *files.Trash
+	// MaxTextBytes is how much of a text file is checked. Zero means
+	// 64 KiB.
+	MaxTextBytes int
+}
+
+// Attach registers the checks on the file service and the moderator.
+func (u *Uploads) Attach() {
+	u.Files.BeforeSave(func(ctx context.Context, file *files.File) error {
+		_, err := u.Moderator.Check(ctx, Content{
+			Kind:   KindUpload,
+			Ref:    "file:" + file.ID,
+			UserID: file.UserID,
+			Text:   describe(file),
+		})
+		return err
+	})
+	u.Files.AfterSave(func(ctx context.Context, file *files.File) {
+		if !strings.HasPrefix(file.ContentType, "text/") {
+			return
+		}
+		text, err := u.read(ctx, file)
+		if err == nil {
+			_, err = u.Moderator.Check(ctx, Content{
+				Kind:   KindUploadContent,
+				Ref:    "file:" + file.ID,
+				UserID: file.UserID,
+				Text:   text,
+			})
+		}
+		if errors.Is(err, ErrBlocked) {
+			_, err = u.Trash.Move(ctx, file.ID)
+		}
+		if err != nil {
+			logger.FromContext(ctx).Error("moderation: checking upload failed", "file_id", file.ID, "error", err)
+		}
+	})
+	u.Moderator.OnDecision(u.decided)
+}
+
+// describe is what is checked of a file before it is stored.
+func describe(file *files.File) string {
+	parts := []string{file.Name}
+	for _, key := range slices.Sorted(maps.Keys(file.Metadata)) {
+		parts = append(parts, file.Metadata[key])
+	}
+	return strings.Join(parts, "\n")
+}
+
+func (u *Uploads) read(ctx context.Context, file *files.File) (string, error) {
+	limit := u.MaxTextBytes
+	if limit <= 0 {
+		limit = 64 << 10
+	}
+	_, content, err := u.Files.Open(ctx, file.ID)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+	data, err := io.ReadAll(io.LimitReader(content, int64(limit)))
+	return strings.ToValidUTF8(string(data), ""), err
+}
+
+func (u *Uploads) decided(ctx context.Context, c *Case) {
+	if c.Kind != KindUpload && c.Kind != KindUploadContent {
+		return
+	}
+	id, _ := strings.CutPrefix(c.Ref, "file:")
+	var err error
+	switch {
+	case c.Status == StatusRejected && c.Action == Flag:
+		_, err = u.Trash.Move(ctx, id)
+	case c.Status == StatusApproved && c.Action == Block:
+		// Files blocked before they were stored do not exist.
+		_, err = u.Trash.Restore(ctx, id)
+	}
+	if err != nil && !errors.Is(err, files.ErrNotFound) {
+		logger.FromContext(ctx).Error("moderation: applying decision failed", "case_id", c.ID, "file_id", id, "error", err)
+	}
+}
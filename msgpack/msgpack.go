@@ -0,0 +1,310 @@
+// Package msgpack encodes and decodes MessagePack, a binary JSON for
+// internal clients that move enough data for JSON to cost. Values map to
+// MessagePack the way encoding/json maps them to JSON, json struct tags
+// included, so the DTOs of the API serve both.
+package msgpack
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/jsoncodec"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// MIMEApplicationMsgPack is the media type of MessagePack. Requests may
+// also be sent as application/x-msgpack or application/vnd.msgpack.
+const MIMEApplicationMsgPack = "application/msgpack"
+
+// ErrTrailingData rejects input holding more than one value.
+var ErrTrailingData = errors.New("msgpack: trailing data after value")
+
+// IsMIME reports whether contentType, without parameters, is MessagePack.
+func IsMIME(contentType string) bool {
+	switch contentType {
+	case MIMEApplicationMsgPack, "application/x-msgpack", "application/vnd.msgpack":
+		return true
+	}
+	return false
+}
+
+// Marshal encodes v. Structs become maps keyed as their json tags say,
+// time.Time an RFC 3339 string, and []byte binary data; other
+// json.Marshaler values are encoded from their JSON.
+func Marshal(v any) ([]byte, error) {
+	return appendValue(nil, reflect.ValueOf(v))
+}
+
+// Unmarshal decodes data into out through the JSON decoder of the app,
+// so json struct tags, and the checks binding makes on JSON, apply to
+// both alike. Binary data arrives as base64, as []byte fields expect.
+func Unmarshal(data []byte, out any) error {
+	converted, err := ToJSON(data)
+	if err != nil {
+		return err
+	}
+	return jsoncodec.Unmarshal(converted, out)
+}
+
+// ToJSON converts one MessagePack value to JSON.
+func ToJSON(data []byte) ([]byte, error) {
+	rest, err := msgp.Skip(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ErrTrailingData
+	}
+	var out bytes.Buffer
+	if _, err := msgp.UnmarshalAsJSON(&out, data); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// FromJSON converts one JSON value to MessagePack, keeping integers
+// integers.
+func FromJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, ErrTrailingData
+	}
+	return msgp.AppendIntf(nil, value)
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func appendValue(b []byte, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return msgp.AppendNil(b), nil
+	}
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return msgp.AppendNil(b), nil
+	}
+
+	switch {
+	case v.Type() == timeType:
+		return msgp.AppendString(b, v.Interface().(time.Time).Format(time.RFC3339Nano)), nil
+	case v.Type().Implements(jsonMarshalerType):
+		data, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return b, err
+		}
+		converted, err := FromJSON(data)
+		return append(b, converted...), err
+	case v.Type().Implements(textMarshalerType):
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return msgp.AppendStringFromBytes(b, text), err
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return appendValue(b, v.Elem())
+	case reflect.Bool:
+		return msgp.AppendBool(b, v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return msgp.AppendInt64(b, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return msgp.AppendUint64(b, v.Uint()), nil
+	case reflect.Float32:
+		return msgp.AppendFloat32(b, float32(v.Float())), nil
+	case reflect.Float64:
+		return msgp.AppendFloat64(b, v.Float()), nil
+	case reflect.String:
+		return msgp.AppendString(b, v.String()), nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return msgp.AppendNil(b), nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return msgp.AppendBytes(b, v.Bytes()), nil
+		}
+		fallthrough
+	case reflect.Array:
+		b = msgp.AppendArrayHeader(b, uint32(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			var err error
+			if b, err = appendValue(b, v.Index(i)); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return msgp.AppendNil(b), nil
+		}
+		b = msgp.AppendMapHeader(b, uint32(v.Len()))
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := mapKey(iter.Key())
+			if err != nil {
+				return b, err
+			}
+			b = msgp.AppendString(b, key)
+			if b, err = appendValue(b, iter.Value()); err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case reflect.Struct:
+		return appendStruct(b, v)
+	}
+	return b, &json.UnsupportedTypeError{Type: v.Type()}
+}
+
+// mapKey turns a map key into a string as encoding/json does.
+func mapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if marshaler, ok := key.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", &json.UnsupportedTypeError{Type: key.Type()}
+}
+
+func appendStruct(b []byte, v reflect.Value) ([]byte, error) {
+	fields := fieldsFor(v.Type())
+	values := make([]reflect.Value, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		value, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && empty(value)) {
+			continue
+		}
+		values = append(values, value)
+		names = append(names, f.name)
+	}
+
+	b = msgp.AppendMapHeader(b, uint32(len(values)))
+	for i, value := range values {
+		b = msgp.AppendString(b, names[i])
+		var err error
+		if b, err = appendValue(b, value); err != nil {
+			return b, err
+		}
+	}
+	return b, nil
+}
+
+// fieldByIndex is v.FieldByIndex, reporting false instead of panicking at
+// a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func empty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// field is an encoded struct field, reached from the struct by index.
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// fields caches the encoded fields per struct type.
+var fields sync.Map
+
+func fieldsFor(typ reflect.Type) []field {
+	if cached, ok := fields.Load(typ); ok {
+		return cached.([]field)
+	}
+	list := collectFields(typ, nil, map[string]bool{})
+	fields.Store(typ, list)
+	return list
+}
+
+// collectFields lists the fields of typ as encoding/json names them,
+// promoting those of untagged embedded structs; a field of the outer
+// struct hides an embedded one of the same name.
+func collectFields(typ reflect.Type, index []int, seen map[string]bool) []field {
+	var list, embedded []field
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		at := append(append([]int{}, index...), i)
+
+		if sf.Anonymous && name == "" {
+			inner := sf.Type
+			if inner.Kind() == reflect.Pointer {
+				inner = inner.Elem()
+			}
+			if inner.Kind() == reflect.Struct {
+				embedded = append(embedded, field{index: at})
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		list = append(list, field{name: name, index: at, omitEmpty: strings.Contains(","+options+",", ",omitempty,")})
+	}
+	for _, e := range embedded {
+		inner := typ.FieldByIndex(e.index[len(e.index)-1:]).Type
+		if inner.Kind() == reflect.Pointer {
+			inner = inner.Elem()
+		}
+		list = append(list, collectFields(inner, e.index, seen)...)
+	}
+	return list
+}
@@ -0,0 +1,87 @@
+package msgpack
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Audit struct {
+	CreatedAt time.Time `json:"created_at"`
+	Note      string    `json:"note,omitempty"`
+}
+
+type order struct {
+	ID       string            `json:"id"`
+	Amount   int               `json:"amount"`
+	Price    float64           `json:"price"`
+	Paid     bool              `json:"paid"`
+	Tags     []string          `json:"tags,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Discount *int              `json:"discount"`
+	Raw      json.RawMessage   `json:"raw,omitempty"`
+	Secret   string            `json:"-"`
+	Untagged string
+	internal string
+	Audit
+}
+
+func TestMarshal(t *testing.T) {
+	o := order{
+		ID:       "o-1",
+		Amount:   -3,
+		Price:    12.5,
+		Paid:     true,
+		Labels:   map[string]string{"gift": "yes"},
+		Raw:      json.RawMessage(`{"n":1}`),
+		Secret:   "rahasia",
+		Untagged: "u",
+		internal: "i",
+		Audit:    Audit{CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+	data, err := Marshal(o)
+	assert.Nil(t, err)
+	converted, err := ToJSON(data)
+	assert.Nil(t, err)
+	expected, _ := json.Marshal(o)
+	assert.JSONEq(t, string(expected), string(converted), "maps to what encoding/json gives")
+
+	var decoded order
+	assert.Nil(t, Unmarshal(data, &decoded))
+	assert.Equal(t, "o-1", decoded.ID)
+	assert.Equal(t, -3, decoded.Amount)
+	assert.Equal(t, o.CreatedAt, decoded.CreatedAt)
+	assert.Empty(t, decoded.Secret)
+
+	data, err = Marshal(map[int][]byte{7: []byte("biner")})
+	assert.Nil(t, err)
+	converted, _ = ToJSON(data)
+	assert.JSONEq(t, `{"7":"YmluZXI="}`, string(converted))
+
+	_, err = Marshal(make(chan int))
+	assert.NotNil(t, err)
+}
+
+func TestFromJSON(t *testing.T) {
+	data, err := FromJSON([]byte(`{"count":3,"ratio":0.5,"items":[null,true,"x"]}`))
+	assert.Nil(t, err)
+	// Integers stay integers: 0x03 is a positive fixint.
+	assert.Contains(t, string(data), "\xa5count\x03")
+	converted, err := ToJSON(data)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"count":3,"ratio":0.5,"items":[null,true,"x"]}`, string(converted))
+
+	_, err = FromJSON([]byte(`{} {}`))
+	assert.Equal(t, ErrTrailingData, err)
+	_, err = ToJSON([]byte("\x01\x02"))
+	assert.Equal(t, ErrTrailingData, err)
+}
+
+func TestIsMIME(t *testing.T) {
+	assert.True(t, IsMIME("application/msgpack"))
+	assert.True(t, IsMIME("application/x-msgpack"))
+	assert.True(t, IsMIME("application/vnd.msgpack"))
+	assert.False(t, IsMIME("application/json"))
+}
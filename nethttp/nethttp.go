@@ -0,0 +1,224 @@
+// Package nethttp runs net/http handlers and middleware inside the Fiber
+// app, so legacy code can move over one route at a time:
+//
+//	legacy := http.NewServeMux()
+//	legacy.HandleFunc("/reports/{id}", reportHandler)
+//	nethttp.Mount(app, "/legacy", legacy)
+//	app.Use(nethttp.Middleware(handlers.CompressHandler))
+//
+// Handlers see the Fiber user context, such as the request logger and a
+// running txn transaction, as r.Context(), and Locals through Local.
+// Request and response bodies are buffered, not streamed.
+package nethttp
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// Handler serves requests with h.
+func Handler(h http.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		r, err := request(c)
+		if err != nil {
+			return err
+		}
+		w := newResponseWriter(c)
+		h.ServeHTTP(w, r)
+		w.finish()
+		return nil
+	}
+}
+
+// HandlerFunc serves requests with f.
+func HandlerFunc(f http.HandlerFunc) fiber.Handler {
+	return Handler(f)
+}
+
+// Mount serves every method of the paths under prefix with h, which sees
+// them without the prefix, as if it was served on its own.
+func Mount(router fiber.Router, prefix string, h http.Handler) {
+	router.Use(prefix, func(c *fiber.Ctx) error {
+		base := strings.TrimSuffix(c.Route().Path, "/")
+		return Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			stripped := *r.URL
+			stripped.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, base), "/")
+			stripped.RawPath = ""
+			r.URL = &stripped
+			h.ServeHTTP(w, r)
+		}))(c)
+	})
+}
+
+// Middleware runs the net/http middleware mw, e.g. one of gorilla/handlers,
+// around the rest of the Fiber chain. The method, path, headers and
+// context values mw sets on the request it hands on reach the next
+// handlers, and their response is written through the ResponseWriter it
+// hands on, so middleware logging or compressing responses sees them.
+func Middleware(mw func(http.Handler) http.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		r, err := request(c)
+		if err != nil {
+			return err
+		}
+
+		var chainErr error
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apply(c, r)
+			if err := c.Next(); err != nil {
+				if err := c.App().ErrorHandler(c, err); err != nil {
+					chainErr = err
+					return
+				}
+			}
+			replay(c, w)
+		})
+		w := newResponseWriter(c)
+		mw(next).ServeHTTP(w, r)
+		w.finish()
+		return chainErr
+	}
+}
+
+// Local returns the Fiber Locals value under key of the request r was
+// converted from.
+func Local(r *http.Request, key string) any {
+	return r.Context().Value(key)
+}
+
+// localsContext resolves the string keys its parent does not know as
+// Fiber Locals.
+type localsContext struct {
+	context.Context
+	fctx *fasthttp.RequestCtx
+}
+
+func (l localsContext) Value(key any) any {
+	if value := l.Context.Value(key); value != nil {
+		return value
+	}
+	if name, ok := key.(string); ok {
+		return l.fctx.UserValue(name)
+	}
+	return nil
+}
+
+func request(c *fiber.Ctx) (*http.Request, error) {
+	r := new(http.Request)
+	if err := fasthttpadaptor.ConvertRequest(c.Context(), r, true); err != nil {
+		return nil, err
+	}
+	return r.WithContext(localsContext{Context: c.UserContext(), fctx: c.Context()}), nil
+}
+
+// apply carries the changes middleware made to r over to the request of c.
+// r shares the buffers of the request it was converted from, so everything
+// is copied before they are overwritten.
+func apply(c *fiber.Ctx, r *http.Request) {
+	method, host := strings.Clone(r.Method), strings.Clone(r.Host)
+	uri, path := strings.Clone(r.URL.RequestURI()), strings.Clone(r.URL.Path)
+	header := make(http.Header, len(r.Header))
+	for name, values := range r.Header {
+		for _, value := range values {
+			header.Add(strings.Clone(name), strings.Clone(value))
+		}
+	}
+
+	request := c.Request()
+	if method != c.Method() {
+		request.Header.SetMethod(method)
+	}
+	if host != string(request.Host()) {
+		request.SetHost(host)
+	}
+	if uri != string(request.RequestURI()) {
+		request.SetRequestURI(uri)
+		c.Path(path)
+	}
+
+	var removed []string
+	request.Header.VisitAll(func(key, _ []byte) {
+		if name := string(key); name != fiber.HeaderHost && header.Values(name) == nil {
+			removed = append(removed, name)
+		}
+	})
+	for _, name := range removed {
+		request.Header.Del(name)
+	}
+	for name, values := range header {
+		request.Header.Del(name)
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+
+	c.SetUserContext(r.Context())
+}
+
+// replay moves the response the Fiber chain wrote to c over to w.
+func replay(c *fiber.Ctx, w http.ResponseWriter) {
+	response := c.Response()
+	status := response.StatusCode()
+	body := append([]byte(nil), response.Body()...)
+	header := w.Header()
+	response.Header.VisitAll(func(key, value []byte) {
+		if name := string(key); name != fiber.HeaderContentLength {
+			header.Add(name, string(value))
+		}
+	})
+	response.Reset()
+
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// responseWriter writes to the response of a Fiber request.
+type responseWriter struct {
+	c           *fiber.Ctx
+	header      http.Header
+	wroteHeader bool
+}
+
+func newResponseWriter(c *fiber.Ctx) *responseWriter {
+	return &responseWriter{c: c, header: http.Header{}}
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	for name, values := range w.header {
+		w.c.Response().Header.Del(name)
+		for _, value := range values {
+			w.c.Response().Header.Add(name, value)
+		}
+	}
+	w.c.Status(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.header.Get(fiber.HeaderContentType) == "" {
+			w.header.Set(fiber.HeaderContentType, http.DetectContentType(p))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.c.Response().BodyWriter().Write(p)
+}
+
+// Flush does nothing, as the response is sent once the handler returns.
+func (w *responseWriter) Flush() {}
+
+func (w *responseWriter) finish() {
+	w.WriteHeader(http.StatusOK)
+}
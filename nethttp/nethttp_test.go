@@ -0,0 +1,134 @@
+package nethttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type contextKey struct{}
+
+func TestMount(t *testing.T) {
+	legacy := http.NewServeMux()
+	legacy.HandleFunc("GET /reports/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Report", r.PathValue("id"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, `{"user":"`+Local(r, "user").(string)+`","trace":"`+r.Context().Value(contextKey{}).(string)+`"}`)
+	})
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", "salman")
+		c.SetUserContext(context.WithValue(c.UserContext(), contextKey{}, "abc"))
+		return c.Next()
+	})
+	Mount(app.Group("/api"), "/legacy", legacy)
+
+	response, err := app.Test(httptest.NewRequest("GET", "/api/legacy/reports/7?full=1", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, response.StatusCode)
+	assert.Equal(t, "7", response.Header.Get("X-Report"))
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, `{"user":"salman","trace":"abc"}`, string(body))
+
+	response, _ = app.Test(httptest.NewRequest("GET", "/api/legacy/missing", nil))
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestHandlerFunc(t *testing.T) {
+	app := fiber.New()
+	app.Post("/echo", HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+
+	response, err := app.Test(httptest.NewRequest("POST", "/echo", strings.NewReader("<p>hi</p>")))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", response.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "<p>hi</p>", string(body))
+}
+
+// upperWriter stands for middleware rewriting responses, e.g. compressing.
+type upperWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *upperWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *upperWriter) Write(p []byte) (int, error) {
+	return w.ResponseWriter.Write([]byte(strings.ToUpper(string(p))))
+}
+
+func TestMiddleware(t *testing.T) {
+	var logged int
+	app := fiber.New()
+	app.Use(Middleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Token") == "" {
+				http.Error(w, "no token", http.StatusUnauthorized)
+				return
+			}
+			writer := &upperWriter{ResponseWriter: w}
+			w.Header().Set("X-Middleware", "yes")
+			r.Header.Del("X-Token")
+			r.Header.Set("X-User", "salman")
+			next.ServeHTTP(writer, r.WithContext(context.WithValue(r.Context(), contextKey{}, "abc")))
+			logged = writer.status
+		})
+	}))
+	app.Get("/hello", func(c *fiber.Ctx) error {
+		c.Set("X-Trace", c.UserContext().Value(contextKey{}).(string))
+		return c.Status(http.StatusCreated).SendString("hello " + c.Get("X-User") + c.Get("X-Token"))
+	})
+
+	request := httptest.NewRequest("GET", "/hello", nil)
+	request.Header.Set("X-Token", "secret")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, http.StatusCreated, logged)
+	assert.Equal(t, "yes", response.Header.Get("X-Middleware"))
+	assert.Equal(t, "abc", response.Header.Get("X-Trace"))
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "HELLO SALMAN", string(body))
+
+	request = httptest.NewRequest("GET", "/missing", nil)
+	request.Header.Set("X-Token", "secret")
+	response, _ = app.Test(request)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	assert.Equal(t, http.StatusNotFound, logged, "errors of the chain pass through the middleware")
+
+	response, _ = app.Test(httptest.NewRequest("GET", "/hello", nil))
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	body, _ = io.ReadAll(response.Body)
+	assert.Equal(t, "no token\n", string(body))
+}
+
+func TestMiddlewareRewritesPath(t *testing.T) {
+	app := fiber.New()
+	app.Use(Middleware(func(next http.Handler) http.Handler {
+		return http.StripPrefix("/v1", next)
+	}))
+	app.Get("/users", func(c *fiber.Ctx) error {
+		return c.SendString(c.Path() + "?" + c.Query("page"))
+	})
+
+	response, err := app.Test(httptest.NewRequest("GET", "/v1/users?page=2", nil))
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "/users?2", string(body))
+}
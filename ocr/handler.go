@@ -0,0 +1,115 @@
+package ocr
+
+import (
+	"errors"
+	"strings"
+
+	"belajar-golang-fiber/files"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler serves recognized texts and searches them.
+type Handler struct {
+	Processor *Processor
+}
+
+// Result is a file matching a search, with the text around the first
+// match.
+type Result struct {
+	File    *files.File `json:"file"`
+	Snippet string      `json:"snippet"`
+}
+
+// Register mounts the routes on router, e.g. app.Group("/files"). It
+// goes before routes matching any id, such as downloads.
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/search", h.search)
+	router.Get("/:id/text", h.text)
+}
+
+// search answers GET /search?q=words&limit=20 with the files of the
+// caller whose text contains every word.
+func (h *Handler) search(ctx *fiber.Ctx) error {
+	query := strings.TrimSpace(ctx.Query("q"))
+	if query == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "q is required")
+	}
+	limit := ctx.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		return fiber.NewError(fiber.StatusBadRequest, "limit must be between 1 and 100")
+	}
+
+	texts, err := h.Processor.Store.Search(ctx.UserContext(), query)
+	if err != nil {
+		return err
+	}
+	results := []Result{}
+	for _, text := range texts {
+		file, err := h.Processor.Files.Repository.Get(ctx.UserContext(), text.FileID)
+		if errors.Is(err, files.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if file.Trashed() || !files.Owns(ctx, file) {
+			continue
+		}
+		results = append(results, Result{File: file, Snippet: snippet(text.Text, Words(query)[0])})
+		if len(results) == limit {
+			break
+		}
+	}
+	return ctx.JSON(results)
+}
+
+// text answers 404 with Retry-After while the current version of the
+// file is still being recognized.
+func (h *Handler) text(ctx *fiber.Ctx) error {
+	file, err := h.Processor.Files.Repository.Get(ctx.UserContext(), ctx.Params("id"))
+	if err == nil && (file.Trashed() || !files.Owns(ctx, file)) || errors.Is(err, files.ErrNotFound) {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !h.Processor.Supported(file) {
+		return fiber.NewError(fiber.StatusNotFound, "no text is recognized for this file type")
+	}
+
+	text, err := h.Processor.Store.Get(ctx.UserContext(), file.ID)
+	if errors.Is(err, ErrNotFound) || err == nil && text.Version != file.Version {
+		ctx.Set(fiber.HeaderRetryAfter, "5")
+		return fiber.NewError(fiber.StatusNotFound, "text is not recognized yet")
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(text)
+}
+
+// snippet cuts about 60 characters on either side of the first
+// occurrence of word in text.
+func snippet(text, word string) string {
+	const around = 60
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	at := 0
+	// Lower-casing may change the length of a few runes; fall back to the
+	// start of the text rather than cut in the wrong place.
+	if len(lower) == len(runes) {
+		if i := strings.Index(string(lower), word); i >= 0 {
+			at = len([]rune(string(lower)[:i]))
+		}
+	}
+	start, end := max(at-around, 0), min(at+len([]rune(word))+around, len(runes))
+	result := strings.Join(strings.Fields(string(runes[start:end])), " ")
+	if start > 0 {
+		result = "…" + result
+	}
+	if end < len(runes) {
+		result += "…"
+	}
+	return result
+}
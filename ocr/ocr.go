@@ -0,0 +1,203 @@
+// Package ocr extracts the text of scanned uploads — images and PDFs —
+// in the background and keeps it searchable. Recognition is done by an
+// Engine: Tesseract runs the tesseract command, and a cloud service can
+// be plugged in by implementing the interface.
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/jobs"
+	"belajar-golang-fiber/logger"
+)
+
+// JobKind is the kind of the jobs recognizing text.
+const JobKind = "ocr.recognize"
+
+// Engine recognizes the text of the image at path.
+type Engine interface {
+	Recognize(ctx context.Context, path string) (string, error)
+}
+
+// Tesseract runs the tesseract command.
+type Tesseract struct {
+	Command string
+	// Languages are the trained models used, e.g. "eng+ind". Empty means
+	// tesseract's default.
+	Languages string
+	// Timeout bounds each run. Zero means a minute.
+	Timeout time.Duration
+}
+
+// Recognize implements Engine.
+func (t *Tesseract) Recognize(ctx context.Context, path string) (string, error) {
+	args := []string{path, "stdout"}
+	if t.Languages != "" {
+		args = append(args, "-l", t.Languages)
+	}
+	output, err := run(ctx, t.Timeout, t.Command, args...)
+	return string(output), err
+}
+
+// Processor recognizes uploads and stores their text.
+type Processor struct {
+	Files *files.Service
+	// Engine recognizes text; nil disables recognition.
+	Engine Engine
+	Store  Store
+	// PDFToPPM rasterizes PDF pages; empty leaves PDFs out.
+	PDFToPPM string
+	// MaxPages caps the pages of a PDF that are recognized. Zero means 20.
+	MaxPages int
+	// Timeout bounds rasterizing a PDF. Zero means a minute.
+	Timeout time.Duration
+}
+
+// Attach enqueues recognition on queue for every supported file saved
+// or replaced through the file service.
+func (p *Processor) Attach(queue *jobs.Queue) {
+	queue.Handle(JobKind, func(ctx context.Context, job *jobs.Job) error {
+		var payload struct {
+			FileID string `json:"file_id"`
+		}
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return p.Recognize(ctx, payload.FileID)
+	})
+	p.Files.AfterSave(func(ctx context.Context, file *files.File) {
+		if !p.Supported(file) {
+			return
+		}
+		if err := queue.Enqueue(ctx, JobKind, map[string]string{"file_id": file.ID}); err != nil {
+			logger.FromContext(ctx).Error("ocr: enqueue failed", "file_id", file.ID, "error", err)
+		}
+	})
+}
+
+// Supported reports whether text is recognized in file. Nothing is
+// without an Engine.
+func (p *Processor) Supported(file *files.File) bool {
+	if p.Engine == nil {
+		return false
+	}
+	switch file.ContentType {
+	case "image/jpeg", "image/png", "image/gif", "image/tiff", "image/bmp", "image/webp":
+		return true
+	case "application/pdf":
+		return p.PDFToPPM != ""
+	}
+	return false
+}
+
+// Recognize extracts and stores the text of the file with id. The pages
+// of a PDF are separated by form feeds.
+func (p *Processor) Recognize(ctx context.Context, id string) error {
+	file, content, err := p.Files.Open(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	if !p.Supported(file) {
+		return fmt.Errorf("ocr: unsupported file type %q", file.ContentType)
+	}
+
+	dir, err := os.MkdirTemp("", "ocr-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source"+strings.ToLower(path.Ext(file.Name)))
+	if err := writeFile(source, content); err != nil {
+		return err
+	}
+	pages := []string{source}
+	if file.ContentType == "application/pdf" {
+		if pages, err = p.rasterize(ctx, dir, source); err != nil {
+			return err
+		}
+	}
+
+	texts := make([]string, 0, len(pages))
+	for _, page := range pages {
+		text, err := p.Engine.Recognize(ctx, page)
+		if err != nil {
+			return err
+		}
+		texts = append(texts, strings.TrimSpace(text))
+	}
+	return p.Store.Put(ctx, &Text{
+		FileID:       file.ID,
+		Version:      file.Version,
+		Text:         strings.Join(texts, "\f"),
+		Pages:        len(pages),
+		RecognizedAt: time.Now(),
+	})
+}
+
+// rasterize renders the pages of the PDF at source as page-N.png at
+// 300 dpi, the resolution tesseract is tuned for.
+func (p *Processor) rasterize(ctx context.Context, dir, source string) ([]string, error) {
+	maxPages := p.MaxPages
+	if maxPages <= 0 {
+		maxPages = 20
+	}
+	_, err := run(ctx, p.Timeout, p.PDFToPPM, "-r", "300", "-png", "-f", "1", "-l", strconv.Itoa(maxPages), source, filepath.Join(dir, "page"))
+	if err != nil {
+		return nil, err
+	}
+	// pdftoppm pads the page numbers to the width of the last one, so
+	// they sort in page order.
+	pages, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, errors.New("ocr: pdf has no pages")
+	}
+	sort.Strings(pages)
+	return pages, nil
+}
+
+func run(ctx context.Context, timeout time.Duration, name string, args ...string) ([]byte, error) {
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, name, args...)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		return nil, fmt.Errorf("ocr: %s: %w: %s", filepath.Base(name), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func writeFile(name string, r io.Reader) error {
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, r)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
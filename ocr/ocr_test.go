@@ -0,0 +1,153 @@
+package ocr
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/jobs"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEngine "recognizes" the content of the image file itself.
+type fakeEngine struct{}
+
+func (fakeEngine) Recognize(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+// setup returns the file service, the processor and a function sending
+// requests to the handler as user 1.
+func setup(t *testing.T) (*files.Service, *Processor, func(*http.Request) (*http.Response, error)) {
+	service := files.NewService(storage.NewLocal(t.TempDir()), files.NewMemoryRepository())
+	service.MaxVersions = 5
+	processor := &Processor{Files: service, Engine: fakeEngine{}, Store: NewMemoryStore()}
+	queue := jobs.NewQueue(10)
+	processor.Attach(queue)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go queue.Run(ctx, 1)
+
+	return service, processor, signedIn(t, &Handler{Processor: processor})
+}
+
+// signedIn mounts handler and returns a function sending requests to it as
+// user 1.
+func signedIn(t *testing.T, handler *Handler) func(*http.Request) (*http.Response, error) {
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app := fiber.New()
+	app.Use(sessions.Middleware())
+	app.Post("/login", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, "1")
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	handler.Register(app.Group("/files"))
+
+	response, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+	assert.Nil(t, err)
+	token, _ := io.ReadAll(response.Body)
+	return func(request *http.Request) (*http.Response, error) {
+		request.Header.Set("Authorization", "Bearer "+string(token))
+		return app.Test(request)
+	}
+}
+
+func recognized(t *testing.T, processor *Processor, file *files.File) {
+	assert.Eventually(t, func() bool {
+		text, err := processor.Store.Get(context.Background(), file.ID)
+		return err == nil && text.Version == file.Version
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestRecognizeAndSearch(t *testing.T) {
+	service, processor, send := setup(t)
+	ctx := files.WithOwner(context.Background(), files.Owner{UserID: "1"})
+
+	scan, err := service.Save(ctx, "invoice.png", strings.NewReader("INVOICE 2024-117\nTotal due: Rp 1.500.000"), "upload")
+	assert.Nil(t, err)
+	_, err = service.Save(ctx, "notes.txt", strings.NewReader("invoice total"), "upload")
+	assert.Nil(t, err)
+	recognized(t, processor, scan)
+
+	response, err := send(httptest.NewRequest("GET", "/files/"+scan.ID+"/text", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	var text Text
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&text))
+	assert.Equal(t, "INVOICE 2024-117\nTotal due: Rp 1.500.000", text.Text)
+	assert.Equal(t, 1, text.Pages)
+
+	// Text files are not scanned.
+	response, err = send(httptest.NewRequest("GET", "/files/search?q=Invoice+total", nil))
+	assert.Nil(t, err)
+	var results []Result
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&results))
+	assert.Len(t, results, 1)
+	assert.Equal(t, scan.ID, results[0].File.ID)
+	assert.Equal(t, "INVOICE 2024-117 Total due: Rp 1.500.000", results[0].Snippet)
+
+	response, err = send(httptest.NewRequest("GET", "/files/search?q=receipt", nil))
+	assert.Nil(t, err)
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&results))
+	assert.Len(t, results, 0)
+
+	// Replacing the file recognizes the new content.
+	scan, err = service.Replace(ctx, scan.ID, strings.NewReader("RECEIPT paid"), nil)
+	assert.Nil(t, err)
+	recognized(t, processor, scan)
+	response, err = send(httptest.NewRequest("GET", "/files/search?q=invoice", nil))
+	assert.Nil(t, err)
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&results))
+	assert.Len(t, results, 0)
+	response, err = send(httptest.NewRequest("GET", "/files/search?q=receipt", nil))
+	assert.Nil(t, err)
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&results))
+	assert.Len(t, results, 1)
+
+	response, err = send(httptest.NewRequest("GET", "/files/search", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestTextNotReady(t *testing.T) {
+	service, _, send := setup(t)
+	// Without a PDF rasterizer, PDFs are left out.
+	file, err := service.Save(files.WithOwner(context.Background(), files.Owner{UserID: "1"}), "scan.pdf", strings.NewReader("%PDF-1.4"), "upload")
+	assert.Nil(t, err)
+
+	response, err := send(httptest.NewRequest("GET", "/files/"+file.ID+"/text", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Equal(t, "", response.Header.Get("Retry-After"))
+
+	processor := &Processor{Files: service, Engine: fakeEngine{}, Store: NewMemoryStore(), PDFToPPM: "pdftoppm"}
+	send = signedIn(t, &Handler{Processor: processor})
+	response, err = send(httptest.NewRequest("GET", "/files/"+file.ID+"/text", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Equal(t, "5", response.Header.Get("Retry-After"))
+}
+
+func TestSnippet(t *testing.T) {
+	text := strings.Repeat("lorem ipsum ", 20) + "needle " + strings.Repeat("dolor sit ", 20)
+	result := snippet(text, "needle")
+	assert.True(t, strings.HasPrefix(result, "…"))
+	assert.True(t, strings.HasSuffix(result, "…"))
+	assert.Contains(t, result, "needle")
+	assert.Equal(t, []string{"a", "b"}, Words("A, b; a"))
+}
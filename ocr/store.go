@@ -0,0 +1,120 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ErrNotFound is returned when no text was recognized for a file yet.
+var ErrNotFound = errors.New("ocr: not found")
+
+// Text is what was recognized in one version of a file.
+type Text struct {
+	FileID       string    `json:"file_id"`
+	Version      int       `json:"version"`
+	Text         string    `json:"text"`
+	Pages        int       `json:"pages"`
+	RecognizedAt time.Time `json:"recognized_at"`
+}
+
+// Store keeps recognized texts, one per file.
+type Store interface {
+	// Put replaces the text of the file.
+	Put(ctx context.Context, text *Text) error
+	Get(ctx context.Context, fileID string) (*Text, error)
+	// Search returns the texts containing every word of query, ignoring
+	// case, most recently recognized first.
+	Search(ctx context.Context, query string) ([]*Text, error)
+}
+
+// MemoryStore keeps texts and an index of their words in process memory.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	texts map[string]*Text
+	index map[string]map[string]bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{texts: map[string]*Text{}, index: map[string]map[string]bool{}}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, text *Text) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if previous, ok := s.texts[text.FileID]; ok {
+		for _, word := range Words(previous.Text) {
+			delete(s.index[word], text.FileID)
+			if len(s.index[word]) == 0 {
+				delete(s.index, word)
+			}
+		}
+	}
+	copied := *text
+	s.texts[text.FileID] = &copied
+	for _, word := range Words(text.Text) {
+		if s.index[word] == nil {
+			s.index[word] = map[string]bool{}
+		}
+		s.index[word][text.FileID] = true
+	}
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, fileID string) (*Text, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	text, ok := s.texts[fileID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *text
+	return &copied, nil
+}
+
+func (s *MemoryStore) Search(ctx context.Context, query string) ([]*Text, error) {
+	words := Words(query)
+	if len(words) == 0 {
+		return nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []*Text
+	for fileID := range s.index[words[0]] {
+		matches := true
+		for _, word := range words[1:] {
+			if !s.index[word][fileID] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			copied := *s.texts[fileID]
+			found = append(found, &copied)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].RecognizedAt.After(found[j].RecognizedAt)
+	})
+	return found, nil
+}
+
+// Words splits text into its distinct lower-cased words.
+func Words(text string) []string {
+	seen := map[string]bool{}
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	return words
+}
@@ -0,0 +1,138 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"belajar-golang-fiber/txn"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type itemDocument struct {
+	SKU       string `bson:"sku"`
+	Name      string `bson:"name"`
+	Quantity  int    `bson:"quantity"`
+	UnitPrice int64  `bson:"unit_price"`
+}
+
+// orderDocument is how an order is stored in MongoDB, its lines embedded.
+// Times keep millisecond precision.
+type orderDocument struct {
+	ID        string         `bson:"_id"`
+	Number    string         `bson:"number"`
+	UserID    string         `bson:"user_id"`
+	Status    string         `bson:"status"`
+	Currency  string         `bson:"currency"`
+	Items     []itemDocument `bson:"items"`
+	Total     int64          `bson:"total"`
+	CreatedAt time.Time      `bson:"created_at"`
+	UpdatedAt time.Time      `bson:"updated_at"`
+}
+
+func toDocument(order *Order) *orderDocument {
+	items := make([]itemDocument, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = itemDocument(item)
+	}
+	return &orderDocument{
+		ID:        order.ID,
+		Number:    order.Number,
+		UserID:    order.UserID,
+		Status:    order.Status,
+		Currency:  order.Currency,
+		Items:     items,
+		Total:     order.Total,
+		CreatedAt: order.CreatedAt,
+		UpdatedAt: order.UpdatedAt,
+	}
+}
+
+func (d *orderDocument) order() *Order {
+	items := make([]Item, len(d.Items))
+	for i, item := range d.Items {
+		items[i] = Item(item)
+	}
+	return &Order{
+		ID:        d.ID,
+		Number:    d.Number,
+		UserID:    d.UserID,
+		Status:    d.Status,
+		Currency:  d.Currency,
+		Items:     items,
+		Total:     d.Total,
+		CreatedAt: d.CreatedAt.UTC(),
+		UpdatedAt: d.UpdatedAt.UTC(),
+	}
+}
+
+// MongoRepository keeps orders in a MongoDB collection. Orders created
+// under a txn transaction are deleted again on rollback.
+type MongoRepository struct {
+	Collection *mongo.Collection
+}
+
+// NewMongoRepository stores orders in the "orders" collection of db.
+func NewMongoRepository(db *mongo.Database) *MongoRepository {
+	return &MongoRepository{Collection: db.Collection("orders")}
+}
+
+// EnsureIndexes creates the index listing a user's orders, unless it
+// exists.
+func (r *MongoRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	return err
+}
+
+func (r *MongoRepository) Create(ctx context.Context, order *Order) error {
+	if _, err := r.Collection.InsertOne(ctx, toDocument(order)); err != nil {
+		return err
+	}
+	txn.OnRollback(ctx, func() {
+		r.Collection.DeleteOne(context.WithoutCancel(ctx), bson.M{"_id": order.ID})
+	})
+	return nil
+}
+
+func (r *MongoRepository) Get(ctx context.Context, id string) (*Order, error) {
+	var document orderDocument
+	err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return document.order(), nil
+}
+
+func (r *MongoRepository) ListByUser(ctx context.Context, userID string, list ListOptions) ([]*Order, int, error) {
+	filter := bson.M{"user_id": userID}
+	total, err := r.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	find := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}}).SetSkip(int64(list.Offset))
+	if list.Limit > 0 {
+		find.SetLimit(int64(list.Limit))
+	}
+	cursor, err := r.Collection.Find(ctx, filter, find)
+	if err != nil {
+		return nil, 0, err
+	}
+	var documents []*orderDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, 0, err
+	}
+	orders := make([]*Order, len(documents))
+	for i, document := range documents {
+		orders[i] = document.order()
+	}
+	return orders, int(total), nil
+}
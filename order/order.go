@@ -0,0 +1,117 @@
+// Package order holds customer orders.
+package order
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when no order matches the given id.
+var ErrNotFound = errors.New("order: not found")
+
+const (
+	StatusPending   = "pending"
+	StatusPaid      = "paid"
+	StatusShipped   = "shipped"
+	StatusCancelled = "cancelled"
+)
+
+// Item is one order line. Prices are in minor units of the order's
+// currency.
+type Item struct {
+	SKU       string
+	Name      string
+	Quantity  int
+	UnitPrice int64
+}
+
+// Order is a purchase made by one user.
+type Order struct {
+	ID        string
+	Number    string
+	UserID    string
+	Status    string
+	Currency  string
+	Items     []Item
+	Total     int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ListOptions pages through ListByUser. A zero Limit returns every order.
+type ListOptions struct {
+	Offset int
+	Limit  int
+}
+
+// Repository persists orders.
+type Repository interface {
+	Create(ctx context.Context, order *Order) error
+	Get(ctx context.Context, id string) (*Order, error)
+	// ListByUser returns a page of the user's orders, newest first, and
+	// their total count.
+	ListByUser(ctx context.Context, userID string, options ListOptions) ([]*Order, int, error)
+}
+
+// MemoryRepository keeps orders in process memory.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	orders map[string]*Order
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{orders: map[string]*Order{}}
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, order *Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.orders[order.ID] = clone(order)
+	return nil
+}
+
+func (r *MemoryRepository) Get(ctx context.Context, id string) (*Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(order), nil
+}
+
+func (r *MemoryRepository) ListByUser(ctx context.Context, userID string, options ListOptions) ([]*Order, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := []*Order{}
+	for _, order := range r.orders {
+		if order.UserID == userID {
+			list = append(list, clone(order))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+
+	total := len(list)
+	if options.Offset > total {
+		options.Offset = total
+	}
+	list = list[options.Offset:]
+	if options.Limit > 0 && options.Limit < len(list) {
+		list = list[:options.Limit]
+	}
+	return list, total, nil
+}
+
+func clone(order *Order) *Order {
+	copied := *order
+	copied.Items = append([]Item(nil), order.Items...)
+	return &copied
+}
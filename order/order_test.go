@@ -0,0 +1,106 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/database"
+	"belajar-golang-fiber/sequence"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type failingRepository struct {
+	*MemoryRepository
+	fail bool
+}
+
+func (r *failingRepository) Create(ctx context.Context, order *Order) error {
+	if r.fail {
+		return errors.New("insert failed")
+	}
+	return r.MemoryRepository.Create(ctx, order)
+}
+
+func TestPlaceNumbersOrdersWithoutGaps(t *testing.T) {
+	sequences, err := sequence.NewService(context.Background(), sequence.NewMemoryStore())
+	assert.Nil(t, err)
+	assert.Nil(t, sequences.Store.SaveDefinition(context.Background(), sequence.Definition{
+		Name: "order", Format: "{TENANT}/{YY}/{SEQ:4}", Reset: sequence.ResetYearly, Start: 1,
+	}))
+
+	orders := &failingRepository{MemoryRepository: NewMemoryRepository()}
+	service := NewService(orders, sequences)
+	input := PlaceInput{Tenant: "acme", Currency: "IDR", Items: []Item{{SKU: "BK-1", Quantity: 1, UnitPrice: 1000}}}
+	year := time.Now().Format("06")
+
+	placed, err := service.Place(context.Background(), "1", input)
+	assert.Nil(t, err)
+	assert.Equal(t, "ACME/"+year+"/0001", placed.Number)
+
+	orders.fail = true
+	_, err = service.Place(context.Background(), "1", input)
+	assert.NotNil(t, err)
+	orders.fail = false
+
+	placed, err = service.Place(context.Background(), "1", input)
+	assert.Nil(t, err)
+	assert.Equal(t, "ACME/"+year+"/0002", placed.Number)
+
+	_, err = service.Place(context.Background(), "1", PlaceInput{Currency: "rupiah", Items: input.Items})
+	var invalid *ValidationError
+	assert.True(t, errors.As(err, &invalid))
+	assert.Equal(t, "currency", invalid.Field)
+}
+
+func TestMongoDocument(t *testing.T) {
+	created := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	order := &Order{
+		ID: "1", Number: "INV/26/0001", UserID: "7", Status: StatusPaid, Currency: "IDR",
+		Items:     []Item{{SKU: "BK-1", Name: "Book", Quantity: 2, UnitPrice: 1000}},
+		Total:     2000,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+
+	data, err := bson.Marshal(toDocument(order))
+	assert.Nil(t, err)
+	var document orderDocument
+	assert.Nil(t, bson.Unmarshal(data, &document))
+	assert.Equal(t, order, document.order())
+	assert.Equal(t, "BK-1", bson.Raw(data).Lookup("items", "0", "sku").StringValue())
+}
+
+// TestMongoRepository runs against a server given as, e.g.,
+// MONGODB_TEST_URI=mongodb://localhost:27017.
+func TestMongoRepository(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set")
+	}
+	ctx := context.Background()
+	db, err := database.OpenMongo(ctx, uri)
+	assert.Nil(t, err)
+	scratch := db.Client().Database("test_orders_" + strconv.FormatInt(time.Now().UnixNano(), 36))
+	defer scratch.Drop(ctx)
+
+	orders := NewMongoRepository(scratch)
+	assert.Nil(t, orders.EnsureIndexes(ctx))
+	created := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		at := created.Add(time.Duration(i) * time.Hour)
+		assert.Nil(t, orders.Create(ctx, &Order{ID: strconv.Itoa(i), UserID: "7", Items: []Item{}, CreatedAt: at, UpdatedAt: at}))
+	}
+
+	page, total, err := orders.ListByUser(ctx, "7", ListOptions{Offset: 1, Limit: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, "2", page[0].ID)
+	_, err = orders.Get(ctx, "9")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,96 @@
+package order
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/sequence"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidationError describes input rejected by Place.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return "order: " + e.Field + " " + e.Message
+}
+
+// PlaceInput is what a new order is created from. Tenant selects the
+// order number series when the "order" sequence is per tenant.
+type PlaceInput struct {
+	Tenant   string
+	Currency string
+	Items    []Item
+}
+
+// Service places orders on top of a Repository. When Sequences is set,
+// orders are numbered from its "order" sequence.
+type Service struct {
+	Orders    Repository
+	Sequences *sequence.Service
+}
+
+func NewService(orders Repository, sequences *sequence.Service) *Service {
+	return &Service{Orders: orders, Sequences: sequences}
+}
+
+// Place validates input and stores a pending order for userID.
+func (s *Service) Place(ctx context.Context, userID string, input PlaceInput) (*Order, error) {
+	currency := strings.ToUpper(strings.TrimSpace(input.Currency))
+	if !currencyPattern.MatchString(currency) {
+		return nil, &ValidationError{Field: "currency", Message: "must be an ISO 4217 code"}
+	}
+	if len(input.Items) == 0 {
+		return nil, &ValidationError{Field: "items", Message: "must not be empty"}
+	}
+
+	var total int64
+	for _, item := range input.Items {
+		if strings.TrimSpace(item.SKU) == "" {
+			return nil, &ValidationError{Field: "items.sku", Message: "is required"}
+		}
+		if item.Quantity < 1 {
+			return nil, &ValidationError{Field: "items.quantity", Message: "must be at least 1"}
+		}
+		if item.UnitPrice < 0 {
+			return nil, &ValidationError{Field: "items.unit_price", Message: "must not be negative"}
+		}
+		total += int64(item.Quantity) * item.UnitPrice
+	}
+
+	now := time.Now()
+	order := &Order{
+		ID:        utils.UUIDv4(),
+		UserID:    userID,
+		Status:    StatusPending,
+		Currency:  currency,
+		Items:     input.Items,
+		Total:     total,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if s.Sequences == nil {
+		if err := s.Orders.Create(ctx, order); err != nil {
+			return nil, err
+		}
+		return order, nil
+	}
+
+	// The number is only used up once the order is stored.
+	_, err := s.Sequences.Next(ctx, "order", sequence.Params{Tenant: input.Tenant, Time: now}, func(number string) error {
+		order.Number = number
+		return s.Orders.Create(ctx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
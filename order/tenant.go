@@ -0,0 +1,33 @@
+package order
+
+import "context"
+
+// TenantRepository keeps the orders of each tenant apart, in the
+// repository For returns for the tenant of ctx; see tenant.Partitioned.
+type TenantRepository struct {
+	For func(ctx context.Context) (Repository, error)
+}
+
+func (r *TenantRepository) Create(ctx context.Context, order *Order) error {
+	orders, err := r.For(ctx)
+	if err != nil {
+		return err
+	}
+	return orders.Create(ctx, order)
+}
+
+func (r *TenantRepository) Get(ctx context.Context, id string) (*Order, error) {
+	orders, err := r.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return orders.Get(ctx, id)
+}
+
+func (r *TenantRepository) ListByUser(ctx context.Context, userID string, options ListOptions) ([]*Order, int, error) {
+	orders, err := r.For(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return orders.ListByUser(ctx, userID, options)
+}
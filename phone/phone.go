@@ -0,0 +1,80 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nyaruka/phonenumbers"
+)
+
+// ErrInvalid is returned for numbers that cannot be dialled.
+var ErrInvalid = errors.New("phone: invalid number")
+
+// Number is a parsed and validated phone number.
+type Number struct {
+	E164          string `json:"e164"`
+	National      string `json:"national"`
+	International string `json:"international"`
+	Region        string `json:"region"`
+	Type          string `json:"type"`
+}
+
+var types = map[phonenumbers.PhoneNumberType]string{
+	phonenumbers.FIXED_LINE:           "fixed_line",
+	phonenumbers.MOBILE:               "mobile",
+	phonenumbers.FIXED_LINE_OR_MOBILE: "fixed_line_or_mobile",
+	phonenumbers.TOLL_FREE:            "toll_free",
+	phonenumbers.PREMIUM_RATE:         "premium_rate",
+	phonenumbers.SHARED_COST:          "shared_cost",
+	phonenumbers.VOIP:                 "voip",
+	phonenumbers.PERSONAL_NUMBER:      "personal_number",
+	phonenumbers.PAGER:                "pager",
+	phonenumbers.UAN:                  "uan",
+	phonenumbers.VOICEMAIL:            "voicemail",
+}
+
+// Parse validates raw, reading numbers without a leading + as national
+// numbers of region (ISO 3166 alpha-2, e.g. "ID").
+func Parse(raw, region string) (Number, error) {
+	parsed, err := phonenumbers.Parse(strings.TrimSpace(raw), strings.ToUpper(region))
+	if err != nil || !phonenumbers.IsValidNumber(parsed) {
+		return Number{}, ErrInvalid
+	}
+
+	kind, ok := types[phonenumbers.GetNumberType(parsed)]
+	if !ok {
+		kind = "unknown"
+	}
+	return Number{
+		E164:          phonenumbers.Format(parsed, phonenumbers.E164),
+		National:      phonenumbers.Format(parsed, phonenumbers.NATIONAL),
+		International: phonenumbers.Format(parsed, phonenumbers.INTERNATIONAL),
+		Region:        phonenumbers.GetRegionCodeForNumber(parsed),
+		Type:          kind,
+	}, nil
+}
+
+// Normalize returns the E.164 form of raw.
+func Normalize(raw, region string) (string, error) {
+	number, err := Parse(raw, region)
+	return number.E164, err
+}
+
+// ValidateHandler answers GET ?number=...&region=... with the parsed
+// number, or 422 when it is not valid. region defaults to defaultRegion.
+func ValidateHandler(defaultRegion string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		number, err := Parse(ctx.Query("number"), ctx.Query("region", defaultRegion))
+		if err != nil {
+			return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"valid": false,
+				"error": err.Error(),
+			})
+		}
+		return ctx.JSON(fiber.Map{
+			"valid":  true,
+			"number": number,
+		})
+	}
+}
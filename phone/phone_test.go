@@ -0,0 +1,49 @@
+package phone
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		raw      string
+		region   string
+		expected string
+	}{
+		{"0812-3456-7890", "ID", "+6281234567890"},
+		{"+62 812 3456 7890", "US", "+6281234567890"},
+		{"(202) 456-1111", "US", "+12024561111"},
+	}
+	for _, c := range cases {
+		e164, err := Normalize(c.raw, c.region)
+		assert.Nil(t, err, c.raw)
+		assert.Equal(t, c.expected, e164, c.raw)
+	}
+
+	_, err := Normalize("12345", "ID")
+	assert.Equal(t, ErrInvalid, err)
+}
+
+func TestValidateHandler(t *testing.T) {
+	app := fiber.New()
+	app.Get("/api/v1/phone/validate", ValidateHandler("ID"))
+
+	request := httptest.NewRequest("GET", "/api/v1/phone/validate?number=081234567890", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	bytes, _ := io.ReadAll(response.Body)
+	assert.Contains(t, string(bytes), `"e164":"+6281234567890"`)
+	assert.Contains(t, string(bytes), `"type":"mobile"`)
+
+	request = httptest.NewRequest("GET", "/api/v1/phone/validate?number=abc", nil)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 422, response.StatusCode)
+}
@@ -0,0 +1,67 @@
+package previews
+
+import (
+	"errors"
+	"io/fs"
+	"strconv"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/middleware/cachecontrol"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler serves the previews of stored files, to those allowed to
+// manage the file, see files.Owns.
+type Handler struct {
+	Generator *Generator
+}
+
+// Register mounts the routes on router, e.g. app.Group("/files"). It
+// goes before routes matching any second segment, such as image
+// variants.
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/:id/preview", h.preview)
+}
+
+// preview answers 404 with Retry-After while the preview is still being
+// rendered.
+func (h *Handler) preview(ctx *fiber.Ctx) error {
+	file, err := h.Generator.Files.Repository.Get(ctx.UserContext(), ctx.Params("id"))
+	if errors.Is(err, files.ErrNotFound) || err == nil && (file.Trashed() || !files.Owns(ctx, file)) {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	kind := h.Generator.Kind(file)
+	if kind == None {
+		return fiber.NewError(fiber.StatusNotFound, "no preview for this file type")
+	}
+
+	store := h.Generator.Files.Storage
+	key := Key(file, kind)
+	info, err := store.Stat(ctx.UserContext(), key)
+	if errors.Is(err, fs.ErrNotExist) {
+		ctx.Set(fiber.HeaderRetryAfter, "5")
+		return fiber.NewError(fiber.StatusNotFound, "preview is not ready yet")
+	}
+	if err != nil {
+		return err
+	}
+	// Replacing the file changes its preview, so it is revalidated
+	// rather than cached for good.
+	ctx.Set(fiber.HeaderETag, `"`+file.ID+"-"+strconv.Itoa(file.Version)+`"`)
+	cachecontrol.Policy{Private: true, NoCache: true}.Apply(ctx)
+	if ctx.Fresh() {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	content, err := store.Open(ctx.UserContext(), key)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, ContentType(kind))
+	return ctx.SendStream(content, int(info.Size()))
+}
@@ -0,0 +1,281 @@
+// Package previews renders what the file browser shows for a document:
+// the first page of a PDF as PNG, office documents converted to PDF
+// first, and the beginning of text files. Rendering runs in the
+// background after an upload; PDFs and office documents need pdftoppm
+// (poppler) and LibreOffice's soffice on the host.
+package previews
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/jobs"
+	"belajar-golang-fiber/logger"
+)
+
+// JobKind is the kind of the jobs rendering previews.
+const JobKind = "previews.generate"
+
+// ErrUnsupported is returned for files no preview is made for.
+var ErrUnsupported = errors.New("previews: unsupported file type")
+
+// Kind is how a file is previewed.
+type Kind int
+
+const (
+	None Kind = iota
+	Text
+	PDF
+	Office
+)
+
+// Generator renders and locates previews.
+type Generator struct {
+	Files *files.Service
+	// PDFToPPM and Soffice are the converter commands. Empty disables the
+	// kinds needing them.
+	PDFToPPM string
+	Soffice  string
+	// Size bounds the longer side of page previews. Zero means 1024.
+	Size int
+	// SnippetBytes caps text previews. Zero means 4 KiB.
+	SnippetBytes int
+	// Timeout bounds each converter run. Zero means a minute.
+	Timeout time.Duration
+}
+
+// NewGenerator uses the converters found on PATH.
+func NewGenerator(service *files.Service) *Generator {
+	g := &Generator{Files: service}
+	if found, err := exec.LookPath("pdftoppm"); err == nil {
+		g.PDFToPPM = found
+	}
+	if found, err := exec.LookPath("soffice"); err == nil {
+		g.Soffice = found
+	}
+	return g
+}
+
+// Attach enqueues preview rendering on queue for every supported file
+// saved or replaced through the file service.
+func (g *Generator) Attach(queue *jobs.Queue) {
+	queue.Handle(JobKind, func(ctx context.Context, job *jobs.Job) error {
+		var payload struct {
+			FileID string `json:"file_id"`
+		}
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return g.Generate(ctx, payload.FileID)
+	})
+	g.Files.AfterSave(func(ctx context.Context, file *files.File) {
+		if g.Kind(file) == None {
+			return
+		}
+		if err := queue.Enqueue(ctx, JobKind, map[string]string{"file_id": file.ID}); err != nil {
+			logger.FromContext(ctx).Error("previews: enqueue failed", "file_id", file.ID, "error", err)
+		}
+	})
+}
+
+// Kind tells how file is previewed, by content type or, for types the
+// host's MIME table does not know, by extension.
+func (g *Generator) Kind(file *files.File) Kind {
+	kind := kindOf(file.ContentType, strings.ToLower(path.Ext(file.Name)))
+	switch {
+	case kind == PDF && g.PDFToPPM == "":
+		return None
+	case kind == Office && (g.PDFToPPM == "" || g.Soffice == ""):
+		return None
+	}
+	return kind
+}
+
+func kindOf(contentType, extension string) Kind {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	switch {
+	case contentType == "application/pdf" || extension == ".pdf":
+		return PDF
+	case strings.HasPrefix(contentType, "text/"),
+		contentType == "application/json", contentType == "application/xml":
+		return Text
+	case strings.HasPrefix(contentType, "application/vnd.openxmlformats-officedocument."),
+		strings.HasPrefix(contentType, "application/vnd.oasis.opendocument."),
+		contentType == "application/msword", contentType == "application/vnd.ms-excel",
+		contentType == "application/vnd.ms-powerpoint", contentType == "application/rtf":
+		return Office
+	}
+	switch extension {
+	case ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx", ".odt", ".ods", ".odp", ".rtf":
+		return Office
+	case ".txt", ".md", ".csv", ".log", ".json", ".xml", ".yaml", ".yml":
+		return Text
+	}
+	return None
+}
+
+// Key is where the preview of the current version of file is stored.
+func Key(file *files.File, kind Kind) string {
+	return ".previews/" + file.ID + "/" + strconv.Itoa(file.Version) + "." + extension(kind)
+}
+
+// ContentType of a preview of kind.
+func ContentType(kind Kind) string {
+	if kind == Text {
+		return "text/plain; charset=utf-8"
+	}
+	return "image/png"
+}
+
+func extension(kind Kind) string {
+	if kind == Text {
+		return "txt"
+	}
+	return "png"
+}
+
+// Generate renders the preview of the file with id.
+func (g *Generator) Generate(ctx context.Context, id string) error {
+	file, content, err := g.Files.Open(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	kind := g.Kind(file)
+	var preview []byte
+	switch kind {
+	case Text:
+		preview, err = g.snippet(content)
+	case PDF, Office:
+		preview, err = g.render(ctx, file, kind, content)
+	default:
+		return ErrUnsupported
+	}
+	if err != nil {
+		return err
+	}
+	return g.store(ctx, Key(file, kind), preview)
+}
+
+// snippet reads the start of a text file, cut at the last complete line
+// when there is one.
+func (g *Generator) snippet(r io.Reader) ([]byte, error) {
+	limit := g.SnippetBytes
+	if limit <= 0 {
+		limit = 4 << 10
+	}
+	data, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > limit {
+		data = data[:limit]
+		if i := bytes.LastIndexByte(data, '\n'); i > 0 {
+			data = data[:i+1]
+		}
+		// The limit may have cut a multi-byte rune in half.
+		for i := 0; i < utf8.UTFMax-1 && len(data) > 0; i++ {
+			if r, size := utf8.DecodeLastRune(data); r != utf8.RuneError || size > 1 {
+				break
+			}
+			data = data[:len(data)-1]
+		}
+	}
+	return bytes.ToValidUTF8(data, []byte("�")), nil
+}
+
+// render converts the document in a scratch directory: office documents
+// to PDF with soffice, then the first page to PNG with pdftoppm.
+func (g *Generator) render(ctx context.Context, file *files.File, kind Kind, content io.Reader) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "preview-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source"+strings.ToLower(path.Ext(file.Name)))
+	if err := writeFile(source, content); err != nil {
+		return nil, err
+	}
+
+	if kind == Office {
+		// soffice names its output after the input, in --outdir.
+		if err := g.run(ctx, g.Soffice, "--headless", "--norestore", "--convert-to", "pdf", "--outdir", dir, source); err != nil {
+			return nil, err
+		}
+		source = filepath.Join(dir, "source.pdf")
+	}
+
+	size := g.Size
+	if size <= 0 {
+		size = 1024
+	}
+	output := filepath.Join(dir, "page")
+	if err := g.run(ctx, g.PDFToPPM, "-png", "-f", "1", "-l", "1", "-singlefile", "-scale-to", strconv.Itoa(size), source, output); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(output + ".png")
+}
+
+func (g *Generator) run(ctx context.Context, name string, args ...string) error {
+	timeout := g.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	command := exec.CommandContext(ctx, name, args...)
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("previews: %s: %w: %s", filepath.Base(name), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func writeFile(name string, r io.Reader) error {
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, r)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// store writes the preview under a temporary name first, so it is never
+// served half written.
+func (g *Generator) store(ctx context.Context, key string, preview []byte) error {
+	temporary := key + ".tmp"
+	writer, err := g.Files.Storage.Create(ctx, temporary)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(preview)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = g.Files.Storage.Rename(ctx, temporary, key)
+	}
+	if err != nil {
+		_ = g.Files.Storage.Remove(ctx, temporary)
+	}
+	return err
+}
@@ -0,0 +1,181 @@
+package previews
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/jobs"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// setup returns the file service, the generator, a function sending
+// requests as user 1 of tenant acme, and the context to save their files
+// with.
+func setup(t *testing.T) (*files.Service, *Generator, func(*http.Request) (*http.Response, error), context.Context) {
+	service := files.NewService(storage.NewLocal(t.TempDir()), files.NewMemoryRepository())
+	generator := &Generator{Files: service}
+	queue := jobs.NewQueue(10)
+	generator.Attach(queue)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go queue.Run(ctx, 1)
+
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app := fiber.New()
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("tenant", "acme")
+		return ctx.Next()
+	})
+	app.Use(sessions.Middleware())
+	app.Post("/login", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, "1")
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	handler := &Handler{Generator: generator}
+	handler.Register(app.Group("/files"))
+
+	response, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+	assert.Nil(t, err)
+	token, _ := io.ReadAll(response.Body)
+	send := func(request *http.Request) (*http.Response, error) {
+		request.Header.Set("Authorization", "Bearer "+string(token))
+		return app.Test(request)
+	}
+	return service, generator, send, files.WithOwner(ctx, files.Owner{UserID: "1", Tenant: "acme"})
+}
+
+func ready(t *testing.T, service *files.Service, key string) {
+	assert.Eventually(t, func() bool {
+		_, err := service.Storage.Stat(context.Background(), key)
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestTextPreview(t *testing.T) {
+	service, generator, send, ctx := setup(t)
+	generator.SnippetBytes = 16
+
+	file, err := service.Save(ctx, "notes.txt", strings.NewReader("line one\nline two\nline three\n"), "upload")
+	assert.Nil(t, err)
+	ready(t, service, Key(file, Text))
+
+	response, err := send(httptest.NewRequest("GET", "/files/"+file.ID+"/preview", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "text/plain; charset=utf-8", response.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "line one\n", string(body))
+
+	request := httptest.NewRequest("GET", "/files/"+file.ID+"/preview", nil)
+	request.Header.Set("If-None-Match", response.Header.Get("ETag"))
+	response, err = send(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 304, response.StatusCode)
+
+	// A new version gets a new preview.
+	file, err = service.Replace(ctx, file.ID, strings.NewReader("second draft"), nil)
+	assert.Nil(t, err)
+	ready(t, service, Key(file, Text))
+	response, err = send(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	body, _ = io.ReadAll(response.Body)
+	assert.Equal(t, "second draft", string(body))
+
+	theirs, err := service.Save(files.WithOwner(ctx, files.Owner{UserID: "1", Tenant: "other"}), "notes.txt", strings.NewReader("secret"), "upload")
+	assert.Nil(t, err)
+	ready(t, service, Key(theirs, Text))
+	response, err = send(httptest.NewRequest("GET", "/files/"+theirs.ID+"/preview", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode, "previews of other tenants are not found")
+}
+
+func TestSnippetKeepsRunesWhole(t *testing.T) {
+	generator := &Generator{SnippetBytes: 5}
+	snippet, err := generator.snippet(strings.NewReader("abcdé and more"))
+	assert.Nil(t, err)
+	assert.Equal(t, "abcd", string(snippet))
+}
+
+func TestUnsupported(t *testing.T) {
+	service, _, send, ctx := setup(t)
+
+	// Without converters PDFs get no preview.
+	file, err := service.Save(ctx, "report.pdf", strings.NewReader("%PDF-1.4"), "upload")
+	assert.Nil(t, err)
+	response, err := send(httptest.NewRequest("GET", "/files/"+file.ID+"/preview", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Equal(t, "", response.Header.Get("Retry-After"))
+
+	response, err = send(httptest.NewRequest("GET", "/files/missing/preview", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+// fakeConverters writes scripts standing in for soffice, which copies
+// its input to a PDF in --outdir, and pdftoppm, which writes page.
+func fakeConverters(t *testing.T, page []byte) (soffice, pdftoppm string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("converter scripts need a POSIX shell")
+	}
+	dir := t.TempDir()
+	pagePath := filepath.Join(dir, "page.png")
+	assert.Nil(t, os.WriteFile(pagePath, page, 0o644))
+	soffice = filepath.Join(dir, "soffice")
+	// The last two arguments are the output directory and the input.
+	assert.Nil(t, os.WriteFile(soffice, []byte(`#!/bin/sh
+for last; do :; done
+eval "outdir=\${$(($#-1))}"
+cp "$last" "$outdir/source.pdf"
+`), 0o755))
+	pdftoppm = filepath.Join(dir, "pdftoppm")
+	assert.Nil(t, os.WriteFile(pdftoppm, []byte(`#!/bin/sh
+for last; do :; done
+cp "`+pagePath+`" "$last.png"
+`), 0o755))
+	return soffice, pdftoppm
+}
+
+func TestOfficePreview(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 6))
+	img.Set(0, 0, color.RGBA{B: 255, A: 255})
+	var page bytes.Buffer
+	assert.Nil(t, png.Encode(&page, img))
+
+	service, generator, send, ctx := setup(t)
+	generator.Soffice, generator.PDFToPPM = fakeConverters(t, page.Bytes())
+
+	file, err := service.Save(ctx, "letter.docx", strings.NewReader("PK..."), "upload")
+	assert.Nil(t, err)
+	assert.Equal(t, Office, generator.Kind(file))
+	ready(t, service, Key(file, Office))
+
+	response, err := send(httptest.NewRequest("GET", "/files/"+file.ID+"/preview", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "image/png", response.Header.Get("Content-Type"))
+	preview, err := png.Decode(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, image.Pt(4, 6), preview.Bounds().Size())
+}
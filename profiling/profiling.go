@@ -0,0 +1,103 @@
+// Package profiling pushes continuous CPU and memory profiles to a
+// Pyroscope server and labels every request with its route, so hotspots
+// can be attributed to handlers. The labels are plain runtime/pprof
+// labels, which a Parca agent scraping /debug/pprof picks up as well.
+package profiling
+
+import (
+	"context"
+	"os"
+	"runtime/pprof"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/grafana/pyroscope-go"
+)
+
+// Labels set on every request.
+const (
+	LabelRoute  = "route"
+	LabelMethod = "method"
+)
+
+// Config locates the Pyroscope server.
+type Config struct {
+	ServerAddress     string
+	ApplicationName   string
+	BasicAuthUser     string
+	BasicAuthPassword string
+	// Tags are attached to every profile, e.g. the environment.
+	Tags map[string]string
+}
+
+// Start begins pushing profiles. The returned profiler must be stopped
+// on shutdown to flush the last profiles.
+func Start(cfg Config) (*pyroscope.Profiler, error) {
+	tags := map[string]string{}
+	if hostname, err := os.Hostname(); err == nil {
+		tags["hostname"] = hostname
+	}
+	for key, value := range cfg.Tags {
+		tags[key] = value
+	}
+
+	return pyroscope.Start(pyroscope.Config{
+		ApplicationName:   cfg.ApplicationName,
+		ServerAddress:     cfg.ServerAddress,
+		BasicAuthUser:     cfg.BasicAuthUser,
+		BasicAuthPassword: cfg.BasicAuthPassword,
+		Tags:              tags,
+		ProfileTypes: []pyroscope.ProfileType{
+			pyroscope.ProfileCPU,
+			pyroscope.ProfileAllocObjects,
+			pyroscope.ProfileAllocSpace,
+			pyroscope.ProfileInuseObjects,
+			pyroscope.ProfileInuseSpace,
+			pyroscope.ProfileGoroutines,
+		},
+	})
+}
+
+// Labels is a middleware running the rest of the request under route and
+// method profiler labels.
+//
+// A middleware only learns the matched route after the handler ran, so
+// the route is resolved up front against the registered routes, in
+// registration order like the router does.
+func Labels() fiber.Handler {
+	var (
+		once   sync.Once
+		routes map[string][]string
+	)
+
+	return func(ctx *fiber.Ctx) error {
+		once.Do(func() {
+			routes = map[string][]string{}
+			for _, route := range ctx.App().GetRoutes(true) {
+				routes[route.Method] = append(routes[route.Method], route.Path)
+			}
+		})
+
+		method := utils.CopyString(ctx.Method())
+		route := match(ctx, routes[method])
+
+		var err error
+		pprof.Do(ctx.UserContext(), pprof.Labels(LabelRoute, route, LabelMethod, method), func(labelled context.Context) {
+			ctx.SetUserContext(labelled)
+			err = ctx.Next()
+		})
+		return err
+	}
+}
+
+func match(ctx *fiber.Ctx, patterns []string) string {
+	path := ctx.Path()
+	config := ctx.App().Config()
+	for _, pattern := range patterns {
+		if fiber.RoutePatternMatch(path, pattern, config) {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
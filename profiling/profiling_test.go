@@ -0,0 +1,41 @@
+package profiling
+
+import (
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabels(t *testing.T) {
+	app := fiber.New()
+	app.Use(Labels())
+
+	labels := map[string]string{}
+	handler := func(ctx *fiber.Ctx) error {
+		pprof.ForLabels(ctx.UserContext(), func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+		return ctx.SendStatus(fiber.StatusNoContent)
+	}
+	app.Get("/users/:id", handler)
+	app.Post("/users/:id", handler)
+	app.Get("/files/*", handler)
+
+	_, err := app.Test(httptest.NewRequest("GET", "/users/7", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{LabelRoute: "/users/:id", LabelMethod: "GET"}, labels)
+
+	_, err = app.Test(httptest.NewRequest("GET", "/files/a/b.txt", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "/files/*", labels[LabelRoute])
+
+	labels = map[string]string{}
+	response, err := app.Test(httptest.NewRequest("GET", "/nope", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Empty(t, labels)
+}
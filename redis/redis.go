@@ -0,0 +1,271 @@
+// Package redis is a small Redis client speaking RESP over a pool of
+// connections, enough for the key-value needs of the app.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNil is returned for missing keys.
+var ErrNil = errors.New("redis: nil")
+
+// Error is an error reply of the server.
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Client sends commands to one Redis server.
+type Client struct {
+	// Addr is host:port.
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	Timeout  time.Duration
+	// MaxIdle caps the connections kept open between commands. Zero
+	// means 8.
+	MaxIdle int
+
+	mu   sync.Mutex
+	idle []*conn
+}
+
+// New connects to rawURL, redis://[user:password@]host:port[/db], on
+// first use.
+func New(rawURL string) (*Client, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "redis" {
+		return nil, fmt.Errorf("redis: unsupported scheme %q", target.Scheme)
+	}
+	client := &Client{Addr: target.Host, Timeout: 5 * time.Second}
+	if target.Port() == "" {
+		client.Addr = net.JoinHostPort(target.Hostname(), "6379")
+	}
+	if user := target.User; user != nil {
+		client.Username = user.Username()
+		client.Password, _ = user.Password()
+	}
+	if db := strings.Trim(target.Path, "/"); db != "" {
+		if client.DB, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("redis: invalid database %q", db)
+		}
+	}
+	return client, nil
+}
+
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Do sends a command and returns its reply: a string, an int64, a []any,
+// or nil for a nil reply. Error replies are returned as Error, and as
+// such within arrays.
+func (c *Client) Do(ctx context.Context, args ...any) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := cn.do(ctx, c.Timeout, args)
+	var replyErr Error
+	if err != nil && !errors.As(err, &replyErr) {
+		cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Get returns the value of key, or ErrNil.
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	reply, err := c.Do(ctx, "GET", key)
+	if err != nil {
+		return "", err
+	}
+	if reply == nil {
+		return "", ErrNil
+	}
+	return reply.(string), nil
+}
+
+// Set stores value under key for ttl, or for good when ttl is zero.
+func (c *Client) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	args := []any{"SET", key, value}
+	if ttl > 0 {
+		args = append(args, "PX", ttl.Milliseconds())
+	}
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+// SetNX stores value under key for ttl unless key exists, reporting
+// whether it did.
+func (c *Client) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	reply, err := c.Do(ctx, "SET", key, value, "PX", ttl.Milliseconds(), "NX")
+	return reply != nil, err
+}
+
+// Del removes keys.
+func (c *Client) Del(ctx context.Context, keys ...string) error {
+	args := []any{"DEL"}
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+// Close closes the idle connections.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cn := range c.idle {
+		cn.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+
+	dialer := &net.Dialer{Timeout: c.Timeout}
+	raw, err := dialer.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{Conn: raw, reader: bufio.NewReader(raw)}
+	if c.Password != "" {
+		args := []any{"AUTH", c.Password}
+		if c.Username != "" {
+			args = []any{"AUTH", c.Username, c.Password}
+		}
+		if _, err := cn.do(ctx, c.Timeout, args); err != nil {
+			raw.Close()
+			return nil, err
+		}
+	}
+	if c.DB != 0 {
+		if _, err := cn.do(ctx, c.Timeout, []any{"SELECT", c.DB}); err != nil {
+			raw.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	maxIdle := c.MaxIdle
+	if maxIdle <= 0 {
+		maxIdle = 8
+	}
+	if len(c.idle) >= maxIdle {
+		cn.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+func (cn *conn) do(ctx context.Context, timeout time.Duration, args []any) (any, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	cn.SetDeadline(deadline)
+
+	var command strings.Builder
+	fmt.Fprintf(&command, "*%d\r\n", len(args))
+	for _, arg := range args {
+		var s string
+		switch arg := arg.(type) {
+		case string:
+			s = arg
+		case []byte:
+			s = string(arg)
+		case int:
+			s = strconv.Itoa(arg)
+		case int64:
+			s = strconv.FormatInt(arg, 10)
+		default:
+			return nil, fmt.Errorf("redis: unsupported argument %T", arg)
+		}
+		fmt.Fprintf(&command, "$%d\r\n%s\r\n", len(s), s)
+	}
+	if _, err := cn.Write([]byte(command.String())); err != nil {
+		return nil, err
+	}
+	return cn.read()
+}
+
+// read parses one RESP2 reply.
+func (cn *conn) read() (any, error) {
+	line, err := cn.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(cn.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		items := make([]any, count)
+		for i := range items {
+			item, err := cn.read()
+			var replyErr Error
+			if errors.As(err, &replyErr) {
+				item, err = replyErr, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
@@ -0,0 +1,141 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServer answers AUTH, SELECT, GET, SET [PX ms] [NX] and DEL from a
+// map, ignoring expiry.
+func fakeServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+	var mu sync.Mutex
+	data := map[string]string{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					args, err := readCommand(reader)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					conn.Write([]byte(answer(data, args)))
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, count)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+		args[i] = string(arg[:size])
+	}
+	return args, nil
+}
+
+func answer(data map[string]string, args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "AUTH":
+		if args[len(args)-1] != "secret" {
+			return "-WRONGPASS invalid username-password pair\r\n"
+		}
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		value, ok := data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		if _, ok := data[args[1]]; ok && strings.ToUpper(args[len(args)-1]) == "NX" {
+			return "$-1\r\n"
+		}
+		data[args[1]] = args[2]
+		return "+OK\r\n"
+	case "DEL":
+		removed := 0
+		for _, key := range args[1:] {
+			if _, ok := data[key]; ok {
+				delete(data, key)
+				removed++
+			}
+		}
+		return ":" + strconv.Itoa(removed) + "\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestClient(t *testing.T) {
+	addr := fakeServer(t)
+	client, err := New("redis://:secret@" + addr + "/2")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, client.DB)
+	defer client.Close()
+	ctx := context.Background()
+
+	_, err = client.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNil)
+
+	assert.Nil(t, client.Set(ctx, "greeting", "halo", time.Minute))
+	value, err := client.Get(ctx, "greeting")
+	assert.Nil(t, err)
+	assert.Equal(t, "halo", value)
+
+	set, err := client.SetNX(ctx, "greeting", "hai", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, set)
+	set, err = client.SetNX(ctx, "other", "hai", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, set)
+
+	assert.Nil(t, client.Del(ctx, "greeting", "other"))
+	_, err = client.Get(ctx, "greeting")
+	assert.ErrorIs(t, err, ErrNil)
+
+	_, err = client.Do(ctx, "FLUSHALL")
+	assert.Equal(t, Error("ERR unknown command"), err)
+	_, err = client.Get(ctx, "greeting")
+	assert.ErrorIs(t, err, ErrNil, "the connection survives error replies")
+
+	wrong, _ := New("redis://:nope@" + addr)
+	_, err = wrong.Get(ctx, "greeting")
+	assert.ErrorContains(t, err, "WRONGPASS")
+}
@@ -0,0 +1,122 @@
+package refdata
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/middleware/cachecontrol"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:embed data/*.json
+var data embed.FS
+
+// Datasets served by Register.
+var Datasets = []string{"countries", "currencies", "timezones", "locales"}
+
+type dataset struct {
+	body []byte
+	etag string
+}
+
+type Country struct {
+	Code      string   `json:"code"`
+	Name      string   `json:"name"`
+	TimeZones []string `json:"time_zones"`
+}
+
+type Currency struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	MinorUnits int    `json:"minor_units"`
+}
+
+// Currencies returns the embedded ISO 4217 currencies keyed by code.
+func Currencies() (map[string]Currency, error) {
+	body, err := data.ReadFile("data/currencies.json")
+	if err != nil {
+		return nil, err
+	}
+	var list []Currency
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+	currencies := make(map[string]Currency, len(list))
+	for _, currency := range list {
+		currencies[currency.Code] = currency
+	}
+	return currencies, nil
+}
+
+// Handler serves the embedded reference datasets with strong ETags so
+// clients can revalidate cheaply.
+type Handler struct {
+	MaxAge    int
+	datasets  map[string]dataset
+	countries map[string]dataset
+}
+
+func NewHandler() (*Handler, error) {
+	h := &Handler{
+		MaxAge:    86400,
+		datasets:  map[string]dataset{},
+		countries: map[string]dataset{},
+	}
+	for _, name := range Datasets {
+		body, err := data.ReadFile("data/" + name + ".json")
+		if err != nil {
+			return nil, err
+		}
+		h.datasets[name] = newDataset(body)
+	}
+
+	var countries []Country
+	if err := json.Unmarshal(h.datasets["countries"].body, &countries); err != nil {
+		return nil, err
+	}
+	for _, country := range countries {
+		body, err := json.Marshal(country)
+		if err != nil {
+			return nil, err
+		}
+		h.countries[country.Code] = newDataset(body)
+	}
+	return h, nil
+}
+
+// Register mounts the routes on router, e.g. app.Group("/api/v1/reference").
+func (h *Handler) Register(router fiber.Router) {
+	for _, name := range Datasets {
+		set := h.datasets[name]
+		router.Get("/"+name, func(ctx *fiber.Ctx) error {
+			return h.send(ctx, set)
+		})
+	}
+	router.Get("/countries/:code", func(ctx *fiber.Ctx) error {
+		set, ok := h.countries[strings.ToUpper(ctx.Params("code"))]
+		if !ok {
+			return fiber.ErrNotFound
+		}
+		return h.send(ctx, set)
+	})
+}
+
+func (h *Handler) send(ctx *fiber.Ctx, set dataset) error {
+	ctx.Set(fiber.HeaderETag, set.etag)
+	cachecontrol.Public(time.Duration(h.MaxAge) * time.Second).Apply(ctx)
+	if ctx.Fresh() {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return ctx.Send(set.body)
+}
+
+func newDataset(body []byte) dataset {
+	sum := sha256.Sum256(body)
+	return dataset{body: body, etag: `"` + hex.EncodeToString(sum[:16]) + `"`}
+}
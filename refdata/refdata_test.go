@@ -0,0 +1,54 @@
+package refdata
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceData(t *testing.T) {
+	handler, err := NewHandler()
+	assert.Nil(t, err)
+
+	app := fiber.New()
+	handler.Register(app.Group("/api/v1/reference"))
+
+	for _, name := range Datasets {
+		request := httptest.NewRequest("GET", "/api/v1/reference/"+name, nil)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode, name)
+		assert.NotEmpty(t, response.Header.Get("ETag"), name)
+
+		var list []map[string]interface{}
+		body, _ := io.ReadAll(response.Body)
+		assert.Nil(t, json.Unmarshal(body, &list), name)
+		assert.NotEmpty(t, list, name)
+
+		request = httptest.NewRequest("GET", "/api/v1/reference/"+name, nil)
+		request.Header.Set("If-None-Match", response.Header.Get("ETag"))
+		response, err = app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 304, response.StatusCode, name)
+	}
+
+	request := httptest.NewRequest("GET", "/api/v1/reference/countries/id", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	country := Country{}
+	body, _ := io.ReadAll(response.Body)
+	assert.Nil(t, json.Unmarshal(body, &country))
+	assert.Equal(t, "Indonesia", country.Name)
+	assert.Contains(t, country.TimeZones, "Asia/Jakarta")
+
+	request = httptest.NewRequest("GET", "/api/v1/reference/countries/xx", nil)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+}
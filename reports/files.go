@@ -0,0 +1,57 @@
+package reports
+
+import (
+	"context"
+	"errors"
+
+	"belajar-golang-fiber/files"
+)
+
+// KindFile is the kind of reported files.
+const KindFile = "file"
+
+// Files makes files reportable, hiding them in Trash and deleting them
+// from there when removed.
+type Files struct {
+	Trash *files.Trash
+}
+
+func (f *Files) Exists(ctx context.Context, id string) (bool, error) {
+	file, err := f.Trash.Service.Repository.Get(ctx, id)
+	if errors.Is(err, files.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !file.Trashed(), nil
+}
+
+// Hide moves the file to the trash; files already there stay.
+func (f *Files) Hide(ctx context.Context, id string) error {
+	_, err := f.Trash.Move(ctx, id)
+	if errors.Is(err, files.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Show restores the file unless it was purged meanwhile.
+func (f *Files) Show(ctx context.Context, id string) error {
+	_, err := f.Trash.Restore(ctx, id)
+	if errors.Is(err, files.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (f *Files) Remove(ctx context.Context, id string) error {
+	if err := f.Hide(ctx, id); err != nil {
+		return err
+	}
+	_, err := f.Trash.Delete(ctx, id)
+	if errors.Is(err, files.ErrNotFound) {
+		return nil
+	}
+	return err
+}
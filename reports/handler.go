@@ -0,0 +1,146 @@
+package reports
+
+import (
+	"errors"
+
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/binding"
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Handler lets signed in users report content and see their reports.
+type Handler struct {
+	Service *Service
+}
+
+// Register mounts the routes on router, e.g. app.Group("/api/v1/reports").
+func (h *Handler) Register(router fiber.Router) {
+	router.Use(session.Require())
+	router.Post("/", h.create)
+	router.Get("/", h.list)
+}
+
+type createRequest struct {
+	Kind     string `json:"kind" form:"kind"`
+	TargetID string `json:"target_id" form:"target_id"`
+	Reason   string `json:"reason" form:"reason"`
+	Details  string `json:"details" form:"details"`
+}
+
+// create answers 201 for a new report and 200 with the earlier one when
+// the user reported the content before.
+func (h *Handler) create(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[createRequest](ctx)
+	if err != nil {
+		return err
+	}
+	report, created, err := h.Service.Report(ctx.UserContext(), Input{
+		Kind:       request.Kind,
+		TargetID:   request.TargetID,
+		ReporterID: session.UserID(ctx),
+		Reason:     request.Reason,
+		Details:    request.Details,
+	})
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	if created {
+		ctx.Status(fiber.StatusCreated)
+	}
+	return ctx.JSON(report)
+}
+
+func (h *Handler) list(ctx *fiber.Ctx) error {
+	reports, err := h.Service.Store.List(ctx.UserContext(), Filter{ReporterID: session.UserID(ctx)})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(reports)
+}
+
+// AdminHandler is the triage queue of the admin area.
+type AdminHandler struct {
+	Service *Service
+}
+
+// Register mounts the routes on router, e.g. adminApp.Group("/reports").
+func (h *AdminHandler) Register(router fiber.Router) {
+	router.Get("/", h.list)
+	router.Get("/:id", h.get)
+	router.Post("/:id/:action<regex(^(hide|dismiss|remove)$)>", h.triage)
+}
+
+// list answers the reports with ?status=, unresolved ones by default, and
+// optionally of one ?kind= and ?target_id=.
+func (h *AdminHandler) list(ctx *fiber.Ctx) error {
+	filter := Filter{Kind: ctx.Query("kind"), TargetID: ctx.Query("target_id")}
+	status := ctx.Query("status")
+	switch status {
+	case "", "all":
+	case StatusOpen, StatusHidden, StatusDismissed, StatusRemoved:
+		filter.Status = status
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "unknown status")
+	}
+	reports, err := h.Service.Store.List(ctx.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+	if status == "" {
+		unresolved := reports[:0]
+		for _, report := range reports {
+			if !report.Resolved() {
+				unresolved = append(unresolved, report)
+			}
+		}
+		reports = unresolved
+	}
+	return ctx.JSON(reports)
+}
+
+func (h *AdminHandler) get(ctx *fiber.Ctx) error {
+	report, err := h.Service.Store.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.JSON(report)
+}
+
+// triage takes an optional {"note": "..."} body.
+func (h *AdminHandler) triage(ctx *fiber.Ctx) error {
+	var body struct {
+		Note string `json:"note"`
+	}
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "malformed body")
+		}
+	}
+	reports, err := h.Service.Triage(ctx.UserContext(), ctx.Params("id"), ctx.Params("action"), audit.Actor(ctx), utils.CopyString(body.Note))
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.JSON(reports)
+}
+
+func answerError(ctx *fiber.Ctx, err error) error {
+	var invalid *ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": invalid.Error(),
+			"field": invalid.Field,
+		})
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrDecided):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, files.ErrLocked):
+		return fiber.NewError(fiber.StatusLocked, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,237 @@
+// Package reports lets users report content, such as files, as abusive and
+// lets admins triage the reports: hide the content while they look into
+// it, remove it, or dismiss the reports.
+package reports
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+var (
+	// ErrNotFound is returned when no report, or no content to report,
+	// matches the given id.
+	ErrNotFound = errors.New("reports: not found")
+	// ErrDecided is returned when triaging reports that were resolved.
+	ErrDecided = errors.New("reports: already resolved")
+)
+
+// Reasons content can be reported for.
+var Reasons = []string{"spam", "harassment", "hate", "violence", "sexual", "copyright", "illegal", "other"}
+
+// Report statuses. Open and hidden reports are unresolved; hidden ones
+// have their content hidden pending review.
+const (
+	StatusOpen      = "open"
+	StatusHidden    = "hidden"
+	StatusDismissed = "dismissed"
+	StatusRemoved   = "removed"
+)
+
+// Triage actions. They apply to every unresolved report of the content.
+const (
+	ActionHide    = "hide"
+	ActionDismiss = "dismiss"
+	ActionRemove  = "remove"
+)
+
+// Report is one user's report of one piece of content.
+type Report struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	TargetID   string     `json:"target_id"`
+	ReporterID string     `json:"reporter_id"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	Note       string     `json:"note,omitempty"`
+}
+
+// Resolved reports whether an admin dismissed the report or removed its
+// content.
+func (r *Report) Resolved() bool {
+	return r.Status == StatusDismissed || r.Status == StatusRemoved
+}
+
+// ValidationError describes an invalid report.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Target is a kind of content that can be reported.
+type Target interface {
+	// Exists reports whether the content with id can be reported.
+	Exists(ctx context.Context, id string) (bool, error)
+	// Hide takes the content out of sight until Show is called.
+	Hide(ctx context.Context, id string) error
+	Show(ctx context.Context, id string) error
+	// Remove takes the content down for good.
+	Remove(ctx context.Context, id string) error
+}
+
+// Input is a new report.
+type Input struct {
+	Kind       string
+	TargetID   string
+	ReporterID string
+	Reason     string
+	Details    string
+}
+
+// Service files and triages reports.
+type Service struct {
+	Store Store
+	// AutoHide hides content once that many users reported it, until an
+	// admin decides. Zero leaves hiding to admins.
+	AutoHide int
+
+	targets map[string]Target
+}
+
+func NewService(store Store) *Service {
+	return &Service{Store: store, targets: map[string]Target{}}
+}
+
+// Handle makes content of kind reportable. Kinds are not safe to add once
+// the service is in use.
+func (s *Service) Handle(kind string, target Target) {
+	s.targets[kind] = target
+}
+
+// Report files a report. A user reporting the same content again gets
+// their earlier report back with created false.
+func (s *Service) Report(ctx context.Context, input Input) (report *Report, created bool, err error) {
+	target, ok := s.targets[input.Kind]
+	if !ok {
+		return nil, false, &ValidationError{Field: "kind", Message: "cannot be reported"}
+	}
+	if !slices.Contains(Reasons, input.Reason) {
+		return nil, false, &ValidationError{Field: "reason", Message: "must be one of " + strings.Join(Reasons, ", ")}
+	}
+	if len(input.Details) > 2000 {
+		return nil, false, &ValidationError{Field: "details", Message: "must be at most 2000 characters"}
+	}
+
+	existing, err := s.Store.List(ctx, Filter{Kind: input.Kind, TargetID: input.TargetID, ReporterID: input.ReporterID})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(existing) > 0 {
+		return existing[0], false, nil
+	}
+	exists, err := target.Exists(ctx, input.TargetID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !exists {
+		return nil, false, ErrNotFound
+	}
+
+	report = &Report{
+		ID:         utils.UUIDv4(),
+		Kind:       input.Kind,
+		TargetID:   input.TargetID,
+		ReporterID: input.ReporterID,
+		Reason:     input.Reason,
+		Details:    input.Details,
+		Status:     StatusOpen,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.Store.Create(ctx, report); err != nil {
+		return nil, false, err
+	}
+	if s.AutoHide > 0 {
+		if err := s.autoHide(ctx, report); err != nil {
+			return nil, false, err
+		}
+	}
+	return report, true, nil
+}
+
+// autoHide hides the content of report once enough users reported it.
+func (s *Service) autoHide(ctx context.Context, report *Report) error {
+	open, err := s.Store.List(ctx, Filter{Kind: report.Kind, TargetID: report.TargetID, Status: StatusOpen})
+	if err != nil || len(open) < s.AutoHide {
+		return err
+	}
+	if err := s.targets[report.Kind].Hide(ctx, report.TargetID); err != nil {
+		return err
+	}
+	for _, r := range open {
+		r.Status = StatusHidden
+		if err := s.Store.Update(ctx, r); err != nil {
+			return err
+		}
+	}
+	report.Status = StatusHidden
+	return nil
+}
+
+// Triage applies action to the content of the report with id and records
+// it on every unresolved report of that content, which it returns.
+func (s *Service) Triage(ctx context.Context, id, action, reviewer, note string) ([]*Report, error) {
+	report, err := s.Store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if report.Resolved() {
+		return nil, ErrDecided
+	}
+	target, ok := s.targets[report.Kind]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	unresolved, err := s.Store.List(ctx, Filter{Kind: report.Kind, TargetID: report.TargetID})
+	if err != nil {
+		return nil, err
+	}
+	unresolved = slices.DeleteFunc(unresolved, (*Report).Resolved)
+	hidden := slices.ContainsFunc(unresolved, func(r *Report) bool { return r.Status == StatusHidden })
+
+	var status string
+	switch action {
+	case ActionHide:
+		status = StatusHidden
+		if !hidden {
+			err = target.Hide(ctx, report.TargetID)
+		}
+	case ActionRemove:
+		status = StatusRemoved
+		err = target.Remove(ctx, report.TargetID)
+	case ActionDismiss:
+		status = StatusDismissed
+		if hidden {
+			err = target.Show(ctx, report.TargetID)
+		}
+	default:
+		return nil, &ValidationError{Field: "action", Message: "unknown"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, r := range unresolved {
+		r.Status = status
+		r.ReviewedAt = &now
+		r.ReviewedBy = reviewer
+		r.Note = note
+		if err := s.Store.Update(ctx, r); err != nil {
+			return nil, err
+		}
+	}
+	return unresolved, nil
+}
@@ -0,0 +1,145 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestApp(t *testing.T, service *Service) (*fiber.App, func(id string) string) {
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app := fiber.New()
+	app.Use(sessions.Middleware())
+	app.Post("/login/:id", func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, ctx.Params("id"))
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	handler := &Handler{Service: service}
+	handler.Register(app.Group("/reports"))
+	admin := &AdminHandler{Service: service}
+	admin.Register(app.Group("/admin/reports"))
+
+	login := func(id string) string {
+		response, err := app.Test(httptest.NewRequest("POST", "/login/"+id, nil))
+		assert.Nil(t, err)
+		return response.Cookies()[0].Value
+	}
+	return app, login
+}
+
+func send(t *testing.T, app *fiber.App, token, method, target, body string) (int, []byte) {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	var raw json.RawMessage
+	json.NewDecoder(response.Body).Decode(&raw)
+	return response.StatusCode, raw
+}
+
+func TestReportAndTriage(t *testing.T) {
+	ctx := context.Background()
+	fileService := files.NewService(storage.NewLocal(t.TempDir()), files.NewMemoryRepository())
+	trash := files.NewTrash(fileService)
+	file, err := fileService.Save(ctx, "flyer.pdf", strings.NewReader("%PDF"), "upload")
+	assert.Nil(t, err)
+
+	service := NewService(NewMemoryStore())
+	service.Handle(KindFile, &Files{Trash: trash})
+	app, login := newTestApp(t, service)
+	ani, budi := login("ani"), login("budi")
+	body := `{"kind":"file","target_id":"` + file.ID + `","reason":"spam"}`
+
+	status, _ := send(t, app, "", "POST", "/reports", body)
+	assert.Equal(t, 401, status)
+	status, _ = send(t, app, ani, "POST", "/reports", `{"kind":"file","target_id":"`+file.ID+`","reason":"boring"}`)
+	assert.Equal(t, 422, status)
+	status, _ = send(t, app, ani, "POST", "/reports", `{"kind":"comment","target_id":"1","reason":"spam"}`)
+	assert.Equal(t, 422, status)
+	status, _ = send(t, app, ani, "POST", "/reports", `{"kind":"file","target_id":"nope","reason":"spam"}`)
+	assert.Equal(t, 404, status)
+
+	var first, again Report
+	status, raw := send(t, app, ani, "POST", "/reports", body)
+	assert.Equal(t, 201, status)
+	json.Unmarshal(raw, &first)
+	status, raw = send(t, app, ani, "POST", "/reports", body)
+	assert.Equal(t, 200, status, "reporting twice returns the first report")
+	json.Unmarshal(raw, &again)
+	assert.Equal(t, first.ID, again.ID)
+	status, _ = send(t, app, budi, "POST", "/reports", body)
+	assert.Equal(t, 201, status)
+
+	var queue []Report
+	_, raw = send(t, app, "", "GET", "/admin/reports", "")
+	json.Unmarshal(raw, &queue)
+	assert.Len(t, queue, 2)
+
+	// Hiding moves the file out of sight until the reports are resolved.
+	status, _ = send(t, app, "", "POST", "/admin/reports/"+first.ID+"/hide", "")
+	assert.Equal(t, 200, status)
+	_, err = trash.Get(ctx, file.ID)
+	assert.Nil(t, err)
+
+	var dismissed []Report
+	status, raw = send(t, app, "", "POST", "/admin/reports/"+first.ID+"/dismiss", `{"note":"satire"}`)
+	assert.Equal(t, 200, status)
+	json.Unmarshal(raw, &dismissed)
+	assert.Len(t, dismissed, 2)
+	assert.Equal(t, StatusDismissed, dismissed[1].Status)
+	assert.Equal(t, "satire", dismissed[1].Note)
+	_, err = trash.Get(ctx, file.ID)
+	assert.ErrorIs(t, err, files.ErrNotFound)
+
+	status, _ = send(t, app, "", "POST", "/admin/reports/"+first.ID+"/remove", "")
+	assert.Equal(t, 409, status)
+	_, raw = send(t, app, "", "GET", "/admin/reports", "")
+	json.Unmarshal(raw, &queue)
+	assert.Len(t, queue, 0)
+
+	var mine []Report
+	_, raw = send(t, app, budi, "GET", "/reports", "")
+	json.Unmarshal(raw, &mine)
+	assert.Len(t, mine, 1)
+}
+
+func TestAutoHide(t *testing.T) {
+	ctx := context.Background()
+	fileService := files.NewService(storage.NewLocal(t.TempDir()), files.NewMemoryRepository())
+	trash := files.NewTrash(fileService)
+	file, _ := fileService.Save(ctx, "a.txt", strings.NewReader("a"), "upload")
+
+	service := NewService(NewMemoryStore())
+	service.AutoHide = 2
+	service.Handle(KindFile, &Files{Trash: trash})
+
+	report, _, err := service.Report(ctx, Input{Kind: KindFile, TargetID: file.ID, ReporterID: "1", Reason: "hate"})
+	assert.Nil(t, err)
+	assert.Equal(t, StatusOpen, report.Status)
+	report, _, err = service.Report(ctx, Input{Kind: KindFile, TargetID: file.ID, ReporterID: "2", Reason: "hate"})
+	assert.Nil(t, err)
+	assert.Equal(t, StatusHidden, report.Status)
+	_, err = trash.Get(ctx, file.ID)
+	assert.Nil(t, err)
+
+	resolved, err := service.Triage(ctx, report.ID, ActionRemove, "admin", "")
+	assert.Nil(t, err)
+	assert.Len(t, resolved, 2)
+	_, err = fileService.Repository.Get(ctx, file.ID)
+	assert.ErrorIs(t, err, files.ErrNotFound)
+}
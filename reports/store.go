@@ -0,0 +1,87 @@
+package reports
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Filter narrows List; empty fields match every report.
+type Filter struct {
+	Kind       string
+	TargetID   string
+	ReporterID string
+	Status     string
+}
+
+func (f Filter) matches(r *Report) bool {
+	return (f.Kind == "" || r.Kind == f.Kind) &&
+		(f.TargetID == "" || r.TargetID == f.TargetID) &&
+		(f.ReporterID == "" || r.ReporterID == f.ReporterID) &&
+		(f.Status == "" || r.Status == f.Status)
+}
+
+// Store persists reports.
+type Store interface {
+	Create(ctx context.Context, report *Report) error
+	Get(ctx context.Context, id string) (*Report, error)
+	// List returns the reports matching filter, oldest first.
+	List(ctx context.Context, filter Filter) ([]*Report, error)
+	Update(ctx context.Context, report *Report) error
+}
+
+// MemoryStore keeps reports in process memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	reports map[string]*Report
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{reports: map[string]*Report{}}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, report *Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *report
+	s.reports[report.ID] = &copied
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	report, ok := s.reports[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *report
+	return &copied, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	reports := []*Report{}
+	for _, report := range s.reports {
+		if filter.matches(report) {
+			copied := *report
+			reports = append(reports, &copied)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].CreatedAt.Before(reports[j].CreatedAt)
+	})
+	return reports, nil
+}
+
+func (s *MemoryStore) Update(ctx context.Context, report *Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.reports[report.ID]; !ok {
+		return ErrNotFound
+	}
+	copied := *report
+	s.reports[report.ID] = &copied
+	return nil
+}
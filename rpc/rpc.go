@@ -0,0 +1,74 @@
+// Package rpc serves the user and order services over gRPC, for internal
+// services calling the app without going through HTTP and JSON. The API
+// is defined in appv1/app.proto.
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+
+	"belajar-golang-fiber/order"
+	"belajar-golang-fiber/user"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// NewServer returns a gRPC server that recovers from panicking handlers
+// and, when token is set, requires it as "authorization: Bearer <token>"
+// metadata on every call.
+func NewServer(token string, options ...grpc.ServerOption) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{recoverer}
+	if token != "" {
+		interceptors = append(interceptors, authenticate(token))
+	}
+	options = append(options, grpc.ChainUnaryInterceptor(interceptors...))
+	return grpc.NewServer(options...)
+}
+
+func recoverer(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("rpc: panic", "method", info.FullMethod, "panic", r, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func authenticate(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, value := range md.Get("authorization") {
+			given, ok := strings.CutPrefix(value, "Bearer ")
+			if ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+				return handler(ctx, req)
+			}
+		}
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid token")
+	}
+}
+
+// toStatus maps service errors to gRPC status codes.
+func toStatus(err error) error {
+	var userInvalid *user.ValidationError
+	var orderInvalid *order.ValidationError
+	switch {
+	case errors.Is(err, user.ErrNotFound), errors.Is(err, order.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, user.ErrUsernameTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.As(err, &userInvalid), errors.As(err, &orderInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	slog.Error("rpc: call failed", "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"belajar-golang-fiber/order"
+	"belajar-golang-fiber/rpc/appv1"
+	"belajar-golang-fiber/user"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func dial(t *testing.T, token string) *grpc.ClientConn {
+	server := NewServer(token)
+	(&Users{Service: user.NewService(user.NewMemoryRepository(), "ID")}).Register(server)
+	(&Orders{Service: order.NewService(order.NewMemoryRepository(), nil)}).Register(server)
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestUsersAndOrders(t *testing.T) {
+	conn := dial(t, "")
+	users := appv1.NewUsersClient(conn)
+	orders := appv1.NewOrdersClient(conn)
+	ctx := context.Background()
+
+	created, err := users.RegisterUser(ctx, &appv1.RegisterUserRequest{Username: "budi", Password: "secret123", Name: "Budi"})
+	assert.Nil(t, err)
+	assert.Equal(t, "budi", created.GetUsername())
+	assert.False(t, created.GetCreatedAt().AsTime().IsZero())
+
+	_, err = users.RegisterUser(ctx, &appv1.RegisterUserRequest{Username: "budi", Password: "secret123"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = users.RegisterUser(ctx, &appv1.RegisterUserRequest{Username: "eko", Password: "x"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	found, err := users.GetUser(ctx, &appv1.GetUserRequest{Id: created.GetId()})
+	assert.Nil(t, err)
+	assert.Equal(t, "Budi", found.GetName())
+	_, err = users.GetUser(ctx, &appv1.GetUserRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	list, err := users.ListUsers(ctx, &appv1.ListUsersRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), list.GetTotal())
+
+	placed, err := orders.PlaceOrder(ctx, &appv1.PlaceOrderRequest{
+		UserId:   created.GetId(),
+		Currency: "idr",
+		Items:    []*appv1.Item{{Sku: "A-1", Quantity: 2, UnitPrice: 1500}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "IDR", placed.GetCurrency())
+	assert.Equal(t, int64(3000), placed.GetTotal())
+
+	_, err = orders.PlaceOrder(ctx, &appv1.PlaceOrderRequest{UserId: created.GetId(), Currency: "IDR"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	listed, err := orders.ListOrders(ctx, &appv1.ListOrdersRequest{UserId: created.GetId()})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), listed.GetTotal())
+	assert.Equal(t, placed.GetId(), listed.GetOrders()[0].GetId())
+
+	got, err := orders.GetOrder(ctx, &appv1.GetOrderRequest{Id: placed.GetId()})
+	assert.Nil(t, err)
+	assert.Equal(t, "A-1", got.GetItems()[0].GetSku())
+}
+
+func TestToken(t *testing.T) {
+	users := appv1.NewUsersClient(dial(t, "s3cret"))
+
+	_, err := users.ListUsers(context.Background(), &appv1.ListUsersRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	_, err = users.ListUsers(ctx, &appv1.ListUsersRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer s3cret")
+	_, err = users.ListUsers(ctx, &appv1.ListUsersRequest{})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"context"
+
+	"belajar-golang-fiber/mapping"
+	"belajar-golang-fiber/order"
+	"belajar-golang-fiber/rpc/appv1"
+	"belajar-golang-fiber/user"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Users serves appv1.Users from a user.Service.
+type Users struct {
+	appv1.UnimplementedUsersServer
+	Service *user.Service
+}
+
+// Register adds the service to server.
+func (s *Users) Register(server grpc.ServiceRegistrar) {
+	appv1.RegisterUsersServer(server, s)
+}
+
+func (s *Users) GetUser(ctx context.Context, req *appv1.GetUserRequest) (*appv1.User, error) {
+	found, err := s.Service.Users.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return mapping.UserMessage(found), nil
+}
+
+func (s *Users) ListUsers(ctx context.Context, req *appv1.ListUsersRequest) (*appv1.ListUsersResponse, error) {
+	users, total, err := s.Service.Users.List(ctx, user.ListOptions{
+		Offset: int(max(req.GetOffset(), 0)),
+		Limit:  int(max(req.GetLimit(), 0)),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := &appv1.ListUsersResponse{Total: int32(total)}
+	for _, found := range users {
+		response.Users = append(response.Users, mapping.UserMessage(found))
+	}
+	return response, nil
+}
+
+func (s *Users) RegisterUser(ctx context.Context, req *appv1.RegisterUserRequest) (*appv1.User, error) {
+	created, err := s.Service.Register(ctx, user.RegisterInput{
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+		Name:     req.GetName(),
+		Email:    req.GetEmail(),
+		Phone:    req.GetPhone(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return mapping.UserMessage(created), nil
+}
+
+// Orders serves appv1.Orders from an order.Service.
+type Orders struct {
+	appv1.UnimplementedOrdersServer
+	Service *order.Service
+}
+
+// Register adds the service to server.
+func (s *Orders) Register(server grpc.ServiceRegistrar) {
+	appv1.RegisterOrdersServer(server, s)
+}
+
+func (s *Orders) GetOrder(ctx context.Context, req *appv1.GetOrderRequest) (*appv1.Order, error) {
+	found, err := s.Service.Orders.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return mapping.OrderMessage(found), nil
+}
+
+func (s *Orders) ListOrders(ctx context.Context, req *appv1.ListOrdersRequest) (*appv1.ListOrdersResponse, error) {
+	orders, total, err := s.Service.Orders.ListByUser(ctx, req.GetUserId(), order.ListOptions{
+		Offset: int(max(req.GetOffset(), 0)),
+		Limit:  int(max(req.GetLimit(), 0)),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := &appv1.ListOrdersResponse{Total: int32(total)}
+	for _, found := range orders {
+		response.Orders = append(response.Orders, mapping.OrderMessage(found))
+	}
+	return response, nil
+}
+
+// PlaceOrder places the order for the user named in the request.
+func (s *Orders) PlaceOrder(ctx context.Context, req *appv1.PlaceOrderRequest) (*appv1.Order, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	items := make([]order.Item, 0, len(req.GetItems()))
+	for _, item := range req.GetItems() {
+		items = append(items, order.Item{
+			SKU:       item.GetSku(),
+			Name:      item.GetName(),
+			Quantity:  int(item.GetQuantity()),
+			UnitPrice: item.GetUnitPrice(),
+		})
+	}
+	placed, err := s.Service.Place(ctx, req.GetUserId(), order.PlaceInput{
+		Tenant:   req.GetTenant(),
+		Currency: req.GetCurrency(),
+		Items:    items,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return mapping.OrderMessage(placed), nil
+}
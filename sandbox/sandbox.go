@@ -0,0 +1,115 @@
+// Package sandbox confines client supplied names to a directory. Names
+// are cleaned lexically first, so "../" and "..\" cannot climb out, and
+// then checked on disk, so a symlink inside the directory cannot lead
+// out of it either unless the policy allows it.
+package sandbox
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrEscape is returned for names that would resolve outside the root.
+var ErrEscape = errors.New("sandbox: path escapes root")
+
+// Symlinks decides which symbolic links below the root are followed.
+type Symlinks int
+
+const (
+	// SymlinksInsideRoot follows links that resolve inside the root.
+	SymlinksInsideRoot Symlinks = iota
+	// SymlinksDeny refuses every name passing through a link.
+	SymlinksDeny
+	// SymlinksFollow follows any link, trusting whoever created it.
+	SymlinksFollow
+)
+
+// Clean turns name into a slash separated path relative to the root.
+// Backslashes count as separators and ".." never climbs above the root.
+// Names with a NUL byte are refused.
+func Clean(name string) (string, error) {
+	if strings.ContainsRune(name, 0) {
+		return "", ErrEscape
+	}
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/"), nil
+}
+
+// Resolve returns the location of name inside root on disk. Components
+// that do not exist yet are fine, so the result can be created.
+func Resolve(root, name string, symlinks Symlinks) (string, error) {
+	clean, err := Clean(name)
+	if err != nil {
+		return "", err
+	}
+	resolved := filepath.Join(root, filepath.FromSlash(clean))
+	if symlinks == SymlinksFollow || clean == "" {
+		return resolved, nil
+	}
+
+	realRoot, err := realPath(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return resolved, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	current := root
+	for _, part := range strings.Split(clean, "/") {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			continue
+		}
+		if symlinks == SymlinksDeny {
+			return "", ErrEscape
+		}
+		target, err := realPath(current)
+		if err != nil || !Within(realRoot, target) {
+			return "", ErrEscape
+		}
+	}
+	return resolved, nil
+}
+
+// realPath is the absolute path of p with every symlink resolved.
+func realPath(p string) (string, error) {
+	p, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(p)
+}
+
+// Within reports whether target is root or below it. Both must be
+// absolute or relative to the same directory.
+func Within(root, target string) bool {
+	rel, err := filepath.Rel(root, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Dir is an http.FileSystem like http.Dir that also applies a symlink
+// policy. Names escaping the root are reported as not existing.
+type Dir struct {
+	Root     string
+	Symlinks Symlinks
+}
+
+func (d Dir) Open(name string) (http.File, error) {
+	p, err := Resolve(d.Root, name, d.Symlinks)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return os.Open(p)
+}
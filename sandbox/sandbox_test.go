@@ -0,0 +1,99 @@
+package sandbox
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClean(t *testing.T) {
+	cases := map[string]string{
+		"a/b.txt":           "a/b.txt",
+		"../../etc/passwd":  "etc/passwd",
+		`..\..\secret`:      "secret",
+		"/a/./b/../c":       "a/c",
+		"a/%2e%2e/b":        "a/%2e%2e/b",
+		"":                  "",
+		"..":                "",
+		"docs//contoh.txt/": "docs/contoh.txt",
+	}
+	for name, expected := range cases {
+		clean, err := Clean(name)
+		assert.Nil(t, err, name)
+		assert.Equal(t, expected, clean, name)
+	}
+
+	_, err := Clean("a\x00.txt")
+	assert.Equal(t, ErrEscape, err)
+}
+
+// sandboxWithLinks builds root/ with a file, a link to a directory
+// inside root and a link to a directory outside it.
+func sandboxWithLinks(t *testing.T) (root, outside string) {
+	base := t.TempDir()
+	root = filepath.Join(base, "root")
+	outside = filepath.Join(base, "outside")
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "docs"), 0o755))
+	assert.Nil(t, os.MkdirAll(outside, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "docs", "contoh.txt"), []byte("dalam"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("rahasia"), 0o644))
+	if err := os.Symlink(filepath.Join(root, "docs"), filepath.Join(root, "alias")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	assert.Nil(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	assert.Nil(t, os.Symlink("../outside/secret.txt", filepath.Join(root, "secret.txt")))
+	return root, outside
+}
+
+func TestResolveSymlinks(t *testing.T) {
+	root, outside := sandboxWithLinks(t)
+
+	p, err := Resolve(root, "../docs/contoh.txt", SymlinksInsideRoot)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(root, "docs", "contoh.txt"), p)
+
+	p, err = Resolve(root, "alias/contoh.txt", SymlinksInsideRoot)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(root, "alias", "contoh.txt"), p)
+
+	p, err = Resolve(root, "docs/baru/nanti.txt", SymlinksInsideRoot)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(root, "docs", "baru", "nanti.txt"), p)
+
+	for _, name := range []string{"escape/secret.txt", "escape", "secret.txt", "escape/baru.txt"} {
+		_, err = Resolve(root, name, SymlinksInsideRoot)
+		assert.Equal(t, ErrEscape, err, name)
+	}
+
+	_, err = Resolve(root, "alias/contoh.txt", SymlinksDeny)
+	assert.Equal(t, ErrEscape, err)
+
+	p, err = Resolve(root, "escape/secret.txt", SymlinksFollow)
+	assert.Nil(t, err)
+	content, err := os.ReadFile(p)
+	assert.Nil(t, err)
+	assert.Equal(t, "rahasia", string(content))
+	assert.True(t, Within(outside, filepath.Join(outside, "secret.txt")))
+	assert.False(t, Within(root, outside))
+}
+
+func TestDir(t *testing.T) {
+	root, _ := sandboxWithLinks(t)
+	dir := Dir{Root: root}
+
+	file, err := dir.Open("/alias/contoh.txt")
+	assert.Nil(t, err)
+	content, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, "dalam", string(content))
+
+	for _, name := range []string{"/escape/secret.txt", "/secret.txt", "/../outside/secret.txt"} {
+		_, err = dir.Open(name)
+		assert.True(t, errors.Is(err, fs.ErrNotExist), name)
+	}
+}
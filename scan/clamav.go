@@ -0,0 +1,91 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// ClamAV scans with a clamd daemon over its INSTREAM command.
+type ClamAV struct {
+	// Network and Address of clamd, e.g. "tcp" and "localhost:3310" or
+	// "unix" and "/run/clamav/clamd.ctl".
+	Network string
+	Address string
+	// Timeout bounds a whole scan. Zero means one minute.
+	Timeout time.Duration
+}
+
+// NewClamAV connects to clamd at a TCP address.
+func NewClamAV(address string) *ClamAV {
+	return &ClamAV{Network: "tcp", Address: address}
+}
+
+// chunkSize stays well below clamd's default StreamMaxLength chunks.
+const chunkSize = 64 * 1024
+
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.Network, c.Address)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, err
+	}
+	chunk := make([]byte, 4+chunkSize)
+	for {
+		n, readErr := r.Read(chunk[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(chunk, uint32(n))
+			if _, err := conn.Write(chunk[:4+n]); err != nil {
+				// clamd hangs up once the stream exceeds its limit; its
+				// reply says so.
+				break
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return Result{}, readErr
+		}
+	}
+	conn.Write([]byte{0, 0, 0, 0})
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return Result{}, err
+	}
+	return parseReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseReply reads "stream: OK", "stream: <signature> FOUND" or
+// "<message> ERROR".
+func parseReply(reply string) (Result, error) {
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return Result{Clean: true}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return Result{Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	}
+	return Result{}, errors.New("clamav: " + reply)
+}
@@ -0,0 +1,70 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClamd answers INSTREAM like clamd, flagging streams containing
+// "EICAR".
+func fakeClamd(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				if command, _ := reader.ReadString(0); command != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var content strings.Builder
+				for {
+					var size uint32
+					if binary.Read(reader, binary.BigEndian, &size) != nil || size == 0 {
+						break
+					}
+					io.CopyN(&content, reader, int64(size))
+				}
+				if strings.Contains(content.String(), "EICAR") {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestClamAV(t *testing.T) {
+	scanner := NewClamAV(fakeClamd(t))
+
+	result, err := scanner.Scan(context.Background(), strings.NewReader(strings.Repeat("clean ", 30000)))
+	assert.Nil(t, err)
+	assert.True(t, result.Clean)
+
+	result, err = scanner.Scan(context.Background(), strings.NewReader("X5O!P%@AP EICAR-STANDARD-ANTIVIRUS-TEST-FILE"))
+	assert.Nil(t, err)
+	assert.False(t, result.Clean)
+	assert.Equal(t, "Eicar-Test-Signature", result.Signature)
+}
+
+func TestParseReply(t *testing.T) {
+	_, err := parseReply("INSTREAM size limit exceeded. ERROR")
+	assert.EqualError(t, err, "clamav: INSTREAM size limit exceeded. ERROR")
+}
@@ -0,0 +1,32 @@
+// Package scan checks uploaded content for malware before it is kept.
+package scan
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrInfected is returned, wrapped with the signature, for content a
+// scanner flagged.
+var ErrInfected = errors.New("scan: content rejected")
+
+// Result is the verdict on scanned content.
+type Result struct {
+	Clean bool
+	// Signature names what was found when not Clean.
+	Signature string
+}
+
+// Scanner inspects content read from r until EOF.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// Nop accepts everything.
+type Nop struct{}
+
+func (Nop) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	_, err := io.Copy(io.Discard, r)
+	return Result{Clean: true}, err
+}
@@ -0,0 +1,84 @@
+package sequence
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reset periods after which a sequence starts over.
+const (
+	ResetNever   = "never"
+	ResetYearly  = "yearly"
+	ResetMonthly = "monthly"
+)
+
+var placeholder = regexp.MustCompile(`\{([A-Z]+)(?::(\d+))?\}`)
+
+// Params are the values a number is formatted with.
+type Params struct {
+	Tenant string
+	Time   time.Time
+}
+
+// ValidateFormat checks that format only uses known placeholders and
+// contains {SEQ}.
+func ValidateFormat(format string) error {
+	hasSeq := false
+	for _, match := range placeholder.FindAllStringSubmatch(format, -1) {
+		switch match[1] {
+		case "SEQ":
+			hasSeq = true
+		case "YYYY", "YY", "MM", "TENANT":
+		default:
+			return fmt.Errorf("sequence: unknown placeholder {%s}", match[1])
+		}
+	}
+	if !hasSeq {
+		return fmt.Errorf("sequence: format must contain {SEQ}")
+	}
+	return nil
+}
+
+// Format renders a number, e.g. "INV/{YYYY}/{SEQ:6}" with value 42 in
+// 2026 gives "INV/2026/000042". {TENANT} is upper-cased.
+func Format(format string, value int64, params Params) string {
+	return placeholder.ReplaceAllStringFunc(format, func(token string) string {
+		match := placeholder.FindStringSubmatch(token)
+		switch match[1] {
+		case "SEQ":
+			digits := strconv.FormatInt(value, 10)
+			if width, _ := strconv.Atoi(match[2]); width > len(digits) {
+				digits = strings.Repeat("0", width-len(digits)) + digits
+			}
+			return digits
+		case "YYYY":
+			return params.Time.Format("2006")
+		case "YY":
+			return params.Time.Format("06")
+		case "MM":
+			return params.Time.Format("01")
+		case "TENANT":
+			return strings.ToUpper(params.Tenant)
+		}
+		return token
+	})
+}
+
+// scope is the counter a definition allocates from for params: one per
+// tenant when the format includes {TENANT}, and one per reset period.
+func scope(definition Definition, params Params) string {
+	var parts []string
+	if strings.Contains(definition.Format, "{TENANT}") {
+		parts = append(parts, strings.ToLower(params.Tenant))
+	}
+	switch definition.Reset {
+	case ResetYearly:
+		parts = append(parts, params.Time.Format("2006"))
+	case ResetMonthly:
+		parts = append(parts, params.Time.Format("2006-01"))
+	}
+	return strings.Join(parts, "/")
+}
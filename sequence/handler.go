@@ -0,0 +1,67 @@
+package sequence
+
+import (
+	"errors"
+
+	"belajar-golang-fiber/binding"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Handler is the admin API for sequence definitions.
+type Handler struct {
+	Service *Service
+}
+
+// Register mounts the routes on router, e.g. adminApp.Group("/sequences").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/", h.list)
+	router.Get("/:name", h.get)
+	router.Put("/:name", h.save)
+}
+
+func (h *Handler) list(ctx *fiber.Ctx) error {
+	definitions, err := h.Service.Store.Definitions(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(definitions)
+}
+
+// get answers the definition with the number that would come next for
+// ?tenant=.
+func (h *Handler) get(ctx *fiber.Ctx) error {
+	definition, err := h.Service.Store.Definition(ctx.UserContext(), ctx.Params("name"))
+	if errors.Is(err, ErrNotFound) {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	next, err := h.Service.Preview(ctx.UserContext(), definition.Name, Params{Tenant: ctx.Query("tenant")})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(fiber.Map{
+		"definition": definition,
+		"next":       next,
+	})
+}
+
+// save creates or replaces a definition. Counters are kept, so changing
+// the format of a running sequence does not restart it.
+func (h *Handler) save(ctx *fiber.Ctx) error {
+	definition, err := binding.Bind[Definition](ctx)
+	if err != nil {
+		return err
+	}
+	definition.Name = utils.CopyString(ctx.Params("name"))
+	if err := definition.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	}
+	if err := h.Service.Store.SaveDefinition(ctx.UserContext(), *definition); err != nil {
+		return err
+	}
+	return ctx.JSON(definition)
+}
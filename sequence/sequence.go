@@ -0,0 +1,106 @@
+// Package sequence allocates gap-free document numbers such as invoice
+// and order numbers, formatted per definition.
+package sequence
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned for unknown sequence names.
+var ErrNotFound = errors.New("sequence: not found")
+
+// Definition describes how numbers of one sequence look and when the
+// counter starts over.
+type Definition struct {
+	Name   string `json:"name" form:"name"`
+	Format string `json:"format" form:"format"`
+	Reset  string `json:"reset" form:"reset"`
+	Start  int64  `json:"start" form:"start"`
+}
+
+// Validate checks the format and reset period, defaulting Reset and Start.
+func (d *Definition) Validate() error {
+	if d.Reset == "" {
+		d.Reset = ResetNever
+	}
+	if d.Start <= 0 {
+		d.Start = 1
+	}
+	switch d.Reset {
+	case ResetNever, ResetYearly, ResetMonthly:
+	default:
+		return errors.New("sequence: reset must be never, yearly or monthly")
+	}
+	return ValidateFormat(d.Format)
+}
+
+// Defaults are saved by NewService for names the store does not know.
+var Defaults = []Definition{
+	{Name: "invoice", Format: "INV/{YYYY}/{SEQ:6}", Reset: ResetYearly, Start: 1},
+	{Name: "order", Format: "ORD-{YY}{SEQ:6}", Reset: ResetYearly, Start: 1},
+}
+
+// Service allocates numbers from a Store.
+type Service struct {
+	Store Store
+	now   func() time.Time
+}
+
+func NewService(ctx context.Context, store Store) (*Service, error) {
+	for _, definition := range Defaults {
+		if _, err := store.Definition(ctx, definition.Name); errors.Is(err, ErrNotFound) {
+			if err := store.SaveDefinition(ctx, definition); err != nil {
+				return nil, err
+			}
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return &Service{Store: store, now: time.Now}, nil
+}
+
+// Next allocates the next number of sequence name and passes it to fn,
+// which should store the numbered document. When fn fails the number is
+// not used up.
+func (s *Service) Next(ctx context.Context, name string, params Params, fn func(number string) error) (string, error) {
+	definition, err := s.Store.Definition(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if params.Time.IsZero() {
+		params.Time = s.now()
+	}
+
+	var number string
+	_, err = s.Store.Allocate(ctx, name, scope(definition, params), definition.Start, func(value int64) error {
+		number = Format(definition.Format, value, params)
+		return fn(number)
+	})
+	if err != nil {
+		return "", err
+	}
+	return number, nil
+}
+
+// Preview returns the number Next would allocate, without allocating it.
+func (s *Service) Preview(ctx context.Context, name string, params Params) (string, error) {
+	definition, err := s.Store.Definition(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if params.Time.IsZero() {
+		params.Time = s.now()
+	}
+
+	current, err := s.Store.Current(ctx, name, scope(definition, params))
+	if err != nil {
+		return "", err
+	}
+	next := definition.Start
+	if current > 0 {
+		next = current + 1
+	}
+	return Format(definition.Format, next, params), nil
+}
@@ -0,0 +1,106 @@
+package sequence
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	at := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "INV/2026/000042", Format("INV/{YYYY}/{SEQ:6}", 42, Params{Time: at}))
+	assert.Equal(t, "ACME-2603-7", Format("{TENANT}-{YY}{MM}-{SEQ}", 7, Params{Tenant: "acme", Time: at}))
+	assert.Equal(t, "1234567", Format("{SEQ:3}", 1234567, Params{}))
+
+	assert.Nil(t, ValidateFormat("ORD-{TENANT}-{SEQ:5}"))
+	assert.NotNil(t, ValidateFormat("ORD-{YYYY}"))
+	assert.NotNil(t, ValidateFormat("ORD-{DAY}-{SEQ}"))
+}
+
+func TestNextIsGapFree(t *testing.T) {
+	service, err := NewService(context.Background(), NewMemoryStore())
+	assert.Nil(t, err)
+	at := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	number, err := service.Next(context.Background(), "invoice", Params{Time: at}, func(string) error { return nil })
+	assert.Nil(t, err)
+	assert.Equal(t, "INV/2026/000001", number)
+
+	_, err = service.Next(context.Background(), "invoice", Params{Time: at}, func(string) error { return errors.New("insert failed") })
+	assert.NotNil(t, err)
+
+	number, _ = service.Next(context.Background(), "invoice", Params{Time: at}, func(string) error { return nil })
+	assert.Equal(t, "INV/2026/000002", number)
+
+	// Yearly reset.
+	number, _ = service.Next(context.Background(), "invoice", Params{Time: at.AddDate(0, 0, 1)}, func(string) error { return nil })
+	assert.Equal(t, "INV/2027/000001", number)
+
+	_, err = service.Next(context.Background(), "receipt", Params{}, func(string) error { return nil })
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestNextPerTenantConcurrently(t *testing.T) {
+	service, err := NewService(context.Background(), NewMemoryStore())
+	assert.Nil(t, err)
+	assert.Nil(t, service.Store.SaveDefinition(context.Background(), Definition{Name: "order", Format: "{TENANT}-{SEQ}", Reset: ResetNever, Start: 1}))
+
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(tenant string) {
+			defer wg.Done()
+			number, err := service.Next(context.Background(), "order", Params{Tenant: tenant}, func(string) error { return nil })
+			assert.Nil(t, err)
+			mu.Lock()
+			seen[number] = true
+			mu.Unlock()
+		}([]string{"a", "b"}[i%2])
+	}
+	wg.Wait()
+	assert.Len(t, seen, 50)
+	assert.True(t, seen["A-25"])
+	assert.True(t, seen["B-25"])
+}
+
+func TestHandler(t *testing.T) {
+	service, err := NewService(context.Background(), NewMemoryStore())
+	assert.Nil(t, err)
+	app := fiber.New()
+	handler := &Handler{Service: service}
+	handler.Register(app.Group("/sequences"))
+
+	request := httptest.NewRequest("PUT", "/sequences/order", strings.NewReader(`{"format":"ORD/{TENANT}/{YYYY}/{SEQ:4}","reset":"yearly","start":100}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest("GET", "/sequences/order?tenant=acme", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	assert.Contains(t, string(body), `"next":"ORD/ACME/`+time.Now().Format("2006")+`/0100"`)
+
+	request = httptest.NewRequest("PUT", "/sequences/order", strings.NewReader(`{"format":"ORD"}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 422, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest("GET", "/sequences", nil))
+	assert.Nil(t, err)
+	body, _ = io.ReadAll(response.Body)
+	assert.Contains(t, string(body), `"name":"invoice"`)
+	assert.Contains(t, string(body), `"name":"order"`)
+}
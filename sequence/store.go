@@ -0,0 +1,93 @@
+package sequence
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Store persists definitions and counters. Allocate must be atomic: the
+// counter only moves when fn succeeds, so a failed insert of the numbered
+// document leaves no gap. A database store runs fn in the transaction that
+// locks the counter row.
+type Store interface {
+	Definition(ctx context.Context, name string) (Definition, error)
+	Definitions(ctx context.Context) ([]Definition, error)
+	SaveDefinition(ctx context.Context, definition Definition) error
+	// Current returns the last allocated value of the counter, 0 if none.
+	Current(ctx context.Context, name, scope string) (int64, error)
+	Allocate(ctx context.Context, name, scope string, start int64, fn func(value int64) error) (int64, error)
+}
+
+// MemoryStore keeps sequences in process memory. Under Prefork every
+// process counts on its own, so it is only suitable for development.
+type MemoryStore struct {
+	mu          sync.Mutex
+	definitions map[string]Definition
+	counters    map[string]int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		definitions: map[string]Definition{},
+		counters:    map[string]int64{},
+	}
+}
+
+func (s *MemoryStore) Definition(ctx context.Context, name string) (Definition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	definition, ok := s.definitions[name]
+	if !ok {
+		return Definition{}, ErrNotFound
+	}
+	return definition, nil
+}
+
+func (s *MemoryStore) Definitions(ctx context.Context) ([]Definition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Definition, 0, len(s.definitions))
+	for _, definition := range s.definitions {
+		list = append(list, definition)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+func (s *MemoryStore) SaveDefinition(ctx context.Context, definition Definition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.definitions[definition.Name] = definition
+	return nil
+}
+
+func (s *MemoryStore) Current(ctx context.Context, name, scope string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counters[name+"\x00"+scope], nil
+}
+
+func (s *MemoryStore) Allocate(ctx context.Context, name, scope string, start int64, fn func(value int64) error) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := name + "\x00" + scope
+	value, ok := s.counters[key]
+	if ok {
+		value++
+	} else {
+		value = start
+	}
+	if err := fn(value); err != nil {
+		return 0, err
+	}
+	s.counters[key] = value
+	return value, nil
+}
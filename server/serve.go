@@ -0,0 +1,196 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/config"
+	"belajar-golang-fiber/logger"
+	"belajar-golang-fiber/middleware/https"
+	"belajar-golang-fiber/middleware/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+)
+
+// listen serves app over plain HTTP or HTTPS depending on TLS_MODE. With
+// TLS on, a second listener on TLS_HTTP_ADDR redirects to HTTPS (and
+// answers ACME HTTP-01 challenges in acme mode). In acme mode, policy
+// decides which hosts get certificates; nil allows TLS_DOMAINS.
+func listen(app *fiber.App, cfg *config.Config, policy autocert.HostPolicy) error {
+	switch cfg.TLS.Mode {
+	case "file":
+		go serveRedirect(cfg.TLS.HTTPAddr, https.RedirectHandler(http.StatusPermanentRedirect))
+		return app.ListenTLS(cfg.Addr, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	case "acme":
+		if policy == nil {
+			policy = autocert.HostWhitelist(cfg.TLS.Domains...)
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: policy,
+			Cache:      autocert.DirCache(cfg.TLS.CacheDir),
+			Email:      cfg.TLS.Email,
+		}
+		go serveRedirect(cfg.TLS.HTTPAddr, manager.HTTPHandler(nil))
+
+		listener, err := tls.Listen("tcp", cfg.Addr, manager.TLSConfig())
+		if err != nil {
+			return err
+		}
+		return app.Listener(listener)
+	default:
+		return app.Listen(cfg.Addr)
+	}
+}
+
+func serveRedirect(addr string, handler http.Handler) {
+	if fiber.IsChild() {
+		return
+	}
+	if err := http.ListenAndServe(addr, handler); err != nil {
+		log.Printf("https redirect listener: %v", err)
+	}
+}
+
+// serveGRPC listens on addr and serves server in the background. Only
+// the parent process does; checkPrefork refuses gRPC under Prefork.
+func serveGRPC(addr string, server *grpc.Server) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Printf("grpc listener: %v", err)
+		}
+	}()
+	return nil
+}
+
+// checkPrefork refuses configurations prefork would break. Ingest and
+// gRPC run in the parent process only, but files and orders are kept in
+// each process's memory: the children would never see the ingested files,
+// and gRPC clients would never see what the children wrote. Sessions, and
+// users under DATABASE_DRIVER=memory, are kept in memory too, so a user
+// signed in by one child would be unknown to the next.
+func checkPrefork(cfg *config.Config) error {
+	if !cfg.Prefork {
+		return nil
+	}
+	if cfg.Ingest.Dir != "" || cfg.Ingest.SFTPAddr != "" {
+		return errors.New("APP_PREFORK: ingest needs a single process, as files are kept in memory")
+	}
+	if cfg.GRPC.Addr != "" {
+		return errors.New("APP_PREFORK: gRPC needs a single process, as orders are kept in memory")
+	}
+	return errors.New("APP_PREFORK: sessions are kept in memory, which prefork children do not share")
+}
+
+// Start serves requests until Stop is called, over HTTP or HTTPS as
+// TLS_MODE says. The parent process also serves gRPC and watches the drop
+// directories, which prefork children cannot share.
+func (s *Server) Start() error {
+	if fiber.IsChild() {
+		fmt.Println("Child process")
+	} else {
+		fmt.Println("Parent process")
+		if err := startIngest(s.ctx, s.Config.Ingest, s.Files); err != nil {
+			return err
+		}
+		if s.grpc != nil {
+			if err := serveGRPC(s.Config.GRPC.Addr, s.grpc); err != nil {
+				return err
+			}
+		}
+	}
+	return listen(s.App, s.Config, s.hostPolicy)
+}
+
+// Stop stops accepting requests, gRPC ones included, and gives the
+// running ones SHUTDOWN_TIMEOUT to finish. Background workers are stopped
+// and connections closed afterwards.
+func (s *Server) Stop() error {
+	timeout := s.Config.ShutdownTimeout
+	var wg sync.WaitGroup
+	if s.grpc != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stopGRPC(s.grpc, timeout)
+		}()
+	}
+	err := s.App.ShutdownWithTimeout(timeout)
+	wg.Wait()
+	return errors.Join(err, s.close())
+}
+
+// Reload reads the configuration again, as config.Read does, and applies
+// what can change without a restart: LOG_LEVEL, the RATE_LIMIT_* of /api
+// and FEATURES. An invalid configuration is rejected as a whole, leaving
+// the running one in place.
+//
+// Reload only changes the process it runs in, so it needs the server to
+// be a single process: prefork children would each keep the configuration
+// they started with, whichever one took POST /admin/config/reload, and
+// SIGHUP only reaches the parent. checkPrefork refuses Prefork, and Reload
+// refuses to run under it should that change.
+func (s *Server) Reload() error {
+	if s.Config.Prefork {
+		return errors.New("reload needs a single process; restart prefork servers instead")
+	}
+	s.reloading.Lock()
+	defer s.reloading.Unlock()
+
+	cfg, err := config.Read()
+	if err != nil {
+		slog.Warn("configuration not reloaded", "error", err)
+		return err
+	}
+	s.logLevel.Set(logger.ParseLevel(cfg.Log.Level))
+	s.apiLimit.Update(ratelimit.Config{
+		Max:         cfg.RateLimit.Max,
+		Window:      cfg.RateLimit.Window,
+		Mode:        cfg.RateLimit.Mode,
+		EnforceFrom: cfg.RateLimit.EnforceFrom,
+	})
+	s.Features.Set(cfg.Features)
+	slog.Info("configuration reloaded",
+		"log_level", cfg.Log.Level,
+		"rate_limit_max", cfg.RateLimit.Max,
+		"rate_limit_mode", cfg.RateLimit.Mode,
+		"features", cfg.Features)
+	return nil
+}
+
+// close stops the background workers and closes what the server opened,
+// last opened first.
+func (s *Server) close() error {
+	s.cancel()
+	var errs []error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		errs = append(errs, s.closers[i]())
+	}
+	return errors.Join(errs...)
+}
+
+func stopGRPC(server *grpc.Server, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		server.Stop()
+	}
+}
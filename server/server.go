@@ -0,0 +1,996 @@
+// Package server builds the whole application, so that other Go programs
+// can embed it and add routes of their own:
+//
+//	srv, err := server.NewServer(config.Load(), server.ModuleFunc(func(s *server.Server) error {
+//		s.App.Get("/api/v1/hello", hello)
+//		return nil
+//	}))
+//	go srv.Start()
+//	defer srv.Stop()
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/account"
+	"belajar-golang-fiber/address"
+	"belajar-golang-fiber/admin"
+	"belajar-golang-fiber/audit"
+	"belajar-golang-fiber/binding"
+	"belajar-golang-fiber/businessday"
+	"belajar-golang-fiber/calendar"
+	"belajar-golang-fiber/captcha"
+	"belajar-golang-fiber/config"
+	"belajar-golang-fiber/consent"
+	"belajar-golang-fiber/contacts"
+	"belajar-golang-fiber/debugstore"
+	"belajar-golang-fiber/domains"
+	"belajar-golang-fiber/errorpage"
+	"belajar-golang-fiber/events"
+	"belajar-golang-fiber/features"
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/fx"
+	"belajar-golang-fiber/gateway"
+	"belajar-golang-fiber/graphql"
+	"belajar-golang-fiber/health"
+	"belajar-golang-fiber/httpclient"
+	"belajar-golang-fiber/i18n"
+	"belajar-golang-fiber/images"
+	"belajar-golang-fiber/inbound"
+	"belajar-golang-fiber/jobs"
+	"belajar-golang-fiber/jsoncodec"
+	"belajar-golang-fiber/legalhold"
+	"belajar-golang-fiber/logger"
+	"belajar-golang-fiber/mapping"
+	"belajar-golang-fiber/middleware/adminauth"
+	"belajar-golang-fiber/middleware/analytics"
+	"belajar-golang-fiber/middleware/bodylimit"
+	"belajar-golang-fiber/middleware/cachecontrol"
+	"belajar-golang-fiber/middleware/clientguard"
+	"belajar-golang-fiber/middleware/csrf"
+	"belajar-golang-fiber/middleware/deadline"
+	"belajar-golang-fiber/middleware/dedupe"
+	"belajar-golang-fiber/middleware/https"
+	"belajar-golang-fiber/middleware/idempotency"
+	"belajar-golang-fiber/middleware/ipfilter"
+	"belajar-golang-fiber/middleware/negotiate"
+	"belajar-golang-fiber/middleware/normalize"
+	"belajar-golang-fiber/middleware/proxy"
+	"belajar-golang-fiber/middleware/ratelimit"
+	"belajar-golang-fiber/middleware/realip"
+	"belajar-golang-fiber/middleware/secure"
+	"belajar-golang-fiber/middleware/securecookie"
+	"belajar-golang-fiber/middleware/tracing"
+	"belajar-golang-fiber/middleware/vhost"
+	"belajar-golang-fiber/moderation"
+	"belajar-golang-fiber/ocr"
+	"belajar-golang-fiber/order"
+	"belajar-golang-fiber/phone"
+	"belajar-golang-fiber/previews"
+	"belajar-golang-fiber/profiling"
+	"belajar-golang-fiber/refdata"
+	"belajar-golang-fiber/reports"
+	"belajar-golang-fiber/rpc"
+	"belajar-golang-fiber/scan"
+	"belajar-golang-fiber/sequence"
+	"belajar-golang-fiber/session"
+	"belajar-golang-fiber/static"
+	"belajar-golang-fiber/storage"
+	"belajar-golang-fiber/tenant"
+	"belajar-golang-fiber/terms"
+	"belajar-golang-fiber/user"
+	"belajar-golang-fiber/view"
+	"belajar-golang-fiber/webhooks"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/middleware/expvar"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/webdav"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Assets are served on /public when STATIC_FROM_DISK is off; the command
+// sets them to the files it embeds from ./source. Without them, ./source
+// is read from disk.
+var Assets http.FileSystem
+
+// Module adds routes, middleware or hooks to a server. Modules are
+// registered after the built-in routes, before the catch-all ones.
+type Module interface {
+	Register(s *Server) error
+}
+
+// ModuleFunc lets a function be a Module.
+type ModuleFunc func(s *Server) error
+
+func (f ModuleFunc) Register(s *Server) error {
+	return f(s)
+}
+
+// Server is the application with the services its modules build on.
+type Server struct {
+	// Config is the configuration the server was built with; Reload does
+	// not change it.
+	Config *config.Config
+	App    *fiber.App
+	// Features are the feature flags of FEATURES, replaced on Reload.
+	Features *features.Flags
+
+	Users    *user.Service
+	Orders   *order.Service
+	Files    *files.Service
+	Audit    *audit.Logger
+	Sessions *session.Manager
+	// Jobs runs background jobs; modules attach their handlers to it.
+	Jobs *jobs.Queue
+
+	ctx        context.Context
+	cancel     context.CancelFunc
+	closers    []func() error
+	grpc       *grpc.Server
+	hostPolicy autocert.HostPolicy
+
+	reloading sync.Mutex
+	logLevel  *slog.LevelVar
+	apiLimit  *ratelimit.Policy
+}
+
+// NewServer wires the application as configured and registers modules.
+// Background workers start right away; requests are served from Start.
+func NewServer(cfg *config.Config, modules ...Module) (*Server, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := checkPrefork(cfg); err != nil {
+		return nil, err
+	}
+	logLevel := new(slog.LevelVar)
+	log := logger.New(logger.Config{Level: cfg.Log.Level, Format: cfg.Log.Format, LevelVar: logLevel})
+	slog.SetDefault(log)
+
+	engine, err := view.New(view.Config{
+		Engine:    cfg.View.Engine,
+		Directory: cfg.View.Directory,
+		Reload:    cfg.View.Reload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpclient.ConfigDefault.Retries = cfg.HTTPClient.Retries
+	httpclient.ConfigDefault.FailureThreshold = cfg.HTTPClient.FailureThreshold
+	httpclient.ConfigDefault.OpenTimeout = cfg.HTTPClient.OpenTimeout
+
+	binding.JSONLimitsDefault = binding.JSONLimits{
+		MaxDepth:        cfg.JSONGuard.MaxDepth,
+		MaxKeys:         cfg.JSONGuard.MaxKeys,
+		MaxStringLength: cfg.JSONGuard.MaxStringLength,
+	}
+
+	bundle, err := i18n.Load(cfg.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		Config:   cfg,
+		Features: features.New(cfg.Features...),
+		ctx:      ctx,
+		cancel:   cancel,
+		logLevel: logLevel,
+	}
+	// What was started is stopped again when construction fails.
+	built := false
+	defer func() {
+		if !built {
+			s.close()
+		}
+	}()
+
+	app := fiber.New(fiber.Config{
+		Views:             engine,
+		ViewsLayout:       cfg.View.Layout,
+		PassLocalsToViews: true,
+		IdleTimeout:       cfg.IdleTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		Prefork:           cfg.Prefork,
+		RequestMethods:    append(append([]string{}, fiber.DefaultMethods...), storage.WebDAVMethods...),
+		JSONEncoder:       jsoncodec.Marshal,
+		JSONDecoder:       jsoncodec.Unmarshal,
+		StreamRequestBody: cfg.Storage.StreamUploads,
+		BodyLimit:         cfg.Limits.MaxBody,
+		ReadBufferSize:    cfg.Limits.ReadBufferSize,
+		Concurrency:       cfg.Limits.Concurrency,
+		ErrorHandler:      errorpage.New(errorpage.Config{Bundle: bundle}),
+	})
+
+	repos, err := newRepositories(ctx, cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	users, orders := repos.Users, repos.Orders
+	// Each tenant has its users and orders in a schema of its own, opened
+	// when first used.
+	var tenants tenant.Store
+	var tenantRequests *ratelimit.Policy
+	if cfg.Tenancy.Enabled {
+		tenants = tenant.NewMemoryStore()
+		tenantRequests = ratelimit.NewPolicy(ratelimit.Config{
+			Name:   "tenant",
+			Max:    cfg.Tenancy.Requests,
+			Window: cfg.Tenancy.RequestWindow,
+			Next: func(c *fiber.Ctx) bool {
+				return tenantRequestLimit(c, cfg.Tenancy.Requests) <= 0
+			},
+			KeyGenerator: func(c *fiber.Ctx) string {
+				return tenant.From(c).ID
+			},
+			Limit: func(c *fiber.Ctx) int {
+				return tenantRequestLimit(c, cfg.Tenancy.Requests)
+			},
+		})
+		for _, id := range cfg.Tenancy.Tenants {
+			if !tenant.ValidID(id) {
+				return nil, fmt.Errorf("TENANTS: invalid tenant id %q", id)
+			}
+			tenants.Create(ctx, &tenant.Tenant{ID: id, Name: id, Schema: tenant.DefaultSchema(id), CreatedAt: time.Now().UTC()})
+		}
+		schemas := tenant.NewPartitioned(repos, repos.Schema)
+		users = &user.TenantRepository{For: func(ctx context.Context) (user.Repository, error) {
+			partition, err := schemas.For(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return partition.Users, nil
+		}}
+		orders = &order.TenantRepository{For: func(ctx context.Context) (order.Repository, error) {
+			partition, err := schemas.For(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return partition.Orders, nil
+		}}
+	}
+	store, err := newStorage(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+	fileService := files.NewService(store, files.NewMemoryRepository())
+	fileService.MaxVersions = cfg.Storage.MaxVersions
+	if cfg.Storage.ClamAVAddress != "" {
+		fileService.Scanner = scan.NewClamAV(cfg.Storage.ClamAVAddress)
+	}
+	if quota := cfg.Storage.Quota; quota != (config.QuotaConfig{}) || tenants != nil {
+		fileService.Quota = files.NewQuota(
+			files.Limits{Bytes: quota.UserBytes, Files: quota.UserFiles},
+			files.Limits{Bytes: quota.TenantBytes, Files: quota.TenantFiles},
+		)
+	}
+	if tenants != nil {
+		// Tenants' settings override the storage quota of tenants.
+		fileService.Quota.ForTenant = func(ctx context.Context, id string) (files.Limits, error) {
+			t, err := tenants.Get(ctx, id)
+			if errors.Is(err, tenant.ErrNotFound) {
+				return fileService.Quota.Tenant, nil
+			}
+			if err != nil {
+				return files.Limits{}, err
+			}
+			return files.Limits{
+				Bytes: t.IntSetting(tenant.SettingStorageBytes, fileService.Quota.Tenant.Bytes),
+				Files: int(t.IntSetting(tenant.SettingStorageFiles, int64(fileService.Quota.Tenant.Files))),
+			}, nil
+		}
+	}
+	calendarService := calendar.NewService(calendar.NewMemoryRepository(), fileService)
+	fileService.AfterSave(calendarService.ImportFile)
+
+	// Deleted files stay restorable until the trash purges them.
+	trash := files.NewTrash(fileService)
+	trash.Retention = cfg.Storage.TrashRetention
+
+	checkers := []moderation.Checker{moderation.NewKeywords(cfg.Moderation.FlagWords, cfg.Moderation.BlockWords)}
+	if cfg.Moderation.APIURL != "" {
+		checkers = append(checkers, moderation.NewAPI(cfg.Moderation.APIURL, cfg.Moderation.APIToken))
+	}
+	moderator := moderation.NewModerator(moderation.NewMemoryStore(), checkers...)
+	uploadModeration := &moderation.Uploads{Moderator: moderator, Files: fileService, Trash: trash}
+	uploadModeration.Attach()
+	reportService := reports.NewService(reports.NewMemoryStore())
+	reportService.AutoHide = cfg.ReportsAutoHide
+	reportService.Handle(reports.KindFile, &reports.Files{Trash: trash})
+
+	queue := jobs.NewQueue(1000)
+	imageProcessor := images.NewProcessor(fileService)
+	imageProcessor.Attach(queue)
+	previewGenerator := previews.NewGenerator(fileService)
+	if cfg.Storage.PDFToPPM != "" {
+		previewGenerator.PDFToPPM = cfg.Storage.PDFToPPM
+	}
+	if cfg.Storage.Soffice != "" {
+		previewGenerator.Soffice = cfg.Storage.Soffice
+	}
+	previewGenerator.Attach(queue)
+	ocrProcessor := &ocr.Processor{
+		Files:    fileService,
+		Store:    ocr.NewMemoryStore(),
+		PDFToPPM: previewGenerator.PDFToPPM,
+	}
+	if tesseract, err := exec.LookPath(cmp.Or(cfg.OCR.Tesseract, "tesseract")); err == nil {
+		ocrProcessor.Engine = &ocr.Tesseract{Command: tesseract, Languages: cfg.OCR.Languages}
+		ocrProcessor.Attach(queue)
+	}
+	var publisher *events.Publisher
+	broker, err := newBroker(cfg.Events)
+	if err != nil {
+		return nil, err
+	}
+	if broker != nil {
+		s.closers = append(s.closers, broker.Close)
+		publisher = events.NewPublisher(broker)
+		publisher.Prefix = cfg.Events.Prefix
+		if publisher.Encoder, err = events.NewEncoder(cfg.Events.Encoding); err != nil {
+			return nil, err
+		}
+		publisher.Attach(queue)
+		fileService.AfterSave(func(ctx context.Context, file *files.File) {
+			publisher.PublishLogged(ctx, events.FileUploaded{
+				FileID:      file.ID,
+				Name:        file.Name,
+				ContentType: file.ContentType,
+				Size:        file.Size,
+				Checksum:    file.Checksum,
+				UserID:      file.UserID,
+				Tenant:      file.Tenant,
+				Version:     file.Version,
+			})
+		})
+	}
+	go queue.Run(ctx, cfg.JobWorkers)
+
+	// Webhooks get a queue of their own, retrying for longer than other
+	// jobs without holding them up.
+	webhookQueue := jobs.NewQueue(1000)
+	webhookQueue.MaxAttempts = cfg.Webhooks.MaxAttempts
+	webhookQueue.Backoff = cfg.Webhooks.Backoff
+	dispatcher := webhooks.NewDispatcher(webhooks.NewMemoryRepository(), webhooks.NewMemoryDeliveryLog())
+	dispatcher.Attach(webhookQueue)
+	go webhookQueue.Run(ctx, cfg.Webhooks.Workers)
+	fileService.AfterSave(func(ctx context.Context, file *files.File) {
+		dispatcher.PublishLogged(ctx, webhooks.EventFileUploaded, file)
+	})
+
+	sequences, err := sequence.NewService(ctx, sequence.NewMemoryStore())
+	if err != nil {
+		return nil, err
+	}
+
+	var auditStore audit.Store = audit.NewMemoryStore()
+	if cfg.Audit.Path != "" {
+		fileStore, err := audit.NewFileStore(cfg.Audit.Path)
+		if err != nil {
+			return nil, err
+		}
+		s.closers = append(s.closers, fileStore.Close)
+		auditStore = fileStore
+	}
+	auditLog := audit.NewLogger(auditStore)
+
+	// Files and users under legal hold are neither deleted nor purged,
+	// and held files keep their content.
+	holds := legalhold.NewService(legalhold.NewMemoryStore(), auditLog)
+	holds.Handle(legalhold.KindUser, func(ctx context.Context, id string) (bool, error) {
+		_, err := users.Get(ctx, id)
+		if errors.Is(err, user.ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	holds.Handle(legalhold.KindFile, func(ctx context.Context, id string) (bool, error) {
+		_, err := fileService.Repository.Get(ctx, id)
+		if errors.Is(err, files.ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	fileService.BeforeSave(holds.FileGuard)
+	trash.BeforeRemove(holds.FileGuard)
+	go trash.Run(ctx, time.Hour)
+
+	termsService := terms.NewService(terms.NewMemoryStore())
+
+	// Tenants serve the app under their own domains once these point at
+	// CUSTOM_DOMAIN_TARGET; certificates for them are issued on demand.
+	var customDomains *domains.Service
+	var hostPolicy autocert.HostPolicy
+	if cfg.CustomDomainTarget != "" {
+		customDomains = domains.NewService(domains.NewMemoryStore(), cfg.CustomDomainTarget)
+		hostPolicy = customDomains.HostPolicy(cfg.TLS.Domains...)
+	}
+
+	// The consumer group EVENTS_CONSUMER_GROUP records the events on the
+	// broker, this app's own included, in the audit log while the app
+	// serves.
+	consumers := &events.Workers{}
+	if subscriber, ok := broker.(events.Subscriber); ok && cfg.Events.ConsumerGroup != "" {
+		consumer := events.NewConsumer(cfg.Events.ConsumerGroup, subscriber)
+		consumer.DeadLetters = broker
+		record := func(ctx context.Context, message events.Message) error {
+			auditLog.Append(ctx, &audit.Event{
+				Action:  "event." + strings.TrimPrefix(message.Subject, cfg.Events.Prefix),
+				Outcome: audit.OutcomeSuccess,
+				Actor:   "consumer:" + cfg.Events.ConsumerGroup,
+				Target:  events.IdempotencyKey(message),
+			})
+			return nil
+		}
+		consumer.Handle(cfg.Events.Prefix+events.TypeUserRegistered, record)
+		consumer.Handle(cfg.Events.Prefix+events.TypeFileUploaded, record)
+		consumers.Add(consumer)
+	}
+	app.Hooks().OnListen(func(fiber.ListenData) error {
+		consumers.Start()
+		return nil
+	})
+	app.Hooks().OnShutdown(func() error {
+		return consumers.Stop(cfg.ShutdownTimeout)
+	})
+
+	// Canonical paths first, so no path-based rule below can be bypassed
+	// by encoding the same path differently.
+	app.Use(normalize.New())
+	// Probes are answered before logging and host or HTTPS redirects.
+	readiness := map[string]health.Check{}
+	if repos.Check != nil {
+		readiness["database"] = repos.Check
+	}
+	app.Get("/readyz", health.New(health.Config{Checks: readiness}))
+	app.Use(realip.New(realip.Config{TrustedProxies: cfg.TrustedProxies}))
+	app.Use(requestid.New(), logger.Middleware(log))
+	app.Use(clientguard.New(clientguard.Config{
+		MaxHeaders:     cfg.Limits.MaxHeaders,
+		MaxHeaderBytes: cfg.Limits.MaxHeaderBytes,
+		MinBodyRate:    cfg.Limits.MinBodyRate,
+		BodyGrace:      cfg.Limits.BodyGrace,
+	}))
+	app.Use(bodylimit.New(bodylimit.Config{
+		JSON: cfg.BodyLimit.JSON,
+		XML:  cfg.BodyLimit.XML,
+		Form: cfg.BodyLimit.Form,
+	}))
+	// Cookies are protected for everything below, which reads and sets
+	// them in plaintext.
+	if keys := cfg.Cookies.Keys; len(keys) > 0 {
+		if _, err := securecookie.NewCodec(keys...); err != nil {
+			return nil, fmt.Errorf("COOKIE_KEYS: %w", err)
+		}
+		app.Use(securecookie.New(securecookie.Config{
+			Keys:   keys,
+			Signed: cfg.Cookies.Signed,
+			Except: cfg.Cookies.Plaintext,
+		}))
+	}
+	if customDomains != nil {
+		app.Use(customDomains.Middleware())
+	}
+	if tenants != nil {
+		app.Use(tenant.New(tenant.Config{
+			Next: func(c *fiber.Ctx) bool {
+				return strings.HasPrefix(c.Path(), "/admin") || strings.HasPrefix(c.Path(), "/debug")
+			},
+			Store:      tenants,
+			BaseDomain: cfg.Tenancy.BaseDomain,
+			Header:     cfg.Tenancy.Header,
+			Required:   cfg.Tenancy.Required,
+		}))
+		// Every request of a tenant counts against its request quota.
+		app.Use(tenantRequests.Handler())
+	}
+	// API hosts answer only the API, web hosts everything but it.
+	apiPrefixes := []string{"/api", "/graphql"}
+	apiHost := vhost.Match(cfg.Hosts.API...)
+	if len(cfg.Hosts.API) > 0 || len(cfg.Hosts.Web) > 0 {
+		hosts := map[string]fiber.Handler{}
+		for _, host := range cfg.Hosts.API {
+			hosts[host] = vhost.Only(apiPrefixes...)
+		}
+		for _, host := range cfg.Hosts.Web {
+			hosts[host] = vhost.Except(apiPrefixes...)
+		}
+		app.Use(vhost.New(vhost.Config{Hosts: hosts}))
+	}
+
+	if cfg.TLS.Mode != "off" {
+		app.Use(https.New(https.Config{
+			Redirect:   true,
+			HSTSMaxAge: cfg.TLS.HSTSMaxAge,
+		}))
+	}
+
+	// The monitor page and the GraphQL playground load their scripts from
+	// jsDelivr and use inline scripts and styles.
+	monitorPolicy := secure.PolicyDefault
+	monitorPolicy.ContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'"
+	playgroundPolicy := secure.PolicyDefault
+	playgroundPolicy.ContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net; style-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net; img-src 'self' data:; font-src https://cdn.jsdelivr.net; frame-ancestors 'none'"
+	app.Use(secure.New(secure.Config{
+		Overrides: map[string]secure.Policy{
+			"/admin/monitor":      monitorPolicy,
+			"/graphql/playground": playgroundPolicy,
+		},
+	}))
+
+	if cfg.Tracing.Enabled {
+		app.Use(tracing.New(tracing.Config{
+			Sampler: &tracing.Sampler{
+				Rate:   cfg.Tracing.Rate,
+				Errors: true,
+				Slow:   cfg.Tracing.Slow,
+				Routes: cfg.Tracing.Routes,
+			},
+		}))
+	}
+
+	if cfg.Profiling.ServerAddress != "" {
+		profiler, err := profiling.Start(profiling.Config{
+			ServerAddress:     cfg.Profiling.ServerAddress,
+			ApplicationName:   cfg.Profiling.ApplicationName,
+			BasicAuthUser:     cfg.Profiling.User,
+			BasicAuthPassword: cfg.Profiling.Password,
+			Tags:              map[string]string{"env": cfg.Env},
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.closers = append(s.closers, profiler.Stop)
+	}
+	if cfg.Profiling.ServerAddress != "" || cfg.Profiling.Labels {
+		app.Use(profiling.Labels())
+	}
+
+	debugStore := debugstore.NewMemoryStore()
+	if cfg.DebugStore.Enabled {
+		app.Use(debugstore.New(debugstore.Config{
+			Store:       debugStore,
+			TTL:         cfg.DebugStore.TTL,
+			MaxBodySize: cfg.DebugStore.MaxBodySize,
+		}))
+	}
+
+	// The admin area and debug routes answer trusted networks only.
+	adminNetworks := ipfilter.New(ipfilter.Config{
+		Allow: cfg.Admin.AllowNetworks,
+		Deny:  cfg.Admin.DenyNetworks,
+		Store: repos.IPRules,
+	})
+	go adminNetworks.Run(ctx)
+	app.Use("/admin", adminNetworks.Handler())
+	app.Use("/debug", adminNetworks.Handler())
+
+	if cfg.Pprof || cfg.DebugStore.Enabled {
+		app.Use("/debug", adminauth.New(adminauth.Config{
+			Token: cfg.Admin.Token,
+			Users: cfg.AdminUsers(),
+		}))
+	}
+	if cfg.Pprof {
+		app.Use(pprof.New())
+		app.Use(expvar.New())
+	}
+	if cfg.DebugStore.Enabled {
+		app.Get("/debug/requests", debugstore.ListHandler(debugStore))
+		app.Get("/debug/requests/:id", debugstore.GetHandler(debugStore))
+	}
+
+	sessions := session.NewManager(session.Config{
+		Store:        session.NewMemoryStore(),
+		Refresh:      session.NewMemoryRefreshStore(),
+		CookieName:   cfg.Session.CookieName,
+		CookieSecure: cfg.TLS.Mode != "off",
+		TTL:          cfg.Session.TTL,
+		RefreshTTL:   cfg.Session.RefreshTTL,
+		Remember:     session.NewMemoryRefreshStore(),
+		RememberTTL:  cfg.Session.RememberTTL,
+
+		RememberGrace: cfg.Session.RememberGrace,
+		OnReuse: func(c *fiber.Ctx, err *session.ReuseError) {
+			auditLog.Log(c, audit.ActionRememberReused, audit.OutcomeFailure, "user:"+err.UserID, map[string]string{"family": err.Family})
+		},
+	})
+	// Browsers add cookies, and Basic credentials once entered, to
+	// requests other sites make them send, so unsafe ones must carry the
+	// CSRF token. Calls authenticated by a bearer token or API key, or
+	// carrying no such credentials at all, are exempt. Mounted apps run
+	// where they are mounted, so the admin guard goes first.
+	csrfGuard := func(mode string) fiber.Handler {
+		return csrf.New(csrf.Config{
+			Mode: mode,
+			Exempt: func(c *fiber.Ctx) bool {
+				return csrf.TokenAuthenticated(c) || csrf.Cookieless(c)
+			},
+			CookieSecure: cfg.TLS.Mode != "off",
+		})
+	}
+	csrfPrefixes := slices.Sorted(maps.Keys(cfg.CSRF))
+	for _, prefix := range csrfPrefixes {
+		if underAdmin(prefix) {
+			app.Use(prefix, csrfGuard(cfg.CSRF[prefix]))
+		}
+	}
+	app.Mount("/admin", admin.New(admin.Config{
+		Auth: adminauth.Config{
+			Token: cfg.Admin.Token,
+			Users: cfg.AdminUsers(),
+		},
+		Users:      users,
+		Sessions:   sessions,
+		Sequences:  sequences,
+		Audit:      auditLog,
+		Webhooks:   dispatcher,
+		Moderation: moderator,
+		Reports:    reportService,
+		LegalHolds: holds,
+		Terms:      termsService,
+		Domains:    customDomains,
+		IPFilter:   adminNetworks,
+		Tenants:    tenants,
+		TenantUsage: func(ctx context.Context, t *tenant.Tenant) (tenant.Usage, error) {
+			return tenantUsage(ctx, t, tenantRequests, cfg.Tenancy.Requests, fileService)
+		},
+		Reload: func(ctx context.Context) error {
+			return s.Reload()
+		},
+
+		RequestMethods: app.Config().RequestMethods,
+	}))
+
+	app.Use(i18n.New(i18n.Config{Bundle: bundle}))
+
+	app.Use(sessions.Middleware())
+	// Signed in users accept the latest terms of service before anything
+	// but reading them, signing out, cookie consent and static assets.
+	app.Use(termsService.Require(cfg.TermsReviewURL, func(c *fiber.Ctx) bool {
+		path := c.Path()
+		return strings.HasPrefix(path, "/api/v1/terms") || path == "/api/v1/logout" ||
+			strings.HasPrefix(path, "/api/v1/consent") || strings.HasPrefix(path, "/public/")
+	}))
+	// Users an admin required to reset their password change it, at
+	// /api/v1/me/password, before anything else.
+	app.Use(account.RequirePasswordChange(users, func(c *fiber.Ctx) bool {
+		path := c.Path()
+		return path == "/api/v1/me/password" || path == "/api/v1/users/"+session.UserID(c) ||
+			path == "/api/v1/logout" || strings.HasPrefix(path, "/public/")
+	}))
+
+	// Page views are only recorded for visitors who consented to
+	// analytics.
+	// API hosts serve no pages, so they neither ask for nor read cookie
+	// consent.
+	consentManager := consent.NewManager(consent.Config{
+		Next:         apiHost,
+		Categories:   cfg.ConsentCategories,
+		CookieSecure: cfg.TLS.Mode != "off",
+	})
+	app.Use(consentManager.Middleware())
+	app.Use(analytics.New(analytics.Config{
+		Next: func(c *fiber.Ctx) bool { return !consent.Granted(c, consent.CategoryAnalytics) },
+	}))
+
+	for _, prefix := range csrfPrefixes {
+		if !underAdmin(prefix) {
+			app.Use(prefix, csrfGuard(cfg.CSRF[prefix]))
+		}
+	}
+
+	app.Use("/api", cachecontrol.New(cachecontrol.Config{
+		Policy: cachecontrol.NoStore,
+	}))
+	app.Use("/api", negotiate.New())
+	app.Use("/api", deadline.New(deadline.Config{
+		Timeout: cfg.RequestTimeout,
+	}))
+	// Route group timeouts nest inside the /api one; the shortest wins.
+	for _, prefix := range slices.Sorted(maps.Keys(cfg.RouteTimeouts)) {
+		app.Use(prefix, deadline.New(deadline.Config{
+			Timeout: cfg.RouteTimeouts[prefix],
+		}))
+	}
+
+	s.apiLimit = ratelimit.NewPolicy(ratelimit.Config{
+		Name:        "api",
+		Max:         cfg.RateLimit.Max,
+		Window:      cfg.RateLimit.Window,
+		Mode:        cfg.RateLimit.Mode,
+		EnforceFrom: cfg.RateLimit.EnforceFrom,
+		KeyGenerator: func(ctx *fiber.Ctx) string {
+			if id := session.UserID(ctx); id != "" {
+				return "user:" + id
+			}
+			return "ip:" + realip.IP(ctx)
+		},
+	})
+	app.Use("/api", s.apiLimit.Handler())
+
+	app.Use("/api", func(ctx *fiber.Ctx) error {
+		fmt.Println("Middleware before processing request")
+		err := ctx.Next()
+		fmt.Println("Middleware after processing request")
+		return err
+	})
+
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(i18n.T(ctx, "hello_world"))
+	})
+
+	app.Use("/public", cachecontrol.New(cachecontrol.Config{
+		Policy: cachecontrol.Public(cfg.Static.MaxAge),
+	}))
+	app.Use("/public", static.New(static.Config{
+		Theme:     themeFS(cfg.Static.ThemeDir),
+		TenantDir: cfg.Static.TenantDir,
+		Default:   staticFS(cfg.Static.FromDisk),
+	}))
+
+	if len(cfg.Proxy.Upstreams) > 0 {
+		external := proxy.New(proxy.Config{
+			Upstreams:      cfg.Proxy.Upstreams,
+			StripPrefix:    cfg.Proxy.Prefix,
+			PreserveHost:   cfg.Proxy.PreserveHost,
+			Timeout:        cfg.Proxy.Timeout,
+			HealthPath:     cfg.Proxy.HealthPath,
+			HealthInterval: cfg.Proxy.HealthInterval,
+		})
+		go external.Run(ctx)
+		app.Use(cfg.Proxy.Prefix, external.Handler())
+	}
+
+	if cfg.Storage.WebDAV {
+		app.Use("/dav", adminauth.New(adminauth.Config{
+			Token: cfg.Admin.Token,
+			Users: cfg.AdminUsers(),
+		}), adaptor.HTTPHandler(&webdav.Handler{
+			Prefix:     "/dav",
+			FileSystem: storage.WebDAV(store),
+			LockSystem: webdav.NewMemLS(),
+		}))
+	}
+
+	referenceHandler, err := refdata.NewHandler()
+	if err != nil {
+		return nil, err
+	}
+	referenceHandler.Register(app.Group("/api/v1/reference"))
+
+	if cfg.Address.PlacesKey != "" {
+		addressHandler := address.NewHandler(address.Config{
+			Provider:  address.NewGooglePlaces(cfg.Address.PlacesKey),
+			CacheTTL:  cfg.Address.CacheTTL,
+			RateLimit: cfg.Address.RateLimit,
+		})
+		addressHandler.Register(app.Group("/api/address"))
+	}
+
+	if cfg.FX.Enabled {
+		currencies, err := refdata.Currencies()
+		if err != nil {
+			return nil, err
+		}
+		rates := fx.NewService(fx.NewFrankfurter(), cfg.FX.Base)
+		rates.StaleAfter = cfg.FX.StaleAfter
+		for code, currency := range currencies {
+			rates.MinorUnits[code] = currency.MinorUnits
+		}
+		// Rates live in process memory, so every prefork child keeps its
+		// own copy fresh.
+		go rates.Run(ctx, cfg.FX.Interval)
+		app.Get("/api/fx/convert", fx.ConvertHandler(rates))
+	}
+
+	businessDays, err := businessday.Load(cfg.HolidaysDir)
+	if err != nil {
+		return nil, err
+	}
+	businessDayHandler := &businessday.Handler{Registry: businessDays}
+	businessDayHandler.Register(app.Group("/api/v1/business-days"))
+
+	// Retries carrying an Idempotency-Key get the response to the first
+	// attempt. Upload bodies are streamed, so their route and size stand
+	// for their content.
+	idempotencyStore, err := newIdempotencyStore(cfg.RedisURL)
+	if err != nil {
+		return nil, err
+	}
+	idempotent := idempotency.New(idempotency.Config{Store: idempotencyStore, TTL: cfg.IdempotencyTTL})
+	app.Use("/upload", idempotency.New(idempotency.Config{
+		Store:       idempotencyStore,
+		TTL:         cfg.IdempotencyTTL,
+		Fingerprint: idempotency.RouteFingerprint,
+	}))
+	app.Use("/api/v1/register", idempotent)
+	app.Use("/api/v1/users/:userId/orders", idempotent)
+
+	app.Use("/upload", files.QuotaGuard(fileService))
+	uploadHandler := &files.Handler{Service: fileService, Audit: auditLog}
+	uploadHandler.Register(app.Group("/upload"))
+
+	// Upload state is kept in storage, so any prefork child can resume.
+	uploads := files.NewUploads(fileService, files.NewStorageUploadRepository(store))
+	uploads.TTL = cfg.Storage.UploadTTL
+	go uploads.Run(ctx, time.Hour)
+	uploadsHandler := &files.UploadsHandler{Uploads: uploads, Audit: auditLog}
+	uploadsHandler.Register(app.Group("/uploads"))
+
+	fileRoutes := app.Group("/files")
+	ocrHandler := &ocr.Handler{Processor: ocrProcessor}
+	ocrHandler.Register(fileRoutes)
+	downloadHandler := &files.DownloadHandler{Service: fileService, Audit: auditLog}
+	downloadHandler.Register(fileRoutes)
+	versionsHandler := &files.VersionsHandler{Service: fileService, Audit: auditLog}
+	versionsHandler.Register(fileRoutes)
+	previewHandler := &previews.Handler{Generator: previewGenerator}
+	previewHandler.Register(fileRoutes)
+	imageHandler := &images.Handler{Processor: imageProcessor}
+	imageHandler.Register(fileRoutes)
+
+	trashHandler := &files.TrashHandler{Trash: trash, Audit: auditLog}
+	trashHandler.Register(fileRoutes, app.Group("/trash"))
+
+	calendarHandler := &calendar.Handler{Service: calendarService, Users: users}
+	calendarHandler.Register(app.Group("/api/v1/events"))
+
+	// Double submitted sign-ups are rejected; repeated orders get the
+	// order created by the first submission. Requests with an
+	// Idempotency-Key are left to the idempotency middleware.
+	hasIdempotencyKey := func(c *fiber.Ctx) bool { return c.Get("Idempotency-Key") != "" }
+	app.Use("/api/v1/register", dedupe.New(dedupe.Config{
+		Next:   hasIdempotencyKey,
+		Window: cfg.DedupeWindow,
+		Policy: dedupe.PolicyConflict,
+	}))
+	app.Use("/api/v1/users/:userId/orders", dedupe.New(dedupe.Config{
+		Next:   hasIdempotencyKey,
+		Window: cfg.DedupeWindow,
+		Policy: dedupe.PolicyReplay,
+	}))
+
+	userService := user.NewService(users, cfg.PhoneRegion)
+	userService.BeforeDelete(holds.UserGuard)
+	userService.AfterRegister(func(ctx context.Context, created *user.User) {
+		dispatcher.PublishLogged(ctx, webhooks.EventUserRegistered, mapping.UserResponse(created))
+	})
+	if publisher != nil {
+		userService.AfterRegister(func(ctx context.Context, created *user.User) {
+			publisher.PublishLogged(ctx, events.UserRegistered{
+				UserID:   created.ID,
+				Username: created.Username,
+				Name:     created.Name,
+				Email:    created.Email,
+			})
+		})
+	}
+	accountHandler := &account.Handler{Users: userService, Sessions: sessions, Audit: auditLog}
+	if cfg.Captcha.After > 0 {
+		accountHandler.Throttle = captcha.NewThrottle(cfg.Captcha.After, cfg.Captcha.Window)
+		accountHandler.Captcha = captcha.NewSiteverify(cfg.Captcha.VerifyURL, cfg.Captcha.Secret)
+	}
+	accountHandler.Register(app.Group("/api/v1"))
+	meHandler := &account.MeHandler{Users: userService, Sessions: sessions, Audit: auditLog}
+	meHandler.Register(app.Group("/api/v1/me"))
+	userResource := &account.UserResource{Service: userService}
+	userResource.Register(app.Group("/api/v1/users"))
+	orderService := order.NewService(orders, sequences)
+	orderResource := &account.OrderResource{Service: orderService}
+	orderResource.Register(app.Group("/api/v1/users/:userId/orders"))
+	graphqlHandler := &graphql.Handler{
+		Schema:     account.GraphQLSchema(userService, orderService),
+		Playground: true,
+	}
+	app.Use("/graphql/playground", s.Features.Require("graphql_playground"))
+	graphqlHandler.Register(app.Group("/graphql"))
+	app.Get("/api/v1/phone/validate", phone.ValidateHandler(cfg.PhoneRegion))
+
+	contactsHandler := &contacts.Handler{Books: contacts.NewBooks()}
+	contactsHandler.Register(app.Group("/api/v1/contacts"))
+	reportHandler := &reports.Handler{Service: reportService}
+	reportHandler.Register(app.Group("/api/v1/reports"))
+	termsHandler := &terms.Handler{Service: termsService}
+	termsHandler.Register(app.Group("/api/v1/terms"))
+	consentHandler := &consent.Handler{Manager: consentManager}
+	consentHandler.Register(app.Group("/api/v1/consent"))
+	app.Get("/api/v1/users/:id/vcard", account.RequireSelfOrAdmin(userService), contacts.UserVCard(users))
+
+	app.Post("/inbound/email/:provider", inbound.Handler(inbound.Config{
+		Files:         fileService,
+		MailgunKey:    cfg.Inbound.MailgunKey,
+		SendGridToken: cfg.Inbound.SendGridToken,
+	}))
+
+	// Received webhooks are handled as jobs; handlers subscribe with
+	// receiver.On.
+	receiver := webhooks.NewReceiver()
+	if cfg.Webhooks.GitHubSecret != "" {
+		receiver.Provider("github", webhooks.GitHub{Secret: cfg.Webhooks.GitHubSecret})
+	}
+	if cfg.Webhooks.StripeSecret != "" {
+		receiver.Provider("stripe", webhooks.Stripe{Secret: cfg.Webhooks.StripeSecret})
+	}
+	receiver.Attach(queue)
+	receiver.Register(app.Group("/webhooks"))
+
+	if cfg.Static.SPADir != "" {
+		app.Use("/app", static.SPA(static.SPAConfig{
+			Root: http.Dir(cfg.Static.SPADir),
+		}))
+	}
+
+	// REST routes for callers that cannot speak gRPC. The gRPC server
+	// checks the bearer token they forward.
+	if target := cfg.GRPC.GatewayTarget; target != "" {
+		conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		rpcGateway := gateway.New(conn)
+		internal := app.Group("/api/internal")
+		internal.Get("/users", rpcGateway.Unary("app.v1.Users.ListUsers"))
+		internal.Post("/users", rpcGateway.Unary("app.v1.Users.RegisterUser"))
+		internal.Get("/users/:id", rpcGateway.Unary("app.v1.Users.GetUser"))
+		internal.Get("/users/:user_id/orders", rpcGateway.Unary("app.v1.Orders.ListOrders"))
+		internal.Post("/users/:user_id/orders", rpcGateway.Unary("app.v1.Orders.PlaceOrder"))
+		internal.Get("/orders/:id", rpcGateway.Unary("app.v1.Orders.GetOrder"))
+	}
+
+	s.Users = userService
+	s.Orders = orderService
+	s.Files = fileService
+	s.Audit = auditLog
+	s.Sessions = sessions
+	s.Jobs = queue
+	s.App = app
+	for _, module := range modules {
+		if err := module.Register(s); err != nil {
+			return nil, err
+		}
+	}
+	app.Use("/api", APINotFound)
+
+	// Internal services reach the same users and orders over gRPC.
+	if cfg.GRPC.Addr != "" && !fiber.IsChild() {
+		s.grpc = rpc.NewServer(cfg.GRPC.Token)
+		(&rpc.Users{Service: userService}).Register(s.grpc)
+		(&rpc.Orders{Service: orderService}).Register(s.grpc)
+	}
+
+	s.hostPolicy = hostPolicy
+	built = true
+	return s, nil
+}
+
+// APINotFound answers requests to /api no route matched.
+func APINotFound(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"error": "Not Found",
+	})
+}
+
+// underAdmin reports whether prefix is routed to the mounted admin app.
+func underAdmin(prefix string) bool {
+	return prefix == "/admin" || strings.HasPrefix(prefix, "/admin/")
+}
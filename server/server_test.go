@@ -0,0 +1,316 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/config"
+	"belajar-golang-fiber/user"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig(t *testing.T) *config.Config {
+	cfg := config.Load()
+	cfg.View.Directory = "../template"
+	cfg.Storage.Dir = t.TempDir()
+	cfg.Prefork = false
+	cfg.ShutdownTimeout = time.Second
+	return cfg
+}
+
+func TestModules(t *testing.T) {
+	hello := ModuleFunc(func(s *Server) error {
+		assert.NotNil(t, s.Users)
+		s.App.Get("/api/v1/hello", func(c *fiber.Ctx) error {
+			return c.SendString("hello")
+		})
+		return nil
+	})
+	srv, err := NewServer(testConfig(t), hello)
+	assert.Nil(t, err)
+	defer srv.Stop()
+
+	response, err := srv.App.Test(httptest.NewRequest("GET", "/api/v1/hello", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "hello", string(body))
+
+	response, _ = srv.App.Test(httptest.NewRequest("GET", "/api/v1/missing", nil))
+	assert.Equal(t, fiber.StatusNotFound, response.StatusCode)
+	body, _ = io.ReadAll(response.Body)
+	assert.Equal(t, `{"error":"Not Found"}`, string(body))
+
+	failed := errors.New("failed")
+	_, err = NewServer(testConfig(t), ModuleFunc(func(s *Server) error { return failed }))
+	assert.ErrorIs(t, err, failed)
+}
+
+func TestStartStop(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	cfg := testConfig(t)
+	cfg.Addr = addr
+	srv, err := NewServer(cfg)
+	assert.Nil(t, err)
+
+	started := make(chan error, 1)
+	go func() { started <- srv.Start() }()
+	assert.Eventually(t, func() bool {
+		response, err := http.Get("http://" + addr + "/readyz")
+		if err != nil {
+			return false
+		}
+		response.Body.Close()
+		return response.StatusCode == http.StatusOK
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, srv.Stop())
+	assert.Nil(t, <-started)
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.env")
+	t.Setenv("CONFIG_FILE", path)
+	assert.Nil(t, os.WriteFile(path, []byte("RATE_LIMIT_MAX=10\nFEATURES=beta\n"), 0o600))
+	srv, err := NewServer(testConfig(t))
+	assert.Nil(t, err)
+	defer srv.Stop()
+
+	limit := func() string {
+		response, err := srv.App.Test(httptest.NewRequest("GET", "/api/v1/missing", nil))
+		assert.Nil(t, err)
+		return response.Header.Get("X-RateLimit-Limit")
+	}
+	assert.Equal(t, "10", limit())
+	assert.True(t, srv.Features.Enabled("beta"))
+
+	// An invalid configuration changes nothing.
+	assert.Nil(t, os.WriteFile(path, []byte("RATE_LIMIT_MAX=20\nLOG_LEVEL=loud\nFEATURES=\n"), 0o600))
+	err = srv.Reload()
+	assert.ErrorContains(t, err, "LOG_LEVEL")
+	assert.Equal(t, "10", limit())
+	assert.True(t, srv.Features.Enabled("beta"))
+
+	assert.Nil(t, os.WriteFile(path, []byte("RATE_LIMIT_MAX=20\nLOG_LEVEL=debug\nFEATURES=gamma\n"), 0o600))
+	assert.Nil(t, srv.Reload())
+	assert.Equal(t, "20", limit())
+	assert.False(t, srv.Features.Enabled("beta"))
+	assert.True(t, srv.Features.Enabled("gamma"))
+	assert.True(t, slog.Default().Enabled(context.Background(), slog.LevelDebug))
+
+	srv.Config.Prefork = true
+	assert.ErrorContains(t, srv.Reload(), "single process")
+}
+
+func TestTenancy(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Database.Driver = "sqlite"
+	cfg.Tenancy = config.TenancyConfig{Enabled: true, Header: "X-Tenant-ID", Tenants: []string{"acme", "globex"}}
+	srv, err := NewServer(cfg)
+	assert.Nil(t, err)
+	defer srv.Stop()
+
+	// The names differ so sign-ups are not taken for double submissions.
+	register := func(tenant string) int {
+		request := httptest.NewRequest("POST", "/api/v1/register", strings.NewReader(`{"username":"salman","password":"rahasia123","name":"`+tenant+`"}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Tenant-ID", tenant)
+		response, err := srv.App.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+	assert.Equal(t, fiber.StatusCreated, register("acme"))
+	assert.Equal(t, fiber.StatusCreated, register("globex"), "usernames are per tenant")
+	assert.NotEqual(t, fiber.StatusCreated, register("acme"))
+	assert.Equal(t, fiber.StatusNotFound, register("initech"))
+
+	_, total, err := srv.Users.Users.List(context.Background(), user.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, total, "the shared schema has no users")
+}
+
+func TestTenantRequestQuota(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Tenancy = config.TenancyConfig{Enabled: true, Header: "X-Tenant-ID", Tenants: []string{"acme", "globex"}, Requests: 2, RequestWindow: time.Hour}
+	srv, err := NewServer(cfg)
+	assert.Nil(t, err)
+	defer srv.Stop()
+
+	get := func(tenant string) int {
+		request := httptest.NewRequest("GET", "/", nil)
+		if tenant != "" {
+			request.Header.Set("X-Tenant-ID", tenant)
+		}
+		response, err := srv.App.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+	assert.Equal(t, fiber.StatusOK, get("acme"))
+	assert.Equal(t, fiber.StatusOK, get("acme"))
+	assert.Equal(t, fiber.StatusTooManyRequests, get("acme"))
+	assert.Equal(t, fiber.StatusOK, get("globex"))
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, fiber.StatusOK, get(""), "requests of no tenant are not counted")
+	}
+}
+
+func TestCaptchaNeedsSecret(t *testing.T) {
+	cfg := testConfig(t)
+	assert.Equal(t, 0, cfg.Captcha.After, "logins are not throttled without CAPTCHA_SECRET")
+
+	cfg.Captcha.After = 5
+	_, err := NewServer(cfg)
+	assert.ErrorContains(t, err, "CAPTCHA_SECRET")
+}
+
+func TestInvalidNetworks(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admin.AllowNetworks = []string{"intranet"}
+	_, err := NewServer(cfg)
+	assert.ErrorContains(t, err, `ADMIN_ALLOW_NETWORKS: invalid network "intranet"`)
+
+	cfg.Admin.AllowNetworks = nil
+	cfg.TrustedProxies = []string{"10.0.0.1", "10.0.0.0/8", "10.0.0.0/"}
+	_, err = NewServer(cfg)
+	assert.ErrorContains(t, err, `TRUSTED_PROXIES: invalid network "10.0.0.0/"`)
+}
+
+func TestPreforkNeedsSharedState(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Prefork = true
+	cfg.Ingest.Dir = t.TempDir()
+	_, err := NewServer(cfg)
+	assert.ErrorContains(t, err, "ingest needs a single process")
+
+	cfg.Ingest.Dir = ""
+	cfg.GRPC.Addr = "localhost:0"
+	_, err = NewServer(cfg)
+	assert.ErrorContains(t, err, "gRPC needs a single process")
+
+	cfg.GRPC.Addr = ""
+	_, err = NewServer(cfg)
+	assert.ErrorContains(t, err, "sessions are kept in memory")
+	assert.False(t, config.Load().Prefork, "prefork is off unless asked for")
+}
+
+func TestUsersRequireSession(t *testing.T) {
+	srv, err := NewServer(testConfig(t))
+	assert.Nil(t, err)
+	defer srv.Stop()
+
+	created, err := srv.Users.Register(context.Background(), user.RegisterInput{Username: "salman", Password: "rahasia123"})
+	assert.Nil(t, err)
+	for _, route := range [][2]string{
+		{"GET", "/api/v1/users"},
+		{"GET", "/api/v1/users/export"},
+		{"PATCH", "/api/v1/users/" + created.ID},
+		{"DELETE", "/api/v1/users/" + created.ID},
+		{"GET", "/api/v1/users/" + created.ID + "/vcard"},
+	} {
+		request := httptest.NewRequest(route[0], route[1], strings.NewReader(`{"password":"diambil"}`))
+		request.Header.Set("Content-Type", "application/json")
+		response, err := srv.App.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, response.StatusCode, route)
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	srv, err := NewServer(testConfig(t))
+	assert.Nil(t, err)
+	defer srv.Stop()
+
+	response, err := srv.App.Test(httptest.NewRequest("GET", "/api/v1/users", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "no-store", response.Header.Get(fiber.HeaderCacheControl))
+
+	response, err = srv.App.Test(httptest.NewRequest("GET", "/api/v1/reference/currencies", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "public, max-age=86400", response.Header.Get(fiber.HeaderCacheControl), "reference data sets its own")
+}
+
+func TestCSRF(t *testing.T) {
+	srv, err := NewServer(testConfig(t))
+	assert.Nil(t, err)
+	defer srv.Stop()
+
+	response, err := srv.App.Test(httptest.NewRequest("GET", "/api/v1/users", nil))
+	assert.Nil(t, err)
+	var token *http.Cookie
+	for _, cookie := range response.Cookies() {
+		if cookie.Name == "csrf_token" {
+			token = cookie
+		}
+	}
+	assert.NotNil(t, token)
+
+	register := func(header string, cookies ...*http.Cookie) int {
+		request := httptest.NewRequest("POST", "/api/v1/register", strings.NewReader(`{"username":"salman","password":"rahasia123"}`))
+		request.Header.Set("Content-Type", "application/json")
+		if header != "" {
+			request.Header.Set("X-CSRF-Token", header)
+		}
+		for _, cookie := range cookies {
+			request.AddCookie(cookie)
+		}
+		response, err := srv.App.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+	assert.Equal(t, fiber.StatusForbidden, register("", token))
+	assert.Equal(t, fiber.StatusCreated, register(token.Value, token))
+}
+
+func TestCSRFBasicAuth(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Admin.Token = "rahasia"
+	cfg.Admin.User, cfg.Admin.Password = "admin", "rahasia"
+	cfg.Admin.AllowNetworks = []string{"0.0.0.0/32"}
+	cfg.Storage.WebDAV = true
+	srv, err := NewServer(cfg)
+	assert.Nil(t, err)
+	defer srv.Stop()
+
+	send := func(method, target string, header ...string) (int, string) {
+		request := httptest.NewRequest(method, target, strings.NewReader("hello"))
+		request.SetBasicAuth("admin", "rahasia")
+		for i := 0; i < len(header); i += 2 {
+			request.Header.Set(header[i], header[i+1])
+		}
+		response, err := srv.App.Test(request)
+		assert.Nil(t, err)
+		body, _ := io.ReadAll(response.Body)
+		return response.StatusCode, string(body)
+	}
+	// A page elsewhere makes the browser, which still has the admin's
+	// Basic credentials, submit a form.
+	for _, target := range []string{"PUT /dav/note.txt", "POST /admin/config/reload"} {
+		method, path, _ := strings.Cut(target, " ")
+		status, body := send(method, path, "Sec-Fetch-Site", "cross-site")
+		assert.Equal(t, fiber.StatusForbidden, status)
+		assert.Contains(t, body, "invalid CSRF token", target)
+	}
+	status, _ := send("POST", "/admin/config/reload", "Authorization", "Bearer rahasia", "Sec-Fetch-Site", "cross-site")
+	assert.Equal(t, fiber.StatusNoContent, status, "the admin token is not ambient")
+	// WebDAV clients are not browsers.
+	status, _ = send("PUT", "/dav/note.txt")
+	assert.Equal(t, fiber.StatusCreated, status)
+	status, _ = send("PUT", "/dav/note.txt", "Authorization", "Bearer rahasia", "Origin", "https://evil.example")
+	assert.Equal(t, fiber.StatusCreated, status)
+}
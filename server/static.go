@@ -0,0 +1,21 @@
+package server
+
+import (
+	"net/http"
+
+	"belajar-golang-fiber/sandbox"
+)
+
+func staticFS(fromDisk bool) http.FileSystem {
+	if fromDisk || Assets == nil {
+		return sandbox.Dir{Root: "./source"}
+	}
+	return Assets
+}
+
+func themeFS(dir string) http.FileSystem {
+	if dir == "" {
+		return nil
+	}
+	return sandbox.Dir{Root: dir}
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"belajar-golang-fiber/config"
+	"belajar-golang-fiber/database"
+	"belajar-golang-fiber/events"
+	"belajar-golang-fiber/health"
+	"belajar-golang-fiber/middleware/idempotency"
+	"belajar-golang-fiber/middleware/ipfilter"
+	"belajar-golang-fiber/order"
+	"belajar-golang-fiber/redis"
+	"belajar-golang-fiber/storage"
+	"belajar-golang-fiber/user"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// newStorage opens the backend selected by STORAGE_BACKEND.
+func newStorage(cfg config.StorageConfig) (storage.Storage, error) {
+	switch cfg.Backend {
+	case "s3":
+		if cfg.S3.Bucket == "" {
+			return nil, errors.New("STORAGE_S3_BUCKET is required with STORAGE_BACKEND=s3")
+		}
+		return storage.NewS3(storage.S3Config(cfg.S3)), nil
+	default:
+		return storage.NewLocal(cfg.Dir), nil
+	}
+}
+
+// repositories are where the server keeps its data.
+type repositories struct {
+	Users   user.Repository
+	Orders  order.Repository
+	IPRules ipfilter.Store
+	// Check tells whether the database answers; it is nil in memory.
+	Check health.Check
+	// Schema opens the users and orders of a tenant schema.
+	Schema func(ctx context.Context, name string) (*repositories, error)
+}
+
+// newRepositories keeps data in the database selected by DATABASE_DRIVER,
+// migrated to the latest schema, or in memory. What has no repository for
+// the database yet stays in memory: orders with SQL databases, IP rules
+// with MongoDB.
+func newRepositories(ctx context.Context, cfg config.DatabaseConfig) (*repositories, error) {
+	switch cfg.Driver {
+	case "memory":
+		return &repositories{
+			Users:   user.NewMemoryRepository(),
+			Orders:  order.NewMemoryRepository(),
+			IPRules: ipfilter.NewMemoryStore(),
+			Schema: func(ctx context.Context, name string) (*repositories, error) {
+				return &repositories{Users: user.NewMemoryRepository(), Orders: order.NewMemoryRepository()}, nil
+			},
+		}, nil
+	case database.DriverMongo:
+		db, err := database.OpenMongo(ctx, cfg.URL)
+		if err != nil {
+			return nil, err
+		}
+		repos, err := newMongoRepositories(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		repos.IPRules = ipfilter.NewMemoryStore()
+		repos.Check = func(ctx context.Context) error {
+			return db.Client().Ping(ctx, nil)
+		}
+		// Tenant schemas are databases named after the main one.
+		repos.Schema = func(ctx context.Context, name string) (*repositories, error) {
+			return newMongoRepositories(ctx, db.Client().Database(db.Name()+"_"+name))
+		}
+		return repos, nil
+	}
+
+	dsn := cfg.URL
+	if dsn == "" && cfg.Driver == database.DriverSQLite {
+		dsn = "file::memory:"
+	}
+	db, err := database.Open(cfg.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetPool(database.PoolConfig(cfg.Pool))
+	db.Publish(cfg.Driver)
+	if err := db.Migrate(ctx); err != nil {
+		return nil, err
+	}
+	return &repositories{
+		Users:   user.NewSQLRepository(db),
+		Orders:  order.NewMemoryRepository(),
+		IPRules: ipfilter.NewSQLStore(db),
+		Check:   db.Check,
+		Schema: func(ctx context.Context, name string) (*repositories, error) {
+			schema, err := db.Schema(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			schema.SetPool(database.PoolConfig(cfg.Pool))
+			return &repositories{Users: user.NewSQLRepository(schema), Orders: order.NewMemoryRepository()}, nil
+		},
+	}, nil
+}
+
+func newMongoRepositories(ctx context.Context, db *mongo.Database) (*repositories, error) {
+	users, orders := user.NewMongoRepository(db), order.NewMongoRepository(db)
+	if err := users.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+	if err := orders.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+	return &repositories{Users: users, Orders: orders}, nil
+}
+
+// newBroker connects to the broker selected by EVENTS_BROKER, or returns
+// nil when there is none.
+func newBroker(cfg config.EventsConfig) (events.Broker, error) {
+	switch cfg.Broker {
+	case "":
+		return nil, nil
+	case "nats":
+		nats := events.NewNATS(cfg.URL)
+		nats.Stream = cfg.Stream
+		return nats, nil
+	case "kafka":
+		return events.NewKafkaREST(cfg.URL), nil
+	default:
+		return nil, errors.New("unknown EVENTS_BROKER " + cfg.Broker)
+	}
+}
+
+// newIdempotencyStore keeps idempotency keys in Redis when REDIS_URL is
+// set, else in memory, where each prefork child only knows its own.
+func newIdempotencyStore(redisURL string) (idempotency.Store, error) {
+	if redisURL == "" {
+		return idempotency.NewMemoryStore(), nil
+	}
+	client, err := redis.New(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	return idempotency.NewRedisStore(client), nil
+}
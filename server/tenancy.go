@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/middleware/ratelimit"
+	"belajar-golang-fiber/tenant"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// tenantRequestLimit is the request quota of the request's tenant: its
+// own setting, else fallback. Zero or less, or no tenant, is unlimited.
+func tenantRequestLimit(c *fiber.Ctx, fallback int) int {
+	t := tenant.From(c)
+	if t == nil {
+		return 0
+	}
+	return int(t.IntSetting(tenant.SettingRequests, int64(fallback)))
+}
+
+// tenantUsage reports what t used of its request and storage quotas,
+// counted by requests and fileService; maxRequests is the request quota
+// of tenants without their own.
+func tenantUsage(ctx context.Context, t *tenant.Tenant, requests *ratelimit.Policy, maxRequests int, fileService *files.Service) (tenant.Usage, error) {
+	var usage tenant.Usage
+	count, reset := requests.Usage(t.ID)
+	usage.Requests.Used = int64(count)
+	if !reset.IsZero() {
+		usage.Requests.Reset = &reset
+	}
+	usage.Requests.Limit = t.IntSetting(tenant.SettingRequests, int64(maxRequests))
+
+	limits, err := fileService.Quota.ForTenant(ctx, t.ID)
+	if err != nil {
+		return usage, err
+	}
+	usage.StorageBytes.Limit, usage.StorageFiles.Limit = limits.Bytes, int64(limits.Files)
+	stored, err := fileService.Repository.List(ctx)
+	if err != nil {
+		return usage, err
+	}
+	for _, file := range stored {
+		if file.Tenant == t.ID {
+			usage.StorageBytes.Used += file.StoredSize()
+			usage.StorageFiles.Used++
+		}
+	}
+	return usage, nil
+}
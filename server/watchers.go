@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"belajar-golang-fiber/config"
+	"belajar-golang-fiber/files"
+	"belajar-golang-fiber/ingest"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// startIngest launches the drop directory watchers. It is only called
+// from the parent process; checkPrefork refuses ingest under Prefork.
+func startIngest(ctx context.Context, cfg config.IngestConfig, service *files.Service) error {
+	if cfg.Dir != "" {
+		watcher := &ingest.Watcher{
+			Source:   &ingest.LocalSource{Dir: cfg.Dir, Settle: cfg.Settle},
+			Files:    service,
+			Label:    "local:" + cfg.Dir,
+			Interval: cfg.Interval,
+		}
+		go watcher.Run(ctx)
+	}
+
+	if cfg.SFTPAddr != "" {
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(cfg.SFTPHostKey))
+		if err != nil {
+			return fmt.Errorf("ingest: INGEST_SFTP_HOST_KEY: %w", err)
+		}
+		watcher := &ingest.Watcher{
+			Source: &ingest.SFTPSource{
+				Addr:     cfg.SFTPAddr,
+				User:     cfg.SFTPUser,
+				Password: cfg.SFTPPassword,
+				Dir:      cfg.SFTPDir,
+				HostKey:  hostKey,
+				Settle:   cfg.Settle,
+			},
+			Files:    service,
+			Label:    "sftp:" + cfg.SFTPAddr,
+			Interval: cfg.Interval,
+		}
+		go watcher.Run(ctx)
+	}
+
+	return nil
+}
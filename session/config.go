@@ -0,0 +1,116 @@
+package session
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for Manager.
+type Config struct {
+	// Next defines a function to skip the middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Store holds the sessions.
+	//
+	// Required.
+	Store Store
+
+	// CookieName is the cookie carrying the token for browsers. API
+	// clients send the same token as "Authorization: Bearer <token>".
+	//
+	// Optional. Default: "session_id"
+	CookieName string
+
+	// CookieSecure marks the cookie Secure; enable it behind HTTPS.
+	//
+	// Optional. Default: false
+	CookieSecure bool
+
+	// TTL is how long a session lives after sign-in.
+	//
+	// Optional. Default: 24 * time.Hour
+	TTL time.Duration
+
+	// Refresh, when set, holds the refresh tokens handed out by Grant and
+	// rotated by Refresh.
+	//
+	// Optional. Default: nil
+	Refresh RefreshStore
+
+	// RefreshTTL is how long a refresh token lives, each rotation
+	// starting anew.
+	//
+	// Optional. Default: 30 * 24 * time.Hour
+	RefreshTTL time.Duration
+
+	// Remember, when set, holds the remember-me tokens of Remember, from
+	// which Middleware signs browsers in again once their session is
+	// gone. It must not be the Refresh store.
+	//
+	// Optional. Default: nil
+	Remember RefreshStore
+
+	// RememberCookieName is the cookie carrying the remember-me token.
+	//
+	// Optional. Default: "remember_me"
+	RememberCookieName string
+
+	// RememberTTL is how long a remember-me token lives, each use
+	// rotating it.
+	//
+	// Optional. Default: 30 * 24 * time.Hour
+	RememberTTL time.Duration
+
+	// RememberGrace is how long a rotated remember-me token still starts
+	// sessions, without rotating it again, for the requests a browser
+	// sends in parallel with the one that rotated it. Reuse after that
+	// revokes the family.
+	//
+	// Optional. Default: 30 * time.Second
+	RememberGrace time.Duration
+
+	// OnReuse, when set, is told of remember-me tokens Middleware saw
+	// used twice, whose family it revoked.
+	//
+	// Optional. Default: nil
+	OnReuse func(c *fiber.Ctx, err *ReuseError)
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	CookieName: "session_id",
+	TTL:        24 * time.Hour,
+	RefreshTTL: 30 * 24 * time.Hour,
+
+	RememberCookieName: "remember_me",
+	RememberTTL:        30 * 24 * time.Hour,
+	RememberGrace:      30 * time.Second,
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.CookieName == "" {
+		cfg.CookieName = ConfigDefault.CookieName
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = ConfigDefault.TTL
+	}
+	if cfg.RefreshTTL <= 0 {
+		cfg.RefreshTTL = ConfigDefault.RefreshTTL
+	}
+	if cfg.RememberCookieName == "" {
+		cfg.RememberCookieName = ConfigDefault.RememberCookieName
+	}
+	if cfg.RememberTTL <= 0 {
+		cfg.RememberTTL = ConfigDefault.RememberTTL
+	}
+	if cfg.RememberGrace <= 0 {
+		cfg.RememberGrace = ConfigDefault.RememberGrace
+	}
+	return cfg
+}
@@ -0,0 +1,340 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// ErrRefreshReused is returned by Refresh for a refresh token that was
+// already rotated. Only a stolen copy gets sent twice, so every session
+// of its family is revoked.
+var ErrRefreshReused = errors.New("session: refresh token reused")
+
+// ReuseError is the ErrRefreshReused of Refresh, naming whose refresh
+// token family was revoked.
+type ReuseError struct {
+	UserID string
+	Family string
+}
+
+func (e *ReuseError) Error() string { return ErrRefreshReused.Error() }
+
+func (e *ReuseError) Unwrap() error { return ErrRefreshReused }
+
+// RefreshToken lets an API client replace its session before, or after,
+// it expires. Each one is good for a single use: Refresh swaps it for a
+// new one of the same Family, the chain started at sign-in. As with
+// sessions, only the SHA-256 of the token is stored.
+type RefreshToken struct {
+	ID        string
+	Family    string
+	UserID    string
+	Tenant    string
+	SessionID string
+	TokenHash string
+	UserAgent string
+	IP        string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// UsedAt is set once the token was rotated.
+	UsedAt *time.Time
+}
+
+// RefreshStore persists refresh tokens, or remember-me tokens, which
+// rotate the same way. Rotated ones are kept until they expire so their
+// reuse can be told apart from unknown tokens.
+type RefreshStore interface {
+	Create(ctx context.Context, token *RefreshToken) error
+	// FindByToken returns the unexpired token whose token hashes to hash,
+	// rotated or not.
+	FindByToken(ctx context.Context, hash string) (*RefreshToken, error)
+	// Use marks the token rotated, failing with ErrRefreshReused when it
+	// already was.
+	Use(ctx context.Context, id string, at time.Time) error
+	ListFamily(ctx context.Context, family string) ([]*RefreshToken, error)
+	DeleteFamily(ctx context.Context, family string) error
+	// DeleteByUser deletes the tokens of userID but those of family
+	// except, if any.
+	DeleteByUser(ctx context.Context, userID, except string) error
+}
+
+// MemoryRefreshStore keeps refresh tokens in process memory; expired ones
+// are dropped lazily.
+type MemoryRefreshStore struct {
+	mu     sync.Mutex
+	tokens map[string]*RefreshToken
+	now    func() time.Time
+}
+
+func NewMemoryRefreshStore() *MemoryRefreshStore {
+	return &MemoryRefreshStore{tokens: map[string]*RefreshToken{}, now: time.Now}
+}
+
+func (s *MemoryRefreshStore) Create(ctx context.Context, token *RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *token
+	s.tokens[token.ID] = &copied
+	return nil
+}
+
+func (s *MemoryRefreshStore) FindByToken(ctx context.Context, hash string) (*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	for _, token := range s.tokens {
+		if token.TokenHash == hash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryRefreshStore) Use(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if token.UsedAt != nil {
+		return ErrRefreshReused
+	}
+	token.UsedAt = &at
+	return nil
+}
+
+func (s *MemoryRefreshStore) ListFamily(ctx context.Context, family string) ([]*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := []*RefreshToken{}
+	for _, token := range s.tokens {
+		if token.Family == family {
+			copied := *token
+			list = append(list, &copied)
+		}
+	}
+	return list, nil
+}
+
+func (s *MemoryRefreshStore) DeleteFamily(ctx context.Context, family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, token := range s.tokens {
+		if token.Family == family {
+			delete(s.tokens, id)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryRefreshStore) DeleteByUser(ctx context.Context, userID, except string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, token := range s.tokens {
+		if token.UserID == userID && (except == "" || token.Family != except) {
+			delete(s.tokens, id)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryRefreshStore) prune() {
+	now := s.now()
+	for id, token := range s.tokens {
+		if now.After(token.ExpiresAt) {
+			delete(s.tokens, id)
+		}
+	}
+}
+
+// Grant is a session and the tokens handed out for it.
+type Grant struct {
+	Session      *Session
+	Token        string
+	Refresh      *RefreshToken
+	RefreshToken string
+}
+
+// Grant starts a session for userID, as Issue does, in a new token
+// family when the Manager has a RefreshStore or a Remember store, along
+// with the first refresh token of the family in the former.
+func (m *Manager) Grant(ctx *fiber.Ctx, userID string) (*Grant, error) {
+	family := ""
+	if len(m.families()) > 0 {
+		family = utils.UUIDv4()
+	}
+	return m.grant(ctx, userID, family)
+}
+
+// grant starts a session of family and its refresh token.
+func (m *Manager) grant(ctx *fiber.Ctx, userID, family string) (*Grant, error) {
+	session, token, err := m.issue(ctx, userID, family)
+	if err != nil {
+		return nil, err
+	}
+	grant := &Grant{Session: session, Token: token}
+	if m.config.Refresh != nil {
+		grant.Refresh, grant.RefreshToken, err = m.familyToken(ctx.UserContext(), m.config.Refresh, session, m.config.RefreshTTL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return grant, nil
+}
+
+// Refresh ends the session of refreshToken and starts a new one, with a
+// new refresh token of the same family. Unknown or expired tokens fail
+// with ErrNotFound, rotated ones with a *ReuseError.
+func (m *Manager) Refresh(ctx *fiber.Ctx, refreshToken string) (*Grant, error) {
+	if m.config.Refresh == nil {
+		return nil, ErrNotFound
+	}
+	found, err := m.use(ctx.UserContext(), m.config.Refresh, refreshToken, tenantOf(ctx), 0)
+	if err != nil {
+		return nil, err
+	}
+	return m.grant(ctx, found.UserID, found.Family)
+}
+
+// familyToken records a new token of the family of session in store.
+func (m *Manager) familyToken(ctx context.Context, store RefreshStore, session *Session, ttl time.Duration) (*RefreshToken, string, error) {
+	token, hash, err := NewToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	record := &RefreshToken{
+		ID:        utils.UUIDv4(),
+		Family:    session.Family,
+		UserID:    session.UserID,
+		Tenant:    session.Tenant,
+		SessionID: session.ID,
+		TokenHash: hash,
+		UserAgent: session.UserAgent,
+		IP:        session.IP,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := store.Create(ctx, record); err != nil {
+		return nil, "", err
+	}
+	return record, token, nil
+}
+
+// errRotatedRecently is returned by use, with the token, for tokens
+// rotated less than the grace period ago.
+var errRotatedRecently = errors.New("session: token rotated recently")
+
+// use marks token of store rotated and ends the session it was handed
+// out with. A token rotated already revokes its family, unless that was
+// less than grace ago. Tokens of another tenant are not found.
+func (m *Manager) use(ctx context.Context, store RefreshStore, token, tenant string, grace time.Duration) (*RefreshToken, error) {
+	found, err := store.FindByToken(ctx, HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if found.Tenant != tenant {
+		return nil, ErrNotFound
+	}
+	now := time.Now()
+	if err := store.Use(ctx, found.ID, now); err != nil {
+		// Without UsedAt, a concurrent request rotated the token
+		// between FindByToken and Use.
+		if errors.Is(err, ErrRefreshReused) && grace > 0 && (found.UsedAt == nil || now.Sub(*found.UsedAt) < grace) {
+			return found, errRotatedRecently
+		}
+		if errors.Is(err, ErrRefreshReused) {
+			reused := &ReuseError{UserID: found.UserID, Family: found.Family}
+			return nil, errors.Join(reused, m.revokeFamily(ctx, found.Family))
+		}
+		return nil, err
+	}
+	if err := m.config.Store.Delete(ctx, found.SessionID); err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	return found, nil
+}
+
+// End ends session and its token family.
+func (m *Manager) End(ctx context.Context, session *Session) error {
+	if err := m.config.Store.Delete(ctx, session.ID); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if session.Family == "" {
+		return nil
+	}
+	for _, store := range m.families() {
+		if err := store.DeleteFamily(ctx, session.Family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeUser ends every session, refresh token and remember-me token of
+// userID.
+func (m *Manager) RevokeUser(ctx context.Context, userID string) error {
+	return m.revokeUser(ctx, userID, &Session{})
+}
+
+// RevokeOthers ends every session, refresh token and remember-me token
+// of the user of current but current and its token family, e.g. after
+// a password change.
+func (m *Manager) RevokeOthers(ctx context.Context, current *Session) error {
+	return m.revokeUser(ctx, current.UserID, current)
+}
+
+func (m *Manager) revokeUser(ctx context.Context, userID string, keep *Session) error {
+	if err := m.config.Store.DeleteByUser(ctx, userID, keep.ID); err != nil {
+		return err
+	}
+	for _, store := range m.families() {
+		if err := store.DeleteByUser(ctx, userID, keep.Family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeFamily ends the sessions a token family started, and the family.
+func (m *Manager) revokeFamily(ctx context.Context, family string) error {
+	for _, store := range m.families() {
+		tokens, err := store.ListFamily(ctx, family)
+		if err != nil {
+			return err
+		}
+		for _, token := range tokens {
+			if err := m.config.Store.Delete(ctx, token.SessionID); err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+		}
+		if err := store.DeleteFamily(ctx, family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// families returns the stores of token families the Manager has.
+func (m *Manager) families() []RefreshStore {
+	var stores []RefreshStore
+	for _, store := range []RefreshStore{m.config.Refresh, m.config.Remember} {
+		if store != nil {
+			stores = append(stores, store)
+		}
+	}
+	return stores
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefresh(t *testing.T) {
+	refresh := NewMemoryRefreshStore()
+	manager := NewManager(Config{Store: NewMemoryStore(), Refresh: refresh})
+
+	var grant *Grant
+	var err error
+	app := fiber.New()
+	app.Use(manager.Middleware())
+	app.Post("/login", func(ctx *fiber.Ctx) error {
+		grant, err = manager.Grant(ctx, "42")
+		return err
+	})
+	app.Post("/refresh", func(ctx *fiber.Ctx) error {
+		grant, err = manager.Refresh(ctx, ctx.Query("token"))
+		return nil
+	})
+	app.Get("/me", Require(), func(ctx *fiber.Ctx) error {
+		return ctx.SendString(UserID(ctx))
+	})
+	me := func(token string) int {
+		request := httptest.NewRequest("GET", "/me", nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode
+	}
+	// rotate leaves the outcome of Refresh in grant and err.
+	rotate := func(token string) *Grant {
+		_, failed := app.Test(httptest.NewRequest("POST", "/refresh?token="+token, nil))
+		assert.Nil(t, failed)
+		return grant
+	}
+
+	app.Test(httptest.NewRequest("POST", "/login", nil))
+	first := grant
+	assert.NotEmpty(t, first.RefreshToken)
+	assert.Equal(t, first.Refresh.Family, first.Session.Family)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), first.Refresh.ExpiresAt, time.Minute)
+
+	second := rotate(first.RefreshToken)
+	assert.Nil(t, err)
+	assert.Equal(t, first.Refresh.Family, second.Refresh.Family)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.Equal(t, 401, me(first.Token), "the refreshed session ends")
+	assert.Equal(t, 200, me(second.Token))
+
+	// The first token comes back: one of its holders stole it.
+	rotate(first.RefreshToken)
+	var reused *ReuseError
+	assert.True(t, errors.As(err, &reused))
+	assert.ErrorIs(t, err, ErrRefreshReused)
+	assert.Equal(t, "42", reused.UserID)
+	assert.Equal(t, 401, me(second.Token), "the whole family is revoked")
+	rotate(second.RefreshToken)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	rotate("unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRevokeUser(t *testing.T) {
+	ctx := context.Background()
+	store, refresh := NewMemoryStore(), NewMemoryRefreshStore()
+	manager := NewManager(Config{Store: store, Refresh: refresh})
+	store.Create(ctx, &Session{ID: "1", UserID: "42", Family: "f", ExpiresAt: time.Now().Add(time.Hour)})
+	refresh.Create(ctx, &RefreshToken{ID: "r1", Family: "f", UserID: "42", SessionID: "1", TokenHash: "h", ExpiresAt: time.Now().Add(time.Hour)})
+	refresh.Create(ctx, &RefreshToken{ID: "r2", Family: "g", UserID: "7", TokenHash: "i", ExpiresAt: time.Now().Add(time.Hour)})
+
+	assert.Nil(t, manager.RevokeUser(ctx, "42"))
+	sessions, _ := store.ListByUser(ctx, "42")
+	assert.Empty(t, sessions)
+	_, err := refresh.FindByToken(ctx, "h")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = refresh.FindByToken(ctx, "i")
+	assert.Nil(t, err)
+
+	assert.Nil(t, refresh.Use(ctx, "r2", time.Now()))
+	assert.ErrorIs(t, refresh.Use(ctx, "r2", time.Now()), ErrRefreshReused)
+}
+
+func TestRevokeOthers(t *testing.T) {
+	ctx := context.Background()
+	store, refresh, remember := NewMemoryStore(), NewMemoryRefreshStore(), NewMemoryRefreshStore()
+	manager := NewManager(Config{Store: store, Refresh: refresh, Remember: remember})
+	expires := time.Now().Add(time.Hour)
+	current := &Session{ID: "1", UserID: "42", Family: "f", ExpiresAt: expires}
+	store.Create(ctx, current)
+	store.Create(ctx, &Session{ID: "2", UserID: "42", Family: "g", ExpiresAt: expires})
+	refresh.Create(ctx, &RefreshToken{ID: "r1", Family: "f", UserID: "42", SessionID: "1", TokenHash: "h", ExpiresAt: expires})
+	refresh.Create(ctx, &RefreshToken{ID: "r2", Family: "g", UserID: "42", SessionID: "2", TokenHash: "i", ExpiresAt: expires})
+	// The session this remember-me token started expired already.
+	remember.Create(ctx, &RefreshToken{ID: "m1", Family: "m", UserID: "42", SessionID: "3", TokenHash: "j", ExpiresAt: expires})
+
+	assert.Nil(t, manager.RevokeOthers(ctx, current))
+	sessions, _ := store.ListByUser(ctx, "42")
+	assert.Equal(t, []*Session{current}, sessions)
+	_, err := refresh.FindByToken(ctx, "h")
+	assert.Nil(t, err, "the current family is kept")
+	_, err = refresh.FindByToken(ctx, "i")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = remember.FindByToken(ctx, "j")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,73 @@
+package session
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Remember keeps the browser of session, which Grant started, signed in
+// past it: Middleware starts a new session from the remember-me cookie
+// once this one is gone, rotating the token each time. It does nothing
+// without a Remember store.
+func (m *Manager) Remember(ctx *fiber.Ctx, session *Session) error {
+	if m.config.Remember == nil || session.Family == "" {
+		return nil
+	}
+	record, token, err := m.familyToken(ctx.UserContext(), m.config.Remember, session, m.config.RememberTTL)
+	if err != nil {
+		return err
+	}
+	m.setRememberCookie(ctx, token, record.ExpiresAt)
+	return nil
+}
+
+// recall starts a session from the remember-me cookie, or returns nil
+// when there is none or it is no good.
+func (m *Manager) recall(ctx *fiber.Ctx) *Session {
+	token := ctx.Cookies(m.config.RememberCookieName)
+	if m.config.Remember == nil || token == "" {
+		return nil
+	}
+	found, err := m.use(ctx.UserContext(), m.config.Remember, token, tenantOf(ctx), m.config.RememberGrace)
+	if errors.Is(err, errRotatedRecently) {
+		// The request that rotated the token set the new cookie.
+		session, _, err := m.issue(ctx, found.UserID, found.Family)
+		if err != nil {
+			return nil
+		}
+		return session
+	}
+	if err != nil {
+		ctx.ClearCookie(m.config.RememberCookieName)
+		var reused *ReuseError
+		if errors.As(err, &reused) && m.config.OnReuse != nil {
+			m.config.OnReuse(ctx, reused)
+		}
+		return nil
+	}
+
+	session, _, err := m.issue(ctx, found.UserID, found.Family)
+	if err != nil {
+		return nil
+	}
+	record, rotated, err := m.familyToken(ctx.UserContext(), m.config.Remember, session, m.config.RememberTTL)
+	if err != nil {
+		return nil
+	}
+	m.setRememberCookie(ctx, rotated, record.ExpiresAt)
+	return session
+}
+
+func (m *Manager) setRememberCookie(ctx *fiber.Ctx, token string, expires time.Time) {
+	ctx.Cookie(&fiber.Cookie{
+		Name:     m.config.RememberCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   m.config.CookieSecure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
@@ -0,0 +1,144 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemember(t *testing.T) {
+	var reused *ReuseError
+	manager := NewManager(Config{
+		Store:    NewMemoryStore(),
+		Remember: NewMemoryRefreshStore(),
+		OnReuse:  func(c *fiber.Ctx, err *ReuseError) { reused = err },
+		// Reuse below comes right after the rotation.
+		RememberGrace: time.Nanosecond,
+	})
+
+	app := fiber.New()
+	app.Use(manager.Middleware())
+	app.Post("/login", func(ctx *fiber.Ctx) error {
+		grant, err := manager.Grant(ctx, "42")
+		if err != nil {
+			return err
+		}
+		return manager.Remember(ctx, grant.Session)
+	})
+	app.Post("/logout", func(ctx *fiber.Ctx) error {
+		return manager.Revoke(ctx)
+	})
+	app.Get("/me", Require(), func(ctx *fiber.Ctx) error {
+		return ctx.SendString(UserID(ctx))
+	})
+	// me sends only the remember-me cookie, as a browser does once its
+	// session cookie is gone, and returns the cookies it got back.
+	me := func(token string) (int, map[string]*http.Cookie) {
+		request := httptest.NewRequest("GET", "/me", nil)
+		request.AddCookie(&http.Cookie{Name: "remember_me", Value: token})
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response.StatusCode, cookies(response)
+	}
+
+	response, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+	assert.Nil(t, err)
+	first := cookies(response)["remember_me"]
+	assert.NotNil(t, first)
+	assert.True(t, first.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, first.SameSite)
+
+	status, got := me(first.Value)
+	assert.Equal(t, 200, status, "the remember-me cookie signs in again")
+	assert.NotEmpty(t, got["session_id"].Value)
+	second := got["remember_me"]
+	assert.NotEqual(t, first.Value, second.Value, "each use rotates the token")
+
+	// The first token comes back: one of its holders stole it.
+	status, got = me(first.Value)
+	assert.Equal(t, 401, status)
+	assert.Empty(t, got["remember_me"].Value)
+	assert.Equal(t, "42", reused.UserID)
+	status, _ = me(second.Value)
+	assert.Equal(t, 401, status, "reuse revokes the family")
+
+	// Signing out forgets the browser.
+	response, _ = app.Test(httptest.NewRequest("POST", "/login", nil))
+	token := cookies(response)["remember_me"].Value
+	request := httptest.NewRequest("POST", "/logout", nil)
+	request.AddCookie(&http.Cookie{Name: "session_id", Value: cookies(response)["session_id"].Value})
+	response, _ = app.Test(request)
+	assert.Empty(t, cookies(response)["remember_me"].Value)
+	status, _ = me(token)
+	assert.Equal(t, 401, status)
+}
+
+func TestRememberParallel(t *testing.T) {
+	var reused *ReuseError
+	manager := NewManager(Config{
+		Store:    NewMemoryStore(),
+		Remember: NewMemoryRefreshStore(),
+		OnReuse:  func(c *fiber.Ctx, err *ReuseError) { reused = err },
+	})
+
+	app := fiber.New()
+	app.Use(manager.Middleware())
+	app.Post("/login", func(ctx *fiber.Ctx) error {
+		grant, err := manager.Grant(ctx, "42")
+		if err != nil {
+			return err
+		}
+		return manager.Remember(ctx, grant.Session)
+	})
+	app.Get("/me", Require(), func(ctx *fiber.Ctx) error {
+		return ctx.SendString(UserID(ctx))
+	})
+
+	response, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+	assert.Nil(t, err)
+	first := cookies(response)["remember_me"].Value
+
+	// A browser opening with a stale session sends every request it
+	// starts with the same remember-me cookie.
+	var wg sync.WaitGroup
+	statuses := make([]int, 2)
+	sessions := make([]string, 2)
+	for i := range statuses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			request := httptest.NewRequest("GET", "/me", nil)
+			request.AddCookie(&http.Cookie{Name: "remember_me", Value: first})
+			response, err := app.Test(request)
+			assert.Nil(t, err)
+			statuses[i] = response.StatusCode
+			if cookie := cookies(response)["session_id"]; cookie != nil {
+				sessions[i] = cookie.Value
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, []int{200, 200}, statuses)
+	assert.Nil(t, reused, "no reuse is reported within the grace period")
+
+	for _, id := range sessions {
+		request := httptest.NewRequest("GET", "/me", nil)
+		request.AddCookie(&http.Cookie{Name: "session_id", Value: id})
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode, "both sessions survive")
+	}
+}
+
+func cookies(response *http.Response) map[string]*http.Cookie {
+	found := map[string]*http.Cookie{}
+	for _, cookie := range response.Cookies() {
+		found[cookie.Name] = cookie
+	}
+	return found
+}
@@ -0,0 +1,173 @@
+// Package session signs users in with server-side sessions. The token is
+// handed to browsers as an HttpOnly cookie and to API clients in the login
+// response, to be sent back as a Bearer token.
+package session
+
+import (
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/middleware/realip"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const (
+	localSession = "session"
+	localUserID  = "user_id"
+	// localTenant is the ctx.Locals key of the tenant id, as set by
+	// tenant.New.
+	localTenant = "tenant"
+)
+
+// Manager issues and resolves sessions.
+type Manager struct {
+	config Config
+}
+
+func NewManager(config ...Config) *Manager {
+	cfg := configDefault(config...)
+	if cfg.Store == nil {
+		panic("session: Store cannot be nil")
+	}
+	return &Manager{config: cfg}
+}
+
+// Store returns the store sessions are kept in.
+func (m *Manager) Store() Store {
+	return m.config.Store
+}
+
+// Issue starts a session for userID, sets the cookie and returns the
+// session with its token.
+func (m *Manager) Issue(ctx *fiber.Ctx, userID string) (*Session, string, error) {
+	return m.issue(ctx, userID, "")
+}
+
+// issue starts a session of the refresh token family, if any.
+func (m *Manager) issue(ctx *fiber.Ctx, userID, family string) (*Session, string, error) {
+	token, hash, err := NewToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:        utils.UUIDv4(),
+		UserID:    utils.CopyString(userID),
+		TokenHash: hash,
+		Family:    family,
+		Tenant:    tenantOf(ctx),
+		UserAgent: utils.CopyString(ctx.Get(fiber.HeaderUserAgent)),
+		IP:        realip.IP(ctx),
+		CreatedAt: now,
+		LastSeen:  now,
+		ExpiresAt: now.Add(m.config.TTL),
+	}
+	if err := m.config.Store.Create(ctx.UserContext(), session); err != nil {
+		return nil, "", err
+	}
+
+	ctx.Cookie(&fiber.Cookie{
+		Name:     m.config.CookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		Secure:   m.config.CookieSecure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return session, token, nil
+}
+
+// Revoke ends the current session, and its token family, and clears the
+// cookies.
+func (m *Manager) Revoke(ctx *fiber.Ctx) error {
+	ctx.ClearCookie(m.config.CookieName, m.config.RememberCookieName)
+	if current := Current(ctx); current != nil {
+		return m.End(ctx.UserContext(), current)
+	}
+	return nil
+}
+
+// RevokeAll ends every session, and token family, of the signed in user
+// and clears the cookies.
+func (m *Manager) RevokeAll(ctx *fiber.Ctx) error {
+	ctx.ClearCookie(m.config.CookieName, m.config.RememberCookieName)
+	if userID := UserID(ctx); userID != "" {
+		return m.RevokeUser(ctx.UserContext(), userID)
+	}
+	return nil
+}
+
+// Middleware resolves the session of the request, if any, and stores it
+// for Current and UserID. Browsers without a valid session cookie get a
+// new session from their remember-me cookie, if any. Other requests pass
+// through anonymously; use Require to reject them. Sessions of another
+// tenant than the request's do not count, so it must run after tenant.New.
+func (m *Manager) Middleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if m.config.Next != nil && m.config.Next(ctx) {
+			return ctx.Next()
+		}
+
+		var session *Session
+		if token := m.token(ctx); token != "" {
+			session, _ = m.config.Store.FindByToken(ctx.UserContext(), HashToken(token))
+		}
+		if session != nil && session.Tenant != tenantOf(ctx) {
+			session = nil
+		}
+		if session == nil && ctx.Get(fiber.HeaderAuthorization) == "" {
+			session = m.recall(ctx)
+		}
+		if session == nil {
+			return ctx.Next()
+		}
+
+		now := time.Now()
+		if now.Sub(session.LastSeen) > time.Minute {
+			m.config.Store.Touch(ctx.UserContext(), session.ID, now)
+		}
+		ctx.Locals(localSession, session)
+		ctx.Locals(localUserID, session.UserID)
+		return ctx.Next()
+	}
+}
+
+func (m *Manager) token(ctx *fiber.Ctx) string {
+	auth := ctx.Get(fiber.HeaderAuthorization)
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return auth[7:]
+	}
+	return ctx.Cookies(m.config.CookieName)
+}
+
+// tenantOf returns the tenant of the request, or "" when it has none.
+func tenantOf(ctx *fiber.Ctx) string {
+	tenant, _ := ctx.Locals(localTenant).(string)
+	return tenant
+}
+
+// Require rejects requests without a session with 401.
+func Require() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if Current(ctx) == nil {
+			return fiber.ErrUnauthorized
+		}
+		return ctx.Next()
+	}
+}
+
+// Current returns the session of the request, or nil when anonymous.
+func Current(ctx *fiber.Ctx) *Session {
+	session, _ := ctx.Locals(localSession).(*Session)
+	return session
+}
+
+// UserID returns the signed-in user's id, or "" when anonymous.
+func UserID(ctx *fiber.Ctx) string {
+	id, _ := ctx.Locals(localUserID).(string)
+	return id
+}
@@ -0,0 +1,145 @@
+package session
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager(t *testing.T) {
+	store := NewMemoryStore()
+	manager := NewManager(Config{Store: store})
+
+	app := fiber.New()
+	app.Use(manager.Middleware())
+	app.Post("/login", func(ctx *fiber.Ctx) error {
+		_, token, err := manager.Issue(ctx, "42")
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	app.Post("/logout", Require(), func(ctx *fiber.Ctx) error {
+		return manager.Revoke(ctx)
+	})
+	app.Get("/me", Require(), func(ctx *fiber.Ctx) error {
+		return ctx.SendString(UserID(ctx))
+	})
+
+	response, err := app.Test(httptest.NewRequest("GET", "/me", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	response, err = app.Test(httptest.NewRequest("POST", "/login", nil))
+	assert.Nil(t, err)
+	cookie := response.Cookies()[0]
+	assert.Equal(t, "session_id", cookie.Name)
+	assert.True(t, cookie.HttpOnly)
+
+	request := httptest.NewRequest("GET", "/me", nil)
+	request.AddCookie(cookie)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	request = httptest.NewRequest("GET", "/me", nil)
+	request.Header.Set("Authorization", "Bearer "+cookie.Value)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	sessions, _ := store.ListByUser(context.Background(), "42")
+	assert.Len(t, sessions, 1)
+	assert.NotEqual(t, cookie.Value, sessions[0].TokenHash)
+
+	request = httptest.NewRequest("POST", "/logout", nil)
+	request.AddCookie(cookie)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	request = httptest.NewRequest("GET", "/me", nil)
+	request.AddCookie(cookie)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	store.Create(context.Background(), &Session{ID: "1", UserID: "42", TokenHash: "h", ExpiresAt: now.Add(time.Hour)})
+
+	_, err := store.FindByToken(context.Background(), "h")
+	assert.Nil(t, err)
+
+	now = now.Add(2 * time.Hour)
+	_, err = store.FindByToken(context.Background(), "h")
+	assert.Equal(t, ErrNotFound, err)
+
+	// Create sweeps sessions expired since the last pass.
+	store.Create(context.Background(), &Session{ID: "2", UserID: "42", TokenHash: "g", ExpiresAt: now.Add(time.Minute)})
+	now = now.Add(2 * time.Minute)
+	store.Create(context.Background(), &Session{ID: "3", UserID: "42", TokenHash: "f", ExpiresAt: now.Add(time.Hour)})
+	assert.Len(t, store.sessions, 1)
+	assert.Equal(t, map[string]string{"f": "3"}, store.byToken)
+}
+
+func TestTenantBound(t *testing.T) {
+	refresh := NewMemoryRefreshStore()
+	manager := NewManager(Config{Store: NewMemoryStore(), Refresh: refresh})
+
+	var grant *Grant
+	var err error
+	app := fiber.New()
+	// Stands in for tenant.New.
+	app.Use(func(ctx *fiber.Ctx) error {
+		if id := ctx.Get("X-Tenant-ID"); id != "" {
+			ctx.Locals("tenant", id)
+		}
+		return ctx.Next()
+	})
+	app.Use(manager.Middleware())
+	app.Post("/login", func(ctx *fiber.Ctx) error {
+		grant, err = manager.Grant(ctx, "42")
+		return err
+	})
+	app.Post("/refresh", func(ctx *fiber.Ctx) error {
+		grant, err = manager.Refresh(ctx, ctx.Query("token"))
+		return nil
+	})
+	app.Get("/me", Require(), func(ctx *fiber.Ctx) error {
+		return ctx.SendString(UserID(ctx))
+	})
+	send := func(method, target, tenant, token string) int {
+		request := httptest.NewRequest(method, target, nil)
+		if tenant != "" {
+			request.Header.Set("X-Tenant-ID", tenant)
+		}
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		response, failed := app.Test(request)
+		assert.Nil(t, failed)
+		return response.StatusCode
+	}
+
+	send("POST", "/login", "acme", "")
+	first := grant
+	assert.Equal(t, "acme", first.Session.Tenant)
+	assert.Equal(t, 200, send("GET", "/me", "acme", first.Token))
+	assert.Equal(t, 401, send("GET", "/me", "globex", first.Token), "sessions are only good for their tenant")
+	assert.Equal(t, 401, send("GET", "/me", "", first.Token))
+
+	send("POST", "/refresh?token="+first.RefreshToken, "globex", "")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 200, send("GET", "/me", "acme", first.Token), "refreshing at another tenant leaves the session be")
+	send("POST", "/refresh?token="+first.RefreshToken, "acme", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "acme", grant.Session.Tenant)
+}
@@ -0,0 +1,184 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned for unknown, expired or revoked sessions.
+var ErrNotFound = errors.New("session: not found")
+
+// Session is a signed-in user on one device. The bearer token itself is
+// never stored, only its SHA-256 in TokenHash.
+type Session struct {
+	ID        string
+	UserID    string
+	TokenHash string
+	UserAgent string
+	IP        string
+	// Family is the refresh token family the session belongs to, if any;
+	// see RefreshToken.
+	Family string
+	// Tenant is the tenant the session was started for, if any. It is
+	// only good for requests of the same tenant.
+	Tenant    string
+	CreatedAt time.Time
+	LastSeen  time.Time
+	ExpiresAt time.Time
+}
+
+// Store persists sessions.
+type Store interface {
+	Create(ctx context.Context, session *Session) error
+	// FindByToken returns the live session whose token hashes to hash.
+	FindByToken(ctx context.Context, hash string) (*Session, error)
+	Touch(ctx context.Context, id string, at time.Time) error
+	ListByUser(ctx context.Context, userID string) ([]*Session, error)
+	Delete(ctx context.Context, id string) error
+	// DeleteByUser deletes the sessions of userID but the one with id
+	// except, if any.
+	DeleteByUser(ctx context.Context, userID, except string) error
+}
+
+// NewToken returns a random bearer token and the hash to store for it.
+func NewToken() (token, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashToken(token), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// pruneInterval is how often Create sweeps expired sessions.
+const pruneInterval = time.Minute
+
+// MemoryStore keeps sessions in process memory, indexed by token hash.
+// Expired sessions are never returned, and are swept by Create at most
+// once per pruneInterval.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	byToken  map[string]string
+	pruned   time.Time
+	now      func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[string]*Session{}, byToken: map[string]string{}, now: time.Now}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := s.now(); now.Sub(s.pruned) >= pruneInterval {
+		s.prune()
+		s.pruned = now
+	}
+	if old, ok := s.sessions[session.ID]; ok {
+		delete(s.byToken, old.TokenHash)
+	}
+	copied := *session
+	s.sessions[session.ID] = &copied
+	s.byToken[session.TokenHash] = session.ID
+	return nil
+}
+
+func (s *MemoryStore) FindByToken(ctx context.Context, hash string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[s.byToken[hash]]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if s.now().After(session.ExpiresAt) {
+		s.remove(session.ID)
+		return nil, ErrNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (s *MemoryStore) Touch(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	session.LastSeen = at
+	return nil
+}
+
+func (s *MemoryStore) ListByUser(ctx context.Context, userID string) ([]*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	list := []*Session{}
+	for _, session := range s.sessions {
+		if session.UserID == userID {
+			copied := *session
+			list = append(list, &copied)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[id]; !ok {
+		return ErrNotFound
+	}
+	s.remove(id)
+	return nil
+}
+
+func (s *MemoryStore) DeleteByUser(ctx context.Context, userID, except string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID && id != except {
+			s.remove(id)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) prune() {
+	now := s.now()
+	for id, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			s.remove(id)
+		}
+	}
+}
+
+// remove deletes the session id along with its token index entry.
+func (s *MemoryStore) remove(id string) {
+	if session, ok := s.sessions[id]; ok {
+		delete(s.byToken, session.TokenHash)
+		delete(s.sessions, id)
+	}
+}
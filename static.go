@@ -0,0 +1,24 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"belajar-golang-fiber/sandbox"
+)
+
+//go:embed source
+var sourceFS embed.FS
+
+func staticFS(fromDisk bool) http.FileSystem {
+	if fromDisk {
+		return sandbox.Dir{Root: "./source"}
+	}
+
+	sub, err := fs.Sub(sourceFS, "source")
+	if err != nil {
+		panic(err)
+	}
+	return http.FS(sub)
+}
@@ -0,0 +1,25 @@
+package static
+
+import (
+	"net/http"
+	"os"
+)
+
+// Chain is an http.FileSystem that opens a name from the first root
+// containing it. Files are returned unwrapped: the filesystem middleware
+// hands an *os.File from an http.Dir root to fasthttp, which sends it
+// with sendfile(2).
+type Chain []http.FileSystem
+
+func (c Chain) Open(name string) (http.File, error) {
+	for _, root := range c {
+		if root == nil {
+			continue
+		}
+		file, err := root.Open(name)
+		if err == nil {
+			return file, nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
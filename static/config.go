@@ -0,0 +1,55 @@
+package static
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Theme is searched first.
+	//
+	// Optional. Default: nil
+	Theme http.FileSystem
+
+	// TenantDir holds one sub-directory per tenant, searched after Theme.
+	//
+	// Optional. Default: ""
+	TenantDir string
+
+	// TenantKey is the Locals key holding the current tenant name.
+	//
+	// Optional. Default: "tenant"
+	TenantKey string
+
+	// Default is searched last.
+	//
+	// Required.
+	Default http.FileSystem
+
+	// MaxAge sets the Cache-Control max-age in seconds.
+	//
+	// Optional. Default: 0
+	MaxAge int
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	TenantKey: "tenant",
+}
+
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	cfg := config[0]
+	if cfg.TenantKey == "" {
+		cfg.TenantKey = ConfigDefault.TenantKey
+	}
+	return cfg
+}
@@ -0,0 +1,91 @@
+package static
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"belajar-golang-fiber/middleware/cachecontrol"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+)
+
+// SPAConfig defines the config for the SPA handler.
+type SPAConfig struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Root holds the built client application.
+	//
+	// Required.
+	Root http.FileSystem
+
+	// Index is served for every unknown route.
+	//
+	// Optional. Default: "/index.html"
+	Index string
+
+	// HashedAsset matches file names carrying a content hash; those are
+	// cached for a year, everything else is revalidated on each request.
+	//
+	// Optional. Default: names like app.3f2a9c1d.js
+	HashedAsset *regexp.Regexp
+}
+
+// SPAConfigDefault is the default config
+var SPAConfigDefault = SPAConfig{
+	Index:       "/index.html",
+	HashedAsset: regexp.MustCompile(`[.-][0-9a-fA-F]{8,}\.[a-zA-Z0-9]+$`),
+}
+
+// SPA creates a handler for client-side routed apps: existing files are
+// served as-is and any other extension-less path falls back to Index.
+func SPA(config ...SPAConfig) fiber.Handler {
+	cfg := SPAConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+		if cfg.Index == "" {
+			cfg.Index = SPAConfigDefault.Index
+		}
+		if cfg.HashedAsset == nil {
+			cfg.HashedAsset = SPAConfigDefault.HashedAsset
+		}
+	}
+	if cfg.Root == nil {
+		panic("static: SPA Root cannot be nil")
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+		if ctx.Method() != fiber.MethodGet && ctx.Method() != fiber.MethodHead {
+			return ctx.Next()
+		}
+
+		name := path.Clean("/" + strings.TrimPrefix(ctx.Path(), ctx.Route().Path))
+		if name != "/" {
+			err := filesystem.SendFile(ctx, cfg.Root, name)
+			if err == nil {
+				if cfg.HashedAsset.MatchString(name) {
+					cachecontrol.Immutable.Apply(ctx)
+				} else {
+					cachecontrol.Revalidate.Apply(ctx)
+				}
+				return nil
+			}
+			if !errors.Is(err, fiber.ErrNotFound) && !errors.Is(err, fiber.ErrForbidden) {
+				return err
+			}
+			if path.Ext(name) != "" {
+				return fiber.ErrNotFound
+			}
+		}
+
+		cachecontrol.Revalidate.Apply(ctx)
+		return filesystem.SendFile(ctx, cfg.Root, cfg.Index)
+	}
+}
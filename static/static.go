@@ -0,0 +1,55 @@
+package static
+
+import (
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"belajar-golang-fiber/sandbox"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+)
+
+var tenantName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// New creates a static file handler that looks a path up in the theme
+// directory, then the current tenant's directory, then the default root,
+// answering 404 only when none of them has it.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+	if cfg.Default == nil {
+		panic("static: Default cannot be nil")
+	}
+
+	var handlers sync.Map
+	handlerFor := func(tenant string) fiber.Handler {
+		if handler, ok := handlers.Load(tenant); ok {
+			return handler.(fiber.Handler)
+		}
+
+		roots := Chain{cfg.Theme}
+		if tenant != "" {
+			roots = append(roots, sandbox.Dir{Root: filepath.Join(cfg.TenantDir, tenant)})
+		}
+		roots = append(roots, cfg.Default)
+
+		handler, _ := handlers.LoadOrStore(tenant, filesystem.New(filesystem.Config{
+			Root:   roots,
+			MaxAge: cfg.MaxAge,
+		}))
+		return handler.(fiber.Handler)
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return ctx.Next()
+		}
+
+		tenant, _ := ctx.Locals(cfg.TenantKey).(string)
+		if cfg.TenantDir == "" || !tenantName.MatchString(tenant) {
+			tenant = ""
+		}
+		return handlerFor(tenant)(ctx)
+	}
+}
@@ -0,0 +1,131 @@
+package static
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallbackChain(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("tenant", ctx.Get("X-Tenant-ID"))
+		return ctx.Next()
+	})
+	app.Use("/public", New(Config{
+		Theme:     http.Dir("./testdata/theme"),
+		TenantDir: "./testdata/tenants",
+		Default:   http.Dir("./testdata/default"),
+	}))
+
+	cases := []struct {
+		path   string
+		tenant string
+		status int
+		body   string
+	}{
+		{"/public/style.css", "acme", 200, "theme"},
+		{"/public/logo.txt", "acme", 200, "acme"},
+		{"/public/logo.txt", "", 200, "default logo"},
+		{"/public/logo.txt", "../tenants/acme", 200, "default logo"},
+		{"/public/readme.txt", "acme", 200, "default readme"},
+		{"/public/missing.txt", "acme", 404, ""},
+	}
+
+	for _, c := range cases {
+		request := httptest.NewRequest("GET", c.path, nil)
+		request.Header.Set("X-Tenant-ID", c.tenant)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, c.status, response.StatusCode, c.path)
+
+		if c.status == 200 {
+			bytes, err := io.ReadAll(response.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, c.body, string(bytes), c.path)
+		}
+	}
+}
+
+func TestSPAFallback(t *testing.T) {
+	app := fiber.New()
+	app.Use("/app", SPA(SPAConfig{Root: http.Dir("./testdata/spa")}))
+
+	cases := []struct {
+		path   string
+		status int
+		body   string
+		cache  string
+	}{
+		{"/app", 200, "<div id=app></div>", "no-cache"},
+		{"/app/users/10/orders", 200, "<div id=app></div>", "no-cache"},
+		{"/app/assets/app.3f2a9c1d.js", 200, "console.log(1)", "public, max-age=31536000, immutable"},
+		{"/app/robots.txt", 200, "User-agent: *", "no-cache"},
+		{"/app/assets/missing.js", 404, "", ""},
+	}
+
+	for _, c := range cases {
+		request := httptest.NewRequest("GET", c.path, nil)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, c.status, response.StatusCode, c.path)
+
+		if c.status == 200 {
+			bytes, err := io.ReadAll(response.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, c.body, string(bytes), c.path)
+			assert.Equal(t, c.cache, response.Header.Get("Cache-Control"), c.path)
+		}
+	}
+}
+
+func TestChainKeepsOSFile(t *testing.T) {
+	chain := Chain{nil, http.Dir("./testdata/theme"), http.Dir("./testdata/default")}
+	file, err := chain.Open("/readme.txt")
+	assert.Nil(t, err)
+	defer file.Close()
+
+	// Anything else makes fasthttp copy through user space instead of
+	// using sendfile.
+	_, ok := file.(*os.File)
+	assert.True(t, ok)
+}
+
+func TestTenantDirRefusesTraversal(t *testing.T) {
+	base := t.TempDir()
+	tenant := filepath.Join(base, "tenants", "acme")
+	assert.Nil(t, os.MkdirAll(tenant, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(base, "secret.txt"), []byte("rahasia"), 0o644))
+	if err := os.Symlink(base, filepath.Join(tenant, "escape")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	app := fiber.New()
+	app.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("tenant", "acme")
+		return ctx.Next()
+	})
+	app.Use("/public", New(Config{
+		TenantDir: filepath.Join(base, "tenants"),
+		Default:   http.Dir("./testdata/default"),
+	}))
+
+	for _, target := range []string{
+		"/public/escape/secret.txt",
+		"/public/../../secret.txt",
+		"/public/..%2f..%2fsecret.txt",
+		"/public/%2e%2e/%2e%2e/secret.txt",
+		"/public/..%5c..%5csecret.txt",
+	} {
+		response, err := app.Test(httptest.NewRequest("GET", target, nil))
+		assert.Nil(t, err)
+		bytes, _ := io.ReadAll(response.Body)
+		assert.NotContains(t, string(bytes), "rahasia", target)
+	}
+}
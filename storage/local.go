@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"belajar-golang-fiber/sandbox"
+)
+
+// Local stores files below a directory on disk.
+type Local struct {
+	root string
+	// Symlinks decides which links below the root are followed. By
+	// default only links staying inside the root are.
+	Symlinks sandbox.Symlinks
+}
+
+func NewLocal(root string) *Local {
+	return &Local{root: root}
+}
+
+// Path resolves name to a location inside the root directory.
+func (l *Local) Path(name string) (string, error) {
+	p, err := sandbox.Resolve(l.root, name, l.Symlinks)
+	if errors.Is(err, sandbox.ErrEscape) {
+		return "", ErrInvalidPath
+	}
+	return p, err
+}
+
+func (l *Local) Open(ctx context.Context, name string) (File, error) {
+	p, err := l.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+func (l *Local) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	p, err := l.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, err
+	}
+	return os.Create(p)
+}
+
+func (l *Local) Stat(ctx context.Context, name string) (fs.FileInfo, error) {
+	p, err := l.Path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(p)
+}
+
+func (l *Local) List(ctx context.Context, dir string) ([]fs.FileInfo, error) {
+	p, err := l.Path(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (l *Local) Mkdir(ctx context.Context, name string) error {
+	p, err := l.Path(name)
+	if err != nil {
+		return err
+	}
+	return os.Mkdir(p, 0o755)
+}
+
+func (l *Local) Rename(ctx context.Context, from, to string) error {
+	source, err := l.Path(from)
+	if err != nil {
+		return err
+	}
+	target, err := l.Path(to)
+	if err != nil {
+		return err
+	}
+	return os.Rename(source, target)
+}
+
+func (l *Local) Remove(ctx context.Context, name string) error {
+	p, err := l.Path(name)
+	if err != nil {
+		return err
+	}
+	if p == filepath.Clean(l.root) {
+		return ErrInvalidPath
+	}
+	return os.RemoveAll(p)
+}
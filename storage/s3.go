@@ -0,0 +1,525 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/sandbox"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// MinPartSize is the smallest part S3 accepts in a multipart upload,
+// other than the last one.
+const MinPartSize = 5 * 1024 * 1024
+
+// S3Config locates a bucket. Endpoint and PathStyle point the client at
+// an S3 compatible service such as MinIO.
+type S3Config struct {
+	Bucket    string
+	Prefix    string
+	Region    string
+	Endpoint  string
+	PathStyle bool
+	AccessKey string
+	SecretKey string
+	// PartSize is the size of each part of a multipart upload. Zero
+	// means 8 MiB; smaller values are raised to MinPartSize.
+	PartSize int
+	// Concurrency caps the parts of one upload sent at the same time.
+	// Zero means 4.
+	Concurrency int
+}
+
+// s3API is the part of *s3.Client used by S3.
+type s3API interface {
+	GetObject(ctx context.Context, input *s3.GetObjectInput, options ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, input *s3.HeadObjectInput, options ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	PutObject(ctx context.Context, input *s3.PutObjectInput, options ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	CopyObject(ctx context.Context, input *s3.CopyObjectInput, options ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+	DeleteObject(ctx context.Context, input *s3.DeleteObjectInput, options ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+	ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, options ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	CreateMultipartUpload(ctx context.Context, input *s3.CreateMultipartUploadInput, options ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, input *s3.UploadPartInput, options ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, input *s3.CompleteMultipartUploadInput, options ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, input *s3.AbortMultipartUploadInput, options ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// S3 stores files as objects in a bucket. Directories are key prefixes;
+// Mkdir writes an empty "dir/" marker so empty ones still list.
+//
+// Files written through Create are sent as a multipart upload while they
+// are written, a few parts at a time, so an upload of any size never
+// touches local disk and only Concurrency parts are held in memory.
+type S3 struct {
+	client      s3API
+	bucket      string
+	prefix      string
+	partSize    int
+	concurrency int
+}
+
+func NewS3(cfg S3Config) *S3 {
+	client := s3.New(s3.Options{
+		Region:       cfg.Region,
+		BaseEndpoint: optional(cfg.Endpoint),
+		UsePathStyle: cfg.PathStyle,
+		Credentials: aws.NewCredentialsCache(aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: cfg.AccessKey, SecretAccessKey: cfg.SecretKey}, nil
+		})),
+	})
+	return newS3(client, cfg)
+}
+
+func newS3(client s3API, cfg S3Config) *S3 {
+	partSize := cfg.PartSize
+	if partSize <= 0 {
+		partSize = 8 * 1024 * 1024
+	}
+	partSize = max(partSize, MinPartSize)
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	return &S3{
+		client:      client,
+		bucket:      cfg.Bucket,
+		prefix:      strings.Trim(cfg.Prefix, "/"),
+		partSize:    partSize,
+		concurrency: concurrency,
+	}
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return aws.String(value)
+}
+
+// key resolves name to an object key below the prefix; the root is "".
+func (s *S3) key(name string) (string, error) {
+	clean, err := sandbox.Clean(name)
+	if err != nil {
+		return "", ErrInvalidPath
+	}
+	return strings.TrimPrefix(path.Join(s.prefix, clean), "/"), nil
+}
+
+func (s *S3) Open(ctx context.Context, name string) (File, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return nil, err
+	}
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &s.bucket, Key: &key})
+	if err != nil {
+		return nil, s3Error("open", name, err)
+	}
+	return &s3File{storage: s, ctx: ctx, key: key, size: aws.ToInt64(head.ContentLength)}, nil
+}
+
+func (s *S3) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Writer{
+		storage: s,
+		ctx:     ctx,
+		key:     key,
+		slots:   make(chan struct{}, s.concurrency),
+	}, nil
+}
+
+func (s *S3) Stat(ctx context.Context, name string) (fs.FileInfo, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return s3Info{name: "/", dir: true}, nil
+	}
+
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &s.bucket, Key: &key})
+	if err == nil {
+		return s3Info{name: path.Base(key), size: aws.ToInt64(head.ContentLength), modTime: aws.ToTime(head.LastModified)}, nil
+	}
+	if !isNotFound(err) {
+		return nil, s3Error("stat", name, err)
+	}
+
+	listed, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  &s.bucket,
+		Prefix:  aws.String(key + "/"),
+		MaxKeys: aws.Int32(1),
+	})
+	if err != nil {
+		return nil, s3Error("stat", name, err)
+	}
+	if len(listed.Contents) == 0 {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return s3Info{name: path.Base(key), dir: true}, nil
+}
+
+func (s *S3) List(ctx context.Context, dir string) ([]fs.FileInfo, error) {
+	key, err := s.key(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := key
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	infos := []fs.FileInfo{}
+	pages := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket:    &s.bucket,
+		Prefix:    &prefix,
+		Delimiter: aws.String("/"),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, s3Error("list", dir, err)
+		}
+		for _, common := range page.CommonPrefixes {
+			infos = append(infos, s3Info{name: path.Base(aws.ToString(common.Prefix)), dir: true})
+		}
+		for _, object := range page.Contents {
+			if aws.ToString(object.Key) == prefix {
+				continue
+			}
+			infos = append(infos, s3Info{
+				name:    path.Base(aws.ToString(object.Key)),
+				size:    aws.ToInt64(object.Size),
+				modTime: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+	return infos, nil
+}
+
+func (s *S3) Mkdir(ctx context.Context, name string) error {
+	key, err := s.key(name)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: &s.bucket,
+		Key:    aws.String(key + "/"),
+		Body:   bytes.NewReader(nil),
+	})
+	return s3Error("mkdir", name, err)
+}
+
+// Rename copies the object and deletes the original. Directories are not
+// renamed.
+func (s *S3) Rename(ctx context.Context, from, to string) error {
+	source, err := s.key(from)
+	if err != nil {
+		return err
+	}
+	target, err := s.key(to)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     &s.bucket,
+		Key:        &target,
+		CopySource: aws.String(s.bucket + "/" + escapeKey(source)),
+	})
+	if err != nil {
+		return s3Error("rename", from, err)
+	}
+	_, err = s.client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &s.bucket, Key: &source})
+	return s3Error("rename", from, err)
+}
+
+func (s *S3) Remove(ctx context.Context, name string) error {
+	key, err := s.key(name)
+	if err != nil {
+		return err
+	}
+	if key == s.prefix {
+		return ErrInvalidPath
+	}
+	_, err = s.client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &s.bucket, Key: &key})
+	return s3Error("remove", name, err)
+}
+
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "NotFound", "NoSuchKey":
+		return true
+	}
+	return false
+}
+
+// s3Error reports missing objects as fs.ErrNotExist, like Local does.
+func s3Error(op, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if isNotFound(err) {
+		err = fs.ErrNotExist
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// s3File reads an object with ranged GETs, starting a new one after
+// every Seek that moves the offset.
+type s3File struct {
+	storage *S3
+	ctx     context.Context
+	key     string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+}
+
+func (f *s3File) Read(p []byte) (int, error) {
+	if f.offset >= f.size {
+		return 0, io.EOF
+	}
+	if f.body == nil {
+		object, err := f.storage.client.GetObject(f.ctx, &s3.GetObjectInput{
+			Bucket: &f.storage.bucket,
+			Key:    &f.key,
+			Range:  aws.String(fmt.Sprintf("bytes=%d-", f.offset)),
+		})
+		if err != nil {
+			return 0, s3Error("read", f.key, err)
+		}
+		f.body = object.Body
+	}
+	n, err := f.body.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *s3File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.size
+	}
+	if offset < 0 {
+		return 0, errors.New("storage: negative offset")
+	}
+	if offset != f.offset && f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *s3File) Close() error {
+	if f.body == nil {
+		return nil
+	}
+	return f.body.Close()
+}
+
+// s3Writer sends every full part as soon as it is written. Files smaller
+// than one part are sent with a single PUT on Close instead.
+type s3Writer struct {
+	storage *S3
+	ctx     context.Context
+	key     string
+	buffer  []byte
+
+	uploadID string
+	number   int32
+	slots    chan struct{}
+	wait     sync.WaitGroup
+
+	mu    sync.Mutex
+	parts []types.CompletedPart
+	err   error
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	if err := w.failed(); err != nil {
+		return 0, err
+	}
+	written := len(p)
+	for len(p) > 0 {
+		if w.buffer == nil {
+			w.buffer = make([]byte, 0, w.storage.partSize)
+		}
+		n := min(len(p), w.storage.partSize-len(w.buffer))
+		w.buffer = append(w.buffer, p[:n]...)
+		p = p[n:]
+		if len(w.buffer) == w.storage.partSize {
+			if err := w.sendPart(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// sendPart starts uploading the buffered part, blocking while
+// Concurrency parts are already on their way.
+func (w *s3Writer) sendPart() error {
+	if w.uploadID == "" {
+		created, err := w.storage.client.CreateMultipartUpload(w.ctx, &s3.CreateMultipartUploadInput{
+			Bucket: &w.storage.bucket,
+			Key:    &w.key,
+		})
+		if err != nil {
+			return s3Error("create", w.key, err)
+		}
+		w.uploadID = aws.ToString(created.UploadId)
+	}
+
+	w.number++
+	number, part := w.number, w.buffer
+	w.buffer = nil
+
+	w.slots <- struct{}{}
+	w.wait.Add(1)
+	go func() {
+		defer func() {
+			<-w.slots
+			w.wait.Done()
+		}()
+		uploaded, err := w.storage.client.UploadPart(w.ctx, &s3.UploadPartInput{
+			Bucket:        &w.storage.bucket,
+			Key:           &w.key,
+			UploadId:      &w.uploadID,
+			PartNumber:    aws.Int32(number),
+			Body:          bytes.NewReader(part),
+			ContentLength: aws.Int64(int64(len(part))),
+		})
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if err != nil {
+			if w.err == nil {
+				w.err = s3Error("write", w.key, err)
+			}
+			return
+		}
+		w.parts = append(w.parts, types.CompletedPart{ETag: uploaded.ETag, PartNumber: aws.Int32(number)})
+	}()
+	return w.failed()
+}
+
+func (w *s3Writer) failed() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close finishes the upload, aborting it when a part failed.
+func (w *s3Writer) Close() error {
+	if w.uploadID == "" {
+		_, err := w.storage.client.PutObject(w.ctx, &s3.PutObjectInput{
+			Bucket:        &w.storage.bucket,
+			Key:           &w.key,
+			Body:          bytes.NewReader(w.buffer),
+			ContentLength: aws.Int64(int64(len(w.buffer))),
+		})
+		return s3Error("write", w.key, err)
+	}
+
+	var err error
+	if len(w.buffer) > 0 {
+		err = w.sendPart()
+	}
+	w.wait.Wait()
+	if err == nil {
+		err = w.failed()
+	}
+	if err != nil {
+		w.abort()
+		return err
+	}
+
+	sort.Slice(w.parts, func(i, j int) bool {
+		return aws.ToInt32(w.parts[i].PartNumber) < aws.ToInt32(w.parts[j].PartNumber)
+	})
+	_, err = w.storage.client.CompleteMultipartUpload(w.ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &w.storage.bucket,
+		Key:             &w.key,
+		UploadId:        &w.uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: w.parts},
+	})
+	if err != nil {
+		w.abort()
+	}
+	return s3Error("write", w.key, err)
+}
+
+// CloseWithError discards the upload: nothing written is kept and the
+// parts already sent are deleted.
+func (w *s3Writer) CloseWithError(cause error) error {
+	w.wait.Wait()
+	if w.uploadID == "" {
+		return nil
+	}
+	return w.abort()
+}
+
+// abort runs even when the request context is done, so no parts are
+// left behind to be billed.
+func (w *s3Writer) abort() error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(w.ctx), 30*time.Second)
+	defer cancel()
+	_, err := w.storage.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &w.storage.bucket,
+		Key:      &w.key,
+		UploadId: &w.uploadID,
+	})
+	return s3Error("abort", w.key, err)
+}
+
+// s3Info describes an object or a key prefix.
+type s3Info struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i s3Info) Name() string       { return i.name }
+func (i s3Info) Size() int64        { return i.size }
+func (i s3Info) ModTime() time.Time { return i.modTime }
+func (i s3Info) IsDir() bool        { return i.dir }
+func (i s3Info) Sys() any           { return nil }
+
+func (i s3Info) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 keeps objects and multipart uploads in memory.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	uploads  map[string]map[int32][]byte
+	aborted  int
+	inFlight int
+	peak     int
+	failPart int32
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}, uploads: map[string]map[int32][]byte{}}
+}
+
+var errNoSuchKey = &smithy.GenericAPIError{Code: "NoSuchKey"}
+
+func (f *fakeS3) GetObject(ctx context.Context, input *s3.GetObjectInput, options ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, ok := f.objects[*input.Key]
+	if !ok {
+		return nil, errNoSuchKey
+	}
+	var offset int
+	if input.Range != nil {
+		offset = int(parseRange(*input.Range))
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(content[offset:]))}, nil
+}
+
+func parseRange(value string) int64 {
+	var offset int64
+	for _, c := range strings.TrimSuffix(strings.TrimPrefix(value, "bytes="), "-") {
+		offset = offset*10 + int64(c-'0')
+	}
+	return offset
+}
+
+func (f *fakeS3) HeadObject(ctx context.Context, input *s3.HeadObjectInput, options ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, ok := f.objects[*input.Key]
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: "NotFound"}
+	}
+	return &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(len(content))), LastModified: aws.Time(time.Now())}, nil
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, input *s3.PutObjectInput, options ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	content, _ := io.ReadAll(input.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[*input.Key] = content
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) CopyObject(ctx context.Context, input *s3.CopyObjectInput, options ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	source := strings.TrimPrefix(*input.CopySource, *input.Bucket+"/")
+	content, ok := f.objects[strings.ReplaceAll(source, "%20", " ")]
+	if !ok {
+		return nil, errNoSuchKey
+	}
+	f.objects[*input.Key] = content
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, input *s3.DeleteObjectInput, options ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, *input.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *fakeS3) ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, options ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	output := &s3.ListObjectsV2Output{}
+	prefixes := map[string]bool{}
+	for key, content := range f.objects {
+		rest, ok := strings.CutPrefix(key, aws.ToString(input.Prefix))
+		if !ok {
+			continue
+		}
+		if dir, _, nested := strings.Cut(rest, "/"); nested && input.Delimiter != nil {
+			prefixes[aws.ToString(input.Prefix)+dir+"/"] = true
+			continue
+		}
+		output.Contents = append(output.Contents, types.Object{Key: aws.String(key), Size: aws.Int64(int64(len(content)))})
+	}
+	for prefix := range prefixes {
+		output.CommonPrefixes = append(output.CommonPrefixes, types.CommonPrefix{Prefix: aws.String(prefix)})
+	}
+	return output, nil
+}
+
+func (f *fakeS3) CreateMultipartUpload(ctx context.Context, input *s3.CreateMultipartUploadInput, options ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploads[*input.Key] = map[int32][]byte{}
+	return &s3.CreateMultipartUploadOutput{UploadId: input.Key}, nil
+}
+
+func (f *fakeS3) UploadPart(ctx context.Context, input *s3.UploadPartInput, options ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	f.mu.Lock()
+	f.inFlight++
+	f.peak = max(f.peak, f.inFlight)
+	f.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+
+	content, _ := io.ReadAll(input.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inFlight--
+	if *input.PartNumber == f.failPart {
+		return nil, errors.New("connection reset")
+	}
+	f.uploads[*input.UploadId][*input.PartNumber] = content
+	return &s3.UploadPartOutput{ETag: aws.String("etag")}, nil
+}
+
+func (f *fakeS3) CompleteMultipartUpload(ctx context.Context, input *s3.CompleteMultipartUploadInput, options ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var content []byte
+	for i, part := range input.MultipartUpload.Parts {
+		if *part.PartNumber != int32(i+1) {
+			return nil, errors.New("parts out of order")
+		}
+		content = append(content, f.uploads[*input.UploadId][*part.PartNumber]...)
+	}
+	delete(f.uploads, *input.UploadId)
+	f.objects[*input.Key] = content
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeS3) AbortMultipartUpload(ctx context.Context, input *s3.AbortMultipartUploadInput, options ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.uploads, *input.UploadId)
+	f.aborted++
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func TestS3MultipartUpload(t *testing.T) {
+	client := newFakeS3()
+	store := newS3(client, S3Config{Bucket: "files", Prefix: "uploads", Concurrency: 2})
+
+	content := bytes.Repeat([]byte("0123456789"), 2*MinPartSize/10+7)
+	writer, err := store.Create(context.Background(), "a/besar.bin")
+	assert.Nil(t, err)
+	// Written in small pieces, like io.Copy from a request body.
+	_, err = io.CopyBuffer(writer, bytes.NewReader(content), make([]byte, 32*1024))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+
+	assert.Equal(t, content, client.objects["uploads/a/besar.bin"])
+	assert.Empty(t, client.uploads)
+	assert.Equal(t, 2, client.peak)
+
+	file, err := store.Open(context.Background(), "a/besar.bin")
+	assert.Nil(t, err)
+	defer file.Close()
+	_, err = file.Seek(int64(len(content)-7), io.SeekStart)
+	assert.Nil(t, err)
+	tail, err := io.ReadAll(file)
+	assert.Nil(t, err)
+	assert.Equal(t, content[len(content)-7:], tail)
+}
+
+func TestS3UploadAbortsOnFailure(t *testing.T) {
+	client := newFakeS3()
+	client.failPart = 2
+	store := newS3(client, S3Config{Bucket: "files", PartSize: MinPartSize})
+
+	writer, err := store.Create(context.Background(), "gagal.bin")
+	assert.Nil(t, err)
+	writer.Write(make([]byte, 3*MinPartSize))
+	assert.NotNil(t, writer.Close())
+	assert.Empty(t, client.uploads)
+	assert.Equal(t, 1, client.aborted)
+	assert.NotContains(t, client.objects, "gagal.bin")
+
+	writer, err = store.Create(context.Background(), "batal.bin")
+	assert.Nil(t, err)
+	writer.Write(make([]byte, MinPartSize))
+	assert.Nil(t, writer.(Aborter).CloseWithError(errors.New("client went away")))
+	assert.Empty(t, client.uploads)
+	assert.Equal(t, 2, client.aborted)
+}
+
+func TestS3SmallFilesAndDirectories(t *testing.T) {
+	client := newFakeS3()
+	store := newS3(client, S3Config{Bucket: "files"})
+	ctx := context.Background()
+
+	writer, err := store.Create(ctx, "../docs/contoh.txt")
+	assert.Nil(t, err)
+	writer.Write([]byte("this is sample file for upload"))
+	assert.Nil(t, writer.Close())
+	assert.Equal(t, "this is sample file for upload", string(client.objects["docs/contoh.txt"]))
+	assert.Nil(t, store.Mkdir(ctx, "kosong"))
+
+	info, err := store.Stat(ctx, "docs")
+	assert.Nil(t, err)
+	assert.True(t, info.IsDir())
+	_, err = store.Stat(ctx, "docs/tidak-ada.txt")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	infos, err := store.List(ctx, "/")
+	assert.Nil(t, err)
+	assert.Len(t, infos, 2)
+
+	assert.Nil(t, store.Rename(ctx, "docs/contoh.txt", "docs/contoh lama.txt"))
+	infos, err = store.List(ctx, "docs")
+	assert.Nil(t, err)
+	assert.Len(t, infos, 1)
+	assert.Equal(t, "contoh lama.txt", infos[0].Name())
+	assert.Equal(t, int64(30), infos[0].Size())
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+)
+
+// ErrInvalidPath is returned for names escaping the storage root.
+var ErrInvalidPath = errors.New("storage: invalid path")
+
+// File is an opened, readable object.
+type File interface {
+	io.ReadSeekCloser
+}
+
+// Aborter is implemented by writers returned from Create that can
+// discard what was written instead of keeping it, such as S3 multipart
+// uploads. Callers giving up on a write use CloseWithError instead of
+// Close.
+type Aborter interface {
+	CloseWithError(err error) error
+}
+
+// Storage is the file backend shared by uploads, downloads and WebDAV.
+// Names are slash separated and relative to the storage root.
+type Storage interface {
+	Open(ctx context.Context, name string) (File, error)
+	Create(ctx context.Context, name string) (io.WriteCloser, error)
+	Stat(ctx context.Context, name string) (fs.FileInfo, error)
+	List(ctx context.Context, dir string) ([]fs.FileInfo, error)
+	Mkdir(ctx context.Context, name string) error
+	Rename(ctx context.Context, from, to string) error
+	Remove(ctx context.Context, name string) error
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+// WebDAVMethods must be added to fiber.Config.RequestMethods so the router
+// accepts WebDAV verbs.
+var WebDAVMethods = []string{"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK"}
+
+var errUnsupported = errors.New("storage: unsupported operation")
+
+// WebDAV adapts a Storage to the webdav.FileSystem interface.
+func WebDAV(store Storage) webdav.FileSystem {
+	return davFS{store: store}
+}
+
+type davFS struct {
+	store Storage
+}
+
+func (d davFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	return d.store.Mkdir(ctx, name)
+}
+
+func (d davFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+		writer, err := d.store.Create(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return &davWriter{name: path.Base(name), writer: writer, modTime: time.Now()}, nil
+	}
+
+	info, err := d.store.Stat(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &davDir{ctx: ctx, store: d.store, name: name, info: info}, nil
+	}
+
+	file, err := d.store.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &davFile{File: file, info: info}, nil
+}
+
+func (d davFS) RemoveAll(ctx context.Context, name string) error {
+	return d.store.Remove(ctx, name)
+}
+
+func (d davFS) Rename(ctx context.Context, oldName, newName string) error {
+	return d.store.Rename(ctx, oldName, newName)
+}
+
+func (d davFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	return d.store.Stat(ctx, name)
+}
+
+type davFile struct {
+	File
+	info fs.FileInfo
+}
+
+func (f *davFile) Readdir(count int) ([]fs.FileInfo, error) { return nil, errUnsupported }
+func (f *davFile) Stat() (fs.FileInfo, error)               { return f.info, nil }
+func (f *davFile) Write(p []byte) (int, error)              { return 0, errUnsupported }
+
+type davDir struct {
+	ctx   context.Context
+	store Storage
+	name  string
+	info  fs.FileInfo
+}
+
+func (d *davDir) Close() error                                 { return nil }
+func (d *davDir) Read(p []byte) (int, error)                   { return 0, errUnsupported }
+func (d *davDir) Seek(offset int64, whence int) (int64, error) { return 0, errUnsupported }
+func (d *davDir) Write(p []byte) (int, error)                  { return 0, errUnsupported }
+func (d *davDir) Stat() (fs.FileInfo, error)                   { return d.info, nil }
+
+func (d *davDir) Readdir(count int) ([]fs.FileInfo, error) {
+	infos, err := d.store.List(d.ctx, d.name)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 && len(infos) > count {
+		infos = infos[:count]
+	}
+	return infos, nil
+}
+
+type davWriter struct {
+	name    string
+	writer  io.WriteCloser
+	size    int64
+	modTime time.Time
+}
+
+func (w *davWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *davWriter) Close() error                                 { return w.writer.Close() }
+func (w *davWriter) Read(p []byte) (int, error)                   { return 0, errUnsupported }
+func (w *davWriter) Seek(offset int64, whence int) (int64, error) { return w.size, nil }
+func (w *davWriter) Readdir(count int) ([]fs.FileInfo, error)     { return nil, errUnsupported }
+func (w *davWriter) Stat() (fs.FileInfo, error)                   { return writtenInfo{w}, nil }
+
+type writtenInfo struct {
+	w *davWriter
+}
+
+func (i writtenInfo) Name() string       { return i.w.name }
+func (i writtenInfo) Size() int64        { return i.w.size }
+func (i writtenInfo) Mode() fs.FileMode  { return 0o644 }
+func (i writtenInfo) ModTime() time.Time { return i.w.modTime }
+func (i writtenInfo) IsDir() bool        { return false }
+func (i writtenInfo) Sys() interface{}   { return nil }
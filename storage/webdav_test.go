@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/webdav"
+)
+
+func TestWebDAV(t *testing.T) {
+	app := fiber.New(fiber.Config{
+		RequestMethods: append(append([]string{}, fiber.DefaultMethods...), WebDAVMethods...),
+	})
+	app.Use("/dav", adaptor.HTTPHandler(&webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: WebDAV(NewLocal(t.TempDir())),
+		LockSystem: webdav.NewMemLS(),
+	}))
+
+	request := httptest.NewRequest("MKCOL", "/dav/docs", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+
+	request = httptest.NewRequest("PUT", "/dav/docs/contoh.txt", strings.NewReader("this is sample file for upload"))
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+
+	request = httptest.NewRequest("PROPFIND", "/dav/docs", nil)
+	request.Header.Set("Depth", "1")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 207, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Contains(t, string(bytes), "/dav/docs/contoh.txt")
+
+	request = httptest.NewRequest("MOVE", "/dav/docs/contoh.txt", nil)
+	request.Header.Set("Destination", "http://example.com/dav/docs/moved.txt")
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+
+	request = httptest.NewRequest("GET", "/dav/docs/moved.txt", nil)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "this is sample file for upload", string(bytes))
+}
+
+func TestLocalPathStaysInRoot(t *testing.T) {
+	local := NewLocal("/srv/files")
+
+	p, err := local.Path("../../etc/passwd")
+	assert.Nil(t, err)
+	assert.Equal(t, "/srv/files/etc/passwd", p)
+
+	p, err = local.Path(`..\..\secret`)
+	assert.Nil(t, err)
+	assert.Equal(t, "/srv/files/secret", p)
+}
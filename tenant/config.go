@@ -0,0 +1,54 @@
+package tenant
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Store holds the tenants requests may resolve to.
+	//
+	// Required.
+	Store Store
+
+	// BaseDomain is the domain whose subdomains name tenants, e.g.
+	// "example.com" for acme.example.com. Empty resolves no subdomains.
+	//
+	// Optional. Default: ""
+	BaseDomain string
+
+	// Header names the tenant, for API clients on a shared host.
+	//
+	// Optional. Default: "X-Tenant-ID"
+	Header string
+
+	// Required answers 400 Bad Request to requests resolving to no tenant.
+	//
+	// Optional. Default: false
+	Required bool
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Header: "X-Tenant-ID",
+}
+
+func configDefault(config ...Config) Config {
+	cfg := ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Store == nil {
+		panic("tenant: Store is required")
+	}
+	if cfg.Header == "" {
+		cfg.Header = ConfigDefault.Header
+	}
+	cfg.BaseDomain = strings.TrimPrefix(strings.ToLower(cfg.BaseDomain), ".")
+	return cfg
+}
@@ -0,0 +1,135 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"belajar-golang-fiber/binding"
+	"belajar-golang-fiber/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler manages tenants in the admin area. Deleting a tenant keeps its
+// schema and data.
+type Handler struct {
+	Store Store
+	// Usage, when set, reports the quotas of a tenant at /:id/usage.
+	Usage func(ctx context.Context, tenant *Tenant) (Usage, error)
+}
+
+// Register mounts the routes on router, e.g. adminApp.Group("/tenants").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/", h.list)
+	router.Post("/", h.create)
+	router.Get("/:id", h.get)
+	if h.Usage != nil {
+		router.Get("/:id/usage", h.usage)
+	}
+	router.Delete("/:id", h.remove)
+}
+
+type createRequest struct {
+	ID       string            `json:"id" form:"id"`
+	Name     string            `json:"name" form:"name"`
+	Schema   string            `json:"schema" form:"schema"`
+	Settings map[string]string `json:"settings"`
+}
+
+func (h *Handler) list(ctx *fiber.Ctx) error {
+	tenants, err := h.Store.List(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(tenants)
+}
+
+// create answers 201 with the tenant, or 422 with the field found invalid.
+// The schema defaults to DefaultSchema of the id.
+func (h *Handler) create(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[createRequest](ctx)
+	if err != nil {
+		return err
+	}
+	if !ValidID(request.ID) {
+		return invalid(ctx, "id", "must be lower case letters, digits and hyphens")
+	}
+	if request.Schema == "" {
+		request.Schema = DefaultSchema(request.ID)
+	}
+	if !database.ValidSchema(request.Schema) {
+		return invalid(ctx, "schema", "must be lower case letters, digits and underscores, starting with a letter")
+	}
+	if request.Name == "" {
+		request.Name = request.ID
+	}
+	for _, key := range []string{SettingRequests, SettingStorageBytes, SettingStorageFiles} {
+		if value, ok := request.Settings[key]; ok {
+			if n, err := strconv.ParseInt(value, 10, 64); err != nil || n < 0 {
+				return invalid(ctx, "settings", key+" must be a number of zero or more")
+			}
+		}
+	}
+
+	tenant := &Tenant{
+		ID:        request.ID,
+		Name:      request.Name,
+		Schema:    request.Schema,
+		Settings:  request.Settings,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = h.Store.Create(ctx.UserContext(), tenant)
+	if errors.Is(err, ErrTaken) {
+		return invalid(ctx, "id", "is taken")
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(tenant)
+}
+
+func (h *Handler) get(ctx *fiber.Ctx) error {
+	tenant, err := h.Store.Get(ctx.UserContext(), ctx.Params("id"))
+	if errors.Is(err, ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(tenant)
+}
+
+func (h *Handler) usage(ctx *fiber.Ctx) error {
+	tenant, err := h.Store.Get(ctx.UserContext(), ctx.Params("id"))
+	if errors.Is(err, ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	usage, err := h.Usage(ctx.UserContext(), tenant)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(usage)
+}
+
+func (h *Handler) remove(ctx *fiber.Ctx) error {
+	err := h.Store.Delete(ctx.UserContext(), ctx.Params("id"))
+	if errors.Is(err, ErrNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func invalid(ctx *fiber.Ctx, field, message string) error {
+	return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error": field + ": " + message,
+		"field": field,
+	})
+}
@@ -0,0 +1,44 @@
+package tenant
+
+import (
+	"context"
+	"sync"
+)
+
+// Partitioned holds a value, such as a set of repositories, per schema:
+// Shared outside of tenants and one opened on first use for each tenant
+// schema.
+type Partitioned[T any] struct {
+	Shared T
+	open   func(ctx context.Context, schema string) (T, error)
+
+	mu      sync.Mutex
+	schemas map[string]T
+}
+
+// NewPartitioned returns the partitions of shared, opening those of
+// tenant schemas with open.
+func NewPartitioned[T any](shared T, open func(ctx context.Context, schema string) (T, error)) *Partitioned[T] {
+	return &Partitioned[T]{Shared: shared, open: open, schemas: map[string]T{}}
+}
+
+// For returns the partition of the tenant schema carried by ctx.
+func (p *Partitioned[T]) For(ctx context.Context) (T, error) {
+	schema := Schema(ctx)
+	if schema == "" {
+		return p.Shared, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if value, ok := p.schemas[schema]; ok {
+		return value, nil
+	}
+	// Opening may outlive the request that asked first.
+	value, err := p.open(context.WithoutCancel(ctx), schema)
+	if err != nil {
+		return value, err
+	}
+	p.schemas[schema] = value
+	return value, nil
+}
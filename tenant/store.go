@@ -0,0 +1,77 @@
+package tenant
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"sync"
+)
+
+// Store persists tenants, keyed by id.
+type Store interface {
+	// Create fails with ErrTaken when the id exists.
+	Create(ctx context.Context, tenant *Tenant) error
+	Get(ctx context.Context, id string) (*Tenant, error)
+	// List returns every tenant, sorted by id.
+	List(ctx context.Context) ([]*Tenant, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore keeps tenants in process memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	tenants map[string]*Tenant
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tenants: map[string]*Tenant{}}
+}
+
+func (s *MemoryStore) Create(ctx context.Context, tenant *Tenant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tenants[tenant.ID]; ok {
+		return ErrTaken
+	}
+	s.tenants[tenant.ID] = clone(tenant)
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Tenant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tenant, ok := s.tenants[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(tenant), nil
+}
+
+func (s *MemoryStore) List(ctx context.Context) ([]*Tenant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tenants := make([]*Tenant, 0, len(s.tenants))
+	for _, tenant := range s.tenants {
+		tenants = append(tenants, clone(tenant))
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].ID < tenants[j].ID
+	})
+	return tenants, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tenants[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.tenants, id)
+	return nil
+}
+
+func clone(tenant *Tenant) *Tenant {
+	copied := *tenant
+	copied.Settings = maps.Clone(tenant.Settings)
+	return &copied
+}
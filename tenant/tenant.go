@@ -0,0 +1,184 @@
+// Package tenant lets one deployment serve several tenants. Requests are
+// resolved to a tenant from their custom domain, a subdomain of the base
+// domain or the X-Tenant-ID header; the tenant then travels in the request
+// context, where repositories read the schema its data is kept in and
+// handlers its settings.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+var (
+	// ErrNotFound is returned when no tenant has the given id.
+	ErrNotFound = errors.New("tenant: not found")
+	// ErrTaken is returned when creating a tenant whose id exists.
+	ErrTaken = errors.New("tenant: id already taken")
+)
+
+// LocalsKey is the ctx.Locals key holding the tenant id, the one
+// domains.Middleware sets for custom domains.
+const LocalsKey = "tenant"
+
+// Tenant is an organization whose data is kept apart from the others'.
+type Tenant struct {
+	// ID is a DNS label, e.g. "acme" served at acme.<base domain>.
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Schema is the database schema or table prefix of the tenant's
+	// data; see database.DB.Schema.
+	Schema string `json:"schema"`
+	// Settings override the configuration for the tenant.
+	Settings  map[string]string `json:"settings,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Setting returns the setting key of the tenant, or fallback when unset.
+func (t *Tenant) Setting(key, fallback string) string {
+	if value, ok := t.Settings[key]; ok {
+		return value
+	}
+	return fallback
+}
+
+// IntSetting returns the setting key of the tenant as a number, or
+// fallback when unset or not a number.
+func (t *Tenant) IntSetting(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(t.Settings[key], 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// Settings of tenants' quotas, overriding the deployment's.
+const (
+	SettingRequests     = "quota_requests"
+	SettingStorageBytes = "quota_storage_bytes"
+	SettingStorageFiles = "quota_storage_files"
+)
+
+// Usage is what a tenant used of its quotas.
+type Usage struct {
+	Requests     Counter `json:"requests"`
+	StorageBytes Counter `json:"storage_bytes"`
+	StorageFiles Counter `json:"storage_files"`
+}
+
+// Counter is the use of one quota. A Limit of zero is unlimited; Reset is
+// when a quota counted per period starts again.
+type Counter struct {
+	Used  int64      `json:"used"`
+	Limit int64      `json:"limit"`
+	Reset *time.Time `json:"reset,omitempty"`
+}
+
+var idPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidID reports whether id is a lower case DNS label.
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// DefaultSchema is the schema of a tenant created without one.
+func DefaultSchema(id string) string {
+	return "tenant_" + strings.ReplaceAll(id, "-", "_")
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying tenant.
+func NewContext(ctx context.Context, tenant *Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenant)
+}
+
+// FromContext returns the tenant carried by ctx, or nil.
+func FromContext(ctx context.Context) *Tenant {
+	tenant, _ := ctx.Value(contextKey{}).(*Tenant)
+	return tenant
+}
+
+// Schema returns the schema of the tenant carried by ctx, or "" for the
+// shared one.
+func Schema(ctx context.Context) string {
+	if tenant := FromContext(ctx); tenant != nil {
+		return tenant.Schema
+	}
+	return ""
+}
+
+// From returns the tenant New resolved for the request, or nil.
+func From(c *fiber.Ctx) *Tenant {
+	return FromContext(c.UserContext())
+}
+
+// New creates a middleware resolving the tenant of requests. A tenant
+// named by two sources must be the same one, and must exist.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		var id string
+		for _, candidate := range []string{
+			// Custom domains are resolved by domains.Middleware.
+			stringLocal(c, LocalsKey),
+			subdomain(c.Hostname(), cfg.BaseDomain),
+			utils.CopyString(c.Get(cfg.Header)),
+		} {
+			if candidate == "" {
+				continue
+			}
+			if id != "" && candidate != id {
+				return fiber.NewError(fiber.StatusBadRequest, "conflicting tenants")
+			}
+			id = candidate
+		}
+		if id == "" {
+			if cfg.Required {
+				return fiber.NewError(fiber.StatusBadRequest, "no tenant")
+			}
+			return c.Next()
+		}
+
+		tenant, err := cfg.Store.Get(c.UserContext(), id)
+		if errors.Is(err, ErrNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, "unknown tenant")
+		}
+		if err != nil {
+			return err
+		}
+		c.Locals(LocalsKey, tenant.ID)
+		c.SetUserContext(NewContext(c.UserContext(), tenant))
+		return c.Next()
+	}
+}
+
+func stringLocal(c *fiber.Ctx, key string) string {
+	value, _ := c.Locals(key).(string)
+	return value
+}
+
+// subdomain returns the label host has below base, e.g. "acme" for
+// acme.example.com, or "" for base itself and other hosts.
+func subdomain(host, base string) string {
+	if base == "" {
+		return ""
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+base)
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
@@ -0,0 +1,130 @@
+package tenant
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApp(t *testing.T, config Config) *fiber.App {
+	store := NewMemoryStore()
+	assert.Nil(t, store.Create(context.Background(), &Tenant{ID: "acme", Schema: "tenant_acme", Settings: map[string]string{"language": "en"}}))
+	assert.Nil(t, store.Create(context.Background(), &Tenant{ID: "globex", Schema: "tenant_globex"}))
+	config.Store = store
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if domain := c.Get("X-Custom-Domain-Tenant"); domain != "" {
+			c.Locals(LocalsKey, domain)
+		}
+		return c.Next()
+	})
+	app.Use(New(config))
+	app.Get("/", func(c *fiber.Ctx) error {
+		tenant := From(c)
+		if tenant == nil {
+			return c.SendString("shared")
+		}
+		return c.SendString(Schema(c.UserContext()) + " " + tenant.Setting("language", "id"))
+	})
+	return app
+}
+
+func TestResolve(t *testing.T) {
+	app := newApp(t, Config{BaseDomain: "example.com"})
+	for _, test := range []struct {
+		host, header, domain string
+		status               int
+		body                 string
+	}{
+		{host: "example.com", status: 200, body: "shared"},
+		{host: "acme.example.com", status: 200, body: "tenant_acme en"},
+		{host: "example.com", header: "globex", status: 200, body: "tenant_globex id"},
+		{host: "shop.acme.test", domain: "acme", status: 200, body: "tenant_acme en"},
+		{host: "acme.example.com", header: "acme", status: 200, body: "tenant_acme en"},
+		{host: "acme.example.com", header: "globex", status: 400},
+		{host: "initech.example.com", status: 404},
+		{host: "a.acme.example.com", status: 200, body: "shared"},
+	} {
+		request := httptest.NewRequest("GET", "http://"+test.host+"/", nil)
+		if test.header != "" {
+			request.Header.Set("X-Tenant-ID", test.header)
+		}
+		if test.domain != "" {
+			request.Header.Set("X-Custom-Domain-Tenant", test.domain)
+		}
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, test.status, response.StatusCode, test)
+		if test.body != "" {
+			body, _ := io.ReadAll(response.Body)
+			assert.Equal(t, test.body, string(body), test)
+		}
+	}
+
+	response, _ := newApp(t, Config{Required: true}).Test(httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestPartitioned(t *testing.T) {
+	opened := 0
+	partitions := NewPartitioned("shared", func(ctx context.Context, schema string) (string, error) {
+		opened++
+		return strings.ToUpper(schema), nil
+	})
+
+	value, _ := partitions.For(context.Background())
+	assert.Equal(t, "shared", value)
+	acme := NewContext(context.Background(), &Tenant{ID: "acme", Schema: "tenant_acme"})
+	value, _ = partitions.For(acme)
+	assert.Equal(t, "TENANT_ACME", value)
+	partitions.For(acme)
+	assert.Equal(t, 1, opened)
+}
+
+func TestHandler(t *testing.T) {
+	app := fiber.New()
+	handler := &Handler{
+		Store: NewMemoryStore(),
+		Usage: func(ctx context.Context, tenant *Tenant) (Usage, error) {
+			return Usage{Requests: Counter{Used: 3, Limit: tenant.IntSetting(SettingRequests, 0)}}, nil
+		},
+	}
+	handler.Register(app.Group("/tenants"))
+
+	request := httptest.NewRequest("POST", "/tenants", strings.NewReader(`{"id":"acme-corp","settings":{"language":"en","quota_requests":"100"}}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+	body, _ := io.ReadAll(response.Body)
+	assert.Contains(t, string(body), `"schema":"tenant_acme_corp"`)
+
+	for input, field := range map[string]string{
+		`{"id":"Acme"}`:                                      "id",
+		`{"id":"acme-corp"}`:                                 "id",
+		`{"id":"acme","schema":"acme; DROP"}`:                "schema",
+		`{"id":"acme","settings":{"quota_requests":"many"}}`: "settings",
+	} {
+		request := httptest.NewRequest("POST", "/tenants", strings.NewReader(input))
+		request.Header.Set("Content-Type", "application/json")
+		response, _ := app.Test(request)
+		assert.Equal(t, 422, response.StatusCode, input)
+		body, _ := io.ReadAll(response.Body)
+		assert.Contains(t, string(body), `"field":"`+field+`"`, input)
+	}
+
+	response, _ = app.Test(httptest.NewRequest("GET", "/tenants/acme-corp/usage", nil))
+	body, _ = io.ReadAll(response.Body)
+	assert.Equal(t, `{"requests":{"used":3,"limit":100},"storage_bytes":{"used":0,"limit":0},"storage_files":{"used":0,"limit":0}}`, string(body))
+
+	response, _ = app.Test(httptest.NewRequest("DELETE", "/tenants/acme-corp", nil))
+	assert.Equal(t, 204, response.StatusCode)
+	response, _ = app.Test(httptest.NewRequest("GET", "/tenants/acme-corp", nil))
+	assert.Equal(t, 404, response.StatusCode)
+}
@@ -0,0 +1,173 @@
+package terms
+
+import (
+	"errors"
+
+	"belajar-golang-fiber/binding"
+	"belajar-golang-fiber/middleware/realip"
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Require keeps signed in users who have not accepted the latest terms
+// out: pages get redirected to reviewURL with ?version=, other requests
+// 403 naming the version. Anonymous requests and those next returns true
+// for, such as the terms routes themselves, pass.
+func (s *Service) Require(reviewURL string, next func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		userID := session.UserID(ctx)
+		if userID == "" || (next != nil && next(ctx)) {
+			return ctx.Next()
+		}
+		pending, err := s.Pending(ctx.UserContext(), userID)
+		if err != nil {
+			return err
+		}
+		if pending == nil {
+			return ctx.Next()
+		}
+		if ctx.Method() == fiber.MethodGet && ctx.Accepts(fiber.MIMETextHTML, fiber.MIMEApplicationJSON) == fiber.MIMETextHTML {
+			return ctx.Redirect(reviewURL+"?version="+pending.Version, fiber.StatusSeeOther)
+		}
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   "the terms of service must be accepted",
+			"version": pending.Version,
+		})
+	}
+}
+
+// Handler serves the terms to users and records their acceptance.
+type Handler struct {
+	Service *Service
+}
+
+// Register mounts the routes on router, e.g. app.Group("/api/v1/terms").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/", h.latest)
+	router.Get("/versions", h.versions)
+	router.Get("/versions/:version", h.version)
+	router.Post("/accept", session.Require(), h.accept)
+	router.Get("/acceptances", session.Require(), h.acceptances)
+}
+
+func (h *Handler) latest(ctx *fiber.Ctx) error {
+	document, err := h.Service.Latest(ctx.UserContext())
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.JSON(document)
+}
+
+func (h *Handler) versions(ctx *fiber.Ctx) error {
+	documents, err := h.Service.Store.Documents(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(documents)
+}
+
+func (h *Handler) version(ctx *fiber.Ctx) error {
+	documents, err := h.Service.Store.Documents(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	for _, document := range documents {
+		if document.Version == ctx.Params("version") {
+			return ctx.JSON(document)
+		}
+	}
+	return answerError(ctx, ErrNotFound)
+}
+
+type acceptRequest struct {
+	Version string `json:"version" form:"version"`
+}
+
+func (h *Handler) accept(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[acceptRequest](ctx)
+	if err != nil {
+		return err
+	}
+	acceptance, err := h.Service.Accept(ctx.UserContext(), Acceptance{
+		UserID:    session.UserID(ctx),
+		Version:   request.Version,
+		IP:        realip.IP(ctx),
+		UserAgent: utils.CopyString(ctx.Get(fiber.HeaderUserAgent)),
+	})
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.JSON(acceptance)
+}
+
+// acceptances answers the history of the signed in user.
+func (h *Handler) acceptances(ctx *fiber.Ctx) error {
+	acceptances, err := h.Service.Store.Acceptances(ctx.UserContext(), Filter{UserID: session.UserID(ctx)})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(acceptances)
+}
+
+// AdminHandler publishes versions and queries acceptances in the admin
+// area.
+type AdminHandler struct {
+	Service *Service
+}
+
+// Register mounts the routes on router, e.g. adminApp.Group("/terms").
+func (h *AdminHandler) Register(router fiber.Router) {
+	router.Get("/", h.list)
+	router.Post("/", h.publish)
+	router.Get("/acceptances", h.acceptances)
+}
+
+func (h *AdminHandler) list(ctx *fiber.Ctx) error {
+	documents, err := h.Service.Store.Documents(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(documents)
+}
+
+func (h *AdminHandler) publish(ctx *fiber.Ctx) error {
+	document, err := binding.Bind[Document](ctx)
+	if err != nil {
+		return err
+	}
+	published, err := h.Service.Publish(ctx.UserContext(), *document)
+	if err != nil {
+		return answerError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(published)
+}
+
+// acceptances answers the acceptances of ?user_id= and ?version=.
+func (h *AdminHandler) acceptances(ctx *fiber.Ctx) error {
+	acceptances, err := h.Service.Store.Acceptances(ctx.UserContext(), Filter{
+		UserID:  ctx.Query("user_id"),
+		Version: ctx.Query("version"),
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(acceptances)
+}
+
+func answerError(ctx *fiber.Ctx, err error) error {
+	var invalid *ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": invalid.Error(),
+			"field": invalid.Field,
+		})
+	case errors.Is(err, ErrNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrVersionExists):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package terms
+
+import (
+	"context"
+	"sync"
+)
+
+// Filter narrows Acceptances; empty fields match every acceptance.
+type Filter struct {
+	UserID  string
+	Version string
+}
+
+func (f Filter) matches(a *Acceptance) bool {
+	return (f.UserID == "" || a.UserID == f.UserID) &&
+		(f.Version == "" || a.Version == f.Version)
+}
+
+// Store persists documents and acceptances.
+type Store interface {
+	// Publish adds a document, failing with ErrVersionExists when its
+	// version is taken.
+	Publish(ctx context.Context, document *Document) error
+	// Documents returns every document, oldest first.
+	Documents(ctx context.Context) ([]*Document, error)
+	Accept(ctx context.Context, acceptance *Acceptance) error
+	// Acceptances returns the acceptances matching filter, oldest first.
+	Acceptances(ctx context.Context, filter Filter) ([]*Acceptance, error)
+}
+
+// MemoryStore keeps documents and acceptances in process memory.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	documents   []*Document
+	acceptances []*Acceptance
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Publish(ctx context.Context, document *Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.documents {
+		if existing.Version == document.Version {
+			return ErrVersionExists
+		}
+	}
+	copied := *document
+	s.documents = append(s.documents, &copied)
+	return nil
+}
+
+func (s *MemoryStore) Documents(ctx context.Context) ([]*Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	documents := make([]*Document, 0, len(s.documents))
+	for _, document := range s.documents {
+		copied := *document
+		documents = append(documents, &copied)
+	}
+	return documents, nil
+}
+
+func (s *MemoryStore) Accept(ctx context.Context, acceptance *Acceptance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *acceptance
+	s.acceptances = append(s.acceptances, &copied)
+	return nil
+}
+
+func (s *MemoryStore) Acceptances(ctx context.Context, filter Filter) ([]*Acceptance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	acceptances := []*Acceptance{}
+	for _, acceptance := range s.acceptances {
+		if filter.matches(acceptance) {
+			copied := *acceptance
+			acceptances = append(acceptances, &copied)
+		}
+	}
+	return acceptances, nil
+}
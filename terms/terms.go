@@ -0,0 +1,137 @@
+// Package terms publishes versions of the terms of service, records
+// which version each user accepted and when, and keeps users who have
+// not accepted the latest version out until they do.
+package terms
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when no document matches the given version,
+	// or none is published yet.
+	ErrNotFound = errors.New("terms: not found")
+	// ErrVersionExists is returned when publishing a version again.
+	ErrVersionExists = errors.New("terms: version already published")
+)
+
+// Document is one published version of the terms.
+type Document struct {
+	Version string `json:"version"`
+	Title   string `json:"title"`
+	// Body is the text of the terms, or URL where they are published.
+	Body        string    `json:"body,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Acceptance records a user accepting a version.
+type Acceptance struct {
+	UserID     string    `json:"user_id"`
+	Version    string    `json:"version"`
+	AcceptedAt time.Time `json:"accepted_at"`
+	IP         string    `json:"ip,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+}
+
+// ValidationError describes input rejected by Publish or Accept.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Service publishes documents and records acceptances. The document
+// published last is the one users must accept.
+type Service struct {
+	Store Store
+}
+
+func NewService(store Store) *Service {
+	return &Service{Store: store}
+}
+
+// Publish makes document the latest version.
+func (s *Service) Publish(ctx context.Context, document Document) (*Document, error) {
+	document.Version = strings.TrimSpace(document.Version)
+	document.Title = strings.TrimSpace(document.Title)
+	switch {
+	case document.Version == "":
+		return nil, &ValidationError{Field: "version", Message: "is required"}
+	case len(document.Version) > 64:
+		return nil, &ValidationError{Field: "version", Message: "is too long"}
+	case document.Title == "":
+		return nil, &ValidationError{Field: "title", Message: "is required"}
+	case document.Body == "" && document.URL == "":
+		return nil, &ValidationError{Field: "body", Message: "or url is required"}
+	}
+	document.PublishedAt = time.Now().UTC()
+	if err := s.Store.Publish(ctx, &document); err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// Latest returns the version users must accept, or ErrNotFound.
+func (s *Service) Latest(ctx context.Context) (*Document, error) {
+	documents, err := s.Store.Documents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(documents) == 0 {
+		return nil, ErrNotFound
+	}
+	return documents[len(documents)-1], nil
+}
+
+// Accept records userID accepting version, which must be the latest.
+// Accepting it again returns the first acceptance.
+func (s *Service) Accept(ctx context.Context, acceptance Acceptance) (*Acceptance, error) {
+	latest, err := s.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if acceptance.Version != latest.Version {
+		return nil, &ValidationError{Field: "version", Message: "must be the latest version, " + latest.Version}
+	}
+	earlier, err := s.accepted(ctx, acceptance.UserID, latest.Version)
+	if err != nil || earlier != nil {
+		return earlier, err
+	}
+	acceptance.AcceptedAt = time.Now().UTC()
+	if err := s.Store.Accept(ctx, &acceptance); err != nil {
+		return nil, err
+	}
+	return &acceptance, nil
+}
+
+// Pending returns the latest version when userID has not accepted it,
+// and nil otherwise, also when nothing is published.
+func (s *Service) Pending(ctx context.Context, userID string) (*Document, error) {
+	latest, err := s.Latest(ctx)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	accepted, err := s.accepted(ctx, userID, latest.Version)
+	if err != nil || accepted != nil {
+		return nil, err
+	}
+	return latest, nil
+}
+
+func (s *Service) accepted(ctx context.Context, userID, version string) (*Acceptance, error) {
+	acceptances, err := s.Store.Acceptances(ctx, Filter{UserID: userID, Version: version})
+	if err != nil || len(acceptances) == 0 {
+		return nil, err
+	}
+	return acceptances[0], nil
+}
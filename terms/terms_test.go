@@ -0,0 +1,113 @@
+package terms
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"belajar-golang-fiber/session"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestApp(t *testing.T, service *Service) (*fiber.App, func(id string) string) {
+	sessions := session.NewManager(session.Config{Store: session.NewMemoryStore()})
+	app := fiber.New()
+	app.Post("/login/:id", sessions.Middleware(), func(ctx *fiber.Ctx) error {
+		_, token, err := sessions.Issue(ctx, ctx.Params("id"))
+		if err != nil {
+			return err
+		}
+		return ctx.SendString(token)
+	})
+	app.Use(sessions.Middleware())
+	app.Use(service.Require("/terms", func(c *fiber.Ctx) bool {
+		return strings.HasPrefix(c.Path(), "/api/terms")
+	}))
+	handler := &Handler{Service: service}
+	handler.Register(app.Group("/api/terms"))
+	admin := &AdminHandler{Service: service}
+	admin.Register(app.Group("/admin/terms"))
+	app.Get("/dashboard", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("dashboard")
+	})
+
+	login := func(id string) string {
+		response, err := app.Test(httptest.NewRequest("POST", "/login/"+id, nil))
+		assert.Nil(t, err)
+		return response.Cookies()[0].Value
+	}
+	return app, login
+}
+
+func send(t *testing.T, app *fiber.App, token, method, target, body string) (int, string) {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	var raw json.RawMessage
+	json.NewDecoder(response.Body).Decode(&raw)
+	return response.StatusCode, string(raw)
+}
+
+func TestTerms(t *testing.T) {
+	app, login := newTestApp(t, NewService(NewMemoryStore()))
+	token := login("1")
+
+	// Nothing to accept before the first version.
+	status, _ := send(t, app, token, "GET", "/dashboard", "")
+	assert.Equal(t, fiber.StatusOK, status)
+	status, _ = send(t, app, "", "GET", "/api/terms", "")
+	assert.Equal(t, fiber.StatusNotFound, status)
+
+	status, _ = send(t, app, "", "POST", "/admin/terms", `{"version":"2026-01","title":"Terms"}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+	status, _ = send(t, app, "", "POST", "/admin/terms", `{"version":"2026-01","title":"Terms","body":"Be nice."}`)
+	assert.Equal(t, fiber.StatusCreated, status)
+	status, _ = send(t, app, "", "POST", "/admin/terms", `{"version":"2026-01","title":"Terms","body":"Be nice."}`)
+	assert.Equal(t, fiber.StatusConflict, status)
+
+	status, body := send(t, app, token, "GET", "/dashboard", "")
+	assert.Equal(t, fiber.StatusForbidden, status)
+	assert.Contains(t, body, `"version":"2026-01"`)
+	request := httptest.NewRequest("GET", "/dashboard", nil)
+	request.Header.Set("Accept", "text/html")
+	request.Header.Set("Authorization", "Bearer "+token)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusSeeOther, response.StatusCode)
+	assert.Equal(t, "/terms?version=2026-01", response.Header.Get("Location"))
+	status, _ = send(t, app, "", "GET", "/dashboard", "")
+	assert.Equal(t, fiber.StatusOK, status, "anonymous requests pass")
+
+	status, _ = send(t, app, token, "POST", "/api/terms/accept", `{"version":"2025-06"}`)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, status)
+	status, _ = send(t, app, token, "POST", "/api/terms/accept", `{"version":"2026-01"}`)
+	assert.Equal(t, fiber.StatusOK, status)
+	status, _ = send(t, app, token, "POST", "/api/terms/accept", `{"version":"2026-01"}`)
+	assert.Equal(t, fiber.StatusOK, status)
+	status, _ = send(t, app, token, "GET", "/dashboard", "")
+	assert.Equal(t, fiber.StatusOK, status)
+
+	// A new version has to be accepted again.
+	send(t, app, "", "POST", "/admin/terms", `{"version":"2026-09","title":"Terms","url":"https://example.com/terms"}`)
+	status, _ = send(t, app, token, "GET", "/dashboard", "")
+	assert.Equal(t, fiber.StatusForbidden, status)
+	send(t, app, token, "POST", "/api/terms/accept", `{"version":"2026-09"}`)
+
+	_, body = send(t, app, token, "GET", "/api/terms/acceptances", "")
+	var history []Acceptance
+	assert.Nil(t, json.Unmarshal([]byte(body), &history))
+	assert.Len(t, history, 2)
+	assert.Equal(t, "2026-01", history[0].Version)
+	_, body = send(t, app, "", "GET", "/admin/terms/acceptances?version=2026-09", "")
+	assert.Contains(t, body, `"user_id":"1"`)
+	status, _ = send(t, app, "", "GET", "/api/terms/acceptances", "")
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+}
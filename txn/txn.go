@@ -0,0 +1,202 @@
+// Package txn groups the writes of a multi-step operation, such as
+// creating a user, recording it in the audit log and enqueueing its
+// welcome mail, so they take effect together or not at all.
+//
+// Stores take part by calling OnRollback with the undo of every write
+// made under a transaction, and AfterCommit for side effects that cannot
+// be undone. The memory stores apply writes at once, so other requests can
+// see them before the transaction commits. Stores with transactions of
+// their own, such as SQL databases, open one per transaction with
+// Resource and finish it with OnCommit and OnRollback.
+package txn
+
+import (
+	"context"
+	"sync"
+
+	"belajar-golang-fiber/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type contextKey struct{}
+
+// Tx is a running transaction. It is the context.Context to pass to the
+// stores written to.
+type Tx struct {
+	context.Context
+
+	mu        sync.Mutex
+	parent    *Tx
+	undo      []func()
+	commits   []func(ctx context.Context) error
+	after     []func(ctx context.Context)
+	resources map[any]any
+	done      bool
+}
+
+// Value returns the transaction itself for the package's key.
+func (t *Tx) Value(key any) any {
+	if key == (contextKey{}) {
+		return t
+	}
+	return t.Context.Value(key)
+}
+
+// From returns the transaction of ctx, or nil outside one.
+func From(ctx context.Context) *Tx {
+	t, _ := ctx.Value(contextKey{}).(*Tx)
+	if t != nil && t.finished() {
+		return nil
+	}
+	return t
+}
+
+// WithTx runs fn in a transaction, committing it when fn returns nil and
+// rolling it back when fn fails or panics. Called under a transaction it
+// opens a savepoint: failing rolls back only what fn did, and succeeding
+// leaves the outcome to the enclosing transaction.
+func WithTx(ctx context.Context, fn func(tx *Tx) error) (err error) {
+	t := &Tx{Context: ctx, parent: From(ctx)}
+	defer func() {
+		if r := recover(); r != nil {
+			t.rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(t); err != nil {
+		t.rollback()
+		return err
+	}
+	return t.commit()
+}
+
+// InRequest runs fn in a transaction that is also the user context of
+// ctx, so handlers and audit logging write under it.
+func InRequest(ctx *fiber.Ctx, fn func(tx *Tx) error) error {
+	userContext := ctx.UserContext()
+	defer ctx.SetUserContext(userContext)
+
+	return WithTx(userContext, func(tx *Tx) error {
+		ctx.SetUserContext(tx)
+		return fn(tx)
+	})
+}
+
+// OnRollback registers undo to run if the transaction of ctx rolls back.
+// Undos run newest first. Outside a transaction it does nothing.
+func OnRollback(ctx context.Context, undo func()) {
+	if t := From(ctx); t != nil {
+		t.mu.Lock()
+		t.undo = append(t.undo, undo)
+		t.mu.Unlock()
+	}
+}
+
+// OnCommit registers fn to run when the outermost transaction of ctx
+// commits, before the AfterCommit functions. An error rolls the
+// transaction back and is returned by WithTx. Outside a transaction it
+// does nothing.
+func OnCommit(ctx context.Context, fn func(ctx context.Context) error) {
+	if t := From(ctx); t != nil {
+		t.mu.Lock()
+		t.commits = append(t.commits, fn)
+		t.mu.Unlock()
+	}
+}
+
+// AfterCommit defers fn until the outermost transaction of ctx commits,
+// dropping it on rollback. Outside a transaction fn runs at once.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	t := From(ctx)
+	if t == nil {
+		fn(ctx)
+		return
+	}
+	t.mu.Lock()
+	t.after = append(t.after, fn)
+	t.mu.Unlock()
+}
+
+// Root returns the outermost transaction t is part of.
+func (t *Tx) Root() *Tx {
+	for t.parent != nil {
+		t = t.parent
+	}
+	return t
+}
+
+// Resource returns the value t holds for key, calling open to create it
+// the first time. Stores key their own transaction, or savepoint, by
+// themselves.
+func (t *Tx) Resource(key any, open func() (any, error)) (any, error) {
+	t.mu.Lock()
+	value, ok := t.resources[key]
+	t.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+
+	// open may register with t, so it runs unlocked.
+	value, err := open()
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.resources == nil {
+		t.resources = map[any]any{}
+	}
+	t.resources[key] = value
+	return value, nil
+}
+
+func (t *Tx) finished() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.done
+}
+
+func (t *Tx) rollback() {
+	t.mu.Lock()
+	undo := t.undo
+	t.undo, t.commits, t.after, t.done = nil, nil, nil, true
+	t.mu.Unlock()
+
+	for i := len(undo) - 1; i >= 0; i-- {
+		undo[i]()
+	}
+	logger.FromContext(t.Context).Debug("txn: rolled back", "writes", len(undo))
+}
+
+func (t *Tx) commit() error {
+	t.mu.Lock()
+	undo, commits, after := t.undo, t.commits, t.after
+	t.mu.Unlock()
+
+	if t.parent != nil {
+		t.mu.Lock()
+		t.undo, t.commits, t.after, t.done = nil, nil, nil, true
+		t.mu.Unlock()
+		t.parent.mu.Lock()
+		t.parent.undo = append(t.parent.undo, undo...)
+		t.parent.commits = append(t.parent.commits, commits...)
+		t.parent.after = append(t.parent.after, after...)
+		t.parent.mu.Unlock()
+		return nil
+	}
+	for _, fn := range commits {
+		if err := fn(t.Context); err != nil {
+			t.rollback()
+			return err
+		}
+	}
+	t.mu.Lock()
+	t.undo, t.commits, t.after, t.done = nil, nil, nil, true
+	t.mu.Unlock()
+	for _, fn := range after {
+		fn(t.Context)
+	}
+	return nil
+}
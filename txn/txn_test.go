@@ -0,0 +1,144 @@
+package txn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// list is a store that undoes its appends on rollback.
+type list struct {
+	items []string
+}
+
+func (l *list) add(ctx context.Context, item string) {
+	l.items = append(l.items, item)
+	OnRollback(ctx, func() { l.items = l.items[:len(l.items)-1] })
+}
+
+func TestCommitAndRollback(t *testing.T) {
+	store := &list{}
+	var sent []string
+
+	err := WithTx(context.Background(), func(tx *Tx) error {
+		store.add(tx, "user")
+		store.add(tx, "audit")
+		AfterCommit(tx, func(ctx context.Context) { sent = append(sent, "welcome") })
+		assert.Empty(t, sent)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"user", "audit"}, store.items)
+	assert.Equal(t, []string{"welcome"}, sent)
+
+	failed := errors.New("mail rejected")
+	err = WithTx(context.Background(), func(tx *Tx) error {
+		store.add(tx, "second user")
+		AfterCommit(tx, func(ctx context.Context) { sent = append(sent, "second welcome") })
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, []string{"user", "audit"}, store.items)
+	assert.Equal(t, []string{"welcome"}, sent)
+
+	assert.Panics(t, func() {
+		_ = WithTx(context.Background(), func(tx *Tx) error {
+			store.add(tx, "third user")
+			panic("boom")
+		})
+	})
+	assert.Equal(t, []string{"user", "audit"}, store.items)
+}
+
+func TestSavepoints(t *testing.T) {
+	store := &list{}
+	var sent []string
+
+	err := WithTx(context.Background(), func(tx *Tx) error {
+		store.add(tx, "user")
+		err := WithTx(tx, func(tx *Tx) error {
+			store.add(tx, "optional")
+			AfterCommit(tx, func(ctx context.Context) { sent = append(sent, "optional") })
+			return errors.New("skipped")
+		})
+		assert.NotNil(t, err)
+		assert.Equal(t, []string{"user"}, store.items)
+
+		return WithTx(tx, func(tx *Tx) error {
+			store.add(tx, "audit")
+			AfterCommit(tx, func(ctx context.Context) { sent = append(sent, "welcome") })
+			return nil
+		})
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"user", "audit"}, store.items)
+	assert.Equal(t, []string{"welcome"}, sent)
+
+	// A committed savepoint is still undone with its transaction.
+	err = WithTx(context.Background(), func(tx *Tx) error {
+		assert.Nil(t, WithTx(tx, func(tx *Tx) error {
+			store.add(tx, "nested")
+			return nil
+		}))
+		assert.Len(t, store.items, 3)
+		return errors.New("failed")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"user", "audit"}, store.items)
+	assert.Equal(t, []string{"welcome"}, sent)
+}
+
+func TestOutsideTransaction(t *testing.T) {
+	ran := false
+	OnRollback(context.Background(), func() { t.Fatal("undo ran without a transaction") })
+	AfterCommit(context.Background(), func(ctx context.Context) { ran = true })
+	assert.True(t, ran)
+
+	var leaked *Tx
+	assert.Nil(t, WithTx(context.Background(), func(tx *Tx) error {
+		leaked = tx
+		return nil
+	}))
+	assert.Nil(t, From(leaked))
+}
+
+func TestCommitFailure(t *testing.T) {
+	store := &list{}
+	var sent []string
+	failed := errors.New("commit failed")
+
+	err := WithTx(context.Background(), func(tx *Tx) error {
+		store.add(tx, "user")
+		return WithTx(tx, func(tx *Tx) error {
+			OnCommit(tx, func(ctx context.Context) error { return failed })
+			AfterCommit(tx, func(ctx context.Context) { sent = append(sent, "welcome") })
+			return nil
+		})
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.Empty(t, store.items)
+	assert.Empty(t, sent)
+}
+
+func TestResource(t *testing.T) {
+	opened := 0
+	open := func() (any, error) {
+		opened++
+		return opened, nil
+	}
+
+	assert.Nil(t, WithTx(context.Background(), func(tx *Tx) error {
+		first, _ := tx.Resource("db", open)
+		again, _ := tx.Resource("db", open)
+		assert.Equal(t, first, again)
+		return WithTx(tx, func(nested *Tx) error {
+			assert.Same(t, tx, nested.Root())
+			savepoint, _ := nested.Resource("db", open)
+			assert.NotEqual(t, first, savepoint)
+			return nil
+		})
+	}))
+	assert.Equal(t, 2, opened)
+}
@@ -0,0 +1,212 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"belajar-golang-fiber/txn"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// userDocument is how a user is stored in MongoDB. Times keep millisecond
+// precision.
+type userDocument struct {
+	ID                    string     `bson:"_id"`
+	Username              string     `bson:"username"`
+	Name                  string     `bson:"name"`
+	Email                 string     `bson:"email"`
+	Phone                 string     `bson:"phone"`
+	Password              []byte     `bson:"password"`
+	Roles                 []string   `bson:"roles,omitempty"`
+	LockedAt              *time.Time `bson:"locked_at,omitempty"`
+	PasswordResetRequired bool       `bson:"password_reset,omitempty"`
+	Version               int        `bson:"version"`
+	CreatedAt             time.Time  `bson:"created_at"`
+	UpdatedAt             time.Time  `bson:"updated_at"`
+	DeletedAt             *time.Time `bson:"deleted_at,omitempty"`
+}
+
+func toDocument(user *User) *userDocument {
+	document := userDocument(*user)
+	return &document
+}
+
+func (d *userDocument) user() *User {
+	user := User(*d)
+	user.CreatedAt = user.CreatedAt.UTC()
+	user.UpdatedAt = user.UpdatedAt.UTC()
+	if user.DeletedAt != nil {
+		at := user.DeletedAt.UTC()
+		user.DeletedAt = &at
+	}
+	if user.LockedAt != nil {
+		at := user.LockedAt.UTC()
+		user.LockedAt = &at
+	}
+	return &user
+}
+
+// MongoRepository keeps users in a MongoDB collection. Writes under a txn
+// transaction are undone one by one on rollback, as MongoDB only has
+// transactions on replica sets.
+type MongoRepository struct {
+	Collection *mongo.Collection
+}
+
+// NewMongoRepository stores users in the "users" collection of db.
+func NewMongoRepository(db *mongo.Database) *MongoRepository {
+	return &MongoRepository{Collection: db.Collection("users")}
+}
+
+// EnsureIndexes creates the indexes the repository relies on, such as the
+// one keeping usernames unique, unless they exist.
+func (r *MongoRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *MongoRepository) Create(ctx context.Context, user *User) error {
+	if user.Version == 0 {
+		user.Version = 1
+	}
+	_, err := r.Collection.InsertOne(ctx, toDocument(user))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrUsernameTaken
+	}
+	if err != nil {
+		return err
+	}
+	txn.OnRollback(ctx, func() {
+		r.Collection.DeleteOne(context.WithoutCancel(ctx), bson.M{"_id": user.ID})
+	})
+	return nil
+}
+
+func (r *MongoRepository) Get(ctx context.Context, id string) (*User, error) {
+	return r.one(ctx, bson.M{"_id": id, "deleted_at": nil})
+}
+
+func (r *MongoRepository) FindByUsername(ctx context.Context, username string) (*User, error) {
+	return r.one(ctx, bson.M{"username": username, "deleted_at": nil})
+}
+
+func (r *MongoRepository) List(ctx context.Context, list ListOptions) ([]*User, int, error) {
+	filter := bson.M{"deleted_at": nil}
+	if list.IncludeDeleted {
+		filter = bson.M{}
+	}
+	total, err := r.Collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	find := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).SetSkip(int64(list.Offset))
+	if list.Limit > 0 {
+		find.SetLimit(int64(list.Limit))
+	}
+	cursor, err := r.Collection.Find(ctx, filter, find)
+	if err != nil {
+		return nil, 0, err
+	}
+	var documents []*userDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, 0, err
+	}
+	users := make([]*User, len(documents))
+	for i, document := range documents {
+		users[i] = document.user()
+	}
+	return users, int(total), nil
+}
+
+func (r *MongoRepository) Update(ctx context.Context, user *User) error {
+	var previous userDocument
+	err := r.Collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": user.ID, "version": user.Version, "deleted_at": nil},
+		bson.M{
+			"$set": bson.M{
+				"username":       user.Username,
+				"name":           user.Name,
+				"email":          user.Email,
+				"phone":          user.Phone,
+				"password":       user.Password,
+				"roles":          user.Roles,
+				"locked_at":      user.LockedAt,
+				"updated_at":     user.UpdatedAt,
+				"password_reset": user.PasswordResetRequired,
+			},
+			"$inc": bson.M{"version": 1},
+		},
+	).Decode(&previous)
+	switch {
+	case mongo.IsDuplicateKeyError(err):
+		return ErrUsernameTaken
+	case errors.Is(err, mongo.ErrNoDocuments):
+		// The user is either gone or changed since it was read.
+		if _, err := r.Get(ctx, user.ID); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	case err != nil:
+		return err
+	}
+	user.Version++
+	txn.OnRollback(ctx, func() {
+		r.Collection.ReplaceOne(context.WithoutCancel(ctx), bson.M{"_id": previous.ID}, &previous)
+	})
+	return nil
+}
+
+func (r *MongoRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$set": bson.M{"deleted_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	txn.OnRollback(ctx, func() {
+		r.Collection.UpdateOne(context.WithoutCancel(ctx), bson.M{"_id": id}, bson.M{"$unset": bson.M{"deleted_at": ""}})
+	})
+	return nil
+}
+
+func (r *MongoRepository) Restore(ctx context.Context, id string) error {
+	var previous userDocument
+	err := r.Collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}},
+		bson.M{"$unset": bson.M{"deleted_at": ""}},
+	).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	txn.OnRollback(ctx, func() {
+		r.Collection.UpdateOne(context.WithoutCancel(ctx), bson.M{"_id": id}, bson.M{"$set": bson.M{"deleted_at": previous.DeletedAt}})
+	})
+	return nil
+}
+
+func (r *MongoRepository) one(ctx context.Context, filter bson.M) (*User, error) {
+	var document userDocument
+	err := r.Collection.FindOne(ctx, filter).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return document.user(), nil
+}
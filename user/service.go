@@ -0,0 +1,323 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/phone"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ValidationError describes input rejected by Register.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return "user: " + e.Field + " " + e.Message
+}
+
+// RegisterInput is what a new account is created from.
+type RegisterInput struct {
+	Username string
+	Password string
+	Name     string
+	Email    string
+	Phone    string
+}
+
+// ErrInvalidCredentials is returned by Authenticate for an unknown
+// username or a wrong password.
+var ErrInvalidCredentials = errors.New("user: invalid credentials")
+
+// LoginInput is what Authenticate checks.
+type LoginInput struct {
+	Username string
+	Password string
+}
+
+// Service implements account registration and updates on top of a
+// Repository.
+type Service struct {
+	Users Repository
+	// PhoneRegion is the region assumed for phone numbers entered
+	// without a country code.
+	PhoneRegion string
+
+	hooks  []func(ctx context.Context, user *User)
+	checks []func(ctx context.Context, user *User) error
+}
+
+func NewService(users Repository, phoneRegion string) *Service {
+	return &Service{Users: users, PhoneRegion: phoneRegion}
+}
+
+// AfterRegister registers a hook called, in order, after every created
+// user. Hooks are not safe to register once the service is serving
+// requests.
+func (s *Service) AfterRegister(hook func(ctx context.Context, user *User)) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// BeforeDelete registers a check called, in order, before a user is
+// deleted. An error keeps the user and is returned by Delete. Checks are
+// not safe to register once the service is serving requests.
+func (s *Service) BeforeDelete(check func(ctx context.Context, user *User) error) {
+	s.checks = append(s.checks, check)
+}
+
+// Register validates input and creates the user. Phone numbers are stored
+// in E.164 form so the same number always compares equal.
+func (s *Service) Register(ctx context.Context, input RegisterInput) (*User, error) {
+	username := strings.TrimSpace(input.Username)
+	if username == "" {
+		return nil, &ValidationError{Field: "username", Message: "is required"}
+	}
+	if err := validatePassword(input.Password); err != nil {
+		return nil, err
+	}
+	email, err := validateEmail(input.Email)
+	if err != nil {
+		return nil, err
+	}
+	number, err := s.normalizePhone(input.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.Users.FindByUsername(ctx, username); err == nil {
+		return nil, ErrUsernameTaken
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &User{
+		ID:        utils.UUIDv4(),
+		Username:  username,
+		Name:      strings.TrimSpace(input.Name),
+		Email:     email,
+		Phone:     number,
+		Password:  hash,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.Users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	for _, hook := range s.hooks {
+		hook(ctx, user)
+	}
+	return user, nil
+}
+
+// UpdateInput changes the fields that are not nil.
+type UpdateInput struct {
+	Username *string
+	Password *string
+	Name     *string
+	Email    *string
+	Phone    *string
+	// Version, when set, is the version the change was based on; the
+	// update fails with ErrVersionConflict once the user moved past it.
+	Version int
+}
+
+// Update applies input to the user with the given id.
+func (s *Service) Update(ctx context.Context, id string, input UpdateInput) (*User, error) {
+	user, err := s.Users.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Version != 0 && input.Version != user.Version {
+		return nil, ErrVersionConflict
+	}
+
+	if input.Username != nil {
+		username := strings.TrimSpace(*input.Username)
+		if username == "" {
+			return nil, &ValidationError{Field: "username", Message: "is required"}
+		}
+		user.Username = username
+	}
+	if input.Name != nil {
+		user.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Email != nil {
+		if user.Email, err = validateEmail(*input.Email); err != nil {
+			return nil, err
+		}
+	}
+	if input.Phone != nil {
+		if user.Phone, err = s.normalizePhone(*input.Phone); err != nil {
+			return nil, err
+		}
+	}
+	if input.Password != nil {
+		if err := validatePassword(*input.Password); err != nil {
+			return nil, err
+		}
+		if user.Password, err = bcrypt.GenerateFromPassword([]byte(*input.Password), bcrypt.DefaultCost); err != nil {
+			return nil, err
+		}
+		user.PasswordResetRequired = false
+	}
+
+	user.UpdatedAt = time.Now()
+	if err := s.Users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangePassword sets the password of the user with the given id to
+// password, provided current is the one they have.
+func (s *Service) ChangePassword(ctx context.Context, id, current, password string) (*User, error) {
+	user, err := s.Users.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword(user.Password, []byte(current)) != nil {
+		return nil, &ValidationError{Field: "current_password", Message: "does not match"}
+	}
+	return s.Update(ctx, id, UpdateInput{Password: &password, Version: user.Version})
+}
+
+// Delete deletes the user with the given id once every BeforeDelete
+// check passes.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	user, err := s.Users.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, check := range s.checks {
+		if err := check(ctx, user); err != nil {
+			return err
+		}
+	}
+	return s.Users.Delete(ctx, id)
+}
+
+// Lock keeps the user with the given id from signing in until Unlock.
+// Locking a locked user changes nothing.
+func (s *Service) Lock(ctx context.Context, id string) (*User, error) {
+	return s.change(ctx, id, func(user *User) {
+		if user.LockedAt == nil {
+			now := time.Now().UTC()
+			user.LockedAt = &now
+		}
+	})
+}
+
+// Unlock lets the user with the given id sign in again.
+func (s *Service) Unlock(ctx context.Context, id string) (*User, error) {
+	return s.change(ctx, id, func(user *User) {
+		user.LockedAt = nil
+	})
+}
+
+// SetRoles replaces the roles of the user with the given id, sorted and
+// without duplicates.
+func (s *Service) SetRoles(ctx context.Context, id string, roles []string) (*User, error) {
+	for _, role := range roles {
+		if !ValidRole(role) {
+			return nil, &ValidationError{Field: "roles", Message: "must be lowercase letters, digits, - and _"}
+		}
+	}
+	roles = slices.Compact(slices.Sorted(slices.Values(roles)))
+	return s.change(ctx, id, func(user *User) {
+		user.Roles = roles
+	})
+}
+
+// RequirePasswordReset has the user with the given id change their
+// password once signed in again; see Update.
+func (s *Service) RequirePasswordReset(ctx context.Context, id string) (*User, error) {
+	return s.change(ctx, id, func(user *User) {
+		user.PasswordResetRequired = true
+	})
+}
+
+// change applies fn to the user with the given id and stores it.
+func (s *Service) change(ctx context.Context, id string, fn func(user *User)) (*User, error) {
+	user, err := s.Users.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fn(user)
+	user.UpdatedAt = time.Now()
+	if err := s.Users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+var rolePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+
+// ValidRole reports whether name can be used as a role.
+func ValidRole(name string) bool {
+	return rolePattern.MatchString(name)
+}
+
+func validatePassword(password string) error {
+	if len(password) < 6 {
+		return &ValidationError{Field: "password", Message: "must be at least 6 characters"}
+	}
+	return nil
+}
+
+func validateEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", nil
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", &ValidationError{Field: "email", Message: "is not a valid email address"}
+	}
+	return email, nil
+}
+
+func (s *Service) normalizePhone(raw string) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return "", nil
+	}
+	number, err := phone.Normalize(raw, s.PhoneRegion)
+	if err != nil {
+		return "", &ValidationError{Field: "phone", Message: "is not a valid phone number"}
+	}
+	return number, nil
+}
+
+// Authenticate returns the user matching the credentials in input. A
+// locked user is refused with ErrAccountLocked, once the password
+// matched, so as not to tell which accounts exist.
+func (s *Service) Authenticate(ctx context.Context, input LoginInput) (*User, error) {
+	user, err := s.Users.FindByUsername(ctx, strings.TrimSpace(input.Username))
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword(user.Password, []byte(input.Password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if user.Locked() {
+		return nil, ErrAccountLocked
+	}
+	return user, nil
+}
@@ -0,0 +1,199 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"belajar-golang-fiber/database"
+)
+
+const userColumns = "id, username, name, email, phone, password, roles, locked_at, password_reset, version, created_at, updated_at, deleted_at"
+
+// SQLRepository keeps users in the users table of a SQL database; see
+// database.Migrate.
+type SQLRepository struct {
+	DB *database.DB
+}
+
+func NewSQLRepository(db *database.DB) *SQLRepository {
+	return &SQLRepository{DB: db}
+}
+
+func (r *SQLRepository) Create(ctx context.Context, user *User) error {
+	if user.Version == 0 {
+		user.Version = 1
+	}
+	_, err := r.DB.Exec(ctx, "INSERT INTO users ("+userColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		user.ID, user.Username, user.Name, user.Email, user.Phone, string(user.Password),
+		strings.Join(user.Roles, ","), nullTime(user.LockedAt), flag(user.PasswordResetRequired), user.Version,
+		user.CreatedAt.UTC(), user.UpdatedAt.UTC(), nullTime(user.DeletedAt))
+	if database.IsUniqueViolation(err) {
+		return ErrUsernameTaken
+	}
+	return err
+}
+
+func (r *SQLRepository) Get(ctx context.Context, id string) (*User, error) {
+	return r.one(ctx, "SELECT "+userColumns+" FROM users WHERE id = ? AND deleted_at IS NULL", id)
+}
+
+func (r *SQLRepository) FindByUsername(ctx context.Context, username string) (*User, error) {
+	return r.one(ctx, "SELECT "+userColumns+" FROM users WHERE username = ? AND deleted_at IS NULL", username)
+}
+
+func (r *SQLRepository) List(ctx context.Context, options ListOptions) ([]*User, int, error) {
+	where := " WHERE deleted_at IS NULL"
+	if options.IncludeDeleted {
+		where = ""
+	}
+	rows, err := r.DB.Query(ctx, "SELECT COUNT(*) FROM users"+where)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int
+	for rows.Next() {
+		err = rows.Scan(&total)
+	}
+	rows.Close()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT " + userColumns + " FROM users" + where + " ORDER BY created_at, id"
+	args := []any{}
+	if options.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, options.Limit, options.Offset)
+	}
+	users, err := r.all(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if options.Limit <= 0 {
+		users = users[min(options.Offset, len(users)):]
+	}
+	return users, total, nil
+}
+
+func (r *SQLRepository) Update(ctx context.Context, user *User) error {
+	result, err := r.DB.Exec(ctx, `UPDATE users SET username = ?, name = ?, email = ?, phone = ?, password = ?,
+	roles = ?, locked_at = ?, password_reset = ?, version = version + 1, updated_at = ?
+	WHERE id = ? AND version = ? AND deleted_at IS NULL`,
+		user.Username, user.Name, user.Email, user.Phone, string(user.Password),
+		strings.Join(user.Roles, ","), nullTime(user.LockedAt), flag(user.PasswordResetRequired),
+		user.UpdatedAt.UTC(), user.ID, user.Version)
+	if database.IsUniqueViolation(err) {
+		return ErrUsernameTaken
+	}
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		// The user is either gone or changed since it was read.
+		if _, err := r.Get(ctx, user.ID); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+	user.Version++
+	return nil
+}
+
+func (r *SQLRepository) Delete(ctx context.Context, id string) error {
+	return r.change(ctx, "UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now().UTC(), id)
+}
+
+func (r *SQLRepository) Restore(ctx context.Context, id string) error {
+	return r.change(ctx, "UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+}
+
+// change runs a statement meant to change one row, failing with
+// ErrNotFound when it changed none.
+func (r *SQLRepository) change(ctx context.Context, query string, args ...any) error {
+	result, err := r.DB.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if changed == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *SQLRepository) one(ctx context.Context, query string, args ...any) (*User, error) {
+	users, err := r.all(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, ErrNotFound
+	}
+	return users[0], nil
+}
+
+func (r *SQLRepository) all(ctx context.Context, query string, args ...any) ([]*User, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*User{}
+	for rows.Next() {
+		var (
+			user          User
+			password      string
+			roles         string
+			lockedAt      sql.NullTime
+			passwordReset int
+			deletedAt     sql.NullTime
+		)
+		err := rows.Scan(&user.ID, &user.Username, &user.Name, &user.Email, &user.Phone, &password,
+			&roles, &lockedAt, &passwordReset, &user.Version, &user.CreatedAt, &user.UpdatedAt, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+		user.Password = []byte(password)
+		if roles != "" {
+			user.Roles = strings.Split(roles, ",")
+		}
+		if lockedAt.Valid {
+			at := lockedAt.Time.UTC()
+			user.LockedAt = &at
+		}
+		user.PasswordResetRequired = passwordReset != 0
+		user.CreatedAt = user.CreatedAt.UTC()
+		user.UpdatedAt = user.UpdatedAt.UTC()
+		if deletedAt.Valid {
+			at := deletedAt.Time.UTC()
+			user.DeletedAt = &at
+		}
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
+// flag stores a bool in an INTEGER column.
+func flag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,65 @@
+package user
+
+import "context"
+
+// TenantRepository keeps the users of each tenant apart, in the
+// repository For returns for the tenant of ctx; see tenant.Partitioned.
+type TenantRepository struct {
+	For func(ctx context.Context) (Repository, error)
+}
+
+func (r *TenantRepository) Create(ctx context.Context, user *User) error {
+	users, err := r.For(ctx)
+	if err != nil {
+		return err
+	}
+	return users.Create(ctx, user)
+}
+
+func (r *TenantRepository) Get(ctx context.Context, id string) (*User, error) {
+	users, err := r.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return users.Get(ctx, id)
+}
+
+func (r *TenantRepository) FindByUsername(ctx context.Context, username string) (*User, error) {
+	users, err := r.For(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return users.FindByUsername(ctx, username)
+}
+
+func (r *TenantRepository) List(ctx context.Context, options ListOptions) ([]*User, int, error) {
+	users, err := r.For(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users.List(ctx, options)
+}
+
+func (r *TenantRepository) Update(ctx context.Context, user *User) error {
+	users, err := r.For(ctx)
+	if err != nil {
+		return err
+	}
+	return users.Update(ctx, user)
+}
+
+func (r *TenantRepository) Delete(ctx context.Context, id string) error {
+	users, err := r.For(ctx)
+	if err != nil {
+		return err
+	}
+	return users.Delete(ctx, id)
+}
+
+func (r *TenantRepository) Restore(ctx context.Context, id string) error {
+	users, err := r.For(ctx)
+	if err != nil {
+		return err
+	}
+	return users.Restore(ctx, id)
+}
@@ -0,0 +1,240 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/txn"
+)
+
+var (
+	// ErrNotFound is returned when no user matches the given id.
+	ErrNotFound = errors.New("user: not found")
+	// ErrUsernameTaken is returned when creating a user whose username is
+	// already registered.
+	ErrUsernameTaken = errors.New("user: username taken")
+	// ErrLocked is wrapped by the errors of checks refusing to delete a
+	// user, e.g. one under legal hold.
+	ErrLocked = errors.New("user: locked")
+	// ErrVersionConflict is returned when updating a user that changed
+	// since it was read.
+	ErrVersionConflict = errors.New("user: modified since it was read")
+	// ErrAccountLocked is returned by Authenticate for a user an admin
+	// locked.
+	ErrAccountLocked = errors.New("user: account locked")
+)
+
+// User is a registered account as stored. It is not meant to be encoded
+// in responses; see dto.UserResponse.
+type User struct {
+	ID       string
+	Username string
+	Name     string
+	Email    string
+	Phone    string
+	Password []byte `json:"-"`
+	// Roles lists what the user may do beyond their own account, e.g.
+	// "admin"; see ValidRole.
+	Roles []string
+	// LockedAt is set while an admin keeps the user from signing in.
+	LockedAt *time.Time
+	// PasswordResetRequired keeps the user to changing their password
+	// once signed in.
+	PasswordResetRequired bool
+	// Version counts the updates of the user, starting at 1. It guards
+	// updates against overwriting changes made since the user was read.
+	Version   int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// DeletedAt is set once the user is deleted; deleted users can be
+	// restored.
+	DeletedAt *time.Time
+}
+
+// Deleted reports whether the user is soft deleted.
+func (u *User) Deleted() bool {
+	return u.DeletedAt != nil
+}
+
+// Locked reports whether an admin locked the user.
+func (u *User) Locked() bool {
+	return u.LockedAt != nil
+}
+
+// ListOptions pages through List. A zero Limit returns every user.
+type ListOptions struct {
+	Offset int
+	Limit  int
+	// IncludeDeleted lists soft deleted users too.
+	IncludeDeleted bool
+}
+
+// Repository persists users. Deleting a user only marks it deleted:
+// Get, FindByUsername and List skip it, unless asked otherwise, until it
+// is restored. Its username stays taken meanwhile.
+//
+// Update only succeeds when the stored Version is still that of the user
+// given, failing with ErrVersionConflict otherwise, and increments it.
+type Repository interface {
+	Create(ctx context.Context, user *User) error
+	Get(ctx context.Context, id string) (*User, error)
+	FindByUsername(ctx context.Context, username string) (*User, error)
+	// List returns a page of users, oldest first, and the total count.
+	List(ctx context.Context, options ListOptions) ([]*User, int, error)
+	Update(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+}
+
+// MemoryRepository keeps users in process memory.
+type MemoryRepository struct {
+	mu    sync.RWMutex
+	users map[string]*User
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{users: map[string]*User{}}
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Username == user.Username {
+			return ErrUsernameTaken
+		}
+	}
+	if user.Version == 0 {
+		user.Version = 1
+	}
+	copied := *user
+	copied.Roles = slices.Clone(user.Roles)
+	r.users[user.ID] = &copied
+	txn.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.users, user.ID)
+	})
+	return nil
+}
+
+func (r *MemoryRepository) Get(ctx context.Context, id string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || user.Deleted() {
+		return nil, ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *MemoryRepository) FindByUsername(ctx context.Context, username string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username && !user.Deleted() {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepository) List(ctx context.Context, options ListOptions) ([]*User, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]*User, 0, len(r.users))
+	for _, user := range r.users {
+		if user.Deleted() && !options.IncludeDeleted {
+			continue
+		}
+		copied := *user
+		list = append(list, &copied)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+
+	total := len(list)
+	if options.Offset > total {
+		options.Offset = total
+	}
+	list = list[options.Offset:]
+	if options.Limit > 0 && options.Limit < len(list) {
+		list = list[:options.Limit]
+	}
+	return list, total, nil
+}
+
+func (r *MemoryRepository) Update(ctx context.Context, user *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || stored.Deleted() {
+		return ErrNotFound
+	}
+	if stored.Version != user.Version {
+		return ErrVersionConflict
+	}
+	for _, existing := range r.users {
+		if existing.ID != user.ID && existing.Username == user.Username {
+			return ErrUsernameTaken
+		}
+	}
+	user.Version++
+	copied := *user
+	copied.Roles = slices.Clone(user.Roles)
+	r.users[user.ID] = &copied
+	txn.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.users[stored.ID] = stored
+	})
+	return nil
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.Deleted() {
+		return ErrNotFound
+	}
+	now := time.Now()
+	user.DeletedAt = &now
+	txn.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		user.DeletedAt = nil
+	})
+	return nil
+}
+
+func (r *MemoryRepository) Restore(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || !user.Deleted() {
+		return ErrNotFound
+	}
+	deletedAt := user.DeletedAt
+	user.DeletedAt = nil
+	txn.OnRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		user.DeletedAt = deletedAt
+	})
+	return nil
+}
@@ -0,0 +1,235 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/database"
+	"belajar-golang-fiber/txn"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRegisterNormalizesPhone(t *testing.T) {
+	users := NewMemoryRepository()
+	service := NewService(users, "ID")
+	var registered []string
+	service.AfterRegister(func(ctx context.Context, user *User) {
+		registered = append(registered, user.Username)
+	})
+
+	created, err := service.Register(context.Background(), RegisterInput{
+		Username: "salman",
+		Password: "rahasia",
+		Phone:    "0812-3456-7890",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "+6281234567890", created.Phone)
+	assert.Nil(t, bcrypt.CompareHashAndPassword(created.Password, []byte("rahasia")))
+
+	stored, err := users.FindByUsername(context.Background(), "salman")
+	assert.Nil(t, err)
+	assert.Equal(t, "+6281234567890", stored.Phone)
+
+	_, err = service.Register(context.Background(), RegisterInput{Username: "salman", Password: "rahasia"})
+	assert.Equal(t, ErrUsernameTaken, err)
+	assert.Equal(t, []string{"salman"}, registered)
+}
+
+// repositories returns every Repository implementation, empty, so tests
+// hold them all to the same behaviour.
+func repositories(t *testing.T) map[string]Repository {
+	db, err := database.Open(database.DriverSQLite, "file::memory:")
+	assert.Nil(t, err)
+	t.Cleanup(func() { db.Close() })
+	assert.Nil(t, db.Migrate(context.Background()))
+
+	repositories := map[string]Repository{
+		"memory": NewMemoryRepository(),
+		"sqlite": NewSQLRepository(db),
+	}
+	// MongoDB is only tested against a running server, e.g.
+	// MONGODB_TEST_URI=mongodb://localhost:27017.
+	if uri := os.Getenv("MONGODB_TEST_URI"); uri != "" {
+		mongoDB, err := database.OpenMongo(context.Background(), uri)
+		assert.Nil(t, err)
+		scratch := mongoDB.Client().Database("test_users_" + strconv.FormatInt(time.Now().UnixNano(), 36))
+		t.Cleanup(func() { scratch.Drop(context.Background()) })
+		mongo := NewMongoRepository(scratch)
+		assert.Nil(t, mongo.EnsureIndexes(context.Background()))
+		repositories["mongodb"] = mongo
+	}
+	return repositories
+}
+
+func TestMongoDocument(t *testing.T) {
+	deleted := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	user := &User{ID: "1", Username: "salman", Password: []byte("$2a$10$hash"), Version: 2, DeletedAt: &deleted}
+
+	data, err := bson.Marshal(toDocument(user))
+	assert.Nil(t, err)
+	var document userDocument
+	assert.Nil(t, bson.Unmarshal(data, &document))
+	assert.Equal(t, user, document.user())
+
+	raw := bson.Raw(data)
+	assert.Equal(t, "1", raw.Lookup("_id").StringValue())
+	assert.Equal(t, int32(2), raw.Lookup("version").Int32())
+
+	user.DeletedAt = nil
+	data, _ = bson.Marshal(toDocument(user))
+	_, err = bson.Raw(data).LookupErr("deleted_at")
+	assert.NotNil(t, err, "users not deleted have no deleted_at")
+}
+
+func TestRepository(t *testing.T) {
+	ctx := context.Background()
+	for name, users := range repositories(t) {
+		created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		for i, username := range []string{"salman", "seif", "fatimah"} {
+			at := created.Add(time.Duration(i) * time.Hour)
+			assert.Nil(t, users.Create(ctx, &User{ID: strconv.Itoa(i + 1), Username: username, Password: []byte("$2a$10$hash"), CreatedAt: at, UpdatedAt: at}), name)
+		}
+		assert.ErrorIs(t, users.Create(ctx, &User{ID: "4", Username: "seif"}), ErrUsernameTaken, name)
+
+		found, err := users.FindByUsername(ctx, "seif")
+		assert.Nil(t, err, name)
+		assert.Equal(t, "2", found.ID, name)
+		assert.Equal(t, []byte("$2a$10$hash"), found.Password, name)
+		assert.Equal(t, created.Add(time.Hour), found.CreatedAt, name)
+		assert.Equal(t, 1, found.Version, name)
+
+		page, total, err := users.List(ctx, ListOptions{Offset: 1, Limit: 1})
+		assert.Nil(t, err, name)
+		assert.Equal(t, 3, total, name)
+		assert.Equal(t, "seif", page[0].Username, name)
+		page, _, _ = users.List(ctx, ListOptions{Offset: 2})
+		assert.Equal(t, "fatimah", page[0].Username, name)
+
+		stale := *found
+		found.Name = "Seif"
+		assert.Nil(t, users.Update(ctx, found), name)
+		assert.Equal(t, 2, found.Version, name)
+		stale.Name = "Seif S."
+		assert.ErrorIs(t, users.Update(ctx, &stale), ErrVersionConflict, name)
+		found.Username = "salman"
+		assert.ErrorIs(t, users.Update(ctx, found), ErrUsernameTaken, name)
+		assert.ErrorIs(t, users.Update(ctx, &User{ID: "9"}), ErrNotFound, name)
+
+		found, _ = users.Get(ctx, "2")
+		assert.Equal(t, "Seif", found.Name, name)
+	}
+}
+
+func TestAccess(t *testing.T) {
+	ctx := context.Background()
+	for name, users := range repositories(t) {
+		service := NewService(users, "ID")
+		created, err := service.Register(ctx, RegisterInput{Username: "salman", Password: "rahasia"})
+		assert.Nil(t, err, name)
+
+		_, err = service.SetRoles(ctx, created.ID, []string{"Admin"})
+		var invalid *ValidationError
+		assert.ErrorAs(t, err, &invalid, name)
+		changed, err := service.SetRoles(ctx, created.ID, []string{"support", "admin", "support"})
+		assert.Nil(t, err, name)
+		assert.Equal(t, []string{"admin", "support"}, changed.Roles, name)
+
+		_, err = service.Lock(ctx, created.ID)
+		assert.Nil(t, err, name)
+		_, err = service.Authenticate(ctx, LoginInput{Username: "salman", Password: "salah!"})
+		assert.ErrorIs(t, err, ErrInvalidCredentials, name, "the wrong password is told first")
+		_, err = service.Authenticate(ctx, LoginInput{Username: "salman", Password: "rahasia"})
+		assert.ErrorIs(t, err, ErrAccountLocked, name)
+		_, err = service.Unlock(ctx, created.ID)
+		assert.Nil(t, err, name)
+
+		_, err = service.RequirePasswordReset(ctx, created.ID)
+		assert.Nil(t, err, name)
+		found, err := service.Authenticate(ctx, LoginInput{Username: "salman", Password: "rahasia"})
+		assert.Nil(t, err, name)
+		assert.True(t, found.PasswordResetRequired, name)
+		assert.Nil(t, found.LockedAt, name)
+		assert.Equal(t, []string{"admin", "support"}, found.Roles, name)
+
+		password := "rahasia2"
+		updated, err := service.Update(ctx, created.ID, UpdateInput{Password: &password})
+		assert.Nil(t, err, name)
+		assert.False(t, updated.PasswordResetRequired, name)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	for name, users := range repositories(t) {
+		users.Create(ctx, &User{ID: "1", Username: "salman"})
+
+		assert.Nil(t, users.Delete(ctx, "1"), name)
+		assert.ErrorIs(t, users.Delete(ctx, "1"), ErrNotFound, name)
+		_, err := users.Get(ctx, "1")
+		assert.ErrorIs(t, err, ErrNotFound, name)
+		_, err = users.FindByUsername(ctx, "salman")
+		assert.ErrorIs(t, err, ErrNotFound, name)
+		assert.ErrorIs(t, users.Create(ctx, &User{ID: "2", Username: "salman"}), ErrUsernameTaken, name)
+
+		list, total, _ := users.List(ctx, ListOptions{})
+		assert.Empty(t, list, name)
+		assert.Equal(t, 0, total, name)
+		list, _, _ = users.List(ctx, ListOptions{IncludeDeleted: true})
+		assert.Len(t, list, 1, name)
+		assert.True(t, list[0].Deleted(), name)
+
+		assert.Nil(t, users.Restore(ctx, "1"), name)
+		assert.ErrorIs(t, users.Restore(ctx, "1"), ErrNotFound, name)
+		found, err := users.Get(ctx, "1")
+		assert.Nil(t, err, name)
+		assert.False(t, found.Deleted(), name)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	ctx := context.Background()
+	for name, users := range repositories(t) {
+		users.Create(ctx, &User{ID: "1", Username: "salman", Name: "Salman"})
+
+		failed := errors.New("failed")
+		err := txn.WithTx(ctx, func(tx *txn.Tx) error {
+			assert.Nil(t, users.Create(tx, &User{ID: "2", Username: "seif"}), name)
+			found, _ := users.Get(tx, "1")
+			found.Name = "Salman Seif"
+			assert.Nil(t, users.Update(tx, found), name)
+			assert.Nil(t, users.Delete(tx, "1"), name)
+			return failed
+		})
+		assert.ErrorIs(t, err, failed, name)
+
+		_, err = users.Get(ctx, "2")
+		assert.ErrorIs(t, err, ErrNotFound, name)
+		found, err := users.Get(ctx, "1")
+		assert.Nil(t, err, name)
+		assert.Equal(t, "Salman", found.Name, name)
+		assert.Equal(t, 1, found.Version, name)
+
+		// A failed savepoint keeps what its transaction did before it.
+		err = txn.WithTx(ctx, func(tx *txn.Tx) error {
+			assert.Nil(t, users.Create(tx, &User{ID: "3", Username: "fatimah"}), name)
+			nested := txn.WithTx(tx, func(tx *txn.Tx) error {
+				assert.Nil(t, users.Delete(tx, "1"), name)
+				return failed
+			})
+			assert.ErrorIs(t, nested, failed, name)
+			return nil
+		})
+		assert.Nil(t, err, name)
+		_, err = users.Get(ctx, "3")
+		assert.Nil(t, err, name)
+		_, err = users.Get(ctx, "1")
+		assert.Nil(t, err, name)
+	}
+}
@@ -0,0 +1,119 @@
+package view
+
+import (
+	"html"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cbroglie/mustache"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Default helper settings.
+const (
+	DefaultAssetPrefix = "/public/"
+	DefaultDateLayout  = "02 Jan 2006"
+	CSRFFieldName      = "_csrf"
+)
+
+// Funcs returns the helpers for engines with a FuncMap:
+//
+//	{{formatDate .CreatedAt}}  {{asset "app.css"}}  {{csrfField .csrf}}
+//	{{if consented .consent "analytics"}}...{{end}}
+//	{{consentEmbed .consent "marketing" "<iframe src=...></iframe>"}}
+//
+// The consent helpers take the categories the consent middleware binds.
+func Funcs(cfg Config) map[string]interface{} {
+	return map[string]interface{}{
+		"formatDate": func(t time.Time) string {
+			return t.Format(cfg.DateLayout)
+		},
+		"asset": func(name string) string {
+			return assetURL(cfg.AssetPrefix, name)
+		},
+		"csrfField": func(token string) template.HTML {
+			return template.HTML(csrfField(token))
+		},
+		"consented": func(granted map[string]bool, category string) bool {
+			return granted[category]
+		},
+		"consentEmbed": func(granted map[string]bool, category, markup string) template.HTML {
+			return template.HTML(consentEmbed(granted[category], category, markup))
+		},
+	}
+}
+
+// Lambdas returns the same helpers as mustache lambdas:
+//
+//	{{#formatDate}}{{CreatedAt}}{{/formatDate}}  {{#asset}}app.css{{/asset}}
+//	{{#csrfField}}{{csrf}}{{/csrfField}}
+func Lambdas(cfg Config) map[string]interface{} {
+	return map[string]interface{}{
+		"formatDate": mustache.LambdaFunc(func(text string, render mustache.RenderFunc) (string, error) {
+			value, err := render(text)
+			if err != nil {
+				return "", err
+			}
+			value = strings.TrimSpace(value)
+			for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999 -0700 MST"} {
+				if t, err := time.Parse(layout, value); err == nil {
+					return t.Format(cfg.DateLayout), nil
+				}
+			}
+			return value, nil
+		}),
+		"asset": mustache.LambdaFunc(func(text string, render mustache.RenderFunc) (string, error) {
+			value, err := render(text)
+			return assetURL(cfg.AssetPrefix, strings.TrimSpace(value)), err
+		}),
+		"csrfField": mustache.LambdaFunc(func(text string, render mustache.RenderFunc) (string, error) {
+			value, err := render(text)
+			return csrfField(html.UnescapeString(strings.TrimSpace(value))), err
+		}),
+	}
+}
+
+// lambdaEngine adds the mustache lambdas to every map binding.
+type lambdaEngine struct {
+	Engine
+	lambdas map[string]interface{}
+}
+
+func (e *lambdaEngine) Render(out io.Writer, name string, binding interface{}, layout ...string) error {
+	var bind map[string]interface{}
+	switch b := binding.(type) {
+	case fiber.Map:
+		bind = b
+	case map[string]interface{}:
+		bind = b
+	}
+	if bind != nil {
+		for key, lambda := range e.lambdas {
+			if _, exists := bind[key]; !exists {
+				bind[key] = lambda
+			}
+		}
+	}
+	return e.Engine.Render(out, name, binding, layout...)
+}
+
+func assetURL(prefix, name string) string {
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(name, "/")
+}
+
+// consentEmbed is markup, a third-party embed written in the template,
+// when category is granted, else a placeholder carrying the markup for
+// scripts to swap in once the visitor consents.
+func consentEmbed(granted bool, category, markup string) string {
+	if granted {
+		return markup
+	}
+	return `<div class="consent-placeholder" data-consent-category="` + html.EscapeString(category) +
+		`" data-consent-embed="` + html.EscapeString(markup) + `"></div>`
+}
+
+func csrfField(token string) string {
+	return `<input type="hidden" name="` + CSRFFieldName + `" value="` + html.EscapeString(token) + `">`
+}
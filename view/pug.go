@@ -0,0 +1,56 @@
+package view
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/Joker/jade"
+	"github.com/gofiber/template/html/v2"
+)
+
+func init() {
+	Register("pug", ".pug", func(cfg Config) (Engine, error) {
+		engine := html.NewFileSystem(pugFileSystem{root: http.Dir(cfg.Directory), extension: cfg.Extension}, cfg.Extension)
+		engine.AddFuncMap(Funcs(cfg))
+		return engine, nil
+	})
+}
+
+// pugFileSystem serves pug templates compiled to html/template source, so
+// the html engine loads, lays out and reloads them like its own files.
+type pugFileSystem struct {
+	root      http.FileSystem
+	extension string
+}
+
+func (fs pugFileSystem) Open(name string) (http.File, error) {
+	file, err := fs.root.Open(name)
+	if err != nil || path.Ext(name) != fs.extension {
+		return file, err
+	}
+	source, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	compiled, err := jade.ParseWithFileSystem(name, source, fs.root)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &pugFile{File: file, Reader: bytes.NewReader([]byte(compiled))}, nil
+}
+
+// pugFile reads the compiled template in place of the pug source.
+type pugFile struct {
+	http.File
+	*bytes.Reader
+}
+
+func (f *pugFile) Read(p []byte) (int, error) { return f.Reader.Read(p) }
+
+func (f *pugFile) Seek(offset int64, whence int) (int64, error) {
+	return f.Reader.Seek(offset, whence)
+}
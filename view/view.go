@@ -0,0 +1,127 @@
+package view
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	core "github.com/gofiber/template"
+	"github.com/gofiber/template/html/v2"
+	"github.com/gofiber/template/mustache/v2"
+)
+
+// Config selects and configures the template engine.
+type Config struct {
+	// Engine is the registered engine name: "mustache", "html" or "pug".
+	Engine string
+
+	// Directory holds the template files.
+	Directory string
+
+	// Extension overrides the engine's default file extension.
+	Extension string
+
+	// Reload re-parses templates on every render so edits show up without
+	// a restart. Meant for development only.
+	Reload bool
+
+	// AssetPrefix is prepended by the asset helper.
+	//
+	// Optional. Default: "/public/"
+	AssetPrefix string
+
+	// DateLayout is used by the formatDate helper.
+	//
+	// Optional. Default: "02 Jan 2006"
+	DateLayout string
+}
+
+// Engine is the shim every template engine satisfies.
+type Engine interface {
+	fiber.Views
+}
+
+type reloader interface {
+	Reload(enabled bool) core.IEngineCore
+}
+
+// Factory builds an engine from a Config with Extension already defaulted.
+type Factory func(cfg Config) (Engine, error)
+
+type registration struct {
+	extension string
+	factory   Factory
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]registration{}
+)
+
+func init() {
+	Register("mustache", ".mustache", func(cfg Config) (Engine, error) {
+		root := http.Dir(cfg.Directory)
+		engine := mustache.NewFileSystemPartials(root, cfg.Extension, root)
+		if cfg.Reload {
+			engine.Reload(true)
+		}
+		return &lambdaEngine{Engine: engine, lambdas: Lambdas(cfg)}, nil
+	})
+	Register("html", ".html", func(cfg Config) (Engine, error) {
+		engine := html.New(cfg.Directory, cfg.Extension)
+		engine.AddFuncMap(Funcs(cfg))
+		return engine, nil
+	})
+}
+
+// Register makes an engine selectable by name, with its default extension.
+func Register(name, extension string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	registry[name] = registration{extension: extension, factory: factory}
+}
+
+// Engines returns the registered engine names.
+func Engines() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New builds the engine named in cfg.Engine.
+func New(cfg Config) (Engine, error) {
+	mu.RLock()
+	reg, ok := registry[cfg.Engine]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("view: unknown engine %q, available: %v", cfg.Engine, Engines())
+	}
+
+	if cfg.Extension == "" {
+		cfg.Extension = reg.extension
+	}
+	if cfg.AssetPrefix == "" {
+		cfg.AssetPrefix = DefaultAssetPrefix
+	}
+	if cfg.DateLayout == "" {
+		cfg.DateLayout = DefaultDateLayout
+	}
+
+	engine, err := reg.factory(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := engine.(reloader); ok && cfg.Reload {
+		r.Reload(true)
+	}
+	return engine, nil
+}
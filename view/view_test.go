@@ -0,0 +1,136 @@
+package view
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineSelection(t *testing.T) {
+	for _, name := range []string{"mustache", "html"} {
+		engine, err := New(Config{Engine: name, Directory: "../template"})
+		assert.Nil(t, err)
+
+		app := fiber.New(fiber.Config{Views: engine})
+		app.Get("/view", func(ctx *fiber.Ctx) error {
+			return ctx.Render("index", fiber.Map{
+				"Title":   "Hello World",
+				"Header":  "Hello, World!",
+				"Content": "This is content",
+			})
+		})
+
+		request := httptest.NewRequest("GET", "/view", nil)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+
+		body, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+		assert.Contains(t, string(body), "<h1>Hello, World!</h1>", name)
+	}
+}
+
+func TestLayoutAndPartials(t *testing.T) {
+	for _, name := range []string{"mustache", "html"} {
+		engine, err := New(Config{Engine: name, Directory: "../template"})
+		assert.Nil(t, err)
+
+		app := fiber.New(fiber.Config{Views: engine, ViewsLayout: "layouts/main"})
+		app.Get("/welcome", func(ctx *fiber.Ctx) error {
+			return ctx.Render("welcome", fiber.Map{
+				"Title":   "Hello World",
+				"Header":  "Hello, World!",
+				"Content": "This is content",
+			})
+		})
+
+		request := httptest.NewRequest("GET", "/welcome", nil)
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, response.StatusCode)
+
+		body, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+		assert.Contains(t, string(body), "<title>Hello World</title>", name)
+		assert.Contains(t, string(body), "<header><h1>Hello, World!</h1></header>", name)
+		assert.Contains(t, string(body), "<p>This is content</p>", name)
+		assert.Contains(t, string(body), "<footer>belajar golang fiber</footer>", name)
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "page.mustache"), []byte("v1"), 0o644))
+
+	engine, err := New(Config{Engine: "mustache", Directory: dir, Reload: true})
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	assert.Nil(t, engine.Render(&out, "page", nil))
+	assert.Equal(t, "v1", out.String())
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "page.mustache"), []byte("v2"), 0o644))
+	out.Reset()
+	assert.Nil(t, engine.Render(&out, "page", nil))
+	assert.Equal(t, "v2", out.String())
+}
+
+func TestUnknownEngine(t *testing.T) {
+	_, err := New(Config{Engine: "jet", Directory: "../template"})
+	assert.NotNil(t, err)
+}
+
+func TestHelpers(t *testing.T) {
+	for _, name := range []string{"mustache", "html"} {
+		engine, err := New(Config{Engine: name, Directory: "./testdata"})
+		assert.Nil(t, err)
+
+		var out bytes.Buffer
+		err = engine.Render(&out, "helpers", fiber.Map{
+			"CreatedAt": time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+			"csrf":      "token&1",
+		})
+		assert.Nil(t, err, name)
+		assert.Equal(t, `<link href="/public/app.css"><span>01 Jun 2025</span><input type="hidden" name="_csrf" value="token&amp;1">`+"\n", out.String(), name)
+	}
+}
+
+func TestPug(t *testing.T) {
+	engine, err := New(Config{Engine: "pug", Directory: "./testdata"})
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	err = engine.Render(&out, "helpers", fiber.Map{
+		"CreatedAt": time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+		"csrf":      "token&1",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `<link href="/public/app.css"/><span>01 Jun 2025</span><input type="hidden" name="_csrf" value="token&amp;1">`, out.String())
+}
+
+func TestConsentHelpers(t *testing.T) {
+	engine, err := New(Config{Engine: "html", Directory: "./testdata"})
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	err = engine.Render(&out, "consent", fiber.Map{
+		"consent": map[string]bool{"analytics": true, "marketing": true},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `<script src="/stats.js"></script><iframe src="https://video.example/1"></iframe>`+"\n", out.String())
+
+	out.Reset()
+	err = engine.Render(&out, "consent", fiber.Map{
+		"consent": map[string]bool{"analytics": false},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `<div class="consent-placeholder" data-consent-category="marketing" data-consent-embed="&lt;iframe src=&#34;https://video.example/1&#34;&gt;&lt;/iframe&gt;"></div>`+"\n", out.String())
+}
@@ -0,0 +1,189 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"belajar-golang-fiber/httpclient"
+	"belajar-golang-fiber/jobs"
+	"belajar-golang-fiber/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// JobKind is the kind of the jobs delivering events.
+const JobKind = "webhooks.deliver"
+
+// Headers sent with every delivery. The signature is "sha256=" and the
+// hex HMAC-SHA256, keyed with the subscription secret, of the timestamp,
+// a dot and the body.
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// maxResponse caps the subscriber response kept in the delivery log.
+const maxResponse = 512
+
+// Dispatcher publishes events to the matching subscriptions.
+type Dispatcher struct {
+	Subscriptions Repository
+	Deliveries    DeliveryLog
+	Client        *httpclient.Client
+
+	queue *jobs.Queue
+}
+
+func NewDispatcher(subscriptions Repository, deliveries DeliveryLog) *Dispatcher {
+	return &Dispatcher{
+		Subscriptions: subscriptions,
+		Deliveries:    deliveries,
+		// The job queue retries; one failing subscriber must not open a
+		// circuit for all of them.
+		Client: httpclient.New(httpclient.Config{
+			Name:             "webhooks",
+			Timeout:          10 * time.Second,
+			Retries:          -1,
+			FailureThreshold: -1,
+		}),
+	}
+}
+
+// Attach runs deliveries as jobs on queue, whose MaxAttempts and Backoff
+// decide how failed deliveries are retried.
+func (d *Dispatcher) Attach(queue *jobs.Queue) {
+	d.queue = queue
+	queue.Handle(JobKind, func(ctx context.Context, job *jobs.Job) error {
+		var payload deliveryJob
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		return d.deliver(ctx, payload, job.Attempts)
+	})
+}
+
+type deliveryJob struct {
+	SubscriptionID string `json:"subscription_id"`
+	Event          Event  `json:"event"`
+}
+
+// Publish enqueues a delivery of data as an event of eventType to every
+// active subscription asking for it.
+func (d *Dispatcher) Publish(ctx context.Context, eventType string, data any) error {
+	if d.queue == nil {
+		return errors.New("webhooks: dispatcher is not attached to a queue")
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	event := Event{ID: utils.UUIDv4(), Type: eventType, CreatedAt: time.Now().UTC(), Data: raw}
+
+	subscriptions, err := d.Subscriptions.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		if !subscription.Wants(eventType) {
+			continue
+		}
+		if err := d.queue.Enqueue(ctx, JobKind, deliveryJob{SubscriptionID: subscription.ID, Event: event}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishLogged is Publish logging errors instead of returning them, for
+// use in hooks that cannot fail.
+func (d *Dispatcher) PublishLogged(ctx context.Context, eventType string, data any) {
+	if err := d.Publish(ctx, eventType, data); err != nil {
+		logger.FromContext(ctx).Error("webhooks: publish failed", "event", eventType, "error", err)
+	}
+}
+
+// deliver sends the event once. Subscriptions deleted or deactivated in
+// the meantime are skipped.
+func (d *Dispatcher) deliver(ctx context.Context, job deliveryJob, attempt int) error {
+	subscription, err := d.Subscriptions.Get(ctx, job.SubscriptionID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !subscription.Wants(job.Event.Type) {
+		return nil
+	}
+
+	body, err := json.Marshal(job.Event)
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	delivery := &Delivery{
+		ID:             utils.UUIDv4(),
+		SubscriptionID: subscription.ID,
+		EventID:        job.Event.ID,
+		EventType:      job.Event.Type,
+		Attempt:        attempt,
+		CreatedAt:      time.Now(),
+	}
+
+	start := time.Now()
+	response, err := d.Client.Do(ctx, httpclient.Request{
+		Method: fiber.MethodPost,
+		URL:    subscription.URL,
+		Header: map[string]string{
+			fiber.HeaderContentType: fiber.MIMEApplicationJSON,
+			HeaderID:                job.Event.ID,
+			HeaderEvent:             job.Event.Type,
+			HeaderTimestamp:         strconv.FormatInt(timestamp, 10),
+			HeaderSignature:         Sign(subscription.Secret, timestamp, body),
+		},
+		Body: body,
+	})
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+	} else {
+		delivery.Status = response.Status
+		delivery.Response = string(response.Body[:min(len(response.Body), maxResponse)])
+	}
+	if recordErr := d.Deliveries.Record(ctx, delivery); recordErr != nil {
+		logger.FromContext(ctx).Error("webhooks: recording delivery failed", "delivery_id", delivery.ID, "error", recordErr)
+	}
+
+	if err != nil {
+		return err
+	}
+	if !delivery.Succeeded() {
+		return fmt.Errorf("webhooks: subscriber answered %d", response.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature header value for body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the one of body sent at timestamp,
+// comparing in constant time.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,176 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"slices"
+	"time"
+
+	"belajar-golang-fiber/binding"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// SubscriptionRequest is the body of POST / and PUT /:id. A missing
+// Secret is generated on create and kept on update; Active defaults to
+// true on create.
+type SubscriptionRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+	Secret      string   `json:"secret"`
+}
+
+// Handler is the admin API for subscriptions. Secrets are only answered
+// when they are set, by create or update.
+type Handler struct {
+	Dispatcher *Dispatcher
+}
+
+// Register mounts the routes on router, e.g. adminApp.Group("/webhooks").
+func (h *Handler) Register(router fiber.Router) {
+	router.Get("/", h.list)
+	router.Post("/", h.create)
+	router.Get("/:id", h.get)
+	router.Put("/:id", h.update)
+	router.Delete("/:id", h.delete)
+	router.Get("/:id/deliveries", h.deliveries)
+}
+
+func (h *Handler) list(ctx *fiber.Ctx) error {
+	subscriptions, err := h.Dispatcher.Subscriptions.List(ctx.UserContext())
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+	return ctx.JSON(subscriptions)
+}
+
+func (h *Handler) create(ctx *fiber.Ctx) error {
+	request, err := binding.Bind[SubscriptionRequest](ctx)
+	if err != nil {
+		return err
+	}
+	if err := request.validate(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	subscription := &Subscription{
+		ID:          utils.UUIDv4(),
+		URL:         request.URL,
+		Events:      request.Events,
+		Description: request.Description,
+		Active:      request.Active == nil || *request.Active,
+		Secret:      request.Secret,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if subscription.Secret == "" {
+		if subscription.Secret, err = newSecret(); err != nil {
+			return err
+		}
+	}
+	if err := h.Dispatcher.Subscriptions.Create(ctx.UserContext(), subscription); err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(subscription)
+}
+
+func (h *Handler) get(ctx *fiber.Ctx) error {
+	subscription, err := h.Dispatcher.Subscriptions.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return notFound(err)
+	}
+	subscription.Secret = ""
+	return ctx.JSON(subscription)
+}
+
+func (h *Handler) update(ctx *fiber.Ctx) error {
+	subscription, err := h.Dispatcher.Subscriptions.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		return notFound(err)
+	}
+	request, err := binding.Bind[SubscriptionRequest](ctx)
+	if err != nil {
+		return err
+	}
+	if err := request.validate(); err != nil {
+		return err
+	}
+
+	subscription.URL = request.URL
+	subscription.Events = request.Events
+	subscription.Description = request.Description
+	if request.Active != nil {
+		subscription.Active = *request.Active
+	}
+	subscription.UpdatedAt = time.Now()
+	rotated := request.Secret != ""
+	if rotated {
+		subscription.Secret = request.Secret
+	}
+	if err := h.Dispatcher.Subscriptions.Update(ctx.UserContext(), subscription); err != nil {
+		return notFound(err)
+	}
+	if !rotated {
+		subscription.Secret = ""
+	}
+	return ctx.JSON(subscription)
+}
+
+func (h *Handler) delete(ctx *fiber.Ctx) error {
+	if err := h.Dispatcher.Subscriptions.Delete(ctx.UserContext(), ctx.Params("id")); err != nil {
+		return notFound(err)
+	}
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) deliveries(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	if _, err := h.Dispatcher.Subscriptions.Get(ctx.UserContext(), id); err != nil {
+		return notFound(err)
+	}
+	deliveries, err := h.Dispatcher.Deliveries.List(ctx.UserContext(), id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(deliveries)
+}
+
+func (r *SubscriptionRequest) validate() error {
+	target, err := url.Parse(r.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "url must be an absolute http or https URL")
+	}
+	if len(r.Events) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "events is required")
+	}
+	for _, event := range r.Events {
+		if event != EventAll && !slices.Contains(Events, event) {
+			return fiber.NewError(fiber.StatusBadRequest, "unknown event "+event)
+		}
+	}
+	return nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return fiber.ErrNotFound
+	}
+	return err
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
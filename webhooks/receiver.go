@@ -0,0 +1,288 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"belajar-golang-fiber/jobs"
+	"belajar-golang-fiber/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReceiveJobKind is the kind of the jobs handling received webhooks.
+const ReceiveJobKind = "webhooks.receive"
+
+// Errors returned by providers.
+var (
+	ErrSignature = errors.New("webhooks: invalid signature")
+	ErrPayload   = errors.New("webhooks: malformed payload")
+)
+
+// Incoming is a verified webhook received from a provider.
+type Incoming struct {
+	Provider   string          `json:"provider"`
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	ReceivedAt time.Time       `json:"received_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Decode unmarshals the event data into v, typically a struct describing
+// the event type.
+func (i *Incoming) Decode(v any) error {
+	return json.Unmarshal(i.Data, v)
+}
+
+// Provider verifies the signature of a webhook sent by one provider and
+// parses it.
+type Provider interface {
+	Parse(header http.Header, body []byte) (*Incoming, error)
+}
+
+// GitHub verifies the X-Hub-Signature-256 header GitHub signs deliveries
+// with. The event type is the X-GitHub-Event header, followed by the
+// action of the payload when it has one, e.g. "pull_request.opened".
+type GitHub struct {
+	Secret string
+}
+
+func (g GitHub) Parse(header http.Header, body []byte) (*Incoming, error) {
+	signature, ok := strings.CutPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+	if !ok || !validMAC(g.Secret, body, signature) {
+		return nil, ErrSignature
+	}
+	var payload struct {
+		Action string `json:"action"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, ErrPayload
+	}
+	eventType := header.Get("X-GitHub-Event")
+	if payload.Action != "" {
+		eventType += "." + payload.Action
+	}
+	return &Incoming{ID: header.Get("X-GitHub-Delivery"), Type: eventType, Data: body}, nil
+}
+
+// Stripe verifies the Stripe-Signature header, "t=<unix>,v1=<hex>", and
+// refuses signatures older than Tolerance, 5 minutes when zero. Data is
+// the object of the event.
+type Stripe struct {
+	Secret    string
+	Tolerance time.Duration
+}
+
+func (s Stripe) Parse(header http.Header, body []byte) (*Incoming, error) {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header.Get("Stripe-Signature"), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if err := checkTimestamp(timestamp, s.Tolerance); err != nil {
+		return nil, err
+	}
+	signed := append([]byte(timestamp+"."), body...)
+	if !anyValidMAC(s.Secret, signed, signatures) {
+		return nil, ErrSignature
+	}
+
+	var event struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object json.RawMessage `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil || event.Type == "" {
+		return nil, ErrPayload
+	}
+	return &Incoming{ID: event.ID, Type: event.Type, Data: event.Data.Object}, nil
+}
+
+// Signed verifies webhooks signed like the Dispatcher signs its own, so
+// instances of this application can subscribe to each other.
+type Signed struct {
+	Secret    string
+	Tolerance time.Duration
+}
+
+func (s Signed) Parse(header http.Header, body []byte) (*Incoming, error) {
+	timestamp := header.Get(HeaderTimestamp)
+	if err := checkTimestamp(timestamp, s.Tolerance); err != nil {
+		return nil, err
+	}
+	unix, _ := strconv.ParseInt(timestamp, 10, 64)
+	if !Verify(s.Secret, unix, body, header.Get(HeaderSignature)) {
+		return nil, ErrSignature
+	}
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil || event.Type == "" {
+		return nil, ErrPayload
+	}
+	return &Incoming{ID: event.ID, Type: event.Type, Data: event.Data}, nil
+}
+
+// checkTimestamp refuses replays of signatures older than tolerance.
+func checkTimestamp(timestamp string, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		tolerance = 5 * time.Minute
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignature
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrSignature
+	}
+	return nil
+}
+
+func validMAC(secret string, body []byte, signature string) bool {
+	return anyValidMAC(secret, body, []string{signature})
+}
+
+// anyValidMAC reports whether one of signatures is the hex HMAC-SHA256 of
+// body, comparing in constant time.
+func anyValidMAC(secret string, body []byte, signatures []string) bool {
+	if secret == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(expected, decoded) {
+			return true
+		}
+	}
+	return false
+}
+
+// IncomingHandler handles a received webhook. Returning an error retries
+// it as a job.
+type IncomingHandler func(ctx context.Context, incoming *Incoming) error
+
+// Receiver accepts webhooks at /:provider, verifies and parses them with
+// the registered Provider and hands them to the handlers of their type in
+// the background, answering the provider right away.
+type Receiver struct {
+	// MaxBody caps the accepted payload. Zero means 1 MiB.
+	MaxBody int
+
+	mu        sync.RWMutex
+	providers map[string]Provider
+	handlers  map[string][]IncomingHandler
+	queue     *jobs.Queue
+}
+
+func NewReceiver() *Receiver {
+	return &Receiver{providers: map[string]Provider{}, handlers: map[string][]IncomingHandler{}}
+}
+
+// Provider accepts webhooks from provider, e.g. "github", verified by p.
+func (r *Receiver) Provider(name string, p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[name] = p
+}
+
+// On calls handler for the webhooks of eventType from provider. EventAll
+// matches every type.
+func (r *Receiver) On(provider, eventType string, handler IncomingHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := provider + " " + eventType
+	r.handlers[key] = append(r.handlers[key], handler)
+}
+
+// Attach runs the handlers as jobs on queue, which retries them when they
+// fail.
+func (r *Receiver) Attach(queue *jobs.Queue) {
+	r.queue = queue
+	queue.Handle(ReceiveJobKind, func(ctx context.Context, job *jobs.Job) error {
+		var incoming Incoming
+		if err := job.Decode(&incoming); err != nil {
+			return err
+		}
+		return r.dispatch(ctx, &incoming)
+	})
+}
+
+// Register mounts the endpoint on router, e.g. app.Group("/webhooks").
+func (r *Receiver) Register(router fiber.Router) {
+	router.Post("/:provider", r.receive)
+}
+
+func (r *Receiver) receive(ctx *fiber.Ctx) error {
+	r.mu.RLock()
+	provider, ok := r.providers[ctx.Params("provider")]
+	r.mu.RUnlock()
+	if !ok {
+		return fiber.ErrNotFound
+	}
+	maxBody := r.MaxBody
+	if maxBody <= 0 {
+		maxBody = 1 << 20
+	}
+	body := ctx.Body()
+	if len(body) > maxBody {
+		return fiber.ErrRequestEntityTooLarge
+	}
+
+	header := http.Header{}
+	ctx.Request().Header.VisitAll(func(key, value []byte) {
+		header.Add(string(key), string(value))
+	})
+	incoming, err := provider.Parse(header, body)
+	if errors.Is(err, ErrSignature) {
+		return fiber.ErrUnauthorized
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	incoming.Provider = ctx.Params("provider")
+	incoming.ReceivedAt = time.Now().UTC()
+
+	if r.queue == nil {
+		return errors.New("webhooks: receiver is not attached to a queue")
+	}
+	if err := r.queue.Enqueue(ctx.UserContext(), ReceiveJobKind, incoming); err != nil {
+		return err
+	}
+	logger.From(ctx).Info("webhooks: received", "provider", incoming.Provider, "type", incoming.Type, "id", incoming.ID)
+	return ctx.SendStatus(fiber.StatusAccepted)
+}
+
+// dispatch runs the handlers of the webhook, returning their errors.
+func (r *Receiver) dispatch(ctx context.Context, incoming *Incoming) error {
+	r.mu.RLock()
+	handlers := append(append([]IncomingHandler(nil),
+		r.handlers[incoming.Provider+" "+incoming.Type]...),
+		r.handlers[incoming.Provider+" "+EventAll]...)
+	r.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, incoming); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
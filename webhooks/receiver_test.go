@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/jobs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func hexMAC(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestReceiver(t *testing.T) {
+	queue := jobs.NewQueue(10)
+	queue.MaxAttempts = 2
+	queue.Backoff = time.Millisecond
+	receiver := NewReceiver()
+	receiver.Provider("github", GitHub{Secret: "rahasia"})
+	receiver.Provider("stripe", Stripe{Secret: "whsec_test"})
+	receiver.Attach(queue)
+
+	type pullRequest struct {
+		Number int `json:"number"`
+	}
+	received := make(chan any, 4)
+	receiver.On("github", "pull_request.opened", func(ctx context.Context, incoming *Incoming) error {
+		var payload pullRequest
+		if err := incoming.Decode(&payload); err != nil {
+			return err
+		}
+		received <- payload
+		return nil
+	})
+	failed := false
+	receiver.On("stripe", EventAll, func(ctx context.Context, incoming *Incoming) error {
+		if !failed {
+			failed = true
+			return errors.New("not yet")
+		}
+		received <- incoming.Type + " " + string(incoming.Data)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go queue.Run(ctx, 1)
+
+	app := fiber.New()
+	receiver.Register(app.Group("/webhooks"))
+
+	body := `{"action":"opened","number":7}`
+	request := httptest.NewRequest("POST", "/webhooks/github", strings.NewReader(body))
+	request.Header.Set("X-GitHub-Event", "pull_request")
+	request.Header.Set("X-Hub-Signature-256", "sha256="+hexMAC("rahasia", body))
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 202, response.StatusCode)
+	assert.Equal(t, pullRequest{Number: 7}, <-received)
+
+	request = httptest.NewRequest("POST", "/webhooks/github", strings.NewReader(body))
+	request.Header.Set("X-GitHub-Event", "pull_request")
+	request.Header.Set("X-Hub-Signature-256", "sha256="+hexMAC("salah", body))
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+
+	body = `{"id":"evt_1","type":"invoice.paid","data":{"object":{"id":"in_1"}}}`
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request = httptest.NewRequest("POST", "/webhooks/stripe", strings.NewReader(body))
+	request.Header.Set("Stripe-Signature", "t="+timestamp+",v1=deadbeef,v1="+hexMAC("whsec_test", timestamp+"."+body))
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 202, response.StatusCode)
+	assert.Equal(t, `invoice.paid {"id":"in_1"}`, <-received, "a failed handler is retried")
+
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	request = httptest.NewRequest("POST", "/webhooks/stripe", strings.NewReader(body))
+	request.Header.Set("Stripe-Signature", "t="+stale+",v1="+hexMAC("whsec_test", stale+"."+body))
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, 401, response.StatusCode, "old signatures are replays")
+
+	response, err = app.Test(httptest.NewRequest("POST", "/webhooks/unknown", strings.NewReader("{}")))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestSignedProvider(t *testing.T) {
+	body := []byte(`{"id":"1","type":"file.uploaded","data":{"name":"a.txt"}}`)
+	timestamp := time.Now().Unix()
+	header := map[string][]string{
+		HeaderTimestamp: {strconv.FormatInt(timestamp, 10)},
+		HeaderSignature: {Sign("rahasia", timestamp, body)},
+	}
+
+	incoming, err := Signed{Secret: "rahasia"}.Parse(header, body)
+	assert.Nil(t, err)
+	assert.Equal(t, "file.uploaded", incoming.Type)
+	assert.JSONEq(t, `{"name":"a.txt"}`, string(incoming.Data))
+
+	_, err = Signed{Secret: "lain"}.Parse(header, body)
+	assert.True(t, errors.Is(err, ErrSignature))
+}
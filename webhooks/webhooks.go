@@ -0,0 +1,196 @@
+// Package webhooks delivers application events to subscriber URLs. Each
+// delivery is a signed JSON POST run as a background job, retried with
+// backoff by the job queue and recorded in a delivery log. A Receiver
+// accepts webhooks from providers such as GitHub and Stripe the same way
+// in the other direction.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Event types subscribers can ask for. "*" subscribes to all of them.
+const (
+	EventUserRegistered = "user.registered"
+	EventFileUploaded   = "file.uploaded"
+	EventAll            = "*"
+)
+
+// Events lists the event types that are published.
+var Events = []string{EventUserRegistered, EventFileUploaded}
+
+// ErrNotFound is returned when no subscription matches the given id.
+var ErrNotFound = errors.New("webhooks: not found")
+
+// Event is the JSON body POSTed to subscribers.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Subscription asks for events of the listed types to be sent to URL,
+// signed with Secret.
+type Subscription struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Wants reports whether events of type eventType go to the subscription.
+func (s *Subscription) Wants(eventType string) bool {
+	return s.Active && (slices.Contains(s.Events, eventType) || slices.Contains(s.Events, EventAll))
+}
+
+// Repository persists subscriptions.
+type Repository interface {
+	Create(ctx context.Context, subscription *Subscription) error
+	Get(ctx context.Context, id string) (*Subscription, error)
+	List(ctx context.Context) ([]*Subscription, error)
+	Update(ctx context.Context, subscription *Subscription) error
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryRepository keeps subscriptions in process memory.
+type MemoryRepository struct {
+	mu            sync.RWMutex
+	subscriptions map[string]*Subscription
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{subscriptions: map[string]*Subscription{}}
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, subscription *Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *subscription
+	r.subscriptions[subscription.ID] = &copied
+	return nil
+}
+
+func (r *MemoryRepository) Get(ctx context.Context, id string) (*Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *subscription
+	return &copied, nil
+}
+
+func (r *MemoryRepository) List(ctx context.Context) ([]*Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*Subscription, 0, len(r.subscriptions))
+	for _, subscription := range r.subscriptions {
+		copied := *subscription
+		list = append(list, &copied)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *MemoryRepository) Update(ctx context.Context, subscription *Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subscriptions[subscription.ID]; !ok {
+		return ErrNotFound
+	}
+	copied := *subscription
+	r.subscriptions[subscription.ID] = &copied
+	return nil
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subscriptions[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.subscriptions, id)
+	return nil
+}
+
+// Delivery is one attempt to send an event to a subscription.
+type Delivery struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Attempt        int       `json:"attempt"`
+	Status         int       `json:"status,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Response       string    `json:"response,omitempty"`
+	DurationMS     int64     `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Succeeded reports whether the subscriber accepted the event.
+func (d *Delivery) Succeeded() bool {
+	return d.Error == "" && d.Status >= 200 && d.Status < 300
+}
+
+// DeliveryLog records delivery attempts.
+type DeliveryLog interface {
+	Record(ctx context.Context, delivery *Delivery) error
+	// List returns the attempts for a subscription, newest first.
+	List(ctx context.Context, subscriptionID string) ([]*Delivery, error)
+}
+
+// MemoryDeliveryLog keeps the latest attempts of every subscription in
+// process memory.
+type MemoryDeliveryLog struct {
+	// Keep is how many attempts are kept per subscription. Zero means 100.
+	Keep int
+
+	mu         sync.RWMutex
+	deliveries map[string][]*Delivery
+}
+
+func NewMemoryDeliveryLog() *MemoryDeliveryLog {
+	return &MemoryDeliveryLog{deliveries: map[string][]*Delivery{}}
+}
+
+func (l *MemoryDeliveryLog) Record(ctx context.Context, delivery *Delivery) error {
+	keep := l.Keep
+	if keep <= 0 {
+		keep = 100
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	copied := *delivery
+	list := append(l.deliveries[delivery.SubscriptionID], &copied)
+	if len(list) > keep {
+		list = slices.Delete(list, 0, len(list)-keep)
+	}
+	l.deliveries[delivery.SubscriptionID] = list
+	return nil
+}
+
+func (l *MemoryDeliveryLog) List(ctx context.Context, subscriptionID string) ([]*Delivery, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	stored := l.deliveries[subscriptionID]
+	list := make([]*Delivery, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		copied := *stored[i]
+		list = append(list, &copied)
+	}
+	return list, nil
+}
@@ -0,0 +1,133 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"belajar-golang-fiber/jobs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionCRUD(t *testing.T) {
+	dispatcher := NewDispatcher(NewMemoryRepository(), NewMemoryDeliveryLog())
+	app := fiber.New()
+	handler := &Handler{Dispatcher: dispatcher}
+	handler.Register(app.Group("/webhooks"))
+
+	send := func(method, target, body string) (*http.Response, map[string]any) {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		var decoded map[string]any
+		json.NewDecoder(response.Body).Decode(&decoded)
+		return response, decoded
+	}
+
+	response, _ := send("POST", "/webhooks", `{"url":"ftp://example.com","events":["file.uploaded"]}`)
+	assert.Equal(t, 400, response.StatusCode)
+	response, _ = send("POST", "/webhooks", `{"url":"https://example.com/hook","events":["order.shipped"]}`)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response, created := send("POST", "/webhooks", `{"url":"https://example.com/hook","events":["file.uploaded"]}`)
+	assert.Equal(t, 201, response.StatusCode)
+	assert.Equal(t, true, created["active"])
+	assert.True(t, strings.HasPrefix(created["secret"].(string), "whsec_"))
+	id := created["id"].(string)
+
+	response, found := send("GET", "/webhooks/"+id, "")
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Nil(t, found["secret"])
+
+	response, updated := send("PUT", "/webhooks/"+id, `{"url":"https://example.com/v2","events":["*"],"active":false}`)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "https://example.com/v2", updated["url"])
+	assert.Equal(t, false, updated["active"])
+	assert.Nil(t, updated["secret"])
+
+	request := httptest.NewRequest("GET", "/webhooks", nil)
+	response, _ = app.Test(request)
+	var list []Subscription
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&list))
+	assert.Len(t, list, 1)
+	assert.Empty(t, list[0].Secret)
+
+	response, _ = send("GET", "/webhooks/"+id+"/deliveries", "")
+	assert.Equal(t, 200, response.StatusCode)
+	response, _ = send("DELETE", "/webhooks/"+id, "")
+	assert.Equal(t, 204, response.StatusCode)
+	response, _ = send("GET", "/webhooks/"+id, "")
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestDeliverySignedAndRetried(t *testing.T) {
+	var mu sync.Mutex
+	var received []Event
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		assert.True(t, Verify("rahasia", timestamp, body, r.Header.Get(HeaderSignature)))
+		assert.Equal(t, EventFileUploaded, r.Header.Get(HeaderEvent))
+
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var event Event
+		json.Unmarshal(body, &event)
+		received = append(received, event)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue := jobs.NewQueue(10)
+	queue.Backoff = 10 * time.Millisecond
+	dispatcher := NewDispatcher(NewMemoryRepository(), NewMemoryDeliveryLog())
+	dispatcher.Attach(queue)
+	go queue.Run(ctx, 1)
+
+	now := time.Now()
+	for _, subscription := range []*Subscription{
+		{ID: "files", URL: server.URL, Events: []string{EventFileUploaded}, Active: true, Secret: "rahasia", CreatedAt: now},
+		{ID: "users", URL: server.URL, Events: []string{EventUserRegistered}, Active: true, Secret: "rahasia", CreatedAt: now},
+		{ID: "paused", URL: server.URL, Events: []string{EventAll}, Active: false, Secret: "rahasia", CreatedAt: now},
+	} {
+		dispatcher.Subscriptions.Create(ctx, subscription)
+	}
+
+	assert.Nil(t, dispatcher.Publish(ctx, EventFileUploaded, map[string]string{"id": "f1"}))
+	var deliveries []*Delivery
+	assert.Eventually(t, func() bool {
+		deliveries, _ = dispatcher.Deliveries.List(ctx, "files")
+		return len(deliveries) == 2
+	}, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	assert.Len(t, received, 1)
+	assert.JSONEq(t, `{"id":"f1"}`, string(received[0].Data))
+	mu.Unlock()
+
+	assert.Equal(t, 2, deliveries[0].Attempt)
+	assert.True(t, deliveries[0].Succeeded())
+	assert.Equal(t, 500, deliveries[1].Status)
+	assert.False(t, deliveries[1].Succeeded())
+
+	for _, id := range []string{"users", "paused"} {
+		deliveries, _ = dispatcher.Deliveries.List(ctx, id)
+		assert.Empty(t, deliveries, id)
+	}
+}